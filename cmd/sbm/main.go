@@ -4,7 +4,9 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"syscall"
 
 	"github.com/xiaobei/singbox-manager/internal/api"
 	"github.com/xiaobei/singbox-manager/internal/daemon"
@@ -92,6 +94,17 @@ func main() {
 	// Start task scheduler
 	server.StartScheduler()
 
+	// On SIGINT/SIGTERM stop sing-box unless it is set to stay detached, then close the store
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		sig := <-signals
+		logger.Printf("Received %s, shutting down", sig)
+		server.Shutdown()
+		store.Close()
+		os.Exit(0)
+	}()
+
 	// Start service
 	addr := fmt.Sprintf(":%d", port)
 	logger.Printf("Starting Web service: http://0.0.0.0%s", addr)
@@ -0,0 +1,76 @@
+package api
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// autoApplyDebounceWindow is how long autoApplyConfig waits for further changes
+	// before rebuilding and reloading sing-box.
+	autoApplyDebounceWindow = 2 * time.Second
+	// autoApplyMaxDelay caps how long a steady stream of changes can hold back the apply.
+	autoApplyMaxDelay = 10 * time.Second
+)
+
+// applyDebouncer coalesces bursts of apply requests into a single run.
+// Trigger returns at once; the result of each run is handed to report.
+type applyDebouncer struct {
+	window  time.Duration
+	maxWait time.Duration
+	run     func() error
+	report  func(error)
+
+	mu      sync.Mutex
+	pending *applyBatch
+	runMu   sync.Mutex // serializes runs so batches never overlap
+}
+
+type applyBatch struct {
+	timer    *time.Timer
+	deadline time.Time // the run starts no later than this, however many triggers follow
+}
+
+func newApplyDebouncer(window, maxWait time.Duration, run func() error, report func(error)) *applyDebouncer {
+	return &applyDebouncer{window: window, maxWait: maxWait, run: run, report: report}
+}
+
+// Trigger schedules a run after the window. A pending run is pushed back by each
+// trigger, but never past maxWait from the first trigger of its batch.
+func (d *applyDebouncer) Trigger() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := time.Now()
+	batch := d.pending
+	if batch == nil {
+		batch = &applyBatch{deadline: now.Add(d.maxWait)}
+		d.pending = batch
+		batch.timer = time.AfterFunc(d.window, func() { d.flush(batch) })
+		return
+	}
+
+	delay := d.window
+	if remaining := batch.deadline.Sub(now); remaining < delay {
+		delay = remaining
+	}
+	batch.timer.Reset(delay)
+}
+
+func (d *applyDebouncer) flush(batch *applyBatch) {
+	d.mu.Lock()
+	if d.pending != batch {
+		// Already flushed by an earlier timer fire
+		d.mu.Unlock()
+		return
+	}
+	d.pending = nil
+	d.mu.Unlock()
+
+	d.runMu.Lock()
+	err := d.run()
+	d.runMu.Unlock()
+	if d.report != nil {
+		d.report(err)
+	}
+}
@@ -0,0 +1,112 @@
+package api
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestApplyDebouncer_CoalescesRapidCalls(t *testing.T) {
+	var runs atomic.Int32
+	applyErr := errors.New("apply failed")
+	results := make(chan error, 4)
+	d := newApplyDebouncer(50*time.Millisecond, time.Second, func() error {
+		runs.Add(1)
+		return applyErr
+	}, func(err error) { results <- err })
+
+	const calls = 10
+	var wg sync.WaitGroup
+	for i := 0; i < calls; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			d.Trigger()
+		}()
+		time.Sleep(5 * time.Millisecond)
+	}
+	wg.Wait()
+
+	if err := waitApplyResult(t, results); !errors.Is(err, applyErr) {
+		t.Fatalf("reported error mismatch: got %v, want %v", err, applyErr)
+	}
+	if got := runs.Load(); got != 1 {
+		t.Fatalf("apply runs mismatch: got %d, want 1", got)
+	}
+
+	// A later call starts a new batch
+	d.Trigger()
+	if err := waitApplyResult(t, results); !errors.Is(err, applyErr) {
+		t.Fatalf("follow-up error mismatch: got %v", err)
+	}
+	if got := runs.Load(); got != 2 {
+		t.Fatalf("apply runs mismatch after follow-up: got %d, want 2", got)
+	}
+}
+
+func TestApplyDebouncer_BatchesSequentialCallers(t *testing.T) {
+	var runs atomic.Int32
+	results := make(chan error, 4)
+	window := 100 * time.Millisecond
+	d := newApplyDebouncer(window, time.Second, func() error {
+		runs.Add(1)
+		return nil
+	}, func(err error) { results <- err })
+
+	// Each caller returns before the next one starts, as handlers saving one after another do
+	for i := 0; i < 5; i++ {
+		start := time.Now()
+		d.Trigger()
+		if elapsed := time.Since(start); elapsed >= window {
+			t.Fatalf("call %d blocked for %v, want it to return before the %v window", i, elapsed, window)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if err := waitApplyResult(t, results); err != nil {
+		t.Fatalf("reported error mismatch: got %v, want nil", err)
+	}
+	if got := runs.Load(); got != 1 {
+		t.Fatalf("apply runs mismatch: got %d, want 1", got)
+	}
+}
+
+func TestApplyDebouncer_SteadyStreamRunsByMaxWait(t *testing.T) {
+	var runs atomic.Int32
+	results := make(chan error, 16)
+	d := newApplyDebouncer(50*time.Millisecond, 150*time.Millisecond, func() error {
+		runs.Add(1)
+		return nil
+	}, func(err error) { results <- err })
+
+	// Triggers keep arriving faster than the window, so only maxWait lets a run through
+	stop := time.After(400 * time.Millisecond)
+	ticker := time.NewTicker(20 * time.Millisecond)
+	defer ticker.Stop()
+loop:
+	for {
+		select {
+		case <-stop:
+			break loop
+		case <-ticker.C:
+			d.Trigger()
+		}
+	}
+
+	if got := runs.Load(); got < 2 {
+		t.Fatalf("apply runs mismatch during steady stream: got %d, want at least 2", got)
+	}
+}
+
+func waitApplyResult(t *testing.T, results <-chan error) error {
+	t.Helper()
+	select {
+	case err := <-results:
+		return err
+	case <-time.After(2 * time.Second):
+		t.Fatalf("timed out waiting for the apply run")
+		return nil
+	}
+}
@@ -0,0 +1,51 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xiaobei/singbox-manager/internal/logger"
+)
+
+// clashSecretLength is the length of generated Clash API secrets
+const clashSecretLength = 32
+
+// rotateClashSecret stores a freshly generated Clash API secret and returns it
+func (s *Server) rotateClashSecret() (string, error) {
+	settings := s.store.GetSettings()
+	if !settings.AllowLAN {
+		return "", fmt.Errorf("Clash API secret is only used when LAN access is enabled")
+	}
+
+	secret := generateRandomSecret(clashSecretLength)
+	if secret == "" {
+		return "", fmt.Errorf("failed to generate secret")
+	}
+	settings.ClashAPISecret = secret
+	if err := s.store.UpdateSettings(settings); err != nil {
+		return "", err
+	}
+	return secret, nil
+}
+
+// rotateClashAPISecret replaces the Clash API secret and reloads sing-box so it takes effect.
+// The new secret is returned once in the response.
+func (s *Server) rotateClashAPISecret(c *gin.Context) {
+	secret, err := s.rotateClashSecret()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	logger.Printf("[settings] Clash API secret rotated")
+
+	if err := s.rebuildAndReload(); err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"data":    gin.H{"secret": secret},
+			"warning": "Secret rotated, but applying config failed: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": gin.H{"secret": secret}, "message": "Secret rotated"})
+}
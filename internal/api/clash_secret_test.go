@@ -0,0 +1,81 @@
+package api
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/xiaobei/singbox-manager/internal/storage"
+)
+
+func TestRotateClashSecret(t *testing.T) {
+	store, err := storage.NewSQLiteStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("create sqlite store: %v", err)
+	}
+	t.Cleanup(func() { _ = store.Close() })
+
+	// Fake Clash API that only accepts the currently stored secret
+	clash := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer "+store.GetSettings().ClashAPISecret {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer clash.Close()
+
+	settings := store.GetSettings()
+	settings.AllowLAN = true
+	settings.ClashAPISecret = "old-secret"
+	settings.ClashAPIPort = clash.Listener.Addr().(*net.TCPAddr).Port
+	if err := store.UpdateSettings(settings); err != nil {
+		t.Fatalf("update settings: %v", err)
+	}
+
+	s := &Server{store: store}
+	secret, err := s.rotateClashSecret()
+	if err != nil {
+		t.Fatalf("rotate secret: %v", err)
+	}
+	if len(secret) != clashSecretLength || secret == "old-secret" {
+		t.Fatalf("unexpected new secret: %q", secret)
+	}
+	if got := store.GetSettings().ClashAPISecret; got != secret {
+		t.Fatalf("persisted secret mismatch: got %q, want %q", got, secret)
+	}
+
+	resp, err := s.clashAPIRequest(http.MethodGet, "/version", nil)
+	if err != nil {
+		t.Fatalf("clash api request: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("new secret rejected: got status %d", resp.StatusCode)
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, clash.URL+"/version", nil)
+	req.Header.Set("Authorization", "Bearer old-secret")
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("old secret request: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("old secret accepted: got status %d", resp.StatusCode)
+	}
+}
+
+func TestRotateClashSecret_RequiresLAN(t *testing.T) {
+	store, err := storage.NewSQLiteStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("create sqlite store: %v", err)
+	}
+	t.Cleanup(func() { _ = store.Close() })
+
+	s := &Server{store: store}
+	if _, err := s.rotateClashSecret(); err == nil {
+		t.Fatalf("expected rotation to be refused without LAN access")
+	}
+}
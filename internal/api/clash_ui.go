@@ -0,0 +1,40 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xiaobei/singbox-manager/internal/logger"
+)
+
+// installClashUI downloads a Clash dashboard bundle into the data directory and points
+// ClashUIPath at it, so sing-box serves it on the Clash API port.
+func (s *Server) installClashUI(c *gin.Context) {
+	name := strings.ToLower(strings.TrimSpace(c.DefaultQuery("dashboard", "yacd")))
+	if err := s.dashboardManager.StartInstall(name, s.useInstalledClashUI); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Install started"})
+}
+
+// useInstalledClashUI saves dir as ClashUIPath and re-applies the config
+func (s *Server) useInstalledClashUI(dir string) error {
+	s.storeSwapMu.RLock()
+	defer s.storeSwapMu.RUnlock()
+
+	settings := s.store.GetSettings()
+	settings.ClashUIPath = dir
+	if err := s.store.UpdateSettings(settings); err != nil {
+		return err
+	}
+	if err := s.autoApplyConfig(); err != nil {
+		logger.Printf("[clash-ui] Installed to %s, but auto-apply config failed: %v", dir, err)
+	}
+	return nil
+}
+
+func (s *Server) getClashUIProgress(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"data": s.dashboardManager.GetProgress()})
+}
@@ -0,0 +1,204 @@
+package api
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xiaobei/singbox-manager/internal/logger"
+	"github.com/xiaobei/singbox-manager/internal/storage"
+)
+
+// clientQuotaCheckInterval throttles quota alert checks, which run after traffic samples
+const clientQuotaCheckInterval = time.Minute
+
+// ClientQuotaStatus is a client's usage in the current day and month against its quota
+type ClientQuotaStatus struct {
+	SourceIP         string `json:"source_ip"`
+	DailyBytes       int64  `json:"daily_bytes"`
+	DailyUsedBytes   int64  `json:"daily_used_bytes"`
+	MonthlyBytes     int64  `json:"monthly_bytes"`
+	MonthlyUsedBytes int64  `json:"monthly_used_bytes"`
+	OverDaily        bool   `json:"over_daily"`
+	OverMonthly      bool   `json:"over_monthly"`
+	OverQuota        bool   `json:"over_quota"`
+	Alert            bool   `json:"alert"`
+}
+
+// ClientQuotaWebhookPayload is posted to Settings.WebhookURL when a client goes over quota
+type ClientQuotaWebhookPayload struct {
+	Event      string `json:"event"` // "client_quota"
+	Timestamp  string `json:"timestamp"`
+	SourceIP   string `json:"source_ip"`
+	Period     string `json:"period"` // "daily" or "monthly"
+	UsedBytes  int64  `json:"used_bytes"`
+	QuotaBytes int64  `json:"quota_bytes"`
+}
+
+// quotaPeriodStarts returns the start of the current local day and month
+func quotaPeriodStarts(now time.Time) (day, month time.Time) {
+	y, m, d := now.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, now.Location()), time.Date(y, m, 1, 0, 0, 0, 0, now.Location())
+}
+
+// computeClientQuotaStatus compares each quota with the client's daily and monthly usage
+func computeClientQuotaStatus(quotas []storage.ClientQuota, daily, monthly []storage.ClientUsage) []ClientQuotaStatus {
+	total := func(usage []storage.ClientUsage) map[string]int64 {
+		m := make(map[string]int64, len(usage))
+		for _, u := range usage {
+			m[u.SourceIP] += u.UploadBytes + u.DownloadBytes
+		}
+		return m
+	}
+	dailyUsed, monthlyUsed := total(daily), total(monthly)
+
+	statuses := make([]ClientQuotaStatus, 0, len(quotas))
+	for _, q := range quotas {
+		st := ClientQuotaStatus{
+			SourceIP:         q.SourceIP,
+			DailyBytes:       q.DailyBytes,
+			DailyUsedBytes:   dailyUsed[q.SourceIP],
+			MonthlyBytes:     q.MonthlyBytes,
+			MonthlyUsedBytes: monthlyUsed[q.SourceIP],
+			Alert:            q.Alert,
+		}
+		st.OverDaily = st.DailyBytes > 0 && st.DailyUsedBytes > st.DailyBytes
+		st.OverMonthly = st.MonthlyBytes > 0 && st.MonthlyUsedBytes > st.MonthlyBytes
+		st.OverQuota = st.OverDaily || st.OverMonthly
+		statuses = append(statuses, st)
+	}
+	return statuses
+}
+
+// clientQuotaStatus loads quotas and usage for the periods containing now
+func (s *Server) clientQuotaStatus(now time.Time) ([]ClientQuotaStatus, error) {
+	quotas := s.store.GetClientQuotas()
+	if len(quotas) == 0 {
+		return []ClientQuotaStatus{}, nil
+	}
+	dayStart, monthStart := quotaPeriodStarts(now)
+	daily, err := s.store.GetClientUsageSince(dayStart)
+	if err != nil {
+		return nil, err
+	}
+	monthly, err := s.store.GetClientUsageSince(monthStart)
+	if err != nil {
+		return nil, err
+	}
+	return computeClientQuotaStatus(quotas, daily, monthly), nil
+}
+
+// checkClientQuotaAlerts fires the webhook once per period for clients that went over
+// a quota with alerts enabled. Calls within clientQuotaCheckInterval are skipped.
+func (s *Server) checkClientQuotaAlerts(now time.Time) {
+	s.quotaMu.Lock()
+	defer s.quotaMu.Unlock()
+	if now.Sub(s.quotaCheckedAt) < clientQuotaCheckInterval {
+		return
+	}
+	s.quotaCheckedAt = now
+
+	statuses, err := s.clientQuotaStatus(now)
+	if err != nil {
+		logger.Printf("[quota] Failed to compute client quota usage: %v", err)
+		return
+	}
+	if s.quotaAlerted == nil {
+		s.quotaAlerted = make(map[string]string)
+	}
+
+	for _, payload := range clientQuotaAlerts(statuses, now, s.quotaAlerted) {
+		logger.Printf("[quota] %s is over its %s quota (%s of %s)", payload.SourceIP, payload.Period,
+			humanizeBytes(payload.UsedBytes), humanizeBytes(payload.QuotaBytes))
+		s.notifyWebhook(payload)
+	}
+}
+
+// clientQuotaAlerts returns the alerts due for statuses, recording in alerted the period
+// each one was sent for so a client is reported once per day/month
+func clientQuotaAlerts(statuses []ClientQuotaStatus, now time.Time, alerted map[string]string) []ClientQuotaWebhookPayload {
+	periods := []struct {
+		name string
+		key  string
+	}{
+		{"daily", now.Format("2006-01-02")},
+		{"monthly", now.Format("2006-01")},
+	}
+
+	var payloads []ClientQuotaWebhookPayload
+	for _, st := range statuses {
+		if !st.Alert {
+			continue
+		}
+		for _, period := range periods {
+			over, used, quota := st.OverDaily, st.DailyUsedBytes, st.DailyBytes
+			if period.name == "monthly" {
+				over, used, quota = st.OverMonthly, st.MonthlyUsedBytes, st.MonthlyBytes
+			}
+			alertKey := st.SourceIP + "|" + period.name
+			if !over || alerted[alertKey] == period.key {
+				continue
+			}
+			alerted[alertKey] = period.key
+			payloads = append(payloads, ClientQuotaWebhookPayload{
+				Event:      "client_quota",
+				Timestamp:  now.Format(time.RFC3339),
+				SourceIP:   st.SourceIP,
+				Period:     period.name,
+				UsedBytes:  used,
+				QuotaBytes: quota,
+			})
+		}
+	}
+	return payloads
+}
+
+// validateClientQuota checks that a quota names a client IP and has non-negative limits
+func validateClientQuota(q storage.ClientQuota) error {
+	if net.ParseIP(strings.TrimSpace(q.SourceIP)) == nil {
+		return fmt.Errorf("source_ip must be an IP address")
+	}
+	if q.DailyBytes < 0 || q.MonthlyBytes < 0 {
+		return fmt.Errorf("quota limits must not be negative")
+	}
+	return nil
+}
+
+func (s *Server) getClientQuotaStatus(c *gin.Context) {
+	statuses, err := s.clientQuotaStatus(time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": statuses})
+}
+
+func (s *Server) setClientQuota(c *gin.Context) {
+	var quota storage.ClientQuota
+	if err := c.ShouldBindJSON(&quota); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := validateClientQuota(quota); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	quota.SourceIP = strings.TrimSpace(quota.SourceIP)
+	quota.UpdatedAt = time.Now()
+	if err := s.store.SetClientQuota(quota); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": quota})
+}
+
+func (s *Server) deleteClientQuota(c *gin.Context) {
+	if err := s.store.DeleteClientQuota(c.Param("sourceIp")); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Deleted successfully"})
+}
@@ -0,0 +1,75 @@
+package api
+
+import (
+	"testing"
+	"time"
+
+	"github.com/xiaobei/singbox-manager/internal/storage"
+)
+
+func TestClientQuotaStatus_UsageAgainstQuota(t *testing.T) {
+	store, err := storage.NewSQLiteStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("create sqlite store: %v", err)
+	}
+	t.Cleanup(func() { _ = store.Close() })
+
+	now := time.Date(2026, 3, 15, 18, 0, 0, 0, time.Local)
+	samples := []struct {
+		at       time.Time
+		ip       string
+		up, down int64
+	}{
+		{time.Date(2026, 3, 2, 10, 0, 0, 0, time.Local), "192.168.1.10", 1000, 4000},    // earlier this month
+		{time.Date(2026, 3, 15, 9, 0, 0, 0, time.Local), "192.168.1.10", 1500, 6500},    // today
+		{time.Date(2026, 3, 15, 17, 0, 0, 0, time.Local), "192.168.1.20", 100, 100},     // today
+		{time.Date(2026, 2, 28, 23, 0, 0, 0, time.Local), "192.168.1.20", 50000, 50000}, // last month
+	}
+	for _, sm := range samples {
+		clients := []storage.ClientTrafficSnapshot{{Timestamp: sm.at, SourceIP: sm.ip, UploadBytes: sm.up, DownloadBytes: sm.down}}
+		if _, err := store.AddTrafficSample(storage.TrafficSample{Timestamp: sm.at}, clients, nil); err != nil {
+			t.Fatalf("add sample: %v", err)
+		}
+	}
+	for _, q := range []storage.ClientQuota{
+		{SourceIP: "192.168.1.10", DailyBytes: 2000, MonthlyBytes: 10000, Alert: true},
+		{SourceIP: "192.168.1.20", MonthlyBytes: 1000},
+	} {
+		if err := store.SetClientQuota(q); err != nil {
+			t.Fatalf("set quota: %v", err)
+		}
+	}
+
+	s := &Server{store: store}
+	statuses, err := s.clientQuotaStatus(now)
+	if err != nil {
+		t.Fatalf("client quota status: %v", err)
+	}
+	if len(statuses) != 2 {
+		t.Fatalf("expected 2 quota statuses, got %+v", statuses)
+	}
+
+	first := statuses[0]
+	if first.SourceIP != "192.168.1.10" || first.DailyUsedBytes != 3000 || first.MonthlyUsedBytes != 8000 {
+		t.Fatalf("192.168.1.10 usage mismatch: got %+v, want 3000 today and 8000 this month", first)
+	}
+	if !first.OverDaily || first.OverMonthly || !first.OverQuota {
+		t.Fatalf("192.168.1.10 should be over its daily quota only: %+v", first)
+	}
+
+	second := statuses[1]
+	if second.MonthlyUsedBytes != 200 || second.OverQuota {
+		t.Fatalf("192.168.1.20 should be within quota, last month excluded: %+v", second)
+	}
+
+	alerted := map[string]string{}
+	if alerts := clientQuotaAlerts(statuses, now, alerted); len(alerts) != 1 || alerts[0].Period != "daily" || alerts[0].UsedBytes != 3000 {
+		t.Fatalf("alerts mismatch: got %+v, want one daily alert", alerts)
+	}
+	if alerts := clientQuotaAlerts(statuses, now.Add(time.Hour), alerted); len(alerts) != 0 {
+		t.Fatalf("expected the daily alert once per day, got %+v", alerts)
+	}
+	if alerts := clientQuotaAlerts(statuses, now.Add(24*time.Hour), alerted); len(alerts) != 1 {
+		t.Fatalf("expected a new alert the next day, got %+v", alerts)
+	}
+}
@@ -0,0 +1,40 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xiaobei/singbox-manager/internal/builder"
+)
+
+// getConfigOutbounds returns only the outbounds array of the current config, so the
+// manager's node set can be spliced into an externally managed sing-box config.
+// Nodes already known to be unsupported by the kernel are left out, like on apply.
+func (s *Server) getConfigOutbounds(c *gin.Context) {
+	cfg, err := s.buildAppliedConfig()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	data, err := json.MarshalIndent(cfg.Outbounds, "", "  ")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.Data(http.StatusOK, "application/json; charset=utf-8", data)
+}
+
+// buildAppliedConfig builds the config the way apply writes it, leaving out nodes already
+// known to be unsupported by the kernel, without re-running sing-box validation.
+func (s *Server) buildAppliedConfig() (*builder.SingBoxConfig, error) {
+	excludeTags := make(map[string]bool)
+	s.unsupportedNodesMu.RLock()
+	for tag := range s.unsupportedNodes {
+		excludeTags[tag] = true
+	}
+	s.unsupportedNodesMu.RUnlock()
+
+	return s.configBuilder(s.installedKernelFeatures(), excludeTags).Build()
+}
@@ -0,0 +1,72 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xiaobei/singbox-manager/internal/daemon"
+	"github.com/xiaobei/singbox-manager/internal/storage"
+)
+
+func TestGetConfigOutbounds_MatchesFullConfig(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	dir := t.TempDir()
+	store, err := storage.NewSQLiteStore(dir)
+	if err != nil {
+		t.Fatalf("create sqlite store: %v", err)
+	}
+	t.Cleanup(func() { _ = store.Close() })
+
+	for _, n := range []storage.UnifiedNode{
+		{Tag: "hk-1", InternalTag: "hk-1", Type: "trojan", Server: "10.0.0.1", ServerPort: 443, Country: "HK",
+			Extra: map[string]interface{}{"password": "secret"}, Status: storage.NodeStatusVerified},
+		{Tag: "jp-1", InternalTag: "jp-1", Type: "trojan", Server: "10.0.0.2", ServerPort: 443, Country: "JP",
+			Extra: map[string]interface{}{"password": "secret"}, Status: storage.NodeStatusVerified},
+	} {
+		if _, err := store.AddNode(n); err != nil {
+			t.Fatalf("insert node %s: %v", n.Tag, err)
+		}
+	}
+
+	s := &Server{
+		store:            store,
+		processManager:   daemon.NewProcessManager(filepath.Join(dir, "missing-sing-box"), filepath.Join(dir, "config.json"), dir),
+		unsupportedNodes: map[string]UnsupportedNodeInfo{},
+	}
+
+	configJSON, err := s.buildConfig()
+	if err != nil {
+		t.Fatalf("build config: %v", err)
+	}
+	var full struct {
+		Outbounds []interface{} `json:"outbounds"`
+	}
+	if err := json.Unmarshal([]byte(configJSON), &full); err != nil {
+		t.Fatalf("decode full config: %v", err)
+	}
+
+	router := gin.New()
+	router.GET("/config/outbounds", s.getConfigOutbounds)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/config/outbounds", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status mismatch: got %d, want %d (%s)", rec.Code, http.StatusOK, rec.Body.String())
+	}
+
+	var outbounds []interface{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &outbounds); err != nil {
+		t.Fatalf("decode outbounds fragment: %v", err)
+	}
+	if len(outbounds) == 0 {
+		t.Fatalf("expected a non-empty outbounds array")
+	}
+	if !reflect.DeepEqual(outbounds, full.Outbounds) {
+		t.Fatalf("outbounds mismatch:\ngot  %v\nwant %v", outbounds, full.Outbounds)
+	}
+}
@@ -0,0 +1,99 @@
+package api
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// configExportsDir is the data directory subfolder alternate config outputs are confined
+// to, so an apply with a path can never overwrite the database, the kernel or logs.
+const configExportsDir = "exports"
+
+// resolveConfigOutputPath resolves an alternate config output path inside the exports
+// directory. Relative paths are taken from that directory. The target must be a .json
+// file, and neither it nor the directories on the way may be symlinks. The configured
+// config file is rejected as well, only a regular apply may overwrite it.
+func (s *Server) resolveConfigOutputPath(path string) (string, error) {
+	dataDir, err := filepath.Abs(s.store.GetDataDir())
+	if err != nil {
+		return "", err
+	}
+	exportsDir := filepath.Join(dataDir, configExportsDir)
+
+	target := filepath.Clean(path)
+	if !filepath.IsAbs(target) {
+		target = filepath.Join(exportsDir, target)
+	}
+	rel, err := filepath.Rel(exportsDir, target)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("path must be inside %s", exportsDir)
+	}
+	if !strings.EqualFold(filepath.Ext(target), ".json") {
+		return "", fmt.Errorf("path must name a .json file")
+	}
+
+	// Walk from the exports directory down to the target, refusing symlinks anywhere
+	current := exportsDir
+	parts := append([]string{""}, strings.Split(rel, string(filepath.Separator))...)
+	for i, part := range parts {
+		current = filepath.Join(current, part)
+		info, err := os.Lstat(current)
+		if os.IsNotExist(err) {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+		if info.Mode()&os.ModeSymlink != 0 {
+			return "", fmt.Errorf("%s is a symlink", current)
+		}
+		if last := i == len(parts)-1; last && !info.Mode().IsRegular() {
+			return "", fmt.Errorf("%s is not a regular file", current)
+		} else if !last && !info.IsDir() {
+			return "", fmt.Errorf("%s is not a directory", current)
+		}
+	}
+
+	mainPath, err := filepath.Abs(s.resolvePath(s.store.GetSettings().ConfigPath))
+	if err == nil && mainPath == target {
+		return "", fmt.Errorf("path is the configured config file, apply without a path to update it")
+	}
+	return target, nil
+}
+
+// writeConfigToPath builds and validates the config like a regular apply and writes it
+// to path, leaving the configured config file and the running sing-box alone. The file
+// is written next to the target and renamed over it, so a symlink swapped in after
+// resolving is replaced rather than followed.
+func (s *Server) writeConfigToPath(path string) ([]UnsupportedNodeInfo, error) {
+	configJSON, newUnsupported, err := s.buildAndValidateConfig()
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".export-*.json")
+	if err != nil {
+		return nil, err
+	}
+	tmpPath := tmp.Name()
+	defer os.Remove(tmpPath)
+	if _, err := tmp.WriteString(configJSON); err != nil {
+		tmp.Close()
+		return nil, err
+	}
+	if err := tmp.Chmod(0644); err != nil {
+		tmp.Close()
+		return nil, err
+	}
+	if err := tmp.Close(); err != nil {
+		return nil, err
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return nil, err
+	}
+	return newUnsupported, nil
+}
@@ -0,0 +1,110 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xiaobei/singbox-manager/internal/daemon"
+	"github.com/xiaobei/singbox-manager/internal/storage"
+)
+
+func TestApplyConfig_AlternatePathLeavesMainConfigUntouched(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	dir := t.TempDir()
+	store, err := storage.NewSQLiteStore(dir)
+	if err != nil {
+		t.Fatalf("create sqlite store: %v", err)
+	}
+	t.Cleanup(func() { _ = store.Close() })
+
+	if _, err := store.AddNode(storage.UnifiedNode{Tag: "hk-1", InternalTag: "hk-1", Type: "trojan", Server: "10.0.0.1", ServerPort: 443,
+		Extra: map[string]interface{}{"password": "secret"}, Status: storage.NodeStatusVerified}); err != nil {
+		t.Fatalf("insert node: %v", err)
+	}
+	settings := store.GetSettings()
+	settings.ConfigPath = filepath.Join(dir, "config.json")
+	if err := store.UpdateSettings(settings); err != nil {
+		t.Fatalf("update settings: %v", err)
+	}
+	const mainContent = `{"stale":true}`
+	if err := os.WriteFile(settings.ConfigPath, []byte(mainContent), 0644); err != nil {
+		t.Fatalf("write main config: %v", err)
+	}
+
+	// Fake sing-box that accepts every config on `check`
+	binPath := filepath.Join(dir, "sing-box")
+	if err := os.WriteFile(binPath, []byte("#!/bin/sh\nexit 0\n"), 0755); err != nil {
+		t.Fatalf("write fake sing-box: %v", err)
+	}
+	s := &Server{
+		store:            store,
+		processManager:   daemon.NewProcessManager(binPath, settings.ConfigPath, dir),
+		unsupportedNodes: map[string]UnsupportedNodeInfo{},
+	}
+	router := gin.New()
+	router.POST("/config/apply", s.applyConfig)
+	apply := func(body string) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodPost, "/config/apply", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := apply(`{"path":"laptop.json"}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status mismatch: got %d, want %d (%s)", rec.Code, http.StatusOK, rec.Body.String())
+	}
+	var resp struct {
+		Data struct {
+			Path string `json:"path"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	wantPath := filepath.Join(dir, "exports", "laptop.json")
+	if resp.Data.Path != wantPath {
+		t.Fatalf("written path mismatch: got %q, want %q", resp.Data.Path, wantPath)
+	}
+	written, err := os.ReadFile(wantPath)
+	if err != nil {
+		t.Fatalf("read written config: %v", err)
+	}
+	if !strings.Contains(string(written), `"hk-1"`) {
+		t.Fatalf("written config should contain the node outbound, got %s", written)
+	}
+	main, err := os.ReadFile(settings.ConfigPath)
+	if err != nil {
+		t.Fatalf("read main config: %v", err)
+	}
+	if string(main) != mainContent {
+		t.Fatalf("main config was modified: got %s", main)
+	}
+
+	// A symlink inside the exports directory must not be followed
+	if err := os.Symlink(filepath.Join(dir, "data.db"), filepath.Join(dir, "exports", "link.json")); err != nil {
+		t.Fatalf("create symlink: %v", err)
+	}
+	if err := os.Mkdir(filepath.Join(dir, "exports", "dir.json"), 0755); err != nil {
+		t.Fatalf("create directory: %v", err)
+	}
+
+	for _, path := range []string{
+		"../outside.json", "/etc/sbm.json", settings.ConfigPath,
+		"../data.db", filepath.Join(dir, "data.db"), "../bin/sing-box", filepath.Join(dir, "bin", "sing-box"),
+		"notes.txt", "link.json", "dir.json", "../config.json",
+	} {
+		if rec := apply(`{"path":"` + path + `"}`); rec.Code != http.StatusBadRequest {
+			t.Fatalf("path %q: status mismatch: got %d, want %d", path, rec.Code, http.StatusBadRequest)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ConfigStatus compares the config file on disk with what apply would write now
+type ConfigStatus struct {
+	Path         string     `json:"path"`
+	Exists       bool       `json:"exists"`
+	UpToDate     bool       `json:"up_to_date"`
+	ExpectedHash string     `json:"expected_hash"`
+	FileHash     string     `json:"file_hash,omitempty"`
+	ModifiedAt   *time.Time `json:"modified_at,omitempty"`
+}
+
+// configHash hashes the canonical form of a JSON config so formatting and key order
+// do not count as changes. Content that is not JSON is hashed as is.
+func configHash(data []byte) string {
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err == nil {
+		if canonical, err := json.Marshal(v); err == nil {
+			data = canonical
+		}
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// compareConfigFile reports whether the file at path matches the expected config
+func compareConfigFile(path string, expected []byte) (*ConfigStatus, error) {
+	status := &ConfigStatus{Path: path, ExpectedHash: configHash(expected)}
+	info, err := os.Stat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return status, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	modified := info.ModTime()
+	status.Exists = true
+	status.ModifiedAt = &modified
+	status.FileHash = configHash(data)
+	status.UpToDate = status.FileHash == status.ExpectedHash
+	return status, nil
+}
+
+// getConfigStatus reports whether the config on disk is out of date with the current
+// settings and nodes, so the UI can point out unapplied changes.
+func (s *Server) getConfigStatus(c *gin.Context) {
+	cfg, err := s.buildAppliedConfig()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	expected, err := json.Marshal(cfg)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	status, err := compareConfigFile(s.resolvePath(s.store.GetSettings().ConfigPath), expected)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": status})
+}
@@ -0,0 +1,92 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xiaobei/singbox-manager/internal/daemon"
+	"github.com/xiaobei/singbox-manager/internal/storage"
+)
+
+func TestGetConfigStatus_MatchAndMismatch(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	dir := t.TempDir()
+	store, err := storage.NewSQLiteStore(dir)
+	if err != nil {
+		t.Fatalf("create sqlite store: %v", err)
+	}
+	t.Cleanup(func() { _ = store.Close() })
+
+	if _, err := store.AddNode(storage.UnifiedNode{Tag: "hk-1", InternalTag: "hk-1", Type: "trojan", Server: "10.0.0.1", ServerPort: 443,
+		Extra: map[string]interface{}{"password": "secret"}, Status: storage.NodeStatusVerified}); err != nil {
+		t.Fatalf("insert node: %v", err)
+	}
+
+	s := &Server{
+		store:            store,
+		processManager:   daemon.NewProcessManager(filepath.Join(dir, "missing-sing-box"), filepath.Join(dir, "config.json"), dir),
+		unsupportedNodes: map[string]UnsupportedNodeInfo{},
+	}
+	router := gin.New()
+	router.GET("/config/status", s.getConfigStatus)
+	status := func() ConfigStatus {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/config/status", nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("status mismatch: got %d, want %d (%s)", rec.Code, http.StatusOK, rec.Body.String())
+		}
+		var resp struct {
+			Data ConfigStatus `json:"data"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("decode response: %v", err)
+		}
+		return resp.Data
+	}
+
+	if got := status(); got.Exists || got.UpToDate {
+		t.Fatalf("expected a missing file to be reported as not up to date, got %+v", got)
+	}
+
+	// Write the config the way apply does
+	configJSON, err := s.buildConfig()
+	if err != nil {
+		t.Fatalf("build config: %v", err)
+	}
+	configPath := s.resolvePath(store.GetSettings().ConfigPath)
+	if err := os.MkdirAll(filepath.Dir(configPath), 0755); err != nil {
+		t.Fatalf("create config dir: %v", err)
+	}
+	if err := s.saveConfigFile(configPath, configJSON); err != nil {
+		t.Fatalf("save config: %v", err)
+	}
+	if got := status(); !got.Exists || !got.UpToDate || got.FileHash != got.ExpectedHash {
+		t.Fatalf("expected the applied config to be up to date, got %+v", got)
+	}
+
+	settings := store.GetSettings()
+	settings.MixedPort = 2090
+	if err := store.UpdateSettings(settings); err != nil {
+		t.Fatalf("update settings: %v", err)
+	}
+	if got := status(); !got.Exists || got.UpToDate || got.FileHash == got.ExpectedHash {
+		t.Fatalf("expected a settings change to make the config stale, got %+v", got)
+	}
+}
+
+func TestConfigHash_IgnoresFormatting(t *testing.T) {
+	compact := configHash([]byte(`{"b":1,"a":[1,2]}`))
+	if indented := configHash([]byte("{\n  \"a\": [1, 2],\n  \"b\": 1\n}")); indented != compact {
+		t.Fatalf("hash mismatch for reformatted config: got %s, want %s", indented, compact)
+	}
+	if reordered := configHash([]byte(`{"b":1,"a":[2,1]}`)); reordered == compact {
+		t.Fatal("expected array order to change the hash")
+	}
+}
@@ -0,0 +1,70 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xiaobei/singbox-manager/internal/storage"
+)
+
+// defaultConnectionLogWindow is how far back the connection log looks when no since is given
+const defaultConnectionLogWindow = 24 * time.Hour
+
+// getMonitoringConnectionLog returns the sampled connection log for auditing which hosts
+// clients reached over time. host matches subdomains too; since/until accept RFC 3339
+// or unix seconds.
+func (s *Server) getMonitoringConnectionLog(c *gin.Context) {
+	since, err := parseTimeQuery(c.Query("since"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid since: %v", err)})
+		return
+	}
+	if since.IsZero() {
+		since = time.Now().Add(-defaultConnectionLogWindow)
+	}
+	until, err := parseTimeQuery(c.Query("until"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid until: %v", err)})
+		return
+	}
+	if !until.IsZero() && !until.After(since) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "until must be after since"})
+		return
+	}
+
+	limit := 500
+	if raw := strings.TrimSpace(c.Query("limit")); raw != "" {
+		if parsed, err := strconv.Atoi(raw); err == nil && parsed > 0 {
+			limit = parsed
+		}
+	}
+
+	entries, err := s.store.GetConnectionLog(storage.ConnectionLogQuery{
+		Host:     strings.ToLower(strings.TrimSpace(c.Query("host"))),
+		SourceIP: strings.TrimSpace(c.Query("source_ip")),
+		Since:    since,
+		Until:    until,
+		Limit:    limit,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": entries})
+}
+
+// parseTimeQuery parses an RFC 3339 timestamp or unix seconds, returning the zero time for an empty value
+func parseTimeQuery(raw string) (time.Time, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return time.Time{}, nil
+	}
+	if secs, err := strconv.ParseInt(raw, 10, 64); err == nil {
+		return time.Unix(secs, 0), nil
+	}
+	return time.Parse(time.RFC3339, raw)
+}
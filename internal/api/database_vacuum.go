@@ -0,0 +1,54 @@
+package api
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xiaobei/singbox-manager/internal/logger"
+	"github.com/xiaobei/singbox-manager/internal/storage"
+)
+
+// vacuumDatabase compacts the SQLite file and reports its size before and after.
+// VACUUM locks the whole database, so it runs under the store swap write lock.
+func (s *Server) vacuumDatabase(c *gin.Context) {
+	s.storeSwapMu.Lock()
+	defer s.storeSwapMu.Unlock()
+
+	sqlStore, ok := s.store.(*storage.SQLiteStore)
+	if !ok {
+		c.JSON(http.StatusNotImplemented, gin.H{"error": "Vacuum is only supported for the SQLite store"})
+		return
+	}
+
+	dbPath := filepath.Join(sqlStore.GetDataDir(), "data.db")
+	if err := sqlStore.Checkpoint(); err != nil {
+		logger.Printf("WAL checkpoint warning: %v", err)
+	}
+	before, err := os.Stat(dbPath)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read database file size"})
+		return
+	}
+
+	if err := sqlStore.Vacuum(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Vacuum failed: " + err.Error()})
+		return
+	}
+
+	after, err := os.Stat(dbPath)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read database file size"})
+		return
+	}
+
+	logger.Printf("[database] Vacuum complete: %s -> %s", humanizeBytes(before.Size()), humanizeBytes(after.Size()))
+	c.JSON(http.StatusOK, gin.H{"data": gin.H{
+		"before_size_bytes": before.Size(),
+		"before_size_human": humanizeBytes(before.Size()),
+		"after_size_bytes":  after.Size(),
+		"after_size_human":  humanizeBytes(after.Size()),
+		"reclaimed_bytes":   before.Size() - after.Size(),
+	}})
+}
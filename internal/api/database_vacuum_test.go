@@ -0,0 +1,70 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xiaobei/singbox-manager/internal/storage"
+)
+
+func TestVacuumDatabase(t *testing.T) {
+	store, err := storage.NewSQLiteStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("create sqlite store: %v", err)
+	}
+	t.Cleanup(func() { _ = store.Close() })
+
+	padding := strings.Repeat("x", 2048)
+	var tags []string
+	for i := 0; i < 300; i++ {
+		tag := fmt.Sprintf("node-%d", i)
+		tags = append(tags, tag)
+		if _, err := store.AddNode(storage.UnifiedNode{
+			Tag: tag, InternalTag: tag, Type: "vmess",
+			Server: fmt.Sprintf("10.0.%d.%d", i/256, i%256), ServerPort: 443,
+			Status: storage.NodeStatusVerified,
+			Extra:  map[string]interface{}{"padding": padding},
+		}); err != nil {
+			t.Fatalf("insert node: %v", err)
+		}
+	}
+	if _, err := store.RemoveNodesByTags(tags); err != nil {
+		t.Fatalf("remove nodes: %v", err)
+	}
+
+	gin.SetMode(gin.TestMode)
+	s := &Server{store: store}
+	r := gin.New()
+	r.POST("/api/database/vacuum", s.vacuumDatabase)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/database/vacuum", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status mismatch: got %d, want %d (%s)", w.Code, http.StatusOK, w.Body.String())
+	}
+
+	var resp struct {
+		Data struct {
+			BeforeSizeBytes int64 `json:"before_size_bytes"`
+			AfterSizeBytes  int64 `json:"after_size_bytes"`
+			ReclaimedBytes  int64 `json:"reclaimed_bytes"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if resp.Data.BeforeSizeBytes <= 0 || resp.Data.AfterSizeBytes <= 0 {
+		t.Fatalf("expected sizes to be reported, got %+v", resp.Data)
+	}
+	if resp.Data.AfterSizeBytes >= resp.Data.BeforeSizeBytes {
+		t.Fatalf("expected vacuum to shrink the file: before %d, after %d", resp.Data.BeforeSizeBytes, resp.Data.AfterSizeBytes)
+	}
+	if resp.Data.ReclaimedBytes != resp.Data.BeforeSizeBytes-resp.Data.AfterSizeBytes {
+		t.Fatalf("reclaimed mismatch: got %d", resp.Data.ReclaimedBytes)
+	}
+}
@@ -0,0 +1,65 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xiaobei/singbox-manager/internal/daemon"
+	"github.com/xiaobei/singbox-manager/internal/storage"
+)
+
+// defaultDebugConnectURL is used when no urltest target is configured
+const defaultDebugConnectURL = "https://www.gstatic.com/generate_204"
+
+// debugConnectNode runs one connection through a single node in a temporary sing-box
+// with debug logging and returns the captured log, so the full handshake error can be
+// read without digging through the main instance's logs.
+func (s *Server) debugConnectNode(c *gin.Context) {
+	settings := s.store.GetSettings()
+	if !settings.DebugAPIEnabled {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Debug API is disabled. Enable it in Settings."})
+		return
+	}
+
+	node := s.findNodeByTag(c.Param("tag"))
+	if node == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Node not found"})
+		return
+	}
+
+	var req struct {
+		URL string `json:"url"`
+	}
+	c.ShouldBindJSON(&req)
+
+	targetURL := strings.TrimSpace(req.URL)
+	if targetURL == "" {
+		targetURL = strings.TrimSpace(settings.URLTestURL)
+	}
+	if targetURL == "" {
+		targetURL = defaultDebugConnectURL
+	}
+
+	result, err := daemon.DebugConnect(s.processManager.GetSingBoxPath(), *node, targetURL, 10*time.Second)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": result})
+}
+
+// findNodeByTag returns the first node, enabled or not, matching the tag
+func (s *Server) findNodeByTag(tag string) *storage.Node {
+	tagSet := parseTagSet([]string{tag})
+	if len(tagSet) == 0 {
+		return nil
+	}
+	for _, n := range s.store.GetAllNodesIncludeDisabled() {
+		if nodeMatchesAnyTag(n, tagSet) {
+			return &n
+		}
+	}
+	return nil
+}
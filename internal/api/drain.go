@@ -0,0 +1,53 @@
+package api
+
+import "time"
+
+// drainConnectionThreshold is the active connection count a restart waits to drop
+// below. A few long-lived idle connections (keep-alives, push channels) rarely close
+// on their own, so waiting for zero would nearly always run into the timeout.
+const drainConnectionThreshold = 3
+
+// restartSingbox restarts sing-box. With Settings.DrainTimeoutSeconds set and sing-box
+// running, the restart waits in the background for active connections to finish, so
+// the request returns at once and progress goes out as service:drain_* events. It
+// reports whether the restart was deferred that way.
+func (s *Server) restartSingbox() (bool, error) {
+	timeout := time.Duration(s.store.GetSettings().DrainTimeoutSeconds) * time.Second
+	if timeout <= 0 || !s.processManager.IsRunning() {
+		return false, s.processManager.Restart()
+	}
+	// A drain already under way restarts after this caller saved its config
+	if !s.drainInProgress.CompareAndSwap(false, true) {
+		return true, nil
+	}
+
+	s.eventBus.PublishTimestamped("service:drain_start", map[string]interface{}{
+		"timeout_seconds": int(timeout / time.Second),
+	})
+	go func() {
+		s.processManager.DrainConnections(s.activeConnectionCount, drainConnectionThreshold, timeout, func(active int) {
+			s.eventBus.Publish("service:drain_progress", map[string]interface{}{
+				"active":    active,
+				"threshold": drainConnectionThreshold,
+			})
+		})
+		// Cleared before restarting so a config saved from here on gets its own restart
+		s.drainInProgress.Store(false)
+
+		data := map[string]interface{}{}
+		if err := s.processManager.Restart(); err != nil {
+			data["error"] = err.Error()
+		}
+		s.eventBus.PublishTimestamped("service:drain_complete", data)
+	}()
+	return true, nil
+}
+
+// activeConnectionCount reports how many connections sing-box carries right now
+func (s *Server) activeConnectionCount() (int, error) {
+	snapshot, err := s.fetchConnectionsSnapshot()
+	if err != nil {
+		return 0, err
+	}
+	return len(snapshot.Connections), nil
+}
@@ -0,0 +1,35 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xiaobei/singbox-manager/internal/builder"
+	"github.com/xiaobei/singbox-manager/internal/storage"
+)
+
+// previewFilter returns the routing tags of the nodes a filter definition would
+// capture, using the builder's matcher, without saving the filter. Nodes left out
+// of the config (unsupported by the kernel, blocked countries) are not listed.
+func (s *Server) previewFilter(c *gin.Context) {
+	var filter storage.Filter
+	if err := c.ShouldBindJSON(&filter); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	excludeTags := make(map[string]bool)
+	s.unsupportedNodesMu.RLock()
+	for tag := range s.unsupportedNodes {
+		excludeTags[tag] = true
+	}
+	s.unsupportedNodesMu.RUnlock()
+
+	tags := builder.NewConfigBuilderWithExclusions(s.store.GetSettings(), s.store.GetAllNodes(), nil, excludeTags).
+		FilterMembers(filter)
+	if tags == nil {
+		tags = []string{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": tags, "count": len(tags)})
+}
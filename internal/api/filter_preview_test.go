@@ -0,0 +1,64 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xiaobei/singbox-manager/internal/storage"
+)
+
+func TestPreviewFilter_IncludeKeyword(t *testing.T) {
+	store, err := storage.NewSQLiteStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("create sqlite store: %v", err)
+	}
+	t.Cleanup(func() { _ = store.Close() })
+
+	for _, n := range []storage.UnifiedNode{
+		{Tag: "HK IPLC 01", DisplayName: "HK IPLC 01", InternalTag: "node_hk_iplc", Type: "vmess", Server: "10.0.0.1", ServerPort: 443, Status: storage.NodeStatusVerified},
+		{Tag: "JP iplc 02", DisplayName: "JP iplc 02", InternalTag: "node_jp_iplc", Type: "vmess", Server: "10.0.0.2", ServerPort: 443, Status: storage.NodeStatusVerified},
+		{Tag: "US Standard", DisplayName: "US Standard", InternalTag: "node_us", Type: "vmess", Server: "10.0.0.3", ServerPort: 443, Status: storage.NodeStatusVerified},
+	} {
+		if _, err := store.AddNode(n); err != nil {
+			t.Fatalf("insert node %s: %v", n.Tag, err)
+		}
+	}
+
+	gin.SetMode(gin.TestMode)
+	s := &Server{store: store, unsupportedNodes: map[string]UnsupportedNodeInfo{}}
+	r := gin.New()
+	r.POST("/api/filters/preview", s.previewFilter)
+
+	body := `{"name":"IPLC","include":["IPLC"],"mode":"urltest","enabled":true}`
+	req := httptest.NewRequest(http.MethodPost, "/api/filters/preview", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status mismatch: got %d, want %d (%s)", w.Code, http.StatusOK, w.Body.String())
+	}
+
+	var resp struct {
+		Data  []string `json:"data"`
+		Count int      `json:"count"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	sort.Strings(resp.Data)
+	if want := []string{"node_hk_iplc", "node_jp_iplc"}; !reflect.DeepEqual(resp.Data, want) {
+		t.Fatalf("matched tags mismatch: got %v, want %v", resp.Data, want)
+	}
+	if resp.Count != 2 {
+		t.Fatalf("count mismatch: got %d, want 2", resp.Count)
+	}
+	if filters := store.GetFilters(); len(filters) != 0 {
+		t.Fatalf("expected preview not to persist the filter, got %d filters", len(filters))
+	}
+}
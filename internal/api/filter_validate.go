@@ -0,0 +1,66 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xiaobei/singbox-manager/internal/storage"
+)
+
+// filterURLCheckTimeout bounds the reachability check of a filter's urltest URL on save
+var filterURLCheckTimeout = 5 * time.Second
+
+// validateFilterURLTest rejects urltest settings that would produce a broken group:
+// a test URL that isn't http(s) or an interval sing-box can't parse.
+func validateFilterURLTest(filter storage.Filter) error {
+	if filter.Mode != "urltest" || filter.URLTestConfig == nil {
+		return nil
+	}
+	if err := validateURLTestSettings(filter.URLTestConfig.URL, ""); err != nil {
+		return fmt.Errorf("urltest_config.url must be an http or https URL")
+	}
+	if interval := strings.TrimSpace(filter.URLTestConfig.Interval); interval != "" {
+		if d, err := time.ParseDuration(interval); err != nil || d <= 0 {
+			return fmt.Errorf("urltest_config.interval %q is not a valid duration, use values like 30s or 5m", interval)
+		}
+	}
+	return nil
+}
+
+// filterURLTestWarnings checks the filter's urltest URL from this host when the
+// request asks for it with check_url=true. An unreachable URL makes every node of
+// the group look dead, so it is reported back instead of failing the save.
+func filterURLTestWarnings(c *gin.Context, filter storage.Filter) []string {
+	if c.Query("check_url") != "true" || filter.Mode != "urltest" || filter.URLTestConfig == nil {
+		return nil
+	}
+	url := strings.TrimSpace(filter.URLTestConfig.URL)
+	if url == "" {
+		return nil
+	}
+	client := &http.Client{Timeout: filterURLCheckTimeout}
+	if err := headURL(client, url); err != nil {
+		return []string{fmt.Sprintf("urltest URL %s is not reachable: %v", url, err)}
+	}
+	return nil
+}
+
+// headURL issues a HEAD request to url. Any 2xx/3xx response counts as reachable.
+func headURL(client *http.Client, url string) error {
+	req, err := http.NewRequest(http.MethodHead, url, nil)
+	if err != nil {
+		return fmt.Errorf("invalid URL: %w", err)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= http.StatusBadRequest {
+		return fmt.Errorf("HTTP status code: %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xiaobei/singbox-manager/internal/storage"
+)
+
+func newFilterTestRouter(t *testing.T) (*gin.Engine, storage.Store) {
+	t.Helper()
+	store, err := storage.NewSQLiteStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("create sqlite store: %v", err)
+	}
+	t.Cleanup(func() { _ = store.Close() })
+	settings := store.GetSettings()
+	settings.AutoApply = false
+	if err := store.UpdateSettings(settings); err != nil {
+		t.Fatalf("update settings: %v", err)
+	}
+
+	gin.SetMode(gin.TestMode)
+	s := &Server{store: store, unsupportedNodes: map[string]UnsupportedNodeInfo{}}
+	r := gin.New()
+	r.POST("/api/filters", s.addFilter)
+	return r, store
+}
+
+func TestAddFilter_WarnsOnUnreachableURLTestURL(t *testing.T) {
+	r, store := newFilterTestRouter(t)
+
+	// A server that is closed right away leaves a URL nothing listens on
+	closed := httptest.NewServer(http.NotFoundHandler())
+	deadURL := closed.URL + "/generate_204"
+	closed.Close()
+
+	body := `{"name":"Auto","mode":"urltest","enabled":true,"urltest_config":{"url":"` + deadURL + `","interval":"5m","tolerance":50}}`
+	req := httptest.NewRequest(http.MethodPost, "/api/filters?check_url=true", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status mismatch: got %d, want %d (%s)", w.Code, http.StatusOK, w.Body.String())
+	}
+
+	var resp struct {
+		Data     storage.Filter `json:"data"`
+		Warnings []string       `json:"warnings"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if len(resp.Warnings) != 1 || !strings.Contains(resp.Warnings[0], deadURL) {
+		t.Fatalf("warnings mismatch: got %v, want one warning naming %s", resp.Warnings, deadURL)
+	}
+	if got := len(store.GetFilters()); got != 1 {
+		t.Fatalf("saved filters mismatch: got %d, want 1", got)
+	}
+}
+
+func TestAddFilter_RejectsMalformedInterval(t *testing.T) {
+	r, store := newFilterTestRouter(t)
+
+	body := `{"name":"Auto","mode":"urltest","enabled":true,"urltest_config":{"url":"https://www.gstatic.com/generate_204","interval":"5 minutes"}}`
+	req := httptest.NewRequest(http.MethodPost, "/api/filters", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("status mismatch: got %d, want %d (%s)", w.Code, http.StatusBadRequest, w.Body.String())
+	}
+	if !strings.Contains(w.Body.String(), "interval") {
+		t.Fatalf("error should mention the interval: %s", w.Body.String())
+	}
+	if got := len(store.GetFilters()); got != 0 {
+		t.Fatalf("saved filters mismatch: got %d, want 0", got)
+	}
+}
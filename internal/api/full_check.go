@@ -0,0 +1,94 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xiaobei/singbox-manager/internal/daemon"
+	"github.com/xiaobei/singbox-manager/internal/storage"
+)
+
+// probeStarter starts (or reuses) a probe for nodes and returns its Clash API port and tag map
+type probeStarter func(nodes []storage.Node) (int, *daemon.ProbeTagMap, error)
+
+// FullCheckResult holds health and site check results from a single probe session
+type FullCheckResult struct {
+	Health map[string]*NodeHealthResult    `json:"health"`
+	Sites  map[string]*NodeSiteCheckResult `json:"sites"`
+}
+
+// performFullCheck runs health and site checks for nodes in one probe session.
+func (s *Server) performFullCheck(start probeStarter, nodes []storage.Node, targets []string) (*FullCheckResult, error) {
+	uniqueNodes := dedupeNodesByEndpoint(nodes)
+
+	port, tagMap, err := start(uniqueNodes)
+	if err != nil {
+		return nil, err
+	}
+
+	return &FullCheckResult{
+		Health: s.runHealthChecks(port, s.probeUDPProxyPort(), tagMap, uniqueNodes),
+		Sites:  s.runSiteChecks(port, tagMap, uniqueNodes, targets),
+	}, nil
+}
+
+func (s *Server) fullCheckNodes(c *gin.Context) {
+	var req struct {
+		Tags  []string `json:"tags"`
+		Sites []string `json:"sites"`
+	}
+	c.ShouldBindJSON(&req)
+
+	targets := sanitizeSiteTargets(req.Sites)
+	if len(targets) == 0 {
+		targets = append([]string{}, defaultSiteCheckTargets...)
+	}
+
+	allNodes := s.store.GetAllNodesIncludeDisabled()
+	var nodes []storage.Node
+	if len(req.Tags) > 0 {
+		tagSet := parseTagSet(req.Tags)
+		for _, n := range allNodes {
+			if nodeMatchesAnyTag(n, tagSet) {
+				nodes = append(nodes, n)
+			}
+		}
+	} else {
+		nodes = allNodes
+	}
+
+	if len(nodes) == 0 {
+		c.JSON(http.StatusOK, gin.H{
+			"data":  &FullCheckResult{Health: map[string]*NodeHealthResult{}, Sites: map[string]*NodeSiteCheckResult{}},
+			"mode":  "",
+			"sites": targets,
+		})
+		return
+	}
+
+	check := func(progress jobProgress) (interface{}, error) {
+		total := len(dedupeNodesByEndpoint(nodes))
+		progress(0, total)
+		result, err := s.performFullCheck(s.ensureProbe, nodes, targets)
+		if err != nil {
+			return nil, err
+		}
+		progress(total, total)
+		return gin.H{
+			"data":  result,
+			"mode":  "probe",
+			"sites": targets,
+		}, nil
+	}
+
+	if isAsyncRequest(c) {
+		s.respondWithJob(c, "full_check", check)
+		return
+	}
+	result, err := check(func(int, int) {})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,66 @@
+package api
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/xiaobei/singbox-manager/internal/daemon"
+	"github.com/xiaobei/singbox-manager/internal/events"
+	"github.com/xiaobei/singbox-manager/internal/storage"
+)
+
+func TestPerformFullCheck_SingleProbeSession(t *testing.T) {
+	clashAPI := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"delay":42}`))
+	}))
+	defer clashAPI.Close()
+
+	_, portStr, err := net.SplitHostPort(clashAPI.Listener.Addr().String())
+	if err != nil {
+		t.Fatalf("split clash api addr: %v", err)
+	}
+	port, _ := strconv.Atoi(portStr)
+
+	store, err := storage.NewSQLiteStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("create sqlite store: %v", err)
+	}
+	t.Cleanup(func() { _ = store.Close() })
+
+	s := &Server{store: store, eventBus: events.NewBus()}
+	nodes := []storage.Node{
+		{Tag: "a", InternalTag: "a", Type: "vmess", Server: "10.0.0.1", ServerPort: 443},
+		{Tag: "a-dup", InternalTag: "a-dup", Type: "vmess", Server: "10.0.0.1", ServerPort: 443},
+		{Tag: "b", InternalTag: "b", Type: "vmess", Server: "10.0.0.2", ServerPort: 443},
+	}
+
+	starts := 0
+	start := func(nodes []storage.Node) (int, *daemon.ProbeTagMap, error) {
+		starts++
+		if len(nodes) != 2 {
+			t.Fatalf("expected deduplicated nodes, got %d", len(nodes))
+		}
+		return port, nil, nil
+	}
+
+	result, err := s.performFullCheck(start, nodes, []string{"https://example.com"})
+	if err != nil {
+		t.Fatalf("perform full check: %v", err)
+	}
+	if starts != 1 {
+		t.Fatalf("probe start count mismatch: got %d, want 1", starts)
+	}
+	if len(result.Health) != 2 || len(result.Sites) != 2 {
+		t.Fatalf("result size mismatch: health=%d sites=%d, want 2 each", len(result.Health), len(result.Sites))
+	}
+	if h := result.Health["10.0.0.1:443"]; h == nil || !h.Alive {
+		t.Fatalf("expected alive health result, got %+v", h)
+	}
+	if site := result.Sites["10.0.0.2:443"]; site == nil || site.Sites["https://example.com"] != 42 {
+		t.Fatalf("expected site delay 42, got %+v", site)
+	}
+}
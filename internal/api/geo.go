@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
 	neturl "net/url"
 	"strconv"
@@ -47,6 +48,12 @@ const (
 // It uses the GeoSelector to route each request through a specific node.
 // Returns a map of "server:port" -> GeoData for nodes that were checked.
 func (s *Server) performGeoCheck(nodes []storage.Node) (map[string]*storage.GeoData, error) {
+	return s.runGeoCheck(nodes, false)
+}
+
+// runGeoCheck is performGeoCheck with control over the 24h cache: forceRefresh
+// looks up every node's egress again, even when fresh geo data is stored.
+func (s *Server) runGeoCheck(nodes []storage.Node, forceRefresh bool) (map[string]*storage.GeoData, error) {
 	if len(nodes) == 0 {
 		return map[string]*storage.GeoData{}, nil
 	}
@@ -87,7 +94,7 @@ func (s *Server) performGeoCheck(nodes []storage.Node) (map[string]*storage.GeoD
 	var nodesToCheck []storage.Node
 	for _, n := range uniqueNodes {
 		key := fmt.Sprintf("%s:%d", n.Server, n.ServerPort)
-		if existing, ok := existingGeo[key]; ok && existing.Timestamp.After(cutoff) && existing.Status == "success" {
+		if existing, ok := existingGeo[key]; ok && !forceRefresh && existing.Timestamp.After(cutoff) && existing.Status == "success" {
 			continue // fresh data, skip
 		}
 		nodesToCheck = append(nodesToCheck, n)
@@ -121,43 +128,7 @@ func (s *Server) performGeoCheck(nodes []storage.Node) (map[string]*storage.GeoD
 			}
 		}
 
-		// Switch GeoSelector to this node via Clash API
-		if err := s.clashSwitchSelector(clashPort, "GeoSelector", probeTag); err != nil {
-			logger.Printf("[geo] Failed to switch selector to %s: %v", probeTag, err)
-			failData := storage.GeoData{
-				Server:      node.Server,
-				ServerPort:  node.ServerPort,
-				NodeTag:     nodeRoutingTag(node),
-				Timestamp:   time.Now(),
-				Status:      "fail",
-				Country:     geoUnknownCountryName,
-				CountryCode: geoUnknownCountryCode,
-			}
-			if saveErr := s.store.UpsertGeoData(failData); saveErr != nil {
-				logger.Printf("[geo] Failed to save geo fail status for %s: %v", key, saveErr)
-			}
-			if saveErr := s.store.UpdateNodeCountry(node.Server, node.ServerPort, geoUnknownCountryCode, storage.GetCountryEmoji(geoUnknownCountryCode)); saveErr != nil {
-				logger.Printf("[geo] Failed to set unknown country for %s: %v", key, saveErr)
-			}
-			results[key] = &failData
-			s.eventBus.Publish("verify:geo_progress", map[string]interface{}{
-				"current":      i + 1,
-				"total":        total,
-				"tag":          nodeDisplayName(node),
-				"internal_tag": nodeRoutingTag(node),
-				"display_name": nodeDisplayName(node),
-				"source_tag":   nodeSourceTag(node),
-				"country":      geoUnknownCountryCode,
-				"status":       "fail",
-			})
-			continue
-		}
-
-		// Small delay to let the selector switch take effect
-		time.Sleep(50 * time.Millisecond)
-
-		// Make HTTP request to ip-api.com through the proxy
-		geoData, err := s.fetchGeoIP(proxyURL, node)
+		geoData, err := s.lookupEgressGeo(clashPort, proxyURL, probeTag, node)
 		if err != nil {
 			logger.Printf("[geo] GeoIP lookup failed for %s (%s): %v", nodeDisplayName(node), key, err)
 			failData := storage.GeoData{
@@ -228,6 +199,43 @@ func (s *Server) performGeoCheck(nodes []storage.Node) (map[string]*storage.GeoD
 	return results, nil
 }
 
+// lookupEgressGeo switches the probe's GeoSelector to probeTag and geolocates the
+// IP that the echo service sees through the geo proxy, i.e. the node's real egress.
+func (s *Server) lookupEgressGeo(clashPort int, proxyURL, probeTag string, node storage.Node) (*storage.GeoData, error) {
+	if err := s.clashSwitchSelector(clashPort, "GeoSelector", probeTag); err != nil {
+		return nil, fmt.Errorf("switch selector to %s: %w", probeTag, err)
+	}
+
+	// Small delay to let the selector switch take effect
+	time.Sleep(50 * time.Millisecond)
+
+	return s.fetchGeoIP(proxyURL, node)
+}
+
+// egressDiffersFromServer reports whether the egress IP is not one of the node's
+// server addresses, which means traffic leaves through a relay or CDN.
+// Unresolvable servers are reported as not differing.
+func egressDiffersFromServer(server, egressIP string, lookupHost func(string) ([]string, error)) bool {
+	egress := net.ParseIP(egressIP)
+	if egress == nil {
+		return false
+	}
+	addrs := []string{server}
+	if net.ParseIP(server) == nil {
+		resolved, err := lookupHost(server)
+		if err != nil || len(resolved) == 0 {
+			return false
+		}
+		addrs = resolved
+	}
+	for _, addr := range addrs {
+		if ip := net.ParseIP(addr); ip != nil && ip.Equal(egress) {
+			return false
+		}
+	}
+	return true
+}
+
 // clashSwitchSelector switches a selector outbound to the specified proxy via Clash API.
 func (s *Server) clashSwitchSelector(clashPort int, selectorTag, proxyTag string) error {
 	client := &http.Client{Timeout: 5 * time.Second}
@@ -265,7 +273,21 @@ func (s *Server) fetchGeoIP(proxyURL string, node storage.Node) (*storage.GeoDat
 		Transport: &http.Transport{Proxy: http.ProxyURL(proxy)},
 	}
 
-	resp, err := client.Get(geoIPURL)
+	apiResp, err := queryIPAPI(client, geoIPURL)
+	if err != nil {
+		return nil, err
+	}
+
+	geo := geoDataFromIPAPI(apiResp)
+	geo.Server = node.Server
+	geo.ServerPort = node.ServerPort
+	geo.NodeTag = nodeRoutingTag(node)
+	return geo, nil
+}
+
+// queryIPAPI requests an ip-api.com compatible endpoint with the given client.
+func queryIPAPI(client *http.Client, url string) (*ipAPIResponse, error) {
+	resp, err := client.Get(url)
 	if err != nil {
 		return nil, fmt.Errorf("request failed: %w", err)
 	}
@@ -284,11 +306,12 @@ func (s *Server) fetchGeoIP(proxyURL string, node storage.Node) (*storage.GeoDat
 	if apiResp.Status != "success" {
 		return nil, fmt.Errorf("ip-api returned fail: %s", apiResp.Message)
 	}
+	return &apiResp, nil
+}
 
+// geoDataFromIPAPI converts an ip-api.com response into a GeoData record.
+func geoDataFromIPAPI(apiResp *ipAPIResponse) *storage.GeoData {
 	return &storage.GeoData{
-		Server:      node.Server,
-		ServerPort:  node.ServerPort,
-		NodeTag:     nodeRoutingTag(node),
 		Timestamp:   time.Now(),
 		Status:      "success",
 		Country:     apiResp.Country,
@@ -304,7 +327,7 @@ func (s *Server) fetchGeoIP(proxyURL string, node storage.Node) (*storage.GeoDat
 		Org:         apiResp.Org,
 		AS:          apiResp.AS,
 		QueryIP:     apiResp.Query,
-	}, nil
+	}
 }
 
 // --- API Handlers ---
@@ -337,7 +360,18 @@ func (s *Server) getNodeGeoData(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"data": data})
 }
 
+// geoEgressMismatch is a node whose egress IP differs from its server address
+type geoEgressMismatch struct {
+	Tag         string `json:"tag"`
+	Server      string `json:"server"`
+	ServerPort  int    `json:"server_port"`
+	EgressIP    string `json:"egress_ip"`
+	CountryCode string `json:"country_code"`
+}
+
 // geoCheckNodes triggers a GeoIP check for nodes (optionally filtered by tags).
+// With ?egress=true cached results are ignored so every node's egress is looked up
+// again, and nodes exiting from an IP other than their server are listed as relayed.
 func (s *Server) geoCheckNodes(c *gin.Context) {
 	var req struct {
 		Tags []string `json:"tags"`
@@ -367,7 +401,8 @@ func (s *Server) geoCheckNodes(c *gin.Context) {
 		"total_nodes": len(nodes),
 	})
 
-	results, err := s.performGeoCheck(nodes)
+	egress := c.Query("egress") == "true"
+	results, err := s.runGeoCheck(nodes, egress)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -377,5 +412,23 @@ func (s *Server) geoCheckNodes(c *gin.Context) {
 		"checked": len(results),
 	})
 
-	c.JSON(http.StatusOK, gin.H{"data": results, "checked": len(results)})
+	response := gin.H{"data": results, "checked": len(results)}
+	if egress {
+		relayed := []geoEgressMismatch{}
+		for _, n := range dedupeNodesByEndpoint(nodes) {
+			geo, ok := results[fmt.Sprintf("%s:%d", n.Server, n.ServerPort)]
+			if !ok || geo.Status != "success" || !egressDiffersFromServer(n.Server, geo.QueryIP, net.LookupHost) {
+				continue
+			}
+			relayed = append(relayed, geoEgressMismatch{
+				Tag:         nodeDisplayName(n),
+				Server:      n.Server,
+				ServerPort:  n.ServerPort,
+				EgressIP:    geo.QueryIP,
+				CountryCode: geo.CountryCode,
+			})
+		}
+		response["relayed"] = relayed
+	}
+	c.JSON(http.StatusOK, response)
 }
@@ -0,0 +1,96 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/xiaobei/singbox-manager/internal/storage"
+)
+
+func TestLookupEgressGeo_UsesGeoSelector(t *testing.T) {
+	var switchedTo string
+	clashAPI := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut || r.URL.Path != "/proxies/GeoSelector" {
+			t.Errorf("unexpected clash API request: %s %s", r.Method, r.URL.Path)
+		}
+		var body struct {
+			Name string `json:"name"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		switchedTo = body.Name
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer clashAPI.Close()
+
+	// Plain HTTP proxy standing in for the probe's geo inbound: it answers the
+	// IP echo request itself, as the selected node's egress would.
+	var proxiedURL string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxiedURL = r.URL.String()
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"status":"success","country":"Germany","countryCode":"DE","city":"Frankfurt","query":"203.0.113.9"}`))
+	}))
+	defer proxy.Close()
+
+	_, portStr, err := net.SplitHostPort(clashAPI.Listener.Addr().String())
+	if err != nil {
+		t.Fatalf("split clash api addr: %v", err)
+	}
+	clashPort, _ := strconv.Atoi(portStr)
+
+	s := &Server{}
+	node := storage.Node{Tag: "relay", InternalTag: "relay", Type: "vmess", Server: "198.51.100.7", ServerPort: 443}
+	geo, err := s.lookupEgressGeo(clashPort, proxy.URL, "probe-relay", node)
+	if err != nil {
+		t.Fatalf("lookup egress geo: %v", err)
+	}
+
+	if switchedTo != "probe-relay" {
+		t.Fatalf("selector target mismatch: got %q, want %q", switchedTo, "probe-relay")
+	}
+	if proxiedURL != geoIPURL {
+		t.Fatalf("proxied URL mismatch: got %q, want %q", proxiedURL, geoIPURL)
+	}
+	if geo.QueryIP != "203.0.113.9" || geo.CountryCode != "DE" {
+		t.Fatalf("geo mismatch: got ip=%q country=%q, want 203.0.113.9/DE", geo.QueryIP, geo.CountryCode)
+	}
+	if geo.Server != node.Server || geo.ServerPort != node.ServerPort {
+		t.Fatalf("geo endpoint mismatch: got %s:%d", geo.Server, geo.ServerPort)
+	}
+	if !egressDiffersFromServer(node.Server, geo.QueryIP, nil) {
+		t.Fatalf("expected egress %s to differ from server %s", geo.QueryIP, node.Server)
+	}
+}
+
+func TestEgressDiffersFromServer(t *testing.T) {
+	lookup := func(host string) ([]string, error) {
+		if host == "node.example.com" {
+			return []string{"198.51.100.7", "2001:db8::7"}, nil
+		}
+		return nil, errors.New("no such host")
+	}
+
+	tests := []struct {
+		name     string
+		server   string
+		egressIP string
+		want     bool
+	}{
+		{name: "same ip", server: "198.51.100.7", egressIP: "198.51.100.7", want: false},
+		{name: "relayed ip", server: "198.51.100.7", egressIP: "203.0.113.9", want: true},
+		{name: "resolved match", server: "node.example.com", egressIP: "2001:db8::7", want: false},
+		{name: "resolved relay", server: "node.example.com", egressIP: "203.0.113.9", want: true},
+		{name: "unresolvable", server: "missing.example.com", egressIP: "203.0.113.9", want: false},
+		{name: "no egress ip", server: "198.51.100.7", egressIP: "", want: false},
+	}
+	for _, tt := range tests {
+		if got := egressDiffersFromServer(tt.server, tt.egressIP, lookup); got != tt.want {
+			t.Fatalf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package api
+
+import (
+	"compress/gzip"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+// gzipStreamingPaths lists route prefixes that must never be compressed (SSE / WebSocket)
+var gzipStreamingPaths = []string{
+	"/api/events/stream",
+	"/api/monitoring/ws/",
+}
+
+var gzipWriterPool = sync.Pool{
+	New: func() interface{} {
+		gz, _ := gzip.NewWriterLevel(nil, gzip.DefaultCompression)
+		return gz
+	},
+}
+
+// gzipResponseWriter sends the response body through a gzip writer
+type gzipResponseWriter struct {
+	gin.ResponseWriter
+	gz *gzip.Writer
+}
+
+func (w *gzipResponseWriter) WriteHeader(code int) {
+	w.Header().Del("Content-Length")
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *gzipResponseWriter) Write(data []byte) (int, error) {
+	w.Header().Del("Content-Length")
+	return w.gz.Write(data)
+}
+
+func (w *gzipResponseWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+func (w *gzipResponseWriter) Flush() {
+	_ = w.gz.Flush()
+	w.ResponseWriter.Flush()
+}
+
+// gzipMiddleware compresses responses for clients that send Accept-Encoding: gzip.
+// Streaming routes and websocket upgrades are passed through untouched.
+func gzipMiddleware(excludedPrefixes []string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !shouldGzip(c, excludedPrefixes) {
+			c.Next()
+			return
+		}
+
+		gz := gzipWriterPool.Get().(*gzip.Writer)
+		gz.Reset(c.Writer)
+		defer func() {
+			_ = gz.Close()
+			gz.Reset(nil)
+			gzipWriterPool.Put(gz)
+		}()
+
+		c.Header("Content-Encoding", "gzip")
+		c.Header("Vary", "Accept-Encoding")
+		c.Writer = &gzipResponseWriter{ResponseWriter: c.Writer, gz: gz}
+		c.Next()
+	}
+}
+
+func shouldGzip(c *gin.Context, excludedPrefixes []string) bool {
+	req := c.Request
+	if !strings.Contains(req.Header.Get("Accept-Encoding"), "gzip") {
+		return false
+	}
+	if strings.EqualFold(req.Header.Get("Upgrade"), "websocket") {
+		return false
+	}
+	if strings.Contains(req.Header.Get("Accept"), "text/event-stream") {
+		return false
+	}
+	for _, prefix := range excludedPrefixes {
+		if strings.HasPrefix(req.URL.Path, prefix) {
+			return false
+		}
+	}
+	return true
+}
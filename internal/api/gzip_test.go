@@ -0,0 +1,90 @@
+package api
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func newGzipTestRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(gzipMiddleware(gzipStreamingPaths))
+	r.GET("/api/nodes", func(c *gin.Context) {
+		nodes := make([]gin.H, 0, 500)
+		for i := 0; i < 500; i++ {
+			nodes = append(nodes, gin.H{"tag": "node", "server": "example.com", "server_port": 443, "index": i})
+		}
+		c.JSON(http.StatusOK, gin.H{"data": nodes})
+	})
+	r.GET("/api/events/stream", func(c *gin.Context) {
+		c.Writer.Header().Set("Content-Type", "text/event-stream")
+		c.SSEvent("ping", "connected")
+		c.Writer.Flush()
+	})
+	return r
+}
+
+func TestGzipMiddleware_CompressesLargeJSON(t *testing.T) {
+	r := newGzipTestRouter()
+
+	req := httptest.NewRequest(http.MethodGet, "/api/nodes", nil)
+	req.Header.Set("Accept-Encoding", "gzip, deflate")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if got := w.Header().Get("Content-Encoding"); got != "gzip" {
+		t.Fatalf("content encoding mismatch: got %q, want gzip", got)
+	}
+	compressedSize := w.Body.Len()
+
+	gz, err := gzip.NewReader(w.Body)
+	if err != nil {
+		t.Fatalf("open gzip body: %v", err)
+	}
+	body, err := io.ReadAll(gz)
+	if err != nil {
+		t.Fatalf("read gzip body: %v", err)
+	}
+	var decoded struct {
+		Data []map[string]interface{} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &decoded); err != nil {
+		t.Fatalf("decode body: %v", err)
+	}
+	if len(decoded.Data) != 500 {
+		t.Fatalf("data length mismatch: got %d, want 500", len(decoded.Data))
+	}
+	if compressedSize >= len(body) {
+		t.Fatalf("expected compressed body smaller than %d bytes, got %d", len(body), compressedSize)
+	}
+
+	// Clients without gzip support get plain JSON
+	plain := httptest.NewRecorder()
+	r.ServeHTTP(plain, httptest.NewRequest(http.MethodGet, "/api/nodes", nil))
+	if got := plain.Header().Get("Content-Encoding"); got != "" {
+		t.Fatalf("expected no content encoding without Accept-Encoding, got %q", got)
+	}
+}
+
+func TestGzipMiddleware_SkipsSSE(t *testing.T) {
+	r := newGzipTestRouter()
+
+	req := httptest.NewRequest(http.MethodGet, "/api/events/stream", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if got := w.Header().Get("Content-Encoding"); got != "" {
+		t.Fatalf("expected SSE response to stay uncompressed, got %q", got)
+	}
+	if !strings.Contains(w.Body.String(), "event:ping") {
+		t.Fatalf("expected raw SSE body, got %q", w.Body.String())
+	}
+}
@@ -0,0 +1,56 @@
+package api
+
+import (
+	"strings"
+
+	"github.com/xiaobei/singbox-manager/internal/storage"
+)
+
+// healthCheckScope narrows a bulk health check to a subset of nodes.
+// Empty fields do not filter; all non-empty fields must match.
+type healthCheckScope struct {
+	Tags    []string
+	Country string
+	Group   string
+}
+
+// scopeHealthCheckNodes resolves the nodes a scoped health check should cover.
+func scopeHealthCheckNodes(store storage.Store, allNodes []storage.Node, scope healthCheckScope) []storage.Node {
+	tagSet := parseTagSet(scope.Tags)
+	country := strings.TrimSpace(scope.Country)
+	group := strings.TrimSpace(scope.Group)
+
+	var groupTags map[string]struct{}
+	if group != "" {
+		groupTags = make(map[string]struct{})
+		for _, status := range []storage.NodeStatus{
+			storage.NodeStatusVerified,
+			storage.NodeStatusPending,
+			storage.NodeStatusArchived,
+		} {
+			for _, node := range store.GetNodes(status) {
+				if node.GroupTag == group {
+					groupTags[unifiedRoutingTag(node)] = struct{}{}
+				}
+			}
+		}
+		if len(groupTags) == 0 {
+			return nil
+		}
+	}
+
+	var nodes []storage.Node
+	for _, n := range allNodes {
+		if !nodeMatchesAnyTag(n, tagSet) {
+			continue
+		}
+		if country != "" && !strings.EqualFold(n.Country, country) {
+			continue
+		}
+		if groupTags != nil && !nodeMatchesAnyTag(n, groupTags) {
+			continue
+		}
+		nodes = append(nodes, n)
+	}
+	return nodes
+}
@@ -0,0 +1,54 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/xiaobei/singbox-manager/internal/storage"
+)
+
+func TestScopeHealthCheckNodes(t *testing.T) {
+	store, err := storage.NewSQLiteStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("create sqlite store: %v", err)
+	}
+	t.Cleanup(func() { _ = store.Close() })
+
+	for _, n := range []storage.UnifiedNode{
+		{Tag: "hk-1", Type: "vmess", Server: "10.0.0.1", ServerPort: 443, Country: "HK", GroupTag: "premium", Status: storage.NodeStatusVerified},
+		{Tag: "hk-2", Type: "vmess", Server: "10.0.0.2", ServerPort: 443, Country: "HK", Status: storage.NodeStatusPending},
+		{Tag: "jp-1", Type: "vmess", Server: "10.0.0.3", ServerPort: 443, Country: "JP", GroupTag: "premium", Status: storage.NodeStatusVerified},
+	} {
+		if _, err := store.AddNode(n); err != nil {
+			t.Fatalf("insert node %s: %v", n.Tag, err)
+		}
+	}
+	allNodes := store.GetAllNodesIncludeDisabled()
+
+	tests := []struct {
+		name  string
+		scope healthCheckScope
+		want  []string
+	}{
+		{name: "all", scope: healthCheckScope{}, want: []string{"hk-1", "hk-2", "jp-1"}},
+		{name: "country", scope: healthCheckScope{Country: "hk"}, want: []string{"hk-1", "hk-2"}},
+		{name: "group", scope: healthCheckScope{Group: "premium"}, want: []string{"hk-1", "jp-1"}},
+		{name: "country and group", scope: healthCheckScope{Country: "HK", Group: "premium"}, want: []string{"hk-1"}},
+		{name: "unknown group", scope: healthCheckScope{Group: "missing"}, want: nil},
+	}
+
+	for _, tt := range tests {
+		got := scopeHealthCheckNodes(store, allNodes, tt.scope)
+		gotTags := make(map[string]bool, len(got))
+		for _, n := range got {
+			gotTags[n.Tag] = true
+		}
+		if len(got) != len(tt.want) {
+			t.Fatalf("%s: node count mismatch: got %d, want %d", tt.name, len(got), len(tt.want))
+		}
+		for _, tag := range tt.want {
+			if !gotTags[tag] {
+				t.Fatalf("%s: expected %s in scoped nodes, got %v", tt.name, tag, gotTags)
+			}
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package api
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/xiaobei/singbox-manager/internal/logger"
+	"github.com/xiaobei/singbox-manager/internal/storage"
+)
+
+const healthWebhookTimeout = 10 * time.Second
+
+// healthWebhookSignatureHeader carries the hex HMAC-SHA256 of the request body
+const healthWebhookSignatureHeader = "X-Signature-256"
+
+// HealthWebhookPayload is the JSON summary posted to Settings.WebhookURL
+type HealthWebhookPayload struct {
+	Event      string   `json:"event"` // "verification" or "health_check"
+	Timestamp  string   `json:"timestamp"`
+	Checked    int      `json:"checked"`
+	Alive      int      `json:"alive"`
+	NewlyDead  []string `json:"newly_dead"`
+	NewlyAlive []string `json:"newly_alive"`
+	Promoted   int      `json:"promoted"`
+	Demoted    int      `json:"demoted"`
+	Archived   int      `json:"archived"`
+}
+
+// validateWebhookURL accepts an empty URL (disabled) or an absolute http(s) URL
+func validateWebhookURL(raw string) error {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return nil
+	}
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("webhook_url must be an http or https URL")
+	}
+	return nil
+}
+
+// latestAliveByEndpoint returns the last recorded alive state per server:port
+func (s *Server) latestAliveByEndpoint() map[string]bool {
+	measurements, err := s.store.GetLatestHealthMeasurements()
+	if err != nil {
+		logger.Printf("[webhook] Failed to load previous health state: %v", err)
+		return nil
+	}
+	state := make(map[string]bool, len(measurements))
+	for _, m := range measurements {
+		state[fmt.Sprintf("%s:%d", m.Server, m.ServerPort)] = m.Alive
+	}
+	return state
+}
+
+// healthTransitions lists nodes whose alive state flipped compared to the previous check.
+// Nodes without a previous measurement are not reported.
+func healthTransitions(previous map[string]bool, nodes []storage.Node, results map[string]*NodeHealthResult) (newlyDead, newlyAlive []string) {
+	newlyDead, newlyAlive = []string{}, []string{}
+	for _, n := range dedupeNodesByEndpoint(nodes) {
+		key := fmt.Sprintf("%s:%d", n.Server, n.ServerPort)
+		wasAlive, known := previous[key]
+		result, checked := results[key]
+		if !known || !checked {
+			continue
+		}
+		switch {
+		case wasAlive && !result.Alive:
+			newlyDead = append(newlyDead, nodeDisplayName(n))
+		case !wasAlive && result.Alive:
+			newlyAlive = append(newlyAlive, nodeDisplayName(n))
+		}
+	}
+	return newlyDead, newlyAlive
+}
+
+// countAlive counts alive entries in a health check result
+func countAlive(results map[string]*NodeHealthResult) int {
+	alive := 0
+	for _, r := range results {
+		if r != nil && r.Alive {
+			alive++
+		}
+	}
+	return alive
+}
+
+// notifyHealthWebhook posts payload to the configured webhook in the background.
+// Delivery failures are only logged so checks are never blocked.
+func (s *Server) notifyHealthWebhook(payload HealthWebhookPayload) {
+	if payload.Timestamp == "" {
+		payload.Timestamp = time.Now().Format(time.RFC3339)
+	}
+	s.notifyWebhook(payload)
+}
+
+// notifyWebhook posts any JSON payload to the configured webhook in the background
+func (s *Server) notifyWebhook(payload interface{}) {
+	settings := s.store.GetSettings()
+	webhookURL := strings.TrimSpace(settings.WebhookURL)
+	if webhookURL == "" {
+		return
+	}
+	secret := settings.WebhookSecret
+
+	go func() {
+		client := &http.Client{Timeout: healthWebhookTimeout}
+		if err := postHealthWebhook(client, webhookURL, secret, payload); err != nil {
+			logger.Printf("[webhook] Delivery failed: %v", err)
+		}
+	}()
+}
+
+// postHealthWebhook sends payload as JSON, signing the body when secret is set
+func postHealthWebhook(client *http.Client, webhookURL, secret string, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, webhookURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "singbox-manager")
+	if secret != "" {
+		req.Header.Set(healthWebhookSignatureHeader, "sha256="+signWebhookBody(secret, body))
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned HTTP %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// signWebhookBody returns the hex HMAC-SHA256 of body keyed by secret
+func signWebhookBody(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
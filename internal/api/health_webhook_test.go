@@ -0,0 +1,99 @@
+package api
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/xiaobei/singbox-manager/internal/storage"
+)
+
+func TestNotifyHealthWebhook_SignedPayload(t *testing.T) {
+	type delivery struct {
+		body      []byte
+		signature string
+	}
+	received := make(chan delivery, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received <- delivery{body: body, signature: r.Header.Get(healthWebhookSignatureHeader)}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	store, err := storage.NewSQLiteStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("create sqlite store: %v", err)
+	}
+	t.Cleanup(func() { _ = store.Close() })
+
+	settings := store.GetSettings()
+	settings.WebhookURL = srv.URL
+	settings.WebhookSecret = "s3cret"
+	if err := store.UpdateSettings(settings); err != nil {
+		t.Fatalf("update settings: %v", err)
+	}
+
+	s := &Server{store: store}
+	s.notifyHealthWebhook(HealthWebhookPayload{
+		Event:      "verification",
+		Checked:    3,
+		Alive:      2,
+		NewlyDead:  []string{"hk-1"},
+		NewlyAlive: []string{},
+		Promoted:   1,
+	})
+
+	var got delivery
+	select {
+	case got = <-received:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("webhook was not delivered")
+	}
+
+	if want := "sha256=" + signWebhookBody("s3cret", got.body); got.signature != want {
+		t.Fatalf("signature mismatch: got %q, want %q", got.signature, want)
+	}
+	var payload HealthWebhookPayload
+	if err := json.Unmarshal(got.body, &payload); err != nil {
+		t.Fatalf("decode payload: %v", err)
+	}
+	if payload.Event != "verification" || payload.Checked != 3 || payload.Alive != 2 || payload.Promoted != 1 {
+		t.Fatalf("payload mismatch: got %+v", payload)
+	}
+	if !reflect.DeepEqual(payload.NewlyDead, []string{"hk-1"}) || payload.Timestamp == "" {
+		t.Fatalf("payload mismatch: got %+v", payload)
+	}
+}
+
+func TestHealthTransitions(t *testing.T) {
+	nodes := []storage.Node{
+		{Tag: "was-alive", Server: "10.0.0.1", ServerPort: 443},
+		{Tag: "was-dead", Server: "10.0.0.2", ServerPort: 443},
+		{Tag: "unchanged", Server: "10.0.0.3", ServerPort: 443},
+		{Tag: "new", Server: "10.0.0.4", ServerPort: 443},
+	}
+	previous := map[string]bool{
+		"10.0.0.1:443": true,
+		"10.0.0.2:443": false,
+		"10.0.0.3:443": true,
+	}
+	results := map[string]*NodeHealthResult{
+		"10.0.0.1:443": {Alive: false},
+		"10.0.0.2:443": {Alive: true},
+		"10.0.0.3:443": {Alive: true},
+		"10.0.0.4:443": {Alive: true},
+	}
+
+	newlyDead, newlyAlive := healthTransitions(previous, nodes, results)
+	if !reflect.DeepEqual(newlyDead, []string{"was-alive"}) {
+		t.Fatalf("newly dead mismatch: got %v", newlyDead)
+	}
+	if !reflect.DeepEqual(newlyAlive, []string{"was-dead"}) {
+		t.Fatalf("newly alive mismatch: got %v", newlyAlive)
+	}
+}
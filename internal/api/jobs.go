@@ -0,0 +1,159 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/xiaobei/singbox-manager/internal/logger"
+)
+
+// jobTTL is how long a finished job stays queryable
+const jobTTL = 15 * time.Minute
+
+// Job statuses
+const (
+	JobStatusPending = "pending"
+	JobStatusRunning = "running"
+	JobStatusDone    = "done"
+	JobStatusFailed  = "failed"
+)
+
+// Job is a long-running operation started by an async request
+type Job struct {
+	ID         string      `json:"job_id"`
+	Kind       string      `json:"kind"`
+	Status     string      `json:"status"`
+	Done       int         `json:"done"`
+	Total      int         `json:"total"`
+	Result     interface{} `json:"result,omitempty"`
+	Error      string      `json:"error,omitempty"`
+	CreatedAt  time.Time   `json:"created_at"`
+	StartedAt  *time.Time  `json:"started_at,omitempty"`
+	FinishedAt *time.Time  `json:"finished_at,omitempty"`
+}
+
+// jobProgress lets a running job report how much of its work is done
+type jobProgress func(done, total int)
+
+// jobFunc is the work of a job. Its result becomes the job result.
+type jobFunc func(progress jobProgress) (interface{}, error)
+
+// jobRegistry keeps jobs in memory and drops finished ones after the TTL.
+type jobRegistry struct {
+	ttl time.Duration
+	now func() time.Time
+
+	mu   sync.Mutex
+	jobs map[string]*Job
+}
+
+func newJobRegistry(ttl time.Duration) *jobRegistry {
+	return &jobRegistry{ttl: ttl, now: time.Now, jobs: make(map[string]*Job)}
+}
+
+// Start registers a pending job and runs it in the background, returning its initial snapshot.
+func (r *jobRegistry) Start(kind string, run jobFunc) Job {
+	r.mu.Lock()
+	r.pruneLocked()
+	job := &Job{ID: uuid.New().String(), Kind: kind, Status: JobStatusPending, CreatedAt: r.now()}
+	r.jobs[job.ID] = job
+	snapshot := *job
+	r.mu.Unlock()
+
+	go r.execute(job, run)
+	return snapshot
+}
+
+// Get returns a snapshot of the job, or false when it is unknown or expired
+func (r *jobRegistry) Get(id string) (Job, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.pruneLocked()
+	job, ok := r.jobs[id]
+	if !ok {
+		return Job{}, false
+	}
+	return *job, true
+}
+
+func (r *jobRegistry) execute(job *Job, run jobFunc) {
+	r.update(job, func(j *Job) {
+		started := r.now()
+		j.Status = JobStatusRunning
+		j.StartedAt = &started
+	})
+
+	result, err := func() (result interface{}, err error) {
+		defer func() {
+			if p := recover(); p != nil {
+				err = fmt.Errorf("job panicked: %v", p)
+			}
+		}()
+		return run(func(done, total int) {
+			r.update(job, func(j *Job) {
+				j.Done = done
+				j.Total = total
+			})
+		})
+	}()
+
+	r.update(job, func(j *Job) {
+		finished := r.now()
+		j.FinishedAt = &finished
+		if err != nil {
+			j.Status = JobStatusFailed
+			j.Error = err.Error()
+			logger.Printf("[jobs] %s %s failed: %v", j.Kind, j.ID, err)
+			return
+		}
+		j.Status = JobStatusDone
+		j.Result = result
+	})
+}
+
+func (r *jobRegistry) update(job *Job, fn func(j *Job)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	fn(job)
+}
+
+// pruneLocked drops jobs that finished more than ttl ago. Callers must hold r.mu.
+func (r *jobRegistry) pruneLocked() {
+	cutoff := r.now().Add(-r.ttl)
+	for id, job := range r.jobs {
+		if job.FinishedAt != nil && job.FinishedAt.Before(cutoff) {
+			delete(r.jobs, id)
+		}
+	}
+}
+
+// isAsyncRequest reports whether the client asked for a job instead of a blocking response
+func isAsyncRequest(c *gin.Context) bool {
+	return c.Query("async") == "true"
+}
+
+// respondWithJob starts run as a job and answers 202 with its id. The job holds the
+// store read lock like a regular request so a database import cannot swap the store
+// underneath it.
+func (s *Server) respondWithJob(c *gin.Context, kind string, run jobFunc) {
+	job := s.jobs.Start(kind, func(progress jobProgress) (interface{}, error) {
+		s.storeSwapMu.RLock()
+		defer s.storeSwapMu.RUnlock()
+		return run(progress)
+	})
+	c.JSON(http.StatusAccepted, gin.H{"job_id": job.ID, "data": job})
+}
+
+// getJob reports the status, progress and result of an async job
+func (s *Server) getJob(c *gin.Context) {
+	job, ok := s.jobs.Get(c.Param("id"))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "job not found or expired"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": job})
+}
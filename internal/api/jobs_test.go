@@ -0,0 +1,73 @@
+package api
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func waitForJobStatus(t *testing.T, r *jobRegistry, id, status string) Job {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		job, ok := r.Get(id)
+		if !ok {
+			t.Fatalf("job %s not found", id)
+		}
+		if job.Status == status {
+			return job
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	job, _ := r.Get(id)
+	t.Fatalf("job status mismatch: got %q, want %q", job.Status, status)
+	return Job{}
+}
+
+func TestJobRegistry_PendingRunningDone(t *testing.T) {
+	r := newJobRegistry(time.Minute)
+	started := make(chan struct{})
+	release := make(chan struct{})
+
+	job := r.Start("health_check", func(progress jobProgress) (interface{}, error) {
+		progress(1, 3)
+		close(started)
+		<-release
+		progress(3, 3)
+		return "ok", nil
+	})
+	if job.Status != JobStatusPending || job.ID == "" {
+		t.Fatalf("expected a pending job with an id, got %+v", job)
+	}
+
+	<-started
+	running := waitForJobStatus(t, r, job.ID, JobStatusRunning)
+	if running.StartedAt == nil || running.Done != 1 || running.Total != 3 {
+		t.Fatalf("running job mismatch: got %+v", running)
+	}
+
+	close(release)
+	done := waitForJobStatus(t, r, job.ID, JobStatusDone)
+	if done.Result != "ok" || done.Done != 3 || done.FinishedAt == nil || done.Error != "" {
+		t.Fatalf("finished job mismatch: got %+v", done)
+	}
+}
+
+func TestJobRegistry_FailureAndExpiry(t *testing.T) {
+	r := newJobRegistry(time.Minute)
+	now := time.Now()
+	r.now = func() time.Time { return now }
+
+	job := r.Start("unsupported_recheck", func(jobProgress) (interface{}, error) {
+		return nil, errors.New("kernel missing")
+	})
+	failed := waitForJobStatus(t, r, job.ID, JobStatusFailed)
+	if failed.Error != "kernel missing" {
+		t.Fatalf("error mismatch: got %q, want %q", failed.Error, "kernel missing")
+	}
+
+	now = now.Add(2 * time.Minute)
+	if _, ok := r.Get(job.ID); ok {
+		t.Fatal("expected finished job to expire after the TTL")
+	}
+}
@@ -0,0 +1,34 @@
+package api
+
+import (
+	"os/exec"
+
+	"github.com/xiaobei/singbox-manager/internal/kernel"
+)
+
+// kernelInstallImpact validates the current config with a downloaded, not yet installed
+// kernel and lists the nodes it would newly reject, so a downgrade cannot silently drop
+// nodes. Feature flags follow the downloaded kernel's version, not the installed one.
+func (s *Server) kernelInstallImpact(binaryPath string) ([]kernel.InstallImpact, error) {
+	features := kernelFeaturesFor(kernel.Version{}, false)
+	if output, err := exec.Command(binaryPath, "version").Output(); err == nil {
+		if version, err := kernel.ParseVersion(string(output)); err == nil {
+			features = kernelFeaturesFor(version, true)
+		}
+	}
+
+	_, rejected, _, err := s.checkConfigWithKernel(binaryPath, features)
+	if err != nil {
+		return nil, err
+	}
+
+	impact := make([]kernel.InstallImpact, 0, len(rejected))
+	for _, info := range rejected {
+		name := info.DisplayName
+		if name == "" {
+			name = info.Tag
+		}
+		impact = append(impact, kernel.InstallImpact{Name: name, Error: info.Error})
+	}
+	return impact, nil
+}
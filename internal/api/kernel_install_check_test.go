@@ -0,0 +1,77 @@
+package api
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/xiaobei/singbox-manager/internal/builder"
+	"github.com/xiaobei/singbox-manager/internal/daemon"
+	"github.com/xiaobei/singbox-manager/internal/storage"
+)
+
+func TestKernelInstallImpact_DowngradeFlagsNewProtocolNodes(t *testing.T) {
+	dir := t.TempDir()
+	store, err := storage.NewSQLiteStore(dir)
+	if err != nil {
+		t.Fatalf("create sqlite store: %v", err)
+	}
+	t.Cleanup(func() { _ = store.Close() })
+
+	for _, n := range []storage.UnifiedNode{
+		{Tag: "trojan-1", InternalTag: "trojan-1", Type: "trojan", Server: "10.0.0.1", ServerPort: 443,
+			Extra: map[string]interface{}{"password": "secret"}, Status: storage.NodeStatusVerified},
+		{Tag: "any-1", InternalTag: "any-1", Type: "anytls", Server: "10.0.0.2", ServerPort: 443,
+			Extra: map[string]interface{}{"password": "secret"}, Status: storage.NodeStatusVerified},
+	} {
+		if _, err := store.AddNode(n); err != nil {
+			t.Fatalf("insert node %s: %v", n.Tag, err)
+		}
+	}
+
+	_, indexToTag, _, err := builder.NewConfigBuilder(store.GetSettings(), store.GetAllNodes(), store.GetFilters()).BuildJSONWithNodeMap()
+	if err != nil {
+		t.Fatalf("build config: %v", err)
+	}
+	anyIndex := -1
+	for idx, tag := range indexToTag {
+		if tag == "any-1" {
+			anyIndex = idx
+		}
+	}
+	if anyIndex < 0 {
+		t.Fatalf("anytls node missing from config: %v", indexToTag)
+	}
+
+	// Fake sing-box 1.11: it predates anytls and rejects that outbound type
+	oldKernel := filepath.Join(dir, "sing-box-old")
+	script := "#!/bin/sh\n" +
+		"if [ \"$1\" = version ]; then echo 'sing-box version 1.11.0'; exit 0; fi\n" +
+		"if grep -q '\"anytls\"' \"$3\"; then\n" +
+		fmt.Sprintf("  echo 'FATAL[0000] decode config: outbounds[%d].type: unknown outbound type: anytls' >&2; exit 1\n", anyIndex) +
+		"fi\n" +
+		"exit 0\n"
+	if err := os.WriteFile(oldKernel, []byte(script), 0755); err != nil {
+		t.Fatalf("write fake sing-box: %v", err)
+	}
+
+	s := &Server{
+		store:            store,
+		processManager:   daemon.NewProcessManager(filepath.Join(dir, "missing-sing-box"), filepath.Join(dir, "config.json"), dir),
+		unsupportedNodes: map[string]UnsupportedNodeInfo{},
+	}
+
+	impact, err := s.kernelInstallImpact(oldKernel)
+	if err != nil {
+		t.Fatalf("install impact: %v", err)
+	}
+	if len(impact) != 1 || impact[0].Name != "any-1" || impact[0].Error == "" {
+		t.Fatalf("impact mismatch: got %+v, want only any-1", impact)
+	}
+
+	// The check only reports, it must not mark nodes unsupported for the installed kernel
+	if len(s.unsupportedNodes) != 0 || len(store.GetUnsupportedNodes()) != 0 {
+		t.Fatalf("expected no unsupported nodes recorded, got %v", s.unsupportedNodes)
+	}
+}
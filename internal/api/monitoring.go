@@ -7,6 +7,7 @@ import (
 	"crypto/sha1"
 	"encoding/base64"
 	"encoding/binary"
+	"encoding/csv"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -181,16 +182,12 @@ type monitoringNodeMeta struct {
 }
 
 func (s *Server) startTrafficAggregator() {
+	s.trafficTicker = newTrafficAggregatorTicker(trafficSampleInterval(s.store.GetSettings()))
 	go func() {
 		// Small startup delay to avoid noisy errors while the app boots.
 		time.Sleep(2 * time.Second)
 
-		ticker := time.NewTicker(2 * time.Second)
-		defer ticker.Stop()
-
-		for range ticker.C {
-			s.collectAndPersistTrafficSample()
-		}
+		s.trafficTicker.run(s.collectAndPersistTrafficSample, nil)
 	}()
 }
 
@@ -238,7 +235,9 @@ func (s *Server) collectAndPersistTrafficSample() {
 
 	if _, err := s.store.AddTrafficSample(sample, clients, resources); err != nil {
 		logger.Printf("[monitoring] failed to persist traffic sample: %v", err)
+		return
 	}
+	s.checkClientQuotaAlerts(time.Now())
 }
 
 func (s *Server) computeTrafficRates(uploadTotal, downloadTotal int64, now time.Time) (int64, int64) {
@@ -793,6 +792,60 @@ func (s *Server) getMonitoringHistory(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"data": samples})
 }
 
+// monitoringCSVHeader lists the columns of the traffic history CSV export
+var monitoringCSVHeader = []string{"timestamp", "up_bps", "down_bps", "connections", "clients", "memory"}
+
+// exportMonitoringHistoryCSV streams the raw traffic samples of the last hours
+// (24 by default) as CSV for spreadsheets and external dashboards.
+func (s *Server) exportMonitoringHistoryCSV(c *gin.Context) {
+	hours := 24
+	if raw := strings.TrimSpace(c.Query("hours")); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "hours must be a positive integer"})
+			return
+		}
+		hours = parsed
+	}
+	if hours > 24*30 {
+		hours = 24 * 30
+	}
+	since := time.Now().Add(-time.Duration(hours) * time.Hour)
+
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", "attachment; filename=traffic-history.csv")
+	c.Status(http.StatusOK)
+
+	w := csv.NewWriter(c.Writer)
+	if err := w.Write(monitoringCSVHeader); err != nil {
+		return
+	}
+	rowsWritten := 0
+	err := s.store.EachTrafficSampleSince(since, func(sample storage.TrafficSample) error {
+		if err := w.Write([]string{
+			sample.Timestamp.UTC().Format(time.RFC3339),
+			strconv.FormatInt(sample.UpBps, 10),
+			strconv.FormatInt(sample.DownBps, 10),
+			strconv.Itoa(sample.ActiveConnections),
+			strconv.Itoa(sample.ClientCount),
+			strconv.FormatInt(sample.MemoryInuse, 10),
+		}); err != nil {
+			return err
+		}
+		rowsWritten++
+		if rowsWritten%500 == 0 {
+			w.Flush()
+			c.Writer.Flush()
+		}
+		return w.Error()
+	})
+	if err != nil {
+		// Headers are already sent, so the export just ends early
+		logger.Printf("[monitoring] CSV export stopped after %d rows: %v", rowsWritten, err)
+	}
+	w.Flush()
+}
+
 func (s *Server) getMonitoringClients(c *gin.Context) {
 	limit := 200
 	if raw := strings.TrimSpace(c.Query("limit")); raw != "" {
@@ -1009,7 +1062,7 @@ func (s *Server) getMonitoringClientResourcesHistory(c *gin.Context) {
 }
 
 func (s *Server) streamTrafficWebSocket(c *gin.Context) {
-	s.proxyClashWebSocket(c, "/traffic", "")
+	s.proxyClashWebSocket(c, "/traffic", "", nil)
 }
 
 func (s *Server) streamConnectionsWebSocket(c *gin.Context) {
@@ -1025,10 +1078,58 @@ func (s *Server) streamConnectionsWebSocket(c *gin.Context) {
 	if intervalMs > 10000 {
 		intervalMs = 10000
 	}
-	s.proxyClashWebSocket(c, "/connections", fmt.Sprintf("interval=%d", intervalMs))
+	var transform func([]byte) ([]byte, error)
+	if sourceIP := strings.TrimSpace(c.Query("source_ip")); sourceIP != "" {
+		transform = func(payload []byte) ([]byte, error) {
+			return filterConnectionsFrame(payload, sourceIP)
+		}
+	}
+	s.proxyClashWebSocket(c, "/connections", fmt.Sprintf("interval=%d", intervalMs), transform)
 }
 
-func (s *Server) proxyClashWebSocket(c *gin.Context, path, rawQuery string) {
+// filterConnectionsFrame keeps only connections from sourceIP in a Clash /connections frame
+// and recomputes the upload/download totals for the remaining subset.
+func filterConnectionsFrame(payload []byte, sourceIP string) ([]byte, error) {
+	var frame map[string]json.RawMessage
+	if err := json.Unmarshal(payload, &frame); err != nil {
+		return nil, fmt.Errorf("parse connections frame: %w", err)
+	}
+
+	var rawConnections []json.RawMessage
+	if raw, ok := frame["connections"]; ok && !bytes.Equal(bytes.TrimSpace(raw), []byte("null")) {
+		if err := json.Unmarshal(raw, &rawConnections); err != nil {
+			return nil, fmt.Errorf("parse connections: %w", err)
+		}
+	}
+
+	filtered := make([]json.RawMessage, 0, len(rawConnections))
+	var uploadTotal, downloadTotal int64
+	for _, raw := range rawConnections {
+		var conn clashConnection
+		if err := json.Unmarshal(raw, &conn); err != nil {
+			continue
+		}
+		if strings.TrimSpace(conn.Metadata.SourceIP) != sourceIP {
+			continue
+		}
+		filtered = append(filtered, raw)
+		uploadTotal += maxI64(conn.Upload, 0)
+		downloadTotal += maxI64(conn.Download, 0)
+	}
+
+	connectionsJSON, err := json.Marshal(filtered)
+	if err != nil {
+		return nil, err
+	}
+	frame["connections"] = connectionsJSON
+	frame["uploadTotal"] = json.RawMessage(strconv.FormatInt(uploadTotal, 10))
+	frame["downloadTotal"] = json.RawMessage(strconv.FormatInt(downloadTotal, 10))
+	return json.Marshal(frame)
+}
+
+// proxyClashWebSocket relays a Clash API websocket to the client.
+// When transform is set, every complete message is passed through it before forwarding.
+func (s *Server) proxyClashWebSocket(c *gin.Context, path, rawQuery string, transform func([]byte) ([]byte, error)) {
 	if !s.processManager.IsRunning() {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "sing-box is not running"})
 		return
@@ -1054,6 +1155,18 @@ func (s *Server) proxyClashWebSocket(c *gin.Context, path, rawQuery string) {
 	}
 	defer upstreamWS.Close()
 
+	forward := func(payload []byte) error {
+		if transform != nil {
+			transformed, err := transform(payload)
+			if err != nil {
+				logger.Printf("[monitoring] ws frame transform error (%s): %v", path, err)
+				return nil
+			}
+			payload = transformed
+		}
+		return downstreamWS.WriteText(payload)
+	}
+
 	var fragmentedOpcode byte
 	var fragmentedPayload []byte
 
@@ -1069,7 +1182,7 @@ func (s *Server) proxyClashWebSocket(c *gin.Context, path, rawQuery string) {
 		switch opcode {
 		case wsOpcodeText, wsOpcodeBinary:
 			if fin {
-				if err := downstreamWS.WriteText(payload); err != nil {
+				if err := forward(payload); err != nil {
 					return
 				}
 				continue
@@ -1082,7 +1195,7 @@ func (s *Server) proxyClashWebSocket(c *gin.Context, path, rawQuery string) {
 			}
 			fragmentedPayload = append(fragmentedPayload, payload...)
 			if fin {
-				if err := downstreamWS.WriteText(fragmentedPayload); err != nil {
+				if err := forward(fragmentedPayload); err != nil {
 					return
 				}
 				fragmentedOpcode = 0
@@ -0,0 +1,63 @@
+package api
+
+import (
+	"encoding/csv"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xiaobei/singbox-manager/internal/storage"
+)
+
+func TestExportMonitoringHistoryCSV_HeaderAndRows(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	store, err := storage.NewSQLiteStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("create sqlite store: %v", err)
+	}
+	t.Cleanup(func() { _ = store.Close() })
+
+	recent := time.Now().Add(-time.Hour).UTC().Truncate(time.Second)
+	for _, sample := range []storage.TrafficSample{
+		{Timestamp: time.Now().Add(-48 * time.Hour), UpBps: 1, DownBps: 1},
+		{Timestamp: recent, UpBps: 1200, DownBps: 34000, ActiveConnections: 7, ClientCount: 2, MemoryInuse: 52428800},
+	} {
+		if _, err := store.AddTrafficSample(sample, nil, nil); err != nil {
+			t.Fatalf("add traffic sample: %v", err)
+		}
+	}
+
+	s := &Server{store: store}
+	router := gin.New()
+	router.GET("/monitoring/history.csv", s.exportMonitoringHistoryCSV)
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/monitoring/history.csv?hours=24", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status mismatch: got %d, want %d", rec.Code, http.StatusOK)
+	}
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/csv") {
+		t.Fatalf("content type mismatch: got %q, want text/csv", ct)
+	}
+
+	records, err := csv.NewReader(rec.Body).ReadAll()
+	if err != nil {
+		t.Fatalf("parse csv: %v", err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("record count mismatch: got %d, want 2 (header and the sample inside the window)", len(records))
+	}
+	wantHeader := []string{"timestamp", "up_bps", "down_bps", "connections", "clients", "memory"}
+	if !reflect.DeepEqual(records[0], wantHeader) {
+		t.Fatalf("header mismatch: got %v, want %v", records[0], wantHeader)
+	}
+	wantRow := []string{recent.Format(time.RFC3339), "1200", "34000", "7", "2", "52428800"}
+	if !reflect.DeepEqual(records[1], wantRow) {
+		t.Fatalf("row mismatch: got %v, want %v", records[1], wantRow)
+	}
+}
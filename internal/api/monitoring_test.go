@@ -1,6 +1,7 @@
 package api
 
 import (
+	"encoding/json"
 	"testing"
 	"time"
 )
@@ -65,6 +66,50 @@ func TestApplyCumulativeTraffic_TracksResourceTotalsAcrossConnectionDrops(t *tes
 	}
 }
 
+func TestFilterConnectionsFrame_KeepsOnlySourceIP(t *testing.T) {
+	payload := []byte(`{"downloadTotal":9000,"uploadTotal":900,"memory":1234,"connections":[
+		{"id":"c1","metadata":{"sourceIP":"10.0.0.1","host":"a.example"},"upload":100,"download":1000},
+		{"id":"c2","metadata":{"sourceIP":"10.0.0.2","host":"b.example"},"upload":300,"download":3000},
+		{"id":"c3","metadata":{"sourceIP":"10.0.0.1","host":"c.example"},"upload":50,"download":500}
+	]}`)
+
+	filtered, err := filterConnectionsFrame(payload, "10.0.0.1")
+	if err != nil {
+		t.Fatalf("filter frame: %v", err)
+	}
+
+	var snapshot clashConnectionsSnapshot
+	if err := json.Unmarshal(filtered, &snapshot); err != nil {
+		t.Fatalf("decode filtered frame: %v", err)
+	}
+	if len(snapshot.Connections) != 2 {
+		t.Fatalf("connections length mismatch: got %d, want 2", len(snapshot.Connections))
+	}
+	for _, conn := range snapshot.Connections {
+		if conn.Metadata.SourceIP != "10.0.0.1" {
+			t.Fatalf("unexpected connection from %s", conn.Metadata.SourceIP)
+		}
+	}
+	if snapshot.UploadTotal != 150 || snapshot.DownloadTotal != 1500 {
+		t.Fatalf("totals mismatch: got %d/%d, want 150/1500", snapshot.UploadTotal, snapshot.DownloadTotal)
+	}
+	if snapshot.Memory.Inuse != 1234 {
+		t.Fatalf("memory mismatch: got %d, want 1234", snapshot.Memory.Inuse)
+	}
+
+	empty, err := filterConnectionsFrame(payload, "10.0.0.9")
+	if err != nil {
+		t.Fatalf("filter frame: %v", err)
+	}
+	var emptySnapshot map[string]json.RawMessage
+	if err := json.Unmarshal(empty, &emptySnapshot); err != nil {
+		t.Fatalf("decode empty frame: %v", err)
+	}
+	if string(emptySnapshot["connections"]) != "[]" {
+		t.Fatalf("expected empty connections array, got %s", emptySnapshot["connections"])
+	}
+}
+
 func mustUTC(raw string) time.Time {
 	ts, err := time.Parse(time.RFC3339, raw)
 	if err != nil {
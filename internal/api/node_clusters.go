@@ -0,0 +1,30 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// getNodeClusters lists server addresses shared by several nodes, so redundant or
+// overloaded endpoints stand out. min_size (default 2) sets the smallest group shown.
+func (s *Server) getNodeClusters(c *gin.Context) {
+	minSize := 2
+	if raw := strings.TrimSpace(c.Query("min_size")); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "min_size must be a positive integer"})
+			return
+		}
+		minSize = parsed
+	}
+
+	clusters, err := s.store.GetServerClusters(minSize)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": clusters})
+}
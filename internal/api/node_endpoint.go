@@ -0,0 +1,47 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xiaobei/singbox-manager/internal/storage"
+)
+
+// NodeByEndpoint is a unified node with its latest health and geo data
+type NodeByEndpoint struct {
+	Node   *storage.UnifiedNode       `json:"node"`
+	Health *storage.HealthMeasurement `json:"health"`
+	Geo    *storage.GeoData           `json:"geo"`
+}
+
+// getNodeByEndpoint looks up the unified node owning a server:port endpoint
+func (s *Server) getNodeByEndpoint(c *gin.Context) {
+	server := strings.TrimSpace(c.Query("server"))
+	if server == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "server is required"})
+		return
+	}
+	port, err := strconv.Atoi(c.Query("port"))
+	if err != nil || port <= 0 || port > 65535 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid port"})
+		return
+	}
+
+	node := s.store.GetNodeByServerPort(server, port)
+	if node == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "node not found"})
+		return
+	}
+
+	result := NodeByEndpoint{Node: node}
+	if measurements, err := s.store.GetHealthMeasurements(server, port, 1); err == nil && len(measurements) > 0 {
+		result.Health = &measurements[0]
+	}
+	if geo, err := s.store.GetGeoData(server, port); err == nil {
+		result.Geo = geo
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": result})
+}
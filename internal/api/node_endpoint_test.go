@@ -0,0 +1,76 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xiaobei/singbox-manager/internal/storage"
+)
+
+func TestGetNodeByEndpoint(t *testing.T) {
+	store, err := storage.NewSQLiteStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("create sqlite store: %v", err)
+	}
+	t.Cleanup(func() { _ = store.Close() })
+
+	nodeID, err := store.AddNode(storage.UnifiedNode{
+		Tag:        "hk-1",
+		Type:       "vmess",
+		Server:     "10.0.0.1",
+		ServerPort: 443,
+		Status:     storage.NodeStatusVerified,
+	})
+	if err != nil {
+		t.Fatalf("insert node: %v", err)
+	}
+	if err := store.AddHealthMeasurements([]storage.HealthMeasurement{{
+		Server:     "10.0.0.1",
+		ServerPort: 443,
+		NodeTag:    "hk-1",
+		Timestamp:  time.Now(),
+		Alive:      true,
+		LatencyMs:  120,
+	}}); err != nil {
+		t.Fatalf("add health measurement: %v", err)
+	}
+
+	gin.SetMode(gin.TestMode)
+	s := &Server{store: store}
+	r := gin.New()
+	r.GET("/api/nodes/by-endpoint", s.getNodeByEndpoint)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/nodes/by-endpoint?server=10.0.0.1&port=443", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("hit status mismatch: got %d, want %d (%s)", w.Code, http.StatusOK, w.Body.String())
+	}
+
+	var resp struct {
+		Data NodeByEndpoint `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if resp.Data.Node == nil || resp.Data.Node.ID != nodeID {
+		t.Fatalf("node mismatch: got %+v, want id %d", resp.Data.Node, nodeID)
+	}
+	if resp.Data.Health == nil || !resp.Data.Health.Alive || resp.Data.Health.LatencyMs != 120 {
+		t.Fatalf("health mismatch: got %+v", resp.Data.Health)
+	}
+	if resp.Data.Geo != nil {
+		t.Fatalf("expected no geo data, got %+v", resp.Data.Geo)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/nodes/by-endpoint?server=10.0.0.2&port=443", nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("miss status mismatch: got %d, want %d", w.Code, http.StatusNotFound)
+	}
+}
@@ -0,0 +1,195 @@
+package api
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xiaobei/singbox-manager/internal/parser"
+	"github.com/xiaobei/singbox-manager/internal/storage"
+	"github.com/xiaobei/singbox-manager/pkg/utils"
+)
+
+// maxImportFileSize caps uploaded node files
+const maxImportFileSize = 5 << 20
+
+// Import file formats
+const (
+	importFormatText   = "text"
+	importFormatBase64 = "base64"
+	importFormatJSON   = "json"
+)
+
+// detectImportFormat guesses whether content is a sing-box outbound JSON,
+// a base64 subscription, or newline-separated URLs.
+func detectImportFormat(content string) string {
+	trimmed := strings.TrimSpace(content)
+	if strings.HasPrefix(trimmed, "[") || strings.HasPrefix(trimmed, "{") {
+		return importFormatJSON
+	}
+	if !strings.Contains(trimmed, "://") && utils.IsBase64(strings.Join(strings.Fields(trimmed), "")) {
+		return importFormatBase64
+	}
+	return importFormatText
+}
+
+// parseImportFile parses file content into per-entry results
+func parseImportFile(content string) ([]bulkParseResult, string, error) {
+	format := detectImportFormat(content)
+	switch format {
+	case importFormatJSON:
+		outbounds, err := parser.DecodeSingboxOutbounds(content)
+		if err != nil {
+			return nil, format, err
+		}
+		results := make([]bulkParseResult, 0, len(outbounds))
+		for i, outbound := range outbounds {
+			result := bulkParseResult{Line: i + 1}
+			if node, err := parser.ParseSingboxOutbound(outbound); err != nil {
+				result.Error = err.Error()
+			} else {
+				result.Node = node
+			}
+			results = append(results, result)
+		}
+		return results, format, nil
+	case importFormatBase64:
+		decoded, err := utils.DecodeBase64(strings.Join(strings.Fields(content), ""))
+		if err != nil {
+			return nil, format, fmt.Errorf("failed to decode base64: %w", err)
+		}
+		content = decoded
+	}
+
+	var results []bulkParseResult
+	for i, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		result := bulkParseResult{URL: line, Line: i + 1}
+		if node, err := parser.ParseURLWithHint(line, ""); err != nil {
+			result.Error = err.Error()
+		} else {
+			result.Node = node
+		}
+		results = append(results, result)
+	}
+	if len(results) == 0 {
+		return nil, format, fmt.Errorf("file contains no nodes")
+	}
+	return results, format, nil
+}
+
+// unifiedNodeFromParsed converts a parsed node into a pending manual node
+func unifiedNodeFromParsed(n storage.Node) storage.UnifiedNode {
+	return storage.UnifiedNode{
+		Tag:          n.Tag,
+		DisplayName:  n.Tag,
+		SourceTag:    n.Tag,
+		Type:         n.Type,
+		Server:       n.Server,
+		ServerPort:   n.ServerPort,
+		Country:      n.Country,
+		CountryEmoji: n.CountryEmoji,
+		Extra:        n.Extra,
+		Status:       storage.NodeStatusPending,
+		Source:       "manual",
+		SourceURL:    n.SourceURL,
+	}
+}
+
+// addParsedNodes stores the successfully parsed results as pending manual nodes.
+// It returns how many were added and how many parsed; the difference was skipped as duplicates.
+func (s *Server) addParsedNodes(results []bulkParseResult, groupTag string) (int, int, error) {
+	tagTemplate := s.store.GetSettings().NodeTagTemplate
+	var nodes []storage.UnifiedNode
+	for _, r := range results {
+		if r.Node == nil {
+			continue
+		}
+		node := unifiedNodeFromParsed(*r.Node)
+		node.GroupTag = groupTag
+		if name := storage.RenderNodeTagTemplate(tagTemplate, node, len(nodes)+1); name != "" {
+			node.DisplayName = name
+		}
+		nodes = append(nodes, node)
+	}
+	if len(nodes) == 0 {
+		return 0, 0, nil
+	}
+	added, err := s.store.AddNodesBulk(nodes)
+	if err != nil {
+		return 0, len(nodes), err
+	}
+	return added, len(nodes), nil
+}
+
+// importNodesFile imports nodes from an uploaded .txt, base64 subscription, or sing-box JSON file
+func (s *Server) importNodesFile(c *gin.Context) {
+	file, header, err := c.Request.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "file is required"})
+		return
+	}
+	defer file.Close()
+
+	if header.Size > maxImportFileSize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("file exceeds %d MB limit", maxImportFileSize>>20)})
+		return
+	}
+	data, err := io.ReadAll(io.LimitReader(file, maxImportFileSize+1))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "failed to read file: " + err.Error()})
+		return
+	}
+	if len(data) > maxImportFileSize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("file exceeds %d MB limit", maxImportFileSize>>20)})
+		return
+	}
+
+	results, format, err := parseImportFile(string(data))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "format": format})
+		return
+	}
+
+	resolve := c.Query("resolve") == "true"
+	groupTag := strings.TrimSpace(c.PostForm("group_tag"))
+	importNodes := func(progress jobProgress) (interface{}, error) {
+		progress(0, len(results))
+		if resolve {
+			pinResolvedServers(results, net.LookupHost)
+		}
+
+		// Mark duplicates before importing so the skipped entries are identifiable
+		markStoredDuplicates(s.store, results)
+
+		added, parsed, err := s.addParsedNodes(results, groupTag)
+		if err != nil {
+			return nil, err
+		}
+		progress(len(results), len(results))
+
+		return gin.H{
+			"data":    results,
+			"format":  format,
+			"added":   added,
+			"skipped": parsed - added,
+		}, nil
+	}
+
+	if isAsyncRequest(c) {
+		s.respondWithJob(c, "import_file", importNodes)
+		return
+	}
+	result, err := importNodes(func(int, int) {})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,145 @@
+package api
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xiaobei/singbox-manager/internal/storage"
+)
+
+type importFileResponse struct {
+	Data    []bulkParseResult `json:"data"`
+	Format  string            `json:"format"`
+	Added   int               `json:"added"`
+	Skipped int               `json:"skipped"`
+}
+
+func postImportFile(t *testing.T, s *Server, filename, content string) (int, importFileResponse) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/api/nodes/import-file", s.importNodesFile)
+
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	fw, err := mw.CreateFormFile("file", filename)
+	if err != nil {
+		t.Fatalf("create form file: %v", err)
+	}
+	fw.Write([]byte(content))
+	mw.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/api/nodes/import-file", &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	var resp importFileResponse
+	if w.Code == http.StatusOK {
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("decode response: %v", err)
+		}
+	}
+	return w.Code, resp
+}
+
+func newImportTestServer(t *testing.T) (*Server, *storage.SQLiteStore) {
+	t.Helper()
+	store, err := storage.NewSQLiteStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("create sqlite store: %v", err)
+	}
+	t.Cleanup(func() { _ = store.Close() })
+	return &Server{store: store}, store
+}
+
+const importTestURLs = "trojan://secret@1.2.3.4:443?sni=example.com#first\n\n# comment\nnot-a-url://x\ntrojan://secret@5.6.7.8:443?sni=example.com#second\n"
+
+func TestImportNodesFile_Text(t *testing.T) {
+	s, store := newImportTestServer(t)
+
+	code, resp := postImportFile(t, s, "nodes.txt", importTestURLs)
+	if code != http.StatusOK {
+		t.Fatalf("status mismatch: got %d, want %d", code, http.StatusOK)
+	}
+	if resp.Format != importFormatText {
+		t.Fatalf("format mismatch: got %q, want %q", resp.Format, importFormatText)
+	}
+	if len(resp.Data) != 3 || resp.Added != 2 {
+		t.Fatalf("result mismatch: got %d results, %d added", len(resp.Data), resp.Added)
+	}
+	if resp.Data[1].Error == "" || resp.Data[1].Line != 4 {
+		t.Fatalf("expected line 4 to fail, got %+v", resp.Data[1])
+	}
+	if got := len(store.GetNodes(storage.NodeStatusPending)); got != 2 {
+		t.Fatalf("pending nodes mismatch: got %d, want 2", got)
+	}
+}
+
+func TestImportNodesFile_Base64(t *testing.T) {
+	s, _ := newImportTestServer(t)
+
+	encoded := base64.StdEncoding.EncodeToString([]byte(importTestURLs))
+	code, resp := postImportFile(t, s, "sub.txt", encoded)
+	if code != http.StatusOK {
+		t.Fatalf("status mismatch: got %d, want %d", code, http.StatusOK)
+	}
+	if resp.Format != importFormatBase64 {
+		t.Fatalf("format mismatch: got %q, want %q", resp.Format, importFormatBase64)
+	}
+	if resp.Added != 2 {
+		t.Fatalf("added mismatch: got %d, want 2", resp.Added)
+	}
+
+	// Importing again reports duplicates and adds nothing
+	code, resp = postImportFile(t, s, "sub.txt", encoded)
+	if code != http.StatusOK || resp.Added != 0 || resp.Skipped != 2 {
+		t.Fatalf("re-import mismatch: code %d, added %d, skipped %d", code, resp.Added, resp.Skipped)
+	}
+	if resp.Data[0].DuplicateOf == "" {
+		t.Fatalf("expected duplicate_of on re-import, got %+v", resp.Data[0])
+	}
+}
+
+func TestImportNodesFile_JSONOutbounds(t *testing.T) {
+	s, store := newImportTestServer(t)
+
+	content := `{"outbounds": [
+		{"type": "shadowsocks", "tag": "ss-1", "server": "9.9.9.9", "server_port": 8388, "method": "aes-128-gcm", "password": "pw"},
+		{"type": "selector", "tag": "Proxy", "outbounds": ["ss-1"]},
+		{"type": "direct", "tag": "DIRECT"}
+	]}`
+	code, resp := postImportFile(t, s, "config.json", content)
+	if code != http.StatusOK {
+		t.Fatalf("status mismatch: got %d, want %d", code, http.StatusOK)
+	}
+	if resp.Format != importFormatJSON {
+		t.Fatalf("format mismatch: got %q, want %q", resp.Format, importFormatJSON)
+	}
+	if len(resp.Data) != 3 || resp.Added != 1 {
+		t.Fatalf("result mismatch: got %d results, %d added", len(resp.Data), resp.Added)
+	}
+	if resp.Data[1].Error == "" || resp.Data[2].Error == "" {
+		t.Fatalf("expected non-proxy outbounds to be rejected: %+v", resp.Data)
+	}
+
+	node := store.GetNodeByServerPort("9.9.9.9", 8388)
+	if node == nil || node.Type != "shadowsocks" || node.Extra["method"] != "aes-128-gcm" {
+		t.Fatalf("imported node mismatch: %+v", node)
+	}
+}
+
+func TestImportNodesFile_TooLarge(t *testing.T) {
+	s, _ := newImportTestServer(t)
+
+	code, _ := postImportFile(t, s, "big.txt", string(bytes.Repeat([]byte("a"), maxImportFileSize+1)))
+	if code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("status mismatch: got %d, want %d", code, http.StatusRequestEntityTooLarge)
+	}
+}
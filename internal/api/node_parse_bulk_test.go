@@ -0,0 +1,65 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xiaobei/singbox-manager/internal/storage"
+)
+
+func TestParseNodeURLsBulk_Dedup(t *testing.T) {
+	store, err := storage.NewSQLiteStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("create sqlite store: %v", err)
+	}
+	t.Cleanup(func() { _ = store.Close() })
+
+	if _, err := store.AddNode(storage.UnifiedNode{
+		Tag:        "existing-node",
+		Type:       "trojan",
+		Server:     "1.2.3.4",
+		ServerPort: 443,
+		Status:     storage.NodeStatusVerified,
+	}); err != nil {
+		t.Fatalf("insert node: %v", err)
+	}
+
+	gin.SetMode(gin.TestMode)
+	s := &Server{store: store}
+	r := gin.New()
+	r.POST("/api/nodes/parse-bulk", s.parseNodeURLsBulk)
+
+	body, _ := json.Marshal(map[string]interface{}{
+		"urls": []string{
+			"trojan://secret@1.2.3.4:443?sni=example.com#dup",
+			"trojan://secret@5.6.7.8:443?sni=example.com#new",
+		},
+	})
+	req := httptest.NewRequest(http.MethodPost, "/api/nodes/parse-bulk?dedup=true", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status mismatch: got %d, want %d (%s)", w.Code, http.StatusOK, w.Body.String())
+	}
+
+	var resp struct {
+		Data []bulkParseResult `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if len(resp.Data) != 2 {
+		t.Fatalf("results length mismatch: got %d, want 2", len(resp.Data))
+	}
+	if resp.Data[0].Node == nil || resp.Data[0].DuplicateOf != "existing-node" {
+		t.Fatalf("expected first URL to be a duplicate of existing-node, got %+v", resp.Data[0])
+	}
+	if resp.Data[1].Node == nil || resp.Data[1].DuplicateOf != "" {
+		t.Fatalf("expected second URL to be new, got %+v", resp.Data[1])
+	}
+}
@@ -0,0 +1,60 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/xiaobei/singbox-manager/internal/events"
+	"github.com/xiaobei/singbox-manager/internal/storage"
+)
+
+func TestArchiveThresholdExceededPendingNodes_SkipsPinned(t *testing.T) {
+	store, err := storage.NewSQLiteStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("create sqlite store: %v", err)
+	}
+	t.Cleanup(func() { _ = store.Close() })
+
+	pinnedID, err := store.AddNode(storage.UnifiedNode{
+		Tag: "pinned", InternalTag: "pinned", Type: "vmess",
+		Server: "10.0.0.1", ServerPort: 443, ConsecutiveFailures: 12,
+	})
+	if err != nil {
+		t.Fatalf("insert pinned node: %v", err)
+	}
+	if err := store.SetNodePinned(pinnedID, true); err != nil {
+		t.Fatalf("pin node: %v", err)
+	}
+	plainID, err := store.AddNode(storage.UnifiedNode{
+		Tag: "plain", InternalTag: "plain", Type: "vmess",
+		Server: "10.0.0.2", ServerPort: 443, ConsecutiveFailures: 12,
+	})
+	if err != nil {
+		t.Fatalf("insert plain node: %v", err)
+	}
+
+	s := &Server{store: store, eventBus: events.NewBus()}
+	var vlog storage.VerificationLog
+	configChanged := false
+	remaining := s.archiveThresholdExceededPendingNodes(store.GetNodes(storage.NodeStatusPending), 10, &vlog, &configChanged)
+
+	if len(remaining) != 1 || remaining[0].ID != pinnedID {
+		t.Fatalf("remaining nodes mismatch: got %+v, want only pinned node %d", remaining, pinnedID)
+	}
+	if vlog.PendingArchived != 1 {
+		t.Fatalf("archived count mismatch: got %d, want 1", vlog.PendingArchived)
+	}
+	if n := store.GetNodeByID(pinnedID); n == nil || n.Status != storage.NodeStatusPending || !n.Pinned {
+		t.Fatalf("expected pinned node to stay pending, got %+v", n)
+	}
+	if n := store.GetNodeByID(plainID); n == nil || n.Status != storage.NodeStatusArchived {
+		t.Fatalf("expected unpinned node to be archived, got %+v", n)
+	}
+
+	configTags := map[string]bool{}
+	for _, n := range store.GetAllNodes() {
+		configTags[n.InternalTag] = true
+	}
+	if !configTags["pinned"] {
+		t.Fatalf("expected pinned pending node in config nodes, got %v", configTags)
+	}
+}
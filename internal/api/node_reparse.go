@@ -0,0 +1,108 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xiaobei/singbox-manager/internal/parser"
+	"github.com/xiaobei/singbox-manager/internal/storage"
+)
+
+// ReparseFailure describes a node whose stored share URL could not be re-parsed
+type ReparseFailure struct {
+	ID    int64  `json:"id"`
+	Tag   string `json:"tag"`
+	Error string `json:"error"`
+}
+
+// ReparseResult summarizes a bulk re-parse run
+type ReparseResult struct {
+	Updated   int              `json:"updated"`
+	Unchanged int              `json:"unchanged"`
+	NoURL     int              `json:"no_url"`
+	Failed    []ReparseFailure `json:"failed"`
+}
+
+// reparseNodes re-runs the parser on each node's stored share URL and updates Extra when it changed.
+// Nodes whose re-parsed type or endpoint no longer matches are reported as failed and left untouched.
+func reparseNodes(store storage.Store, nodes []storage.UnifiedNode) ReparseResult {
+	result := ReparseResult{Failed: []ReparseFailure{}}
+	for _, node := range nodes {
+		if node.SourceURL == "" {
+			result.NoURL++
+			continue
+		}
+
+		fail := func(err error) {
+			result.Failed = append(result.Failed, ReparseFailure{ID: node.ID, Tag: node.DisplayOrTag(), Error: err.Error()})
+		}
+
+		parsed, err := parser.ParseURL(node.SourceURL)
+		if err != nil {
+			fail(err)
+			continue
+		}
+		if parsed.Type != node.Type || parsed.Server != node.Server || parsed.ServerPort != node.ServerPort {
+			fail(fmt.Errorf("re-parsed endpoint %s://%s:%d does not match stored node", parsed.Type, parsed.Server, parsed.ServerPort))
+			continue
+		}
+
+		if sameExtra(node.Extra, parsed.Extra) {
+			result.Unchanged++
+			continue
+		}
+		if err := store.UpdateNodeExtra(node.ID, parsed.Extra); err != nil {
+			fail(err)
+			continue
+		}
+		result.Updated++
+	}
+	return result
+}
+
+// sameExtra compares two Extra maps by their JSON form, as stored in the database
+func sameExtra(a, b map[string]interface{}) bool {
+	aJSON, errA := json.Marshal(a)
+	bJSON, errB := json.Marshal(b)
+	return errA == nil && errB == nil && string(aJSON) == string(bJSON)
+}
+
+// reparseUnifiedNodes re-parses stored share URLs so existing nodes pick up parser fixes.
+func (s *Server) reparseUnifiedNodes(c *gin.Context) {
+	var req struct {
+		IDs []int64 `json:"ids"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var nodes []storage.UnifiedNode
+	if len(req.IDs) > 0 {
+		for _, id := range req.IDs {
+			if n := s.store.GetNodeByID(id); n != nil {
+				nodes = append(nodes, *n)
+			}
+		}
+	} else {
+		for _, status := range []storage.NodeStatus{storage.NodeStatusVerified, storage.NodeStatusPending, storage.NodeStatusArchived} {
+			nodes = append(nodes, s.store.GetNodes(status)...)
+		}
+	}
+
+	result := reparseNodes(s.store, nodes)
+	msg := fmt.Sprintf("Re-parsed nodes: %d updated, %d unchanged, %d failed, %d without source URL",
+		result.Updated, result.Unchanged, len(result.Failed), result.NoURL)
+
+	if result.Updated > 0 {
+		if err := s.autoApplyConfig(); err != nil {
+			c.JSON(http.StatusOK, gin.H{"data": result, "message": msg + ", but auto-apply failed: " + err.Error()})
+			return
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{"data": result, "message": msg})
+}
@@ -0,0 +1,84 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/xiaobei/singbox-manager/internal/parser"
+	"github.com/xiaobei/singbox-manager/internal/storage"
+)
+
+func TestReparseNodes_UpdatesBrokenExtra(t *testing.T) {
+	store, err := storage.NewSQLiteStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("create sqlite store: %v", err)
+	}
+	t.Cleanup(func() { _ = store.Close() })
+
+	const shareURL = "vless://11111111-2222-3333-4444-555555555555@1.2.3.4:443?security=tls&sni=example.com&type=grpc&serviceName=svc&packetEncoding=packet#grpc-node"
+	parsed, err := parser.ParseURL(shareURL)
+	if err != nil {
+		t.Fatalf("parse share url: %v", err)
+	}
+
+	// Extra as stored by an older, buggy parser
+	broken := map[string]interface{}{
+		"uuid":            "11111111-2222-3333-4444-555555555555",
+		"packet_encoding": "packet",
+		"transport":       map[string]interface{}{"type": "grpc", "service_name": "svc", "mode": "gun"},
+	}
+	brokenID, err := store.AddNode(storage.UnifiedNode{
+		Tag:        "grpc-node",
+		Type:       "vless",
+		Server:     "1.2.3.4",
+		ServerPort: 443,
+		Extra:      broken,
+		SourceURL:  shareURL,
+		Status:     storage.NodeStatusVerified,
+	})
+	if err != nil {
+		t.Fatalf("insert broken node: %v", err)
+	}
+	if _, err := store.AddNode(storage.UnifiedNode{
+		Tag:        "no-url",
+		Type:       "vless",
+		Server:     "5.6.7.8",
+		ServerPort: 443,
+		Status:     storage.NodeStatusVerified,
+	}); err != nil {
+		t.Fatalf("insert node without url: %v", err)
+	}
+	if _, err := store.AddNode(storage.UnifiedNode{
+		Tag:        "moved",
+		Type:       "vless",
+		Server:     "9.9.9.9",
+		ServerPort: 443,
+		SourceURL:  shareURL,
+		Status:     storage.NodeStatusVerified,
+	}); err != nil {
+		t.Fatalf("insert mismatched node: %v", err)
+	}
+
+	result := reparseNodes(store, store.GetNodes(storage.NodeStatusVerified))
+	if result.Updated != 1 || result.NoURL != 1 || len(result.Failed) != 1 {
+		t.Fatalf("result mismatch: %+v", result)
+	}
+
+	node := store.GetNodeByID(brokenID)
+	if node == nil {
+		t.Fatalf("expected node %d to exist", brokenID)
+	}
+	if node.SourceURL != shareURL {
+		t.Fatalf("source url mismatch: got %q", node.SourceURL)
+	}
+	if !sameExtra(node.Extra, parsed.Extra) {
+		t.Fatalf("extra not updated: got %v, want %v", node.Extra, parsed.Extra)
+	}
+	if node.Extra["packet_encoding"] != "packetaddr" {
+		t.Fatalf("packet encoding mismatch: got %v", node.Extra["packet_encoding"])
+	}
+
+	again := reparseNodes(store, []storage.UnifiedNode{*node})
+	if again.Updated != 0 || again.Unchanged != 1 {
+		t.Fatalf("second run mismatch: %+v", again)
+	}
+}
@@ -0,0 +1,177 @@
+package api
+
+import (
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xiaobei/singbox-manager/internal/storage"
+)
+
+// Latency component bounds: an average at or below scoreLatencyBestMs scores 100,
+// at or above scoreLatencyWorstMs scores 0, linear in between.
+const (
+	scoreLatencyBestMs  = 100.0
+	scoreLatencyWorstMs = 1500.0
+)
+
+// NodeScore is a node's 0-100 quality score and the components it was blended from.
+// A component is nil when there is no data for it; Score is nil when no component has data.
+type NodeScore struct {
+	ID             int64              `json:"id"`
+	NodeTag        string             `json:"node_tag"`
+	DisplayName    string             `json:"display_name"`
+	Status         storage.NodeStatus `json:"status"`
+	Server         string             `json:"server"`
+	ServerPort     int                `json:"server_port"`
+	Score          *float64           `json:"score"`
+	LatencyScore   *float64           `json:"latency_score"`
+	UptimeScore    *float64           `json:"uptime_score"`
+	SitesScore     *float64           `json:"sites_score"`
+	AvgLatencyMs   float64            `json:"avg_latency_ms"`
+	UptimePercent  float64            `json:"uptime_percent"`
+	SitesReachable int                `json:"sites_reachable"`
+	SitesChecked   int                `json:"sites_checked"`
+}
+
+// scoreWeights are the relative weights of the score components
+type scoreWeights struct {
+	Latency int `json:"latency"`
+	Uptime  int `json:"uptime"`
+	Sites   int `json:"sites"`
+}
+
+// latencyComponent maps an average latency onto 0-100 between the best and worst bounds
+func latencyComponent(avgMs float64) float64 {
+	switch {
+	case avgMs <= scoreLatencyBestMs:
+		return 100
+	case avgMs >= scoreLatencyWorstMs:
+		return 0
+	}
+	return 100 * (scoreLatencyWorstMs - avgMs) / (scoreLatencyWorstMs - scoreLatencyBestMs)
+}
+
+// scoreNode blends the available components of a node into its quality score:
+//   - latency: average latency of the alive checks in the window, see latencyComponent;
+//     a node that was never alive scores 0
+//   - uptime: the uptime percentage over the window
+//   - sites: percentage of sites reachable in the latest site check
+//
+// The score is the weighted mean of the components that have data, so a node that was
+// never site-checked is ranked on latency and uptime alone rather than penalised.
+func scoreNode(score *NodeScore, stats *storage.NodeStabilityStats, sites []storage.SiteMeasurement, weights scoreWeights) {
+	var sum, total float64
+	add := func(component *float64, weight int) {
+		if component == nil || weight <= 0 {
+			return
+		}
+		sum += *component * float64(weight)
+		total += float64(weight)
+	}
+
+	if stats != nil && stats.TotalChecks > 0 {
+		latency := 0.0
+		if stats.AliveChecks > 0 {
+			latency = latencyComponent(stats.AvgLatencyMs)
+		}
+		score.LatencyScore = roundScore(latency)
+		score.UptimeScore = roundScore(stats.UptimePercent)
+		score.AvgLatencyMs = stats.AvgLatencyMs
+		score.UptimePercent = stats.UptimePercent
+	}
+	if len(sites) > 0 {
+		for _, m := range sites {
+			if m.DelayMs > 0 && m.ErrorType == "" {
+				score.SitesReachable++
+			}
+		}
+		score.SitesChecked = len(sites)
+		score.SitesScore = roundScore(100 * float64(score.SitesReachable) / float64(score.SitesChecked))
+	}
+
+	add(score.LatencyScore, weights.Latency)
+	add(score.UptimeScore, weights.Uptime)
+	add(score.SitesScore, weights.Sites)
+	if total > 0 {
+		score.Score = roundScore(sum / total)
+	}
+}
+
+// roundScore rounds to one decimal place
+func roundScore(v float64) *float64 {
+	rounded := math.Round(v*10) / 10
+	return &rounded
+}
+
+// sortNodeScores orders scored nodes best first, then unscored nodes, ties by name
+func sortNodeScores(scores []NodeScore) {
+	sort.SliceStable(scores, func(i, j int) bool {
+		a, b := scores[i].Score, scores[j].Score
+		if (a == nil) != (b == nil) {
+			return a != nil
+		}
+		if a != nil && *a != *b {
+			return *a > *b
+		}
+		return scores[i].DisplayName < scores[j].DisplayName
+	})
+}
+
+// getNodeScores ranks pending and verified nodes by quality score over the last days
+// (default 7, max 90) using the weights from settings.
+func (s *Server) getNodeScores(c *gin.Context) {
+	days, _ := strconv.Atoi(c.Query("days"))
+	if days <= 0 {
+		days = 7
+	}
+	if days > 90 {
+		days = 90
+	}
+
+	bulkStats, err := s.store.GetBulkHealthStats(days)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	siteMeasurements, err := s.store.GetLatestSiteMeasurements()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	statsByEndpoint := make(map[storage.ServerPortKey]*storage.NodeStabilityStats, len(bulkStats))
+	for i := range bulkStats {
+		statsByEndpoint[storage.ServerPortKey{Server: bulkStats[i].Server, ServerPort: bulkStats[i].ServerPort}] = &bulkStats[i]
+	}
+	sitesByEndpoint := make(map[storage.ServerPortKey][]storage.SiteMeasurement)
+	for _, m := range siteMeasurements {
+		key := storage.ServerPortKey{Server: m.Server, ServerPort: m.ServerPort}
+		sitesByEndpoint[key] = append(sitesByEndpoint[key], m)
+	}
+
+	settings := s.store.GetSettings()
+	weights := scoreWeights{Latency: settings.ScoreWeightLatency, Uptime: settings.ScoreWeightUptime, Sites: settings.ScoreWeightSites}
+
+	scores := make([]NodeScore, 0)
+	for _, status := range []storage.NodeStatus{storage.NodeStatusVerified, storage.NodeStatusPending} {
+		for _, node := range s.store.GetNodes(status) {
+			key := storage.ServerPortKey{Server: node.Server, ServerPort: node.ServerPort}
+			score := NodeScore{
+				ID:          node.ID,
+				NodeTag:     unifiedRoutingTag(node),
+				DisplayName: unifiedDisplayName(node),
+				Status:      node.Status,
+				Server:      node.Server,
+				ServerPort:  node.ServerPort,
+			}
+			scoreNode(&score, statsByEndpoint[key], sitesByEndpoint[key], weights)
+			scores = append(scores, score)
+		}
+	}
+	sortNodeScores(scores)
+
+	c.JSON(http.StatusOK, gin.H{"data": scores, "weights": weights, "days": days})
+}
@@ -0,0 +1,115 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xiaobei/singbox-manager/internal/storage"
+)
+
+func TestGetNodeScores_RanksBySeededHealth(t *testing.T) {
+	store, err := storage.NewSQLiteStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("create sqlite store: %v", err)
+	}
+	t.Cleanup(func() { _ = store.Close() })
+
+	for _, n := range []storage.UnifiedNode{
+		{Tag: "fast", Type: "vmess", Server: "10.0.0.1", ServerPort: 443, Status: storage.NodeStatusVerified},
+		{Tag: "slow", Type: "vmess", Server: "10.0.0.2", ServerPort: 443, Status: storage.NodeStatusVerified},
+		{Tag: "flaky", Type: "vmess", Server: "10.0.0.3", ServerPort: 443, Status: storage.NodeStatusPending},
+		{Tag: "dead", Type: "vmess", Server: "10.0.0.4", ServerPort: 443, Status: storage.NodeStatusPending},
+		{Tag: "unchecked", Type: "vmess", Server: "10.0.0.5", ServerPort: 443, Status: storage.NodeStatusVerified},
+	} {
+		if _, err := store.AddNode(n); err != nil {
+			t.Fatalf("insert node %s: %v", n.Tag, err)
+		}
+	}
+
+	now := time.Now()
+	check := func(server, tag string, ago time.Duration, alive bool, latency int) storage.HealthMeasurement {
+		return storage.HealthMeasurement{Server: server, ServerPort: 443, NodeTag: tag, Timestamp: now.Add(-ago), Alive: alive, LatencyMs: latency}
+	}
+	if err := store.AddHealthMeasurements([]storage.HealthMeasurement{
+		check("10.0.0.1", "fast", 2*time.Hour, true, 50),
+		check("10.0.0.1", "fast", time.Hour, true, 50),
+		check("10.0.0.2", "slow", 2*time.Hour, true, 800),
+		check("10.0.0.2", "slow", time.Hour, true, 800),
+		check("10.0.0.3", "flaky", 2*time.Hour, true, 100),
+		check("10.0.0.3", "flaky", time.Hour, false, 0),
+		check("10.0.0.4", "dead", 2*time.Hour, false, 0),
+		check("10.0.0.4", "dead", time.Hour, false, 0),
+	}); err != nil {
+		t.Fatalf("add health measurements: %v", err)
+	}
+	site := func(server, tag, name string, delay int, errorType string) storage.SiteMeasurement {
+		return storage.SiteMeasurement{Server: server, ServerPort: 443, NodeTag: tag, Timestamp: now.Add(-time.Hour), Site: name, DelayMs: delay, ErrorType: errorType}
+	}
+	if err := store.AddSiteMeasurements([]storage.SiteMeasurement{
+		site("10.0.0.1", "fast", "youtube.com", 120, ""),
+		site("10.0.0.1", "fast", "netflix.com", 150, ""),
+		site("10.0.0.2", "slow", "youtube.com", 900, ""),
+		site("10.0.0.2", "slow", "netflix.com", 0, "timeout"),
+	}); err != nil {
+		t.Fatalf("add site measurements: %v", err)
+	}
+
+	gin.SetMode(gin.TestMode)
+	s := &Server{store: store}
+	r := gin.New()
+	r.GET("/api/nodes/scores", s.getNodeScores)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/nodes/scores", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status mismatch: got %d, want %d (%s)", w.Code, http.StatusOK, w.Body.String())
+	}
+
+	var resp struct {
+		Data    []NodeScore  `json:"data"`
+		Weights scoreWeights `json:"weights"`
+		Days    int          `json:"days"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if resp.Days != 7 {
+		t.Fatalf("days mismatch: got %d, want 7", resp.Days)
+	}
+	if resp.Weights.Latency != storage.DefaultScoreWeightLatency || resp.Weights.Sites != storage.DefaultScoreWeightSites {
+		t.Fatalf("weights mismatch: got %+v", resp.Weights)
+	}
+
+	var order []string
+	for _, score := range resp.Data {
+		order = append(order, score.DisplayName)
+	}
+	// fast: 100; flaky: (100*40 + 50*40) / 80 = 75; slow: (50*40 + 100*40 + 50*20) / 100 = 70; dead: 0
+	want := []string{"fast", "flaky", "slow", "dead", "unchecked"}
+	if len(order) != len(want) {
+		t.Fatalf("ranking size mismatch: got %v, want %v", order, want)
+	}
+	for i := range want {
+		if order[i] != want[i] {
+			t.Fatalf("ranking mismatch: got %v, want %v", order, want)
+		}
+	}
+
+	if got := resp.Data[0].Score; got == nil || *got != 100 {
+		t.Fatalf("fast score mismatch: got %v, want 100", got)
+	}
+	if got := resp.Data[2].SitesScore; got == nil || *got != 50 {
+		t.Fatalf("slow sites score mismatch: got %v, want 50", got)
+	}
+	if got := resp.Data[3].Score; got == nil || *got != 0 {
+		t.Fatalf("dead score mismatch: got %v, want 0", got)
+	}
+	if resp.Data[4].Score != nil {
+		t.Fatalf("expected no score for an unchecked node, got %v", *resp.Data[4].Score)
+	}
+}
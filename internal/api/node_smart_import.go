@@ -0,0 +1,74 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xiaobei/singbox-manager/internal/parser"
+)
+
+// maxSmartImportSize caps pasted text for smart import
+const maxSmartImportSize = 1 << 20
+
+// mixedEntryResults converts scanned entries into bulk parse results
+func mixedEntryResults(entries []parser.MixedEntry) []bulkParseResult {
+	results := make([]bulkParseResult, 0, len(entries))
+	for _, e := range entries {
+		result := bulkParseResult{URL: e.Raw, Line: e.Line, Node: e.Node}
+		if e.Err != nil {
+			result.Error = e.Err.Error()
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+// smartImportNodes imports every proxy found in pasted text, whatever mix of
+// share links, base64 subscription blocks and sing-box JSON it contains
+func (s *Server) smartImportNodes(c *gin.Context) {
+	var req struct {
+		Text     string `json:"text" binding:"required"`
+		GroupTag string `json:"group_tag"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if len(req.Text) > maxSmartImportSize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("text exceeds %d MB limit", maxSmartImportSize>>20)})
+		return
+	}
+
+	found := parser.ScanMixedContent(req.Text)
+	if found.Len() == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "no proxies found in text"})
+		return
+	}
+
+	urls := mixedEntryResults(found.URLs)
+	base64 := mixedEntryResults(found.Base64)
+	jsonOutbounds := mixedEntryResults(found.JSON)
+	all := make([]bulkParseResult, 0, found.Len())
+	for _, results := range [][]bulkParseResult{urls, base64, jsonOutbounds} {
+		markStoredDuplicates(s.store, results)
+		all = append(all, results...)
+	}
+
+	added, parsed, err := s.addParsedNodes(all, strings.TrimSpace(req.GroupTag))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": gin.H{
+			"urls":   urls,
+			"base64": base64,
+			"json":   jsonOutbounds,
+		},
+		"added":   added,
+		"skipped": parsed - added,
+	})
+}
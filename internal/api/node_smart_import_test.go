@@ -0,0 +1,53 @@
+package api
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xiaobei/singbox-manager/internal/storage"
+)
+
+func TestSmartImportNodes_ImportsEveryFormat(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	s, store := newImportTestServer(t)
+	r := gin.New()
+	r.POST("/api/nodes/smart-import", s.smartImportNodes)
+
+	sub := base64.StdEncoding.EncodeToString([]byte("trojan://secret@9.9.9.9:443#b64\n"))
+	text := "new ones:\ntrojan://secret@1.2.3.4:443#first\ntrojan://secret@5.6.7.8:443#second\n" + sub + "\n"
+	body, _ := json.Marshal(map[string]string{"text": text})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/nodes/smart-import", bytes.NewReader(body)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status mismatch: got %d, want %d (%s)", w.Code, http.StatusOK, w.Body.String())
+	}
+	var resp struct {
+		Data struct {
+			URLs   []bulkParseResult `json:"urls"`
+			Base64 []bulkParseResult `json:"base64"`
+			JSON   []bulkParseResult `json:"json"`
+		} `json:"data"`
+		Added int `json:"added"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if len(resp.Data.URLs) != 2 || len(resp.Data.Base64) != 1 || len(resp.Data.JSON) != 0 {
+		t.Fatalf("categories mismatch: got %d urls, %d base64, %d json", len(resp.Data.URLs), len(resp.Data.Base64), len(resp.Data.JSON))
+	}
+	if resp.Added != 3 || len(store.GetNodes(storage.NodeStatusPending)) != 3 {
+		t.Fatalf("import mismatch: added %d, stored %d, want 3", resp.Added, len(store.GetNodes(storage.NodeStatusPending)))
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/nodes/smart-import", bytes.NewReader([]byte(`{"text":"nothing to see here"}`))))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("status mismatch for text without proxies: got %d, want %d", w.Code, http.StatusBadRequest)
+	}
+}
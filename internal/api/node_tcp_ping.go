@@ -0,0 +1,141 @@
+package api
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xiaobei/singbox-manager/internal/daemon"
+	"github.com/xiaobei/singbox-manager/internal/logger"
+	"github.com/xiaobei/singbox-manager/internal/storage"
+)
+
+const (
+	tcpPingTimeout     = 3 * time.Second
+	tcpPingConcurrency = 64
+)
+
+// tcpPingNodes dials each unique server:port directly, without the probe.
+// Results are keyed by "server:port" like the probe health check. UDP-only
+// protocols have nothing listening on TCP and are left out of the results.
+func tcpPingNodes(nodes []storage.Node, timeout time.Duration, concurrency int) map[string]*NodeHealthResult {
+	tcpNodes, _ := splitTCPPingNodes(nodes)
+	uniqueNodes := dedupeNodesByEndpoint(tcpNodes)
+	results := make(map[string]*NodeHealthResult, len(uniqueNodes))
+	var mu sync.Mutex
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+
+	for _, node := range uniqueNodes {
+		wg.Add(1)
+		go func(n storage.Node) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			result := &NodeHealthResult{Groups: make(map[string]int)}
+			addr := net.JoinHostPort(n.Server, strconv.Itoa(n.ServerPort))
+			start := time.Now()
+			conn, err := net.DialTimeout("tcp", addr, timeout)
+			if err == nil {
+				result.Alive = true
+				result.TCPLatencyMs = time.Since(start).Milliseconds()
+				if result.TCPLatencyMs == 0 {
+					result.TCPLatencyMs = 1 // keep alive results distinguishable from failures
+				}
+				conn.Close()
+			}
+
+			key := fmt.Sprintf("%s:%d", n.Server, n.ServerPort)
+			mu.Lock()
+			results[key] = result
+			mu.Unlock()
+		}(node)
+	}
+	wg.Wait()
+
+	return results
+}
+
+// splitTCPPingNodes separates nodes a TCP dial can check from the UDP-only ones
+// (hysteria, tuic, wireguard...), returning the tags of the latter
+func splitTCPPingNodes(nodes []storage.Node) ([]storage.Node, []string) {
+	var tcpNodes []storage.Node
+	notApplicable := []string{}
+	for _, n := range nodes {
+		if daemon.UsesUDPHealthCheck(n.Type) {
+			notApplicable = append(notApplicable, nodeRoutingTag(n))
+			continue
+		}
+		tcpNodes = append(tcpNodes, n)
+	}
+	return tcpNodes, notApplicable
+}
+
+// tcpPingMeasurements converts TCP ping results into health measurements
+func tcpPingMeasurements(nodes []storage.Node, results map[string]*NodeHealthResult, now time.Time) []storage.HealthMeasurement {
+	var measurements []storage.HealthMeasurement
+	for _, n := range dedupeNodesByEndpoint(nodes) {
+		key := fmt.Sprintf("%s:%d", n.Server, n.ServerPort)
+		r, ok := results[key]
+		if !ok {
+			continue
+		}
+		measurements = append(measurements, storage.HealthMeasurement{
+			Server:     n.Server,
+			ServerPort: n.ServerPort,
+			NodeTag:    nodeRoutingTag(n),
+			Timestamp:  now,
+			Alive:      r.Alive,
+			LatencyMs:  int(r.TCPLatencyMs),
+			Mode:       "tcp",
+		})
+	}
+	return measurements
+}
+
+// tcpPingNodesHandler checks raw TCP reachability of nodes without starting the probe
+func (s *Server) tcpPingNodesHandler(c *gin.Context) {
+	var req struct {
+		Tags []string `json:"tags"`
+	}
+	c.ShouldBindJSON(&req)
+
+	allNodes := s.store.GetAllNodesIncludeDisabled()
+
+	var nodes []storage.Node
+	if len(req.Tags) > 0 {
+		tagSet := parseTagSet(req.Tags)
+		for _, n := range allNodes {
+			if nodeMatchesAnyTag(n, tagSet) {
+				nodes = append(nodes, n)
+			}
+		}
+	} else {
+		nodes = allNodes
+	}
+
+	// UDP-only nodes are reported as not applicable instead of dead
+	nodes, notApplicable := splitTCPPingNodes(nodes)
+	if len(nodes) == 0 {
+		c.JSON(http.StatusOK, gin.H{
+			"data":           map[string]*NodeHealthResult{},
+			"mode":           "tcp",
+			"not_applicable": notApplicable,
+		})
+		return
+	}
+
+	results := tcpPingNodes(nodes, tcpPingTimeout, tcpPingConcurrency)
+	if measurements := tcpPingMeasurements(nodes, results, time.Now()); len(measurements) > 0 {
+		if err := s.store.AddHealthMeasurements(measurements); err != nil {
+			logger.Printf("[health] Failed to save TCP ping measurements: %v", err)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": results, "mode": "tcp", "not_applicable": notApplicable})
+}
@@ -0,0 +1,69 @@
+package api
+
+import (
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/xiaobei/singbox-manager/internal/storage"
+)
+
+func TestTCPPingNodes_LocalListener(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer ln.Close()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+	openPort := ln.Addr().(*net.TCPAddr).Port
+
+	closed, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	closedPort := closed.Addr().(*net.TCPAddr).Port
+	closed.Close()
+
+	nodes := []storage.Node{
+		{Tag: "up", Server: "127.0.0.1", ServerPort: openPort},
+		{Tag: "up-dup", Server: "127.0.0.1", ServerPort: openPort},
+		{Tag: "down", Server: "127.0.0.1", ServerPort: closedPort},
+		{Tag: "hy2", Type: "hysteria2", Server: "127.0.0.2", ServerPort: openPort},
+	}
+
+	results := tcpPingNodes(nodes, time.Second, 4)
+	if len(results) != 2 {
+		t.Fatalf("results length mismatch: got %d, want 2", len(results))
+	}
+
+	up := results[fmt.Sprintf("127.0.0.1:%d", openPort)]
+	if up == nil || !up.Alive || up.TCPLatencyMs <= 0 {
+		t.Fatalf("expected open port to be alive with latency, got %+v", up)
+	}
+	down := results[fmt.Sprintf("127.0.0.1:%d", closedPort)]
+	if down == nil || down.Alive {
+		t.Fatalf("expected closed port to be down, got %+v", down)
+	}
+
+	measurements := tcpPingMeasurements(nodes, results, time.Now())
+	if len(measurements) != 2 {
+		t.Fatalf("measurements length mismatch: got %d, want 2", len(measurements))
+	}
+	for _, m := range measurements {
+		if m.Mode != "tcp" {
+			t.Fatalf("mode mismatch: got %q, want tcp", m.Mode)
+		}
+	}
+	if _, notApplicable := splitTCPPingNodes(nodes); len(notApplicable) != 1 || notApplicable[0] != "hy2" {
+		t.Fatalf("not applicable mismatch: got %v, want [hy2]", notApplicable)
+	}
+}
@@ -0,0 +1,108 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xiaobei/singbox-manager/internal/storage"
+)
+
+// NodeUsage reports when a node last carried traffic
+type NodeUsage struct {
+	ID            int64              `json:"id"`
+	NodeTag       string             `json:"node_tag"`
+	DisplayName   string             `json:"display_name"`
+	Status        storage.NodeStatus `json:"status"`
+	LastUsedAt    *time.Time         `json:"last_used_at"`
+	UploadBytes   int64              `json:"upload_bytes"`
+	DownloadBytes int64              `json:"download_bytes"`
+}
+
+// nodeUsageStats aggregates traffic chain stats per canonical node tag
+type nodeUsageStats struct {
+	LastUsedAt    time.Time
+	UploadBytes   int64
+	DownloadBytes int64
+}
+
+// computeNodeUsage maps traffic chains back to known node tags and keeps the latest use per node
+func computeNodeUsage(chainStats []storage.TrafficChainStats, knownTags map[string]string) map[string]*nodeUsageStats {
+	usage := make(map[string]*nodeUsageStats)
+	for _, chainStat := range chainStats {
+		nodeTag, ok := selectNodeTagFromChain(chainStat.ProxyChain, knownTags)
+		if !ok || nodeTag == "" {
+			continue
+		}
+		u, exists := usage[nodeTag]
+		if !exists {
+			u = &nodeUsageStats{}
+			usage[nodeTag] = u
+		}
+		u.UploadBytes += maxI64(chainStat.UploadBytes, 0)
+		u.DownloadBytes += maxI64(chainStat.DownloadBytes, 0)
+		if chainStat.LastSeen.After(u.LastUsedAt) {
+			u.LastUsedAt = chainStat.LastSeen
+		}
+	}
+	return usage
+}
+
+func (s *Server) getNodesUsage(c *gin.Context) {
+	hours := 0
+	if raw := strings.TrimSpace(c.Query("hours")); raw != "" {
+		if parsed, err := strconv.Atoi(raw); err == nil && parsed >= 0 {
+			hours = parsed
+		}
+	}
+	if hours > 24*365 {
+		hours = 24 * 365
+	}
+	var lookback time.Duration
+	if hours > 0 {
+		lookback = time.Duration(hours) * time.Hour
+	}
+
+	chainStats, err := s.store.GetTrafficChainStats(5000, lookback)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	usage := computeNodeUsage(chainStats, collectKnownNodeTags(s.store))
+
+	var items []NodeUsage
+	for _, status := range []storage.NodeStatus{
+		storage.NodeStatusVerified,
+		storage.NodeStatusPending,
+		storage.NodeStatusArchived,
+	} {
+		for _, node := range s.store.GetNodes(status) {
+			tag := unifiedRoutingTag(node)
+			item := NodeUsage{
+				ID:          node.ID,
+				NodeTag:     tag,
+				DisplayName: unifiedDisplayName(node),
+				Status:      node.Status,
+			}
+			if u, ok := usage[tag]; ok {
+				lastUsed := u.LastUsedAt
+				item.LastUsedAt = &lastUsed
+				item.UploadBytes = u.UploadBytes
+				item.DownloadBytes = u.DownloadBytes
+			}
+			items = append(items, item)
+		}
+	}
+	if items == nil {
+		items = []NodeUsage{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": items,
+		"meta": gin.H{
+			"hours": hours,
+		},
+	})
+}
@@ -0,0 +1,51 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/xiaobei/singbox-manager/internal/storage"
+)
+
+func TestComputeNodeUsage_MapsChainsToKnownNodes(t *testing.T) {
+	store, err := storage.NewSQLiteStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("create sqlite store: %v", err)
+	}
+	t.Cleanup(func() { _ = store.Close() })
+
+	for _, n := range []storage.UnifiedNode{
+		{Tag: "HK Premium", DisplayName: "HK Premium", InternalTag: "node_hk", Type: "vmess", Server: "10.0.0.1", ServerPort: 443, Status: storage.NodeStatusVerified},
+		{Tag: "JP Idle", DisplayName: "JP Idle", InternalTag: "node_jp", Type: "vmess", Server: "10.0.0.2", ServerPort: 443, Status: storage.NodeStatusVerified},
+	} {
+		if _, err := store.AddNode(n); err != nil {
+			t.Fatalf("insert node %s: %v", n.Tag, err)
+		}
+	}
+
+	older := mustUTC("2026-03-04T06:00:00Z")
+	newer := mustUTC("2026-03-04T07:30:00Z")
+	chainStats := []storage.TrafficChainStats{
+		{ProxyChain: "Proxy -> Auto -> node_hk", LastSeen: older, UploadBytes: 10, DownloadBytes: 20},
+		{ProxyChain: "Final -> HK Premium", LastSeen: newer, UploadBytes: 5, DownloadBytes: 5},
+		{ProxyChain: "direct", LastSeen: newer, UploadBytes: 100, DownloadBytes: 100},
+	}
+
+	usage := computeNodeUsage(chainStats, collectKnownNodeTags(store))
+
+	hk, ok := usage["node_hk"]
+	if !ok {
+		t.Fatalf("expected usage for node_hk, got %v", usage)
+	}
+	if !hk.LastUsedAt.Equal(newer) {
+		t.Fatalf("last used mismatch: got %v, want %v", hk.LastUsedAt, newer)
+	}
+	if hk.UploadBytes != 15 || hk.DownloadBytes != 25 {
+		t.Fatalf("bytes mismatch: got %d/%d, want 15/25", hk.UploadBytes, hk.DownloadBytes)
+	}
+	if _, ok := usage["node_jp"]; ok {
+		t.Fatalf("expected no usage for idle node")
+	}
+	if len(usage) != 1 {
+		t.Fatalf("usage size mismatch: got %d, want 1", len(usage))
+	}
+}
@@ -0,0 +1,49 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xiaobei/singbox-manager/internal/daemon"
+	"github.com/xiaobei/singbox-manager/internal/storage"
+)
+
+// previewProbeConfig returns the probe config that would be started for the requested
+// nodes (all nodes when no tags are given) along with the probe_N tag mapping, without
+// launching sing-box. Nodes dropped by the transport pre-filter are listed as excluded.
+func (s *Server) previewProbeConfig(c *gin.Context) {
+	if !s.store.GetSettings().DebugAPIEnabled {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Debug API is disabled. Enable it in Settings."})
+		return
+	}
+
+	var req struct {
+		Tags []string `json:"tags"`
+	}
+	c.ShouldBindJSON(&req)
+
+	var nodes []storage.Node
+	tagSet := parseTagSet(req.Tags)
+	for _, n := range s.store.GetAllNodesIncludeDisabled() {
+		if nodeMatchesAnyTag(n, tagSet) {
+			nodes = append(nodes, n)
+		}
+	}
+	nodes = dedupeNodesByEndpoint(nodes)
+
+	cfg, tagMap, excluded, err := daemon.PreviewProbeConfig(nodes, s.installedKernelFeatures().wireGuardEndpoint)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if excluded == nil {
+		excluded = []daemon.BrokenNode{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": gin.H{
+		"config":    cfg,
+		"tag_map":   tagMap.ProbeToOrig,
+		"endpoints": tagMap.KeyToProbe,
+		"excluded":  excluded,
+	}})
+}
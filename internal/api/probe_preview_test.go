@@ -0,0 +1,110 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xiaobei/singbox-manager/internal/daemon"
+	"github.com/xiaobei/singbox-manager/internal/storage"
+)
+
+func TestPreviewProbeConfig_TagsAndGeoSelector(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	dir := t.TempDir()
+	store, err := storage.NewSQLiteStore(dir)
+	if err != nil {
+		t.Fatalf("create sqlite store: %v", err)
+	}
+	t.Cleanup(func() { _ = store.Close() })
+
+	for _, n := range []storage.UnifiedNode{
+		{Tag: "hk-1", InternalTag: "hk-1", Type: "trojan", Server: "10.0.0.1", ServerPort: 443,
+			Extra: map[string]interface{}{"password": "secret"}, Status: storage.NodeStatusVerified},
+		{Tag: "jp-1", InternalTag: "jp-1", Type: "trojan", Server: "10.0.0.2", ServerPort: 443,
+			Extra: map[string]interface{}{"password": "secret"}, Status: storage.NodeStatusPending},
+		{Tag: "xhttp-1", InternalTag: "xhttp-1", Type: "vless", Server: "10.0.0.3", ServerPort: 443,
+			Extra: map[string]interface{}{"uuid": "id", "transport": map[string]interface{}{"type": "xhttp"}}, Status: storage.NodeStatusVerified},
+		{Tag: "us-1", InternalTag: "us-1", Type: "trojan", Server: "10.0.0.4", ServerPort: 443,
+			Extra: map[string]interface{}{"password": "secret"}, Status: storage.NodeStatusVerified},
+	} {
+		if _, err := store.AddNode(n); err != nil {
+			t.Fatalf("insert node %s: %v", n.Tag, err)
+		}
+	}
+
+	s := &Server{
+		store:          store,
+		processManager: daemon.NewProcessManager(filepath.Join(dir, "missing-sing-box"), filepath.Join(dir, "config.json"), dir),
+	}
+	router := gin.New()
+	router.POST("/probe/preview", s.previewProbeConfig)
+	preview := func() *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		body := strings.NewReader(`{"tags":["hk-1","jp-1","xhttp-1"]}`)
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/probe/preview", body))
+		return rec
+	}
+
+	if rec := preview(); rec.Code != http.StatusForbidden {
+		t.Fatalf("status with debug disabled mismatch: got %d, want %d", rec.Code, http.StatusForbidden)
+	}
+
+	settings := store.GetSettings()
+	settings.DebugAPIEnabled = true
+	if err := store.UpdateSettings(settings); err != nil {
+		t.Fatalf("enable debug api: %v", err)
+	}
+
+	rec := preview()
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status mismatch: got %d, want %d (%s)", rec.Code, http.StatusOK, rec.Body.String())
+	}
+	var resp struct {
+		Data struct {
+			Config struct {
+				Outbounds []map[string]interface{} `json:"outbounds"`
+			} `json:"config"`
+			TagMap   map[string]string `json:"tag_map"`
+			Excluded []struct {
+				Tag   string `json:"tag"`
+				Error string `json:"error"`
+			} `json:"excluded"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+
+	wantTagMap := map[string]string{"probe_0": "hk-1", "probe_1": "jp-1"}
+	if !reflect.DeepEqual(resp.Data.TagMap, wantTagMap) {
+		t.Fatalf("tag map mismatch: got %v, want %v", resp.Data.TagMap, wantTagMap)
+	}
+	if len(resp.Data.Excluded) != 1 || resp.Data.Excluded[0].Tag != "xhttp-1" {
+		t.Fatalf("excluded nodes mismatch: got %+v, want only xhttp-1", resp.Data.Excluded)
+	}
+
+	outboundsByTag := make(map[string]map[string]interface{})
+	for _, ob := range resp.Data.Config.Outbounds {
+		tag, _ := ob["tag"].(string)
+		outboundsByTag[tag] = ob
+	}
+	for _, tag := range []string{"probe_0", "probe_1"} {
+		if outboundsByTag[tag]["type"] != "trojan" {
+			t.Fatalf("expected trojan outbound %s, got %v", tag, outboundsByTag[tag])
+		}
+	}
+	geo, ok := outboundsByTag["GeoSelector"]
+	if !ok || geo["type"] != "selector" {
+		t.Fatalf("expected GeoSelector selector outbound, got %v", geo)
+	}
+	if got := geo["outbounds"]; !reflect.DeepEqual(got, []interface{}{"probe_0", "probe_1"}) {
+		t.Fatalf("GeoSelector members mismatch: got %v, want [probe_0 probe_1]", got)
+	}
+}
@@ -0,0 +1,108 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xiaobei/singbox-manager/internal/storage"
+)
+
+// profileSummary is a profile as listed, without its full snapshot
+type profileSummary struct {
+	ID          string    `json:"id"`
+	Name        string    `json:"name"`
+	FilterCount int       `json:"filter_count"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+func (s *Server) getProfiles(c *gin.Context) {
+	profiles := s.store.GetProfiles()
+	summaries := make([]profileSummary, 0, len(profiles))
+	for _, p := range profiles {
+		summaries = append(summaries, profileSummary{
+			ID:          p.ID,
+			Name:        p.Name,
+			FilterCount: len(p.Filters),
+			CreatedAt:   p.CreatedAt,
+			UpdatedAt:   p.UpdatedAt,
+		})
+	}
+	c.JSON(http.StatusOK, gin.H{"data": summaries})
+}
+
+func (s *Server) createProfile(c *gin.Context) {
+	var req struct {
+		Name string `json:"name"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if strings.TrimSpace(req.Name) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Profile name is required"})
+		return
+	}
+
+	profile, err := s.snapshotProfile(req.Name)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": profile})
+}
+
+func (s *Server) activateProfile(c *gin.Context) {
+	profile := s.store.GetProfile(c.Param("id"))
+	if profile == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Profile not found"})
+		return
+	}
+
+	if err := s.restoreProfile(profile); err != nil {
+		var problems settingsProblemsError
+		if errors.As(err, &problems) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Profile settings are invalid: " + err.Error(), "problems": problems})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := s.rebuildAndReload(); err != nil {
+		c.JSON(http.StatusOK, gin.H{"data": profile, "warning": "Profile activated, but applying config failed: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": profile, "message": "Profile activated"})
+}
+
+// snapshotProfile saves the current filters and routing settings under name. Nodes are
+// not part of a profile, they stay shared across all of them.
+func (s *Server) snapshotProfile(name string) (*storage.Profile, error) {
+	return s.store.SaveProfile(storage.Profile{
+		Name:     name,
+		Filters:  s.store.GetFilters(),
+		Settings: storage.ProfileSettingsFrom(s.store.GetSettings()),
+	})
+}
+
+// restoreProfile replaces the filters and the routing settings with the profile's
+// snapshot. Everything else, credentials, inbounds and paths included, is kept as it is:
+// a profile only switches the routing setup. The result is validated before anything is saved.
+func (s *Server) restoreProfile(profile *storage.Profile) error {
+	settings := s.store.GetSettings()
+	profile.Settings.ApplyTo(settings)
+
+	// Ports and bind addresses are not part of a profile, so they are not probed again
+	if problems := collectSettingsProblems(settings, skipPortProbe, nil); len(problems) > 0 {
+		return settingsProblemsError(problems)
+	}
+
+	if err := s.store.ReplaceFilters(profile.Filters); err != nil {
+		return err
+	}
+	return s.store.UpdateSettings(settings)
+}
@@ -0,0 +1,156 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xiaobei/singbox-manager/internal/daemon"
+	"github.com/xiaobei/singbox-manager/internal/storage"
+)
+
+func TestProfileSnapshotActivateRoundTrip(t *testing.T) {
+	store, err := storage.NewSQLiteStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("create sqlite store: %v", err)
+	}
+	t.Cleanup(func() { _ = store.Close() })
+	s := &Server{store: store}
+
+	work := storage.Filter{ID: "work", Name: "Work", Mode: "urltest", Include: []string{"office"}, Enabled: true}
+	if err := store.AddFilter(work); err != nil {
+		t.Fatalf("add filter: %v", err)
+	}
+	settings := store.GetSettings()
+	settings.FinalOutbound = "Work"
+	settings.MixedPort = 7890
+	if err := store.UpdateSettings(settings); err != nil {
+		t.Fatalf("update settings: %v", err)
+	}
+
+	saved, err := s.snapshotProfile(" work ")
+	if err != nil {
+		t.Fatalf("snapshot profile: %v", err)
+	}
+	if saved.Name != "work" || saved.ID == "" {
+		t.Fatalf("saved profile mismatch: got %+v", saved)
+	}
+
+	// Switch to a different setup, including an install-specific path that must survive activation
+	home := storage.Filter{ID: "home", Name: "Home", Mode: "select", Enabled: true}
+	if err := store.ReplaceFilters([]storage.Filter{home}); err != nil {
+		t.Fatalf("replace filters: %v", err)
+	}
+	settings.FinalOutbound = "Home"
+	settings.MixedPort = 2080
+	settings.ConfigPath = "custom/config.json"
+	if err := store.UpdateSettings(settings); err != nil {
+		t.Fatalf("update settings: %v", err)
+	}
+
+	profile := store.GetProfile(saved.ID)
+	if profile == nil {
+		t.Fatalf("expected profile %s to be stored", saved.ID)
+	}
+	if err := s.restoreProfile(profile); err != nil {
+		t.Fatalf("restore profile: %v", err)
+	}
+
+	filters := store.GetFilters()
+	if len(filters) != 1 || filters[0].ID != "work" || !reflect.DeepEqual(filters[0].Include, []string{"office"}) {
+		t.Fatalf("restored filters mismatch: got %+v", filters)
+	}
+	restored := store.GetSettings()
+	if restored.FinalOutbound != "Work" {
+		t.Fatalf("restored final outbound mismatch: got %q, want Work", restored.FinalOutbound)
+	}
+	if restored.MixedPort != 2080 {
+		t.Fatalf("mixed port mismatch: got %d, want 2080 kept since inbounds are not part of a profile", restored.MixedPort)
+	}
+	if restored.ConfigPath != "custom/config.json" {
+		t.Fatalf("config path mismatch: got %q, want it kept across activation", restored.ConfigPath)
+	}
+
+	// Re-capturing under the same name updates the profile in place
+	resaved, err := s.snapshotProfile("work")
+	if err != nil {
+		t.Fatalf("resnapshot profile: %v", err)
+	}
+	if resaved.ID != saved.ID || len(store.GetProfiles()) != 1 {
+		t.Fatalf("expected same-name snapshot to replace profile %s, got %+v", saved.ID, store.GetProfiles())
+	}
+}
+
+func TestActivateProfile_KeepsCredentials(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	dir := t.TempDir()
+	store, err := storage.NewSQLiteStore(dir)
+	if err != nil {
+		t.Fatalf("create sqlite store: %v", err)
+	}
+	t.Cleanup(func() { _ = store.Close() })
+
+	settings := store.GetSettings()
+	settings.AutoApply = false
+	settings.FinalOutbound = "DIRECT"
+	settings.ClashAPISecret = "old-secret"
+	settings.SocksPassword = "old-socks"
+	if err := store.UpdateSettings(settings); err != nil {
+		t.Fatalf("update settings: %v", err)
+	}
+	s := &Server{
+		store:            store,
+		processManager:   daemon.NewProcessManager(filepath.Join(dir, "missing-sing-box"), filepath.Join(dir, "config.json"), dir),
+		unsupportedNodes: map[string]UnsupportedNodeInfo{},
+	}
+	router := gin.New()
+	router.POST("/profiles", s.createProfile)
+	router.POST("/profiles/:id/activate", s.activateProfile)
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/profiles", strings.NewReader(`{"name":"travel"}`)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("create status mismatch: got %d, want %d (%s)", rec.Code, http.StatusOK, rec.Body.String())
+	}
+	if strings.Contains(rec.Body.String(), "old-secret") || strings.Contains(rec.Body.String(), "old-socks") {
+		t.Fatalf("create response leaks credentials: %s", rec.Body.String())
+	}
+	var created struct {
+		Data storage.Profile `json:"data"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &created); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+
+	// Rotate the credentials and change the routing after the snapshot
+	settings.ClashAPISecret = "new-secret"
+	settings.SocksPassword = "new-socks"
+	settings.WebhookURL = "https://hooks.example.com/sbm"
+	settings.FinalOutbound = "Proxy"
+	if err := store.UpdateSettings(settings); err != nil {
+		t.Fatalf("update settings: %v", err)
+	}
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/profiles/"+created.Data.ID+"/activate", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("activate status mismatch: got %d, want %d (%s)", rec.Code, http.StatusOK, rec.Body.String())
+	}
+	if strings.Contains(rec.Body.String(), "secret\"") || strings.Contains(rec.Body.String(), "socks\"") {
+		t.Fatalf("activate response leaks credentials: %s", rec.Body.String())
+	}
+
+	got := store.GetSettings()
+	if got.FinalOutbound != "DIRECT" {
+		t.Fatalf("final outbound mismatch: got %q, want DIRECT from the profile", got.FinalOutbound)
+	}
+	if got.ClashAPISecret != "new-secret" || got.SocksPassword != "new-socks" || got.WebhookURL != "https://hooks.example.com/sbm" {
+		t.Fatalf("credentials were reverted: secret %q socks %q webhook %q", got.ClashAPISecret, got.SocksPassword, got.WebhookURL)
+	}
+}
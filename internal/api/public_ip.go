@@ -0,0 +1,114 @@
+package api
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	neturl "net/url"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xiaobei/singbox-manager/internal/builder"
+	"github.com/xiaobei/singbox-manager/internal/storage"
+)
+
+const (
+	publicIPCacheTTL       = 30 * time.Second
+	publicIPRequestTimeout = 10 * time.Second
+)
+
+// PublicIPResult describes the egress IP seen through the running proxy
+type PublicIPResult struct {
+	IP        string           `json:"ip"`
+	Geo       *storage.GeoData `json:"geo"`
+	CheckedAt time.Time        `json:"checked_at"`
+	Cached    bool             `json:"cached"`
+}
+
+// fetchPublicIP asks an ip-api.com compatible echo service for the egress IP through the given proxy.
+func fetchPublicIP(proxyURL, echoURL string) (*PublicIPResult, error) {
+	proxy, err := neturl.Parse(proxyURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxy URL: %w", err)
+	}
+
+	client := &http.Client{
+		Timeout:   publicIPRequestTimeout,
+		Transport: &http.Transport{Proxy: http.ProxyURL(proxy)},
+	}
+
+	apiResp, err := queryIPAPI(client, echoURL)
+	if err != nil {
+		return nil, err
+	}
+
+	geo := geoDataFromIPAPI(apiResp)
+	return &PublicIPResult{
+		IP:        apiResp.Query,
+		Geo:       geo,
+		CheckedAt: geo.Timestamp,
+	}, nil
+}
+
+// cachedPublicIP returns the cached result while it is still fresh.
+func (s *Server) cachedPublicIP(now time.Time) *PublicIPResult {
+	s.publicIPMu.Lock()
+	defer s.publicIPMu.Unlock()
+	if s.publicIPCache == nil || now.Sub(s.publicIPCache.CheckedAt) > publicIPCacheTTL {
+		return nil
+	}
+	result := *s.publicIPCache
+	result.Cached = true
+	return &result
+}
+
+func (s *Server) storePublicIP(result *PublicIPResult) {
+	s.publicIPMu.Lock()
+	s.publicIPCache = result
+	s.publicIPMu.Unlock()
+}
+
+// getProxyPublicIP returns the public IP and geo seen through the mixed inbound.
+func (s *Server) getProxyPublicIP(c *gin.Context) {
+	if !s.processManager.IsRunning() {
+		c.JSON(http.StatusOK, gin.H{"data": nil, "running": false})
+		return
+	}
+
+	if c.Query("refresh") != "true" {
+		if cached := s.cachedPublicIP(time.Now()); cached != nil {
+			c.JSON(http.StatusOK, gin.H{"data": cached, "running": true})
+			return
+		}
+	}
+
+	settings := s.store.GetSettings()
+	if !settings.MixedEnabled || settings.MixedPort == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "mixed inbound is not enabled"})
+		return
+	}
+
+	result, err := fetchPublicIP(mixedInboundURL(settings), geoIPURL)
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+		return
+	}
+	s.storePublicIP(result)
+
+	c.JSON(http.StatusOK, gin.H{"data": result, "running": true})
+}
+
+// mixedInboundURL is the proxy URL of the mixed inbound, on the address it listens on.
+// A wildcard listen address is reached through loopback.
+func mixedInboundURL(settings *storage.Settings) string {
+	host := builder.InboundListenAddress(settings)
+	if ip := net.ParseIP(host); ip != nil && ip.IsUnspecified() {
+		if ip.To4() != nil {
+			host = "127.0.0.1"
+		} else {
+			host = "::1"
+		}
+	}
+	return "http://" + net.JoinHostPort(host, strconv.Itoa(settings.MixedPort))
+}
@@ -0,0 +1,96 @@
+package api
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/xiaobei/singbox-manager/internal/storage"
+)
+
+func TestFetchPublicIP_ThroughLocalProxy(t *testing.T) {
+	echo := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{"status":"success","country":"Germany","countryCode":"DE","city":"Frankfurt","query":"203.0.113.7"}`)
+	}))
+	defer echo.Close()
+
+	var proxied atomic.Int32
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied.Add(1)
+		if !r.URL.IsAbs() {
+			t.Errorf("expected absolute proxy request URL, got %q", r.URL.String())
+		}
+		outReq, err := http.NewRequest(r.Method, r.URL.String(), nil)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		resp, err := http.DefaultTransport.RoundTrip(outReq)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		defer resp.Body.Close()
+		w.WriteHeader(resp.StatusCode)
+		_, _ = io.Copy(w, resp.Body)
+	}))
+	defer proxy.Close()
+
+	result, err := fetchPublicIP(proxy.URL, echo.URL+"/json/")
+	if err != nil {
+		t.Fatalf("fetch public ip: %v", err)
+	}
+	if proxied.Load() != 1 {
+		t.Fatalf("proxy request count mismatch: got %d, want 1", proxied.Load())
+	}
+	if result.IP != "203.0.113.7" {
+		t.Fatalf("ip mismatch: got %q, want 203.0.113.7", result.IP)
+	}
+	if result.Geo == nil || result.Geo.CountryCode != "DE" || result.Geo.City != "Frankfurt" {
+		t.Fatalf("geo mismatch: %+v", result.Geo)
+	}
+}
+
+func TestCachedPublicIP_ExpiresAfterTTL(t *testing.T) {
+	s := &Server{}
+	now := time.Now()
+	s.storePublicIP(&PublicIPResult{IP: "203.0.113.7", CheckedAt: now})
+
+	cached := s.cachedPublicIP(now.Add(publicIPCacheTTL / 2))
+	if cached == nil || !cached.Cached || cached.IP != "203.0.113.7" {
+		t.Fatalf("expected fresh cached result, got %+v", cached)
+	}
+	if s.publicIPCache.Cached {
+		t.Fatalf("expected stored result to stay unmarked")
+	}
+	if got := s.cachedPublicIP(now.Add(publicIPCacheTTL + time.Second)); got != nil {
+		t.Fatalf("expected expired cache, got %+v", got)
+	}
+}
+
+func TestMixedInboundURL_FollowsListenAddress(t *testing.T) {
+	tests := []struct {
+		listen   string
+		allowLAN bool
+		want     string
+	}{
+		{want: "http://127.0.0.1:2080"},
+		{allowLAN: true, want: "http://127.0.0.1:2080"},
+		{listen: "192.168.1.5", want: "http://192.168.1.5:2080"},
+		{listen: "::", want: "http://[::1]:2080"},
+		{listen: "fd00::5", want: "http://[fd00::5]:2080"},
+	}
+	for _, tt := range tests {
+		settings := storage.DefaultSettings()
+		settings.MixedPort = 2080
+		settings.ListenAddress = tt.listen
+		settings.AllowLAN = tt.allowLAN
+		if got := mixedInboundURL(settings); got != tt.want {
+			t.Fatalf("mixed inbound url mismatch for %q: got %q, want %q", tt.listen, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package api
+
+import (
+	"time"
+
+	"github.com/xiaobei/singbox-manager/internal/logger"
+	"github.com/xiaobei/singbox-manager/internal/storage"
+)
+
+// deferAutoApply writes the new config during quiet hours but leaves the running
+// sing-box alone, marking the apply pending until the window ends.
+func (s *Server) deferAutoApply(quiet storage.QuietHours) error {
+	if _, err := s.regenerateAndSaveConfig(); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	s.quietMu.Lock()
+	defer s.quietMu.Unlock()
+	s.applyPending = true
+	if s.quietTimer == nil {
+		wait := quiet.NextEnd(now).Sub(now)
+		s.quietTimer = time.AfterFunc(wait, s.applyDeferredConfig)
+		logger.Printf("[auto-apply] Quiet hours: config saved, reload deferred by %s", wait.Round(time.Second))
+	}
+	return nil
+}
+
+// applyDeferredConfig reloads sing-box with the pending config once quiet hours end.
+func (s *Server) applyDeferredConfig() {
+	s.quietMu.Lock()
+	s.quietTimer = nil
+	pending := s.applyPending
+	s.quietMu.Unlock()
+	if !pending {
+		return
+	}
+
+	// The window may have been moved while the timer was waiting
+	if quiet := s.store.GetSettings().QuietHours; quiet.Contains(time.Now()) {
+		if err := s.deferAutoApply(quiet); err != nil {
+			logger.Printf("[auto-apply] Deferred apply failed: %v", err)
+		}
+		return
+	}
+
+	s.clearPendingApply()
+	if err := s.rebuildAndReload(); err != nil {
+		logger.Printf("[auto-apply] Deferred apply failed: %v", err)
+	}
+}
+
+// clearPendingApply drops a deferred apply, e.g. after a manual apply picked it up.
+func (s *Server) clearPendingApply() {
+	s.quietMu.Lock()
+	defer s.quietMu.Unlock()
+	s.applyPending = false
+	if s.quietTimer != nil {
+		s.quietTimer.Stop()
+		s.quietTimer = nil
+	}
+}
+
+// isApplyPending reports whether a config change is waiting for quiet hours to end.
+func (s *Server) isApplyPending() bool {
+	s.quietMu.Lock()
+	defer s.quietMu.Unlock()
+	return s.applyPending
+}
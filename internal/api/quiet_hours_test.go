@@ -0,0 +1,79 @@
+package api
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/xiaobei/singbox-manager/internal/daemon"
+	"github.com/xiaobei/singbox-manager/internal/storage"
+)
+
+func TestRunAutoApply_QuietHoursWritesWithoutRestart(t *testing.T) {
+	dir := t.TempDir()
+	store, err := storage.NewSQLiteStore(dir)
+	if err != nil {
+		t.Fatalf("create sqlite store: %v", err)
+	}
+	t.Cleanup(func() { _ = store.Close() })
+
+	now := time.Now()
+	settings := store.GetSettings()
+	settings.ConfigPath = filepath.Join(dir, "config.json")
+	settings.AutoApply = true
+	settings.QuietHours = storage.QuietHours{
+		Start: now.Add(-time.Hour).Format("15:04"),
+		End:   now.Add(time.Hour).Format("15:04"),
+	}
+	if err := store.UpdateSettings(settings); err != nil {
+		t.Fatalf("update settings: %v", err)
+	}
+	if err := os.WriteFile(settings.ConfigPath, []byte(`{"placeholder": true}`), 0644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+
+	// Fake sing-box: every check passes, every run is recorded
+	runs := filepath.Join(dir, "runs.log")
+	binPath := filepath.Join(dir, "sing-box")
+	script := "#!/bin/sh\n" +
+		"if [ \"$1\" = version ]; then echo 'sing-box version 1.12.0'; exit 0; fi\n" +
+		"if [ \"$1\" = check ]; then exit 0; fi\n" +
+		"echo run >> '" + runs + "'\n" +
+		"while :; do sleep 1; done\n"
+	if err := os.WriteFile(binPath, []byte(script), 0755); err != nil {
+		t.Fatalf("write fake sing-box: %v", err)
+	}
+
+	pm := daemon.NewProcessManager(binPath, settings.ConfigPath, dir)
+	t.Cleanup(func() { _ = pm.Stop() })
+	if err := pm.Start(); err != nil {
+		t.Fatalf("start sing-box: %v", err)
+	}
+	pid := pm.GetPID()
+
+	s := &Server{store: store, processManager: pm, unsupportedNodes: map[string]UnsupportedNodeInfo{}}
+	t.Cleanup(s.clearPendingApply)
+
+	if err := s.runAutoApply(); err != nil {
+		t.Fatalf("auto apply: %v", err)
+	}
+
+	written, err := os.ReadFile(settings.ConfigPath)
+	if err != nil {
+		t.Fatalf("read config: %v", err)
+	}
+	if strings.Contains(string(written), "placeholder") {
+		t.Fatalf("config was not written during quiet hours: %s", written)
+	}
+	if !s.isApplyPending() {
+		t.Fatal("expected the apply to be marked pending")
+	}
+	if !pm.IsRunning() || pm.GetPID() != pid {
+		t.Fatalf("sing-box was restarted during quiet hours: pid %d, want %d", pm.GetPID(), pid)
+	}
+	if data, _ := os.ReadFile(runs); strings.Count(string(data), "run") != 1 {
+		t.Fatalf("sing-box run count mismatch: got %q, want one run", data)
+	}
+}
@@ -0,0 +1,18 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// getRouteRules returns the route rules in the order sing-box evaluates them, each
+// annotated with the setting that produced it, so rule precedence can be read
+// without digging through the generated config.
+func (s *Server) getRouteRules(c *gin.Context) {
+	rules, final := s.newConfigBuilder().ResolvedRouteRules()
+	c.JSON(http.StatusOK, gin.H{"data": gin.H{
+		"rules": rules,
+		"final": final,
+	}})
+}
@@ -0,0 +1,34 @@
+package api
+
+import (
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xiaobei/singbox-manager/internal/builder"
+)
+
+// simulateRoute reports which route rule a domain or IP would hit in the current
+// config and the outbound it ends up on, without involving sing-box.
+func (s *Server) simulateRoute(c *gin.Context) {
+	domain := strings.TrimSpace(c.Query("domain"))
+	ip := strings.TrimSpace(c.Query("ip"))
+	if (domain == "") == (ip == "") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "exactly one of domain or ip is required"})
+		return
+	}
+	if ip != "" && net.ParseIP(ip) == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid ip address"})
+		return
+	}
+
+	cfg, err := s.newConfigBuilder().Build()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	result := builder.SimulateRoute(cfg.Route, cfg.Outbounds, builder.RouteTarget{Domain: domain, IP: ip})
+	c.JSON(http.StatusOK, gin.H{"data": result})
+}
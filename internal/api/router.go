@@ -73,7 +73,8 @@ type Server struct {
 	port           int    // Web service port
 	version        string // sbm version
 
-	eventBus *events.Bus
+	eventBus         *events.Bus
+	dashboardManager *kernel.DashboardManager
 
 	unsupportedNodes   map[string]UnsupportedNodeInfo
 	unsupportedNodesMu sync.RWMutex
@@ -81,6 +82,7 @@ type Server struct {
 	importMu           sync.Mutex
 
 	verifyInProgress atomic.Bool
+	drainInProgress  atomic.Bool // a restart is waiting for connections to drain
 
 	monitoringMu           sync.Mutex
 	lastTrafficSampleAt    time.Time
@@ -92,9 +94,33 @@ type Server struct {
 	clientCumTraffic   map[string]clientCumulativeEntry // key = source IP
 	resourceCumTraffic map[string]clientCumulativeEntry // key = source IP + host
 
+	// Client quota alerting: last check time and the period each alert was sent for
+	quotaMu        sync.Mutex
+	quotaCheckedAt time.Time
+	quotaAlerted   map[string]string // key = source IP + period kind
+
 	watchdogMu           sync.Mutex
 	watchdogFailStreak   map[string]int
 	watchdogCooldownTill map[string]time.Time
+
+	publicIPMu    sync.Mutex
+	publicIPCache *PublicIPResult
+
+	// Auto-apply deferred by quiet hours, reloaded when the window ends
+	quietMu      sync.Mutex
+	applyPending bool
+	quietTimer   *time.Timer
+
+	// Compact status: the config hash recorded at apply time and the cached active node
+	statusMu           sync.Mutex
+	appliedConfigHash  string // hash of the config last written to the configured file
+	configChanged      bool   // config inputs changed after that write
+	statusActiveNode   string
+	statusActiveNodeAt time.Time
+
+	applyDebouncer *applyDebouncer
+	trafficTicker  *trafficAggregatorTicker
+	jobs           *jobRegistry
 }
 
 // NewServer creates an API server
@@ -116,6 +142,7 @@ func NewServer(store storage.Store, processManager *daemon.ProcessManager, probe
 		launchdManager:       launchdManager,
 		systemdManager:       systemdManager,
 		kernelManager:        kernelManager,
+		dashboardManager:     kernel.NewDashboardManager(store.GetDataDir(), store.GetSettings),
 		scheduler:            service.NewScheduler(store, subService),
 		router:               gin.Default(),
 		sbmPath:              sbmPath,
@@ -125,8 +152,12 @@ func NewServer(store storage.Store, processManager *daemon.ProcessManager, probe
 		unsupportedNodes:     make(map[string]UnsupportedNodeInfo),
 		watchdogFailStreak:   make(map[string]int),
 		watchdogCooldownTill: make(map[string]time.Time),
+		jobs:                 newJobRegistry(jobTTL),
 	}
 
+	s.applyDebouncer = newApplyDebouncer(autoApplyDebounceWindow, autoApplyMaxDelay, s.runAutoApply, s.reportAutoApply)
+	s.kernelManager.SetInstallCheck(s.kernelInstallImpact)
+
 	// Wire event bus to services
 	s.scheduler.SetEventBus(eventBus)
 	s.subService.SetEventBus(eventBus)
@@ -138,6 +169,7 @@ func NewServer(store storage.Store, processManager *daemon.ProcessManager, probe
 	// Set scheduler callbacks
 	s.scheduler.SetUpdateCallback(s.autoApplyConfig)
 	s.scheduler.SetVerificationCallback(s.RunVerification)
+	s.scheduler.SetHealthCheckCallback(s.RunPipelineHealthCheck)
 
 	s.setupRoutes()
 	s.startTrafficAggregator()
@@ -156,9 +188,9 @@ func (s *Server) StopScheduler() {
 }
 
 func (s *Server) storeAccessGuard(c *gin.Context) {
-	// Import acquires write lock itself. SSE stream is long-lived and should not block imports.
+	// Import and vacuum acquire write lock themselves. SSE stream is long-lived and should not block imports.
 	path := c.Request.URL.Path
-	if path == "/api/database/import" || path == "/api/events/stream" || strings.HasPrefix(path, "/api/monitoring/ws/") {
+	if path == "/api/database/import" || path == "/api/database/vacuum" || path == "/api/events/stream" || strings.HasPrefix(path, "/api/monitoring/ws/") {
 		c.Next()
 		return
 	}
@@ -215,6 +247,9 @@ func (s *Server) setupRoutes() {
 		MaxAge:           12 * time.Hour,
 	}))
 
+	// Compress responses (large node lists, dumps, history); SSE/WebSocket stay raw
+	s.router.Use(gzipMiddleware(gzipStreamingPaths))
+
 	// API route group
 	api := s.router.Group("/api")
 	api.Use(s.storeAccessGuard)
@@ -230,12 +265,20 @@ func (s *Server) setupRoutes() {
 		// Filter management
 		api.GET("/filters", s.getFilters)
 		api.POST("/filters", s.addFilter)
+		api.POST("/filters/preview", s.previewFilter)
 		api.PUT("/filters/:id", s.updateFilter)
 		api.DELETE("/filters/:id", s.deleteFilter)
 
+		// Profiles
+		api.GET("/profiles", s.getProfiles)
+		api.POST("/profiles", s.createProfile)
+		api.POST("/profiles/:id/activate", s.activateProfile)
+
 		// Settings
 		api.GET("/settings", s.getSettings)
 		api.PUT("/settings", s.updateSettings)
+		api.POST("/settings/validate", s.validateSettings)
+		api.POST("/settings/rotate-secret", s.rotateClashAPISecret)
 
 		// System hosts
 		api.GET("/system-hosts", s.getSystemHosts)
@@ -244,14 +287,25 @@ func (s *Server) setupRoutes() {
 		api.POST("/config/generate", s.generateConfig)
 		api.POST("/config/apply", s.applyConfig)
 		api.GET("/config/preview", s.previewConfig)
+		api.GET("/config/outbounds", s.getConfigOutbounds)
+		api.GET("/config/status", s.getConfigStatus)
 		api.GET("/config/saved", s.savedConfig)
 
+		// Route simulation
+		api.GET("/route/simulate", s.simulateRoute)
+		api.GET("/route/rules", s.getRouteRules)
+		api.GET("/rules/stats", s.getRuleStats)
+
+		// Global search
+		api.GET("/search", s.globalSearch)
+
 		// Service management
 		api.GET("/service/status", s.getServiceStatus)
 		api.POST("/service/start", s.startService)
 		api.POST("/service/stop", s.stopService)
 		api.POST("/service/restart", s.restartService)
 		api.POST("/service/reload", s.reloadService)
+		api.POST("/service/reload-file", s.reloadServiceFromFile)
 
 		// launchd management
 		api.GET("/launchd/status", s.getLaunchdStatus)
@@ -281,18 +335,31 @@ func (s *Server) setupRoutes() {
 		// Nodes
 		api.GET("/nodes", s.getAllNodes)
 		api.GET("/nodes/countries", s.getCountryGroups)
+		api.GET("/nodes/usage", s.getNodesUsage)
+		api.GET("/nodes/scores", s.getNodeScores)
+		api.GET("/nodes/by-endpoint", s.getNodeByEndpoint)
+		api.GET("/nodes/clusters", s.getNodeClusters)
+		api.PUT("/nodes/countries/:code/override", s.updateCountryOverride)
 		api.GET("/nodes/country/:code", s.getNodesByCountry)
 		api.POST("/nodes/parse", s.parseNodeURL)
 		api.POST("/nodes/parse-bulk", s.parseNodeURLsBulk)
+		api.POST("/nodes/import-file", s.importNodesFile)
+		api.POST("/nodes/smart-import", s.smartImportNodes)
 		api.POST("/nodes/health-check", s.healthCheckNodes)
 		api.POST("/nodes/health-check-single", s.healthCheckSingleNode)
+		api.POST("/nodes/tcp-ping", s.tcpPingNodesHandler)
 		api.POST("/nodes/site-check", s.siteCheckNodes)
+		api.POST("/nodes/full-check", s.fullCheckNodes)
 		api.POST("/nodes/speed-test", s.speedCheckNodes)
 		api.GET("/nodes/unsupported", s.getUnsupportedNodes)
 		api.POST("/nodes/unsupported/recheck", s.recheckUnsupportedNodes)
+		api.POST("/nodes/unsupported/reconcile", s.reconcileUnsupportedNodes)
 		api.DELETE("/nodes/unsupported", s.clearUnsupportedNodes)
 		api.POST("/nodes/unsupported/delete", s.deleteUnsupportedNodes)
 
+		// Async jobs
+		api.GET("/jobs/:id", s.getJob)
+
 		// Unified nodes
 		api.GET("/nodes/unified", s.getUnifiedNodes)
 		api.POST("/nodes/unified", s.addUnifiedNode)
@@ -304,10 +371,15 @@ func (s *Server) setupRoutes() {
 		api.POST("/nodes/unified/:id/archive", s.archiveUnifiedNode)
 		api.POST("/nodes/unified/:id/unarchive", s.unarchiveUnifiedNode)
 		api.POST("/nodes/unified/:id/favorite", s.toggleNodeFavorite)
+		api.POST("/nodes/unified/:id/pin", s.toggleNodePinned)
+		api.PUT("/nodes/unified/:id/country", s.setUnifiedNodeCountry)
+		api.POST("/nodes/unified/:id/exclude-from-auto", s.toggleNodeExcludeFromAuto)
 		api.POST("/nodes/unified/bulk-promote", s.bulkPromoteNodes)
 		api.POST("/nodes/unified/bulk-archive", s.bulkArchiveNodes)
 		api.POST("/nodes/unified/bulk-unarchive", s.bulkUnarchiveNodes)
+		api.DELETE("/nodes/unified/archived", s.deleteOldArchivedNodes)
 		api.POST("/nodes/unified/export-links", s.exportNodeLinks)
+		api.POST("/nodes/reparse", s.reparseUnifiedNodes)
 		api.GET("/nodes/unified/counts", s.getNodeCounts)
 
 		// Verification
@@ -318,13 +390,21 @@ func (s *Server) setupRoutes() {
 		api.GET("/verification/status", s.getVerificationStatus)
 		api.POST("/verification/start", s.startVerificationScheduler)
 		api.POST("/verification/stop", s.stopVerificationScheduler)
+		api.POST("/verification/pause", s.pauseVerificationScheduler)
+		api.POST("/verification/resume", s.resumeVerificationScheduler)
 		api.GET("/pipeline/activity", s.getPipelineActivityLogs)
 
 		// Kernel management
 		api.GET("/kernel/info", s.getKernelInfo)
 		api.GET("/kernel/releases", s.getKernelReleases)
 		api.POST("/kernel/download", s.startKernelDownload)
+		api.POST("/kernel/download/cancel", s.cancelKernelDownload)
 		api.GET("/kernel/progress", s.getKernelProgress)
+		api.GET("/kernel/supports", s.getKernelSupports)
+
+		// Clash dashboard
+		api.POST("/clash-ui/install", s.installClashUI)
+		api.GET("/clash-ui/progress", s.getClashUIProgress)
 
 		// Proxy group management (Clash API proxy)
 		api.GET("/proxy/groups", s.getProxyGroups)
@@ -335,16 +415,25 @@ func (s *Server) setupRoutes() {
 		api.GET("/proxy/mode", s.getProxyMode)
 		api.PUT("/proxy/mode", s.setProxyMode)
 
+		// Egress public IP through the running proxy
+		api.GET("/proxy/public-ip", s.getProxyPublicIP)
+
 		// Monitoring
 		api.GET("/monitoring/overview", s.getMonitoringOverview)
 		api.GET("/monitoring/lifetime", s.getMonitoringLifetimeStats)
 		api.GET("/monitoring/history", s.getMonitoringHistory)
+		api.GET("/monitoring/history.csv", s.exportMonitoringHistoryCSV)
 		api.GET("/monitoring/clients", s.getMonitoringClients)
 		api.GET("/monitoring/clients/recent", s.getMonitoringRecentClients)
 		api.GET("/monitoring/clients/history", s.getMonitoringClientHistory)
+		api.GET("/monitoring/clients/quota", s.getClientQuotaStatus)
+		api.PUT("/monitoring/clients/quota", s.setClientQuota)
+		api.DELETE("/monitoring/clients/quota/:sourceIp", s.deleteClientQuota)
 		api.GET("/monitoring/resources", s.getMonitoringResources)
 		api.GET("/monitoring/nodes", s.getMonitoringNodesTraffic)
+		api.GET("/monitoring/traffic/by-country", s.getMonitoringTrafficByCountry)
 		api.GET("/monitoring/clients/:sourceIp/resources/history", s.getMonitoringClientResourcesHistory)
+		api.GET("/monitoring/connections/log", s.getMonitoringConnectionLog)
 		api.GET("/monitoring/ws/traffic", s.streamTrafficWebSocket)
 		api.GET("/monitoring/ws/connections", s.streamConnectionsWebSocket)
 
@@ -352,16 +441,19 @@ func (s *Server) setupRoutes() {
 		api.GET("/database/stats", s.getDatabaseStats)
 		api.GET("/database/export", s.exportDatabase)
 		api.POST("/database/import", s.importDatabase)
+		api.POST("/database/vacuum", s.vacuumDatabase)
 
 		// Debug API
 		api.GET("/debug/dump", s.debugDump)
 		api.GET("/debug/logs/singbox", s.debugSingboxLogs)
 		api.GET("/debug/logs/app", s.debugAppLogs)
 		api.GET("/debug/logs/probe", s.debugProbeLogs)
+		api.POST("/nodes/:tag/debug-connect", s.debugConnectNode)
 
 		// Probe management
 		api.GET("/probe/status", s.getProbeStatus)
 		api.POST("/probe/stop", s.stopProbe)
+		api.POST("/probe/preview", s.previewProbeConfig)
 
 		// GeoIP
 		api.GET("/nodes/geo", s.getAllGeoData)
@@ -370,6 +462,7 @@ func (s *Server) setupRoutes() {
 
 		// Diagnostics
 		api.GET("/diagnostic", s.getDiagnostic)
+		api.GET("/status", s.getCompactStatus)
 
 		// SSE event stream
 		api.GET("/events/stream", s.handleEventStream)
@@ -381,6 +474,7 @@ func (s *Server) setupRoutes() {
 		api.GET("/measurements/health/stats/bulk", s.getBulkHealthStats)
 		api.POST("/measurements/health", s.saveHealthMeasurements)
 		api.GET("/measurements/site", s.getSiteMeasurements)
+		api.GET("/measurements/site/matrix", s.getSiteMatrix)
 		api.POST("/measurements/site", s.saveSiteMeasurements)
 		api.GET("/measurements/speed/latest", s.getLatestSpeedMeasurements)
 	}
@@ -414,40 +508,81 @@ func (s *Server) Run(addr string) error {
 
 func (s *Server) getSubscriptions(c *gin.Context) {
 	subs := s.subService.GetAll()
-	c.JSON(http.StatusOK, gin.H{"data": subs})
+	c.JSON(http.StatusOK, gin.H{"data": redactSubscriptions(subs)})
 }
 
 func (s *Server) addSubscription(c *gin.Context) {
 	var req struct {
 		Name string `json:"name" binding:"required"`
 		URL  string `json:"url" binding:"required"`
+		storage.SubscriptionAuth
+		storage.SubscriptionPipeline
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if req.PipelineMinStability < 0 || req.PipelineMinStability > 100 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "pipeline_min_stability must be between 0 and 100"})
+		return
+	}
+	req.AuthHeader = strings.TrimSpace(req.AuthHeader)
+	if err := validateSubscriptionAuth(req.SubscriptionAuth); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
-	sub, err := s.subService.Add(req.Name, req.URL)
+	sub, err := s.subService.Add(req.Name, req.URL, req.SubscriptionAuth, req.SubscriptionPipeline)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	redacted := redactSubscription(*sub)
 
 	// Auto-apply config
 	if err := s.autoApplyConfig(); err != nil {
-		c.JSON(http.StatusOK, gin.H{"data": sub, "warning": "Added successfully, but auto-apply config failed: " + err.Error()})
+		c.JSON(http.StatusOK, gin.H{"data": redacted, "warning": "Added successfully, but auto-apply config failed: " + err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"data": sub})
+	c.JSON(http.StatusOK, gin.H{"data": redacted})
 }
 
 func (s *Server) updateSubscription(c *gin.Context) {
 	id := c.Param("id")
 
-	var sub storage.Subscription
-	if err := c.ShouldBindJSON(&sub); err != nil {
+	// Auth fields are pointers so an update that leaves them out keeps the stored credentials
+	var req struct {
+		storage.Subscription
+		AuthHeader *string `json:"auth_header"`
+		AuthValue  *string `json:"auth_value"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	sub := req.Subscription
+
+	if sub.PipelineMinStability < 0 || sub.PipelineMinStability > 100 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "pipeline_min_stability must be between 0 and 100"})
+		return
+	}
+	if current := s.subService.Get(id); current != nil {
+		sub.SubscriptionAuth = current.SubscriptionAuth
+	}
+	if req.AuthHeader != nil {
+		sub.AuthHeader = strings.TrimSpace(*req.AuthHeader)
+		if sub.AuthHeader == "" && req.AuthValue == nil {
+			// Clearing the header drops the value that went with it
+			sub.AuthValue = ""
+		}
+	}
+	// The client may echo the masked value back, which also keeps the stored one
+	if req.AuthValue != nil && *req.AuthValue != redactedAuthValue {
+		sub.AuthValue = *req.AuthValue
+	}
+	if err := validateSubscriptionAuth(sub.SubscriptionAuth); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
@@ -501,19 +636,27 @@ func (s *Server) refreshSubscription(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "Refreshed successfully"})
 }
 
+// refreshAllSubscriptions refreshes every enabled subscription. Per-subscription
+// failures are reported in data.failed instead of failing the whole request.
 func (s *Server) refreshAllSubscriptions(c *gin.Context) {
-	if err := s.subService.RefreshAll(); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
+	result, err := s.subService.RefreshAll()
+	if err != nil {
+		logger.Printf("[subscriptions] Refresh all: %d refreshed, %d failed: %v", result.Refreshed, len(result.Failed), err)
+	}
+
+	message := "Refreshed successfully"
+	if len(result.Failed) > 0 {
+		message = fmt.Sprintf("Refreshed %d subscription(s), %d failed", result.Refreshed, len(result.Failed))
 	}
 
 	// Auto-apply config
-	if err := s.autoApplyConfig(); err != nil {
-		c.JSON(http.StatusOK, gin.H{"message": "Refreshed successfully, but auto-apply config failed: " + err.Error()})
-		return
+	if result.Refreshed > 0 {
+		if err := s.autoApplyConfig(); err != nil {
+			message += ", but auto-apply config failed: " + err.Error()
+		}
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Refreshed successfully"})
+	c.JSON(http.StatusOK, gin.H{"message": message, "data": result})
 }
 
 // ==================== Filter API ====================
@@ -530,6 +673,12 @@ func (s *Server) addFilter(c *gin.Context) {
 		return
 	}
 
+	if err := validateFilterURLTest(filter); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	warnings := filterURLTestWarnings(c, filter)
+
 	// Generate ID
 	filter.ID = uuid.New().String()
 
@@ -538,13 +687,17 @@ func (s *Server) addFilter(c *gin.Context) {
 		return
 	}
 
+	response := gin.H{"data": filter}
+	if len(warnings) > 0 {
+		response["warnings"] = warnings
+	}
+
 	// Auto-apply config
 	if err := s.autoApplyConfig(); err != nil {
-		c.JSON(http.StatusOK, gin.H{"data": filter, "warning": "Added successfully, but auto-apply config failed: " + err.Error()})
-		return
+		response["warning"] = "Added successfully, but auto-apply config failed: " + err.Error()
 	}
 
-	c.JSON(http.StatusOK, gin.H{"data": filter})
+	c.JSON(http.StatusOK, response)
 }
 
 func (s *Server) updateFilter(c *gin.Context) {
@@ -556,19 +709,29 @@ func (s *Server) updateFilter(c *gin.Context) {
 		return
 	}
 
+	if err := validateFilterURLTest(filter); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	warnings := filterURLTestWarnings(c, filter)
+
 	filter.ID = id
 	if err := s.store.UpdateFilter(filter); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
+	response := gin.H{"message": "Updated successfully"}
+	if len(warnings) > 0 {
+		response["warnings"] = warnings
+	}
+
 	// Auto-apply config
 	if err := s.autoApplyConfig(); err != nil {
-		c.JSON(http.StatusOK, gin.H{"message": "Updated successfully, but auto-apply config failed: " + err.Error()})
-		return
+		response["message"] = "Updated successfully, but auto-apply config failed: " + err.Error()
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Updated successfully"})
+	c.JSON(http.StatusOK, response)
 }
 
 func (s *Server) deleteFilter(c *gin.Context) {
@@ -610,6 +773,12 @@ func (s *Server) updateSettings(c *gin.Context) {
 	}
 	settings.ProxyMode = storage.NormalizeProxyMode(settings.ProxyMode)
 
+	if problems := collectSettingsProblems(&settings, skipPortProbe, nil); len(problems) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": settingsProblemsError(problems).Error(), "problems": problems})
+		return
+	}
+	settings.NoNodesMode = storage.NormalizeNoNodesMode(settings.NoNodesMode)
+
 	// Handle secret based on LAN access setting
 	if settings.AllowLAN {
 		// When LAN access is enabled and secret is empty, auto-generate one
@@ -631,6 +800,7 @@ func (s *Server) updateSettings(c *gin.Context) {
 
 	// Restart scheduler (interval may have been updated)
 	s.scheduler.Restart()
+	s.reconfigureTrafficAggregator()
 
 	// Auto-apply config
 	if err := s.autoApplyConfig(); err != nil {
@@ -686,6 +856,10 @@ func (s *Server) exportDatabase(c *gin.Context) {
 	c.File(dbPath)
 }
 
+// importSchedulerPauseTimeout bounds how long an import keeps the scheduler paused
+// if the handler never gets to swap or recover the store
+const importSchedulerPauseTimeout = 10 * time.Minute
+
 func (s *Server) importDatabase(c *gin.Context) {
 	file, err := c.FormFile("database")
 	if err != nil {
@@ -742,7 +916,7 @@ func (s *Server) importDatabase(c *gin.Context) {
 	defer s.storeSwapMu.Unlock()
 
 	wasSchedulerRunning := s.scheduler.IsRunning()
-	s.scheduler.Stop()
+	s.scheduler.Pause("database import", importSchedulerPauseTimeout)
 
 	dbPath := filepath.Join(dataDir, "data.db")
 	backupPath := dbPath + ".backup-import"
@@ -906,15 +1080,22 @@ func (s *Server) swapStoreDependencies(newStore storage.Store, startScheduler bo
 	newScheduler.SetEventBus(s.eventBus)
 	newScheduler.SetUpdateCallback(s.autoApplyConfig)
 	newScheduler.SetVerificationCallback(s.RunVerification)
+	newScheduler.SetHealthCheckCallback(s.RunPipelineHealthCheck)
 
+	if s.scheduler != nil {
+		// Drops any pause so its auto-resume cannot restart the old scheduler
+		s.scheduler.Stop()
+	}
 	s.store = newStore
 	s.subService = newSubService
 	s.scheduler = newScheduler
 	s.kernelManager = kernel.NewManager(newStore.GetDataDir(), newStore.GetSettings)
+	s.kernelManager.SetInstallCheck(s.kernelInstallImpact)
 
 	settings := s.store.GetSettings()
 	s.processManager.SetConfigPath(s.resolvePath(settings.ConfigPath))
 	s.reloadUnsupportedNodesFromStore()
+	s.reconfigureTrafficAggregator()
 
 	if startScheduler {
 		s.scheduler.Start()
@@ -992,22 +1173,59 @@ func (s *Server) savedConfig(c *gin.Context) {
 	c.Data(http.StatusOK, "application/json", data)
 }
 
+// applyConfig rebuilds the config into the configured file and restarts sing-box.
+// With a path (query or body) the config is only written there instead, for use on
+// other machines; the configured file and the running instance are left alone.
 func (s *Server) applyConfig(c *gin.Context) {
-	newUnsupported, err := s.regenerateAndSaveConfig()
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
+	var req struct {
+		Path string `json:"path"`
+	}
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	if path := strings.TrimSpace(c.Query("path")); path != "" {
+		req.Path = path
 	}
 
-	// Restart service
-	if s.processManager.IsRunning() {
-		if err := s.processManager.Restart(); err != nil {
+	var newUnsupported []UnsupportedNodeInfo
+	response := gin.H{"message": "Config applied"}
+	if path := strings.TrimSpace(req.Path); path != "" {
+		target, err := s.resolveConfigOutputPath(path)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		newUnsupported, err = s.writeConfigToPath(target)
+		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
+		response = gin.H{"message": "Config written", "data": gin.H{"path": target}}
+	} else {
+		var err error
+		newUnsupported, err = s.regenerateAndSaveConfig()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		s.clearPendingApply()
+
+		// Restart service
+		if s.processManager.IsRunning() {
+			deferred, err := s.restartSingbox()
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+			if deferred {
+				response["message"] = "Config applied, sing-box restarts once active connections drain"
+			}
+		}
 	}
 
-	response := gin.H{"message": "Config applied"}
 	if len(newUnsupported) > 0 {
 		tags := make([]string, len(newUnsupported))
 		for i, u := range newUnsupported {
@@ -1024,20 +1242,61 @@ func (s *Server) applyConfig(c *gin.Context) {
 }
 
 func (s *Server) buildConfig() (string, error) {
-	settings := s.store.GetSettings()
-	nodes := s.store.GetAllNodes()
-	filters := s.store.GetFilters()
-
-	b := builder.NewConfigBuilder(settings, nodes, filters)
-	return b.BuildJSON()
+	return s.newConfigBuilder().BuildJSON()
 }
 
 // buildAndValidateConfig generates config, validates it with sing-box check,
 // and iteratively removes unsupported nodes until validation passes.
 func (s *Server) buildAndValidateConfig() (string, []UnsupportedNodeInfo, error) {
-	settings := s.store.GetSettings()
+	configJSON, newUnsupported, tagToNode, err := s.checkConfigWithKernel(s.processManager.GetSingBoxPath(), s.installedKernelFeatures())
+	if err != nil {
+		return "", nil, err
+	}
+
+	// Config is valid — store new unsupported nodes
+	if len(newUnsupported) > 0 {
+		s.unsupportedNodesMu.Lock()
+		for _, info := range newUnsupported {
+			s.unsupportedNodes[info.Tag] = info
+		}
+		s.unsupportedNodesMu.Unlock()
+
+		// Persist to store
+		for _, info := range newUnsupported {
+			un := storage.UnsupportedNode{
+				NodeTag:    info.Tag,
+				Error:      info.Error,
+				DetectedAt: info.Time,
+			}
+			if n, ok := tagToNode[info.Tag]; ok {
+				un.Server = n.Server
+				un.ServerPort = n.ServerPort
+			}
+			if err := s.store.AddUnsupportedNode(un); err != nil {
+				logger.Printf("[config] Failed to persist unsupported node %s: %v", info.Tag, err)
+			}
+		}
+
+		// Log excluded nodes
+		tags := make([]string, len(newUnsupported))
+		for i, u := range newUnsupported {
+			if strings.TrimSpace(u.DisplayName) != "" {
+				tags[i] = u.DisplayName
+			} else {
+				tags[i] = u.Tag
+			}
+		}
+		logger.Printf("[config] Excluded %d unsupported node(s): %s", len(newUnsupported), strings.Join(tags, ", "))
+	}
+	return configJSON, newUnsupported, nil
+}
+
+// checkConfigWithKernel builds the config and runs `sing-box check` with the given binary,
+// excluding nodes it rejects until the config passes. Nodes already known to be unsupported
+// are excluded up front, so the returned list only holds newly rejected ones. Nothing is
+// persisted, which lets it also vet a kernel that is not installed yet.
+func (s *Server) checkConfigWithKernel(singboxPath string, features kernelFeatures) (string, []UnsupportedNodeInfo, map[string]storage.Node, error) {
 	nodes := s.store.GetAllNodes()
-	filters := s.store.GetFilters()
 
 	excludeTags := make(map[string]bool)
 
@@ -1051,33 +1310,31 @@ func (s *Server) buildAndValidateConfig() (string, []UnsupportedNodeInfo, error)
 	var newUnsupported []UnsupportedNodeInfo
 	const maxIterations = 50
 
-	singboxPath := s.processManager.GetSingBoxPath()
+	// Build tag→Node map to resolve metadata for unsupported tags.
+	tagToNode := make(map[string]storage.Node, len(nodes))
+	for _, n := range nodes {
+		for _, candidate := range nodeTagCandidates(n) {
+			tagToNode[candidate] = n
+		}
+	}
 
 	for i := 0; i < maxIterations; i++ {
-		b := builder.NewConfigBuilderWithExclusions(settings, nodes, filters, excludeTags)
-		configJSON, indexToTag, err := b.BuildJSONWithNodeMap()
+		configJSON, indexToTag, endpointIndexToTag, err := s.configBuilder(features, excludeTags).BuildJSONWithNodeMap()
 		if err != nil {
-			return "", nil, err
-		}
-		// Build tag→Node map to resolve metadata for unsupported tags.
-		tagToNode := make(map[string]storage.Node, len(nodes))
-		for _, n := range nodes {
-			for _, candidate := range nodeTagCandidates(n) {
-				tagToNode[candidate] = n
-			}
+			return "", nil, nil, err
 		}
 
 		// Write to temp file for validation
 		tmpFile, err := os.CreateTemp("", "sbm-validate-*.json")
 		if err != nil {
-			return "", nil, fmt.Errorf("failed to create temp file: %w", err)
+			return "", nil, nil, fmt.Errorf("failed to create temp file: %w", err)
 		}
 		tmpPath := tmpFile.Name()
 
 		if _, err := tmpFile.WriteString(configJSON); err != nil {
 			tmpFile.Close()
 			os.Remove(tmpPath)
-			return "", nil, fmt.Errorf("failed to write temp config: %w", err)
+			return "", nil, nil, fmt.Errorf("failed to write temp config: %w", err)
 		}
 		tmpFile.Close()
 
@@ -1087,49 +1344,14 @@ func (s *Server) buildAndValidateConfig() (string, []UnsupportedNodeInfo, error)
 		os.Remove(tmpPath)
 
 		if checkErr == nil {
-			// Config is valid — store new unsupported nodes
-			if len(newUnsupported) > 0 {
-				s.unsupportedNodesMu.Lock()
-				for _, info := range newUnsupported {
-					s.unsupportedNodes[info.Tag] = info
-				}
-				s.unsupportedNodesMu.Unlock()
-
-				// Persist to store
-				for _, info := range newUnsupported {
-					un := storage.UnsupportedNode{
-						NodeTag:    info.Tag,
-						Error:      info.Error,
-						DetectedAt: info.Time,
-					}
-					if n, ok := tagToNode[info.Tag]; ok {
-						un.Server = n.Server
-						un.ServerPort = n.ServerPort
-					}
-					if err := s.store.AddUnsupportedNode(un); err != nil {
-						logger.Printf("[config] Failed to persist unsupported node %s: %v", info.Tag, err)
-					}
-				}
-
-				// Log excluded nodes
-				tags := make([]string, len(newUnsupported))
-				for i, u := range newUnsupported {
-					if strings.TrimSpace(u.DisplayName) != "" {
-						tags[i] = u.DisplayName
-					} else {
-						tags[i] = u.Tag
-					}
-				}
-				logger.Printf("[config] Excluded %d unsupported node(s): %s", len(newUnsupported), strings.Join(tags, ", "))
-			}
-			return configJSON, newUnsupported, nil
+			return configJSON, newUnsupported, tagToNode, nil
 		}
 
 		// Parse errors
 		checkErrors := builder.ParseCheckErrors(string(output))
 		if !checkErrors.HasErrors() {
 			// Unrecognized error, cannot auto-fix
-			return "", nil, fmt.Errorf("config check failed: %s", string(output))
+			return "", nil, nil, fmt.Errorf("config check failed: %s", string(output))
 		}
 
 		// Build reverse map: tag -> list of outbound indices (for duplicate detection)
@@ -1137,15 +1359,23 @@ func (s *Server) buildAndValidateConfig() (string, []UnsupportedNodeInfo, error)
 		for idx, tag := range indexToTag {
 			tagToIndices[tag] = append(tagToIndices[tag], idx)
 		}
+		for idx, tag := range endpointIndexToTag {
+			tagToIndices[tag] = append(tagToIndices[tag], idx)
+		}
 
 		foundNew := false
 
-		// Handle outbound index errors (outbounds[N].field: message)
+		// Handle outbound index errors (outbounds[N].field: message) and
+		// WireGuard endpoint errors (endpoints[N].field: message)
 		for _, oe := range checkErrors.OutboundErrors {
-			tag, ok := indexToTag[oe.Index]
+			nodeTags := indexToTag
+			if oe.Endpoint {
+				nodeTags = endpointIndexToTag
+			}
+			tag, ok := nodeTags[oe.Index]
 			if !ok {
 				// Error in a non-node outbound (group/selector), cannot auto-fix
-				return "", nil, fmt.Errorf("config check failed: %s", string(output))
+				return "", nil, nil, fmt.Errorf("config check failed: %s", string(output))
 			}
 			if !excludeTags[tag] {
 				excludeTags[tag] = true
@@ -1197,15 +1427,20 @@ func (s *Server) buildAndValidateConfig() (string, []UnsupportedNodeInfo, error)
 		}
 
 		if !foundNew {
-			return "", nil, fmt.Errorf("config check failed: %s", string(output))
+			return "", nil, nil, fmt.Errorf("config check failed: %s", string(output))
 		}
 	}
 
-	return "", nil, fmt.Errorf("config validation exceeded max iterations (%d)", maxIterations)
+	return "", nil, nil, fmt.Errorf("config validation exceeded max iterations (%d)", maxIterations)
 }
 
+// saveConfigFile writes the config to the configured file and records it as applied
 func (s *Server) saveConfigFile(path, content string) error {
-	return os.WriteFile(path, []byte(content), 0644)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		return err
+	}
+	s.recordAppliedConfig([]byte(content))
+	return nil
 }
 
 // regenerateAndSaveConfig builds a validated config, persists it to the configured file,
@@ -1230,12 +1465,49 @@ func (s *Server) resolvePath(path string) string {
 	return filepath.Join(s.store.GetDataDir(), path)
 }
 
-// autoApplyConfig auto-applies config (if sing-box is running)
+// autoApplyConfig applies the config when auto-apply is enabled.
+// Calls arriving within autoApplyDebounceWindow share a single rebuild/reload that
+// runs in the background; its result goes out as a config:auto_apply_complete event.
 func (s *Server) autoApplyConfig() error {
+	s.markConfigChanged()
+	if !s.store.GetSettings().AutoApply {
+		return nil
+	}
+	if s.applyDebouncer == nil {
+		return s.runAutoApply()
+	}
+	s.applyDebouncer.Trigger()
+	return nil
+}
+
+// reportAutoApply publishes the result of a debounced auto-apply run.
+func (s *Server) reportAutoApply(err error) {
+	data := map[string]interface{}{}
+	if err != nil {
+		logger.Printf("[config] Auto-apply failed: %v", err)
+		data["error"] = err.Error()
+	}
+	s.eventBus.PublishTimestamped("config:auto_apply_complete", data)
+}
+
+// runAutoApply rebuilds, validates and saves the config, then reloads sing-box.
+// Inside quiet hours the config is only written and the reload is deferred.
+func (s *Server) runAutoApply() error {
 	settings := s.store.GetSettings()
 	if !settings.AutoApply {
 		return nil
 	}
+	if settings.QuietHours.Contains(time.Now()) {
+		return s.deferAutoApply(settings.QuietHours)
+	}
+	s.clearPendingApply()
+	return s.rebuildAndReload()
+}
+
+// rebuildAndReload regenerates the config and reloads sing-box if it is running,
+// regardless of the auto-apply setting.
+func (s *Server) rebuildAndReload() error {
+	settings := s.store.GetSettings()
 
 	// Generate and validate config
 	configJSON, _, err := s.buildAndValidateConfig()
@@ -1253,7 +1525,8 @@ func (s *Server) autoApplyConfig() error {
 		if err := s.processManager.Reload(); err == nil {
 			return nil
 		}
-		return s.processManager.Restart()
+		_, err := s.restartSingbox()
+		return err
 	}
 
 	return nil
@@ -1273,6 +1546,30 @@ func (s *Server) getUnsupportedNodes(c *gin.Context) {
 }
 
 func (s *Server) recheckUnsupportedNodes(c *gin.Context) {
+	recheck := func(progress jobProgress) (interface{}, error) {
+		newUnsupported, err := s.revalidateUnsupportedNodes()
+		if err != nil {
+			return nil, err
+		}
+		progress(1, 1)
+		return gin.H{"data": s.unsupportedNodeList(), "message": fmt.Sprintf("Recheck completed, %d unsupported node(s)", len(newUnsupported))}, nil
+	}
+
+	if isAsyncRequest(c) {
+		s.respondWithJob(c, "unsupported_recheck", recheck)
+		return
+	}
+	result, err := recheck(func(int, int) {})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// revalidateUnsupportedNodes clears the unsupported list, re-validates the config
+// against the current kernel and saves it. Returns the nodes that are still unsupported.
+func (s *Server) revalidateUnsupportedNodes() ([]UnsupportedNodeInfo, error) {
 	// Clear unsupported list (in-memory + store)
 	s.unsupportedNodesMu.Lock()
 	s.unsupportedNodes = make(map[string]UnsupportedNodeInfo)
@@ -1284,25 +1581,54 @@ func (s *Server) recheckUnsupportedNodes(c *gin.Context) {
 	// Re-validate config
 	configJSON, newUnsupported, err := s.buildAndValidateConfig()
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
+		return nil, err
 	}
 
 	// Save the validated config
 	settings := s.store.GetSettings()
 	if err := s.saveConfigFile(s.resolvePath(settings.ConfigPath), configJSON); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
+		return nil, err
 	}
+	return newUnsupported, nil
+}
 
-	// Return updated list
+// unsupportedNodeList snapshots the in-memory unsupported nodes
+func (s *Server) unsupportedNodeList() []UnsupportedNodeInfo {
 	s.unsupportedNodesMu.RLock()
 	defer s.unsupportedNodesMu.RUnlock()
 	nodes := make([]UnsupportedNodeInfo, 0, len(s.unsupportedNodes))
 	for _, info := range s.unsupportedNodes {
 		nodes = append(nodes, info)
 	}
-	c.JSON(http.StatusOK, gin.H{"data": nodes, "message": fmt.Sprintf("Recheck completed, %d unsupported node(s)", len(newUnsupported))})
+	return nodes
+}
+
+// reconcileUnsupportedNodes drops unsupported entries for nodes that no longer exist,
+// then re-validates the rest in case a kernel upgrade made them supported.
+func (s *Server) reconcileUnsupportedNodes(c *gin.Context) {
+	removed, err := s.store.DeleteStaleUnsupportedNodes()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to remove stale entries: %v", err)})
+		return
+	}
+	s.reloadUnsupportedNodesFromStore()
+
+	rechecked := 0
+	if remaining := s.unsupportedNodeList(); len(remaining) > 0 {
+		rechecked = len(remaining)
+		if _, err := s.revalidateUnsupportedNodes(); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	nodes := s.unsupportedNodeList()
+	c.JSON(http.StatusOK, gin.H{
+		"data":      nodes,
+		"removed":   removed,
+		"rechecked": rechecked,
+		"message":   fmt.Sprintf("Removed %d stale entries, %d unsupported node(s) remain", removed, len(nodes)),
+	})
 }
 
 func (s *Server) clearUnsupportedNodes(c *gin.Context) {
@@ -1408,12 +1734,17 @@ func (s *Server) getServiceStatus(c *gin.Context) {
 		version = v
 	}
 
+	safeMode, lastError := s.processManager.SafeMode()
+
 	c.JSON(http.StatusOK, gin.H{
 		"data": gin.H{
-			"running":     running,
-			"pid":         pid,
-			"version":     version,
-			"sbm_version": s.version,
+			"running":       running,
+			"pid":           pid,
+			"version":       version,
+			"sbm_version":   s.version,
+			"safe_mode":     safeMode,
+			"last_error":    lastError,
+			"apply_pending": s.isApplyPending(),
 		},
 	})
 }
@@ -1425,6 +1756,9 @@ func (s *Server) startService(c *gin.Context) {
 		return
 	}
 
+	// An explicit start from the user leaves crash-loop safe mode
+	s.processManager.ClearSafeMode()
+
 	if err := s.processManager.Start(); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -1482,12 +1816,19 @@ func (s *Server) restartService(c *gin.Context) {
 		return
 	}
 
-	if err := s.processManager.Restart(); err != nil {
+	// An explicit start from the user leaves crash-loop safe mode
+	s.processManager.ClearSafeMode()
+
+	deferred, err := s.restartSingbox()
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
 	response := gin.H{"message": "Service restarted"}
+	if deferred {
+		response["message"] = "Service restarts once active connections drain"
+	}
 	if len(newUnsupported) > 0 {
 		tags := make([]string, len(newUnsupported))
 		for i, u := range newUnsupported {
@@ -2035,7 +2376,24 @@ func (s *Server) getProbeLogs(c *gin.Context) {
 // ==================== Node API ====================
 
 func (s *Server) getAllNodes(c *gin.Context) {
-	nodes := s.store.GetAllNodes()
+	sortBy := strings.ToLower(strings.TrimSpace(c.Query("sort")))
+	if sortBy == "" {
+		nodes := s.store.GetAllNodes()
+		c.JSON(http.StatusOK, gin.H{"data": nodes})
+		return
+	}
+	if !storage.IsValidNodeSort(sortBy) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid sort, expected one of: latency, country, tag, last_checked"})
+		return
+	}
+
+	order := strings.ToLower(strings.TrimSpace(c.DefaultQuery("order", "asc")))
+	if order != "asc" && order != "desc" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid order, expected asc or desc"})
+		return
+	}
+
+	nodes := s.store.GetAllNodesSorted(sortBy, order == "desc")
 	c.JSON(http.StatusOK, gin.H{"data": nodes})
 }
 
@@ -2044,6 +2402,45 @@ func (s *Server) getCountryGroups(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"data": groups})
 }
 
+func (s *Server) updateCountryOverride(c *gin.Context) {
+	code := strings.ToUpper(strings.TrimSpace(c.Param("code")))
+	if code == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "country code is required"})
+		return
+	}
+
+	var req struct {
+		Emoji string `json:"emoji"`
+		Name  string `json:"name"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	req.Emoji = strings.TrimSpace(req.Emoji)
+	req.Name = strings.TrimSpace(req.Name)
+
+	// Empty emoji and name reset the group to its built-in display
+	var err error
+	if req.Emoji == "" && req.Name == "" {
+		err = s.store.DeleteCountryOverride(code)
+	} else {
+		err = s.store.SetCountryOverride(storage.CountryOverride{Code: code, Emoji: req.Emoji, Name: req.Name})
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Auto-apply config
+	if err := s.autoApplyConfig(); err != nil {
+		c.JSON(http.StatusOK, gin.H{"message": "Updated successfully, but auto-apply config failed: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Updated successfully"})
+}
+
 func (s *Server) getNodesByCountry(c *gin.Context) {
 	code := c.Param("code")
 	nodes := s.store.GetNodesByCountry(code)
@@ -2065,10 +2462,25 @@ func (s *Server) parseNodeURL(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Parse failed: " + err.Error()})
 		return
 	}
+	if c.Query("resolve") == "true" {
+		if err := parser.PinResolvedServer(node, net.LookupHost); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
 
 	c.JSON(http.StatusOK, gin.H{"data": node})
 }
 
+// bulkParseResult is the outcome of parsing one URL from a pasted list
+type bulkParseResult struct {
+	URL         string        `json:"url"`
+	Node        *storage.Node `json:"node,omitempty"`
+	Error       string        `json:"error,omitempty"`
+	DuplicateOf string        `json:"duplicate_of,omitempty"` // Tag of the stored node with the same server:port
+	Line        int           `json:"line,omitempty"`         // 1-based line or entry number in an imported file
+}
+
 func (s *Server) parseNodeURLsBulk(c *gin.Context) {
 	var req struct {
 		URLs            []string `json:"urls" binding:"required"`
@@ -2080,13 +2492,7 @@ func (s *Server) parseNodeURLsBulk(c *gin.Context) {
 		return
 	}
 
-	type parseResult struct {
-		URL   string        `json:"url"`
-		Node  *storage.Node `json:"node,omitempty"`
-		Error string        `json:"error,omitempty"`
-	}
-
-	results := make([]parseResult, 0, len(req.URLs))
+	results := make([]bulkParseResult, 0, len(req.URLs))
 	for _, rawURL := range req.URLs {
 		trimmed := strings.TrimSpace(rawURL)
 		if trimmed == "" {
@@ -2094,15 +2500,49 @@ func (s *Server) parseNodeURLsBulk(c *gin.Context) {
 		}
 		node, err := parser.ParseURLWithHint(trimmed, req.DefaultProtocol)
 		if err != nil {
-			results = append(results, parseResult{URL: trimmed, Error: err.Error()})
+			results = append(results, bulkParseResult{URL: trimmed, Error: err.Error()})
 		} else {
-			results = append(results, parseResult{URL: trimmed, Node: node})
+			results = append(results, bulkParseResult{URL: trimmed, Node: node})
 		}
 	}
 
+	if c.Query("resolve") == "true" {
+		pinResolvedServers(results, net.LookupHost)
+	}
+	if c.Query("dedup") == "true" {
+		markStoredDuplicates(s.store, results)
+	}
+
 	c.JSON(http.StatusOK, gin.H{"data": results})
 }
 
+// pinResolvedServers pins parsed nodes to their resolved server IP, keeping the domain
+// as TLS SNI. Entries whose host fails to resolve become errors instead of nodes.
+func pinResolvedServers(results []bulkParseResult, lookupHost func(string) ([]string, error)) {
+	for i := range results {
+		if results[i].Node == nil {
+			continue
+		}
+		if err := parser.PinResolvedServer(results[i].Node, lookupHost); err != nil {
+			results[i].Node = nil
+			results[i].Error = err.Error()
+		}
+	}
+}
+
+// markStoredDuplicates sets DuplicateOf on parsed results whose server:port is already stored
+func markStoredDuplicates(store storage.Store, results []bulkParseResult) {
+	for i := range results {
+		node := results[i].Node
+		if node == nil {
+			continue
+		}
+		if existing := store.GetNodeByServerPort(node.Server, node.ServerPort); existing != nil {
+			results[i].DuplicateOf = unifiedDisplayName(*existing)
+		}
+	}
+}
+
 // ==================== Health Check API ====================
 
 // NodeHealthResult represents health check result for a single node
@@ -2119,51 +2559,6 @@ type NodeSiteCheckResult struct {
 	Errors map[string]string `json:"errors,omitempty"` // per-site error type when delay is 0
 }
 
-// matchFilter checks if a node matches a filter (duplicated from builder for API layer)
-func matchFilter(node storage.Node, filter storage.Filter) bool {
-	name := strings.ToLower(strings.TrimSpace(node.DisplayOrTag() + " " + node.SourceOrTag()))
-
-	if len(filter.IncludeCountries) > 0 {
-		matched := false
-		for _, country := range filter.IncludeCountries {
-			if strings.EqualFold(node.Country, country) {
-				matched = true
-				break
-			}
-		}
-		if !matched {
-			return false
-		}
-	}
-
-	for _, country := range filter.ExcludeCountries {
-		if strings.EqualFold(node.Country, country) {
-			return false
-		}
-	}
-
-	if len(filter.Include) > 0 {
-		matched := false
-		for _, keyword := range filter.Include {
-			if strings.Contains(name, strings.ToLower(keyword)) {
-				matched = true
-				break
-			}
-		}
-		if !matched {
-			return false
-		}
-	}
-
-	for _, keyword := range filter.Exclude {
-		if strings.Contains(name, strings.ToLower(keyword)) {
-			return false
-		}
-	}
-
-	return true
-}
-
 var defaultSiteCheckTargets = []string{
 	"https://chatgpt.com",
 	"https://2ip.ru",
@@ -2284,6 +2679,12 @@ func classifyDelayError(raw string) string {
 }
 
 func (s *Server) clashProxyDelayWithURLDetailed(port int, secret, nodeTag, targetURL string, timeoutMs int) proxyDelayResult {
+	return s.clashProxyDelayRequest(port, secret, nodeTag, targetURL, "", timeoutMs)
+}
+
+// clashProxyDelayRequest asks the Clash API for a proxy delay. A non-empty expected
+// status (e.g. "200/204") is forwarded so kernels that support it accept those codes.
+func (s *Server) clashProxyDelayRequest(port int, secret, nodeTag, targetURL, expected string, timeoutMs int) proxyDelayResult {
 	if strings.TrimSpace(targetURL) == "" {
 		return proxyDelayResult{ErrorType: "invalid_target", ErrorDetail: "empty target url"}
 	}
@@ -2299,6 +2700,9 @@ func (s *Server) clashProxyDelayWithURLDetailed(port int, secret, nodeTag, targe
 		neturl.QueryEscape(targetURL),
 		timeoutMs,
 	)
+	if expected = strings.TrimSpace(expected); expected != "" {
+		apiURL += "&expected=" + neturl.QueryEscape(expected)
+	}
 
 	req, err := http.NewRequest("GET", apiURL, nil)
 	if err != nil {
@@ -2392,12 +2796,23 @@ func (s *Server) clashProxyDelayWithURL(port int, secret, nodeTag, targetURL str
 	return res.Delay
 }
 
+// defaultDelayTestURL is the delay check target when no urltest URL is configured
+const defaultDelayTestURL = "https://www.gstatic.com/generate_204"
+
+// clashProxyDelay measures nodeTag against the configured urltest URL and expected status.
 func (s *Server) clashProxyDelay(port int, secret, nodeTag string) int {
-	return s.clashProxyDelayWithURL(port, secret, nodeTag, "https://www.gstatic.com/generate_204", 5000)
+	targetURL, expected := defaultDelayTestURL, ""
+	if settings := s.store.GetSettings(); settings != nil {
+		if u := strings.TrimSpace(settings.URLTestURL); u != "" {
+			targetURL = u
+		}
+		expected = settings.URLTestExpectedStatus
+	}
+	return s.clashProxyDelayRequest(port, secret, nodeTag, targetURL, expected, 5000).Delay
 }
 
-func (s *Server) performHealthCheck(nodes []storage.Node) (map[string]*NodeHealthResult, string, error) {
-	// Deduplicate nodes by server:port — check each endpoint only once
+// dedupeNodesByEndpoint keeps the first node for each server:port so every endpoint is checked once
+func dedupeNodesByEndpoint(nodes []storage.Node) []storage.Node {
 	seen := make(map[string]bool, len(nodes))
 	uniqueNodes := make([]storage.Node, 0, len(nodes))
 	for _, n := range nodes {
@@ -2407,12 +2822,31 @@ func (s *Server) performHealthCheck(nodes []storage.Node) (map[string]*NodeHealt
 			uniqueNodes = append(uniqueNodes, n)
 		}
 	}
+	return uniqueNodes
+}
 
-	port, tagMap, _, _, err := s.probeManager.EnsureRunning(uniqueNodes)
+// ensureProbe starts (or reuses) the probe for nodes and returns its Clash API port and tag map
+func (s *Server) ensureProbe(nodes []storage.Node) (int, *daemon.ProbeTagMap, error) {
+	port, tagMap, _, _, err := s.probeManager.EnsureRunning(nodes)
+	return port, tagMap, err
+}
+
+func (s *Server) performHealthCheck(nodes []storage.Node) (map[string]*NodeHealthResult, string, error) {
+	uniqueNodes := dedupeNodesByEndpoint(nodes)
+
+	port, tagMap, err := s.ensureProbe(uniqueNodes)
 	if err != nil {
 		return nil, "", err
 	}
 
+	return s.runHealthChecks(port, s.probeUDPProxyPort(), tagMap, uniqueNodes), "probe", nil
+}
+
+// runHealthChecks checks each endpoint through a running probe and saves the measurements.
+// QUIC/UDP protocols are checked with a UDP exchange through udpPort when it is set,
+// the rest with the Clash API delay test; each measurement records which was used.
+func (s *Server) runHealthChecks(port, udpPort int, tagMap *daemon.ProbeTagMap, uniqueNodes []storage.Node) map[string]*NodeHealthResult {
+	udpTarget := s.udpCheckTarget()
 	results := make(map[string]*NodeHealthResult)
 	var mu sync.Mutex
 	sem := make(chan struct{}, 50)
@@ -2440,7 +2874,12 @@ func (s *Server) performHealthCheck(nodes []storage.Node) (map[string]*NodeHealt
 				}
 			}
 
-			delay := s.clashProxyDelay(port, "", probeTag)
+			var delay int
+			if healthCheckMode(n, udpPort) == "udp" {
+				delay = udpProxyDelay(udpPort, probeTag, udpTarget)
+			} else {
+				delay = s.clashProxyDelay(port, "", probeTag)
+			}
 			if delay > 0 {
 				result.Alive = true
 			}
@@ -2481,7 +2920,7 @@ func (s *Server) performHealthCheck(nodes []storage.Node) (map[string]*NodeHealt
 				Timestamp:  now,
 				Alive:      r.Alive,
 				LatencyMs:  latency,
-				Mode:       "probe",
+				Mode:       healthCheckMode(n, udpPort),
 			})
 		}
 	}
@@ -2491,29 +2930,29 @@ func (s *Server) performHealthCheck(nodes []storage.Node) (map[string]*NodeHealt
 		}
 	}
 
-	return results, "probe", nil
+	return results
 }
 
 func (s *Server) healthCheckNodes(c *gin.Context) {
 	var req struct {
-		Tags []string `json:"tags"`
+		Tags    []string `json:"tags"`
+		Country string   `json:"country"`
+		Group   string   `json:"group"`
 	}
 	c.ShouldBindJSON(&req)
-
-	allNodes := s.store.GetAllNodesIncludeDisabled()
-
-	var nodes []storage.Node
-	if len(req.Tags) > 0 {
-		tagSet := parseTagSet(req.Tags)
-		for _, n := range allNodes {
-			if nodeMatchesAnyTag(n, tagSet) {
-				nodes = append(nodes, n)
-			}
-		}
-	} else {
-		nodes = allNodes
+	if country := c.Query("country"); country != "" {
+		req.Country = country
+	}
+	if group := c.Query("group"); group != "" {
+		req.Group = group
 	}
 
+	nodes := scopeHealthCheckNodes(s.store, s.store.GetAllNodesIncludeDisabled(), healthCheckScope{
+		Tags:    req.Tags,
+		Country: req.Country,
+		Group:   req.Group,
+	})
+
 	if len(nodes) == 0 {
 		c.JSON(http.StatusOK, gin.H{
 			"data": map[string]*NodeHealthResult{},
@@ -2522,12 +2961,38 @@ func (s *Server) healthCheckNodes(c *gin.Context) {
 		return
 	}
 
-	results, mode, err := s.performHealthCheck(nodes)
+	check := func(progress jobProgress) (interface{}, error) {
+		total := len(dedupeNodesByEndpoint(nodes))
+		progress(0, total)
+
+		previous := s.latestAliveByEndpoint()
+		results, mode, err := s.performHealthCheck(nodes)
+		if err != nil {
+			return nil, err
+		}
+
+		newlyDead, newlyAlive := healthTransitions(previous, nodes, results)
+		s.notifyHealthWebhook(HealthWebhookPayload{
+			Event:      "health_check",
+			Checked:    len(results),
+			Alive:      countAlive(results),
+			NewlyDead:  newlyDead,
+			NewlyAlive: newlyAlive,
+		})
+		progress(total, total)
+		return gin.H{"data": results, "mode": mode}, nil
+	}
+
+	if isAsyncRequest(c) {
+		s.respondWithJob(c, "health_check", check)
+		return
+	}
+	result, err := check(func(int, int) {})
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"data": results, "mode": mode})
+	c.JSON(http.StatusOK, result)
 }
 
 func (s *Server) healthCheckSingleNode(c *gin.Context) {
@@ -2573,22 +3038,18 @@ func (s *Server) healthCheckSingleNode(c *gin.Context) {
 }
 
 func (s *Server) performSiteCheck(nodes []storage.Node, targets []string) (map[string]*NodeSiteCheckResult, string, error) {
-	// Deduplicate nodes by server:port — check each endpoint only once
-	seen := make(map[string]bool, len(nodes))
-	uniqueNodes := make([]storage.Node, 0, len(nodes))
-	for _, n := range nodes {
-		key := fmt.Sprintf("%s:%d", n.Server, n.ServerPort)
-		if !seen[key] {
-			seen[key] = true
-			uniqueNodes = append(uniqueNodes, n)
-		}
-	}
+	uniqueNodes := dedupeNodesByEndpoint(nodes)
 
-	port, tagMap, _, _, err := s.probeManager.EnsureRunning(uniqueNodes)
+	port, tagMap, err := s.ensureProbe(uniqueNodes)
 	if err != nil {
 		return nil, "", err
 	}
 
+	return s.runSiteChecks(port, tagMap, uniqueNodes, targets), "probe", nil
+}
+
+// runSiteChecks checks each endpoint against targets through a running probe and saves the measurements
+func (s *Server) runSiteChecks(port int, tagMap *daemon.ProbeTagMap, uniqueNodes []storage.Node, targets []string) map[string]*NodeSiteCheckResult {
 	results := make(map[string]*NodeSiteCheckResult)
 	var mu sync.Mutex
 	sem := make(chan struct{}, 80)
@@ -2674,7 +3135,7 @@ func (s *Server) performSiteCheck(nodes []storage.Node, targets []string) (map[s
 		}
 	}
 
-	return results, "probe", nil
+	return results
 }
 
 func (s *Server) siteCheckNodes(c *gin.Context) {
@@ -2782,6 +3243,7 @@ func (s *Server) addUnifiedNodesBulk(c *gin.Context) {
 		return
 	}
 
+	tagTemplate := s.store.GetSettings().NodeTagTemplate
 	for i := range req.Nodes {
 		if req.GroupTag != "" && req.Nodes[i].GroupTag == "" {
 			req.Nodes[i].GroupTag = req.GroupTag
@@ -2789,6 +3251,9 @@ func (s *Server) addUnifiedNodesBulk(c *gin.Context) {
 		if req.Source != "" && req.Nodes[i].Source == "" {
 			req.Nodes[i].Source = req.Source
 		}
+		if name := storage.RenderNodeTagTemplate(tagTemplate, req.Nodes[i], i+1); name != "" {
+			req.Nodes[i].DisplayName = name
+		}
 	}
 
 	added, err := s.store.AddNodesBulk(req.Nodes)
@@ -2932,6 +3397,115 @@ func (s *Server) toggleNodeFavorite(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "ok"})
 }
 
+// toggleNodePinned pins or unpins a node. Pinning changes the config node set
+// when the node is pending, so the config is re-applied in that case.
+func (s *Server) toggleNodePinned(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	var req struct {
+		Pinned bool `json:"pinned"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	node := s.store.GetNodeByID(id)
+	if node == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "node not found"})
+		return
+	}
+	if err := s.store.SetNodePinned(id, req.Pinned); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if node.Status == storage.NodeStatusPending && node.Pinned != req.Pinned {
+		s.autoApplyConfig()
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "ok"})
+}
+
+// toggleNodeExcludeFromAuto keeps a node out of or puts it back into the Auto and
+// country urltest groups. The node itself stays selectable in Proxy.
+func (s *Server) toggleNodeExcludeFromAuto(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	var req struct {
+		Exclude bool `json:"exclude"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	node := s.store.GetNodeByID(id)
+	if node == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "node not found"})
+		return
+	}
+	if err := s.store.SetNodeExcludeFromAuto(id, req.Exclude); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if node.ExcludeFromAuto != req.Exclude {
+		s.autoApplyConfig()
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "ok"})
+}
+
+// setUnifiedNodeCountry sets a node's country by hand. With locked set, geo checks
+// keep the country instead of overwriting it with the detected one.
+func (s *Server) setUnifiedNodeCountry(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	var req struct {
+		Country string `json:"country"`
+		Locked  bool   `json:"locked"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	country := strings.ToUpper(strings.TrimSpace(req.Country))
+	if !isCountryCode(country) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "country must be a two-letter country code"})
+		return
+	}
+	if s.store.GetNodeByID(id) == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "node not found"})
+		return
+	}
+	if err := s.store.SetNodeCountry(id, country, storage.GetCountryEmoji(country), req.Locked); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	s.autoApplyConfig()
+	c.JSON(http.StatusOK, gin.H{"data": s.store.GetNodeByID(id)})
+}
+
+// isCountryCode reports whether code is a two-letter uppercase country code.
+func isCountryCode(code string) bool {
+	if len(code) != 2 {
+		return false
+	}
+	for _, r := range code {
+		if r < 'A' || r > 'Z' {
+			return false
+		}
+	}
+	return true
+}
+
 func (s *Server) bulkPromoteNodes(c *gin.Context) {
 	var req struct {
 		IDs []int64 `json:"ids" binding:"required"`
@@ -2977,6 +3551,26 @@ func (s *Server) bulkUnarchiveNodes(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"unarchived": count, "message": fmt.Sprintf("Unarchived %d nodes", count)})
 }
 
+func (s *Server) deleteOldArchivedNodes(c *gin.Context) {
+	days := 30
+	if raw := strings.TrimSpace(c.Query("older_than_days")); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "older_than_days must be a positive integer"})
+			return
+		}
+		days = parsed
+	}
+
+	cutoff := time.Now().Add(-time.Duration(days) * 24 * time.Hour)
+	count, err := s.store.DeleteArchivedNodesOlderThan(cutoff)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"deleted": count, "message": fmt.Sprintf("Deleted %d archived nodes older than %d days", count, days)})
+}
+
 func (s *Server) getNodeCounts(c *gin.Context) {
 	counts := s.store.GetNodeCounts()
 	c.JSON(http.StatusOK, gin.H{"data": counts})
@@ -3148,6 +3742,7 @@ func (s *Server) getVerificationStatus(c *gin.Context) {
 		"next_run_at":               s.scheduler.GetNextVerifyTime(),
 		"node_counts":               s.store.GetNodeCounts(),
 		"scheduler_running":         s.scheduler.IsRunning(),
+		"scheduler_paused":          s.scheduler.GetPause(),
 		"verification_in_progress":  s.verifyInProgress.Load(),
 		"sub_update_enabled":        settings.SubscriptionInterval > 0,
 		"sub_update_interval_min":   settings.SubscriptionInterval,
@@ -3182,6 +3777,35 @@ func (s *Server) stopVerificationScheduler(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "Scheduler stopped"})
 }
 
+func (s *Server) pauseVerificationScheduler(c *gin.Context) {
+	var req struct {
+		Reason  string `json:"reason"`
+		Minutes int    `json:"minutes"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Minutes < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "minutes must not be negative"})
+		return
+	}
+	reason := strings.TrimSpace(req.Reason)
+	if reason == "" {
+		reason = "paused manually"
+	}
+	s.scheduler.Pause(reason, time.Duration(req.Minutes)*time.Minute)
+	c.JSON(http.StatusOK, gin.H{"message": "Scheduler paused", "data": s.scheduler.GetPause()})
+}
+
+func (s *Server) resumeVerificationScheduler(c *gin.Context) {
+	if !s.scheduler.Resume() {
+		c.JSON(http.StatusOK, gin.H{"message": "Scheduler is not paused"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Scheduler resumed"})
+}
+
 func (s *Server) getVerificationLogs(c *gin.Context) {
 	limitStr := c.DefaultQuery("limit", "20")
 	limit, err := strconv.Atoi(limitStr)
@@ -3226,6 +3850,7 @@ func (s *Server) getKernelReleases(c *gin.Context) {
 func (s *Server) startKernelDownload(c *gin.Context) {
 	var req struct {
 		Version string `json:"version" binding:"required"`
+		Confirm bool   `json:"confirm"` // install even if the new kernel would reject nodes
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -3233,7 +3858,7 @@ func (s *Server) startKernelDownload(c *gin.Context) {
 		return
 	}
 
-	if err := s.kernelManager.StartDownload(req.Version); err != nil {
+	if err := s.kernelManager.StartDownload(req.Version, req.Confirm); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
@@ -3241,11 +3866,95 @@ func (s *Server) startKernelDownload(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "Download started"})
 }
 
+func (s *Server) cancelKernelDownload(c *gin.Context) {
+	if err := s.kernelManager.CancelDownload(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Download cancelled"})
+}
+
 func (s *Server) getKernelProgress(c *gin.Context) {
 	progress := s.kernelManager.GetProgress()
 	c.JSON(http.StatusOK, gin.H{"data": progress})
 }
 
+func (s *Server) getKernelSupports(c *gin.Context) {
+	outboundType := strings.TrimSpace(c.Query("type"))
+	if outboundType == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "type is required"})
+		return
+	}
+
+	rawVersion, err := s.processManager.Version()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	version, err := kernel.ParseVersion(rawVersion)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": kernel.SupportsOutbound(version, outboundType)})
+}
+
+// installedKernelVersion returns the parsed version of the installed kernel, or false when unknown
+func (s *Server) installedKernelVersion() (kernel.Version, bool) {
+	rawVersion, err := s.processManager.Version()
+	if err != nil {
+		return kernel.Version{}, false
+	}
+	version, err := kernel.ParseVersion(rawVersion)
+	if err != nil {
+		return kernel.Version{}, false
+	}
+	return version, true
+}
+
+// kernelFeatures are the kernel-dependent config builder options
+type kernelFeatures struct {
+	ech               bool // TLS ECH
+	multiplex         bool // outbound multiplex
+	wireGuardEndpoint bool // WireGuard in the endpoints section
+}
+
+// kernelFeaturesFor returns the builder options for a kernel version. An unknown
+// version is treated as supporting everything so configs are left untouched.
+func kernelFeaturesFor(version kernel.Version, known bool) kernelFeatures {
+	if !known {
+		return kernelFeatures{ech: true, multiplex: true, wireGuardEndpoint: true}
+	}
+	return kernelFeatures{
+		ech:               kernel.SupportsTLSECH(version),
+		multiplex:         kernel.SupportsMultiplex(version),
+		wireGuardEndpoint: kernel.SupportsWireGuardEndpoint(version),
+	}
+}
+
+// installedKernelFeatures returns the builder options of the installed kernel,
+// reading its version once
+func (s *Server) installedKernelFeatures() kernelFeatures {
+	return kernelFeaturesFor(s.installedKernelVersion())
+}
+
+// configBuilder returns a builder for the stored settings, nodes and filters that skips
+// excludeTags and targets a kernel with the given features
+func (s *Server) configBuilder(features kernelFeatures, excludeTags map[string]bool) *builder.ConfigBuilder {
+	return builder.NewConfigBuilderWithExclusions(s.store.GetSettings(), s.store.GetAllNodes(), s.store.GetFilters(), excludeTags).
+		WithCountryOverrides(s.store.GetCountryOverrides()).
+		WithECHSupport(features.ech).
+		WithMultiplexSupport(features.multiplex).
+		WithWireGuardEndpointSupport(features.wireGuardEndpoint)
+}
+
+// newConfigBuilder returns a builder for the stored config targeting the installed kernel
+func (s *Server) newConfigBuilder() *builder.ConfigBuilder {
+	return s.configBuilder(s.installedKernelFeatures(), nil)
+}
+
 // ==================== Proxy Group Management (Clash API) ====================
 
 func (s *Server) getProxyGroups(c *gin.Context) {
@@ -3608,7 +4317,7 @@ func (s *Server) debugDump(c *gin.Context) {
 		return
 	}
 
-	subscriptions := s.store.GetSubscriptions()
+	subscriptions := redactSubscriptions(s.store.GetSubscriptions())
 	nodeCounts := s.store.GetNodeCounts()
 	filters := s.store.GetFilters()
 	countryGroups := s.store.GetCountryGroups()
@@ -4004,6 +4713,15 @@ func (s *Server) getSiteMeasurements(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"data": measurements})
 }
 
+func (s *Server) getSiteMatrix(c *gin.Context) {
+	matrix, err := s.store.GetSiteMatrix()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": matrix})
+}
+
 func (s *Server) saveSiteMeasurements(c *gin.Context) {
 	var req struct {
 		Measurements []storage.SiteMeasurement `json:"measurements"`
@@ -4046,13 +4764,13 @@ func (s *Server) getDiagnostic(c *gin.Context) {
 	// 2. Settings & Inbound Listeners
 	settings := s.store.GetSettings()
 	var listeners []gin.H
-	if settings.MixedPort > 0 {
+	if settings.MixedEnabled && settings.MixedPort > 0 {
 		listeners = append(listeners, gin.H{"type": "mixed", "port": settings.MixedPort, "bind": settings.MixedAddress})
 	}
-	if settings.SocksPort > 0 {
+	if settings.SocksEnabled && settings.SocksPort > 0 {
 		listeners = append(listeners, gin.H{"type": "socks", "port": settings.SocksPort, "bind": settings.SocksAddress})
 	}
-	if settings.HttpPort > 0 {
+	if settings.HttpEnabled && settings.HttpPort > 0 {
 		listeners = append(listeners, gin.H{"type": "http", "port": settings.HttpPort, "bind": settings.HttpAddress})
 	}
 	if settings.ShadowsocksPort > 0 {
@@ -0,0 +1,122 @@
+package api
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xiaobei/singbox-manager/internal/builder"
+	"github.com/xiaobei/singbox-manager/internal/storage"
+)
+
+// defaultRuleStatsWindow is how far back rule stats look when neither hours nor since is given
+const defaultRuleStatsWindow = 24 * time.Hour
+
+// ruleStatsTopHosts caps how many example hosts are listed per rule
+const ruleStatsTopHosts = 5
+
+// RuleHitStats is the estimated number of sampled observations a route rule handled
+type RuleHitStats struct {
+	RuleIndex   int               `json:"rule_index"` // -1 for the route final
+	Rule        builder.RouteRule `json:"rule,omitempty"`
+	Outbound    string            `json:"outbound"`
+	Hits        int               `json:"hits"`  // Traffic samples whose host routed through this rule
+	Hosts       int               `json:"hosts"` // Distinct hosts among those samples
+	TopHosts    []string          `json:"top_hosts"`
+	Approximate bool              `json:"approximate"` // Some hits came from a geosite rule set matched by name only
+}
+
+// estimateRuleHits replays every observed host through the route rules and credits
+// its sample count to the first rule that would route it. Rules that do not pick an
+// outbound (sniff, hijack-dns, ...) are left out; the route final comes last.
+func estimateRuleHits(route *builder.RouteConfig, outbounds []builder.Outbound, observations []storage.HostObservation) []RuleHitStats {
+	stats := make([]RuleHitStats, 0)
+	byIndex := make(map[int]*RuleHitStats)
+	if route != nil {
+		for i, rule := range route.Rules {
+			outbound, final := builder.RouteRuleOutbound(rule)
+			if !final {
+				continue
+			}
+			stats = append(stats, RuleHitStats{RuleIndex: i, Rule: rule, Outbound: outbound, TopHosts: []string{}})
+		}
+		stats = append(stats, RuleHitStats{RuleIndex: -1, Outbound: route.Final, TopHosts: []string{}})
+	}
+	for i := range stats {
+		byIndex[stats[i].RuleIndex] = &stats[i]
+	}
+
+	// observations arrive most observed first, so TopHosts keeps that order
+	for _, o := range observations {
+		target := builder.RouteTarget{Domain: o.Host}
+		if host, _, err := net.SplitHostPort(o.Host); err == nil {
+			target.Domain = host
+		}
+		if net.ParseIP(target.Domain) != nil {
+			target = builder.RouteTarget{IP: target.Domain}
+		}
+
+		sim := builder.SimulateRoute(route, outbounds, target)
+		entry, ok := byIndex[sim.RuleIndex]
+		if !ok {
+			continue
+		}
+		entry.Hits += o.Samples
+		entry.Hosts++
+		if len(entry.TopHosts) < ruleStatsTopHosts {
+			entry.TopHosts = append(entry.TopHosts, o.Host)
+		}
+		if sim.Approximate {
+			entry.Approximate = true
+		}
+	}
+	return stats
+}
+
+// getRuleStats estimates per-rule hit counts over a window (hours, default 24, or since
+// as RFC 3339 / unix seconds). sing-box's Clash API lists rules without match counters,
+// so the counts come from replaying hosts recorded in the traffic samples through the
+// current route rules: a hit is one sampled observation of a host, not a connection,
+// and hosts are credited to the rule that would route them today, which may differ
+// from the config that was active when they were sampled.
+func (s *Server) getRuleStats(c *gin.Context) {
+	since, err := parseTimeQuery(c.Query("since"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid since: %v", err)})
+		return
+	}
+	if since.IsZero() {
+		window := defaultRuleStatsWindow
+		if raw := strings.TrimSpace(c.Query("hours")); raw != "" {
+			hours, err := strconv.Atoi(raw)
+			if err != nil || hours <= 0 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "hours must be a positive integer"})
+				return
+			}
+			window = time.Duration(hours) * time.Hour
+		}
+		since = time.Now().Add(-window)
+	}
+
+	observations, err := s.store.GetHostObservations(since)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	cfg, err := s.buildAppliedConfig()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":   estimateRuleHits(cfg.Route, cfg.Outbounds, observations),
+		"since":  since.UTC(),
+		"hosts":  len(observations),
+		"method": "estimated",
+	})
+}
@@ -0,0 +1,73 @@
+package api
+
+import (
+	"testing"
+	"time"
+
+	"github.com/xiaobei/singbox-manager/internal/builder"
+	"github.com/xiaobei/singbox-manager/internal/storage"
+)
+
+func TestEstimateRuleHits_DomainSuffixRule(t *testing.T) {
+	store, err := storage.NewSQLiteStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("create sqlite store: %v", err)
+	}
+	t.Cleanup(func() { _ = store.Close() })
+
+	base := time.Now().Add(-time.Hour).UTC()
+	for i, resources := range [][]storage.ClientResourceSnapshot{
+		{
+			{SourceIP: "10.0.0.1", Host: "video.example.com", ProxyChain: "Proxy"},
+			{SourceIP: "10.0.0.2", Host: "other.org", ProxyChain: "DIRECT"},
+		},
+		{
+			{SourceIP: "10.0.0.1", Host: "video.example.com", ProxyChain: "Proxy"},
+			{SourceIP: "10.0.0.2", Host: "example.com:443", ProxyChain: "Proxy"},
+			{SourceIP: "10.0.0.2", Host: "notexample.com", ProxyChain: "DIRECT"},
+		},
+	} {
+		sample := storage.TrafficSample{Timestamp: base.Add(time.Duration(i) * time.Minute)}
+		if _, err := store.AddTrafficSample(sample, nil, resources); err != nil {
+			t.Fatalf("add traffic sample %d: %v", i, err)
+		}
+	}
+	// Outside the window
+	old := storage.TrafficSample{Timestamp: base.Add(-48 * time.Hour)}
+	if _, err := store.AddTrafficSample(old, nil, []storage.ClientResourceSnapshot{{SourceIP: "10.0.0.1", Host: "old.example.com"}}); err != nil {
+		t.Fatalf("add old traffic sample: %v", err)
+	}
+
+	observations, err := store.GetHostObservations(base.Add(-time.Minute))
+	if err != nil {
+		t.Fatalf("get host observations: %v", err)
+	}
+	if len(observations) != 4 || observations[0].Host != "video.example.com" || observations[0].Samples != 2 {
+		t.Fatalf("observations mismatch: got %+v", observations)
+	}
+
+	route := &builder.RouteConfig{
+		Rules: []builder.RouteRule{
+			{"action": "sniff"},
+			{"domain_suffix": []string{".example.com"}, "outbound": "Proxy"},
+		},
+		Final: "DIRECT",
+	}
+	stats := estimateRuleHits(route, nil, observations)
+	if len(stats) != 2 {
+		t.Fatalf("stats length mismatch: got %d, want 2 (%+v)", len(stats), stats)
+	}
+
+	suffix := stats[0]
+	// A leading dot matches subdomains only, so example.com itself falls through to final
+	if suffix.RuleIndex != 1 || suffix.Outbound != "Proxy" || suffix.Hits != 2 || suffix.Hosts != 1 {
+		t.Fatalf("domain_suffix rule stats mismatch: got %+v", suffix)
+	}
+	if suffix.TopHosts[0] != "video.example.com" {
+		t.Fatalf("top host mismatch: got %v, want video.example.com first", suffix.TopHosts)
+	}
+	final := stats[1]
+	if final.RuleIndex != -1 || final.Outbound != "DIRECT" || final.Hits != 3 || final.Hosts != 3 {
+		t.Fatalf("final stats mismatch: got %+v", final)
+	}
+}
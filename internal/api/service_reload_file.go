@@ -0,0 +1,43 @@
+package api
+
+import (
+	"net/http"
+	"os"
+
+	"github.com/gin-gonic/gin"
+)
+
+// reloadServiceFromFile restarts sing-box with the config file exactly as it is on disk,
+// skipping the rebuild restartService does, so hand edits to the file survive. The file
+// must pass `sing-box check` before the running instance is touched.
+func (s *Server) reloadServiceFromFile(c *gin.Context) {
+	path := s.resolvePath(s.store.GetSettings().ConfigPath)
+	if _, err := os.Stat(path); err != nil {
+		if os.IsNotExist(err) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "config file does not exist: " + path})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	s.processManager.SetConfigPath(path)
+	if err := s.processManager.Check(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// An explicit restart from the user leaves crash-loop safe mode
+	s.processManager.ClearSafeMode()
+
+	deferred, err := s.restartSingbox()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	message := "Service restarted with the config on disk"
+	if deferred {
+		message = "Service restarts with the config on disk once active connections drain"
+	}
+	c.JSON(http.StatusOK, gin.H{"message": message, "data": gin.H{"path": path}})
+}
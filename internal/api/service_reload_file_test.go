@@ -0,0 +1,95 @@
+package api
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xiaobei/singbox-manager/internal/daemon"
+	"github.com/xiaobei/singbox-manager/internal/storage"
+)
+
+func TestReloadServiceFromFile_LoadsFileVerbatim(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	dir := t.TempDir()
+	store, err := storage.NewSQLiteStore(dir)
+	if err != nil {
+		t.Fatalf("create sqlite store: %v", err)
+	}
+	t.Cleanup(func() { _ = store.Close() })
+
+	settings := store.GetSettings()
+	settings.ConfigPath = filepath.Join(dir, "config.json")
+	if err := store.UpdateSettings(settings); err != nil {
+		t.Fatalf("update settings: %v", err)
+	}
+
+	// Fake sing-box: check rejects configs marked invalid, run records the file it was given
+	loaded := filepath.Join(dir, "loaded.json")
+	binPath := filepath.Join(dir, "sing-box")
+	script := "#!/bin/sh\n" +
+		"if [ \"$1\" = check ]; then\n" +
+		"  if grep -q invalid \"$3\"; then echo 'decode config: invalid' >&2; exit 1; fi\n" +
+		"  exit 0\n" +
+		"fi\n" +
+		"cp \"$3\" '" + loaded + "'\n" +
+		"exec sleep 30\n"
+	if err := os.WriteFile(binPath, []byte(script), 0755); err != nil {
+		t.Fatalf("write fake sing-box: %v", err)
+	}
+
+	pm := daemon.NewProcessManager(binPath, filepath.Join(dir, "other.json"), dir)
+	t.Cleanup(func() { _ = pm.Stop() })
+	s := &Server{store: store, processManager: pm}
+	router := gin.New()
+	router.POST("/service/reload-file", s.reloadServiceFromFile)
+	reload := func() *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/service/reload-file", nil))
+		return rec
+	}
+
+	if rec := reload(); rec.Code != http.StatusNotFound {
+		t.Fatalf("missing file status mismatch: got %d, want %d (%s)", rec.Code, http.StatusNotFound, rec.Body.String())
+	}
+
+	if err := os.WriteFile(settings.ConfigPath, []byte(`{"invalid": true}`), 0644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	if rec := reload(); rec.Code != http.StatusBadRequest {
+		t.Fatalf("invalid file status mismatch: got %d, want %d (%s)", rec.Code, http.StatusBadRequest, rec.Body.String())
+	}
+	if pm.IsRunning() {
+		t.Fatal("sing-box must not start when the check fails")
+	}
+
+	// Hand-written, not something the builder would produce
+	manual := []byte("{\n  \"log\": {\"level\": \"warn\"},\n  \"outbounds\": [{\"type\": \"direct\", \"tag\": \"hand-edited\"}]\n}\n")
+	if err := os.WriteFile(settings.ConfigPath, manual, 0644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	if rec := reload(); rec.Code != http.StatusOK {
+		t.Fatalf("status mismatch: got %d, want %d (%s)", rec.Code, http.StatusOK, rec.Body.String())
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	var got []byte
+	for time.Now().Before(deadline) {
+		if got, err = os.ReadFile(loaded); err == nil && len(got) > 0 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if !bytes.Equal(got, manual) {
+		t.Fatalf("loaded config mismatch: got %q, want %q", got, manual)
+	}
+	if onDisk, _ := os.ReadFile(settings.ConfigPath); !bytes.Equal(onDisk, manual) {
+		t.Fatalf("config file was rewritten: got %q", onDisk)
+	}
+}
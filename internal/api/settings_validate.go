@@ -0,0 +1,217 @@
+package api
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xiaobei/singbox-manager/internal/builder"
+	"github.com/xiaobei/singbox-manager/internal/storage"
+)
+
+// settingsProblem is one issue found while validating settings
+type settingsProblem struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// settingsProblemsError carries the problems that kept settings from being saved
+type settingsProblemsError []settingsProblem
+
+func (e settingsProblemsError) Error() string {
+	parts := make([]string, 0, len(e))
+	for _, p := range e {
+		parts = append(parts, p.Field+": "+p.Message)
+	}
+	return strings.Join(parts, "; ")
+}
+
+// settingsPort is a port bound by the generated config or the manager itself
+type settingsPort struct {
+	field string
+	port  int
+	host  string // address sing-box binds, empty for ports that are not probed
+}
+
+// settingsPorts lists the configured ports with the address each one binds
+func settingsPorts(settings *storage.Settings) []settingsPort {
+	inboundHost := builder.InboundListenAddress(settings)
+	clashHost := "127.0.0.1"
+	if settings.AllowLAN {
+		clashHost = "0.0.0.0"
+	}
+	return []settingsPort{
+		{field: "mixed_port", port: enabledPort(settings.MixedEnabled, settings.MixedPort), host: inboundHost},
+		{field: "socks_port", port: enabledPort(settings.SocksEnabled, settings.SocksPort), host: inboundHost},
+		{field: "http_port", port: enabledPort(settings.HttpEnabled, settings.HttpPort), host: inboundHost},
+		{field: "shadowsocks_port", port: settings.ShadowsocksPort, host: inboundHost},
+		{field: "clash_api_port", port: settings.ClashAPIPort, host: clashHost},
+		// Already bound by this process, so only checked for conflicts
+		{field: "web_port", port: settings.WebPort},
+	}
+}
+
+// enabledPort returns port for an enabled inbound and 0 for a disabled one, whose port is not bound
+func enabledPort(enabled bool, port int) int {
+	if !enabled {
+		return 0
+	}
+	return port
+}
+
+// skipPortProbe accepts every port. Saving uses it since the running sing-box
+// holds the current ports and would make them look taken.
+func skipPortProbe(string, int) error { return nil }
+
+// checkPortFree reports whether host:port can currently be bound
+func checkPortFree(host string, port int) error {
+	l, err := net.Listen("tcp", net.JoinHostPort(host, strconv.Itoa(port)))
+	if err != nil {
+		return err
+	}
+	return l.Close()
+}
+
+// collectSettingsProblems checks settings without saving them: distinct, in-range and
+// bindable ports, the listen address, DNS server lists, intervals and value ranges.
+// Ports in ownedPorts are held by the running sing-box and not probed.
+func collectSettingsProblems(settings *storage.Settings, portFree func(host string, port int) error, ownedPorts map[int]bool) []settingsProblem {
+	problems := []settingsProblem{}
+	add := func(field, format string, args ...interface{}) {
+		problems = append(problems, settingsProblem{Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+
+	// Ports
+	portOwner := make(map[int]string)
+	for _, p := range settingsPorts(settings) {
+		if p.port == 0 && p.field != "web_port" {
+			continue // disabled
+		}
+		if p.port < 1 || p.port > 65535 {
+			add(p.field, "port %d is out of range 1-65535", p.port)
+			continue
+		}
+		if other, ok := portOwner[p.port]; ok {
+			add(p.field, "port %d is already used by %s", p.port, other)
+			continue
+		}
+		portOwner[p.port] = p.field
+		if p.host == "" || ownedPorts[p.port] {
+			continue
+		}
+		if err := portFree(p.host, p.port); err != nil {
+			add(p.field, "port %d is not available on %s: %v", p.port, p.host, err)
+		}
+	}
+
+	// Bind address. The per-inbound addresses are external hostnames for share links.
+	if err := storage.ValidateListenAddress(settings.ListenAddress); err != nil {
+		add("listen_address", "%v", err)
+	}
+
+	// DNS
+	for _, d := range []struct{ field, raw string }{
+		{"proxy_dns", settings.ProxyDNS},
+		{"direct_dns", settings.DirectDNS},
+	} {
+		if invalid := builder.InvalidDNSServers(d.raw); len(invalid) > 0 {
+			add(d.field, "unsupported DNS server(s): %s", strings.Join(invalid, ", "))
+		}
+	}
+
+	// Intervals
+	if settings.SubscriptionInterval < 0 {
+		add("subscription_interval", "must not be negative")
+	}
+	if settings.VerificationInterval < 0 {
+		add("verification_interval", "must not be negative")
+	}
+	if settings.ArchiveThreshold < 1 {
+		add("archive_threshold", "must be at least 1")
+	}
+	if settings.MinUptimePercent < 0 || settings.MinUptimePercent > 100 {
+		add("min_uptime_percent", "must be between 0 and 100")
+	}
+	if settings.UptimeWindowHours < 0 {
+		add("uptime_window_hours", "must not be negative")
+	}
+	if settings.HealthRetentionDays < 0 {
+		add("health_retention_days", "must not be negative")
+	}
+	if n := settings.DrainTimeoutSeconds; n < 0 || n > storage.MaxDrainTimeoutSeconds {
+		add("drain_timeout_seconds", "must be between 0 and %d", storage.MaxDrainTimeoutSeconds)
+	}
+	if !storage.IsValidNoNodesMode(settings.NoNodesMode) {
+		add("no_nodes_mode", "must be direct or reject")
+	}
+	if err := storage.ValidateScoreWeights(settings.ScoreWeightLatency, settings.ScoreWeightUptime, settings.ScoreWeightSites); err != nil {
+		add("score_weight_latency", "%v", err)
+	}
+	if n := settings.TrafficSampleIntervalSeconds; n != 0 && (n < storage.MinTrafficSampleIntervalSeconds || n > storage.MaxTrafficSampleIntervalSeconds) {
+		add("traffic_sample_interval_seconds", "must be between %d and %d",
+			storage.MinTrafficSampleIntervalSeconds, storage.MaxTrafficSampleIntervalSeconds)
+	}
+
+	// Value checks
+	if err := storage.ValidateSniffSettings(settings.Sniffers, settings.SniffTimeout); err != nil {
+		add("sniffers", "%v", err)
+	}
+	if err := validateWebhookURL(settings.WebhookURL); err != nil {
+		add("webhook_url", "%v", err)
+	}
+	if err := validateURLTestSettings(settings.URLTestURL, settings.URLTestExpectedStatus); err != nil {
+		add("urltest_url", "%v", err)
+	}
+	if err := validateUDPCheckTarget(settings.UDPCheckTarget); err != nil {
+		add("udp_check_target", "%v", err)
+	}
+	if err := storage.ValidateTunSettings(settings.TunStack, settings.TunMTU); err != nil {
+		add("tun_stack", "%v", err)
+	}
+	if err := storage.ValidateTunRoutes(settings.TunIncludeRoutes); err != nil {
+		add("tun_include_routes", "%v", err)
+	}
+	if err := storage.ValidateTunRoutes(settings.TunExcludeRoutes); err != nil {
+		add("tun_exclude_routes", "%v", err)
+	}
+	if err := storage.ValidateDirectProcesses(settings.DirectProcesses); err != nil {
+		add("direct_processes", "%v", err)
+	}
+	if err := storage.ValidateQuietHours(settings.QuietHours); err != nil {
+		add("quiet_hours", "%v", err)
+	}
+	if err := storage.ValidateUTLSFingerprint(settings.DefaultUTLSFingerprint); err != nil {
+		add("default_utls_fingerprint", "%v", err)
+	}
+
+	return problems
+}
+
+// validateSettings reports every problem in the submitted settings without saving them,
+// so the UI can check a form before committing it.
+func (s *Server) validateSettings(c *gin.Context) {
+	var settings storage.Settings
+	if err := c.ShouldBindJSON(&settings); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Ports the running sing-box already holds would otherwise always look taken
+	ownedPorts := make(map[int]bool)
+	if s.processManager != nil && s.processManager.IsRunning() {
+		for _, p := range settingsPorts(s.store.GetSettings()) {
+			if p.port > 0 && p.field != "web_port" {
+				ownedPorts[p.port] = true
+			}
+		}
+	}
+
+	problems := collectSettingsProblems(&settings, checkPortFree, ownedPorts)
+	c.JSON(http.StatusOK, gin.H{"data": gin.H{
+		"valid":    len(problems) == 0,
+		"problems": problems,
+	}})
+}
@@ -0,0 +1,115 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xiaobei/singbox-manager/internal/storage"
+)
+
+func TestCollectSettingsProblems_ConflictingPorts(t *testing.T) {
+	portFree := func(string, int) error { return nil }
+
+	settings := storage.DefaultSettings()
+	if problems := collectSettingsProblems(settings, portFree, nil); len(problems) != 0 {
+		t.Fatalf("expected default settings to be valid, got %+v", problems)
+	}
+
+	settings.SocksPort = settings.MixedPort
+	settings.SocksEnabled = true
+	settings.HttpPort = settings.ClashAPIPort
+	settings.HttpEnabled = true
+	settings.ProxyDNS = "https://1.1.1.1/dns-query, ftp://bad"
+	settings.ArchiveThreshold = 0
+	settings.ListenAddress = "proxy.example.com"
+	// External hostnames for share links, not bind addresses
+	settings.MixedAddress = "proxy.example.com"
+	settings.ShadowsocksAddress = "ss.example.com"
+
+	problems := collectSettingsProblems(settings, portFree, nil)
+	got := make(map[string]string, len(problems))
+	for _, p := range problems {
+		got[p.Field] = p.Message
+	}
+	want := map[string]string{
+		"socks_port":        "port 2080 is already used by mixed_port",
+		"clash_api_port":    "port 9091 is already used by http_port",
+		"proxy_dns":         "unsupported DNS server(s): ftp://bad",
+		"archive_threshold": "must be at least 1",
+		"listen_address":    `invalid listen address "proxy.example.com", expected an IP address`,
+	}
+	if len(got) != len(want) {
+		t.Fatalf("problems mismatch: got %+v, want %v", problems, want)
+	}
+	for field, msg := range want {
+		if got[field] != msg {
+			t.Fatalf("%s problem mismatch: got %q, want %q", field, got[field], msg)
+		}
+	}
+
+	// A disabled inbound does not bind its port, so it cannot conflict
+	settings.SocksEnabled = false
+	for _, p := range collectSettingsProblems(settings, portFree, nil) {
+		if p.Field == "socks_port" {
+			t.Fatalf("unexpected problem for disabled socks inbound: %+v", p)
+		}
+	}
+}
+
+func TestValidateSettings_PortInUse(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	store, err := storage.NewSQLiteStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("create sqlite store: %v", err)
+	}
+	t.Cleanup(func() { _ = store.Close() })
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer l.Close()
+	busyPort := l.Addr().(*net.TCPAddr).Port
+
+	settings := storage.DefaultSettings()
+	settings.MixedPort = busyPort
+	settings.ShadowsocksPort = 0
+	settings.ClashAPIPort = 0
+	body, _ := json.Marshal(settings)
+
+	s := &Server{store: store}
+	router := gin.New()
+	router.POST("/settings/validate", s.validateSettings)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/settings/validate", bytes.NewReader(body)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status mismatch: got %d, want %d (%s)", rec.Code, http.StatusOK, rec.Body.String())
+	}
+
+	var resp struct {
+		Data struct {
+			Valid    bool              `json:"valid"`
+			Problems []settingsProblem `json:"problems"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if resp.Data.Valid || len(resp.Data.Problems) != 1 || resp.Data.Problems[0].Field != "mixed_port" {
+		t.Fatalf("expected only an in-use mixed_port problem, got %+v", resp.Data)
+	}
+
+	// A port held by the running sing-box is not reported as taken
+	if problems := collectSettingsProblems(settings, checkPortFree, map[int]bool{busyPort: true}); len(problems) != 0 {
+		t.Fatalf("expected owned port to be skipped, got %+v", problems)
+	}
+	if saved := store.GetSettings(); saved.MixedPort == busyPort {
+		t.Fatal("validation must not save settings")
+	}
+}
@@ -0,0 +1,35 @@
+package api
+
+import (
+	"github.com/xiaobei/singbox-manager/internal/logger"
+	"github.com/xiaobei/singbox-manager/internal/storage"
+)
+
+// singboxStopper is the part of the process manager the shutdown path needs
+type singboxStopper interface {
+	IsRunning() bool
+	Stop() error
+}
+
+// teardownSingbox stops sing-box on manager exit unless it is configured to stay detached.
+// It reports whether sing-box was stopped.
+func teardownSingbox(settings *storage.Settings, pm singboxStopper) (bool, error) {
+	if settings == nil || settings.DetachSingbox || pm == nil || !pm.IsRunning() {
+		return false, nil
+	}
+	if err := pm.Stop(); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Shutdown runs the manager's exit teardown. It must be called before the store is
+// closed, since the detach choice is read from settings.
+func (s *Server) Shutdown() {
+	stopped, err := teardownSingbox(s.store.GetSettings(), s.processManager)
+	if err != nil {
+		logger.Printf("[shutdown] Failed to stop sing-box: %v", err)
+	} else if stopped {
+		logger.Printf("[shutdown] Stopped sing-box")
+	}
+}
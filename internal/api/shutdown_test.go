@@ -0,0 +1,44 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/xiaobei/singbox-manager/internal/storage"
+)
+
+type fakeSingboxStopper struct {
+	running bool
+	stops   int
+}
+
+func (f *fakeSingboxStopper) IsRunning() bool { return f.running }
+
+func (f *fakeSingboxStopper) Stop() error {
+	f.stops++
+	f.running = false
+	return nil
+}
+
+func TestTeardownSingbox(t *testing.T) {
+	settings := storage.DefaultSettings()
+	if !settings.DetachSingbox {
+		t.Fatalf("expected sing-box to be detached by default")
+	}
+
+	pm := &fakeSingboxStopper{running: true}
+	stopped, err := teardownSingbox(settings, pm)
+	if err != nil || stopped || pm.stops != 0 || !pm.running {
+		t.Fatalf("detached teardown mismatch: stopped=%v err=%v stops=%d running=%v", stopped, err, pm.stops, pm.running)
+	}
+
+	settings.DetachSingbox = false
+	stopped, err = teardownSingbox(settings, pm)
+	if err != nil || !stopped || pm.stops != 1 || pm.running {
+		t.Fatalf("attached teardown mismatch: stopped=%v err=%v stops=%d running=%v", stopped, err, pm.stops, pm.running)
+	}
+
+	stopped, err = teardownSingbox(settings, pm)
+	if err != nil || stopped || pm.stops != 1 {
+		t.Fatalf("expected no stop when sing-box is not running: stopped=%v err=%v stops=%d", stopped, err, pm.stops)
+	}
+}
@@ -0,0 +1,114 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xiaobei/singbox-manager/internal/storage"
+)
+
+// statusActiveNodeTTL is how long the active node read from the Clash API is reused,
+// since widgets poll the status every few seconds
+const statusActiveNodeTTL = 10 * time.Second
+
+// CompactStatus is the small status document polled by widgets and menubar apps
+type CompactStatus struct {
+	Running          bool               `json:"running"`
+	ActiveNode       string             `json:"active_node"`
+	UpBps            int64              `json:"up_bps"`
+	DownBps          int64              `json:"down_bps"`
+	Nodes            storage.NodeCounts `json:"nodes"`
+	UnappliedChanges bool               `json:"unapplied_changes"`
+}
+
+// getCompactStatus returns the essentials in one small payload, assembled from the
+// latest traffic sample, node counts, the cached active node and the config hash
+// recorded at apply time.
+func (s *Server) getCompactStatus(c *gin.Context) {
+	status := CompactStatus{
+		Running: s.processManager.IsRunning(),
+		Nodes:   s.store.GetNodeCounts(),
+	}
+
+	if latest, err := s.store.GetLatestTrafficSample(); err == nil && latest != nil {
+		status.UpBps = latest.UpBps
+		status.DownBps = latest.DownBps
+	}
+
+	if status.Running {
+		status.ActiveNode = s.cachedActiveNode(time.Now())
+	}
+
+	status.UnappliedChanges = s.isApplyPending() || !s.configUpToDate()
+
+	c.JSON(http.StatusOK, gin.H{"data": status})
+}
+
+// cachedActiveNode returns the node the Proxy selector currently resolves to, reusing
+// the last answer for statusActiveNodeTTL
+func (s *Server) cachedActiveNode(now time.Time) string {
+	s.statusMu.Lock()
+	if !s.statusActiveNodeAt.IsZero() && now.Sub(s.statusActiveNodeAt) < statusActiveNodeTTL {
+		active := s.statusActiveNode
+		s.statusMu.Unlock()
+		return active
+	}
+	s.statusMu.Unlock()
+
+	active := ""
+	if proxies, err := s.fetchClashProxiesSnapshot(); err == nil {
+		if root, ok := proxies["Proxy"]; ok && strings.TrimSpace(root.Now) != "" {
+			active = resolveProxyLeaf(proxies, root.Now)
+		}
+	}
+
+	s.statusMu.Lock()
+	defer s.statusMu.Unlock()
+	s.statusActiveNode = active
+	s.statusActiveNodeAt = now
+	return active
+}
+
+// recordAppliedConfig remembers the hash of the config just written to the configured file
+func (s *Server) recordAppliedConfig(content []byte) {
+	hash := configHash(content)
+	s.statusMu.Lock()
+	defer s.statusMu.Unlock()
+	s.appliedConfigHash = hash
+	s.configChanged = false
+}
+
+// markConfigChanged notes that settings or nodes changed after the last apply
+func (s *Server) markConfigChanged() {
+	s.statusMu.Lock()
+	defer s.statusMu.Unlock()
+	s.configChanged = true
+}
+
+// configUpToDate reports whether the configured file still holds the config last
+// applied and nothing changed since. Before the first apply of this run, the file is
+// compared once with a fresh build to seed the recorded hash; a config that cannot
+// be built counts as up to date then, there is nothing newer to apply.
+func (s *Server) configUpToDate() bool {
+	data, err := os.ReadFile(s.resolvePath(s.store.GetSettings().ConfigPath))
+	if err != nil {
+		return false
+	}
+	fileHash := configHash(data)
+
+	s.statusMu.Lock()
+	defer s.statusMu.Unlock()
+	if s.appliedConfigHash == "" {
+		s.appliedConfigHash = fileHash
+		if cfg, err := s.buildAppliedConfig(); err == nil {
+			if expected, err := json.Marshal(cfg); err == nil {
+				s.configChanged = configHash(expected) != fileHash
+			}
+		}
+	}
+	return !s.configChanged && fileHash == s.appliedConfigHash
+}
@@ -0,0 +1,125 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xiaobei/singbox-manager/internal/daemon"
+	"github.com/xiaobei/singbox-manager/internal/storage"
+)
+
+func TestGetCompactStatus_Shape(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	dir := t.TempDir()
+	store, err := storage.NewSQLiteStore(dir)
+	if err != nil {
+		t.Fatalf("create sqlite store: %v", err)
+	}
+	t.Cleanup(func() { _ = store.Close() })
+
+	for _, n := range []storage.UnifiedNode{
+		{Tag: "hk-1", InternalTag: "hk-1", Type: "trojan", Server: "10.0.0.1", ServerPort: 443,
+			Extra: map[string]interface{}{"password": "secret"}, Status: storage.NodeStatusVerified},
+		{Tag: "jp-1", InternalTag: "jp-1", Type: "trojan", Server: "10.0.0.2", ServerPort: 443,
+			Extra: map[string]interface{}{"password": "secret"}, Status: storage.NodeStatusPending},
+	} {
+		if _, err := store.AddNode(n); err != nil {
+			t.Fatalf("insert node %s: %v", n.Tag, err)
+		}
+	}
+	if _, err := store.AddTrafficSample(storage.TrafficSample{Timestamp: time.Now(), UpBps: 1200, DownBps: 34000}, nil, nil); err != nil {
+		t.Fatalf("add traffic sample: %v", err)
+	}
+
+	settings := store.GetSettings()
+	settings.ConfigPath = filepath.Join(dir, "config.json")
+	settings.AutoApply = false
+	if err := store.UpdateSettings(settings); err != nil {
+		t.Fatalf("update settings: %v", err)
+	}
+
+	s := &Server{
+		store:            store,
+		processManager:   daemon.NewProcessManager(filepath.Join(dir, "missing-sing-box"), settings.ConfigPath, dir),
+		unsupportedNodes: map[string]UnsupportedNodeInfo{},
+	}
+	router := gin.New()
+	router.GET("/status", s.getCompactStatus)
+	get := func() map[string]interface{} {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/status", nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("status mismatch: got %d, want %d (%s)", rec.Code, http.StatusOK, rec.Body.String())
+		}
+		var resp struct {
+			Data map[string]interface{} `json:"data"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("decode response: %v", err)
+		}
+		return resp.Data
+	}
+
+	data := get()
+	keys := make([]string, 0, len(data))
+	for k := range data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	if want := []string{"active_node", "down_bps", "nodes", "running", "unapplied_changes", "up_bps"}; !reflect.DeepEqual(keys, want) {
+		t.Fatalf("keys mismatch: got %v, want %v", keys, want)
+	}
+	if data["running"] != false || data["active_node"] != "" || data["up_bps"] != float64(1200) || data["down_bps"] != float64(34000) {
+		t.Fatalf("status mismatch: got %v", data)
+	}
+	if want := map[string]interface{}{"pending": float64(1), "verified": float64(1), "archived": float64(0)}; !reflect.DeepEqual(data["nodes"], want) {
+		t.Fatalf("node counts mismatch: got %v, want %v", data["nodes"], want)
+	}
+	if data["unapplied_changes"] != true {
+		t.Fatalf("expected unapplied changes without a config file, got %v", data["unapplied_changes"])
+	}
+
+	// Writing the config apply would produce seeds the recorded hash from one comparison
+	configJSON, err := s.buildConfig()
+	if err != nil {
+		t.Fatalf("build config: %v", err)
+	}
+	if err := os.WriteFile(settings.ConfigPath, []byte(configJSON), 0644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	if data := get(); data["unapplied_changes"] != false {
+		t.Fatalf("expected no unapplied changes after writing the config, got %v", data["unapplied_changes"])
+	}
+
+	// A change without auto-apply stays unapplied until the config is saved again
+	if err := s.autoApplyConfig(); err != nil {
+		t.Fatalf("auto apply: %v", err)
+	}
+	if data := get(); data["unapplied_changes"] != true {
+		t.Fatalf("expected unapplied changes after a config change, got %v", data["unapplied_changes"])
+	}
+	if err := s.saveConfigFile(settings.ConfigPath, configJSON); err != nil {
+		t.Fatalf("save config: %v", err)
+	}
+	if data := get(); data["unapplied_changes"] != false {
+		t.Fatalf("expected no unapplied changes after saving the config, got %v", data["unapplied_changes"])
+	}
+
+	// Editing the file by hand no longer matches the applied hash
+	if err := os.WriteFile(settings.ConfigPath, []byte(`{"log":{"level":"debug"}}`), 0644); err != nil {
+		t.Fatalf("edit config: %v", err)
+	}
+	if data := get(); data["unapplied_changes"] != true {
+		t.Fatalf("expected unapplied changes after a hand edit, got %v", data["unapplied_changes"])
+	}
+}
@@ -0,0 +1,46 @@
+package api
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/xiaobei/singbox-manager/internal/storage"
+)
+
+// redactedAuthValue replaces a stored subscription auth value in API responses.
+// Sending it back on update keeps the stored value.
+const redactedAuthValue = "********"
+
+// validateSubscriptionAuth checks that the auth header is a plain header name and
+// that neither part can smuggle extra header lines into the request.
+func validateSubscriptionAuth(auth storage.SubscriptionAuth) error {
+	if auth.AuthHeader == "" {
+		if auth.AuthValue != "" {
+			return fmt.Errorf("auth_header is required when auth_value is set")
+		}
+		return nil
+	}
+	if strings.ContainsAny(auth.AuthHeader, " \t\r\n:") {
+		return fmt.Errorf("auth_header %q is not a valid header name", auth.AuthHeader)
+	}
+	if strings.ContainsAny(auth.AuthValue, "\r\n") {
+		return fmt.Errorf("auth_value must not contain line breaks")
+	}
+	return nil
+}
+
+// redactSubscription returns sub with its auth value masked for API responses
+func redactSubscription(sub storage.Subscription) storage.Subscription {
+	if sub.AuthValue != "" {
+		sub.AuthValue = redactedAuthValue
+	}
+	return sub
+}
+
+// redactSubscriptions masks the auth values of every subscription in place
+func redactSubscriptions(subs []storage.Subscription) []storage.Subscription {
+	for i := range subs {
+		subs[i] = redactSubscription(subs[i])
+	}
+	return subs
+}
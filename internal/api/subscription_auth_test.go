@@ -0,0 +1,70 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xiaobei/singbox-manager/internal/service"
+	"github.com/xiaobei/singbox-manager/internal/storage"
+)
+
+func TestUpdateSubscription_KeepsAuthWhenOmitted(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	store, err := storage.NewSQLiteStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("create sqlite store: %v", err)
+	}
+	t.Cleanup(func() { _ = store.Close() })
+
+	settings := store.GetSettings()
+	settings.AutoApply = false
+	if err := store.UpdateSettings(settings); err != nil {
+		t.Fatalf("update settings: %v", err)
+	}
+	if err := store.AddSubscription(storage.Subscription{
+		ID:               "sub-1",
+		Name:             "Provider",
+		URL:              "https://example.com/sub",
+		Enabled:          true,
+		Nodes:            []storage.Node{},
+		SubscriptionAuth: storage.SubscriptionAuth{AuthHeader: "Authorization", AuthValue: "Bearer token"},
+	}); err != nil {
+		t.Fatalf("add subscription: %v", err)
+	}
+
+	s := &Server{store: store, subService: service.NewSubscriptionService(store)}
+	router := gin.New()
+	router.PUT("/subscriptions/:id", s.updateSubscription)
+
+	update := func(body string) {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodPut, "/subscriptions/sub-1", strings.NewReader(body)))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("update status mismatch: got %d, want %d (%s)", rec.Code, http.StatusOK, rec.Body.String())
+		}
+	}
+
+	update(`{"name":"Renamed","url":"https://example.com/sub","enabled":true}`)
+	got := store.GetSubscription("sub-1")
+	if got.Name != "Renamed" {
+		t.Fatalf("name mismatch: got %q, want Renamed", got.Name)
+	}
+	if got.AuthHeader != "Authorization" || got.AuthValue != "Bearer token" {
+		t.Fatalf("auth was erased by a name-only update: %+v", got.SubscriptionAuth)
+	}
+
+	update(`{"name":"Renamed","url":"https://example.com/sub","enabled":true,"auth_header":"Authorization","auth_value":"` + redactedAuthValue + `"}`)
+	if got := store.GetSubscription("sub-1"); got.AuthValue != "Bearer token" {
+		t.Fatalf("masked value should keep the stored one, got %q", got.AuthValue)
+	}
+
+	update(`{"name":"Renamed","url":"https://example.com/sub","enabled":true,"auth_header":""}`)
+	if got := store.GetSubscription("sub-1"); got.AuthHeader != "" || got.AuthValue != "" {
+		t.Fatalf("clearing the header should drop the auth, got %+v", got.SubscriptionAuth)
+	}
+}
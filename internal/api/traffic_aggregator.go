@@ -0,0 +1,82 @@
+package api
+
+import (
+	"sync"
+	"time"
+
+	"github.com/xiaobei/singbox-manager/internal/logger"
+	"github.com/xiaobei/singbox-manager/internal/storage"
+)
+
+// trafficAggregatorTicker drives periodic traffic sampling with an interval
+// that can be changed while running.
+type trafficAggregatorTicker struct {
+	mu       sync.Mutex
+	interval time.Duration
+	resetCh  chan struct{}
+}
+
+func newTrafficAggregatorTicker(interval time.Duration) *trafficAggregatorTicker {
+	return &trafficAggregatorTicker{
+		interval: interval,
+		resetCh:  make(chan struct{}, 1),
+	}
+}
+
+// Interval returns the current tick interval.
+func (t *trafficAggregatorTicker) Interval() time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.interval
+}
+
+// SetInterval changes the tick interval; the running loop restarts its ticker.
+func (t *trafficAggregatorTicker) SetInterval(interval time.Duration) bool {
+	t.mu.Lock()
+	if interval <= 0 || interval == t.interval {
+		t.mu.Unlock()
+		return false
+	}
+	t.interval = interval
+	t.mu.Unlock()
+
+	select {
+	case t.resetCh <- struct{}{}:
+	default:
+	}
+	return true
+}
+
+// run calls collect on every tick until stopCh is closed (nil runs forever).
+func (t *trafficAggregatorTicker) run(collect func(), stopCh <-chan struct{}) {
+	ticker := time.NewTicker(t.Interval())
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stopCh:
+			return
+		case <-t.resetCh:
+			ticker.Reset(t.Interval())
+		case <-ticker.C:
+			collect()
+		}
+	}
+}
+
+// trafficSampleInterval converts the configured sample interval to a duration
+func trafficSampleInterval(settings *storage.Settings) time.Duration {
+	seconds := storage.NormalizeTrafficSampleInterval(settings.TrafficSampleIntervalSeconds)
+	return time.Duration(seconds) * time.Second
+}
+
+// reconfigureTrafficAggregator applies a changed sample interval to the running aggregator
+func (s *Server) reconfigureTrafficAggregator() {
+	if s.trafficTicker == nil {
+		return
+	}
+	interval := trafficSampleInterval(s.store.GetSettings())
+	if s.trafficTicker.SetInterval(interval) {
+		logger.Printf("[monitoring] traffic sample interval changed to %s", interval)
+	}
+}
@@ -0,0 +1,66 @@
+package api
+
+import (
+	"testing"
+	"time"
+
+	"github.com/xiaobei/singbox-manager/internal/storage"
+)
+
+func TestReconfigureTrafficAggregator_ChangesTickerInterval(t *testing.T) {
+	store, err := storage.NewSQLiteStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("create sqlite store: %v", err)
+	}
+	t.Cleanup(func() { _ = store.Close() })
+
+	s := &Server{store: store}
+	s.trafficTicker = newTrafficAggregatorTicker(trafficSampleInterval(store.GetSettings()))
+	if got := s.trafficTicker.Interval(); got != 2*time.Second {
+		t.Fatalf("default interval mismatch: got %s, want 2s", got)
+	}
+
+	ticks := make(chan struct{}, 1)
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	go s.trafficTicker.run(func() {
+		select {
+		case ticks <- struct{}{}:
+		default:
+		}
+	}, stopCh)
+
+	settings := store.GetSettings()
+	settings.TrafficSampleIntervalSeconds = 1
+	if err := store.UpdateSettings(settings); err != nil {
+		t.Fatalf("update settings: %v", err)
+	}
+	s.reconfigureTrafficAggregator()
+
+	if got := s.trafficTicker.Interval(); got != time.Second {
+		t.Fatalf("interval mismatch after update: got %s, want 1s", got)
+	}
+	select {
+	case <-ticks:
+	case <-time.After(1800 * time.Millisecond):
+		t.Fatalf("expected a tick at the new 1s interval before the old 2s one")
+	}
+}
+
+func TestTrafficSampleInterval_Bounds(t *testing.T) {
+	tests := []struct {
+		seconds int
+		want    time.Duration
+	}{
+		{seconds: 0, want: 2 * time.Second},
+		{seconds: 1, want: time.Second},
+		{seconds: 30, want: 30 * time.Second},
+		{seconds: 600, want: 60 * time.Second},
+	}
+	for _, tt := range tests {
+		got := trafficSampleInterval(&storage.Settings{TrafficSampleIntervalSeconds: tt.seconds})
+		if got != tt.want {
+			t.Fatalf("interval mismatch for %d: got %s, want %s", tt.seconds, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package api
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xiaobei/singbox-manager/internal/storage"
+)
+
+// unknownTrafficCountry groups traffic of nodes without a detected country
+const unknownTrafficCountry = "OTHER"
+
+// CountryTraffic is the traffic carried by all nodes of one country
+type CountryTraffic struct {
+	Country       string    `json:"country"`
+	CountryName   string    `json:"country_name"`
+	CountryEmoji  string    `json:"country_emoji"`
+	NodeCount     int       `json:"node_count"` // nodes of this country that carried traffic
+	UploadBytes   int64     `json:"upload_bytes"`
+	DownloadBytes int64     `json:"download_bytes"`
+	TotalBytes    int64     `json:"total_bytes"`
+	LastSeen      time.Time `json:"last_seen"`
+}
+
+// collectNodeCountries maps canonical node tags to their country code
+func collectNodeCountries(store storage.Store) map[string]string {
+	countries := make(map[string]string)
+	for _, status := range []storage.NodeStatus{
+		storage.NodeStatusVerified,
+		storage.NodeStatusPending,
+		storage.NodeStatusArchived,
+	} {
+		for _, node := range store.GetNodes(status) {
+			if tag := unifiedRoutingTag(node); tag != "" {
+				countries[tag] = strings.ToUpper(strings.TrimSpace(node.Country))
+			}
+		}
+	}
+	return countries
+}
+
+// computeCountryTraffic aggregates per-node traffic into per-country totals, largest first
+func computeCountryTraffic(chainStats []storage.TrafficChainStats, knownTags, nodeCountries map[string]string) []CountryTraffic {
+	agg := make(map[string]*CountryTraffic)
+	for nodeTag, usage := range computeNodeUsage(chainStats, knownTags) {
+		code := nodeCountries[nodeTag]
+		if code == "" {
+			code = unknownTrafficCountry
+		}
+		item, ok := agg[code]
+		if !ok {
+			item = &CountryTraffic{Country: code}
+			if code != unknownTrafficCountry {
+				item.CountryName = storage.GetCountryName(code)
+				item.CountryEmoji = storage.GetCountryEmoji(code)
+			} else {
+				item.CountryName = "Other"
+				item.CountryEmoji = storage.GetCountryEmoji("")
+			}
+			agg[code] = item
+		}
+		item.NodeCount++
+		item.UploadBytes += usage.UploadBytes
+		item.DownloadBytes += usage.DownloadBytes
+		if usage.LastUsedAt.After(item.LastSeen) {
+			item.LastSeen = usage.LastUsedAt
+		}
+	}
+
+	items := make([]CountryTraffic, 0, len(agg))
+	for _, item := range agg {
+		item.TotalBytes = item.UploadBytes + item.DownloadBytes
+		items = append(items, *item)
+	}
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].TotalBytes == items[j].TotalBytes {
+			return items[i].Country < items[j].Country
+		}
+		return items[i].TotalBytes > items[j].TotalBytes
+	})
+	return items
+}
+
+// getMonitoringTrafficByCountry reports upload/download aggregated by node country
+func (s *Server) getMonitoringTrafficByCountry(c *gin.Context) {
+	hours := 0
+	if raw := strings.TrimSpace(c.Query("hours")); raw != "" {
+		if parsed, err := strconv.Atoi(raw); err == nil && parsed >= 0 {
+			hours = parsed
+		}
+	}
+	if hours > 24*365 {
+		hours = 24 * 365
+	}
+	var lookback time.Duration
+	if hours > 0 {
+		lookback = time.Duration(hours) * time.Hour
+	}
+
+	chainStats, err := s.store.GetTrafficChainStats(5000, lookback)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	items := computeCountryTraffic(chainStats, collectKnownNodeTags(s.store), collectNodeCountries(s.store))
+	c.JSON(http.StatusOK, gin.H{"data": items, "hours": hours})
+}
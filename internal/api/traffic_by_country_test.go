@@ -0,0 +1,46 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/xiaobei/singbox-manager/internal/storage"
+)
+
+func TestComputeCountryTraffic(t *testing.T) {
+	store, err := storage.NewSQLiteStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("create sqlite store: %v", err)
+	}
+	t.Cleanup(func() { _ = store.Close() })
+
+	for _, n := range []storage.UnifiedNode{
+		{Tag: "hk-1", InternalTag: "node_hk1", Type: "vmess", Server: "10.0.0.1", ServerPort: 443, Country: "HK", Status: storage.NodeStatusVerified},
+		{Tag: "hk-2", InternalTag: "node_hk2", Type: "vmess", Server: "10.0.0.2", ServerPort: 443, Country: "HK", Status: storage.NodeStatusVerified},
+		{Tag: "jp-1", InternalTag: "node_jp1", Type: "vmess", Server: "10.0.0.3", ServerPort: 443, Country: "JP", Status: storage.NodeStatusVerified},
+	} {
+		if _, err := store.AddNode(n); err != nil {
+			t.Fatalf("insert node %s: %v", n.Tag, err)
+		}
+	}
+
+	seen := mustUTC("2026-03-04T07:30:00Z")
+	chainStats := []storage.TrafficChainStats{
+		{ProxyChain: "Proxy -> node_hk1", LastSeen: seen, UploadBytes: 100, DownloadBytes: 1000},
+		{ProxyChain: "Proxy -> Auto -> node_hk2", LastSeen: seen, UploadBytes: 50, DownloadBytes: 500},
+		{ProxyChain: "Final -> node_jp1", LastSeen: seen, UploadBytes: 10, DownloadBytes: 20},
+		{ProxyChain: "direct", LastSeen: seen, UploadBytes: 9999, DownloadBytes: 9999},
+	}
+
+	items := computeCountryTraffic(chainStats, collectKnownNodeTags(store), collectNodeCountries(store))
+	if len(items) != 2 {
+		t.Fatalf("country count mismatch: got %d, want 2 (%+v)", len(items), items)
+	}
+
+	hk, jp := items[0], items[1]
+	if hk.Country != "HK" || hk.NodeCount != 2 || hk.UploadBytes != 150 || hk.DownloadBytes != 1500 || hk.TotalBytes != 1650 {
+		t.Fatalf("HK aggregation mismatch: got %+v", hk)
+	}
+	if jp.Country != "JP" || jp.NodeCount != 1 || jp.TotalBytes != 30 {
+		t.Fatalf("JP aggregation mismatch: got %+v", jp)
+	}
+}
@@ -0,0 +1,266 @@
+package api
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xiaobei/singbox-manager/internal/daemon"
+	"github.com/xiaobei/singbox-manager/internal/storage"
+)
+
+// defaultUDPCheckTarget answers the DNS query sent by UDP health checks
+const defaultUDPCheckTarget = "1.1.1.1:53"
+
+// UDP health checks retry once, UDP being lossy, within the same overall budget as delay tests
+const (
+	udpCheckAttempts = 2
+	udpCheckTimeout  = 2500 * time.Millisecond
+)
+
+// validateUDPCheckTarget checks that a UDP health check target is host:port
+func validateUDPCheckTarget(target string) error {
+	target = strings.TrimSpace(target)
+	if target == "" {
+		return nil
+	}
+	host, port, err := net.SplitHostPort(target)
+	if err != nil || host == "" {
+		return fmt.Errorf("udp_check_target must be host:port, e.g. %s", defaultUDPCheckTarget)
+	}
+	if n, err := strconv.Atoi(port); err != nil || n < 1 || n > 65535 {
+		return fmt.Errorf("udp_check_target port must be between 1 and 65535")
+	}
+	return nil
+}
+
+// udpCheckTarget returns the configured UDP health check target
+func (s *Server) udpCheckTarget() string {
+	if settings := s.store.GetSettings(); settings != nil {
+		if target := strings.TrimSpace(settings.UDPCheckTarget); target != "" {
+			return target
+		}
+	}
+	return defaultUDPCheckTarget
+}
+
+// probeUDPProxyPort returns the probe's UDP health check socks port, 0 when unavailable
+func (s *Server) probeUDPProxyPort() int {
+	if s.probeManager == nil {
+		return 0
+	}
+	return s.probeManager.UDPProxyPort()
+}
+
+// healthCheckMode is the measurement mode of a node's health check: "udp" for QUIC/UDP
+// protocols when the probe exposes its UDP socks inbound, "probe" otherwise.
+func healthCheckMode(node storage.Node, udpPort int) string {
+	if udpPort > 0 && daemon.UsesUDPHealthCheck(node.Type) {
+		return "udp"
+	}
+	return "probe"
+}
+
+// udpProxyDelay measures a UDP round trip to target through the probe's socks inbound,
+// authenticating as probeTag so sing-box routes it through that node. Returns 0 on failure.
+func udpProxyDelay(port int, probeTag, target string) int {
+	proxyAddr := net.JoinHostPort("127.0.0.1", strconv.Itoa(port))
+	for attempt := 0; attempt < udpCheckAttempts; attempt++ {
+		rtt, err := socks5UDPExchange(proxyAddr, probeTag, probeTag, target, dnsProbeQuery(uint16(attempt+1)), udpCheckTimeout)
+		if err == nil {
+			if ms := int(rtt.Milliseconds()); ms > 0 {
+				return ms
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+// dnsProbeQuery builds a DNS A query for www.gstatic.com. DNS servers answer it and
+// echo servers return it unchanged, so either kind of target works.
+func dnsProbeQuery(id uint16) []byte {
+	msg := []byte{byte(id >> 8), byte(id), 0x01, 0x00, 0, 1, 0, 0, 0, 0, 0, 0}
+	for _, label := range strings.Split("www.gstatic.com", ".") {
+		msg = append(msg, byte(len(label)))
+		msg = append(msg, label...)
+	}
+	return append(msg, 0, 0, 1, 0, 1)
+}
+
+// socks5UDPExchange sends payload to target through a SOCKS5 UDP ASSOCIATE on proxyAddr
+// (username/password auth) and returns the time until the first reply.
+func socks5UDPExchange(proxyAddr, username, password, target string, payload []byte, timeout time.Duration) (time.Duration, error) {
+	deadline := time.Now().Add(timeout)
+	ctrl, err := net.DialTimeout("tcp", proxyAddr, timeout)
+	if err != nil {
+		return 0, err
+	}
+	defer ctrl.Close()
+	ctrl.SetDeadline(deadline)
+
+	// Greeting offering username/password only, then RFC 1929 sub-negotiation
+	if _, err := ctrl.Write([]byte{0x05, 0x01, 0x02}); err != nil {
+		return 0, err
+	}
+	reply := make([]byte, 2)
+	if _, err := io.ReadFull(ctrl, reply); err != nil {
+		return 0, err
+	}
+	if reply[0] != 0x05 || reply[1] != 0x02 {
+		return 0, fmt.Errorf("socks5 proxy refused username/password auth")
+	}
+	auth := []byte{0x01, byte(len(username))}
+	auth = append(auth, username...)
+	auth = append(auth, byte(len(password)))
+	auth = append(auth, password...)
+	if _, err := ctrl.Write(auth); err != nil {
+		return 0, err
+	}
+	if _, err := io.ReadFull(ctrl, reply); err != nil {
+		return 0, err
+	}
+	if reply[1] != 0x00 {
+		return 0, fmt.Errorf("socks5 auth failed")
+	}
+
+	// UDP ASSOCIATE from any local address
+	if _, err := ctrl.Write([]byte{0x05, 0x03, 0x00, 0x01, 0, 0, 0, 0, 0, 0}); err != nil {
+		return 0, err
+	}
+	relay, err := readSocks5Reply(ctrl)
+	if err != nil {
+		return 0, err
+	}
+	if relay.IP == nil || relay.IP.IsUnspecified() {
+		host, _, _ := net.SplitHostPort(proxyAddr)
+		relay.IP = net.ParseIP(host)
+	}
+
+	header, err := socks5UDPHeader(target)
+	if err != nil {
+		return 0, err
+	}
+	conn, err := net.DialUDP("udp", nil, relay)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close()
+	conn.SetDeadline(deadline)
+
+	start := time.Now()
+	if _, err := conn.Write(append(header, payload...)); err != nil {
+		return 0, err
+	}
+	buf := make([]byte, 2048)
+	n, err := conn.Read(buf)
+	if err != nil {
+		return 0, err
+	}
+	rtt := time.Since(start)
+	if socks5UDPHeaderLen(buf[:n]) >= n {
+		return 0, errors.New("empty udp reply")
+	}
+	return rtt, nil
+}
+
+// socks5UDPHeaderLen returns the length of the SOCKS5 UDP header at the start of packet,
+// or len(packet) when it is truncated
+func socks5UDPHeaderLen(packet []byte) int {
+	if len(packet) < 4 {
+		return len(packet)
+	}
+	size := 0
+	switch packet[3] {
+	case 0x01:
+		size = 4 + net.IPv4len + 2
+	case 0x04:
+		size = 4 + net.IPv6len + 2
+	case 0x03:
+		if len(packet) < 5 {
+			return len(packet)
+		}
+		size = 5 + int(packet[4]) + 2
+	default:
+		return len(packet)
+	}
+	if size > len(packet) {
+		return len(packet)
+	}
+	return size
+}
+
+// readSocks5Reply reads a SOCKS5 command reply and returns its bound address
+func readSocks5Reply(r io.Reader) (*net.UDPAddr, error) {
+	head := make([]byte, 4)
+	if _, err := io.ReadFull(r, head); err != nil {
+		return nil, err
+	}
+	if head[1] != 0x00 {
+		return nil, fmt.Errorf("socks5 udp associate failed with code %d", head[1])
+	}
+	var ip net.IP
+	switch head[3] {
+	case 0x01:
+		ip = make(net.IP, net.IPv4len)
+	case 0x04:
+		ip = make(net.IP, net.IPv6len)
+	case 0x03:
+		size := make([]byte, 1)
+		if _, err := io.ReadFull(r, size); err != nil {
+			return nil, err
+		}
+		host := make([]byte, size[0])
+		if _, err := io.ReadFull(r, host); err != nil {
+			return nil, err
+		}
+		addrs, err := net.LookupIP(string(host))
+		if err != nil || len(addrs) == 0 {
+			return nil, fmt.Errorf("resolve socks5 relay %s: %v", host, err)
+		}
+		ip = addrs[0]
+	default:
+		return nil, fmt.Errorf("socks5 reply has unknown address type %d", head[3])
+	}
+	if head[3] != 0x03 {
+		if _, err := io.ReadFull(r, ip); err != nil {
+			return nil, err
+		}
+	}
+	port := make([]byte, 2)
+	if _, err := io.ReadFull(r, port); err != nil {
+		return nil, err
+	}
+	return &net.UDPAddr{IP: ip, Port: int(binary.BigEndian.Uint16(port))}, nil
+}
+
+// socks5UDPHeader builds the SOCKS5 UDP request header addressing target
+func socks5UDPHeader(target string) ([]byte, error) {
+	host, portStr, err := net.SplitHostPort(target)
+	if err != nil {
+		return nil, err
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil {
+		return nil, err
+	}
+	header := []byte{0, 0, 0}
+	if ip := net.ParseIP(host); ip != nil {
+		if ip4 := ip.To4(); ip4 != nil {
+			header = append(append(header, 0x01), ip4...)
+		} else {
+			header = append(append(header, 0x04), ip.To16()...)
+		}
+	} else {
+		if len(host) > 255 {
+			return nil, fmt.Errorf("udp target host too long")
+		}
+		header = append(append(header, 0x03, byte(len(host))), host...)
+	}
+	return binary.BigEndian.AppendUint16(header, uint16(port)), nil
+}
@@ -0,0 +1,152 @@
+package api
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/xiaobei/singbox-manager/internal/events"
+	"github.com/xiaobei/singbox-manager/internal/storage"
+)
+
+// startFakeSocks5UDP runs a SOCKS5 proxy that accepts username/password auth and
+// echoes UDP datagrams back, recording the usernames it saw.
+func startFakeSocks5UDP(t *testing.T) (int, func() []string) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen socks: %v", err)
+	}
+	relay, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("listen relay: %v", err)
+	}
+	t.Cleanup(func() {
+		ln.Close()
+		relay.Close()
+	})
+
+	go func() {
+		buf := make([]byte, 2048)
+		for {
+			n, addr, err := relay.ReadFromUDP(buf)
+			if err != nil {
+				return
+			}
+			relay.WriteToUDP(buf[:n], addr)
+		}
+	}()
+
+	var mu sync.Mutex
+	var users []string
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func(conn net.Conn) {
+				defer conn.Close()
+				greeting := make([]byte, 3)
+				if _, err := io.ReadFull(conn, greeting); err != nil {
+					return
+				}
+				conn.Write([]byte{0x05, 0x02})
+				head := make([]byte, 2)
+				io.ReadFull(conn, head)
+				user := make([]byte, head[1])
+				io.ReadFull(conn, user)
+				io.ReadFull(conn, head[:1])
+				pass := make([]byte, head[0])
+				io.ReadFull(conn, pass)
+				mu.Lock()
+				users = append(users, string(user))
+				mu.Unlock()
+				conn.Write([]byte{0x01, 0x00})
+
+				request := make([]byte, 10)
+				if _, err := io.ReadFull(conn, request); err != nil || request[1] != 0x03 {
+					return
+				}
+				port := relay.LocalAddr().(*net.UDPAddr).Port
+				conn.Write([]byte{0x05, 0x00, 0x00, 0x01, 127, 0, 0, 1, byte(port >> 8), byte(port)})
+				io.Copy(io.Discard, conn)
+			}(conn)
+		}
+	}()
+
+	return ln.Addr().(*net.TCPAddr).Port, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), users...)
+	}
+}
+
+func TestRunHealthChecks_QUICNodesUseUDPPath(t *testing.T) {
+	clashAPI := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"delay":42}`))
+	}))
+	defer clashAPI.Close()
+	_, portStr, _ := net.SplitHostPort(clashAPI.Listener.Addr().String())
+	clashPort, _ := strconv.Atoi(portStr)
+	udpPort, socksUsers := startFakeSocks5UDP(t)
+
+	store, err := storage.NewSQLiteStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("create sqlite store: %v", err)
+	}
+	t.Cleanup(func() { _ = store.Close() })
+
+	s := &Server{store: store, eventBus: events.NewBus()}
+	nodes := []storage.Node{
+		{Tag: "hy2", InternalTag: "hy2", Type: "hysteria2", Server: "10.0.0.1", ServerPort: 443},
+		{Tag: "tuic", InternalTag: "tuic", Type: "tuic", Server: "10.0.0.2", ServerPort: 443},
+		{Tag: "vmess", InternalTag: "vmess", Type: "vmess", Server: "10.0.0.3", ServerPort: 443},
+	}
+
+	results := s.runHealthChecks(clashPort, udpPort, nil, nodes)
+	for _, key := range []string{"10.0.0.1:443", "10.0.0.2:443", "10.0.0.3:443"} {
+		if r := results[key]; r == nil || !r.Alive {
+			t.Fatalf("expected %s alive, got %+v", key, r)
+		}
+	}
+	if got := results["10.0.0.3:443"].Groups["Proxy"]; got != 42 {
+		t.Fatalf("vmess delay mismatch: got %d, want 42 from the clash api", got)
+	}
+
+	seen := map[string]bool{}
+	for _, user := range socksUsers() {
+		seen[user] = true
+	}
+	if !seen["hy2"] || !seen["tuic"] || seen["vmess"] {
+		t.Fatalf("udp path users mismatch: got %v, want hy2 and tuic only", socksUsers())
+	}
+
+	for server, want := range map[string]string{"10.0.0.1": "udp", "10.0.0.2": "udp", "10.0.0.3": "probe"} {
+		measurements, err := store.GetHealthMeasurements(server, 443, 10)
+		if err != nil {
+			t.Fatalf("get measurements for %s: %v", server, err)
+		}
+		if len(measurements) != 1 || measurements[0].Mode != want {
+			t.Fatalf("measurement mode for %s mismatch: got %+v, want %s", server, measurements, want)
+		}
+	}
+}
+
+func TestValidateUDPCheckTarget(t *testing.T) {
+	for _, target := range []string{"", "1.1.1.1:53", "[2606:4700::1111]:53", "echo.example.com:7"} {
+		if err := validateUDPCheckTarget(target); err != nil {
+			t.Fatalf("expected %q to be valid: %v", target, err)
+		}
+	}
+	for _, target := range []string{"1.1.1.1", ":53", "1.1.1.1:0", "1.1.1.1:dns"} {
+		if err := validateUDPCheckTarget(target); err == nil {
+			t.Fatalf("expected %q to be rejected", target)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package api
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// validateURLTestSettings checks the urltest target URL and expected status list.
+// Expected status uses the Clash syntax: codes or ranges joined by "/", e.g. "200/204" or "200-299".
+func validateURLTestSettings(targetURL, expected string) error {
+	if targetURL = strings.TrimSpace(targetURL); targetURL != "" {
+		u, err := url.Parse(targetURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("urltest_url must be an http or https URL")
+		}
+	}
+
+	expected = strings.TrimSpace(expected)
+	if expected == "" {
+		return nil
+	}
+	for _, part := range strings.Split(expected, "/") {
+		lo, hi, isRange := strings.Cut(part, "-")
+		if !isRange {
+			hi = lo
+		}
+		from, errFrom := parseHTTPStatus(lo)
+		to, errTo := parseHTTPStatus(hi)
+		if errFrom != nil || errTo != nil || from > to {
+			return fmt.Errorf("urltest_expected_status %q is invalid, use codes or ranges like 200/204 or 200-299", expected)
+		}
+	}
+	return nil
+}
+
+func parseHTTPStatus(s string) (int, error) {
+	code, err := strconv.Atoi(strings.TrimSpace(s))
+	if err != nil {
+		return 0, err
+	}
+	if code < 100 || code > 599 {
+		return 0, fmt.Errorf("status %d out of range", code)
+	}
+	return code, nil
+}
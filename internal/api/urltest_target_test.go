@@ -0,0 +1,84 @@
+package api
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/xiaobei/singbox-manager/internal/storage"
+)
+
+func TestClashProxyDelay_ConfiguredTargetAndExpectedStatus(t *testing.T) {
+	// Health URL that answers 200 instead of 204
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer target.Close()
+
+	var gotURL, gotExpected string
+	clash := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotURL = r.URL.Query().Get("url")
+		gotExpected = r.URL.Query().Get("expected")
+		resp, err := http.Get(gotURL)
+		if err != nil {
+			w.WriteHeader(http.StatusGatewayTimeout)
+			return
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]int{"delay": 42})
+	}))
+	defer clash.Close()
+
+	store, err := storage.NewSQLiteStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("create sqlite store: %v", err)
+	}
+	t.Cleanup(func() { _ = store.Close() })
+
+	settings := store.GetSettings()
+	settings.URLTestURL = target.URL + "/health"
+	settings.URLTestExpectedStatus = "200/204"
+	if err := store.UpdateSettings(settings); err != nil {
+		t.Fatalf("update settings: %v", err)
+	}
+
+	s := &Server{store: store}
+	delay := s.clashProxyDelay(clash.Listener.Addr().(*net.TCPAddr).Port, "", "node-1")
+	if delay != 42 {
+		t.Fatalf("delay mismatch: got %d, want 42", delay)
+	}
+	if gotURL != target.URL+"/health" {
+		t.Fatalf("target url mismatch: got %q", gotURL)
+	}
+	if gotExpected != "200/204" {
+		t.Fatalf("expected status mismatch: got %q, want %q", gotExpected, "200/204")
+	}
+}
+
+func TestValidateURLTestSettings(t *testing.T) {
+	tests := []struct {
+		url, expected string
+		ok            bool
+	}{
+		{"", "", true},
+		{"http://cp.cloudflare.com/", "200", true},
+		{"https://example.com/health", "200/204", true},
+		{"", "200-299/304", true},
+		{"ftp://example.com", "", false},
+		{"", "abc", false},
+		{"", "299-200", false},
+		{"", "700", false},
+	}
+	for _, tt := range tests {
+		err := validateURLTestSettings(tt.url, tt.expected)
+		if (err == nil) != tt.ok {
+			t.Fatalf("validate(%q, %q) mismatch: got %v, want ok=%v", tt.url, tt.expected, err, tt.ok)
+		}
+	}
+}
@@ -46,6 +46,18 @@ func (s *Server) RunVerificationSample(sampleSize int) {
 	s.runVerificationCore(nil, sampleSize)
 }
 
+// RunPipelineHealthCheck health-checks the given nodes and records the measurements.
+// Used by the subscription auto-promote pipeline, which decides promotion itself.
+func (s *Server) RunPipelineHealthCheck(nodes []storage.Node) error {
+	if !s.verifyInProgress.CompareAndSwap(false, true) {
+		return fmt.Errorf("verification already in progress")
+	}
+	defer s.verifyInProgress.Store(false)
+
+	_, _, err := s.performHealthCheck(nodes)
+	return err
+}
+
 // runVerificationCore performs the actual verification work.
 // Caller must hold verifyInProgress.
 func (s *Server) runVerificationCore(tagSet map[string]struct{}, sampleSize int) {
@@ -59,6 +71,7 @@ func (s *Server) runVerificationCore(tagSet map[string]struct{}, sampleSize int)
 	vlog := storage.VerificationLog{
 		Timestamp: time.Now(),
 	}
+	webhook := HealthWebhookPayload{Event: "verification"}
 
 	defer func() {
 		vlog.DurationMs = time.Since(start).Milliseconds()
@@ -76,6 +89,13 @@ func (s *Server) runVerificationCore(tagSet map[string]struct{}, sampleSize int)
 			"archived":    vlog.PendingArchived,
 			"timestamp":   time.Now().Format(time.RFC3339),
 		})
+
+		if webhook.Checked > 0 {
+			webhook.Promoted = vlog.PendingPromoted
+			webhook.Demoted = vlog.VerifiedDemoted
+			webhook.Archived = vlog.PendingArchived
+			s.notifyHealthWebhook(webhook)
+		}
 	}()
 
 	configChanged := false
@@ -155,12 +175,16 @@ func (s *Server) runVerificationCore(tagSet map[string]struct{}, sampleSize int)
 		"total_nodes": len(allCheckNodes),
 	})
 
+	previousAlive := s.latestAliveByEndpoint()
 	healthResults, _, err := s.performHealthCheck(allCheckNodes)
 	if err != nil {
 		vlog.Error = fmt.Sprintf("health check failed: %v", err)
 		logger.Printf("[verifier] Health check failed: %v", err)
 		return
 	}
+	webhook.Checked = len(healthResults)
+	webhook.Alive = countAlive(healthResults)
+	webhook.NewlyDead, webhook.NewlyAlive = healthTransitions(previousAlive, allCheckNodes, healthResults)
 
 	// 2. Site check via probe (mandatory sites) only for alive nodes
 	siteTargets := append([]string(nil), defaultSiteCheckTargets...)
@@ -246,7 +270,9 @@ func (s *Server) runVerificationCore(tagSet map[string]struct{}, sampleSize int)
 				logger.Printf("[verifier] Failed to increment failures for %d: %v", pn.ID, err)
 				continue
 			}
-			if failures >= archiveThreshold {
+			if failures >= archiveThreshold && pn.Pinned {
+				logger.Printf("[verifier] Pinned node %d (%s) reached archive threshold but skipping archive", pn.ID, unifiedDisplayName(pn))
+			} else if failures >= archiveThreshold {
 				if err := s.store.ArchiveNode(pn.ID); err != nil {
 					logger.Printf("[verifier] Failed to archive node %d: %v", pn.ID, err)
 					continue
@@ -302,9 +328,9 @@ func (s *Server) runVerificationCore(tagSet map[string]struct{}, sampleSize int)
 		}
 
 		if !alive || !sitesOk {
-			if vn.IsFavorite {
-				// Избранные серверы не демоутятся — просто логируем
-				logger.Printf("[verifier] Favorite node %d (%s) failed check but skipping demotion", vn.ID, unifiedDisplayName(vn))
+			if vn.IsFavorite || vn.Pinned {
+				// Избранные и закреплённые серверы не демоутятся — просто логируем
+				logger.Printf("[verifier] Favorite/pinned node %d (%s) failed check but skipping demotion", vn.ID, unifiedDisplayName(vn))
 				s.store.ResetConsecutiveFailures(vn.ID)
 			} else {
 				failures, err := s.store.IncrementConsecutiveFailures(vn.ID)
@@ -371,7 +397,7 @@ func (s *Server) archiveThresholdExceededPendingNodes(
 
 	filtered := make([]storage.UnifiedNode, 0, len(pendingNodes))
 	for _, pn := range pendingNodes {
-		if pn.ConsecutiveFailures < archiveThreshold {
+		if pn.Pinned || pn.ConsecutiveFailures < archiveThreshold {
 			filtered = append(filtered, pn)
 			continue
 		}
@@ -467,7 +493,8 @@ func (s *Server) archiveBrokenNodes(
 			continue
 		}
 
-		// Archive the broken node immediately
+		// Archive the broken node immediately. Pinned nodes are archived too:
+		// sing-box refuses to start with them, so keeping them would break the config.
 		if err := s.store.ArchiveNode(un.ID); err != nil {
 			logger.Printf("[verifier] Failed to archive broken node %d (%s): %v", un.ID, bn.Tag, err)
 			continue
@@ -0,0 +1,40 @@
+package builder
+
+// Route rule sources, naming the part of the settings that produced a rule
+const (
+	RouteRuleSourceSniff         = "sniff"          // settings sniffers
+	RouteRuleSourceHijackDNS     = "hijack-dns"     // built-in DNS hijack
+	RouteRuleSourceBlockQUIC     = "block-quic"     // settings QUIC block
+	RouteRuleSourceSystemHosts   = "system-hosts"   // an /etc/hosts entry
+	RouteRuleSourceHost          = "host"           // a user-defined hosts entry
+	RouteRuleSourceDirectProcess = "direct-process" // settings direct processes
+	RouteRuleSourceNoNodes       = "no-nodes"       // settings no-nodes mode, when no node is usable
+)
+
+// RouteRuleSource identifies what produced a route rule
+type RouteRuleSource struct {
+	Kind string `json:"kind"`
+	ID   string `json:"id,omitempty"`   // ID of the producing entity, when it has one
+	Name string `json:"name,omitempty"` // domain or other human-readable key
+}
+
+// ResolvedRouteRule is a route rule at its final position, with its source
+type ResolvedRouteRule struct {
+	Index    int             `json:"index"`
+	Rule     RouteRule       `json:"rule"`
+	Source   RouteRuleSource `json:"source"`
+	Outbound string          `json:"outbound,omitempty"` // empty for rules that do not pick an outbound
+}
+
+// ResolvedRouteRules returns the route rules in the order sing-box evaluates them,
+// each annotated with what produced it, plus the route final.
+func (b *ConfigBuilder) ResolvedRouteRules() ([]ResolvedRouteRule, string) {
+	_, _, indexToTag, endpointIndexToTag := b.buildOutboundsAndEndpoints()
+	route, sources := b.buildRouteWithSources(noUsableNodes(indexToTag, endpointIndexToTag))
+	rules := make([]ResolvedRouteRule, 0, len(route.Rules))
+	for i, rule := range route.Rules {
+		outbound, _ := RouteRuleOutbound(rule)
+		rules = append(rules, ResolvedRouteRule{Index: i, Rule: rule, Source: sources[i], Outbound: outbound})
+	}
+	return rules, route.Final
+}
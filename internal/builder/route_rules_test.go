@@ -0,0 +1,72 @@
+package builder
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/xiaobei/singbox-manager/internal/storage"
+)
+
+func TestResolvedRouteRules_SourcesMatchProducers(t *testing.T) {
+	settings := storage.DefaultSettings()
+	settings.Hosts = []storage.HostEntry{
+		{ID: "h1", Domain: "nas.lan", IPs: []string{"192.168.1.10"}, Enabled: true},
+		{ID: "h2", Domain: "off.lan", IPs: []string{"192.168.1.11"}, Enabled: false},
+	}
+	settings.DirectProcesses = []string{"Telegram", "/usr/bin/curl"}
+
+	b := NewConfigBuilder(settings, nil, nil)
+	rules, final := b.ResolvedRouteRules()
+	if final != "Final" {
+		t.Fatalf("final mismatch: got %q, want %q", final, "Final")
+	}
+	route := b.buildRoute(false)
+	if len(rules) != len(route.Rules) {
+		t.Fatalf("rule count mismatch: got %d, want %d", len(rules), len(route.Rules))
+	}
+
+	var hostRules, processRules []ResolvedRouteRule
+	for i, r := range rules {
+		if r.Index != i || !reflect.DeepEqual(r.Rule, route.Rules[i]) {
+			t.Fatalf("rule %d out of order: got %+v, want %v", i, r, route.Rules[i])
+		}
+		switch r.Source.Kind {
+		case RouteRuleSourceSniff:
+			if r.Rule["action"] != "sniff" {
+				t.Fatalf("sniff source on %v", r.Rule)
+			}
+		case RouteRuleSourceHijackDNS:
+			if r.Rule["action"] != "hijack-dns" {
+				t.Fatalf("hijack-dns source on %v", r.Rule)
+			}
+		case RouteRuleSourceSystemHosts:
+			if domains, _ := r.Rule["domain"].([]string); len(domains) != 1 || domains[0] != r.Source.Name {
+				t.Fatalf("system hosts source %q on %v", r.Source.Name, r.Rule)
+			}
+		case RouteRuleSourceHost:
+			hostRules = append(hostRules, r)
+		case RouteRuleSourceDirectProcess:
+			processRules = append(processRules, r)
+		default:
+			t.Fatalf("unexpected source %+v on %v", r.Source, r.Rule)
+		}
+	}
+	if rules[0].Source.Kind != RouteRuleSourceSniff || rules[1].Source.Kind != RouteRuleSourceHijackDNS {
+		t.Fatalf("leading rules mismatch: got %+v, %+v", rules[0].Source, rules[1].Source)
+	}
+
+	if len(hostRules) != 1 || hostRules[0].Source.ID != "h1" || hostRules[0].Source.Name != "nas.lan" {
+		t.Fatalf("host rules mismatch: got %+v", hostRules)
+	}
+	if got := hostRules[0].Rule["override_address"]; got != "192.168.1.10" || hostRules[0].Outbound != "DIRECT" {
+		t.Fatalf("host rule mismatch: got %v", hostRules[0].Rule)
+	}
+
+	if len(processRules) != 2 {
+		t.Fatalf("direct process rules mismatch: got %+v", processRules)
+	}
+	if !reflect.DeepEqual(processRules[0].Rule["process_name"], []string{"Telegram"}) ||
+		!reflect.DeepEqual(processRules[1].Rule["process_path"], []string{"/usr/bin/curl"}) {
+		t.Fatalf("direct process rules mismatch: got %v, %v", processRules[0].Rule, processRules[1].Rule)
+	}
+}
@@ -0,0 +1,244 @@
+package builder
+
+import (
+	"net"
+	"regexp"
+	"strings"
+)
+
+// RouteTarget is the destination being simulated: a domain, an IP, or both
+type RouteTarget struct {
+	Domain string `json:"domain,omitempty"`
+	IP     string `json:"ip,omitempty"`
+}
+
+// RouteSimulation describes which route rule a target hits and where it ends up
+type RouteSimulation struct {
+	Target      RouteTarget `json:"target"`
+	RuleIndex   int         `json:"rule_index"` // -1 when the route final was used
+	Rule        RouteRule   `json:"rule,omitempty"`
+	Outbound    string      `json:"outbound"`
+	Chain       []string    `json:"chain"`       // Outbound followed through selector defaults
+	Approximate bool        `json:"approximate"` // A geosite rule set was matched by name, or the rule has an invert/network condition
+}
+
+// SimulateRoute walks route rules in order the way sing-box does and returns
+// the first final rule matching the target. Rules with conditions that cannot
+// be evaluated from a bare domain/IP (protocol, port, inbound, ...) never match.
+// Rule sets are approximated from their tag: geosite-<name> matches domains
+// containing <name> as a label, geoip-<code> is never matched without a GeoIP database.
+// Network conditions are assumed to hold and inverted rules are negated; both are
+// reported as approximate.
+func SimulateRoute(route *RouteConfig, outbounds []Outbound, target RouteTarget) RouteSimulation {
+	target.Domain = strings.ToLower(strings.TrimSuffix(strings.TrimSpace(target.Domain), "."))
+	target.IP = strings.TrimSpace(target.IP)
+
+	result := RouteSimulation{Target: target, RuleIndex: -1}
+	if route == nil {
+		return result
+	}
+
+	for i, rule := range route.Rules {
+		outbound, final := RouteRuleOutbound(rule)
+		if !final {
+			continue
+		}
+		matched, approximate := matchRouteRule(rule, target)
+		if !matched {
+			continue
+		}
+		result.RuleIndex = i
+		result.Rule = rule
+		result.Outbound = outbound
+		result.Approximate = approximate
+		result.Chain = resolveOutboundChain(outbounds, outbound)
+		return result
+	}
+
+	result.Outbound = route.Final
+	result.Chain = resolveOutboundChain(outbounds, route.Final)
+	return result
+}
+
+// RouteRuleOutbound returns the rule's destination and whether the rule ends matching.
+// sniff, hijack-dns helpers and route-options rules do not pick an outbound.
+func RouteRuleOutbound(rule RouteRule) (string, bool) {
+	action, _ := rule["action"].(string)
+	switch action {
+	case "", "route":
+		outbound, _ := rule["outbound"].(string)
+		return outbound, outbound != ""
+	case "reject":
+		return "REJECT", true
+	default:
+		return "", false
+	}
+}
+
+// simulatedRuleKeys are the match conditions SimulateRoute can evaluate
+var simulatedRuleKeys = map[string]bool{
+	"domain":         true,
+	"domain_suffix":  true,
+	"domain_keyword": true,
+	"domain_regex":   true,
+	"ip_cidr":        true,
+	"ip_is_private":  true,
+	"rule_set":       true,
+}
+
+// matchRouteRule reports whether target satisfies the rule's conditions.
+// Like sing-box, domain and IP conditions are ORed together.
+func matchRouteRule(rule RouteRule, target RouteTarget) (matched bool, approximate bool) {
+	hasCondition := false
+	hasNetwork := false
+	for key := range rule {
+		switch key {
+		case "action", "outbound", "method", "override_address", "override_port", "invert":
+			continue
+		case "network":
+			// The target carries no network, so the condition is assumed to hold
+			hasNetwork = true
+			continue
+		}
+		if !simulatedRuleKeys[key] {
+			return false, false
+		}
+		hasCondition = true
+	}
+
+	matched, approximate = true, false
+	if hasCondition {
+		matched, approximate = matchRouteConditions(rule, target)
+	}
+	if invert, _ := rule["invert"].(bool); invert {
+		return !matched, true
+	}
+	return matched, approximate || (matched && hasNetwork)
+}
+
+// matchRouteConditions evaluates the domain, IP and rule set conditions of a rule
+func matchRouteConditions(rule RouteRule, target RouteTarget) (matched bool, approximate bool) {
+	domain := target.Domain
+	if domain != "" {
+		for _, d := range ruleStrings(rule["domain"]) {
+			if strings.EqualFold(d, domain) {
+				return true, false
+			}
+		}
+		for _, suffix := range ruleStrings(rule["domain_suffix"]) {
+			// A leading dot only matches subdomains, without it the domain itself matches too
+			suffix = strings.ToLower(suffix)
+			if strings.HasPrefix(suffix, ".") {
+				if strings.HasSuffix(domain, suffix) {
+					return true, false
+				}
+				continue
+			}
+			if domain == suffix || strings.HasSuffix(domain, "."+suffix) {
+				return true, false
+			}
+		}
+		for _, keyword := range ruleStrings(rule["domain_keyword"]) {
+			if keyword != "" && strings.Contains(domain, strings.ToLower(keyword)) {
+				return true, false
+			}
+		}
+		for _, pattern := range ruleStrings(rule["domain_regex"]) {
+			if re, err := regexp.Compile(pattern); err == nil && re.MatchString(domain) {
+				return true, false
+			}
+		}
+	}
+
+	if ip := net.ParseIP(target.IP); ip != nil {
+		for _, cidr := range ruleStrings(rule["ip_cidr"]) {
+			if !strings.Contains(cidr, "/") {
+				if other := net.ParseIP(cidr); other != nil && other.Equal(ip) {
+					return true, false
+				}
+				continue
+			}
+			if _, network, err := net.ParseCIDR(cidr); err == nil && network.Contains(ip) {
+				return true, false
+			}
+		}
+		if private, _ := rule["ip_is_private"].(bool); private && isPrivateIP(ip) {
+			return true, false
+		}
+	}
+
+	for _, tag := range ruleStrings(rule["rule_set"]) {
+		if name, ok := strings.CutPrefix(tag, "geosite-"); ok && domain != "" && domainHasLabel(domain, name) {
+			return true, true
+		}
+	}
+
+	return false, false
+}
+
+// isPrivateIP reports whether ip is a LAN, loopback or link-local address, the
+// destinations sing-box's ip_is_private matches
+func isPrivateIP(ip net.IP) bool {
+	return ip.IsPrivate() || ip.IsLoopback() || ip.IsLinkLocalUnicast() || ip.IsUnspecified()
+}
+
+// domainHasLabel reports whether name appears as a whole label in domain,
+// e.g. "youtube" in "www.youtube.com"
+func domainHasLabel(domain, name string) bool {
+	name = strings.ToLower(name)
+	for _, label := range strings.Split(domain, ".") {
+		if label == name {
+			return true
+		}
+	}
+	return false
+}
+
+// resolveOutboundChain follows selector defaults from tag until a non-selector outbound
+func resolveOutboundChain(outbounds []Outbound, tag string) []string {
+	byTag := make(map[string]Outbound, len(outbounds))
+	for _, ob := range outbounds {
+		if t, ok := ob["tag"].(string); ok {
+			byTag[t] = ob
+		}
+	}
+
+	var chain []string
+	seen := make(map[string]bool)
+	for tag != "" && !seen[tag] {
+		seen[tag] = true
+		chain = append(chain, tag)
+
+		ob, ok := byTag[tag]
+		if !ok || ob["type"] != "selector" {
+			break
+		}
+		next, _ := ob["default"].(string)
+		if next == "" {
+			if choices := ruleStrings(ob["outbounds"]); len(choices) > 0 {
+				next = choices[0]
+			}
+		}
+		tag = next
+	}
+	return chain
+}
+
+// ruleStrings normalizes a rule value that may be a string or a list
+func ruleStrings(v interface{}) []string {
+	switch val := v.(type) {
+	case string:
+		return []string{val}
+	case []string:
+		return val
+	case []interface{}:
+		out := make([]string, 0, len(val))
+		for _, item := range val {
+			if s, ok := item.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+package builder
+
+import (
+	"reflect"
+	"testing"
+)
+
+func simulationFixture() (*RouteConfig, []Outbound) {
+	route := &RouteConfig{
+		Rules: []RouteRule{
+			{"action": "sniff"},
+			{"protocol": "dns", "action": "hijack-dns"},
+			{"domain": []string{"router.lan"}, "outbound": "DIRECT", "override_address": "192.168.1.1"},
+			{"rule_set": []string{"geosite-youtube"}, "outbound": "YouTube"},
+			{"ip_cidr": []string{"10.0.0.0/8"}, "outbound": "DIRECT"},
+		},
+		Final: "Final",
+	}
+	outbounds := []Outbound{
+		{"tag": "DIRECT", "type": "direct"},
+		{"tag": "Auto", "type": "urltest", "outbounds": []string{"node-a"}},
+		{"tag": "Proxy", "type": "selector", "outbounds": []string{"Auto", "node-a"}, "default": "Auto"},
+		{"tag": "YouTube", "type": "selector", "outbounds": []string{"Proxy", "DIRECT"}},
+		{"tag": "Final", "type": "selector", "outbounds": []string{"Proxy", "DIRECT"}, "default": "Proxy"},
+	}
+	return route, outbounds
+}
+
+func TestSimulateRoute_GeositeVsFinal(t *testing.T) {
+	route, outbounds := simulationFixture()
+
+	hit := SimulateRoute(route, outbounds, RouteTarget{Domain: "www.YouTube.com."})
+	if hit.RuleIndex != 3 || hit.Outbound != "YouTube" || !hit.Approximate {
+		t.Fatalf("geosite match mismatch: got %+v", hit)
+	}
+	if want := []string{"YouTube", "Proxy", "Auto"}; !reflect.DeepEqual(hit.Chain, want) {
+		t.Fatalf("chain mismatch: got %v, want %v", hit.Chain, want)
+	}
+
+	miss := SimulateRoute(route, outbounds, RouteTarget{Domain: "example.com"})
+	if miss.RuleIndex != -1 || miss.Outbound != "Final" || miss.Approximate {
+		t.Fatalf("final fallback mismatch: got %+v", miss)
+	}
+	if want := []string{"Final", "Proxy", "Auto"}; !reflect.DeepEqual(miss.Chain, want) {
+		t.Fatalf("chain mismatch: got %v, want %v", miss.Chain, want)
+	}
+}
+
+func TestSimulateRoute_HostOverrideAndIP(t *testing.T) {
+	route, outbounds := simulationFixture()
+
+	host := SimulateRoute(route, outbounds, RouteTarget{Domain: "router.lan"})
+	if host.RuleIndex != 2 || host.Outbound != "DIRECT" {
+		t.Fatalf("host override mismatch: got %+v", host)
+	}
+
+	ip := SimulateRoute(route, outbounds, RouteTarget{IP: "10.1.2.3"})
+	if ip.RuleIndex != 4 || ip.Outbound != "DIRECT" {
+		t.Fatalf("ip_cidr match mismatch: got %+v", ip)
+	}
+}
+
+func TestSimulateRoute_SuffixInvertNetwork(t *testing.T) {
+	_, outbounds := simulationFixture()
+	route := &RouteConfig{
+		Rules: []RouteRule{
+			{"domain_suffix": []string{".example.com"}, "outbound": "DIRECT"},
+			{"domain_suffix": []string{"example.org"}, "outbound": "YouTube"},
+			{"network": "udp", "domain": []string{"quic.test"}, "action": "reject"},
+			{"domain_suffix": []string{"lan"}, "invert": true, "outbound": "Proxy"},
+		},
+		Final: "Final",
+	}
+
+	tests := []struct {
+		domain      string
+		ruleIndex   int
+		outbound    string
+		approximate bool
+	}{
+		{domain: "www.example.com", ruleIndex: 0, outbound: "DIRECT"},
+		// The apex does not match a leading-dot suffix
+		{domain: "example.com", ruleIndex: 3, outbound: "Proxy", approximate: true},
+		{domain: "example.org", ruleIndex: 1, outbound: "YouTube"},
+		{domain: "cdn.example.org", ruleIndex: 1, outbound: "YouTube"},
+		{domain: "quic.test", ruleIndex: 2, outbound: "REJECT", approximate: true},
+		{domain: "printer.lan", ruleIndex: -1, outbound: "Final"},
+	}
+	for _, tt := range tests {
+		got := SimulateRoute(route, outbounds, RouteTarget{Domain: tt.domain})
+		if got.RuleIndex != tt.ruleIndex || got.Outbound != tt.outbound || got.Approximate != tt.approximate {
+			t.Fatalf("%s: simulation mismatch: got rule %d -> %s (approximate %v), want rule %d -> %s (approximate %v)",
+				tt.domain, got.RuleIndex, got.Outbound, got.Approximate, tt.ruleIndex, tt.outbound, tt.approximate)
+		}
+	}
+}
+
+func TestSimulateRoute_PrivateIP(t *testing.T) {
+	_, outbounds := simulationFixture()
+	route := &RouteConfig{
+		Rules: []RouteRule{
+			{"ip_is_private": true, "outbound": "DIRECT"},
+			{"network": []string{"tcp", "udp"}, "action": "reject"},
+		},
+		Final: "Final",
+	}
+
+	if got := SimulateRoute(route, outbounds, RouteTarget{IP: "192.168.1.20"}); got.RuleIndex != 0 || got.Outbound != "DIRECT" {
+		t.Fatalf("private ip mismatch: got %+v", got)
+	}
+	if got := SimulateRoute(route, outbounds, RouteTarget{IP: "8.8.8.8"}); got.RuleIndex != 1 || got.Outbound != "REJECT" {
+		t.Fatalf("public ip mismatch: got %+v", got)
+	}
+}
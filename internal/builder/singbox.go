@@ -3,6 +3,7 @@ package builder
 import (
 	"encoding/json"
 	"fmt"
+	"log"
 	"net"
 	"net/url"
 	"os"
@@ -17,6 +18,7 @@ type SingBoxConfig struct {
 	Log          *LogConfig          `json:"log,omitempty"`
 	DNS          *DNSConfig          `json:"dns,omitempty"`
 	NTP          *NTPConfig          `json:"ntp,omitempty"`
+	Endpoints    []Endpoint          `json:"endpoints,omitempty"`
 	Inbounds     []Inbound           `json:"inbounds,omitempty"`
 	Outbounds    []Outbound          `json:"outbounds"`
 	Route        *RouteConfig        `json:"route,omitempty"`
@@ -85,6 +87,9 @@ type Inbound struct {
 	AutoRoute                bool          `json:"auto_route,omitempty"`
 	StrictRoute              bool          `json:"strict_route,omitempty"`
 	Stack                    string        `json:"stack,omitempty"`
+	MTU                      int           `json:"mtu,omitempty"`
+	RouteAddress             []string      `json:"route_address,omitempty"`
+	RouteExcludeAddress      []string      `json:"route_exclude_address,omitempty"`
 	Sniff                    bool          `json:"sniff,omitempty"`
 	SniffOverrideDestination bool          `json:"sniff_override_destination,omitempty"`
 	Users                    []InboundUser `json:"users,omitempty"`
@@ -96,6 +101,9 @@ type Inbound struct {
 // Outbound represents outbound configuration
 type Outbound map[string]interface{}
 
+// Endpoint represents endpoint configuration (WireGuard on sing-box 1.11+)
+type Endpoint map[string]interface{}
+
 // DomainResolver represents domain resolver configuration
 type DomainResolver struct {
 	Server     string `json:"server"`
@@ -108,6 +116,7 @@ type RouteConfig struct {
 	RuleSet               []RuleSet       `json:"rule_set,omitempty"`
 	Final                 string          `json:"final,omitempty"`
 	AutoDetectInterface   bool            `json:"auto_detect_interface,omitempty"`
+	DefaultInterface      string          `json:"default_interface,omitempty"`
 	DefaultDomainResolver *DomainResolver `json:"default_domain_resolver,omitempty"`
 }
 
@@ -153,10 +162,14 @@ type dnsServerSpec struct {
 
 // ConfigBuilder builds sing-box configuration
 type ConfigBuilder struct {
-	settings    *storage.Settings
-	nodes       []storage.Node
-	filters     []storage.Filter
-	excludeTags map[string]bool
+	settings         *storage.Settings
+	nodes            []storage.Node
+	filters          []storage.Filter
+	excludeTags      map[string]bool
+	countryOverrides map[string]storage.CountryOverride
+	echUnsupported   bool
+	muxUnsupported   bool
+	wgLegacyOutbound bool
 }
 
 // NewConfigBuilder creates a new configuration builder
@@ -178,16 +191,54 @@ func NewConfigBuilderWithExclusions(settings *storage.Settings, nodes []storage.
 	}
 }
 
+// WithCountryOverrides sets custom country group names/emoji keyed by country code
+func (b *ConfigBuilder) WithCountryOverrides(overrides map[string]storage.CountryOverride) *ConfigBuilder {
+	b.countryOverrides = overrides
+	return b
+}
+
+// WithECHSupport sets whether the target kernel supports TLS ECH.
+// When unsupported, tls.ech is stripped from outbounds instead of failing validation.
+func (b *ConfigBuilder) WithECHSupport(supported bool) *ConfigBuilder {
+	b.echUnsupported = !supported
+	return b
+}
+
+// WithMultiplexSupport sets whether the target kernel supports outbound multiplex.
+// When unsupported, multiplex blocks are stripped and Settings.MultiplexEnabled is ignored.
+func (b *ConfigBuilder) WithMultiplexSupport(supported bool) *ConfigBuilder {
+	b.muxUnsupported = !supported
+	return b
+}
+
+// WithWireGuardEndpointSupport sets whether the target kernel takes WireGuard in the
+// endpoints section. When unsupported, WireGuard nodes stay in the legacy outbound form.
+func (b *ConfigBuilder) WithWireGuardEndpointSupport(supported bool) *ConfigBuilder {
+	b.wgLegacyOutbound = !supported
+	return b
+}
+
+// countryGroupTag returns the outbound tag of a country group, format: "flag emoji + name"
+func (b *ConfigBuilder) countryGroupTag(code string) string {
+	var override *storage.CountryOverride
+	if o, ok := b.countryOverrides[code]; ok {
+		override = &o
+	}
+	emoji, name := storage.ResolveCountryDisplay(code, override)
+	return fmt.Sprintf("%s %s", emoji, name)
+}
+
 // Build builds the sing-box configuration
 func (b *ConfigBuilder) Build() (*SingBoxConfig, error) {
-	outbounds, _ := b.buildOutboundsWithMap()
+	outbounds, endpoints, indexToTag, endpointIndexToTag := b.buildOutboundsAndEndpoints()
 	config := &SingBoxConfig{
 		Log:       b.buildLog(),
 		DNS:       b.buildDNS(),
 		NTP:       b.buildNTP(),
+		Endpoints: endpoints,
 		Inbounds:  b.buildInbounds(),
 		Outbounds: outbounds,
-		Route:     b.buildRoute(),
+		Route:     b.buildRoute(noUsableNodes(indexToTag, endpointIndexToTag)),
 	}
 
 	// Add Clash API support
@@ -213,16 +264,18 @@ func (b *ConfigBuilder) BuildJSON() (string, error) {
 	return string(data), nil
 }
 
-// BuildJSONWithNodeMap builds the JSON string and returns a map from outbound index to node tag
-func (b *ConfigBuilder) BuildJSONWithNodeMap() (string, map[int]string, error) {
-	outbounds, indexToTag := b.buildOutboundsWithMap()
+// BuildJSONWithNodeMap builds the JSON string and returns maps from outbound index and
+// endpoint index to node tag
+func (b *ConfigBuilder) BuildJSONWithNodeMap() (string, map[int]string, map[int]string, error) {
+	outbounds, endpoints, indexToTag, endpointIndexToTag := b.buildOutboundsAndEndpoints()
 	config := &SingBoxConfig{
 		Log:       b.buildLog(),
 		DNS:       b.buildDNS(),
 		NTP:       b.buildNTP(),
+		Endpoints: endpoints,
 		Inbounds:  b.buildInbounds(),
 		Outbounds: outbounds,
-		Route:     b.buildRoute(),
+		Route:     b.buildRoute(noUsableNodes(indexToTag, endpointIndexToTag)),
 	}
 
 	if b.settings.ClashAPIPort > 0 {
@@ -231,10 +284,10 @@ func (b *ConfigBuilder) BuildJSONWithNodeMap() (string, map[int]string, error) {
 
 	data, err := json.MarshalIndent(config, "", "  ")
 	if err != nil {
-		return "", nil, fmt.Errorf("failed to serialize config: %w", err)
+		return "", nil, nil, fmt.Errorf("failed to serialize config: %w", err)
 	}
 
-	return string(data), indexToTag, nil
+	return string(data), indexToTag, endpointIndexToTag, nil
 }
 
 // buildLog builds log configuration
@@ -284,6 +337,16 @@ func ParseSystemHosts() map[string][]string {
 	return hosts
 }
 
+// sortedHostDomains returns the domains of a hosts map in order, keeping the generated config stable
+func sortedHostDomains(hosts map[string][]string) []string {
+	domains := make([]string, 0, len(hosts))
+	for domain := range hosts {
+		domains = append(domains, domain)
+	}
+	sort.Strings(domains)
+	return domains
+}
+
 func splitDNSServerList(raw string, defaults []string) []string {
 	raw = strings.TrimSpace(raw)
 	if raw == "" {
@@ -360,6 +423,18 @@ func parseDNSServerSpec(raw string) (dnsServerSpec, bool) {
 	}
 }
 
+// InvalidDNSServers returns the entries of a DNS server list that the builder
+// cannot parse and would silently skip.
+func InvalidDNSServers(raw string) []string {
+	var invalid []string
+	for _, entry := range splitDNSServerList(raw, nil) {
+		if _, ok := parseDNSServerSpec(entry); !ok {
+			invalid = append(invalid, entry)
+		}
+	}
+	return invalid
+}
+
 func buildDNSServerChain(prefix, raw string, defaults []string, detour string) []DNSServer {
 	entries := splitDNSServerList(raw, defaults)
 	servers := make([]DNSServer, 0, len(entries))
@@ -418,12 +493,15 @@ func (b *ConfigBuilder) buildDNS() *DNSConfig {
 
 	servers := append([]DNSServer{}, proxyServers...)
 	servers = append(servers, directServers...)
-	servers = append(servers, DNSServer{
+	fakeIPServer := DNSServer{
 		Tag:        "dns_fakeip",
 		Type:       "fakeip",
 		Inet4Range: "198.18.0.0/15",
-		Inet6Range: "fc00::/18",
-	})
+	}
+	if b.settings.IPv6Enabled {
+		fakeIPServer.Inet6Range = "fc00::/18"
+	}
+	servers = append(servers, fakeIPServer)
 	// Bootstrap resolver: plain IP-based UDP server used by DefaultDomainResolver
 	// to resolve domain-based DNS server addresses (avoids circular dependency)
 	servers = append(servers, DNSServer{
@@ -449,7 +527,8 @@ func (b *ConfigBuilder) buildDNS() *DNSConfig {
 	var domains []string
 
 	// First add system hosts
-	for domain, ips := range systemHosts {
+	for _, domain := range sortedHostDomains(systemHosts) {
+		ips := systemHosts[domain]
 		if len(ips) == 1 {
 			predefined[domain] = ips[0]
 		} else {
@@ -492,8 +571,14 @@ func (b *ConfigBuilder) buildDNS() *DNSConfig {
 		rules = append([]DNSRule{hostsRule}, rules...)
 	}
 
+	// Without IPv6, never hand out AAAA answers that cannot be routed
+	strategy := "prefer_ipv4"
+	if !b.settings.IPv6Enabled {
+		strategy = "ipv4_only"
+	}
+
 	return &DNSConfig{
-		Strategy:         "prefer_ipv4",
+		Strategy:         strategy,
 		Servers:          servers,
 		Rules:            rules,
 		Final:            proxyServers[0].Tag,
@@ -509,18 +594,31 @@ func (b *ConfigBuilder) buildNTP() *NTPConfig {
 	}
 }
 
+// inboundListenAddress returns the configured listen address, or one derived
+// from the LAN access setting when none is set
+func (b *ConfigBuilder) inboundListenAddress() string {
+	return InboundListenAddress(b.settings)
+}
+
+// InboundListenAddress is the address inbounds bind to for the given settings
+func InboundListenAddress(settings *storage.Settings) string {
+	if addr := strings.TrimSpace(settings.ListenAddress); addr != "" {
+		return addr
+	}
+	if settings.AllowLAN {
+		return "0.0.0.0"
+	}
+	return "127.0.0.1"
+}
+
 // buildInbounds builds inbound configuration
 func (b *ConfigBuilder) buildInbounds() []Inbound {
-	// Determine listen address based on LAN access setting
-	listenAddr := "127.0.0.1"
-	if b.settings.AllowLAN {
-		listenAddr = "0.0.0.0"
-	}
+	listenAddr := b.inboundListenAddress()
 
 	var inbounds []Inbound
 
 	// Mixed inbound (HTTP+SOCKS5 on one port)
-	if b.settings.MixedPort > 0 {
+	if b.settings.MixedEnabled && b.settings.MixedPort > 0 {
 		inbounds = append(inbounds, Inbound{
 			Type:                     "mixed",
 			Tag:                      "mixed-in",
@@ -532,7 +630,7 @@ func (b *ConfigBuilder) buildInbounds() []Inbound {
 	}
 
 	// SOCKS5 inbound
-	if b.settings.SocksPort > 0 {
+	if b.settings.SocksEnabled && b.settings.SocksPort > 0 {
 		socks := Inbound{
 			Type:                     "socks",
 			Tag:                      "socks-in",
@@ -550,7 +648,7 @@ func (b *ConfigBuilder) buildInbounds() []Inbound {
 	}
 
 	// HTTP inbound
-	if b.settings.HttpPort > 0 {
+	if b.settings.HttpEnabled && b.settings.HttpPort > 0 {
 		http := Inbound{
 			Type:                     "http",
 			Tag:                      "http-in",
@@ -584,13 +682,24 @@ func (b *ConfigBuilder) buildInbounds() []Inbound {
 
 	// TUN inbound
 	if b.settings.TunEnabled {
+		tunAddress := []string{"172.19.0.1/30"}
+		if b.settings.IPv6Enabled {
+			tunAddress = append(tunAddress, "fdfe:dcba:9876::1/126")
+		}
+		tunStack := b.settings.TunStack
+		if tunStack == "" {
+			tunStack = storage.DefaultTunStack
+		}
 		inbounds = append(inbounds, Inbound{
 			Type:                     "tun",
 			Tag:                      "tun-in",
-			Address:                  []string{"172.19.0.1/30", "fdfe:dcba:9876::1/126"},
+			Address:                  tunAddress,
 			AutoRoute:                true,
 			StrictRoute:              true,
-			Stack:                    "mixed",
+			Stack:                    tunStack,
+			MTU:                      b.settings.TunMTU,
+			RouteAddress:             storage.NormalizeTunRoutes(b.settings.TunIncludeRoutes),
+			RouteExcludeAddress:      storage.NormalizeTunRoutes(b.settings.TunExcludeRoutes),
 			Sniff:                    true,
 			SniffOverrideDestination: true,
 		})
@@ -601,7 +710,17 @@ func (b *ConfigBuilder) buildInbounds() []Inbound {
 
 // buildOutboundsWithMap builds outbound configuration and returns a map from outbound index to node tag
 func (b *ConfigBuilder) buildOutboundsWithMap() ([]Outbound, map[int]string) {
+	outbounds, _, indexToTag, _ := b.buildOutboundsAndEndpoints()
+	return outbounds, indexToTag
+}
+
+// buildOutboundsAndEndpoints builds the outbounds and the WireGuard endpoints, and returns
+// maps from outbound index and endpoint index to node tag. Endpoints are selectable by tag
+// like outbounds.
+func (b *ConfigBuilder) buildOutboundsAndEndpoints() ([]Outbound, []Endpoint, map[int]string, map[int]string) {
 	indexToTag := make(map[int]string)
+	endpointIndexToTag := make(map[int]string)
+	var endpoints []Endpoint
 	outbounds := []Outbound{
 		{"type": "direct", "tag": "DIRECT"},
 		// block outbound removed in sing-box 1.11+, using route action reject instead
@@ -609,8 +728,10 @@ func (b *ConfigBuilder) buildOutboundsWithMap() ([]Outbound, map[int]string) {
 
 	// Collect all node tags and group by country
 	var allNodeTags []string
+	var autoNodeTags []string // Nodes taking part in Auto, without those excluded from auto-selection
 	nodeTagSet := make(map[string]bool)
 	countryNodes := make(map[string][]string) // Country code -> node tag list
+	nodeCountry := make(map[string]string)    // Node tag -> country code, for stable ordering
 
 	// Build blocked countries set for fast lookup
 	blockedCountrySet := make(map[string]bool, len(b.settings.BlockedCountries))
@@ -632,11 +753,23 @@ func (b *ConfigBuilder) buildOutboundsWithMap() ([]Outbound, map[int]string) {
 		if nodeTagSet[routingTag] {
 			continue
 		}
-		indexToTag[len(outbounds)] = routingTag
 		outbound := b.nodeToOutbound(node)
-		outbounds = append(outbounds, outbound)
+		if node.Type == "wireguard" && !b.wgLegacyOutbound {
+			endpointIndexToTag[len(endpoints)] = routingTag
+			endpoints = append(endpoints, WireGuardEndpoint(outbound))
+		} else {
+			indexToTag[len(outbounds)] = routingTag
+			outbounds = append(outbounds, outbound)
+		}
 		allNodeTags = append(allNodeTags, routingTag)
 		nodeTagSet[routingTag] = true
+		nodeCountry[routingTag] = node.Country
+
+		// Excluded nodes stay selectable in Proxy but skip the urltest groups
+		if node.ExcludeFromAuto {
+			continue
+		}
+		autoNodeTags = append(autoNodeTags, routingTag)
 
 		// Group by country
 		if node.Country != "" {
@@ -647,6 +780,25 @@ func (b *ConfigBuilder) buildOutboundsWithMap() ([]Outbound, map[int]string) {
 		}
 	}
 
+	// Without usable nodes the service must still start: Proxy only offers DIRECT below
+	noNodes := len(allNodeTags) == 0
+	if noNodes {
+		if storage.NormalizeNoNodesMode(b.settings.NoNodesMode) == storage.NoNodesModeReject {
+			log.Printf("[builder] no usable nodes, rejecting proxied traffic until nodes are available")
+		} else {
+			log.Printf("[builder] no usable nodes, Proxy falls back to DIRECT")
+		}
+	}
+
+	// Keep selector lists stable across rebuilds regardless of store order
+	if b.settings.SortProxyNodes {
+		sortNodeTags(allNodeTags, nodeCountry)
+		sortNodeTags(autoNodeTags, nodeCountry)
+		for _, tags := range countryNodes {
+			sortNodeTags(tags, nodeCountry)
+		}
+	}
+
 	// Collect filter groups
 	var filterGroupTags []string
 	filterNodeMap := make(map[string][]string)
@@ -657,18 +809,7 @@ func (b *ConfigBuilder) buildOutboundsWithMap() ([]Outbound, map[int]string) {
 		}
 
 		// Filter nodes based on filter criteria
-		var filteredTags []string
-		for _, node := range b.nodes {
-			if shouldExcludeNode(node, b.excludeTags) {
-				continue
-			}
-			if blockedCountrySet[node.Country] {
-				continue
-			}
-			if b.matchFilter(node, filter) {
-				filteredTags = append(filteredTags, node.RoutingTag())
-			}
-		}
+		filteredTags := b.filterMembers(filter, blockedCountrySet)
 
 		if len(filteredTags) == 0 {
 			continue
@@ -691,7 +832,7 @@ func (b *ConfigBuilder) buildOutboundsWithMap() ([]Outbound, map[int]string) {
 				group["interval"] = filter.URLTestConfig.Interval
 				group["tolerance"] = filter.URLTestConfig.Tolerance
 			} else {
-				group["url"] = "http://www.gstatic.com/generate_204"
+				group["url"] = b.urlTestURL()
 				group["interval"] = "3m"
 				group["tolerance"] = 150
 			}
@@ -717,9 +858,7 @@ func (b *ConfigBuilder) buildOutboundsWithMap() ([]Outbound, map[int]string) {
 		}
 
 		// Create country group tag, format: "flag emoji + name" or "HK"
-		emoji := storage.GetCountryEmoji(code)
-		name := storage.GetCountryName(code)
-		groupTag := fmt.Sprintf("%s %s", emoji, name)
+		groupTag := b.countryGroupTag(code)
 		countryGroupTags = append(countryGroupTags, groupTag)
 
 		// Create auto-select group
@@ -727,20 +866,20 @@ func (b *ConfigBuilder) buildOutboundsWithMap() ([]Outbound, map[int]string) {
 			"tag":          groupTag,
 			"type":         "urltest",
 			"outbounds":    nodes,
-			"url":          "http://www.gstatic.com/generate_204",
+			"url":          b.urlTestURL(),
 			"interval":     "3m",
 			"tolerance":    150,
 			"idle_timeout": "30m",
 		})
 	}
 
-	// Create auto-select group (all nodes)
-	if len(allNodeTags) > 0 {
+	// Create auto-select group (all nodes not excluded from auto-selection)
+	if len(autoNodeTags) > 0 {
 		outbounds = append(outbounds, Outbound{
 			"tag":          "Auto",
 			"type":         "urltest",
-			"outbounds":    allNodeTags,
-			"url":          "http://www.gstatic.com/generate_204",
+			"outbounds":    autoNodeTags,
+			"url":          b.urlTestURL(),
 			"interval":     "3m",
 			"tolerance":    150,
 			"idle_timeout": "30m",
@@ -750,7 +889,7 @@ func (b *ConfigBuilder) buildOutboundsWithMap() ([]Outbound, map[int]string) {
 	// Create main selector:
 	// include individual nodes so dashboard can switch to a specific node directly.
 	var proxyOutbounds []string
-	if len(allNodeTags) > 0 {
+	if len(autoNodeTags) > 0 {
 		proxyOutbounds = append(proxyOutbounds, "Auto")
 	}
 	proxyOutbounds = append(proxyOutbounds, allNodeTags...)
@@ -766,8 +905,10 @@ func (b *ConfigBuilder) buildOutboundsWithMap() ([]Outbound, map[int]string) {
 		"type":      "selector",
 		"outbounds": proxyOutbounds,
 	}
-	if len(allNodeTags) > 0 {
+	if len(autoNodeTags) > 0 {
 		proxySelector["default"] = "Auto"
+	} else if noNodes {
+		proxySelector["default"] = "DIRECT"
 	}
 	outbounds = append(outbounds, proxySelector)
 
@@ -779,15 +920,335 @@ func (b *ConfigBuilder) buildOutboundsWithMap() ([]Outbound, map[int]string) {
 		"tag":       "Final",
 		"type":      "selector",
 		"outbounds": fallbackOutbounds,
-		"default":   b.settings.FinalOutbound,
+		"default":   resolveFinalOutbound(b.settings.FinalOutbound, fallbackOutbounds),
 	})
 
-	return outbounds, indexToTag
+	return outbounds, endpoints, indexToTag, endpointIndexToTag
+}
+
+// defaultURLTestURL is the urltest probe target when none is configured
+const defaultURLTestURL = "http://www.gstatic.com/generate_204"
+
+// urlTestURL returns the configured urltest target or generate_204
+func (b *ConfigBuilder) urlTestURL() string {
+	if u := strings.TrimSpace(b.settings.URLTestURL); u != "" {
+		return u
+	}
+	return defaultURLTestURL
+}
+
+// sortNodeTags orders node tags by country code, then by tag
+func sortNodeTags(tags []string, nodeCountry map[string]string) {
+	sort.SliceStable(tags, func(i, j int) bool {
+		ci, cj := nodeCountry[tags[i]], nodeCountry[tags[j]]
+		if ci != cj {
+			return ci < cj
+		}
+		return tags[i] < tags[j]
+	})
+}
+
+// resolveFinalOutbound returns the Final selector default, falling back to Proxy
+// when the configured outbound is not one of the selector's choices.
+func resolveFinalOutbound(final string, choices []string) string {
+	final = strings.TrimSpace(final)
+	if final == "" {
+		return "Proxy"
+	}
+	for _, tag := range choices {
+		if tag == final {
+			return final
+		}
+	}
+	log.Printf("[builder] final outbound %q does not exist, falling back to Proxy", final)
+	return "Proxy"
+}
+
+// utlsOutboundTypes are the TCP-based outbound types that support a uTLS client hello
+var utlsOutboundTypes = map[string]bool{
+	"vmess":  true,
+	"vless":  true,
+	"trojan": true,
+	"http":   true,
+}
+
+// applyDefaultUTLS adds the default uTLS fingerprint to a TLS outbound that has none.
+// The tls map is copied since it is shared with the node's Extra.
+func applyDefaultUTLS(outbound Outbound, fingerprint string) {
+	if fingerprint == "" {
+		return
+	}
+	outboundType, _ := outbound["type"].(string)
+	if !utlsOutboundTypes[outboundType] {
+		return
+	}
+	tls, ok := outbound["tls"].(map[string]interface{})
+	if !ok {
+		return
+	}
+	if enabled, _ := tls["enabled"].(bool); !enabled {
+		return
+	}
+	if _, hasUTLS := tls["utls"]; hasUTLS {
+		return
+	}
+	withUTLS := make(map[string]interface{}, len(tls)+1)
+	for k, v := range tls {
+		withUTLS[k] = v
+	}
+	withUTLS["utls"] = map[string]interface{}{
+		"enabled":     true,
+		"fingerprint": fingerprint,
+	}
+	outbound["tls"] = withUTLS
 }
 
 // nodeToOutbound converts a node to outbound configuration
 func (b *ConfigBuilder) nodeToOutbound(node storage.Node) Outbound {
-	return NodeToOutbound(node)
+	outbound := NodeToOutbound(node)
+	applyDefaultUTLS(outbound, b.settings.DefaultUTLSFingerprint)
+	if b.echUnsupported {
+		if tls, ok := outbound["tls"].(map[string]interface{}); ok {
+			if _, hasECH := tls["ech"]; hasECH {
+				log.Printf("[builder] kernel does not support TLS ECH, dropping ech for %s", node.RoutingTag())
+				stripped := make(map[string]interface{}, len(tls))
+				for k, v := range tls {
+					if k != "ech" {
+						stripped[k] = v
+					}
+				}
+				outbound["tls"] = stripped
+			}
+		}
+	}
+	applyUDPOverTCP(outbound, b.settings.UDPOverTCP)
+	if b.muxUnsupported {
+		if _, hasMux := outbound["multiplex"]; hasMux {
+			log.Printf("[builder] kernel does not support multiplex, dropping it for %s", node.RoutingTag())
+			delete(outbound, "multiplex")
+		}
+	} else if b.settings.MultiplexEnabled {
+		applyDefaultMultiplex(outbound)
+	}
+	applyTCPFastOpen(outbound, b.settings.TCPFastOpen)
+	applyUDPFragment(outbound, b.settings.UDPFragment)
+	return outbound
+}
+
+// multiplexOutboundTypes are the outbound types that accept a multiplex block
+var multiplexOutboundTypes = map[string]bool{
+	"shadowsocks": true,
+	"trojan":      true,
+	"vmess":       true,
+	"vless":       true,
+}
+
+// supportsMultiplex reports whether an outbound can carry a multiplex block.
+// XTLS flows such as vision cannot be multiplexed.
+func supportsMultiplex(outbound Outbound) bool {
+	outboundType, _ := outbound["type"].(string)
+	if !multiplexOutboundTypes[outboundType] {
+		return false
+	}
+	flow, _ := outbound["flow"].(string)
+	return flow == ""
+}
+
+// applyDefaultMultiplex enables multiplex with the sing-box defaults on an outbound
+// that supports it and does not configure its own.
+func applyDefaultMultiplex(outbound Outbound) {
+	if _, hasMux := outbound["multiplex"]; hasMux || !supportsMultiplex(outbound) {
+		return
+	}
+	// sing-box rejects multiplex together with UDP over TCP
+	if _, hasUoT := outbound["udp_over_tcp"]; hasUoT {
+		return
+	}
+	outbound["multiplex"] = map[string]interface{}{
+		"enabled": true,
+	}
+}
+
+// tcpFastOpenOutboundTypes are the TCP-based outbound types the global TCP Fast Open
+// default applies to
+var tcpFastOpenOutboundTypes = map[string]bool{
+	"shadowsocks": true,
+	"trojan":      true,
+	"vmess":       true,
+	"vless":       true,
+	"socks":       true,
+	"http":        true,
+	"anytls":      true,
+}
+
+// applyTCPFastOpen sets the tcp_fast_open dialer option. A node's own choice wins over
+// the global default; false is dropped since it is what sing-box does anyway.
+func applyTCPFastOpen(outbound Outbound, global bool) {
+	if enabled, ok := outbound["tcp_fast_open"].(bool); ok {
+		if !enabled {
+			delete(outbound, "tcp_fast_open")
+		}
+		return
+	}
+	outboundType, _ := outbound["type"].(string)
+	if global && tcpFastOpenOutboundTypes[outboundType] {
+		outbound["tcp_fast_open"] = true
+	}
+}
+
+// udpOverTCPOutboundTypes are the outbound types that accept udp_over_tcp
+var udpOverTCPOutboundTypes = map[string]bool{
+	"shadowsocks": true,
+	"socks":       true,
+}
+
+// normalizeUDPOverTCP converts a stored udp_over_tcp value to a form sing-box accepts:
+// a boolean, or {"enabled": true, "version": N} when a version is given. It reports
+// false for values that cannot be understood.
+func normalizeUDPOverTCP(value interface{}) (interface{}, bool) {
+	switch v := value.(type) {
+	case bool:
+		return v, true
+	case string:
+		switch strings.ToLower(strings.TrimSpace(v)) {
+		case "true", "1":
+			return true, true
+		case "false", "0":
+			return false, true
+		}
+		return nil, false
+	case map[string]interface{}:
+		enabled, _ := v["enabled"].(bool)
+		if !enabled {
+			return false, true
+		}
+		var version int
+		switch n := v["version"].(type) {
+		case float64:
+			version = int(n)
+		case int:
+			version = n
+		case int64:
+			version = int(n)
+		}
+		if version <= 0 {
+			return true, true
+		}
+		return map[string]interface{}{"enabled": true, "version": version}, true
+	}
+	return nil, false
+}
+
+// applyUDPOverTCP settles udp_over_tcp on an outbound. A node's own choice wins over the
+// global default, which only covers shadowsocks nodes without multiplex; false is
+// dropped since it is what sing-box does anyway.
+func applyUDPOverTCP(outbound Outbound, global bool) {
+	switch value := outbound["udp_over_tcp"].(type) {
+	case bool:
+		if !value {
+			delete(outbound, "udp_over_tcp")
+		}
+		return
+	case map[string]interface{}:
+		return
+	}
+	outboundType, _ := outbound["type"].(string)
+	if _, hasMux := outbound["multiplex"]; global && outboundType == "shadowsocks" && !hasMux {
+		outbound["udp_over_tcp"] = true
+	}
+}
+
+// normalizeTransport cleans up transport fields imported from share links so they do
+// not produce a silently broken outbound:
+//   - mode is dropped, sing-box has no such field
+//   - grpc service_name is trimmed and loses its leading slash, and is omitted when empty
+//   - the v2ray "h2" type becomes sing-box "http"; its path gets a leading slash and
+//     blank or malformed hosts are dropped, omitting path/host when nothing is left
+func normalizeTransport(transport map[string]interface{}) {
+	delete(transport, "mode")
+
+	switch transport["type"] {
+	case "grpc":
+		name, _ := transport["service_name"].(string)
+		name = strings.TrimLeft(strings.TrimSpace(name), "/")
+		if name == "" {
+			delete(transport, "service_name")
+		} else {
+			transport["service_name"] = name
+		}
+	case "http", "h2":
+		transport["type"] = "http"
+		if path, ok := transport["path"].(string); ok {
+			if path = strings.TrimSpace(path); path == "" {
+				delete(transport, "path")
+			} else {
+				if !strings.HasPrefix(path, "/") {
+					path = "/" + path
+				}
+				transport["path"] = path
+			}
+		}
+		if _, ok := transport["host"]; ok {
+			if hosts := transportHosts(transport["host"]); len(hosts) > 0 {
+				transport["host"] = hosts
+			} else {
+				delete(transport, "host")
+			}
+		}
+	}
+}
+
+// transportHosts returns the usable host names of an http transport host field, which
+// may be a string or a list depending on where the node came from
+func transportHosts(raw interface{}) []string {
+	var values []string
+	switch v := raw.(type) {
+	case string:
+		values = strings.Split(v, ",")
+	case []string:
+		values = v
+	case []interface{}:
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+	}
+	var hosts []string
+	for _, host := range values {
+		host = strings.TrimSpace(host)
+		if host == "" || strings.ContainsAny(host, "/ \t") {
+			continue
+		}
+		hosts = append(hosts, host)
+	}
+	return hosts
+}
+
+// udpFragmentOutboundTypes are the outbound types that dial the server over UDP, which
+// the global UDP fragmentation default applies to
+var udpFragmentOutboundTypes = map[string]bool{
+	"shadowsocks": true,
+	"socks":       true,
+	"hysteria":    true,
+	"hysteria2":   true,
+	"tuic":        true,
+	"wireguard":   true,
+}
+
+// applyUDPFragment sets the udp_fragment dialer option. A node's own choice wins over
+// the global default; false is dropped since it is what sing-box does anyway.
+func applyUDPFragment(outbound Outbound, global bool) {
+	if enabled, ok := outbound["udp_fragment"].(bool); ok {
+		if !enabled {
+			delete(outbound, "udp_fragment")
+		}
+		return
+	}
+	outboundType, _ := outbound["type"].(string)
+	if global && udpFragmentOutboundTypes[outboundType] {
+		outbound["udp_fragment"] = true
+	}
 }
 
 // NodeToOutbound converts a storage.Node to an Outbound config entry.
@@ -795,7 +1256,7 @@ func NodeToOutbound(node storage.Node) Outbound {
 	outbound := Outbound{
 		"tag":         node.RoutingTag(),
 		"type":        node.Type,
-		"server":      node.Server,
+		"server":      storage.NormalizeServer(node.Server),
 		"server_port": node.ServerPort,
 	}
 
@@ -804,9 +1265,40 @@ func NodeToOutbound(node storage.Node) Outbound {
 		outbound[k] = v
 	}
 
-	// Remove fields from transport that sing-box doesn't support
+	// Work on a copy so cleaning up the transport never rewrites the stored node
 	if transport, ok := outbound["transport"].(map[string]interface{}); ok {
-		delete(transport, "mode")
+		cleaned := make(map[string]interface{}, len(transport))
+		for k, v := range transport {
+			cleaned[k] = v
+		}
+		normalizeTransport(cleaned)
+		outbound["transport"] = cleaned
+	}
+
+	// packet_encoding is a top-level vmess/vless option; older imports stored
+	// the v2ray name "packet" for packetaddr
+	if pe, ok := outbound["packet_encoding"].(string); ok {
+		switch {
+		case node.Type != "vmess" && node.Type != "vless":
+			delete(outbound, "packet_encoding")
+		case pe == "packet":
+			outbound["packet_encoding"] = "packetaddr"
+		}
+	}
+
+	// udp_over_tcp is stored as a bool or as {enabled, version}, and only
+	// shadowsocks and socks accept it
+	if value, hasUoT := outbound["udp_over_tcp"]; hasUoT {
+		if normalized, ok := normalizeUDPOverTCP(value); ok && udpOverTCPOutboundTypes[node.Type] {
+			outbound["udp_over_tcp"] = normalized
+		} else {
+			delete(outbound, "udp_over_tcp")
+		}
+	}
+
+	// multiplex from share links is only valid on the stream protocols
+	if _, hasMux := outbound["multiplex"]; hasMux && !supportsMultiplex(outbound) {
+		delete(outbound, "multiplex")
 	}
 
 	// Set connect timeout to avoid hanging on half-dead proxies
@@ -819,8 +1311,34 @@ func NodeToOutbound(node storage.Node) Outbound {
 	return outbound
 }
 
-// matchFilter checks if a node matches a filter
-func (b *ConfigBuilder) matchFilter(node storage.Node, filter storage.Filter) bool {
+// FilterMembers returns the routing tags of the nodes a filter group would contain,
+// skipping excluded nodes and blocked countries. The filter's Enabled flag is ignored.
+func (b *ConfigBuilder) FilterMembers(filter storage.Filter) []string {
+	blockedCountrySet := make(map[string]bool, len(b.settings.BlockedCountries))
+	for _, code := range b.settings.BlockedCountries {
+		blockedCountrySet[code] = true
+	}
+	return b.filterMembers(filter, blockedCountrySet)
+}
+
+func (b *ConfigBuilder) filterMembers(filter storage.Filter, blockedCountrySet map[string]bool) []string {
+	var tags []string
+	for _, node := range b.nodes {
+		if shouldExcludeNode(node, b.excludeTags) {
+			continue
+		}
+		if blockedCountrySet[node.Country] {
+			continue
+		}
+		if MatchFilter(node, filter) {
+			tags = append(tags, node.RoutingTag())
+		}
+	}
+	return tags
+}
+
+// MatchFilter checks if a node matches a filter
+func MatchFilter(node storage.Node, filter storage.Filter) bool {
 	name := strings.ToLower(strings.TrimSpace(node.DisplayOrTag() + " " + node.SourceOrTag()))
 
 	// 1. Check country include conditions
@@ -868,6 +1386,12 @@ func (b *ConfigBuilder) matchFilter(node storage.Node, filter storage.Filter) bo
 	return true
 }
 
+// noUsableNodes reports whether the outbound builder left no node in the config,
+// given its outbound and endpoint index maps
+func noUsableNodes(indexToTag, endpointIndexToTag map[int]string) bool {
+	return len(indexToTag) == 0 && len(endpointIndexToTag) == 0
+}
+
 func shouldExcludeNode(node storage.Node, excludeTags map[string]bool) bool {
 	if excludeTags == nil {
 		return false
@@ -889,8 +1413,26 @@ func shouldExcludeNode(node storage.Node, excludeTags map[string]bool) bool {
 	return legacyTag != "" && excludeTags[legacyTag]
 }
 
-// buildRoute builds route configuration
-func (b *ConfigBuilder) buildRoute() *RouteConfig {
+// blockQUICRouteRule rejects QUIC so clients fall back to TCP/TLS. With the quic sniffer
+// the rule matches the sniffed protocol; without it, UDP to port 443 is the closest match.
+func blockQUICRouteRule(sniffers []string) RouteRule {
+	for _, sniffer := range sniffers {
+		if sniffer == "quic" {
+			return RouteRule{"protocol": "quic", "action": "reject"}
+		}
+	}
+	return RouteRule{"network": "udp", "port": 443, "action": "reject"}
+}
+
+// buildRoute builds route configuration. noNodes tells it the outbounds carry no node.
+func (b *ConfigBuilder) buildRoute(noNodes bool) *RouteConfig {
+	route, _ := b.buildRouteWithSources(noNodes)
+	return route
+}
+
+// buildRouteWithSources builds the route configuration along with the source of
+// every route rule, index-aligned with route.Rules.
+func (b *ConfigBuilder) buildRouteWithSources(noNodes bool) (*RouteConfig, []RouteRuleSource) {
 	route := &RouteConfig{
 		AutoDetectInterface: true,
 		Final:               "Final",
@@ -901,29 +1443,53 @@ func (b *ConfigBuilder) buildRoute() *RouteConfig {
 		},
 	}
 
+	// Explicit default interface replaces auto-detection (multi-homed / VPN-over-VPN setups)
+	if iface := strings.TrimSpace(b.settings.DefaultInterface); !b.settings.AutoDetectInterface && iface != "" {
+		route.AutoDetectInterface = false
+		route.DefaultInterface = iface
+	}
+
 	// No rule sets — all traffic goes through proxy
 
 	// Build route rules (minimal: sniff, dns hijack, hosts overrides)
 	var rules []RouteRule
+	var sources []RouteRuleSource
+	add := func(source RouteRuleSource, rule RouteRule) {
+		rules = append(rules, rule)
+		sources = append(sources, source)
+	}
 
 	// 1. Sniff action (detect traffic type, used with FakeIP)
-	rules = append(rules, RouteRule{
+	sniffers := b.settings.Sniffers
+	if len(sniffers) == 0 {
+		sniffers = storage.DefaultSniffers()
+	}
+	sniffTimeout := b.settings.SniffTimeout
+	if sniffTimeout == "" {
+		sniffTimeout = storage.DefaultSniffTimeout
+	}
+	add(RouteRuleSource{Kind: RouteRuleSourceSniff}, RouteRule{
 		"action":  "sniff",
-		"sniffer": []string{"dns", "http", "tls", "quic"},
-		"timeout": "500ms",
+		"sniffer": sniffers,
+		"timeout": sniffTimeout,
 	})
 
+	// QUIC block goes right after sniff, which it depends on, so no rule can route QUIC first
+	if b.settings.BlockQUIC {
+		add(RouteRuleSource{Kind: RouteRuleSourceBlockQUIC}, blockQUICRouteRule(sniffers))
+	}
+
 	// 2. DNS hijack
-	rules = append(rules, RouteRule{
+	add(RouteRuleSource{Kind: RouteRuleSourceHijackDNS}, RouteRule{
 		"protocol": "dns",
 		"action":   "hijack-dns",
 	})
 
 	// 3. Hosts domain overrides (system + user-defined)
 	systemHosts := ParseSystemHosts()
-	for domain, ips := range systemHosts {
-		if len(ips) > 0 {
-			rules = append(rules, RouteRule{
+	for _, domain := range sortedHostDomains(systemHosts) {
+		if ips := systemHosts[domain]; len(ips) > 0 {
+			add(RouteRuleSource{Kind: RouteRuleSourceSystemHosts, Name: domain}, RouteRule{
 				"domain":           []string{domain},
 				"outbound":         "DIRECT",
 				"override_address": ips[0],
@@ -932,7 +1498,7 @@ func (b *ConfigBuilder) buildRoute() *RouteConfig {
 	}
 	for _, host := range b.settings.Hosts {
 		if host.Enabled && host.Domain != "" && len(host.IPs) > 0 {
-			rules = append(rules, RouteRule{
+			add(RouteRuleSource{Kind: RouteRuleSourceHost, ID: host.ID, Name: host.Domain}, RouteRule{
 				"domain":           []string{host.Domain},
 				"outbound":         "DIRECT",
 				"override_address": host.IPs[0],
@@ -940,9 +1506,57 @@ func (b *ConfigBuilder) buildRoute() *RouteConfig {
 		}
 	}
 
+	// 4. Applications pinned to DIRECT regardless of destination
+	for _, rule := range directProcessRouteRules(b.settings.DirectProcesses) {
+		add(RouteRuleSource{Kind: RouteRuleSourceDirectProcess}, rule)
+	}
+
+	// 5. Without usable nodes, reject mode refuses what would otherwise leave through DIRECT.
+	// It goes last so hosts overrides and direct processes keep working, and LAN / private
+	// destinations stay reachable since they never went through a proxy anyway.
+	if storage.NormalizeNoNodesMode(b.settings.NoNodesMode) == storage.NoNodesModeReject && noNodes {
+		add(RouteRuleSource{Kind: RouteRuleSourceNoNodes}, RouteRule{"ip_is_private": true, "outbound": "DIRECT"})
+		add(RouteRuleSource{Kind: RouteRuleSourceNoNodes},
+			rejectRouteRule(RouteRule{"network": []string{"tcp", "udp"}}, storage.RejectMethodDefault))
+	}
+
 	route.Rules = rules
 
-	return route
+	return route, sources
+}
+
+// directProcessRouteRules routes the given processes to DIRECT, matching bare names
+// by process_name and paths by process_path. The two fields are ANDed within one
+// sing-box rule, so each gets its own. sing-box only resolves the owning process on
+// desktop platforms, elsewhere the rules never match.
+func directProcessRouteRules(processes []string) []RouteRule {
+	names, paths := storage.SplitDirectProcesses(processes)
+	var rules []RouteRule
+	if len(names) > 0 {
+		rules = append(rules, RouteRule{"process_name": names, "outbound": "DIRECT"})
+	}
+	if len(paths) > 0 {
+		rules = append(rules, RouteRule{"process_path": paths, "outbound": "DIRECT"})
+	}
+	return rules
+}
+
+// rejectRouteRule turns a match rule into a reject action with the given method.
+// "default" answers with RST / ICMP unreachable so browsers stop waiting on
+// blocked resources, "drop" silently discards them.
+func rejectRouteRule(match RouteRule, method string) RouteRule {
+	rule := make(RouteRule, len(match)+2)
+	for k, v := range match {
+		if k == "outbound" {
+			continue
+		}
+		rule[k] = v
+	}
+	rule["action"] = "reject"
+	if m := storage.NormalizeRejectMethod(method); m != storage.RejectMethodDefault {
+		rule["method"] = m
+	}
+	return rule
 }
 
 // buildExperimental builds experimental configuration
@@ -0,0 +1,1002 @@
+package builder
+
+import (
+	"encoding/json"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/xiaobei/singbox-manager/internal/storage"
+)
+
+func findSniffRule(t *testing.T, route *RouteConfig) RouteRule {
+	t.Helper()
+	for _, rule := range route.Rules {
+		if rule["action"] == "sniff" {
+			return rule
+		}
+	}
+	t.Fatalf("sniff rule not found in route rules")
+	return nil
+}
+
+func TestBuildRoute_CustomSniffers(t *testing.T) {
+	settings := storage.DefaultSettings()
+	settings.Sniffers = []string{"dns", "http", "tls"}
+	settings.SniffTimeout = "1s"
+
+	route := NewConfigBuilder(settings, nil, nil).buildRoute(false)
+	rule := findSniffRule(t, route)
+
+	if got, want := rule["sniffer"], []string{"dns", "http", "tls"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("sniffer mismatch: got %v, want %v", got, want)
+	}
+	if got := rule["timeout"]; got != "1s" {
+		t.Fatalf("timeout mismatch: got %v, want 1s", got)
+	}
+}
+
+func TestBuildRoute_DefaultSniffers(t *testing.T) {
+	settings := storage.DefaultSettings()
+	settings.Sniffers = nil
+	settings.SniffTimeout = ""
+
+	route := NewConfigBuilder(settings, nil, nil).buildRoute(false)
+	rule := findSniffRule(t, route)
+
+	if got, want := rule["sniffer"], []string{"dns", "http", "tls", "quic"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("sniffer mismatch: got %v, want %v", got, want)
+	}
+	if got := rule["timeout"]; got != storage.DefaultSniffTimeout {
+		t.Fatalf("timeout mismatch: got %v, want %s", got, storage.DefaultSniffTimeout)
+	}
+}
+
+func TestBuildOutbounds_CountryOverrideTag(t *testing.T) {
+	nodes := []storage.Node{
+		{Tag: "hk-1", InternalTag: "hk-1", Type: "shadowsocks", Server: "1.1.1.1", ServerPort: 8388, Country: "HK",
+			Extra: map[string]interface{}{"method": "aes-128-gcm", "password": "secret"}},
+		{Tag: "jp-1", InternalTag: "jp-1", Type: "shadowsocks", Server: "2.2.2.2", ServerPort: 8388, Country: "JP",
+			Extra: map[string]interface{}{"method": "aes-128-gcm", "password": "secret"}},
+	}
+	overrides := map[string]storage.CountryOverride{
+		"HK": {Code: "HK", Name: "HK-Premium"},
+	}
+
+	b := NewConfigBuilder(storage.DefaultSettings(), nodes, nil).WithCountryOverrides(overrides)
+	outbounds, _ := b.buildOutboundsWithMap()
+
+	tags := make(map[string]bool)
+	for _, ob := range outbounds {
+		if tag, ok := ob["tag"].(string); ok {
+			tags[tag] = true
+		}
+	}
+
+	hkTag := storage.GetCountryEmoji("HK") + " HK-Premium"
+	if !tags[hkTag] {
+		t.Fatalf("expected overridden country group %q in outbounds, got %v", hkTag, tags)
+	}
+	jpTag := storage.GetCountryEmoji("JP") + " " + storage.GetCountryName("JP")
+	if !tags[jpTag] {
+		t.Fatalf("expected default country group %q in outbounds, got %v", jpTag, tags)
+	}
+}
+
+func TestBuildOutbounds_ExcludeFromAutoKeepsNodeInProxyOnly(t *testing.T) {
+	nodes := []storage.Node{
+		{Tag: "hk-1", InternalTag: "hk-1", Type: "shadowsocks", Server: "1.1.1.1", ServerPort: 8388, Country: "HK",
+			Extra: map[string]interface{}{"method": "aes-128-gcm", "password": "secret"}},
+		{Tag: "hk-flaky", InternalTag: "hk-flaky", Type: "shadowsocks", Server: "2.2.2.2", ServerPort: 8388, Country: "HK",
+			Extra: map[string]interface{}{"method": "aes-128-gcm", "password": "secret"}, ExcludeFromAuto: true},
+	}
+
+	outbounds, _ := NewConfigBuilder(storage.DefaultSettings(), nodes, nil).buildOutboundsWithMap()
+
+	if findOutbound(outbounds, "hk-flaky") == nil {
+		t.Fatalf("excluded node should still be an outbound")
+	}
+	hkTag := storage.GetCountryEmoji("HK") + " " + storage.GetCountryName("HK")
+	for _, tag := range []string{"Auto", hkTag} {
+		group := findOutbound(outbounds, tag)
+		if group == nil {
+			t.Fatalf("urltest group %q missing", tag)
+		}
+		if got := group["outbounds"].([]string); !reflect.DeepEqual(got, []string{"hk-1"}) {
+			t.Fatalf("%s members mismatch: got %v, want [hk-1]", tag, got)
+		}
+	}
+
+	proxy := findOutbound(outbounds, "Proxy")
+	members := proxy["outbounds"].([]string)
+	if !slices.Contains(members, "hk-flaky") || !slices.Contains(members, "hk-1") {
+		t.Fatalf("Proxy selector should list both nodes, got %v", members)
+	}
+	if proxy["default"] != "Auto" {
+		t.Fatalf("Proxy default mismatch: got %v, want Auto", proxy["default"])
+	}
+}
+
+func TestBuild_WireGuardNodeBecomesEndpoint(t *testing.T) {
+	nodes := []storage.Node{
+		{Tag: "wg-1", InternalTag: "wg-1", Type: "wireguard", Server: "3.3.3.3", ServerPort: 51820, Country: "DE",
+			Extra: map[string]interface{}{
+				"local_address":   []interface{}{"10.0.0.2/32"},
+				"private_key":     "cHJpdmF0ZQ==",
+				"peer_public_key": "cHVibGlj",
+				"mtu":             float64(1408),
+			}},
+	}
+
+	cfg, err := NewConfigBuilder(storage.DefaultSettings(), nodes, nil).Build()
+	if err != nil {
+		t.Fatalf("build config: %v", err)
+	}
+	if findOutbound(cfg.Outbounds, "wg-1") != nil {
+		t.Fatalf("wireguard node should not be an outbound on endpoint-capable kernels")
+	}
+	if len(cfg.Endpoints) != 1 {
+		t.Fatalf("endpoint count mismatch: got %d, want 1", len(cfg.Endpoints))
+	}
+	want := Endpoint{
+		"tag":             "wg-1",
+		"type":            "wireguard",
+		"address":         []interface{}{"10.0.0.2/32"},
+		"private_key":     "cHJpdmF0ZQ==",
+		"mtu":             float64(1408),
+		"connect_timeout": "8s",
+		"peers": []interface{}{map[string]interface{}{
+			"address":     "3.3.3.3",
+			"port":        51820,
+			"public_key":  "cHVibGlj",
+			"allowed_ips": []string{"0.0.0.0/0", "::/0"},
+		}},
+	}
+	if !reflect.DeepEqual(cfg.Endpoints[0], want) {
+		t.Fatalf("endpoint mismatch:\ngot  %v\nwant %v", cfg.Endpoints[0], want)
+	}
+
+	proxy := findOutbound(cfg.Outbounds, "Proxy")
+	if members := proxy["outbounds"].([]string); !slices.Contains(members, "wg-1") {
+		t.Fatalf("Proxy selector should list the endpoint by tag, got %v", members)
+	}
+	if auto := findOutbound(cfg.Outbounds, "Auto"); !slices.Contains(auto["outbounds"].([]string), "wg-1") {
+		t.Fatalf("Auto should include the endpoint, got %v", auto["outbounds"])
+	}
+
+	legacy, err := NewConfigBuilder(storage.DefaultSettings(), nodes, nil).WithWireGuardEndpointSupport(false).Build()
+	if err != nil {
+		t.Fatalf("build legacy config: %v", err)
+	}
+	if len(legacy.Endpoints) != 0 || findOutbound(legacy.Outbounds, "wg-1") == nil {
+		t.Fatalf("older kernels should keep the wireguard outbound, got %d endpoints", len(legacy.Endpoints))
+	}
+}
+
+func TestParseCheckErrors_EndpointIndex(t *testing.T) {
+	nodes := []storage.Node{
+		{Tag: "ss-1", Type: "shadowsocks", Server: "1.1.1.1", ServerPort: 8388},
+		{Tag: "wg-1", Type: "wireguard", Server: "3.3.3.3", ServerPort: 51820},
+	}
+	_, indexToTag, endpointIndexToTag, err := NewConfigBuilder(storage.DefaultSettings(), nodes, nil).BuildJSONWithNodeMap()
+	if err != nil {
+		t.Fatalf("build config: %v", err)
+	}
+	if endpointIndexToTag[0] != "wg-1" {
+		t.Fatalf("endpoint map mismatch: got %v, want wg-1 at 0", endpointIndexToTag)
+	}
+	for _, tag := range indexToTag {
+		if tag == "wg-1" {
+			t.Fatalf("endpoint should not be in the outbound map: %v", indexToTag)
+		}
+	}
+
+	errs := ParseCheckErrors("FATAL[0000] decode config: endpoints[0].peers[0].public_key: invalid key\n" +
+		"FATAL[0000] initialize outbound[1]: unknown method")
+	want := []OutboundError{
+		{Index: 0, Endpoint: true, Field: "peers[0].public_key", Message: "invalid key"},
+		{Index: 1, Field: "", Message: "unknown method"},
+	}
+	if !reflect.DeepEqual(errs.OutboundErrors, want) {
+		t.Fatalf("parsed errors mismatch:\ngot  %+v\nwant %+v", errs.OutboundErrors, want)
+	}
+}
+
+func TestBuild_EmptyNodeSetFallsBackToDirect(t *testing.T) {
+	cfg, err := NewConfigBuilder(storage.DefaultSettings(), nil, nil).Build()
+	if err != nil {
+		t.Fatalf("build config: %v", err)
+	}
+
+	if findOutbound(cfg.Outbounds, "Auto") != nil {
+		t.Fatalf("Auto should be left out without nodes")
+	}
+	proxy := findOutbound(cfg.Outbounds, "Proxy")
+	if proxy == nil {
+		t.Fatalf("Proxy selector missing")
+	}
+	if got := proxy["outbounds"].([]string); !reflect.DeepEqual(got, []string{"DIRECT"}) {
+		t.Fatalf("Proxy members mismatch: got %v, want [DIRECT]", got)
+	}
+	if proxy["default"] != "DIRECT" {
+		t.Fatalf("Proxy default mismatch: got %v, want DIRECT", proxy["default"])
+	}
+
+	// Every selector member must exist, or sing-box refuses the config
+	tags := make(map[string]bool, len(cfg.Outbounds))
+	for _, ob := range cfg.Outbounds {
+		tags[ob["tag"].(string)] = true
+	}
+	for _, ob := range cfg.Outbounds {
+		members, _ := ob["outbounds"].([]string)
+		for _, member := range members {
+			if !tags[member] {
+				t.Fatalf("%v references missing outbound %q", ob["tag"], member)
+			}
+		}
+	}
+	for _, rule := range cfg.Route.Rules {
+		if rule["action"] == "reject" {
+			t.Fatalf("direct mode should not add a reject rule, got %v", rule)
+		}
+	}
+
+	settings := storage.DefaultSettings()
+	settings.NoNodesMode = storage.NoNodesModeReject
+	rejecting, err := NewConfigBuilder(settings, nil, nil).Build()
+	if err != nil {
+		t.Fatalf("build reject config: %v", err)
+	}
+	rules := rejecting.Route.Rules
+	if want := (RouteRule{"ip_is_private": true, "outbound": "DIRECT"}); !reflect.DeepEqual(rules[len(rules)-2], want) {
+		t.Fatalf("private exemption mismatch: got %v, want %v", rules[len(rules)-2], want)
+	}
+	if want := (RouteRule{"network": []string{"tcp", "udp"}, "action": "reject"}); !reflect.DeepEqual(rules[len(rules)-1], want) {
+		t.Fatalf("last rule mismatch: got %v, want %v", rules[len(rules)-1], want)
+	}
+
+	// Nodes the outbound builder drops (blocked countries) count as no nodes
+	settings.BlockedCountries = []string{"US"}
+	blocked := []storage.Node{{Tag: "us-1", Type: "socks", Server: "1.2.3.4", ServerPort: 1080, Country: "US"}}
+	cfg, err = NewConfigBuilder(settings, blocked, nil).Build()
+	if err != nil {
+		t.Fatalf("build blocked config: %v", err)
+	}
+	if last := cfg.Route.Rules[len(cfg.Route.Rules)-1]; last["action"] != "reject" {
+		t.Fatalf("blocked nodes should still reject, got last rule %v", last)
+	}
+
+	settings.BlockedCountries = nil
+	cfg, err = NewConfigBuilder(settings, blocked, nil).Build()
+	if err != nil {
+		t.Fatalf("build config with nodes: %v", err)
+	}
+	for _, rule := range cfg.Route.Rules {
+		if rule["action"] == "reject" {
+			t.Fatalf("usable nodes should not add a reject rule, got %v", rule)
+		}
+	}
+}
+
+func TestBuild_IPv6Disabled(t *testing.T) {
+	settings := storage.DefaultSettings()
+	settings.TunEnabled = true
+	settings.IPv6Enabled = false
+
+	b := NewConfigBuilder(settings, nil, nil)
+
+	dns := b.buildDNS()
+	if dns.Strategy != "ipv4_only" {
+		t.Fatalf("dns strategy mismatch: got %q, want ipv4_only", dns.Strategy)
+	}
+	for _, srv := range dns.Servers {
+		if srv.Type == "fakeip" && srv.Inet6Range != "" {
+			t.Fatalf("expected no IPv6 fakeip range, got %q", srv.Inet6Range)
+		}
+	}
+
+	for _, in := range b.buildInbounds() {
+		if in.Type != "tun" {
+			continue
+		}
+		for _, addr := range in.Address {
+			if strings.Contains(addr, ":") {
+				t.Fatalf("expected no IPv6 TUN address, got %q", addr)
+			}
+		}
+	}
+}
+
+func TestBuild_IPv6EnabledByDefault(t *testing.T) {
+	settings := storage.DefaultSettings()
+	settings.TunEnabled = true
+
+	b := NewConfigBuilder(settings, nil, nil)
+
+	if dns := b.buildDNS(); dns.Strategy != "prefer_ipv4" {
+		t.Fatalf("dns strategy mismatch: got %q, want prefer_ipv4", dns.Strategy)
+	}
+	hasIPv6 := false
+	for _, in := range b.buildInbounds() {
+		if in.Type == "tun" {
+			for _, addr := range in.Address {
+				if strings.Contains(addr, ":") {
+					hasIPv6 = true
+				}
+			}
+		}
+	}
+	if !hasIPv6 {
+		t.Fatalf("expected IPv6 TUN address when IPv6 is enabled")
+	}
+}
+
+func TestRejectRouteRule_Methods(t *testing.T) {
+	match := RouteRule{
+		"rule_set": []string{"geosite-category-ads-all"},
+		"outbound": "REJECT",
+	}
+
+	tests := []struct {
+		method     string
+		wantMethod interface{}
+	}{
+		{method: "", wantMethod: nil},
+		{method: storage.RejectMethodDefault, wantMethod: nil},
+		{method: storage.RejectMethodDrop, wantMethod: "drop"},
+		{method: " DROP ", wantMethod: "drop"},
+	}
+
+	for _, tt := range tests {
+		rule := rejectRouteRule(match, tt.method)
+		if rule["action"] != "reject" {
+			t.Fatalf("action mismatch for %q: got %v, want reject", tt.method, rule["action"])
+		}
+		if _, ok := rule["outbound"]; ok {
+			t.Fatalf("expected outbound to be dropped for %q: %v", tt.method, rule)
+		}
+		if rule["method"] != tt.wantMethod {
+			t.Fatalf("method mismatch for %q: got %v, want %v", tt.method, rule["method"], tt.wantMethod)
+		}
+		if !reflect.DeepEqual(rule["rule_set"], match["rule_set"]) {
+			t.Fatalf("rule_set mismatch for %q: got %v", tt.method, rule["rule_set"])
+		}
+	}
+	if match["outbound"] != "REJECT" {
+		t.Fatalf("expected match rule to be left untouched")
+	}
+}
+
+func TestBuildRoute_ExplicitDefaultInterface(t *testing.T) {
+	settings := storage.DefaultSettings()
+	settings.AutoDetectInterface = false
+	settings.DefaultInterface = " eth1 "
+
+	route := NewConfigBuilder(settings, nil, nil).buildRoute(false)
+
+	if route.AutoDetectInterface {
+		t.Fatalf("expected auto_detect_interface to be off")
+	}
+	if route.DefaultInterface != "eth1" {
+		t.Fatalf("default interface mismatch: got %q, want eth1", route.DefaultInterface)
+	}
+}
+
+func TestBuildRoute_DirectProcesses(t *testing.T) {
+	settings := storage.DefaultSettings()
+	settings.DirectProcesses = []string{" restic ", "", "Backblaze.exe", "/usr/bin/rclone"}
+
+	route := NewConfigBuilder(settings, nil, nil).buildRoute(false)
+
+	var byName, byPath RouteRule
+	for _, rule := range route.Rules {
+		if _, ok := rule["process_name"]; ok {
+			byName = rule
+		}
+		if _, ok := rule["process_path"]; ok {
+			byPath = rule
+		}
+	}
+	if byName == nil || byPath == nil {
+		t.Fatalf("expected process_name and process_path rules, got %v", route.Rules)
+	}
+	if got, want := byName["process_name"], []string{"restic", "Backblaze.exe"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("process_name mismatch: got %v, want %v", got, want)
+	}
+	if got, want := byPath["process_path"], []string{"/usr/bin/rclone"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("process_path mismatch: got %v, want %v", got, want)
+	}
+	if byName["outbound"] != "DIRECT" || byPath["outbound"] != "DIRECT" {
+		t.Fatalf("expected process rules to route DIRECT, got %v / %v", byName["outbound"], byPath["outbound"])
+	}
+	if _, ok := byName["process_path"]; ok {
+		t.Fatalf("process_name and process_path must not share a rule: %v", byName)
+	}
+}
+
+func TestBuildRoute_BlockQUICComesFirst(t *testing.T) {
+	settings := storage.DefaultSettings()
+	settings.DirectProcesses = []string{"restic"}
+	settings.Hosts = []storage.HostEntry{{ID: "h1", Domain: "nas.lan", IPs: []string{"192.168.1.2"}, Enabled: true}}
+
+	if rules := NewConfigBuilder(settings, nil, nil).buildRoute(false).Rules; rules[1]["action"] == "reject" {
+		t.Fatalf("QUIC block should be off by default, got %v", rules[1])
+	}
+
+	settings.BlockQUIC = true
+	rules := NewConfigBuilder(settings, nil, nil).buildRoute(false).Rules
+	if rules[0]["action"] != "sniff" {
+		t.Fatalf("sniff must stay the first rule, got %v", rules[0])
+	}
+	want := RouteRule{"protocol": "quic", "action": "reject"}
+	if !reflect.DeepEqual(rules[1], want) {
+		t.Fatalf("QUIC rule mismatch: got %v, want %v", rules[1], want)
+	}
+	for _, rule := range rules[2:] {
+		if rule["action"] == "reject" {
+			t.Fatalf("QUIC rule should appear once, found another reject: %v", rule)
+		}
+	}
+
+	// Without the quic sniffer the protocol is unknown, so UDP 443 is rejected instead
+	settings.Sniffers = []string{"http", "tls"}
+	rules = NewConfigBuilder(settings, nil, nil).buildRoute(false).Rules
+	want = RouteRule{"network": "udp", "port": 443, "action": "reject"}
+	if !reflect.DeepEqual(rules[1], want) {
+		t.Fatalf("UDP 443 rule mismatch: got %v, want %v", rules[1], want)
+	}
+}
+
+func TestBuildRoute_AutoDetectInterfaceWins(t *testing.T) {
+	settings := storage.DefaultSettings()
+	settings.DefaultInterface = "eth1"
+
+	route := NewConfigBuilder(settings, nil, nil).buildRoute(false)
+
+	if !route.AutoDetectInterface || route.DefaultInterface != "" {
+		t.Fatalf("expected auto-detect without explicit interface, got auto=%v iface=%q", route.AutoDetectInterface, route.DefaultInterface)
+	}
+
+	settings.AutoDetectInterface = false
+	settings.DefaultInterface = ""
+	route = NewConfigBuilder(settings, nil, nil).buildRoute(false)
+	if !route.AutoDetectInterface {
+		t.Fatalf("expected auto-detect fallback when no interface is set")
+	}
+}
+
+func TestNodeToOutbound_DefaultUTLSFingerprint(t *testing.T) {
+	settings := storage.DefaultSettings()
+	settings.DefaultUTLSFingerprint = "firefox"
+	b := NewConfigBuilder(settings, nil, nil)
+
+	tlsNode := func(nodeType string, tls map[string]interface{}) storage.Node {
+		return storage.Node{Tag: nodeType, Type: nodeType, Server: "1.2.3.4", ServerPort: 443,
+			Extra: map[string]interface{}{"tls": tls}}
+	}
+	utlsOf := func(ob Outbound) map[string]interface{} {
+		tls, _ := ob["tls"].(map[string]interface{})
+		utls, _ := tls["utls"].(map[string]interface{})
+		return utls
+	}
+
+	plain := tlsNode("trojan", map[string]interface{}{"enabled": true, "server_name": "example.com"})
+	utls := utlsOf(b.nodeToOutbound(plain))
+	if utls["enabled"] != true || utls["fingerprint"] != "firefox" {
+		t.Fatalf("expected default fingerprint, got %v", utls)
+	}
+	if _, mutated := plain.Extra["tls"].(map[string]interface{})["utls"]; mutated {
+		t.Fatal("expected node extra tls map to stay untouched")
+	}
+
+	own := tlsNode("vless", map[string]interface{}{"enabled": true,
+		"utls": map[string]interface{}{"enabled": true, "fingerprint": "safari"}})
+	if fp := utlsOf(b.nodeToOutbound(own))["fingerprint"]; fp != "safari" {
+		t.Fatalf("expected node fingerprint to win, got %v", fp)
+	}
+
+	for _, n := range []storage.Node{
+		tlsNode("hysteria2", map[string]interface{}{"enabled": true}),
+		tlsNode("vmess", map[string]interface{}{"enabled": false}),
+		{Tag: "ss", Type: "shadowsocks", Server: "1.2.3.4", ServerPort: 8388},
+	} {
+		if utls := utlsOf(b.nodeToOutbound(n)); utls != nil {
+			t.Fatalf("%s: expected no utls block, got %v", n.Tag, utls)
+		}
+	}
+
+	if utls := utlsOf(NewConfigBuilder(storage.DefaultSettings(), nil, nil).nodeToOutbound(plain)); utls != nil {
+		t.Fatalf("expected no utls without a default fingerprint, got %v", utls)
+	}
+}
+
+func TestNodeToOutbound_TLSECH(t *testing.T) {
+	node := storage.Node{
+		Tag:        "ech-node",
+		Type:       "vless",
+		Server:     "1.2.3.4",
+		ServerPort: 443,
+		Extra: map[string]interface{}{
+			"uuid": "11111111-2222-3333-4444-555555555555",
+			"tls": map[string]interface{}{
+				"enabled":     true,
+				"server_name": "example.com",
+				"ech": map[string]interface{}{
+					"enabled": true,
+					"config":  []string{"-----BEGIN ECH CONFIGS-----", "AAT+DQAA", "-----END ECH CONFIGS-----"},
+				},
+			},
+		},
+	}
+
+	raw, err := json.Marshal(NewConfigBuilder(storage.DefaultSettings(), nil, nil).nodeToOutbound(node))
+	if err != nil {
+		t.Fatalf("marshal outbound: %v", err)
+	}
+	var decoded struct {
+		TLS struct {
+			ECH struct {
+				Enabled bool     `json:"enabled"`
+				Config  []string `json:"config"`
+			} `json:"ech"`
+		} `json:"tls"`
+	}
+	if err := json.Unmarshal(raw, &decoded); err != nil {
+		t.Fatalf("decode outbound: %v", err)
+	}
+	if !decoded.TLS.ECH.Enabled || len(decoded.TLS.ECH.Config) != 3 {
+		t.Fatalf("unexpected tls.ech: %s", raw)
+	}
+
+	stripped := NewConfigBuilder(storage.DefaultSettings(), nil, nil).WithECHSupport(false).nodeToOutbound(node)
+	tls := stripped["tls"].(map[string]interface{})
+	if _, ok := tls["ech"]; ok {
+		t.Fatalf("expected tls.ech to be dropped for unsupported kernel")
+	}
+	if tls["server_name"] != "example.com" {
+		t.Fatalf("expected other tls fields to be kept, got %v", tls)
+	}
+	if _, ok := node.Extra["tls"].(map[string]interface{})["ech"]; !ok {
+		t.Fatalf("expected node extra to be left untouched")
+	}
+}
+
+func findOutbound(outbounds []Outbound, tag string) Outbound {
+	for _, ob := range outbounds {
+		if ob["tag"] == tag {
+			return ob
+		}
+	}
+	return nil
+}
+
+func TestBuildOutbounds_InvalidFinalOutboundFallsBack(t *testing.T) {
+	settings := storage.DefaultSettings()
+	settings.FinalOutbound = "Proxyy"
+
+	outbounds, _ := NewConfigBuilder(settings, nil, nil).buildOutboundsWithMap()
+	final := findOutbound(outbounds, "Final")
+	if final == nil {
+		t.Fatalf("expected Final selector in outbounds")
+	}
+	if final["default"] != "Proxy" {
+		t.Fatalf("final default mismatch: got %v, want Proxy", final["default"])
+	}
+}
+
+func TestBuildOutbounds_ValidFinalOutboundKept(t *testing.T) {
+	settings := storage.DefaultSettings()
+	settings.FinalOutbound = "DIRECT"
+
+	outbounds, _ := NewConfigBuilder(settings, nil, nil).buildOutboundsWithMap()
+	final := findOutbound(outbounds, "Final")
+	if final == nil || final["default"] != "DIRECT" {
+		t.Fatalf("expected Final default DIRECT, got %v", final)
+	}
+}
+
+func TestBuildOutbounds_StableNodeOrder(t *testing.T) {
+	node := func(tag, country, server string) storage.Node {
+		return storage.Node{Tag: tag, InternalTag: tag, Type: "shadowsocks", Server: server, ServerPort: 8388, Country: country,
+			Extra: map[string]interface{}{"method": "aes-128-gcm", "password": "secret"}}
+	}
+	first := []storage.Node{node("jp-2", "JP", "1.1.1.1"), node("hk-1", "HK", "2.2.2.2"), node("jp-1", "JP", "3.3.3.3")}
+	second := []storage.Node{first[2], first[0], first[1]}
+
+	settings := storage.DefaultSettings()
+	settings.SortProxyNodes = true
+	build := func(nodes []storage.Node) []Outbound {
+		outbounds, _ := NewConfigBuilder(settings, nodes, nil).buildOutboundsWithMap()
+		return outbounds
+	}
+	a, b := build(first), build(second)
+
+	want := []string{"Auto", "hk-1", "jp-1", "jp-2"}
+	for _, tag := range []string{"Proxy", "Auto"} {
+		ga, gb := findOutbound(a, tag), findOutbound(b, tag)
+		if ga == nil || gb == nil {
+			t.Fatalf("expected %s group in outbounds", tag)
+		}
+		if !reflect.DeepEqual(ga["outbounds"], gb["outbounds"]) {
+			t.Fatalf("%s order differs across builds: %v vs %v", tag, ga["outbounds"], gb["outbounds"])
+		}
+	}
+	proxy := findOutbound(a, "Proxy")["outbounds"].([]string)
+	if !reflect.DeepEqual(proxy[:len(want)], want) {
+		t.Fatalf("proxy order mismatch: got %v, want prefix %v", proxy, want)
+	}
+}
+
+func TestBuildOutbounds_CustomURLTestURL(t *testing.T) {
+	settings := storage.DefaultSettings()
+	settings.URLTestURL = "http://cp.cloudflare.com/"
+	nodes := []storage.Node{
+		{Tag: "hk-1", InternalTag: "hk-1", Type: "shadowsocks", Server: "1.1.1.1", ServerPort: 8388, Country: "HK",
+			Extra: map[string]interface{}{"method": "aes-128-gcm", "password": "secret"}},
+	}
+
+	outbounds, _ := NewConfigBuilder(settings, nodes, nil).buildOutboundsWithMap()
+	auto := findOutbound(outbounds, "Auto")
+	if auto == nil || auto["url"] != settings.URLTestURL {
+		t.Fatalf("expected Auto url %q, got %v", settings.URLTestURL, auto)
+	}
+
+	outbounds, _ = NewConfigBuilder(storage.DefaultSettings(), nodes, nil).buildOutboundsWithMap()
+	if auto := findOutbound(outbounds, "Auto"); auto["url"] != defaultURLTestURL {
+		t.Fatalf("expected default Auto url, got %v", auto["url"])
+	}
+}
+
+func findTunInbound(t *testing.T, inbounds []Inbound) Inbound {
+	t.Helper()
+	for _, in := range inbounds {
+		if in.Type == "tun" {
+			return in
+		}
+	}
+	t.Fatalf("tun inbound not found")
+	return Inbound{}
+}
+
+func TestBuildInbounds_TunStackAndMTU(t *testing.T) {
+	for _, stack := range []string{"system", "gvisor", "mixed"} {
+		settings := storage.DefaultSettings()
+		settings.TunStack = stack
+
+		tun := findTunInbound(t, NewConfigBuilder(settings, nil, nil).buildInbounds())
+		if tun.Stack != stack {
+			t.Fatalf("stack mismatch: got %q, want %q", tun.Stack, stack)
+		}
+		if tun.MTU != 0 {
+			t.Fatalf("expected default MTU to be omitted, got %d", tun.MTU)
+		}
+	}
+
+	settings := storage.DefaultSettings()
+	settings.TunStack = ""
+	settings.TunMTU = 1400
+	tun := findTunInbound(t, NewConfigBuilder(settings, nil, nil).buildInbounds())
+	if tun.Stack != storage.DefaultTunStack {
+		t.Fatalf("stack mismatch: got %q, want default %q", tun.Stack, storage.DefaultTunStack)
+	}
+	if tun.MTU != 1400 {
+		t.Fatalf("mtu mismatch: got %d, want 1400", tun.MTU)
+	}
+}
+
+func TestBuildInbounds_TunRoutes(t *testing.T) {
+	settings := storage.DefaultSettings()
+	tun := findTunInbound(t, NewConfigBuilder(settings, nil, nil).buildInbounds())
+	raw, err := json.Marshal(tun)
+	if err != nil {
+		t.Fatalf("marshal tun inbound: %v", err)
+	}
+	if strings.Contains(string(raw), "route_address") || strings.Contains(string(raw), "route_exclude_address") {
+		t.Fatalf("expected no route lists by default, got %s", raw)
+	}
+
+	settings.TunIncludeRoutes = []string{"0.0.0.0/1", "128.0.0.0/1", " "}
+	settings.TunExcludeRoutes = []string{"172.17.0.0/16", " 10.8.0.0/24 "}
+	tun = findTunInbound(t, NewConfigBuilder(settings, nil, nil).buildInbounds())
+	raw, err = json.Marshal(tun)
+	if err != nil {
+		t.Fatalf("marshal tun inbound: %v", err)
+	}
+	var decoded struct {
+		RouteAddress        []string `json:"route_address"`
+		RouteExcludeAddress []string `json:"route_exclude_address"`
+	}
+	if err := json.Unmarshal(raw, &decoded); err != nil {
+		t.Fatalf("decode tun inbound: %v", err)
+	}
+	if !reflect.DeepEqual(decoded.RouteAddress, []string{"0.0.0.0/1", "128.0.0.0/1"}) {
+		t.Fatalf("route_address mismatch: got %v", decoded.RouteAddress)
+	}
+	if !reflect.DeepEqual(decoded.RouteExcludeAddress, []string{"172.17.0.0/16", "10.8.0.0/24"}) {
+		t.Fatalf("route_exclude_address mismatch: got %v", decoded.RouteExcludeAddress)
+	}
+}
+
+func TestBuildInbounds_ListenAddress(t *testing.T) {
+	settings := storage.DefaultSettings()
+	settings.AllowLAN = true
+	settings.ListenAddress = "192.168.1.5"
+	settings.MixedPort = 2080
+	settings.SocksPort = 2081
+	settings.SocksEnabled = true
+	settings.HttpPort = 2082
+	settings.HttpEnabled = true
+	settings.ShadowsocksPort = 2083
+	settings.ShadowsocksMethod = "aes-128-gcm"
+	settings.ShadowsocksPassword = "secret"
+
+	inbounds := NewConfigBuilder(settings, nil, nil).buildInbounds()
+	listening := 0
+	for _, in := range inbounds {
+		if in.Type == "tun" {
+			continue
+		}
+		listening++
+		if in.Listen != "192.168.1.5" {
+			t.Fatalf("%s listen mismatch: got %q, want %q", in.Tag, in.Listen, "192.168.1.5")
+		}
+	}
+	if listening != 4 {
+		t.Fatalf("listening inbound count mismatch: got %d, want 4", listening)
+	}
+
+	settings.ListenAddress = ""
+	for _, in := range NewConfigBuilder(settings, nil, nil).buildInbounds() {
+		if in.Type != "tun" && in.Listen != "0.0.0.0" {
+			t.Fatalf("%s listen mismatch without override: got %q, want 0.0.0.0", in.Tag, in.Listen)
+		}
+	}
+}
+
+func TestBuildInbounds_DisabledInboundKeepsPort(t *testing.T) {
+	settings := storage.DefaultSettings()
+	settings.TunEnabled = false
+	settings.ShadowsocksPort = 0
+	settings.MixedEnabled = false
+	settings.SocksPort = 2081
+	settings.SocksEnabled = true
+	settings.HttpPort = 2082
+	settings.HttpEnabled = false
+
+	inbounds := NewConfigBuilder(settings, nil, nil).buildInbounds()
+	if len(inbounds) != 1 || inbounds[0].Tag != "socks-in" {
+		t.Fatalf("expected only socks-in, got %+v", inbounds)
+	}
+	if settings.MixedPort != 2080 || settings.HttpPort != 2082 {
+		t.Fatalf("disabled inbound ports changed: mixed %d, http %d", settings.MixedPort, settings.HttpPort)
+	}
+}
+
+func TestNodeToOutbound_TCPFastOpen(t *testing.T) {
+	nodes := map[string]storage.Node{
+		"plain": {Tag: "plain", Type: "trojan", Server: "1.1.1.1", ServerPort: 443, Extra: map[string]interface{}{"password": "p"}},
+		"on":    {Tag: "on", Type: "vless", Server: "1.1.1.2", ServerPort: 443, Extra: map[string]interface{}{"uuid": "u", "tcp_fast_open": true}},
+		"off":   {Tag: "off", Type: "vmess", Server: "1.1.1.3", ServerPort: 443, Extra: map[string]interface{}{"uuid": "u", "tcp_fast_open": false}},
+		"hy2":   {Tag: "hy2", Type: "hysteria2", Server: "1.1.1.4", ServerPort: 443, Extra: map[string]interface{}{"password": "p"}},
+	}
+
+	settings := storage.DefaultSettings()
+	b := NewConfigBuilder(settings, nil, nil)
+	for tag, want := range map[string]bool{"plain": false, "on": true, "off": false, "hy2": false} {
+		if got := b.nodeToOutbound(nodes[tag])["tcp_fast_open"] == true; got != want {
+			t.Fatalf("%s tcp_fast_open without global default mismatch: got %v, want %v", tag, got, want)
+		}
+	}
+
+	settings.TCPFastOpen = true
+	for tag, want := range map[string]bool{"plain": true, "on": true, "off": false, "hy2": false} {
+		if got := b.nodeToOutbound(nodes[tag])["tcp_fast_open"] == true; got != want {
+			t.Fatalf("%s tcp_fast_open with global default mismatch: got %v, want %v", tag, got, want)
+		}
+	}
+	if _, has := b.nodeToOutbound(nodes["off"])["tcp_fast_open"]; has {
+		t.Fatal("expected a node opting out to omit tcp_fast_open")
+	}
+}
+
+func TestNodeToOutbound_UDPFragment(t *testing.T) {
+	nodes := map[string]storage.Node{
+		"hy2":    {Tag: "hy2", Type: "hysteria2", Server: "1.1.1.1", ServerPort: 443, Extra: map[string]interface{}{"password": "p"}},
+		"ss-on":  {Tag: "ss-on", Type: "shadowsocks", Server: "1.1.1.2", ServerPort: 8388, Extra: map[string]interface{}{"password": "p", "udp_fragment": true}},
+		"tuic-0": {Tag: "tuic-0", Type: "tuic", Server: "1.1.1.3", ServerPort: 443, Extra: map[string]interface{}{"uuid": "u", "udp_fragment": false}},
+		"trojan": {Tag: "trojan", Type: "trojan", Server: "1.1.1.4", ServerPort: 443, Extra: map[string]interface{}{"password": "p"}},
+	}
+
+	settings := storage.DefaultSettings()
+	b := NewConfigBuilder(settings, nil, nil)
+	for tag, want := range map[string]bool{"hy2": false, "ss-on": true, "tuic-0": false, "trojan": false} {
+		if got := b.nodeToOutbound(nodes[tag])["udp_fragment"] == true; got != want {
+			t.Fatalf("%s udp_fragment without global default mismatch: got %v, want %v", tag, got, want)
+		}
+	}
+
+	settings.UDPFragment = true
+	for tag, want := range map[string]bool{"hy2": true, "ss-on": true, "tuic-0": false, "trojan": false} {
+		if got := b.nodeToOutbound(nodes[tag])["udp_fragment"] == true; got != want {
+			t.Fatalf("%s udp_fragment with global default mismatch: got %v, want %v", tag, got, want)
+		}
+	}
+	if _, has := b.nodeToOutbound(nodes["tuic-0"])["udp_fragment"]; has {
+		t.Fatal("expected a node opting out to omit udp_fragment")
+	}
+}
+
+func TestNodeToOutbound_Multiplex(t *testing.T) {
+	nodeMux := map[string]interface{}{"enabled": true, "protocol": "h2mux", "max_connections": 4}
+	nodes := map[string]storage.Node{
+		"own":    {Tag: "own", Type: "trojan", Server: "1.1.1.1", ServerPort: 443, Extra: map[string]interface{}{"password": "p", "multiplex": nodeMux}},
+		"plain":  {Tag: "plain", Type: "vmess", Server: "1.1.1.2", ServerPort: 443, Extra: map[string]interface{}{"uuid": "u"}},
+		"vision": {Tag: "vision", Type: "vless", Server: "1.1.1.3", ServerPort: 443, Extra: map[string]interface{}{"uuid": "u", "flow": "xtls-rprx-vision", "multiplex": nodeMux}},
+		"hy2":    {Tag: "hy2", Type: "hysteria2", Server: "1.1.1.4", ServerPort: 443, Extra: map[string]interface{}{"password": "p"}},
+	}
+
+	settings := storage.DefaultSettings()
+	settings.MultiplexEnabled = true
+	b := NewConfigBuilder(settings, nil, nil)
+	wantMux := map[string]interface{}{
+		"own":    nodeMux,
+		"plain":  map[string]interface{}{"enabled": true},
+		"vision": nil,
+		"hy2":    nil,
+	}
+	for tag, want := range wantMux {
+		got, has := b.nodeToOutbound(nodes[tag])["multiplex"]
+		if want == nil {
+			if has {
+				t.Fatalf("%s: expected no multiplex block, got %v", tag, got)
+			}
+			continue
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("%s multiplex mismatch: got %v, want %v", tag, got, want)
+		}
+	}
+
+	settings.MultiplexEnabled = false
+	if _, has := b.nodeToOutbound(nodes["plain"])["multiplex"]; has {
+		t.Fatal("expected no default multiplex when the setting is off")
+	}
+
+	b.WithMultiplexSupport(false)
+	if _, has := b.nodeToOutbound(nodes["own"])["multiplex"]; has {
+		t.Fatal("expected multiplex to be dropped for an unsupported kernel")
+	}
+}
+
+func TestNodeToOutbound_IPServerKeepsDomainSNI(t *testing.T) {
+	settings := storage.DefaultSettings()
+	settings.DefaultUTLSFingerprint = "chrome"
+	b := NewConfigBuilder(settings, nil, nil)
+	b.echUnsupported = true
+
+	node := storage.Node{Tag: "ip-sni", Type: "vless", Server: "203.0.113.7", ServerPort: 443,
+		Extra: map[string]interface{}{
+			"uuid": "11111111-2222-3333-4444-555555555555",
+			"tls":  map[string]interface{}{"enabled": true, "server_name": "cdn.example.com", "ech": map[string]interface{}{"enabled": true}},
+		}}
+
+	for name, ob := range map[string]Outbound{"NodeToOutbound": NodeToOutbound(node), "nodeToOutbound": b.nodeToOutbound(node)} {
+		if ob["server"] != "203.0.113.7" {
+			t.Fatalf("%s server mismatch: got %v, want 203.0.113.7", name, ob["server"])
+		}
+		tls, _ := ob["tls"].(map[string]interface{})
+		if tls["server_name"] != "cdn.example.com" {
+			t.Fatalf("%s server_name mismatch: got %v, want cdn.example.com", name, tls["server_name"])
+		}
+	}
+}
+
+func TestNodeToOutbound_GRPCServiceName(t *testing.T) {
+	for raw, want := range map[string]string{"": "", "   ": "", "/": "", "svc": "svc", "/svc": "svc", " /my.Service ": "my.Service"} {
+		transport := map[string]interface{}{"type": "grpc", "service_name": raw}
+		node := storage.Node{Tag: "grpc", Type: "vless", Server: "1.1.1.1", ServerPort: 443,
+			Extra: map[string]interface{}{"uuid": "u", "transport": transport}}
+
+		got, _ := NodeToOutbound(node)["transport"].(map[string]interface{})
+		name, has := got["service_name"]
+		if want == "" {
+			if has {
+				t.Fatalf("%q: expected service_name to be omitted, got %v", raw, name)
+			}
+		} else if name != want {
+			t.Fatalf("%q service_name mismatch: got %v, want %s", raw, name, want)
+		}
+		if transport["service_name"] != raw {
+			t.Fatalf("%q: stored node transport was modified: %v", raw, transport)
+		}
+	}
+}
+
+func TestNodeToOutbound_HTTPTransportHostPath(t *testing.T) {
+	node := storage.Node{Tag: "h2", Type: "vmess", Server: "1.1.1.1", ServerPort: 443,
+		Extra: map[string]interface{}{"uuid": "u", "transport": map[string]interface{}{
+			"type": "h2", "path": " ws ", "host": []interface{}{" a.example.com ", "", "bad host", "b.example.com/x", "c.example.com"},
+		}}}
+
+	got, _ := NodeToOutbound(node)["transport"].(map[string]interface{})
+	want := map[string]interface{}{"type": "http", "path": "/ws", "host": []string{"a.example.com", "c.example.com"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("http transport mismatch: got %v, want %v", got, want)
+	}
+
+	node.Extra["transport"] = map[string]interface{}{"type": "http", "path": "", "host": []string{" ", ""}}
+	got, _ = NodeToOutbound(node)["transport"].(map[string]interface{})
+	if want := map[string]interface{}{"type": "http"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("empty host/path mismatch: got %v, want %v", got, want)
+	}
+}
+
+func TestNodeToOutbound_UDPOverTCP(t *testing.T) {
+	ss := func(tag string, uot interface{}) storage.Node {
+		extra := map[string]interface{}{"method": "aes-128-gcm", "password": "p"}
+		if uot != nil {
+			extra["udp_over_tcp"] = uot
+		}
+		return storage.Node{Tag: tag, Type: "shadowsocks", Server: "1.1.1.1", ServerPort: 8388, Extra: extra}
+	}
+	// The object form as it comes back from the database, with a float version
+	versioned := map[string]interface{}{"enabled": true, "version": float64(2)}
+	nodes := map[string]storage.Node{
+		"plain":     ss("plain", nil),
+		"on":        ss("on", true),
+		"off":       ss("off", false),
+		"versioned": ss("versioned", versioned),
+		"disabled":  ss("disabled", map[string]interface{}{"enabled": false, "version": float64(2)}),
+		"trojan":    {Tag: "trojan", Type: "trojan", Server: "1.1.1.2", ServerPort: 443, Extra: map[string]interface{}{"password": "p", "udp_over_tcp": true}},
+	}
+
+	if got := NodeToOutbound(nodes["versioned"])["udp_over_tcp"]; !reflect.DeepEqual(got, map[string]interface{}{"enabled": true, "version": 2}) {
+		t.Fatalf("object form mismatch: got %#v", got)
+	}
+	if _, has := NodeToOutbound(nodes["trojan"])["udp_over_tcp"]; has {
+		t.Fatal("expected udp_over_tcp to be dropped from a trojan outbound")
+	}
+
+	settings := storage.DefaultSettings()
+	b := NewConfigBuilder(settings, nil, nil)
+	want := map[string]interface{}{
+		"plain":     nil,
+		"on":        true,
+		"off":       nil,
+		"versioned": map[string]interface{}{"enabled": true, "version": 2},
+		"disabled":  nil,
+	}
+	check := func(label string, want map[string]interface{}) {
+		t.Helper()
+		for tag, w := range want {
+			got, has := b.nodeToOutbound(nodes[tag])["udp_over_tcp"]
+			if w == nil {
+				if has {
+					t.Fatalf("%s %s: expected no udp_over_tcp, got %v", label, tag, got)
+				}
+				continue
+			}
+			if !reflect.DeepEqual(got, w) {
+				t.Fatalf("%s %s udp_over_tcp mismatch: got %#v, want %#v", label, tag, got, w)
+			}
+		}
+	}
+	check("no default", want)
+
+	settings.UDPOverTCP = true
+	want["plain"] = true
+	check("global default", want)
+
+	// UDP over TCP and multiplex cannot be combined
+	settings.MultiplexEnabled = true
+	out := b.nodeToOutbound(nodes["plain"])
+	if _, hasMux := out["multiplex"]; hasMux || out["udp_over_tcp"] != true {
+		t.Fatalf("expected udp_over_tcp without multiplex, got %v", out)
+	}
+	muxNode := ss("mux", nil)
+	muxNode.Extra["multiplex"] = map[string]interface{}{"enabled": true}
+	if _, has := b.nodeToOutbound(muxNode)["udp_over_tcp"]; has {
+		t.Fatal("expected no default udp_over_tcp on a node with its own multiplex")
+	}
+}
@@ -6,11 +6,12 @@ import (
 	"strings"
 )
 
-// OutboundError represents a parsed sing-box validation error for an outbound
+// OutboundError represents a parsed sing-box validation error for an outbound or endpoint
 type OutboundError struct {
-	Index   int    // outbound index in the config (-1 if not index-based)
-	Field   string // the problematic field path (e.g. "transport.mode")
-	Message string // full error message
+	Index    int    // outbound (or endpoint) index in the config (-1 if not index-based)
+	Endpoint bool   // true when Index points into endpoints rather than outbounds
+	Field    string // the problematic field path (e.g. "transport.mode")
+	Message  string // full error message
 }
 
 // DuplicateTagError represents a duplicate outbound tag error
@@ -24,7 +25,7 @@ type CheckErrors struct {
 	DuplicateTagErrors []DuplicateTagError
 }
 
-var outboundErrorRe = regexp.MustCompile(`outbounds?\[(\d+)\]\.?([^:]*?):\s*(.+)`)
+var outboundErrorRe = regexp.MustCompile(`(outbound|endpoint)s?\[(\d+)\]\.?([^:]*?):\s*(.+)`)
 var duplicateTagRe = regexp.MustCompile(`duplicate outbound/endpoint tag:\s*(.+)`)
 
 // ParseCheckErrors parses sing-box check output and extracts all recognizable errors.
@@ -34,18 +35,21 @@ func ParseCheckErrors(output string) CheckErrors {
 	for _, line := range strings.Split(output, "\n") {
 		line = strings.TrimSpace(line)
 
-		// Check for outbound index errors: outbounds[N].field: message or initialize outbound[N]: message
-		if strings.Contains(line, "outbound[") || strings.Contains(line, "outbounds[") {
+		// Check for outbound index errors: outbounds[N].field: message or initialize outbound[N]: message.
+		// WireGuard endpoints fail the same way as endpoints[N] / endpoint[N].
+		if strings.Contains(line, "outbound[") || strings.Contains(line, "outbounds[") ||
+			strings.Contains(line, "endpoint[") || strings.Contains(line, "endpoints[") {
 			matches := outboundErrorRe.FindStringSubmatch(line)
-			if len(matches) >= 4 {
-				idx, err := strconv.Atoi(matches[1])
+			if len(matches) >= 5 {
+				idx, err := strconv.Atoi(matches[2])
 				if err != nil {
 					continue
 				}
 				result.OutboundErrors = append(result.OutboundErrors, OutboundError{
-					Index:   idx,
-					Field:   strings.TrimSpace(matches[2]),
-					Message: strings.TrimSpace(matches[3]),
+					Index:    idx,
+					Endpoint: matches[1] == "endpoint",
+					Field:    strings.TrimSpace(matches[3]),
+					Message:  strings.TrimSpace(matches[4]),
 				})
 			}
 			continue
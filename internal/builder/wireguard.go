@@ -0,0 +1,73 @@
+package builder
+
+// wireGuardDefaultAllowedIPs sends all traffic through a peer that does not list its own
+var wireGuardDefaultAllowedIPs = []string{"0.0.0.0/0", "::/0"}
+
+// WireGuardEndpoint converts a WireGuard outbound in the legacy form into the endpoint
+// form sing-box 1.11+ expects. The top-level server and peer key become a single peer
+// unless the node lists its own peers. Fields already in endpoint form are kept.
+func WireGuardEndpoint(outbound Outbound) Endpoint {
+	endpoint := Endpoint{}
+	peer := map[string]interface{}{}
+	for k, v := range outbound {
+		switch k {
+		case "server":
+			peer["address"] = v
+		case "server_port":
+			peer["port"] = v
+		case "peer_public_key":
+			peer["public_key"] = v
+		case "pre_shared_key", "reserved":
+			peer[k] = v
+		case "local_address":
+			endpoint["address"] = v
+		case "system_interface":
+			endpoint["system"] = v
+		case "interface_name":
+			endpoint["name"] = v
+		case "gso", "network":
+			// Not accepted by the endpoint
+		case "peers":
+			endpoint["peers"] = wireGuardEndpointPeers(v)
+		default:
+			endpoint[k] = v
+		}
+	}
+
+	if _, hasPeers := endpoint["peers"]; !hasPeers {
+		if _, hasAllowedIPs := peer["allowed_ips"]; !hasAllowedIPs {
+			peer["allowed_ips"] = wireGuardDefaultAllowedIPs
+		}
+		endpoint["peers"] = []interface{}{peer}
+	}
+	return endpoint
+}
+
+// wireGuardEndpointPeers renames the legacy peer fields (server, server_port) to
+// their endpoint names and fills in allowed_ips where it is missing
+func wireGuardEndpointPeers(raw interface{}) []interface{} {
+	list, _ := raw.([]interface{})
+	peers := make([]interface{}, 0, len(list))
+	for _, item := range list {
+		legacy, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		peer := make(map[string]interface{}, len(legacy))
+		for k, v := range legacy {
+			switch k {
+			case "server":
+				peer["address"] = v
+			case "server_port":
+				peer["port"] = v
+			default:
+				peer[k] = v
+			}
+		}
+		if _, hasAllowedIPs := peer["allowed_ips"]; !hasAllowedIPs {
+			peer["allowed_ips"] = wireGuardDefaultAllowedIPs
+		}
+		peers = append(peers, peer)
+	}
+	return peers
+}
@@ -0,0 +1,194 @@
+package daemon
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/xiaobei/singbox-manager/internal/builder"
+	"github.com/xiaobei/singbox-manager/internal/logger"
+	"github.com/xiaobei/singbox-manager/internal/storage"
+)
+
+// DebugConnectResult is the outcome of a single connection attempt through one node,
+// together with everything sing-box logged at debug level while making it.
+type DebugConnectResult struct {
+	Success    bool     `json:"success"`
+	StatusCode int      `json:"status_code,omitempty"`
+	LatencyMs  int64    `json:"latency_ms,omitempty"`
+	Error      string   `json:"error,omitempty"`
+	Log        []string `json:"log"`
+}
+
+// debugConnectTag is the outbound tag of the node in a debug-connect config
+const debugConnectTag = "debug-node"
+
+// lockedBuffer collects sing-box stdout and stderr from both pipes at once
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) Lines() []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	lines := []string{}
+	for _, line := range strings.Split(b.buf.String(), "\n") {
+		if line = strings.TrimRight(line, "\r"); strings.TrimSpace(line) != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// buildDebugConnectConfig builds a config that routes everything from a local mixed
+// inbound through the single node, with debug logging so handshake errors are visible.
+// WireGuard nodes go into endpoints when wgEndpoint is set (sing-box 1.11+).
+func buildDebugConnectConfig(node storage.Node, port int, wgEndpoint bool) *builder.SingBoxConfig {
+	ob := builder.NodeToOutbound(node)
+	ob["tag"] = debugConnectTag
+
+	cfg := &builder.SingBoxConfig{
+		Log: &builder.LogConfig{Level: "debug", Timestamp: true},
+		Inbounds: []builder.Inbound{{
+			Type:       "mixed",
+			Tag:        "debug-in",
+			Listen:     "127.0.0.1",
+			ListenPort: port,
+		}},
+		Outbounds: []builder.Outbound{ob, {"type": "direct", "tag": "DIRECT"}},
+		Route:     &builder.RouteConfig{Final: debugConnectTag},
+	}
+	if node.Type == "wireguard" && wgEndpoint {
+		cfg.Endpoints = []builder.Endpoint{builder.WireGuardEndpoint(ob)}
+		cfg.Outbounds = cfg.Outbounds[1:]
+	}
+	return cfg
+}
+
+// DebugConnect starts a throwaway sing-box with only this node, makes one request to
+// targetURL through it and returns the captured log. The process and its config file
+// are removed before returning. A failed connection is reported in the result, the
+// error is only set when sing-box could not be run at all.
+func DebugConnect(singboxPath string, node storage.Node, targetURL string, timeout time.Duration) (*DebugConnectResult, error) {
+	if _, err := os.Stat(singboxPath); os.IsNotExist(err) {
+		return nil, fmt.Errorf("sing-box binary not found: %s", singboxPath)
+	}
+
+	port, err := getFreePort()
+	if err != nil {
+		return nil, fmt.Errorf("failed to find free port: %w", err)
+	}
+
+	cfgJSON, err := json.MarshalIndent(buildDebugConnectConfig(node, port, kernelTakesWireGuardEndpoint(singboxPath)), "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal config: %w", err)
+	}
+
+	tmpFile, err := os.CreateTemp("", "sbm-debug-connect-*.json")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp file: %w", err)
+	}
+	tmpPath := tmpFile.Name()
+	defer os.Remove(tmpPath)
+
+	if _, err := tmpFile.Write(cfgJSON); err != nil {
+		tmpFile.Close()
+		return nil, fmt.Errorf("failed to write config: %w", err)
+	}
+	tmpFile.Close()
+
+	var output lockedBuffer
+	cmd := exec.Command(singboxPath, "run", "-c", tmpPath)
+	cmd.Dir = os.TempDir()
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start sing-box: %w", err)
+	}
+	logger.Printf("[debug-connect] Started sing-box PID %d on port %d for %s", cmd.Process.Pid, port, node.Tag)
+
+	var waitErr error
+	exited := make(chan struct{})
+	go func() {
+		waitErr = cmd.Wait()
+		close(exited)
+	}()
+	stop := func() {
+		cmd.Process.Kill()
+		<-exited
+	}
+
+	result := &DebugConnectResult{}
+	if !waitForListener(port, exited, 5*time.Second) {
+		select {
+		case <-exited:
+			result.Error = "sing-box exited before accepting connections"
+			if waitErr != nil {
+				result.Error += ": " + waitErr.Error()
+			}
+		default:
+			stop()
+			result.Error = fmt.Sprintf("sing-box did not open port %d in time", port)
+		}
+		result.Log = output.Lines()
+		return result, nil
+	}
+
+	proxyURL, _ := url.Parse(fmt.Sprintf("http://127.0.0.1:%d", port))
+	client := &http.Client{
+		Timeout:   timeout,
+		Transport: &http.Transport{Proxy: http.ProxyURL(proxyURL), DisableKeepAlives: true},
+	}
+	start := time.Now()
+	resp, err := client.Get(targetURL)
+	if err != nil {
+		result.Error = err.Error()
+	} else {
+		resp.Body.Close()
+		result.Success = true
+		result.StatusCode = resp.StatusCode
+		result.LatencyMs = time.Since(start).Milliseconds()
+	}
+
+	// Give sing-box a moment to flush the log lines for the closing connection
+	time.Sleep(200 * time.Millisecond)
+	stop()
+
+	result.Log = output.Lines()
+	return result, nil
+}
+
+// waitForListener reports whether the inbound started accepting connections before
+// sing-box exited or the limit passed.
+func waitForListener(port int, exited <-chan struct{}, limit time.Duration) bool {
+	addr := fmt.Sprintf("127.0.0.1:%d", port)
+	deadline := time.Now().Add(limit)
+	for time.Now().Before(deadline) {
+		select {
+		case <-exited:
+			return false
+		case <-time.After(100 * time.Millisecond):
+		}
+		if conn, err := net.DialTimeout("tcp", addr, 200*time.Millisecond); err == nil {
+			conn.Close()
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,53 @@
+package daemon
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/xiaobei/singbox-manager/internal/storage"
+)
+
+func TestDebugConnect_CapturesLogOnFailure(t *testing.T) {
+	dir := t.TempDir()
+	bin := filepath.Join(dir, "sing-box")
+	script := "#!/bin/sh\n" +
+		"echo 'DEBUG outbound/vless[debug-node]: dial 203.0.113.1:443' >&2\n" +
+		"echo 'ERROR outbound/vless[debug-node]: tls handshake: remote error: bad certificate' >&2\n" +
+		"exit 1\n"
+	if err := os.WriteFile(bin, []byte(script), 0755); err != nil {
+		t.Fatalf("write fake sing-box: %v", err)
+	}
+
+	node := storage.Node{Tag: "broken", Type: "vless", Server: "203.0.113.1", ServerPort: 443}
+	result, err := DebugConnect(bin, node, "http://example.com/", 2*time.Second)
+	if err != nil {
+		t.Fatalf("DebugConnect: %v", err)
+	}
+	if result.Success {
+		t.Fatalf("expected a failed connect, got %+v", result)
+	}
+	if !strings.Contains(result.Error, "exited") {
+		t.Fatalf("error mismatch: got %q", result.Error)
+	}
+	if len(result.Log) != 2 || !strings.Contains(result.Log[1], "bad certificate") {
+		t.Fatalf("log mismatch: got %v", result.Log)
+	}
+}
+
+func TestBuildDebugConnectConfig(t *testing.T) {
+	node := storage.Node{Tag: "n", Type: "shadowsocks", Server: "10.0.0.1", ServerPort: 8388}
+	cfg := buildDebugConnectConfig(node, 12345, true)
+
+	if cfg.Log == nil || cfg.Log.Level != "debug" {
+		t.Fatalf("log level mismatch: got %+v", cfg.Log)
+	}
+	if len(cfg.Inbounds) != 1 || cfg.Inbounds[0].ListenPort != 12345 || cfg.Inbounds[0].Listen != "127.0.0.1" {
+		t.Fatalf("inbound mismatch: got %+v", cfg.Inbounds)
+	}
+	if cfg.Outbounds[0]["tag"] != debugConnectTag || cfg.Route.Final != debugConnectTag {
+		t.Fatalf("routing mismatch: outbound %v, final %q", cfg.Outbounds[0]["tag"], cfg.Route.Final)
+	}
+}
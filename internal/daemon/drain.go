@@ -0,0 +1,50 @@
+package daemon
+
+import (
+	"time"
+
+	"github.com/xiaobei/singbox-manager/internal/logger"
+)
+
+// drainPollInterval is how often the connection count is polled while draining
+var drainPollInterval = time.Second
+
+// ConnectionCounter reports how many connections sing-box currently carries
+type ConnectionCounter func() (int, error)
+
+// DrainConnections waits up to timeout for sing-box's active connections to drop
+// below threshold, reporting each polled count to progress (which may be nil). It
+// returns when the count is low enough, the timeout passes or the count cannot be
+// read, since without the Clash API there is nothing left to wait for. Callers
+// restart afterwards; a zero timeout or a stopped process returns right away.
+func (pm *ProcessManager) DrainConnections(count ConnectionCounter, threshold int, timeout time.Duration, progress func(active int)) {
+	if timeout <= 0 || !pm.IsRunning() {
+		return
+	}
+	drainConnections(count, threshold, timeout, progress)
+}
+
+// drainConnections polls count until fewer than threshold connections are active or
+// timeout passes.
+func drainConnections(count ConnectionCounter, threshold int, timeout time.Duration, progress func(active int)) {
+	deadline := time.Now().Add(timeout)
+	for {
+		active, err := count()
+		if err != nil {
+			logger.Printf("[drain] Cannot read active connections, restarting now: %v", err)
+			return
+		}
+		if progress != nil {
+			progress(active)
+		}
+		if active < threshold {
+			logger.Printf("[drain] %d active connection(s) left, restarting", active)
+			return
+		}
+		if !time.Now().Before(deadline) {
+			logger.Printf("[drain] %d connection(s) still active after %s, forcing restart", active, timeout)
+			return
+		}
+		time.Sleep(min(drainPollInterval, time.Until(deadline)))
+	}
+}
@@ -0,0 +1,42 @@
+package daemon
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDrainThenRestart_WaitsForConnectionsToDrain(t *testing.T) {
+	prev := drainPollInterval
+	drainPollInterval = time.Millisecond
+	t.Cleanup(func() { drainPollInterval = prev })
+
+	// Mock connections source: transfers finish one poll at a time
+	counts := []int{12, 7, 4, 1}
+	polls := 0
+	count := func() (int, error) {
+		c := counts[min(polls, len(counts)-1)]
+		polls++
+		return c, nil
+	}
+	var reported []int
+
+	drainConnections(count, 2, time.Minute, func(active int) { reported = append(reported, active) })
+	if polls != len(counts) {
+		t.Fatalf("drain timing mismatch: got drain end after %d polls, want %d", polls, len(counts))
+	}
+	if len(reported) != len(counts) || reported[len(reported)-1] != 1 {
+		t.Fatalf("progress mismatch: got %v, want %v", reported, counts)
+	}
+}
+
+func TestDrainThenRestart_ForcesAfterTimeout(t *testing.T) {
+	prev := drainPollInterval
+	drainPollInterval = 5 * time.Millisecond
+	t.Cleanup(func() { drainPollInterval = prev })
+
+	start := time.Now()
+	drainConnections(func() (int, error) { return 50, nil }, 2, 50*time.Millisecond, nil)
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond || elapsed > 2*time.Second {
+		t.Fatalf("drain wait mismatch: got %s, want about 50ms", elapsed)
+	}
+}
@@ -16,15 +16,16 @@ import (
 	"time"
 
 	"github.com/xiaobei/singbox-manager/internal/builder"
+	"github.com/xiaobei/singbox-manager/internal/kernel"
 	"github.com/xiaobei/singbox-manager/internal/logger"
 	"github.com/xiaobei/singbox-manager/internal/storage"
 )
 
 // BrokenNode describes a node that failed sing-box config validation.
 type BrokenNode struct {
-	Index int    // index in the original nodes slice
-	Tag   string // original node tag
-	Error string // validation error message
+	Index int    `json:"index"` // index in the original nodes slice
+	Tag   string `json:"tag"`   // original node tag
+	Error string `json:"error"` // validation error message
 }
 
 // ProbeStatus represents the current state of the probe sing-box instance.
@@ -48,6 +49,7 @@ type ProbeManager struct {
 	cmd                *exec.Cmd
 	port               int
 	geoProxyPort       int // mixed inbound port for GeoIP lookups
+	udpProxyPort       int // socks inbound port for UDP health checks
 	pid                int
 	mu                 sync.Mutex
 	running            bool
@@ -102,8 +104,13 @@ func (pm *ProbeManager) Start(nodes []storage.Node) ([]BrokenNode, error) {
 		return nil, fmt.Errorf("failed to find free geo proxy port: %w", err)
 	}
 
+	udpPort, err := getFreePort()
+	if err != nil {
+		return nil, fmt.Errorf("failed to find free udp proxy port: %w", err)
+	}
+
 	// Validate config iteratively, removing broken nodes.
-	validNodes, brokenNodes, err := pm.validateProbeConfig(nodes, port, geoPort)
+	validNodes, brokenNodes, err := pm.validateProbeConfig(nodes, port, geoPort, udpPort)
 	if err != nil {
 		return brokenNodes, fmt.Errorf("probe config validation failed: %w", err)
 	}
@@ -112,7 +119,7 @@ func (pm *ProbeManager) Start(nodes []storage.Node) ([]BrokenNode, error) {
 		return brokenNodes, fmt.Errorf("no valid nodes remaining after validation")
 	}
 
-	cfg, tagMap := buildProbeConfig(validNodes, port, geoPort)
+	cfg, tagMap := buildProbeConfig(validNodes, port, geoPort, udpPort, kernelTakesWireGuardEndpoint(pm.singboxPath))
 	cfgJSON, err := json.MarshalIndent(cfg, "", "  ")
 	if err != nil {
 		return brokenNodes, fmt.Errorf("failed to marshal config: %w", err)
@@ -131,7 +138,7 @@ func (pm *ProbeManager) Start(nodes []storage.Node) ([]BrokenNode, error) {
 	}
 	tmpFile.Close()
 
-	logger.Printf("[probe] Starting probe sing-box on port %d (geo: %d) for %d nodes (%d broken excluded)", port, geoPort, len(validNodes), len(brokenNodes))
+	logger.Printf("[probe] Starting probe sing-box on port %d (geo: %d, udp: %d) for %d nodes (%d broken excluded)", port, geoPort, udpPort, len(validNodes), len(brokenNodes))
 	cmd := exec.Command(pm.singboxPath, "run", "-c", tmpPath)
 	cmd.Dir = pm.dataDir
 
@@ -153,6 +160,7 @@ func (pm *ProbeManager) Start(nodes []storage.Node) ([]BrokenNode, error) {
 	pm.cmd = cmd
 	pm.port = port
 	pm.geoProxyPort = geoPort
+	pm.udpProxyPort = udpPort
 	pm.pid = cmd.Process.Pid
 	pm.running = true
 	pm.configPath = tmpPath
@@ -212,6 +220,7 @@ func (pm *ProbeManager) stopLocked() {
 	pm.cmd = nil
 	pm.port = 0
 	pm.geoProxyPort = 0
+	pm.udpProxyPort = 0
 	pm.pid = 0
 	pm.configPath = ""
 	pm.nodeTags = nil
@@ -264,6 +273,14 @@ func (pm *ProbeManager) GeoProxyPort() int {
 	return pm.geoProxyPort
 }
 
+// UDPProxyPort returns the current UDP health check socks inbound port (0 if not running).
+// Each UDP-probed node is reachable through it with its probe tag as both username and password.
+func (pm *ProbeManager) UDPProxyPort() int {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+	return pm.udpProxyPort
+}
+
 // IsRunning returns whether the probe process is alive.
 func (pm *ProbeManager) IsRunning() bool {
 	pm.mu.Lock()
@@ -377,9 +394,33 @@ func preFilterBrokenNodes(nodes []storage.Node) (valid []storage.Node, broken []
 	return
 }
 
+// PreviewProbeConfig builds the probe config Start would launch for nodes, without
+// running sing-box check or starting a process. Nodes dropped by the transport
+// pre-filter are returned as broken; nodes that only fail sing-box check still
+// appear in the preview so their outbounds can be inspected. WireGuard nodes are
+// previewed as endpoints when wgEndpoint is set.
+func PreviewProbeConfig(nodes []storage.Node, wgEndpoint bool) (*builder.SingBoxConfig, *ProbeTagMap, []BrokenNode, error) {
+	port, err := getFreePort()
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to find free port: %w", err)
+	}
+	geoPort, err := getFreePort()
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to find free geo proxy port: %w", err)
+	}
+	udpPort, err := getFreePort()
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to find free udp proxy port: %w", err)
+	}
+
+	validNodes, brokenNodes := preFilterBrokenNodes(nodes)
+	cfg, tagMap := buildProbeConfig(validNodes, port, geoPort, udpPort, wgEndpoint)
+	return cfg, tagMap, brokenNodes, nil
+}
+
 // validateProbeConfig runs `sing-box check` iteratively, removing broken nodes
 // until the config validates. Pre-filters known bad transport types first.
-func (pm *ProbeManager) validateProbeConfig(nodes []storage.Node, port int, geoPort int) ([]storage.Node, []BrokenNode, error) {
+func (pm *ProbeManager) validateProbeConfig(nodes []storage.Node, port int, geoPort int, udpPort int) ([]storage.Node, []BrokenNode, error) {
 	// Phase 1: Pre-filter nodes with known unsupported transport types (instant, no sing-box check)
 	filteredNodes, preFilterBroken := preFilterBrokenNodes(nodes)
 	if len(preFilterBroken) > 0 {
@@ -413,7 +454,7 @@ func (pm *ProbeManager) validateProbeConfig(nodes []storage.Node, port int, geoP
 			pm.validationProgress(batchStart+len(preFilterBroken), len(nodes), len(allBroken))
 		}
 
-		validBatch, brokenBatch, err := pm.validateBatch(batch, port, geoPort)
+		validBatch, brokenBatch, err := pm.validateBatch(batch, port, geoPort, udpPort)
 		if err != nil && len(validBatch) == 0 && len(brokenBatch) == 0 {
 			logger.Printf("[probe] Batch validation failed (nodes %d-%d): %v", batchStart, batchEnd, err)
 			continue
@@ -434,13 +475,14 @@ func (pm *ProbeManager) validateProbeConfig(nodes []storage.Node, port int, geoP
 }
 
 // validateBatch validates a small batch of nodes with sing-box check iteratively.
-func (pm *ProbeManager) validateBatch(nodes []storage.Node, port int, geoPort int) ([]storage.Node, []BrokenNode, error) {
+func (pm *ProbeManager) validateBatch(nodes []storage.Node, port int, geoPort int, udpPort int) ([]storage.Node, []BrokenNode, error) {
 	excluded := make(map[int]bool)
 	var brokenNodes []BrokenNode
 	maxIterations := len(nodes) + 2
 
-	outboundRe := regexp.MustCompile(`outbounds?\[(\d+)\]\.?([^:]*?):\s*(.+)`)
+	outboundRe := regexp.MustCompile(`(outbound|endpoint)s?\[(\d+)\]\.?([^:]*?):\s*(.+)`)
 	dupRe := regexp.MustCompile(`duplicate outbound/endpoint tag:\s*(.+)`)
+	wgEndpoint := kernelTakesWireGuardEndpoint(pm.singboxPath)
 
 	for iter := 0; iter < maxIterations; iter++ {
 		var validNodes []storage.Node
@@ -454,7 +496,7 @@ func (pm *ProbeManager) validateBatch(nodes []storage.Node, port int, geoPort in
 			return nil, brokenNodes, fmt.Errorf("all nodes in batch are broken")
 		}
 
-		cfg, _ := buildProbeConfig(validNodes, port, geoPort)
+		cfg, _ := buildProbeConfig(validNodes, port, geoPort, udpPort, wgEndpoint)
 		cfgJSON, err := json.MarshalIndent(cfg, "", "  ")
 		if err != nil {
 			return nil, brokenNodes, err
@@ -480,21 +522,30 @@ func (pm *ProbeManager) validateBatch(nodes []storage.Node, port int, geoPort in
 		foundNew := false
 
 		for _, match := range outboundRe.FindAllStringSubmatch(outputStr, -1) {
-			if len(match) < 4 {
+			if len(match) < 5 {
 				continue
 			}
-			idx, err := strconv.Atoi(match[1])
+			idx, err := strconv.Atoi(match[2])
 			if err != nil {
 				continue
 			}
-			// Probe config has 1 system outbound (DIRECT) at the start
-			nodeIdx := idx - 1
+			// Map the config index back to the node through its probe_N tag,
+			// WireGuard endpoints are numbered separately from outbounds
+			var tag interface{}
+			if match[1] == "endpoint" {
+				if idx < len(cfg.Endpoints) {
+					tag = cfg.Endpoints[idx]["tag"]
+				}
+			} else if idx < len(cfg.Outbounds) {
+				tag = cfg.Outbounds[idx]["tag"]
+			}
+			nodeIdx := probeNodeIndex(tag)
 			if nodeIdx < 0 || nodeIdx >= len(validNodes) {
 				continue
 			}
 			origIdx := pm.findOriginalIndex(nodes, validNodes[nodeIdx], excluded)
 			if origIdx >= 0 && !excluded[origIdx] {
-				errMsg := strings.TrimSpace(match[3])
+				errMsg := strings.TrimSpace(match[4])
 				excluded[origIdx] = true
 				brokenNodes = append(brokenNodes, BrokenNode{
 					Index: origIdx,
@@ -575,27 +626,52 @@ type ProbeTagMap struct {
 	KeyToProbe map[string]string
 }
 
+// udpHealthCheckTypes are the QUIC/UDP-native protocols whose health is checked with a
+// UDP exchange instead of the Clash API HTTP delay test, which only exercises TCP.
+var udpHealthCheckTypes = map[string]bool{
+	"hysteria":  true,
+	"hysteria2": true,
+	"tuic":      true,
+	"wireguard": true,
+}
+
+// UsesUDPHealthCheck reports whether nodes of this type are health-checked over UDP
+func UsesUDPHealthCheck(nodeType string) bool {
+	return udpHealthCheckTypes[nodeType]
+}
+
 // buildProbeConfig builds a minimal sing-box config for probing.
 // It assigns unique tags to each node to avoid sing-box "duplicate tag" errors
 // (nodes from different subscriptions often share the same advertising tag).
+// UDP-checked nodes are also reachable through a socks inbound on udpProxyPort,
+// selected by authenticating with the probe tag. WireGuard nodes become endpoints
+// when wgEndpoint is set (sing-box 1.11+).
 // Returns the config and a tag mapping for correlating results back.
-func buildProbeConfig(nodes []storage.Node, clashAPIPort int, geoProxyPort int) (*builder.SingBoxConfig, *ProbeTagMap) {
+func buildProbeConfig(nodes []storage.Node, clashAPIPort int, geoProxyPort int, udpProxyPort int, wgEndpoint bool) (*builder.SingBoxConfig, *ProbeTagMap) {
 	outbounds := []builder.Outbound{
 		{"type": "direct", "tag": "DIRECT"},
 	}
+	var endpoints []builder.Endpoint
 
 	tagMap := &ProbeTagMap{
 		ProbeToOrig: make(map[string]string, len(nodes)),
 		KeyToProbe:  make(map[string]string, len(nodes)),
 	}
 
-	var probeTags []string
+	var probeTags, udpProbeTags []string
 	for i, n := range nodes {
 		probeTag := fmt.Sprintf("probe_%d", i)
 		ob := builder.NodeToOutbound(n)
 		ob["tag"] = probeTag
-		outbounds = append(outbounds, ob)
+		if n.Type == "wireguard" && wgEndpoint {
+			endpoints = append(endpoints, builder.WireGuardEndpoint(ob))
+		} else {
+			outbounds = append(outbounds, ob)
+		}
 		probeTags = append(probeTags, probeTag)
+		if UsesUDPHealthCheck(n.Type) {
+			udpProbeTags = append(udpProbeTags, probeTag)
+		}
 
 		key := fmt.Sprintf("%s:%d", n.Server, n.ServerPort)
 		tagMap.ProbeToOrig[probeTag] = n.RoutingTag()
@@ -639,8 +715,35 @@ func buildProbeConfig(nodes []storage.Node, clashAPIPort int, geoProxyPort int)
 		}
 	}
 
+	// Socks inbound for UDP health checks, one user per UDP-checked node
+	if udpProxyPort > 0 && len(udpProbeTags) > 0 {
+		users := make([]builder.InboundUser, 0, len(udpProbeTags))
+		for _, tag := range udpProbeTags {
+			users = append(users, builder.InboundUser{Username: tag, Password: tag})
+		}
+		inbounds = append(inbounds, builder.Inbound{
+			Type:       "socks",
+			Tag:        "udp-in",
+			Listen:     "127.0.0.1",
+			ListenPort: udpProxyPort,
+			Users:      users,
+		})
+
+		if route == nil {
+			route = &builder.RouteConfig{Final: "DIRECT"}
+		}
+		for _, tag := range udpProbeTags {
+			route.Rules = append(route.Rules, builder.RouteRule{
+				"inbound":   []string{"udp-in"},
+				"auth_user": []string{tag},
+				"outbound":  tag,
+			})
+		}
+	}
+
 	return &builder.SingBoxConfig{
 		Log:       &builder.LogConfig{Level: "warn", Timestamp: true},
+		Endpoints: endpoints,
 		Inbounds:  inbounds,
 		Outbounds: outbounds,
 		Route:     route,
@@ -653,6 +756,35 @@ func buildProbeConfig(nodes []storage.Node, clashAPIPort int, geoProxyPort int)
 	}, tagMap
 }
 
+// probeNodeIndex returns N for a probe_N tag, or -1 for any other tag
+func probeNodeIndex(tag interface{}) int {
+	s, _ := tag.(string)
+	rest, ok := strings.CutPrefix(s, "probe_")
+	if !ok {
+		return -1
+	}
+	idx, err := strconv.Atoi(rest)
+	if err != nil {
+		return -1
+	}
+	return idx
+}
+
+// kernelTakesWireGuardEndpoint reports whether the sing-box binary expects WireGuard
+// in the endpoints section. An unknown version is treated as supported, like the
+// main config builder does.
+func kernelTakesWireGuardEndpoint(singboxPath string) bool {
+	output, err := exec.Command(singboxPath, "version").Output()
+	if err != nil {
+		return true
+	}
+	version, err := kernel.ParseVersion(string(output))
+	if err != nil {
+		return true
+	}
+	return kernel.SupportsWireGuardEndpoint(version)
+}
+
 // sortedNodeTags returns a sorted list of node tags.
 func sortedNodeTags(nodes []storage.Node) []string {
 	tags := make([]string, len(nodes))
@@ -0,0 +1,80 @@
+package daemon
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/xiaobei/singbox-manager/internal/storage"
+)
+
+func TestBuildProbeConfig_UDPInboundForQUICNodes(t *testing.T) {
+	nodes := []storage.Node{
+		{Tag: "vmess", Type: "vmess", Server: "10.0.0.1", ServerPort: 443},
+		{Tag: "hy2", Type: "hysteria2", Server: "10.0.0.2", ServerPort: 443, Extra: map[string]interface{}{"password": "pw"}},
+	}
+
+	cfg, tagMap := buildProbeConfig(nodes, 9090, 9091, 9092, true)
+
+	var udpUsers []string
+	for _, in := range cfg.Inbounds {
+		if in.Tag != "udp-in" {
+			continue
+		}
+		if in.Type != "socks" || in.ListenPort != 9092 {
+			t.Fatalf("udp inbound mismatch: got %+v", in)
+		}
+		for _, user := range in.Users {
+			udpUsers = append(udpUsers, user.Username)
+		}
+	}
+	hyTag := tagMap.KeyToProbe["10.0.0.2:443"]
+	if want := []string{hyTag}; !reflect.DeepEqual(udpUsers, want) {
+		t.Fatalf("udp inbound users mismatch: got %v, want %v", udpUsers, want)
+	}
+
+	var routed bool
+	for _, rule := range cfg.Route.Rules {
+		if users, ok := rule["auth_user"].([]string); ok {
+			if !reflect.DeepEqual(users, []string{hyTag}) || rule["outbound"] != hyTag {
+				t.Fatalf("auth_user rule mismatch: got %v", rule)
+			}
+			routed = true
+		}
+	}
+	if !routed {
+		t.Fatalf("expected an auth_user rule routing the hysteria2 node, got %v", cfg.Route.Rules)
+	}
+
+	cfg, _ = buildProbeConfig(nodes[:1], 9090, 9091, 9092, true)
+	for _, in := range cfg.Inbounds {
+		if in.Tag == "udp-in" {
+			t.Fatalf("expected no udp inbound without QUIC nodes")
+		}
+	}
+}
+
+func TestBuildProbeConfig_WireGuardEndpoint(t *testing.T) {
+	nodes := []storage.Node{
+		{Tag: "ss", Type: "shadowsocks", Server: "10.0.0.1", ServerPort: 8388},
+		{Tag: "wg", Type: "wireguard", Server: "10.0.0.2", ServerPort: 51820},
+	}
+
+	cfg, tagMap := buildProbeConfig(nodes, 9090, 9091, 9092, true)
+	wgTag := tagMap.KeyToProbe["10.0.0.2:51820"]
+	if len(cfg.Endpoints) != 1 || cfg.Endpoints[0]["tag"] != wgTag {
+		t.Fatalf("endpoints mismatch: got %v, want %s", cfg.Endpoints, wgTag)
+	}
+	for _, ob := range cfg.Outbounds {
+		if ob["tag"] == wgTag {
+			t.Fatalf("wireguard node should not be an outbound on endpoint-capable kernels")
+		}
+	}
+	if got := probeNodeIndex(cfg.Endpoints[0]["tag"]); got != 1 {
+		t.Fatalf("probe index mismatch: got %d, want 1", got)
+	}
+
+	legacy, _ := buildProbeConfig(nodes, 9090, 9091, 9092, false)
+	if len(legacy.Endpoints) != 0 {
+		t.Fatalf("older kernels should keep the wireguard outbound, got %v", legacy.Endpoints)
+	}
+}
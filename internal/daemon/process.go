@@ -18,6 +18,39 @@ import (
 
 const defaultMaxProcessLogs = 10000
 
+const (
+	// crashLoopThreshold Fast exits within crashLoopWindow that trigger safe mode
+	crashLoopThreshold = 3
+	crashLoopWindow    = 2 * time.Minute
+	// crashFastExitUptime Exits sooner than this after start count as crashes
+	crashFastExitUptime = 30 * time.Second
+)
+
+// crashTracker Records fast exits and reports when a crash loop is detected
+type crashTracker struct {
+	threshold int
+	window    time.Duration
+	exits     []time.Time
+}
+
+// recordExit Record a crash at now and report whether the threshold is reached
+func (t *crashTracker) recordExit(now time.Time) bool {
+	cutoff := now.Add(-t.window)
+	kept := t.exits[:0]
+	for _, ts := range t.exits {
+		if ts.After(cutoff) {
+			kept = append(kept, ts)
+		}
+	}
+	t.exits = append(kept, now)
+	return len(t.exits) >= t.threshold
+}
+
+// reset Forget recorded crashes
+func (t *crashTracker) reset() {
+	t.exits = nil
+}
+
 // ProcessManager Process manager
 type ProcessManager struct {
 	singboxPath string
@@ -30,6 +63,9 @@ type ProcessManager struct {
 	pid         int // Save PID (supports process recovery even if cmd is nil)
 	logs        []string
 	maxLogs     int
+	crashes     crashTracker
+	safeMode    bool   // Set after repeated fast crashes; Start refuses until cleared
+	lastStderr  string // Last stderr line of the most recent process
 }
 
 // NewProcessManager Create process manager
@@ -41,6 +77,10 @@ func NewProcessManager(singboxPath, configPath, dataDir string) *ProcessManager
 		pidFile:     filepath.Join(dataDir, "singbox.pid"),
 		maxLogs:     defaultMaxProcessLogs,
 		logs:        make([]string, 0),
+		crashes: crashTracker{
+			threshold: crashLoopThreshold,
+			window:    crashLoopWindow,
+		},
 	}
 
 	// Try to recover existing sing-box process on startup
@@ -336,6 +376,10 @@ func (pm *ProcessManager) Start() error {
 		logger.Printf("Cleared stale sing-box running state before start")
 	}
 
+	if pm.safeMode {
+		return fmt.Errorf("sing-box is in safe mode after repeated crashes, last error: %s", pm.lastStderr)
+	}
+
 	// Check if sing-box exists
 	if _, err := os.Stat(pm.singboxPath); os.IsNotExist(err) {
 		return fmt.Errorf("sing-box does not exist: %s", pm.singboxPath)
@@ -366,9 +410,11 @@ func (pm *ProcessManager) Start() error {
 
 	startedCmd := pm.cmd
 	startedPID := pm.cmd.Process.Pid
+	startedAt := time.Now()
 
 	pm.running = true
 	pm.pid = startedPID
+	pm.lastStderr = ""
 
 	// Write PID file
 	if err := os.WriteFile(pm.pidFile, []byte(strconv.Itoa(pm.pid)), 0644); err != nil {
@@ -384,7 +430,10 @@ func (pm *ProcessManager) Start() error {
 	}
 
 	// Async read logs
+	var readers sync.WaitGroup
+	readers.Add(2)
 	go func() {
+		defer readers.Done()
 		scanner := bufio.NewScanner(stdout)
 		for scanner.Scan() {
 			line := scanner.Text()
@@ -397,10 +446,16 @@ func (pm *ProcessManager) Start() error {
 	}()
 
 	go func() {
+		defer readers.Done()
 		scanner := bufio.NewScanner(stderr)
 		for scanner.Scan() {
 			line := scanner.Text()
 			pm.addLog(line)
+			if strings.TrimSpace(line) != "" {
+				pm.mu.Lock()
+				pm.lastStderr = line
+				pm.mu.Unlock()
+			}
 			// Also write to log file
 			if singboxLogger != nil {
 				singboxLogger.WriteRaw(line)
@@ -410,6 +465,8 @@ func (pm *ProcessManager) Start() error {
 
 	// Monitor process exit
 	go func(cmd *exec.Cmd, pid int) {
+		// Drain output first so the last stderr line is captured before Wait closes the pipes
+		readers.Wait()
 		err := cmd.Wait()
 
 		pm.mu.Lock()
@@ -421,8 +478,20 @@ func (pm *ProcessManager) Start() error {
 		pm.running = false
 		pm.pid = 0
 		pm.cmd = nil
+		enteredSafeMode := false
+		if now := time.Now(); now.Sub(startedAt) < crashFastExitUptime && !pm.safeMode {
+			if pm.crashes.recordExit(now) {
+				pm.safeMode = true
+				enteredSafeMode = true
+			}
+		}
+		lastStderr := pm.lastStderr
 		pm.mu.Unlock()
 
+		if enteredSafeMode {
+			logger.Printf("sing-box crashed %d times within %s, entering safe mode, last error: %s", crashLoopThreshold, crashLoopWindow, lastStderr)
+		}
+
 		os.Remove(pm.pidFile)
 		if err != nil {
 			logger.Printf("sing-box process exited, PID: %d, err: %v", pid, err)
@@ -482,6 +551,24 @@ func (pm *ProcessManager) Restart() error {
 	return pm.Start()
 }
 
+// SafeMode Report whether crash-loop safe mode is active and the last stderr line
+func (pm *ProcessManager) SafeMode() (bool, string) {
+	pm.mu.RLock()
+	defer pm.mu.RUnlock()
+	return pm.safeMode, pm.lastStderr
+}
+
+// ClearSafeMode Leave safe mode and forget recorded crashes (explicit user start)
+func (pm *ProcessManager) ClearSafeMode() {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+	if pm.safeMode {
+		logger.Printf("sing-box safe mode cleared")
+	}
+	pm.safeMode = false
+	pm.crashes.reset()
+}
+
 // Reload Hot reload config
 func (pm *ProcessManager) Reload() error {
 	pm.mu.RLock()
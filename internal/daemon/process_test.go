@@ -0,0 +1,89 @@
+package daemon
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestProcessManager_RepeatedFastExitsEnterSafeMode(t *testing.T) {
+	dir := t.TempDir()
+	binPath := filepath.Join(dir, "sing-box")
+	script := "#!/bin/sh\necho 'FATAL[0000] decode config: unknown field' >&2\nexit 1\n"
+	if err := os.WriteFile(binPath, []byte(script), 0755); err != nil {
+		t.Fatalf("write fake sing-box: %v", err)
+	}
+	configPath := filepath.Join(dir, "config.json")
+	if err := os.WriteFile(configPath, []byte("{}"), 0644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+
+	pm := NewProcessManager(binPath, configPath, dir)
+
+	for i := 0; i < crashLoopThreshold; i++ {
+		if err := pm.Start(); err != nil {
+			t.Fatalf("start #%d: %v", i+1, err)
+		}
+		waitForExit(t, pm)
+	}
+
+	safeMode, lastErr := pm.SafeMode()
+	if !safeMode {
+		t.Fatalf("expected safe mode after %d fast exits", crashLoopThreshold)
+	}
+	if !strings.Contains(lastErr, "unknown field") {
+		t.Fatalf("last stderr mismatch: got %q", lastErr)
+	}
+
+	err := pm.Start()
+	if err == nil || !strings.Contains(err.Error(), "safe mode") {
+		t.Fatalf("expected start to be refused in safe mode, got %v", err)
+	}
+
+	pm.ClearSafeMode()
+	if safeMode, _ := pm.SafeMode(); safeMode {
+		t.Fatalf("expected safe mode to be cleared")
+	}
+	if err := pm.Start(); err != nil {
+		t.Fatalf("start after clearing safe mode: %v", err)
+	}
+	waitForExit(t, pm)
+}
+
+func TestCrashTracker_DropsExitsOutsideWindow(t *testing.T) {
+	tracker := crashTracker{
+		threshold: 3,
+		window:    time.Minute,
+	}
+	base := time.Now()
+
+	if tracker.recordExit(base) || tracker.recordExit(base.Add(10*time.Second)) {
+		t.Fatalf("threshold reached too early")
+	}
+	if tracker.recordExit(base.Add(2 * time.Minute)) {
+		t.Fatalf("expected old exits to fall out of the window")
+	}
+	if tracker.recordExit(base.Add(2*time.Minute + time.Second)) {
+		t.Fatalf("threshold reached with only 2 exits in window")
+	}
+	if !tracker.recordExit(base.Add(2*time.Minute + 2*time.Second)) {
+		t.Fatalf("expected threshold with 3 exits in window")
+	}
+}
+
+func waitForExit(t *testing.T, pm *ProcessManager) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		pm.mu.RLock()
+		running := pm.running
+		pm.mu.RUnlock()
+		if !running {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("fake sing-box did not exit in time")
+}
@@ -0,0 +1,136 @@
+package kernel
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// Checksum verification statuses reported in DownloadProgress.Checksum
+const (
+	ChecksumVerified    = "verified"
+	ChecksumMismatch    = "mismatch"
+	ChecksumUnavailable = "unavailable"
+)
+
+// findChecksumAsset finds the checksum file published alongside an asset in a release.
+// Per-asset files (<asset>.sha256, <asset>.sha256sum) take precedence over a shared checksums file.
+func findChecksumAsset(release *GithubRelease, assetName string) *GithubAsset {
+	if release == nil {
+		return nil
+	}
+	for _, suffix := range []string{".sha256", ".sha256sum"} {
+		for i := range release.Assets {
+			if release.Assets[i].Name == assetName+suffix {
+				return &release.Assets[i]
+			}
+		}
+	}
+	for i := range release.Assets {
+		name := strings.ToLower(release.Assets[i].Name)
+		if strings.Contains(name, "checksum") || strings.Contains(name, "sha256sum") {
+			return &release.Assets[i]
+		}
+	}
+	return nil
+}
+
+// parseChecksumFile extracts the SHA256 hash for assetName from a checksum file.
+// Accepts "sha256sum" style lines ("<hash>  <name>" or "<hash> *<name>") and
+// single-hash files containing only the digest.
+func parseChecksumFile(data []byte, assetName string) (string, error) {
+	var single string
+	lines := 0
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		lines++
+		if len(fields) == 1 {
+			single = fields[0]
+			continue
+		}
+		name := strings.TrimPrefix(fields[len(fields)-1], "*")
+		if name == assetName || strings.HasSuffix(name, "/"+assetName) {
+			return normalizeSHA256(fields[0])
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+	if lines == 1 && single != "" {
+		return normalizeSHA256(single)
+	}
+	return "", fmt.Errorf("checksum for %s not found", assetName)
+}
+
+// normalizeSHA256 validates and lower-cases a hex SHA256 digest
+func normalizeSHA256(hash string) (string, error) {
+	hash = strings.ToLower(strings.TrimSpace(hash))
+	if len(hash) != sha256.Size*2 {
+		return "", fmt.Errorf("invalid sha256 digest: %q", hash)
+	}
+	if _, err := hex.DecodeString(hash); err != nil {
+		return "", fmt.Errorf("invalid sha256 digest: %q", hash)
+	}
+	return hash, nil
+}
+
+// verifyFileSHA256 checks that the file at path has the expected SHA256 digest
+func verifyFileSHA256(path, expected string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return err
+	}
+	actual := hex.EncodeToString(h.Sum(nil))
+	if actual != strings.ToLower(expected) {
+		return fmt.Errorf("checksum mismatch: expected %s, got %s", expected, actual)
+	}
+	return nil
+}
+
+// fetchExpectedChecksum downloads the checksum file and returns the digest for assetName
+func (m *Manager) fetchExpectedChecksum(ctx context.Context, checksumAsset *GithubAsset, assetName string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, m.buildDownloadURL(checksumAsset.BrowserDownloadURL), nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("checksum download failed, HTTP status code: %d", resp.StatusCode)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return "", err
+	}
+	return parseChecksumFile(data, assetName)
+}
+
+// setChecksumStatus records the checksum verification status in the progress payload
+func (m *Manager) setChecksumStatus(status string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	progress := *m.progress
+	progress.Checksum = status
+	m.progress = &progress
+}
@@ -0,0 +1,54 @@
+package kernel
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestVerifyFileSHA256(t *testing.T) {
+	content := []byte("sing-box archive contents")
+	sum := sha256.Sum256(content)
+	good := hex.EncodeToString(sum[:])
+
+	path := filepath.Join(t.TempDir(), "sing-box-1.12.0-linux-amd64.tar.gz")
+	if err := os.WriteFile(path, content, 0644); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+
+	checksums := []byte(good + "  sing-box-1.12.0-linux-amd64.tar.gz\n" +
+		"0000000000000000000000000000000000000000000000000000000000000000  sing-box-1.12.0-darwin-arm64.tar.gz\n")
+	expected, err := parseChecksumFile(checksums, "sing-box-1.12.0-linux-amd64.tar.gz")
+	if err != nil {
+		t.Fatalf("parse checksum file: %v", err)
+	}
+	if err := verifyFileSHA256(path, expected); err != nil {
+		t.Fatalf("expected good checksum to verify, got %v", err)
+	}
+
+	bad, err := parseChecksumFile(checksums, "sing-box-1.12.0-darwin-arm64.tar.gz")
+	if err != nil {
+		t.Fatalf("parse checksum file: %v", err)
+	}
+	if err := verifyFileSHA256(path, bad); err == nil {
+		t.Fatalf("expected bad checksum to fail verification")
+	}
+}
+
+func TestFindChecksumAsset(t *testing.T) {
+	release := &GithubRelease{Assets: []GithubAsset{
+		{Name: "sing-box-1.12.0-linux-amd64.tar.gz"},
+		{Name: "checksums.txt"},
+		{Name: "sing-box-1.12.0-linux-amd64.tar.gz.sha256"},
+	}}
+	got := findChecksumAsset(release, "sing-box-1.12.0-linux-amd64.tar.gz")
+	if got == nil || got.Name != "sing-box-1.12.0-linux-amd64.tar.gz.sha256" {
+		t.Fatalf("expected per-asset checksum file, got %+v", got)
+	}
+
+	if got := findChecksumAsset(&GithubRelease{Assets: release.Assets[:1]}, release.Assets[0].Name); got != nil {
+		t.Fatalf("expected no checksum asset, got %+v", got)
+	}
+}
@@ -0,0 +1,260 @@
+package kernel
+
+import (
+	"archive/zip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/xiaobei/singbox-manager/internal/storage"
+)
+
+// DashboardURLs maps the supported Clash dashboards to their gh-pages bundle archives
+var DashboardURLs = map[string]string{
+	"yacd":       "https://github.com/MetaCubeX/Yacd-meta/archive/refs/heads/gh-pages.zip",
+	"metacubexd": "https://github.com/MetaCubeX/metacubexd/archive/refs/heads/gh-pages.zip",
+}
+
+// DashboardNames returns the supported dashboard names in order
+func DashboardNames() []string {
+	names := make([]string, 0, len(DashboardURLs))
+	for name := range DashboardURLs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// DashboardManager downloads Clash dashboard bundles into the data directory so
+// sing-box can serve them through the Clash API external_ui.
+type DashboardManager struct {
+	dataDir     string
+	getSettings func() *storage.Settings
+	urls        map[string]string
+	mu          sync.RWMutex
+	progress    *DownloadProgress
+	installing  bool
+}
+
+// NewDashboardManager creates a dashboard manager
+func NewDashboardManager(dataDir string, getSettings func() *storage.Settings) *DashboardManager {
+	return &DashboardManager{
+		dataDir:     dataDir,
+		getSettings: getSettings,
+		urls:        DashboardURLs,
+		progress:    &DownloadProgress{Status: "idle"},
+	}
+}
+
+// Dir returns the directory a dashboard is installed to
+func (d *DashboardManager) Dir(name string) string {
+	return filepath.Join(d.dataDir, "ui", name)
+}
+
+// StartInstall downloads and unpacks a dashboard in the background. onInstalled runs
+// with the install directory once the bundle is in place; its error fails the install.
+func (d *DashboardManager) StartInstall(name string, onInstalled func(dir string) error) error {
+	url, ok := d.urls[name]
+	if !ok {
+		return fmt.Errorf("unknown dashboard %q, supported: %s", name, strings.Join(DashboardNames(), ", "))
+	}
+
+	d.mu.Lock()
+	if d.installing {
+		d.mu.Unlock()
+		return fmt.Errorf("a dashboard install is already in progress")
+	}
+	d.installing = true
+	d.progress = &DownloadProgress{Status: "preparing", Message: "Preparing download..."}
+	d.mu.Unlock()
+
+	go d.install(name, url, onInstalled)
+	return nil
+}
+
+// GetProgress returns install progress
+func (d *DashboardManager) GetProgress() *DownloadProgress {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.progress
+}
+
+func (d *DashboardManager) setProgress(progress *DownloadProgress) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.progress = progress
+}
+
+func (d *DashboardManager) setInstallComplete(status, message string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.installing = false
+	d.progress = &DownloadProgress{Status: status, Progress: 100, Message: message}
+}
+
+func (d *DashboardManager) install(name, url string, onInstalled func(dir string) error) {
+	defer func() {
+		if r := recover(); r != nil {
+			d.setInstallComplete("error", fmt.Sprintf("Error occurred during install: %v", r))
+		}
+	}()
+
+	tmpDir, err := os.MkdirTemp("", "singbox-dashboard")
+	if err != nil {
+		d.setInstallComplete("error", fmt.Sprintf("Failed to create temporary directory: %v", err))
+		return
+	}
+	defer os.RemoveAll(tmpDir)
+
+	if proxy := d.getSettings().GithubProxy; proxy != "" {
+		url = proxy + url
+	}
+	archive := filepath.Join(tmpDir, name+".zip")
+	d.setProgress(&DownloadProgress{Status: "downloading", Message: "Downloading..."})
+	if err := downloadToFile(context.Background(), url, archive, 0, d.setProgress); err != nil {
+		d.setInstallComplete("error", fmt.Sprintf("Download failed: %v", err))
+		return
+	}
+
+	d.setProgress(&DownloadProgress{Status: "extracting", Progress: 80, Message: "Extracting..."})
+	staged := filepath.Join(tmpDir, "bundle")
+	if err := extractBundle(archive, staged); err != nil {
+		d.setInstallComplete("error", fmt.Sprintf("Extraction failed: %v", err))
+		return
+	}
+
+	d.setProgress(&DownloadProgress{Status: "installing", Progress: 90, Message: "Installing..."})
+	dir := d.Dir(name)
+	if err := replaceDir(staged, dir); err != nil {
+		d.setInstallComplete("error", fmt.Sprintf("Installation failed: %v", err))
+		return
+	}
+	if onInstalled != nil {
+		if err := onInstalled(dir); err != nil {
+			d.setInstallComplete("error", fmt.Sprintf("Installed to %s but failed to apply: %v", dir, err))
+			return
+		}
+	}
+
+	d.setInstallComplete("completed", fmt.Sprintf("%s installed to %s", name, dir))
+}
+
+// extractBundle unpacks a dashboard zip into destDir, dropping the single top-level
+// directory GitHub archives wrap their content in.
+func extractBundle(archivePath, destDir string) error {
+	r, err := zip.OpenReader(archivePath)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	prefix := commonTopDir(r.File)
+	extracted := 0
+	for _, f := range r.File {
+		name := strings.TrimPrefix(f.Name, prefix)
+		if name == "" || f.FileInfo().IsDir() {
+			continue
+		}
+		target := filepath.Join(destDir, filepath.FromSlash(name))
+		if !strings.HasPrefix(target, filepath.Clean(destDir)+string(os.PathSeparator)) {
+			return fmt.Errorf("illegal path in archive: %s", f.Name)
+		}
+		if err := extractZipFile(f, target); err != nil {
+			return err
+		}
+		extracted++
+	}
+	if extracted == 0 {
+		return errors.New("archive contains no files")
+	}
+	if _, err := os.Stat(filepath.Join(destDir, "index.html")); err != nil {
+		return errors.New("archive has no index.html")
+	}
+	return nil
+}
+
+// commonTopDir returns "dir/" when every entry sits under the same top-level directory
+func commonTopDir(files []*zip.File) string {
+	prefix := ""
+	for _, f := range files {
+		idx := strings.Index(f.Name, "/")
+		if idx == -1 {
+			return ""
+		}
+		top := f.Name[:idx+1]
+		if prefix == "" {
+			prefix = top
+		} else if top != prefix {
+			return ""
+		}
+	}
+	return prefix
+}
+
+func extractZipFile(f *zip.File, target string) error {
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	out, err := os.Create(target)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, rc); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// replaceDir moves src to dest, replacing an existing installation
+func replaceDir(src, dest string) error {
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return fmt.Errorf("Failed to create directory: %w", err)
+	}
+	if err := os.RemoveAll(dest); err != nil {
+		return fmt.Errorf("Failed to remove old version: %w", err)
+	}
+	if err := os.Rename(src, dest); err == nil {
+		return nil
+	}
+	// src may be on another filesystem than the data directory
+	return filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dest, rel)
+		if info.IsDir() {
+			return os.MkdirAll(target, 0755)
+		}
+		in, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer in.Close()
+		out, err := os.Create(target)
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(out, in); err != nil {
+			out.Close()
+			return err
+		}
+		return out.Close()
+	})
+}
@@ -0,0 +1,98 @@
+package kernel
+
+import (
+	"archive/zip"
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/xiaobei/singbox-manager/internal/storage"
+)
+
+func dashboardZip(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range files {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatalf("create zip entry: %v", err)
+		}
+		w.Write([]byte(content))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("close zip: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func waitForDashboardInstall(t *testing.T, d *DashboardManager) *DownloadProgress {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if p := d.GetProgress(); p.Status == "completed" || p.Status == "error" {
+			return p
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("install did not finish, last progress: %+v", d.GetProgress())
+	return nil
+}
+
+func TestDashboardManager_Install(t *testing.T) {
+	bundle := dashboardZip(t, map[string]string{
+		"Yacd-meta-gh-pages/index.html":      "<html></html>",
+		"Yacd-meta-gh-pages/assets/index.js": "console.log(1)",
+	})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(bundle)
+	}))
+	defer srv.Close()
+
+	d := NewDashboardManager(t.TempDir(), storage.DefaultSettings)
+	d.urls = map[string]string{"yacd": srv.URL + "/gh-pages.zip"}
+
+	if err := d.StartInstall("unknown", nil); err == nil {
+		t.Fatal("expected an error for an unknown dashboard")
+	}
+
+	// A previous install is replaced rather than merged
+	stale := filepath.Join(d.Dir("yacd"), "stale.js")
+	os.MkdirAll(filepath.Dir(stale), 0755)
+	os.WriteFile(stale, []byte("old"), 0644)
+
+	var installedDir string
+	if err := d.StartInstall("yacd", func(dir string) error {
+		installedDir = dir
+		return nil
+	}); err != nil {
+		t.Fatalf("start install: %v", err)
+	}
+	if p := waitForDashboardInstall(t, d); p.Status != "completed" {
+		t.Fatalf("install status mismatch: got %q (%s), want completed", p.Status, p.Message)
+	}
+
+	if installedDir != d.Dir("yacd") {
+		t.Fatalf("installed dir mismatch: got %q, want %q", installedDir, d.Dir("yacd"))
+	}
+	for _, name := range []string{"index.html", filepath.Join("assets", "index.js")} {
+		if _, err := os.Stat(filepath.Join(installedDir, name)); err != nil {
+			t.Fatalf("expected %s in the installed bundle: %v", name, err)
+		}
+	}
+	if _, err := os.Stat(stale); !os.IsNotExist(err) {
+		t.Fatalf("expected the previous install to be removed, stat err: %v", err)
+	}
+}
+
+func TestExtractBundle_RejectsMissingIndex(t *testing.T) {
+	archive := filepath.Join(t.TempDir(), "bundle.zip")
+	os.WriteFile(archive, dashboardZip(t, map[string]string{"dist/app.js": "x"}), 0644)
+	if err := extractBundle(archive, filepath.Join(t.TempDir(), "out")); err == nil {
+		t.Fatal("expected an error for a bundle without index.html")
+	}
+}
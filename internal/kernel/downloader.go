@@ -4,6 +4,8 @@ import (
 	"archive/tar"
 	"archive/zip"
 	"compress/gzip"
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -11,10 +13,11 @@ import (
 	"path/filepath"
 	"runtime"
 	"strings"
+	"time"
 )
 
 // downloadAndInstall downloads and installs kernel
-func (m *Manager) downloadAndInstall(version string) {
+func (m *Manager) downloadAndInstall(ctx context.Context, version string, confirmed bool) {
 	defer func() {
 		if r := recover(); r != nil {
 			m.setDownloadComplete("error", fmt.Sprintf("Error occurred during download: %v", r))
@@ -23,7 +26,10 @@ func (m *Manager) downloadAndInstall(version string) {
 
 	// 1. Fetch releases
 	m.updateProgress("preparing", 0, "Getting version information...", 0, 0)
-	releases, err := m.FetchReleases()
+	releases, err := m.fetchReleases(ctx)
+	if m.downloadCancelled(ctx) {
+		return
+	}
 	if err != nil {
 		m.setDownloadComplete("error", fmt.Sprintf("Failed to get version information: %v", err))
 		return
@@ -49,12 +55,44 @@ func (m *Manager) downloadAndInstall(version string) {
 	tmpFile := filepath.Join(tmpDir, asset.Name)
 
 	m.updateProgress("downloading", 0, "Downloading...", 0, asset.Size)
-	if err := m.downloadFile(downloadURL, tmpFile, asset.Size); err != nil {
+	if err := m.downloadFile(ctx, downloadURL, tmpFile, asset.Size); err != nil {
+		if errors.Is(err, context.Canceled) {
+			m.setDownloadComplete("cancelled", "Download cancelled")
+			return
+		}
 		m.setDownloadComplete("error", fmt.Sprintf("Download failed: %v", err))
 		return
 	}
+	if m.downloadCancelled(ctx) {
+		return
+	}
+
+	// 5. Verify checksum before touching the installed binary
+	if checksumAsset := findChecksumAsset(findRelease(releases, version), asset.Name); checksumAsset != nil {
+		m.updateProgress("verifying", 78, "Verifying checksum...", asset.Size, asset.Size)
+		expected, err := m.fetchExpectedChecksum(ctx, checksumAsset, asset.Name)
+		if m.downloadCancelled(ctx) {
+			return
+		}
+		if err != nil {
+			m.setChecksumStatus(ChecksumUnavailable)
+			m.setDownloadComplete("error", fmt.Sprintf("Failed to get checksum: %v", err))
+			return
+		}
+		if err := verifyFileSHA256(tmpFile, expected); err != nil {
+			m.setChecksumStatus(ChecksumMismatch)
+			m.setDownloadComplete("error", fmt.Sprintf("Checksum verification failed: %v", err))
+			return
+		}
+		m.setChecksumStatus(ChecksumVerified)
+	} else {
+		m.setChecksumStatus(ChecksumUnavailable)
+	}
 
-	// 5. Extract file
+	// 6. Extract file
+	if m.downloadCancelled(ctx) {
+		return
+	}
 	m.updateProgress("extracting", 80, "Extracting...", asset.Size, asset.Size)
 	binaryPath, err := m.extractArchive(tmpFile, tmpDir)
 	if err != nil {
@@ -62,20 +100,71 @@ func (m *Manager) downloadAndInstall(version string) {
 		return
 	}
 
-	// 6. Install to target path
+	// 7. Check which nodes the new kernel would reject before replacing the installed one
+	if !confirmed {
+		if impact, err := m.checkBeforeInstall(binaryPath); err != nil {
+			m.setDownloadNeedsConfirm(fmt.Sprintf("Could not check nodes against sing-box %s: %v", version, err), nil)
+			return
+		} else if len(impact) > 0 {
+			m.setDownloadNeedsConfirm(fmt.Sprintf("sing-box %s would reject %d node(s)", version, len(impact)), impact)
+			return
+		}
+	}
+
+	// 8. Install to target path, the last point a cancel can still stop
+	if m.downloadCancelled(ctx) {
+		return
+	}
 	m.updateProgress("installing", 90, "Installing...", asset.Size, asset.Size)
 	if err := m.installBinary(binaryPath); err != nil {
 		m.setDownloadComplete("error", fmt.Sprintf("Installation failed: %v", err))
 		return
 	}
 
-	// 7. Completed
+	// 9. Completed
 	m.setDownloadComplete("completed", fmt.Sprintf("sing-box %s installed successfully", version))
 }
 
-// downloadFile downloads a file
-func (m *Manager) downloadFile(url, dest string, totalSize int64) error {
-	resp, err := http.Get(url)
+// downloadCancelled reports whether ctx was cancelled, marking the download as
+// cancelled if so
+func (m *Manager) downloadCancelled(ctx context.Context) bool {
+	if ctx.Err() == nil {
+		return false
+	}
+	m.setDownloadComplete("cancelled", "Download cancelled")
+	return true
+}
+
+// checkBeforeInstall runs the install check, if any, against the extracted binary
+func (m *Manager) checkBeforeInstall(binaryPath string) ([]InstallImpact, error) {
+	m.mu.RLock()
+	check := m.installCheck
+	m.mu.RUnlock()
+	if check == nil {
+		return nil, nil
+	}
+
+	m.updateProgress("checking", 85, "Checking nodes against the new kernel...", 0, 0)
+	if err := os.Chmod(binaryPath, 0755); err != nil {
+		return nil, err
+	}
+	return check(binaryPath)
+}
+
+// downloadFile downloads a file, removing the partial file if the download fails or is cancelled
+func (m *Manager) downloadFile(ctx context.Context, url, dest string, totalSize int64) error {
+	return downloadToFile(ctx, url, dest, totalSize, m.setProgress)
+}
+
+// downloadToFile downloads url to dest, reporting progress over the first 80% of the
+// range. totalSize falls back to the response Content-Length when zero. The partial
+// file is removed if the download fails or is cancelled.
+func downloadToFile(ctx context.Context, url, dest string, totalSize int64, report func(*DownloadProgress)) (err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
 		return err
 	}
@@ -84,40 +173,77 @@ func (m *Manager) downloadFile(url, dest string, totalSize int64) error {
 	if resp.StatusCode != http.StatusOK {
 		return fmt.Errorf("Download failed, HTTP status code: %d", resp.StatusCode)
 	}
+	if totalSize <= 0 {
+		totalSize = resp.ContentLength
+	}
 
 	out, err := os.Create(dest)
 	if err != nil {
 		return err
 	}
-	defer out.Close()
+	defer func() {
+		out.Close()
+		if err != nil {
+			os.Remove(dest)
+		}
+	}()
 
 	var downloaded int64
 	buffer := make([]byte, 32*1024)
+	start := time.Now()
 
 	for {
-		n, err := resp.Body.Read(buffer)
+		n, readErr := resp.Body.Read(buffer)
 		if n > 0 {
-			_, writeErr := out.Write(buffer[:n])
-			if writeErr != nil {
+			if _, writeErr := out.Write(buffer[:n]); writeErr != nil {
 				return writeErr
 			}
 			downloaded += int64(n)
 
 			// Update progress
-			progress := float64(downloaded) / float64(totalSize) * 80 // Download phase occupies 80%
-			m.updateProgress("downloading", progress, fmt.Sprintf("Downloading %.1f%%", progress/0.8), downloaded, totalSize)
+			progress := 0.0
+			message := fmt.Sprintf("Downloading %.1f MB", float64(downloaded)/(1<<20))
+			if totalSize > 0 {
+				progress = float64(downloaded) / float64(totalSize) * 80 // Download phase occupies 80%
+				message = fmt.Sprintf("Downloading %.1f%%", progress/0.8)
+			}
+			speed, eta := downloadRate(downloaded, totalSize, time.Since(start))
+			report(&DownloadProgress{
+				Status:     "downloading",
+				Progress:   progress,
+				Message:    message,
+				Downloaded: downloaded,
+				Total:      totalSize,
+				SpeedBps:   speed,
+				ETASeconds: eta,
+			})
 		}
-		if err == io.EOF {
+		if readErr == io.EOF {
 			break
 		}
-		if err != nil {
-			return err
+		if readErr != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return readErr
 		}
 	}
 
 	return nil
 }
 
+// downloadRate returns the average speed in bytes per second and the estimated seconds remaining
+func downloadRate(downloaded, total int64, elapsed time.Duration) (speedBps, etaSeconds int64) {
+	if elapsed <= 0 || downloaded <= 0 {
+		return 0, 0
+	}
+	speedBps = int64(float64(downloaded) / elapsed.Seconds())
+	if speedBps > 0 && total > downloaded {
+		etaSeconds = (total - downloaded + speedBps - 1) / speedBps
+	}
+	return speedBps, etaSeconds
+}
+
 // extractArchive extracts archive
 func (m *Manager) extractArchive(archivePath, destDir string) (string, error) {
 	if strings.HasSuffix(archivePath, ".tar.gz") || strings.HasSuffix(archivePath, ".tgz") {
@@ -0,0 +1,105 @@
+package kernel
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/xiaobei/singbox-manager/internal/storage"
+)
+
+func TestDownloadFile_CancelRemovesPartialFile(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Length", "1048576")
+		w.WriteHeader(http.StatusOK)
+		w.Write(make([]byte, 1024))
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	defer srv.Close()
+
+	m := NewManager(t.TempDir(), storage.DefaultSettings)
+	dest := filepath.Join(t.TempDir(), "sing-box.tar.gz")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- m.downloadFile(ctx, srv.URL, dest, 1048576)
+	}()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for m.GetProgress().Downloaded == 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("download did not start")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	cancel()
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("expected context.Canceled, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("download did not stop after cancellation")
+	}
+
+	if _, err := os.Stat(dest); !os.IsNotExist(err) {
+		t.Fatalf("expected partial file to be removed, stat err: %v", err)
+	}
+}
+
+func TestDownloadRate(t *testing.T) {
+	speed, eta := downloadRate(1000, 5000, 2*time.Second)
+	if speed != 500 {
+		t.Fatalf("speed mismatch: got %d, want 500", speed)
+	}
+	if eta != 8 {
+		t.Fatalf("eta mismatch: got %d, want 8", eta)
+	}
+}
+
+func TestDownloadAndInstall_CancelDuringReleaseLookup(t *testing.T) {
+	requested := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(requested)
+		<-r.Context().Done()
+	}))
+	defer srv.Close()
+
+	settings := storage.DefaultSettings()
+	settings.GithubProxy = srv.URL + "/"
+	m := NewManager(t.TempDir(), func() *storage.Settings { return settings })
+	if err := m.StartDownload("v1.12.0", true); err != nil {
+		t.Fatalf("start download: %v", err)
+	}
+
+	select {
+	case <-requested:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("release lookup did not start")
+	}
+	if err := m.CancelDownload(); err != nil {
+		t.Fatalf("cancel download: %v", err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for m.IsDownloading() {
+		if time.Now().After(deadline) {
+			t.Fatalf("release lookup did not stop after cancellation")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if status := m.GetProgress().Status; status != "cancelled" {
+		t.Fatalf("status mismatch: got %q, want cancelled", status)
+	}
+	if _, err := os.Stat(m.binPath); !os.IsNotExist(err) {
+		t.Fatalf("expected nothing installed, stat err: %v", err)
+	}
+}
@@ -0,0 +1,142 @@
+package kernel
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Version is a parsed sing-box semantic version
+type Version struct {
+	Major int `json:"major"`
+	Minor int `json:"minor"`
+	Patch int `json:"patch"`
+}
+
+func (v Version) String() string {
+	return fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+}
+
+// Less reports whether v is lower than other
+func (v Version) Less(other Version) bool {
+	if v.Major != other.Major {
+		return v.Major < other.Major
+	}
+	if v.Minor != other.Minor {
+		return v.Minor < other.Minor
+	}
+	return v.Patch < other.Patch
+}
+
+var versionPattern = regexp.MustCompile(`v?(\d+)\.(\d+)(?:\.(\d+))?`)
+
+// ParseVersion extracts a version from a version string or raw `sing-box version` output.
+// Pre-release suffixes (e.g. 1.12.0-beta.3) are ignored.
+func ParseVersion(raw string) (Version, error) {
+	match := versionPattern.FindStringSubmatch(strings.TrimSpace(raw))
+	if match == nil {
+		return Version{}, fmt.Errorf("unable to parse version from %q", raw)
+	}
+	var v Version
+	v.Major, _ = strconv.Atoi(match[1])
+	v.Minor, _ = strconv.Atoi(match[2])
+	if match[3] != "" {
+		v.Patch, _ = strconv.Atoi(match[3])
+	}
+	return v, nil
+}
+
+// featureRange describes the kernel versions that support an outbound type.
+// A zero removedIn means the type is still supported.
+type featureRange struct {
+	minVersion Version
+	removedIn  Version
+	note       string
+}
+
+// outboundSupport maps outbound types to the kernel versions that support them
+var outboundSupport = map[string]featureRange{
+	"direct":      {},
+	"selector":    {},
+	"urltest":     {},
+	"socks":       {},
+	"http":        {},
+	"shadowsocks": {},
+	"vmess":       {},
+	"trojan":      {},
+	"shadowtls":   {minVersion: Version{1, 1, 0}},
+	"vless":       {minVersion: Version{1, 2, 0}},
+	"hysteria":    {minVersion: Version{1, 3, 0}},
+	"tuic":        {minVersion: Version{1, 3, 0}},
+	"ssh":         {minVersion: Version{1, 3, 0}},
+	"hysteria2":   {minVersion: Version{1, 5, 0}},
+	"wireguard":   {note: "built as an endpoint from 1.11, as the legacy outbound before"},
+	"anytls":      {minVersion: Version{1, 12, 0}},
+	"block":       {removedIn: Version{1, 13, 0}, note: "use the reject route action instead"},
+	"dns":         {removedIn: Version{1, 13, 0}, note: "use the hijack-dns route action instead"},
+}
+
+// tlsECHMinVersion is the first kernel with ECH built in; earlier releases
+// needed the with_ech build tag and used a different config format.
+var tlsECHMinVersion = Version{1, 12, 0}
+
+// SupportsTLSECH reports whether the given kernel version supports TLS ECH in outbounds.
+func SupportsTLSECH(v Version) bool {
+	return !v.Less(tlsECHMinVersion)
+}
+
+// multiplexMinVersion is the first kernel with sing-mux for every multiplex-capable
+// outbound (shadowsocks, trojan, vmess and vless) and the padding option.
+var multiplexMinVersion = Version{1, 3, 0}
+
+// SupportsMultiplex reports whether the given kernel version supports outbound multiplex.
+func SupportsMultiplex(v Version) bool {
+	return !v.Less(multiplexMinVersion)
+}
+
+// wireGuardEndpointMinVersion is the first kernel with the top-level endpoints
+// section; WireGuard moved there from outbounds.
+var wireGuardEndpointMinVersion = Version{1, 11, 0}
+
+// SupportsWireGuardEndpoint reports whether the given kernel version expects WireGuard
+// as an endpoint rather than a legacy outbound.
+func SupportsWireGuardEndpoint(v Version) bool {
+	return !v.Less(wireGuardEndpointMinVersion)
+}
+
+// FeatureSupport describes whether a kernel version supports an outbound type
+type FeatureSupport struct {
+	Type       string `json:"type"`
+	Version    string `json:"version"`
+	Known      bool   `json:"known"`
+	Supported  bool   `json:"supported"`
+	MinVersion string `json:"min_version,omitempty"`
+	RemovedIn  string `json:"removed_in,omitempty"`
+	Note       string `json:"note,omitempty"`
+}
+
+// SupportsOutbound reports whether the given kernel version supports an outbound type.
+// Unknown types are reported as unsupported with Known set to false.
+func SupportsOutbound(v Version, outboundType string) FeatureSupport {
+	outboundType = strings.ToLower(strings.TrimSpace(outboundType))
+	result := FeatureSupport{Type: outboundType, Version: v.String()}
+
+	fr, ok := outboundSupport[outboundType]
+	if !ok {
+		return result
+	}
+	result.Known = true
+	result.Note = fr.note
+	result.Supported = !v.Less(fr.minVersion)
+	if fr.minVersion != (Version{}) {
+		result.MinVersion = fr.minVersion.String()
+	}
+	if fr.removedIn != (Version{}) {
+		result.RemovedIn = fr.removedIn.String()
+		if !v.Less(fr.removedIn) {
+			result.Supported = false
+		}
+	}
+	return result
+}
@@ -0,0 +1,74 @@
+package kernel
+
+import "testing"
+
+func TestParseVersion(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want Version
+	}{
+		{"sing-box version 1.12.3\n\nEnvironment: go1.24.1 linux/amd64", Version{1, 12, 3}},
+		{"v1.11.0", Version{1, 11, 0}},
+		{"1.13.0-beta.2", Version{1, 13, 0}},
+		{"1.10", Version{1, 10, 0}},
+	}
+	for _, tt := range tests {
+		got, err := ParseVersion(tt.raw)
+		if err != nil {
+			t.Fatalf("ParseVersion(%q) error: %v", tt.raw, err)
+		}
+		if got != tt.want {
+			t.Fatalf("ParseVersion(%q) mismatch: got %v, want %v", tt.raw, got, tt.want)
+		}
+	}
+
+	if _, err := ParseVersion("unknown"); err == nil {
+		t.Fatalf("expected error for unparsable version")
+	}
+}
+
+func TestSupportsOutbound(t *testing.T) {
+	tests := []struct {
+		name      string
+		version   Version
+		outbound  string
+		known     bool
+		supported bool
+	}{
+		{"anytls before introduction", Version{1, 11, 5}, "anytls", true, false},
+		{"anytls at introduction", Version{1, 12, 0}, "anytls", true, true},
+		{"wireguard legacy outbound on 1.10", Version{1, 10, 7}, "wireguard", true, true},
+		{"wireguard endpoint on 1.11", Version{1, 11, 0}, "wireguard", true, true},
+		{"block before removal", Version{1, 12, 9}, "block", true, true},
+		{"block after removal", Version{1, 13, 0}, "block", true, false},
+		{"case insensitive", Version{1, 12, 0}, "VLESS", true, true},
+		{"unknown type", Version{1, 12, 0}, "quantum", false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := SupportsOutbound(tt.version, tt.outbound)
+			if got.Known != tt.known || got.Supported != tt.supported {
+				t.Fatalf("support mismatch: got known=%v supported=%v, want known=%v supported=%v",
+					got.Known, got.Supported, tt.known, tt.supported)
+			}
+		})
+	}
+}
+
+func TestSupportsTLSECH(t *testing.T) {
+	if SupportsTLSECH(Version{1, 11, 15}) {
+		t.Fatalf("expected ECH to be unsupported on 1.11.15")
+	}
+	if !SupportsTLSECH(Version{1, 12, 0}) {
+		t.Fatalf("expected ECH to be supported on 1.12.0")
+	}
+}
+
+func TestSupportsWireGuardEndpoint(t *testing.T) {
+	if SupportsWireGuardEndpoint(Version{1, 10, 7}) {
+		t.Fatalf("expected the legacy WireGuard outbound on 1.10.7")
+	}
+	if !SupportsWireGuardEndpoint(Version{1, 11, 0}) {
+		t.Fatalf("expected the WireGuard endpoint on 1.11.0")
+	}
+}
@@ -1,6 +1,7 @@
 package kernel
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -26,13 +27,27 @@ type KernelInfo struct {
 
 // DownloadProgress represents download progress
 type DownloadProgress struct {
-	Status     string  `json:"status"`     // idle, downloading, extracting, installing, completed, error
-	Progress   float64 `json:"progress"`   // 0-100
-	Message    string  `json:"message"`    // Status description
-	Downloaded int64   `json:"downloaded"` // Downloaded bytes
-	Total      int64   `json:"total"`      // Total bytes
+	Status     string          `json:"status"`             // idle, preparing, downloading, verifying, extracting, installing, completed, cancelled, error
+	Progress   float64         `json:"progress"`           // 0-100
+	Message    string          `json:"message"`            // Status description
+	Downloaded int64           `json:"downloaded"`         // Downloaded bytes
+	Total      int64           `json:"total"`              // Total bytes
+	SpeedBps   int64           `json:"speed_bps"`          // Average download speed in bytes per second
+	ETASeconds int64           `json:"eta_seconds"`        // Estimated seconds remaining, 0 if unknown
+	Checksum   string          `json:"checksum,omitempty"` // SHA256 verification: verified, mismatch, unavailable
+	Impact     []InstallImpact `json:"impact,omitempty"`   // nodes the downloaded kernel would reject, set with status "confirm"
 }
 
+// InstallImpact is a node that would stop validating with a downloaded kernel
+type InstallImpact struct {
+	Name  string `json:"name"`
+	Error string `json:"error"`
+}
+
+// InstallCheck vets a downloaded binary before it replaces the installed one and
+// returns the nodes it would break
+type InstallCheck func(binaryPath string) ([]InstallImpact, error)
+
 // GithubRelease represents GitHub release information
 type GithubRelease struct {
 	TagName    string        `json:"tag_name"`
@@ -50,12 +65,14 @@ type GithubAsset struct {
 
 // Manager handles kernel management
 type Manager struct {
-	dataDir     string
-	binPath     string                       // absolute path to the sing-box binary
-	getSettings func() *storage.Settings
-	mu          sync.RWMutex
-	progress    *DownloadProgress
-	downloading bool
+	dataDir      string
+	binPath      string // absolute path to the sing-box binary
+	getSettings  func() *storage.Settings
+	mu           sync.RWMutex
+	progress     *DownloadProgress
+	downloading  bool
+	cancel       context.CancelFunc // cancels the in-flight download
+	installCheck InstallCheck
 }
 
 // NewManager creates a kernel manager
@@ -75,6 +92,13 @@ func NewManager(dataDir string, getSettings func() *storage.Settings) *Manager {
 	}
 }
 
+// SetInstallCheck sets the check run on a downloaded kernel before it is installed
+func (m *Manager) SetInstallCheck(check InstallCheck) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.installCheck = check
+}
+
 // GetInfo returns kernel information
 func (m *Manager) GetInfo() *KernelInfo {
 	info := &KernelInfo{
@@ -131,6 +155,11 @@ func (m *Manager) getVersion(singboxPath string) (string, error) {
 
 // FetchReleases fetches GitHub releases list
 func (m *Manager) FetchReleases() ([]GithubRelease, error) {
+	return m.fetchReleases(context.Background())
+}
+
+// fetchReleases fetches GitHub releases list, giving up when ctx is cancelled
+func (m *Manager) fetchReleases(ctx context.Context) ([]GithubRelease, error) {
 	settings := m.getSettings()
 	apiURL := "https://api.github.com/repos/SagerNet/sing-box/releases"
 
@@ -139,7 +168,11 @@ func (m *Manager) FetchReleases() ([]GithubRelease, error) {
 		apiURL = settings.GithubProxy + apiURL
 	}
 
-	resp, err := http.Get(apiURL)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, apiURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch releases: %w", err)
+	}
+	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch releases: %w", err)
 	}
@@ -184,8 +217,9 @@ func (m *Manager) GetLatestVersion() (string, error) {
 	return releases[0].TagName, nil
 }
 
-// StartDownload starts downloading a specific version
-func (m *Manager) StartDownload(version string) error {
+// StartDownload starts downloading a specific version. Unless confirmed, the download
+// stops before installing when the install check reports nodes the kernel would break.
+func (m *Manager) StartDownload(version string, confirmed bool) error {
 	m.mu.Lock()
 	if m.downloading {
 		m.mu.Unlock()
@@ -196,14 +230,27 @@ func (m *Manager) StartDownload(version string) error {
 		Status:  "preparing",
 		Message: "Preparing download...",
 	}
+	ctx, cancel := context.WithCancel(context.Background())
+	m.cancel = cancel
 	m.mu.Unlock()
 
 	// Execute download asynchronously
-	go m.downloadAndInstall(version)
+	go m.downloadAndInstall(ctx, version, confirmed)
 
 	return nil
 }
 
+// CancelDownload aborts the in-flight download
+func (m *Manager) CancelDownload() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.downloading || m.cancel == nil {
+		return fmt.Errorf("no download in progress")
+	}
+	m.cancel()
+	return nil
+}
+
 // GetProgress returns download progress
 func (m *Manager) GetProgress() *DownloadProgress {
 	m.mu.RLock()
@@ -218,7 +265,7 @@ func (m *Manager) IsDownloading() bool {
 	return m.downloading
 }
 
-// updateProgress updates progress
+// updateProgress updates progress, keeping the checksum status already reported
 func (m *Manager) updateProgress(status string, progress float64, message string, downloaded, total int64) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
@@ -228,32 +275,57 @@ func (m *Manager) updateProgress(status string, progress float64, message string
 		Message:    message,
 		Downloaded: downloaded,
 		Total:      total,
+		Checksum:   m.progress.Checksum,
 	}
 }
 
+// setProgress replaces the current progress
+func (m *Manager) setProgress(progress *DownloadProgress) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.progress = progress
+}
+
 // setDownloadComplete marks download as complete
 func (m *Manager) setDownloadComplete(status string, message string) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	m.downloading = false
+	if m.cancel != nil {
+		m.cancel()
+		m.cancel = nil
+	}
 	m.progress = &DownloadProgress{
 		Status:   status,
 		Progress: 100,
 		Message:  message,
+		Checksum: m.progress.Checksum,
 	}
 }
 
-// getAssetInfo gets asset info for the current platform
-func (m *Manager) getAssetInfo(releases []GithubRelease, version string) (*GithubAsset, error) {
-	// Find matching version
-	var targetRelease *GithubRelease
+// setDownloadNeedsConfirm stops the download before installing and reports the nodes
+// the downloaded kernel would break
+func (m *Manager) setDownloadNeedsConfirm(message string, impact []InstallImpact) {
+	m.setDownloadComplete("confirm", message)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.progress.Impact = impact
+}
+
+// findRelease finds the release with the given tag
+func findRelease(releases []GithubRelease, version string) *GithubRelease {
 	for i := range releases {
 		if releases[i].TagName == version {
-			targetRelease = &releases[i]
-			break
+			return &releases[i]
 		}
 	}
+	return nil
+}
 
+// getAssetInfo gets asset info for the current platform
+func (m *Manager) getAssetInfo(releases []GithubRelease, version string) (*GithubAsset, error) {
+	// Find matching version
+	targetRelease := findRelease(releases, version)
 	if targetRelease == nil {
 		return nil, fmt.Errorf("version %s not found", version)
 	}
@@ -33,14 +33,19 @@ type ClashProxy struct {
 	ALPN           []string               `yaml:"alpn,omitempty"`
 	Fingerprint    string                 `yaml:"fingerprint,omitempty"`
 	Flow           string                 `yaml:"flow,omitempty"`
+	PacketEncoding string                 `yaml:"packet-encoding,omitempty"`
 	UDP            bool                   `yaml:"udp,omitempty"`
 	Plugin         string                 `yaml:"plugin,omitempty"`
 	PluginOpts     map[string]interface{} `yaml:"plugin-opts,omitempty"`
+	UDPOverTCP     bool                   `yaml:"udp-over-tcp,omitempty"`
+	UDPOverTCPVer  int                    `yaml:"udp-over-tcp-version,omitempty"`
 	WSOpts         *WSOpts                `yaml:"ws-opts,omitempty"`
 	H2Opts         *H2Opts                `yaml:"h2-opts,omitempty"`
 	HTTPOpts       *HTTPOpts              `yaml:"http-opts,omitempty"`
 	GrpcOpts       *GrpcOpts              `yaml:"grpc-opts,omitempty"`
 	RealityOpts    *RealityOpts           `yaml:"reality-opts,omitempty"`
+	Smux           *SmuxOpts              `yaml:"smux,omitempty"`
+	TFO            *bool                  `yaml:"tfo,omitempty"`
 	// Hysteria2 specific
 	Auth         string `yaml:"auth,omitempty"`
 	Obfs         string `yaml:"obfs,omitempty"`
@@ -85,6 +90,16 @@ type RealityOpts struct {
 	ShortID   string `yaml:"short-id,omitempty"`
 }
 
+// SmuxOpts multiplex options
+type SmuxOpts struct {
+	Enabled        bool   `yaml:"enabled,omitempty"`
+	Protocol       string `yaml:"protocol,omitempty"`
+	MaxConnections int    `yaml:"max-connections,omitempty"`
+	MinStreams     int    `yaml:"min-streams,omitempty"`
+	MaxStreams     int    `yaml:"max-streams,omitempty"`
+	Padding        bool   `yaml:"padding,omitempty"`
+}
+
 // ParseClashYAML parses Clash YAML configuration
 func ParseClashYAML(content string) ([]storage.Node, error) {
 	var config ClashConfig
@@ -114,12 +129,15 @@ func convertClashProxy(proxy ClashProxy) (*storage.Node, error) {
 		nodeType = "shadowsocks"
 		extra["method"] = proxy.Cipher
 		extra["password"] = proxy.Password
-		if proxy.Plugin != "" {
-			extra["plugin"] = proxy.Plugin
-			if proxy.PluginOpts != nil {
-				extra["plugin_opts"] = proxy.PluginOpts
+		if plugin, _ := parseSSPlugin(proxy.Plugin); plugin != "" {
+			extra["plugin"] = plugin
+			if opts := clashSSPluginOpts(plugin, proxy.PluginOpts); opts != "" {
+				extra["plugin_opts"] = opts
 			}
 		}
+		if uot := clashUDPOverTCP(proxy.UDPOverTCP, proxy.UDPOverTCPVer); uot != nil {
+			extra["udp_over_tcp"] = uot
+		}
 
 	case "vmess":
 		nodeType = "vmess"
@@ -129,6 +147,9 @@ func convertClashProxy(proxy ClashProxy) (*storage.Node, error) {
 		if extra["security"] == "" {
 			extra["security"] = "auto"
 		}
+		if pe := normalizePacketEncoding(proxy.PacketEncoding); pe != "" {
+			extra["packet_encoding"] = pe
+		}
 
 	case "vless":
 		nodeType = "vless"
@@ -136,6 +157,9 @@ func convertClashProxy(proxy ClashProxy) (*storage.Node, error) {
 		if proxy.Flow != "" {
 			extra["flow"] = proxy.Flow
 		}
+		if pe := normalizePacketEncoding(proxy.PacketEncoding); pe != "" {
+			extra["packet_encoding"] = pe
+		}
 
 	case "trojan":
 		nodeType = "trojan"
@@ -345,10 +369,19 @@ func convertClashProxy(proxy ClashProxy) (*storage.Node, error) {
 		extra["tls"] = tls
 	}
 
+	// Multiplex
+	if proxy.Smux != nil && proxy.Smux.Enabled {
+		extra["multiplex"] = newMultiplex(proxy.Smux.Protocol, proxy.Smux.MaxConnections,
+			proxy.Smux.MinStreams, proxy.Smux.MaxStreams, proxy.Smux.Padding)
+	}
+	if proxy.TFO != nil {
+		extra["tcp_fast_open"] = *proxy.TFO
+	}
+
 	node := &storage.Node{
 		Tag:          proxy.Name,
 		Type:         nodeType,
-		Server:       proxy.Server,
+		Server:       storage.NormalizeServer(proxy.Server),
 		ServerPort:   proxy.Port,
 		Extra:        extra,
 	}
@@ -69,7 +69,7 @@ func (p *HttpParser) Parse(rawURL string) (*storage.Node, error) {
 
 	// Set default name
 	if name == "" {
-		name = fmt.Sprintf("%s:%d", server, port)
+		name = formatServerPort(server, port)
 	}
 
 	// Build Extra
@@ -104,11 +104,19 @@ func (p *HttpParser) Parse(rawURL string) (*storage.Node, error) {
 		} else {
 			tls["server_name"] = server
 		}
-		if getParamBool(params, "insecure") {
-			tls["insecure"] = true
+		applyTLSInsecure(tls, paramsInsecure(params))
+		if fp := fingerprintParam(params); fp != "" {
+			if err := validateFingerprint(fp); err != nil {
+				return nil, err
+			}
+			tls["utls"] = map[string]interface{}{
+				"enabled":     true,
+				"fingerprint": fp,
+			}
 		}
 		extra["tls"] = tls
 	}
+	applyTCPFastOpenParam(extra, params)
 
 	node := &storage.Node{
 		Tag:        name,
@@ -55,7 +55,7 @@ func (p *Hysteria2Parser) Parse(rawURL string) (*storage.Node, error) {
 
 	// Set default name
 	if name == "" {
-		name = fmt.Sprintf("%s:%d", server, port)
+		name = formatServerPort(server, port)
 	}
 
 	// Build Extra
@@ -77,9 +77,7 @@ func (p *Hysteria2Parser) Parse(rawURL string) (*storage.Node, error) {
 	}
 
 	// Skip certificate verification
-	if getParamBool(params, "insecure") || getParamBool(params, "allowInsecure") {
-		tls["insecure"] = true
-	}
+	applyTLSInsecure(tls, paramsInsecure(params))
 
 	// ALPN
 	if alpn := params.Get("alpn"); alpn != "" {
@@ -125,6 +123,7 @@ func (p *Hysteria2Parser) Parse(rawURL string) (*storage.Node, error) {
 	if hopInterval := params.Get("hop-interval"); hopInterval != "" {
 		extra["hop_interval"] = hopInterval
 	}
+	applyUDPFragmentParam(extra, params)
 
 	node := &storage.Node{
 		Tag:        name,
@@ -0,0 +1,220 @@
+package parser
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/xiaobei/singbox-manager/internal/storage"
+	"github.com/xiaobei/singbox-manager/pkg/utils"
+)
+
+// minBase64BlockLen keeps ordinary words made of base64 characters from being
+// taken for the start of a subscription block
+const minBase64BlockLen = 16
+
+// proxyURLPattern finds share links embedded in free text
+var proxyURLPattern = regexp.MustCompile(`(?i)\b(?:ss|vmess|vless|trojan|hysteria2|hy2|hysteria|tuic|socks|socks4|socks4a|socks5|https?)://[^\s"'<>]+`)
+
+// MixedEntry is a single proxy found in pasted text
+type MixedEntry struct {
+	Raw  string // Share link, or "type server:port" for a JSON outbound
+	Line int    // 1-based line the entry or its enclosing block starts on
+	Node *storage.Node
+	Err  error
+}
+
+// MixedContent groups the proxies found in pasted text by the format they were in
+type MixedContent struct {
+	URLs   []MixedEntry
+	Base64 []MixedEntry
+	JSON   []MixedEntry
+}
+
+// Len returns the number of entries across all formats
+func (m MixedContent) Len() int {
+	return len(m.URLs) + len(m.Base64) + len(m.JSON)
+}
+
+// ScanMixedContent extracts every proxy it can find in arbitrary text, such as
+// a chat message or email mixing share links, base64 subscription blocks and
+// sing-box outbound JSON. JSON values are taken out first so links inside
+// them are not counted twice; the rest is scanned line by line.
+func ScanMixedContent(text string) MixedContent {
+	var result MixedContent
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	text, result.JSON = scanJSONOutbounds(text)
+
+	seen := make(map[string]bool)
+	var block []string
+	blockStart := 0
+	flush := func() {
+		if len(block) > 0 {
+			result.Base64 = append(result.Base64, scanBase64Block(block, blockStart, seen)...)
+			block = nil
+		}
+	}
+
+	for i, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		// Wrapped base64 continues on lines shorter than the minimum
+		if isBase64Token(line) && (len(block) > 0 || len(line) >= minBase64BlockLen) {
+			if len(block) == 0 {
+				blockStart = i + 1
+			}
+			block = append(block, line)
+			continue
+		}
+		flush()
+
+		for _, field := range strings.Fields(line) {
+			if len(field) >= minBase64BlockLen && isBase64Token(field) {
+				result.Base64 = append(result.Base64, scanBase64Block([]string{field}, i+1, seen)...)
+			}
+		}
+		result.URLs = append(result.URLs, scanURLs(line, i+1, seen)...)
+	}
+	flush()
+
+	return result
+}
+
+// scanURLs parses the share links found in text, skipping ones already seen
+func scanURLs(text string, line int, seen map[string]bool) []MixedEntry {
+	var entries []MixedEntry
+	for _, raw := range proxyURLPattern.FindAllString(text, -1) {
+		raw = trimURLPunctuation(raw)
+		if seen[raw] || isWebLink(raw) {
+			continue
+		}
+		seen[raw] = true
+		entry := MixedEntry{Raw: raw, Line: line}
+		entry.Node, entry.Err = ParseURLWithHint(raw, "")
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// scanBase64Block decodes a base64 block and parses the share links inside.
+// If the joined lines do not decode, each line is tried on its own.
+func scanBase64Block(lines []string, line int, seen map[string]bool) []MixedEntry {
+	if decoded, err := utils.DecodeBase64(strings.Join(lines, "")); err == nil && strings.Contains(decoded, "://") {
+		return scanURLs(decoded, line, seen)
+	}
+	if len(lines) == 1 {
+		return nil
+	}
+	var entries []MixedEntry
+	for i, l := range lines {
+		entries = append(entries, scanBase64Block([]string{l}, line+i, seen)...)
+	}
+	return entries
+}
+
+// scanJSONOutbounds decodes every JSON value in text that holds sing-box
+// outbounds and returns text with those values blanked out, keeping line breaks
+func scanJSONOutbounds(text string) (string, []MixedEntry) {
+	var entries []MixedEntry
+	masked := []byte(text)
+	for i := 0; i < len(text); i++ {
+		if text[i] != '{' && text[i] != '[' {
+			continue
+		}
+		dec := json.NewDecoder(strings.NewReader(text[i:]))
+		var value interface{}
+		if err := dec.Decode(&value); err != nil {
+			continue
+		}
+		end := i + int(dec.InputOffset())
+
+		line := 1 + strings.Count(text[:i], "\n")
+		for _, outbound := range jsonOutbounds(value) {
+			entry := MixedEntry{Raw: describeOutbound(outbound), Line: line}
+			entry.Node, entry.Err = ParseSingboxOutbound(outbound)
+			entries = append(entries, entry)
+		}
+		for j := i; j < end; j++ {
+			if masked[j] != '\n' {
+				masked[j] = ' '
+			}
+		}
+		i = end - 1
+	}
+	return string(masked), entries
+}
+
+// jsonOutbounds returns the proxy outbounds in a decoded JSON value: a single
+// outbound, an outbound array, or a config with an "outbounds" key.
+// Outbounds without a server (selector, direct, ...) are left out.
+func jsonOutbounds(value interface{}) []map[string]interface{} {
+	var candidates []interface{}
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if list, ok := v["outbounds"].([]interface{}); ok {
+			candidates = list
+		} else {
+			candidates = []interface{}{v}
+		}
+	case []interface{}:
+		candidates = v
+	}
+
+	var outbounds []map[string]interface{}
+	for _, c := range candidates {
+		outbound, ok := c.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if _, ok := outbound["type"].(string); !ok {
+			continue
+		}
+		if _, ok := outbound["server"]; !ok {
+			continue
+		}
+		outbounds = append(outbounds, outbound)
+	}
+	return outbounds
+}
+
+// describeOutbound labels a JSON outbound for results
+func describeOutbound(outbound map[string]interface{}) string {
+	return fmt.Sprintf("%v %v:%v", outbound["type"], outbound["server"], outbound["server_port"])
+}
+
+// isBase64Token reports whether s could be (part of) a base64 block
+func isBase64Token(s string) bool {
+	return s != "" && !strings.Contains(s, "://") && utils.IsBase64(s)
+}
+
+// isWebLink reports whether an http(s) link looks like a web page rather than
+// a proxy: proxy links carry an explicit port and no path
+func isWebLink(raw string) bool {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return false
+	}
+	scheme := strings.ToLower(u.Scheme)
+	if scheme != "http" && scheme != "https" {
+		return false
+	}
+	return u.Port() == "" || (u.Path != "" && u.Path != "/")
+}
+
+// trimURLPunctuation drops sentence punctuation that the link pattern picks up
+// at the end of a link, keeping a closing parenthesis that has a matching opener
+func trimURLPunctuation(raw string) string {
+	for raw != "" {
+		last := raw[len(raw)-1]
+		switch {
+		case strings.IndexByte(".,;:!?]}", last) >= 0:
+			raw = raw[:len(raw)-1]
+		case last == ')' && strings.Count(raw, "(") < strings.Count(raw, ")"):
+			raw = raw[:len(raw)-1]
+		default:
+			return raw
+		}
+	}
+	return raw
+}
@@ -0,0 +1,53 @@
+package parser
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+)
+
+func TestScanMixedContent_URLsBase64AndJSON(t *testing.T) {
+	sub := base64.StdEncoding.EncodeToString([]byte("trojan://secret@9.9.9.9:443#b64-one\ntrojan://secret@9.9.9.10:443#b64-two\n"))
+	// Mail clients wrap long base64 blocks
+	wrapped := sub[:40] + "\n" + sub[40:]
+
+	text := strings.Join([]string{
+		"Hi! Here are the new servers (see https://example.com/help for setup):",
+		"trojan://secret@1.2.3.4:443?sni=example.com#first.",
+		"and the backup one: ss://YWVzLTI1Ni1nY206cGFzcw@5.6.7.8:8388#second",
+		"",
+		"Full subscription:",
+		wrapped,
+		"",
+		`sing-box users: {"type":"trojan","tag":"json-one","server":"7.7.7.7","server_port":443,"password":"x","tls":{"enabled":true}}`,
+		"Thanks",
+	}, "\n")
+
+	got := ScanMixedContent(text)
+
+	if len(got.URLs) != 2 {
+		t.Fatalf("url count mismatch: got %d, want 2 (%+v)", len(got.URLs), got.URLs)
+	}
+	if got.URLs[0].Raw != "trojan://secret@1.2.3.4:443?sni=example.com#first" || got.URLs[0].Line != 2 {
+		t.Fatalf("first url mismatch: got %q on line %d", got.URLs[0].Raw, got.URLs[0].Line)
+	}
+	if n := got.URLs[1].Node; n == nil || n.Type != "shadowsocks" || n.Server != "5.6.7.8" {
+		t.Fatalf("second url mismatch: got %+v (err %v)", n, got.URLs[1].Err)
+	}
+
+	if len(got.Base64) != 2 {
+		t.Fatalf("base64 count mismatch: got %d, want 2 (%+v)", len(got.Base64), got.Base64)
+	}
+	for i, want := range []string{"9.9.9.9", "9.9.9.10"} {
+		if n := got.Base64[i].Node; n == nil || n.Server != want || got.Base64[i].Line != 6 {
+			t.Fatalf("base64 entry %d mismatch: got %+v on line %d, want server %s on line 6", i, n, got.Base64[i].Line, want)
+		}
+	}
+
+	if len(got.JSON) != 1 || got.JSON[0].Node == nil || got.JSON[0].Node.Server != "7.7.7.7" {
+		t.Fatalf("json entries mismatch: got %+v", got.JSON)
+	}
+	if got.Len() != 5 {
+		t.Fatalf("total mismatch: got %d, want 5", got.Len())
+	}
+}
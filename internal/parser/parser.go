@@ -60,6 +60,8 @@ func ParseURLWithHint(rawURL string, defaultProtocol string) (*storage.Node, err
 	if err != nil {
 		return nil, err
 	}
+	node.Server = storage.NormalizeServer(node.Server)
+	node.SourceURL = rawURL
 
 	return node, nil
 }
@@ -145,7 +147,7 @@ func parseServerInfo(serverInfo string) (host string, port int, err error) {
 		if idx == -1 {
 			return "", 0, fmt.Errorf("invalid server address: %s", serverInfo)
 		}
-		host = serverInfo[1:idx]
+		host = storage.NormalizeServer(serverInfo[1:idx])
 		portStr := serverInfo[idx+2:]
 		port, err = strconv.Atoi(portStr)
 		if err != nil {
@@ -215,18 +217,210 @@ func getParamBool(params url.Values, key string) bool {
 	return v == "1" || v == "true" || v == "True" || v == "TRUE"
 }
 
-// validFingerprints is the set of allowed uTLS fingerprint values
-var validFingerprints = map[string]bool{
-	"chrome":     true,
-	"firefox":    true,
-	"safari":     true,
-	"ios":        true,
-	"android":    true,
-	"edge":       true,
-	"360":        true,
-	"qq":         true,
-	"random":     true,
-	"randomized": true,
+// insecureParamKeys are the spellings clients use for "skip TLS certificate verification".
+// Keys are matched case-insensitively. Verification is disabled when ANY of them is
+// truthy; a falsy value never re-enables verification turned off by another key.
+var insecureParamKeys = []string{"allowInsecure", "insecure", "skip-cert-verify", "allow_insecure"}
+
+// isTruthy reports whether a share-link or JSON value means "true"
+func isTruthy(v interface{}) bool {
+	switch val := v.(type) {
+	case bool:
+		return val
+	case float64:
+		return val != 0
+	case int:
+		return val != 0
+	case string:
+		switch strings.ToLower(strings.TrimSpace(val)) {
+		case "1", "true", "yes", "on":
+			return true
+		}
+	}
+	return false
+}
+
+// paramsInsecure reports whether any insecure variant in params is truthy
+func paramsInsecure(params url.Values) bool {
+	for key, values := range params {
+		for _, known := range insecureParamKeys {
+			if !strings.EqualFold(key, known) {
+				continue
+			}
+			for _, v := range values {
+				if isTruthy(v) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// applyTLSInsecure sets tls.insecure when insecure is true and leaves tls untouched otherwise
+func applyTLSInsecure(tls map[string]interface{}, insecure bool) {
+	if insecure {
+		tls["insecure"] = true
+	}
+}
+
+// normalizePacketEncoding maps a share-link packet encoding to the sing-box value.
+// "packet" is the v2ray name for packetaddr; unknown values are dropped.
+func normalizePacketEncoding(pe string) string {
+	switch strings.ToLower(strings.TrimSpace(pe)) {
+	case "packet", "packetaddr":
+		return "packetaddr"
+	case "xudp":
+		return "xudp"
+	default:
+		return ""
+	}
+}
+
+// applyTLSFragmentParams copies TLS fragment parameters into a sing-box tls map.
+// fragment accepts a boolean or an xray-style spec (e.g. "tlshello,100-200,10-20"),
+// either of which enables sing-box's TLS ClientHello fragmentation.
+func applyTLSFragmentParams(params url.Values, tls map[string]interface{}) {
+	if fragment := strings.ToLower(strings.TrimSpace(params.Get("fragment"))); fragment != "" && fragment != "0" && fragment != "false" {
+		tls["fragment"] = true
+	}
+	if delay := params.Get("fragmentFallbackDelay"); delay != "" {
+		tls["fragment_fallback_delay"] = delay
+	}
+	if getParamBool(params, "recordFragment") {
+		tls["record_fragment"] = true
+	}
+}
+
+// applyECHParams copies Encrypted Client Hello parameters into a sing-box tls map.
+// ech=1 enables ECH with the config fetched via DNS; a base64 ECH config list
+// (in ech or echConfigList) is embedded as a PEM block.
+func applyECHParams(params url.Values, tls map[string]interface{}) {
+	value := strings.TrimSpace(params.Get("echConfigList"))
+	if value == "" {
+		value = strings.TrimSpace(params.Get("ech"))
+	}
+	switch strings.ToLower(value) {
+	case "", "0", "false":
+		return
+	}
+
+	ech := map[string]interface{}{
+		"enabled": true,
+	}
+	if config := echConfigPEM(value); config != nil {
+		ech["config"] = config
+	}
+	tls["ech"] = ech
+}
+
+// echConfigPEM converts a base64 ECH config list into the PEM lines sing-box expects.
+// Returns nil when the value is not a base64 config list (e.g. ech=1).
+func echConfigPEM(value string) []string {
+	// Query parsing turns '+' into ' '
+	value = strings.ReplaceAll(value, " ", "+")
+	raw, err := utils.DecodeBase64(value)
+	// ECHConfigList starts with a 2-byte length of the remaining bytes
+	if err != nil || len(raw) < 4 || int(raw[0])<<8|int(raw[1]) != len(raw)-2 {
+		return nil
+	}
+	encoded := utils.EncodeBase64(raw)
+	lines := []string{"-----BEGIN ECH CONFIGS-----"}
+	for len(encoded) > 64 {
+		lines = append(lines, encoded[:64])
+		encoded = encoded[64:]
+	}
+	lines = append(lines, encoded, "-----END ECH CONFIGS-----")
+	return lines
+}
+
+// multiplexProtocols are the multiplex protocols sing-box supports
+var multiplexProtocols = map[string]bool{"smux": true, "yamux": true, "h2mux": true}
+
+// newMultiplex builds a sing-box multiplex block. An unknown protocol is left out so
+// sing-box uses its default (smux); zero limits are omitted.
+func newMultiplex(protocol string, maxConnections, minStreams, maxStreams int, padding bool) map[string]interface{} {
+	mux := map[string]interface{}{
+		"enabled": true,
+	}
+	if protocol = strings.ToLower(strings.TrimSpace(protocol)); multiplexProtocols[protocol] {
+		mux["protocol"] = protocol
+	}
+	if maxConnections > 0 {
+		mux["max_connections"] = maxConnections
+	}
+	if minStreams > 0 {
+		mux["min_streams"] = minStreams
+	}
+	if maxStreams > 0 {
+		mux["max_streams"] = maxStreams
+	}
+	if padding {
+		mux["padding"] = true
+	}
+	return mux
+}
+
+// multiplexFromParams builds a multiplex block from share-link parameters, or nil when
+// multiplexing is off. mux takes a boolean or a protocol name (smux, yamux, h2mux);
+// muxProtocol, maxConnections, minStreams, maxStreams and padding tune the block.
+func multiplexFromParams(params url.Values) map[string]interface{} {
+	mux := strings.ToLower(strings.TrimSpace(params.Get("mux")))
+	protocol := params.Get("muxProtocol")
+	switch {
+	case multiplexProtocols[mux]:
+		if protocol == "" {
+			protocol = mux
+		}
+	case !isTruthy(mux):
+		return nil
+	}
+	return newMultiplex(protocol,
+		getParamInt(params, "maxConnections", 0),
+		getParamInt(params, "minStreams", 0),
+		getParamInt(params, "maxStreams", 0),
+		getParamBool(params, "padding"))
+}
+
+// tcpFastOpenParamKeys are the share-link parameters clients use for TCP Fast Open
+var tcpFastOpenParamKeys = []string{"tfo", "fastopen", "tcp-fast-open"}
+
+// udpFragmentParamKeys are the share-link parameters clients use for UDP fragmentation
+var udpFragmentParamKeys = []string{"udp_fragment", "udp-fragment"}
+
+// applyTCPFastOpenParam stores the node's TCP Fast Open choice from share-link
+// parameters. An explicit false is kept so it overrides the global default.
+func applyTCPFastOpenParam(extra map[string]interface{}, params url.Values) {
+	applyDialerFlagParam(extra, "tcp_fast_open", params, tcpFastOpenParamKeys)
+}
+
+// applyUDPFragmentParam stores the node's UDP fragmentation choice from share-link
+// parameters. An explicit false is kept so it overrides the global default.
+func applyUDPFragmentParam(extra map[string]interface{}, params url.Values) {
+	applyDialerFlagParam(extra, "udp_fragment", params, udpFragmentParamKeys)
+}
+
+// applyDialerFlagParam stores field from the first of keys set in params
+func applyDialerFlagParam(extra map[string]interface{}, field string, params url.Values, keys []string) {
+	for _, key := range keys {
+		if v := strings.TrimSpace(params.Get(key)); v != "" {
+			extra[field] = isTruthy(v)
+			return
+		}
+	}
+}
+
+// fingerprintParamKeys are the share-link parameters carrying a uTLS fingerprint
+var fingerprintParamKeys = []string{"fp", "utls", "fingerprint"}
+
+// fingerprintParam returns the first uTLS fingerprint found in params, lowercased
+func fingerprintParam(params url.Values) string {
+	for _, key := range fingerprintParamKeys {
+		if fp := strings.ToLower(strings.TrimSpace(params.Get(key))); fp != "" {
+			return fp
+		}
+	}
+	return ""
 }
 
 // validateFingerprint checks if the fingerprint value is valid.
@@ -235,7 +429,7 @@ func validateFingerprint(fp string) error {
 	if fp == "" {
 		return nil
 	}
-	if !validFingerprints[fp] {
+	if !storage.UTLSFingerprints[fp] {
 		return fmt.Errorf("invalid uTLS fingerprint: %q", fp)
 	}
 	return nil
@@ -0,0 +1,52 @@
+package parser
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/xiaobei/singbox-manager/internal/builder"
+)
+
+func TestParseURL_BracketedIPv6(t *testing.T) {
+	tests := []struct {
+		name string
+		url  string
+		port int
+	}{
+		{"vless", "vless://11111111-2222-3333-4444-555555555555@[2001:db8::1]:443?security=tls&sni=example.com", 443},
+		{"vless uppercase", "vless://11111111-2222-3333-4444-555555555555@[2001:DB8:0::1]:443?security=tls", 443},
+		{"trojan", "trojan://secret@[2001:db8::1]:8443?sni=example.com", 8443},
+		{"shadowsocks", "ss://YWVzLTEyOC1nY206cGFzcw@[2001:db8::1]:8388", 8388},
+		{"shadowsocks plugin", "ss://YWVzLTEyOC1nY206cGFzcw@[2001:db8::1]:8388/?plugin=obfs-local;obfs=http", 8388},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node, err := ParseURL(tt.url)
+			if err != nil {
+				t.Fatalf("ParseURL() error = %v", err)
+			}
+			if node.Server != "2001:db8::1" || node.ServerPort != tt.port {
+				t.Fatalf("server mismatch: got %q:%d, want 2001:db8::1:%d", node.Server, node.ServerPort, tt.port)
+			}
+			if want := "[2001:db8::1]:" + strconv.Itoa(tt.port); node.Tag != want {
+				t.Fatalf("default name mismatch: got %q, want %q", node.Tag, want)
+			}
+			if got := builder.NodeToOutbound(*node)["server"]; got != "2001:db8::1" {
+				t.Fatalf("outbound server mismatch: got %v, want bare 2001:db8::1", got)
+			}
+		})
+	}
+}
+
+func TestParseSingboxOutbound_BracketedIPv6(t *testing.T) {
+	node, err := ParseSingboxOutbound(map[string]interface{}{
+		"type": "trojan", "server": "[2001:db8::1]", "server_port": float64(443), "password": "p",
+	})
+	if err != nil {
+		t.Fatalf("ParseSingboxOutbound() error = %v", err)
+	}
+	if node.Server != "2001:db8::1" || node.Tag != "trojan-[2001:db8::1]:443" {
+		t.Fatalf("node mismatch: got server %q tag %q", node.Server, node.Tag)
+	}
+}
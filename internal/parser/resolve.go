@@ -0,0 +1,131 @@
+package parser
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/xiaobei/singbox-manager/internal/storage"
+)
+
+// PinResolvedServer replaces a hostname server with one of its resolved addresses
+// (IPv4 preferred) so the node connects without a DNS lookup at dial time. Wherever
+// the hostname was implied by the server address it is written out: as the SNI when
+// the node uses TLS without a server_name, and as the HTTP Host of ws, httpupgrade
+// and http transports without one. IP servers are left unchanged.
+func PinResolvedServer(node *storage.Node, lookupHost func(host string) ([]string, error)) error {
+	host := node.Server
+	if host == "" || net.ParseIP(host) != nil {
+		return nil
+	}
+
+	addrs, err := lookupHost(host)
+	if err != nil {
+		return fmt.Errorf("resolve %s: %w", host, err)
+	}
+	ip := ""
+	for _, addr := range addrs {
+		parsed := net.ParseIP(addr)
+		if parsed == nil {
+			continue
+		}
+		if parsed.To4() != nil {
+			ip = addr
+			break
+		}
+		if ip == "" {
+			ip = addr
+		}
+	}
+	if ip == "" {
+		return fmt.Errorf("resolve %s: no addresses", host)
+	}
+
+	if tls, ok := node.Extra["tls"].(map[string]interface{}); ok && isTruthy(tls["enabled"]) {
+		if sni, _ := tls["server_name"].(string); sni == "" {
+			pinned := make(map[string]interface{}, len(tls)+1)
+			for k, v := range tls {
+				pinned[k] = v
+			}
+			pinned["server_name"] = host
+			node.Extra["tls"] = pinned
+		}
+	}
+	pinTransportHost(node.Extra, host)
+	node.Server = ip
+	return nil
+}
+
+// pinTransportHost sets host as the HTTP Host of a ws, httpupgrade or http transport
+// that does not name one. The transport map is copied, not mutated.
+func pinTransportHost(extra map[string]interface{}, host string) {
+	transport, ok := extra["transport"].(map[string]interface{})
+	if !ok {
+		return
+	}
+	pinned := make(map[string]interface{}, len(transport)+1)
+	for k, v := range transport {
+		pinned[k] = v
+	}
+
+	switch transport["type"] {
+	case "ws", "httpupgrade":
+		if hostHeader(transport["headers"]) != "" {
+			return
+		}
+		if h, _ := transport["host"].(string); h != "" {
+			return
+		}
+		headers := make(map[string]interface{})
+		switch existing := transport["headers"].(type) {
+		case map[string]interface{}:
+			for k, v := range existing {
+				headers[k] = v
+			}
+		case map[string]string:
+			for k, v := range existing {
+				headers[k] = v
+			}
+		}
+		headers["Host"] = host
+		pinned["headers"] = headers
+	case "http", "h2":
+		switch hosts := transport["host"].(type) {
+		case []string:
+			if len(hosts) > 0 {
+				return
+			}
+		case []interface{}:
+			if len(hosts) > 0 {
+				return
+			}
+		case string:
+			if hosts != "" {
+				return
+			}
+		}
+		pinned["host"] = []string{host}
+	default:
+		return
+	}
+	extra["transport"] = pinned
+}
+
+// hostHeader returns the Host entry of a transport headers map, matched case-insensitively
+func hostHeader(headers interface{}) string {
+	switch h := headers.(type) {
+	case map[string]interface{}:
+		for k, v := range h {
+			if s, _ := v.(string); strings.EqualFold(k, "Host") && s != "" {
+				return s
+			}
+		}
+	case map[string]string:
+		for k, v := range h {
+			if strings.EqualFold(k, "Host") && v != "" {
+				return v
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,139 @@
+package parser
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParsers_IPServerWithDomainSNI(t *testing.T) {
+	links := map[string]string{
+		"trojan":    "trojan://secret@203.0.113.7:443?sni=cdn.example.com#n",
+		"vless":     "vless://11111111-2222-3333-4444-555555555555@203.0.113.7:443?security=tls&sni=cdn.example.com&type=ws&host=cdn.example.com#n",
+		"hysteria2": "hysteria2://secret@203.0.113.7:443?sni=cdn.example.com#n",
+	}
+	for name, link := range links {
+		t.Run(name, func(t *testing.T) {
+			node, err := ParseURL(link)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if node.Server != "203.0.113.7" {
+				t.Fatalf("server mismatch: got %q, want 203.0.113.7", node.Server)
+			}
+			tls, _ := node.Extra["tls"].(map[string]interface{})
+			if tls["server_name"] != "cdn.example.com" {
+				t.Fatalf("server_name mismatch: got %v, want cdn.example.com", tls["server_name"])
+			}
+		})
+	}
+}
+
+func TestPinResolvedServer(t *testing.T) {
+	lookup := func(host string) ([]string, error) {
+		if host != "proxy.example.com" {
+			return nil, errors.New("no such host")
+		}
+		return []string{"2001:db8::1", "203.0.113.7"}, nil
+	}
+
+	node, err := ParseURL("vless://11111111-2222-3333-4444-555555555555@proxy.example.com:443?security=tls#n")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	original := node.Extra["tls"].(map[string]interface{})
+	if err := PinResolvedServer(node, lookup); err != nil {
+		t.Fatalf("pin resolved server: %v", err)
+	}
+	if node.Server != "203.0.113.7" {
+		t.Fatalf("server mismatch: got %q, want IPv4 203.0.113.7", node.Server)
+	}
+	if sni := node.Extra["tls"].(map[string]interface{})["server_name"]; sni != "proxy.example.com" {
+		t.Fatalf("server_name mismatch: got %v, want proxy.example.com", sni)
+	}
+	if _, mutated := original["server_name"]; mutated {
+		t.Fatal("expected the parsed tls map to be copied, not mutated")
+	}
+
+	explicit, err := ParseURL("trojan://secret@proxy.example.com:443?sni=cdn.example.org#n")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := PinResolvedServer(explicit, lookup); err != nil {
+		t.Fatalf("pin resolved server: %v", err)
+	}
+	if sni := explicit.Extra["tls"].(map[string]interface{})["server_name"]; explicit.Server != "203.0.113.7" || sni != "cdn.example.org" {
+		t.Fatalf("expected explicit SNI kept with pinned IP, got server %q sni %v", explicit.Server, sni)
+	}
+
+	ipNode, err := ParseURL("trojan://secret@198.51.100.1:443#n")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := PinResolvedServer(ipNode, func(string) ([]string, error) {
+		t.Fatal("lookup must not run for IP servers")
+		return nil, nil
+	}); err != nil || ipNode.Server != "198.51.100.1" {
+		t.Fatalf("expected IP server untouched, got %q (%v)", ipNode.Server, err)
+	}
+
+	unresolvable, err := ParseURL("trojan://secret@missing.example.com:443#n")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := PinResolvedServer(unresolvable, lookup); err == nil || unresolvable.Server != "missing.example.com" {
+		t.Fatalf("expected resolve error with server unchanged, got %q (%v)", unresolvable.Server, err)
+	}
+}
+
+func TestPinResolvedServer_TransportHost(t *testing.T) {
+	lookup := func(string) ([]string, error) { return []string{"203.0.113.7"}, nil }
+
+	tests := []struct {
+		name string
+		link string
+		want func(transport map[string]interface{}) interface{}
+		host interface{}
+		sni  string
+	}{
+		{
+			name: "ws without host",
+			link: "vless://11111111-2222-3333-4444-555555555555@proxy.example.com:443?security=tls&type=ws&path=%2Fws#n",
+			want: func(tr map[string]interface{}) interface{} { return hostHeader(tr["headers"]) },
+			host: "proxy.example.com",
+			sni:  "proxy.example.com",
+		},
+		{
+			name: "ws with host",
+			link: "vless://11111111-2222-3333-4444-555555555555@proxy.example.com:443?security=tls&type=ws&host=cdn.example.org#n",
+			want: func(tr map[string]interface{}) interface{} { return hostHeader(tr["headers"]) },
+			host: "cdn.example.org",
+			sni:  "cdn.example.org",
+		},
+		{
+			name: "http without host",
+			link: "vless://11111111-2222-3333-4444-555555555555@proxy.example.com:443?security=tls&type=http&path=%2F#n",
+			want: func(tr map[string]interface{}) interface{} { return tr["host"] },
+			host: []string{"proxy.example.com"},
+			sni:  "proxy.example.com",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node, err := ParseURL(tt.link)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if err := PinResolvedServer(node, lookup); err != nil {
+				t.Fatalf("pin resolved server: %v", err)
+			}
+			transport := node.Extra["transport"].(map[string]interface{})
+			if got := tt.want(transport); !reflect.DeepEqual(got, tt.host) {
+				t.Fatalf("transport host mismatch: got %v, want %v", got, tt.host)
+			}
+			if sni := node.Extra["tls"].(map[string]interface{})["server_name"]; sni != tt.sni {
+				t.Fatalf("server_name mismatch: got %v, want %s", sni, tt.sni)
+			}
+		})
+	}
+}
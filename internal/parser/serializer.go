@@ -89,7 +89,7 @@ func formatServerPort(server string, port int) string {
 	return fmt.Sprintf("%s:%d", server, port)
 }
 
-// ss://BASE64(method:password)@server:port#name
+// ss://BASE64(method:password)@server:port[/?plugin=...&uot=1]#name
 func serializeShadowsocks(node *storage.Node) (string, error) {
 	method := extraStr(node.Extra, "method")
 	password := extraStr(node.Extra, "password")
@@ -98,8 +98,34 @@ func serializeShadowsocks(node *storage.Node) (string, error) {
 	}
 
 	userInfo := base64.URLEncoding.EncodeToString([]byte(method + ":" + password))
-	u := fmt.Sprintf("ss://%s@%s#%s", userInfo, formatServerPort(node.Server, node.ServerPort), url.PathEscape(node.Tag))
-	return u, nil
+	u := fmt.Sprintf("ss://%s@%s", userInfo, formatServerPort(node.Server, node.ServerPort))
+	var query []string
+	if plugin := extraStr(node.Extra, "plugin"); plugin != "" {
+		if opts := extraStr(node.Extra, "plugin_opts"); opts != "" {
+			plugin += ";" + opts
+		}
+		query = append(query, "plugin="+url.QueryEscape(plugin))
+	}
+	if ssUDPOverTCPEnabled(node.Extra["udp_over_tcp"]) {
+		query = append(query, "uot=1")
+	}
+	if len(query) > 0 {
+		u += "/?" + strings.Join(query, "&")
+	}
+	return u + "#" + url.PathEscape(node.Tag), nil
+}
+
+// ssUDPOverTCPEnabled reports whether a stored udp_over_tcp value (bool or
+// {enabled, version}) turns UDP over TCP on
+func ssUDPOverTCPEnabled(value interface{}) bool {
+	switch v := value.(type) {
+	case bool:
+		return v
+	case map[string]interface{}:
+		enabled, _ := v["enabled"].(bool)
+		return enabled
+	}
+	return false
 }
 
 // vmess://BASE64(json)
@@ -20,6 +20,8 @@ func (p *ShadowsocksParser) Protocol() string {
 // Parse parses a Shadowsocks URL
 // Format 1 (SIP002): ss://BASE64(method:password)@server:port#name
 // Format 2 (Legacy): ss://BASE64(method:password@server:port)#name
+// SIP002 links may carry a plugin: ss://...@server:port/?plugin=obfs-local;obfs=http#name
+// and UDP over TCP as uot=1 (udp-over-tcp=true in some clients)
 func (p *ShadowsocksParser) Parse(rawURL string) (*storage.Node, error) {
 	// Remove protocol prefix
 	rawURL = strings.TrimPrefix(rawURL, "ss://")
@@ -31,6 +33,22 @@ func (p *ShadowsocksParser) Parse(rawURL string) (*storage.Node, error) {
 		rawURL = rawURL[:idx]
 	}
 
+	// Separate query (?plugin=...) and the optional slash before it
+	var plugin, pluginOpts string
+	var udpOverTCP bool
+	dialerParams := url.Values{}
+	if idx := strings.Index(rawURL, "?"); idx != -1 {
+		query := rawURL[idx+1:]
+		plugin, pluginOpts = parseSSPlugin(ssQueryValue(query, "plugin"))
+		udpOverTCP = ssQueryFlag(query, "uot") || ssQueryFlag(query, "udp-over-tcp")
+		for _, key := range append(append([]string{}, tcpFastOpenParamKeys...), udpFragmentParamKeys...) {
+			if v := ssQueryValue(query, key); v != "" {
+				dialerParams.Set(key, v)
+			}
+		}
+		rawURL = strings.TrimSuffix(rawURL[:idx], "/")
+	}
+
 	var method, password, server string
 	var port int
 
@@ -97,7 +115,7 @@ func (p *ShadowsocksParser) Parse(rawURL string) (*storage.Node, error) {
 
 	// Set default name
 	if name == "" {
-		name = fmt.Sprintf("%s:%d", server, port)
+		name = formatServerPort(server, port)
 	}
 
 	node := &storage.Node{
@@ -112,5 +130,109 @@ func (p *ShadowsocksParser) Parse(rawURL string) (*storage.Node, error) {
 		},
 	}
 
+	if udpOverTCP {
+		node.Extra["udp_over_tcp"] = true
+	}
+	if plugin != "" {
+		node.Extra["plugin"] = plugin
+		if pluginOpts != "" {
+			node.Extra["plugin_opts"] = pluginOpts
+		}
+	}
+	applyTCPFastOpenParam(node.Extra, dialerParams)
+	applyUDPFragmentParam(node.Extra, dialerParams)
+
 	return node, nil
 }
+
+// ssQueryValue returns the unescaped value of key in a SIP002 query. url.ParseQuery is
+// not used because plugin values often carry unescaped semicolons, which it rejects.
+func ssQueryValue(query, key string) string {
+	for _, pair := range strings.Split(query, "&") {
+		k, v, _ := strings.Cut(pair, "=")
+		if k != key {
+			continue
+		}
+		if unescaped, err := url.QueryUnescape(v); err == nil {
+			return unescaped
+		}
+		return v
+	}
+	return ""
+}
+
+// ssQueryFlag reports whether key is set to a true value (1 or true) in a SIP002 query
+func ssQueryFlag(query, key string) bool {
+	switch strings.ToLower(strings.TrimSpace(ssQueryValue(query, key))) {
+	case "1", "true":
+		return true
+	}
+	return false
+}
+
+// clashUDPOverTCP converts Clash udp-over-tcp / udp-over-tcp-version into the sing-box
+// udp_over_tcp value, nil when disabled
+func clashUDPOverTCP(enabled bool, version int) interface{} {
+	if !enabled {
+		return nil
+	}
+	if version > 0 {
+		return map[string]interface{}{"enabled": true, "version": version}
+	}
+	return true
+}
+
+// parseSSPlugin splits a SIP003 plugin string "name;opt=value;..." into the plugin name
+// and its options, mapping the simple-obfs aliases to sing-box's obfs-local
+func parseSSPlugin(value string) (plugin, opts string) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return "", ""
+	}
+	plugin, opts, _ = strings.Cut(value, ";")
+	plugin = strings.TrimSpace(plugin)
+	switch plugin {
+	case "simple-obfs", "obfs":
+		plugin = "obfs-local"
+	}
+	return plugin, strings.TrimSpace(opts)
+}
+
+// clashSSPluginOpts converts Clash plugin-opts into the SIP003 option string sing-box
+// expects in plugin_opts
+func clashSSPluginOpts(plugin string, opts map[string]interface{}) string {
+	str := func(key string) string {
+		if v, ok := opts[key]; ok && v != nil {
+			return strings.TrimSpace(fmt.Sprint(v))
+		}
+		return ""
+	}
+
+	var parts []string
+	switch plugin {
+	case "obfs-local":
+		if mode := str("mode"); mode != "" {
+			parts = append(parts, "obfs="+mode)
+		}
+		if host := str("host"); host != "" {
+			parts = append(parts, "obfs-host="+host)
+		}
+	case "v2ray-plugin":
+		if mode := str("mode"); mode != "" {
+			parts = append(parts, "mode="+mode)
+		}
+		if str("tls") == "true" {
+			parts = append(parts, "tls")
+		}
+		if host := str("host"); host != "" {
+			parts = append(parts, "host="+host)
+		}
+		if path := str("path"); path != "" {
+			parts = append(parts, "path="+path)
+		}
+		if str("mux") == "true" {
+			parts = append(parts, "mux=1")
+		}
+	}
+	return strings.Join(parts, ";")
+}
@@ -0,0 +1,128 @@
+package parser
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/xiaobei/singbox-manager/internal/builder"
+)
+
+func TestShadowsocksParser_Plugin(t *testing.T) {
+	tests := []struct {
+		name       string
+		url        string
+		wantPlugin string
+		wantOpts   string
+	}{
+		{
+			name:       "obfs-local unescaped",
+			url:        "ss://YWVzLTEyOC1nY206cGFzcw@1.2.3.4:8388/?plugin=obfs-local;obfs=http;obfs-host=www.bing.com#obfs",
+			wantPlugin: "obfs-local",
+			wantOpts:   "obfs=http;obfs-host=www.bing.com",
+		},
+		{
+			name:       "simple-obfs escaped without slash",
+			url:        "ss://YWVzLTEyOC1nY206cGFzcw@1.2.3.4:8388?plugin=simple-obfs%3Bobfs%3Dtls%3Bobfs-host%3Dcdn.example.com#obfs",
+			wantPlugin: "obfs-local",
+			wantOpts:   "obfs=tls;obfs-host=cdn.example.com",
+		},
+		{
+			name:       "v2ray-plugin",
+			url:        "ss://YWVzLTEyOC1nY206cGFzcw@example.com:443/?plugin=v2ray-plugin%3Bmode%3Dwebsocket%3Btls%3Bhost%3Dexample.com%3Bpath%3D%2Fws#v2ray",
+			wantPlugin: "v2ray-plugin",
+			wantOpts:   "mode=websocket;tls;host=example.com;path=/ws",
+		},
+		{
+			name: "no plugin",
+			url:  "ss://YWVzLTEyOC1nY206cGFzcw@1.2.3.4:8388#plain",
+		},
+	}
+
+	p := &ShadowsocksParser{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node, err := p.Parse(tt.url)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if node.ServerPort == 0 || node.Extra["method"] != "aes-128-gcm" || node.Extra["password"] != "pass" {
+				t.Fatalf("node mismatch: got %+v", node)
+			}
+
+			outbound := builder.NodeToOutbound(*node)
+			plugin, _ := outbound["plugin"].(string)
+			opts, _ := outbound["plugin_opts"].(string)
+			if plugin != tt.wantPlugin || opts != tt.wantOpts {
+				t.Fatalf("plugin mismatch: got %q %q, want %q %q", plugin, opts, tt.wantPlugin, tt.wantOpts)
+			}
+			if _, has := outbound["plugin_opts"]; has && tt.wantOpts == "" {
+				t.Fatalf("expected plugin_opts to be omitted, got %v", outbound["plugin_opts"])
+			}
+
+			// Serializing keeps the plugin so exported links stay usable
+			link, err := SerializeNode(node)
+			if err != nil {
+				t.Fatalf("SerializeNode() error = %v", err)
+			}
+			again, err := p.Parse(link)
+			if err != nil {
+				t.Fatalf("re-parse %s: %v", link, err)
+			}
+			if again.Extra["plugin"] != node.Extra["plugin"] || again.Extra["plugin_opts"] != node.Extra["plugin_opts"] {
+				t.Fatalf("round trip mismatch: got %v, want %v", again.Extra, node.Extra)
+			}
+		})
+	}
+}
+
+func TestClashSSPluginOpts(t *testing.T) {
+	obfs := clashSSPluginOpts("obfs-local", map[string]interface{}{"mode": "http", "host": "bing.com"})
+	if obfs != "obfs=http;obfs-host=bing.com" {
+		t.Fatalf("obfs opts mismatch: got %q", obfs)
+	}
+	v2ray := clashSSPluginOpts("v2ray-plugin", map[string]interface{}{"mode": "websocket", "tls": true, "host": "a.com", "path": "/ws"})
+	if v2ray != "mode=websocket;tls;host=a.com;path=/ws" {
+		t.Fatalf("v2ray-plugin opts mismatch: got %q", v2ray)
+	}
+}
+
+func TestShadowsocksParser_UDPOverTCP(t *testing.T) {
+	p := &ShadowsocksParser{}
+	node, err := p.Parse("ss://YWVzLTEyOC1nY206cGFzcw@1.2.3.4:8388/?plugin=obfs-local%3Bobfs%3Dhttp&uot=1#uot")
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if node.Extra["udp_over_tcp"] != true || node.Extra["plugin"] != "obfs-local" {
+		t.Fatalf("extra mismatch: got %v", node.Extra)
+	}
+
+	link, err := SerializeNode(node)
+	if err != nil {
+		t.Fatalf("SerializeNode() error = %v", err)
+	}
+	again, err := p.Parse(link)
+	if err != nil {
+		t.Fatalf("re-parse %s: %v", link, err)
+	}
+	if again.Extra["udp_over_tcp"] != true || again.Extra["plugin_opts"] != "obfs=http" {
+		t.Fatalf("round trip mismatch: got %v from %s", again.Extra, link)
+	}
+
+	plain, err := p.Parse("ss://YWVzLTEyOC1nY206cGFzcw@1.2.3.4:8388/?uot=0#plain")
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if _, has := plain.Extra["udp_over_tcp"]; has {
+		t.Fatalf("expected no udp_over_tcp, got %v", plain.Extra)
+	}
+
+	if got := clashUDPOverTCP(true, 2); !reflect.DeepEqual(got, map[string]interface{}{"enabled": true, "version": 2}) {
+		t.Fatalf("clash versioned mismatch: got %v", got)
+	}
+	if got := clashUDPOverTCP(true, 0); got != true {
+		t.Fatalf("clash boolean mismatch: got %v", got)
+	}
+	if got := clashUDPOverTCP(false, 2); got != nil {
+		t.Fatalf("clash disabled mismatch: got %v", got)
+	}
+}
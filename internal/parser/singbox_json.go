@@ -0,0 +1,87 @@
+package parser
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/xiaobei/singbox-manager/internal/storage"
+)
+
+// importableOutboundTypes are the sing-box outbound types that map to proxy nodes
+var importableOutboundTypes = map[string]bool{
+	"shadowsocks": true,
+	"vmess":       true,
+	"vless":       true,
+	"trojan":      true,
+	"hysteria2":   true,
+	"tuic":        true,
+	"socks":       true,
+	"http":        true,
+}
+
+// DecodeSingboxOutbounds decodes a sing-box outbound array, or a full config
+// with an "outbounds" key, into raw outbound objects.
+func DecodeSingboxOutbounds(content string) ([]map[string]interface{}, error) {
+	content = strings.TrimSpace(content)
+
+	var outbounds []map[string]interface{}
+	if strings.HasPrefix(content, "{") {
+		var config struct {
+			Outbounds []map[string]interface{} `json:"outbounds"`
+		}
+		if err := json.Unmarshal([]byte(content), &config); err != nil {
+			return nil, fmt.Errorf("failed to parse JSON: %w", err)
+		}
+		outbounds = config.Outbounds
+	} else if err := json.Unmarshal([]byte(content), &outbounds); err != nil {
+		return nil, fmt.Errorf("failed to parse JSON: %w", err)
+	}
+
+	if len(outbounds) == 0 {
+		return nil, fmt.Errorf("no outbounds found")
+	}
+	return outbounds, nil
+}
+
+// ParseSingboxOutbound converts a sing-box outbound object to a node.
+// Protocol-specific fields are kept as-is in Extra.
+func ParseSingboxOutbound(outbound map[string]interface{}) (*storage.Node, error) {
+	outboundType, _ := outbound["type"].(string)
+	if !importableOutboundTypes[outboundType] {
+		return nil, fmt.Errorf("unsupported outbound type: %q", outboundType)
+	}
+
+	server, _ := outbound["server"].(string)
+	server = storage.NormalizeServer(server)
+	if server == "" {
+		return nil, fmt.Errorf("outbound is missing server")
+	}
+	portValue, ok := outbound["server_port"].(float64)
+	port := int(portValue)
+	if !ok || port <= 0 || port > 65535 || float64(port) != portValue {
+		return nil, fmt.Errorf("invalid server_port: %v", outbound["server_port"])
+	}
+
+	tag, _ := outbound["tag"].(string)
+	if strings.TrimSpace(tag) == "" {
+		tag = fmt.Sprintf("%s-%s", outboundType, formatServerPort(server, port))
+	}
+
+	extra := make(map[string]interface{}, len(outbound))
+	for k, v := range outbound {
+		switch k {
+		case "tag", "type", "server", "server_port":
+			continue
+		}
+		extra[k] = v
+	}
+
+	return &storage.Node{
+		Tag:        tag,
+		Type:       outboundType,
+		Server:     server,
+		ServerPort: port,
+		Extra:      extra,
+	}, nil
+}
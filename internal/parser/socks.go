@@ -81,7 +81,7 @@ func (p *SocksParser) Parse(rawURL string) (*storage.Node, error) {
 
 	// Set default name
 	if name == "" {
-		name = fmt.Sprintf("%s:%d", server, port)
+		name = formatServerPort(server, port)
 	}
 
 	// Build Extra
@@ -114,6 +114,8 @@ func (p *SocksParser) Parse(rawURL string) (*storage.Node, error) {
 			"enabled": true,
 		}
 	}
+	applyTCPFastOpenParam(extra, params)
+	applyUDPFragmentParam(extra, params)
 
 	node := &storage.Node{
 		Tag:        name,
@@ -41,7 +41,7 @@ func (p *TrojanParser) Parse(rawURL string) (*storage.Node, error) {
 
 	// Set default name
 	if name == "" {
-		name = fmt.Sprintf("%s:%d", server, port)
+		name = formatServerPort(server, port)
 	}
 
 	// Build Extra
@@ -104,15 +104,16 @@ func (p *TrojanParser) Parse(rawURL string) (*storage.Node, error) {
 		}
 
 		// Skip certificate verification
-		if getParamBool(params, "allowInsecure") || getParamBool(params, "insecure") {
-			tls["insecure"] = true
-		}
+		applyTLSInsecure(tls, paramsInsecure(params))
 
 		// ALPN
 		if alpn := params.Get("alpn"); alpn != "" {
 			tls["alpn"] = strings.Split(alpn, ",")
 		}
 
+		// Encrypted Client Hello
+		applyECHParams(params, tls)
+
 		// Reality configuration
 		if security == "reality" {
 			reality := map[string]interface{}{
@@ -127,7 +128,10 @@ func (p *TrojanParser) Parse(rawURL string) (*storage.Node, error) {
 			tls["reality"] = reality
 
 			// uTLS fingerprint
-			fp := strings.TrimSpace(getParamString(params, "fp", "chrome"))
+			fp := fingerprintParam(params)
+			if fp == "" {
+				fp = "chrome"
+			}
 			if err := validateFingerprint(fp); err != nil {
 				return nil, err
 			}
@@ -135,7 +139,7 @@ func (p *TrojanParser) Parse(rawURL string) (*storage.Node, error) {
 				"enabled":     true,
 				"fingerprint": fp,
 			}
-		} else if fp := strings.TrimSpace(params.Get("fp")); fp != "" {
+		} else if fp := fingerprintParam(params); fp != "" {
 			if err := validateFingerprint(fp); err != nil {
 				return nil, err
 			}
@@ -148,6 +152,13 @@ func (p *TrojanParser) Parse(rawURL string) (*storage.Node, error) {
 		extra["tls"] = tls
 	}
 
+	// Multiplex
+	if mux := multiplexFromParams(params); mux != nil {
+		extra["multiplex"] = mux
+	}
+	applyTCPFastOpenParam(extra, params)
+	applyUDPFragmentParam(extra, params)
+
 	node := &storage.Node{
 		Tag:        name,
 		Type:       "trojan",
@@ -52,7 +52,7 @@ func (p *TuicParser) Parse(rawURL string) (*storage.Node, error) {
 
 	// Set default name
 	if name == "" {
-		name = fmt.Sprintf("%s:%d", server, port)
+		name = formatServerPort(server, port)
 	}
 
 	// Build Extra
@@ -72,9 +72,7 @@ func (p *TuicParser) Parse(rawURL string) (*storage.Node, error) {
 	}
 
 	// Skip certificate verification
-	if getParamBool(params, "insecure") || getParamBool(params, "allowInsecure") || getParamBool(params, "skip-cert-verify") {
-		tls["insecure"] = true
-	}
+	applyTLSInsecure(tls, paramsInsecure(params))
 
 	// ALPN
 	if alpn := params.Get("alpn"); alpn != "" {
@@ -111,6 +109,7 @@ func (p *TuicParser) Parse(rawURL string) (*storage.Node, error) {
 	if heartbeat := params.Get("heartbeat"); heartbeat != "" {
 		extra["heartbeat"] = heartbeat
 	}
+	applyUDPFragmentParam(extra, params)
 
 	node := &storage.Node{
 		Tag:        name,
@@ -40,7 +40,7 @@ func (p *VlessParser) Parse(rawURL string) (*storage.Node, error) {
 
 	// Set default name
 	if name == "" {
-		name = fmt.Sprintf("%s:%d", server, port)
+		name = formatServerPort(server, port)
 	}
 
 	// Build Extra
@@ -54,7 +54,7 @@ func (p *VlessParser) Parse(rawURL string) (*storage.Node, error) {
 	}
 
 	// Packet encoding (e.g. xudp)
-	if pe := params.Get("packetEncoding"); pe != "" {
+	if pe := normalizePacketEncoding(params.Get("packetEncoding")); pe != "" {
 		extra["packet_encoding"] = pe
 	}
 
@@ -119,15 +119,19 @@ func (p *VlessParser) Parse(rawURL string) (*storage.Node, error) {
 		}
 
 		// Skip certificate verification
-		if getParamBool(params, "allowInsecure") || getParamBool(params, "insecure") {
-			tls["insecure"] = true
-		}
+		applyTLSInsecure(tls, paramsInsecure(params))
 
 		// ALPN
 		if alpn := params.Get("alpn"); alpn != "" {
 			tls["alpn"] = strings.Split(alpn, ",")
 		}
 
+		// TLS fragment
+		applyTLSFragmentParams(params, tls)
+
+		// Encrypted Client Hello
+		applyECHParams(params, tls)
+
 		// Reality configuration
 		if security == "reality" {
 			reality := map[string]interface{}{
@@ -142,7 +146,10 @@ func (p *VlessParser) Parse(rawURL string) (*storage.Node, error) {
 			tls["reality"] = reality
 
 			// uTLS fingerprint
-			fp := strings.TrimSpace(getParamString(params, "fp", "chrome"))
+			fp := fingerprintParam(params)
+			if fp == "" {
+				fp = "chrome"
+			}
 			if err := validateFingerprint(fp); err != nil {
 				return nil, err
 			}
@@ -150,7 +157,7 @@ func (p *VlessParser) Parse(rawURL string) (*storage.Node, error) {
 				"enabled":     true,
 				"fingerprint": fp,
 			}
-		} else if fp := strings.TrimSpace(params.Get("fp")); fp != "" {
+		} else if fp := fingerprintParam(params); fp != "" {
 			// uTLS for regular TLS
 			if err := validateFingerprint(fp); err != nil {
 				return nil, err
@@ -164,6 +171,13 @@ func (p *VlessParser) Parse(rawURL string) (*storage.Node, error) {
 		extra["tls"] = tls
 	}
 
+	// Multiplex
+	if mux := multiplexFromParams(params); mux != nil {
+		extra["multiplex"] = mux
+	}
+	applyTCPFastOpenParam(extra, params)
+	applyUDPFragmentParam(extra, params)
+
 	node := &storage.Node{
 		Tag:        name,
 		Type:       "vless",
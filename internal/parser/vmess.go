@@ -36,7 +36,16 @@ type vmessConfig struct {
 	SNI  string      `json:"sni"`            // SNI
 	ALPN string      `json:"alpn"`           // ALPN
 	Fp   string      `json:"fp"`             // Fingerprint
-	Skip bool        `json:"skip-cert-verify"` // skip certificate verification
+	Skip interface{} `json:"skip-cert-verify"` // skip certificate verification
+
+	AllowInsecure interface{} `json:"allowInsecure,omitempty"` // skip certificate verification (v2rayN spelling)
+	Insecure      interface{} `json:"insecure,omitempty"`      // skip certificate verification
+
+	PacketEncoding string `json:"packetEncoding"` // packet encoding (packet, xudp)
+	Fragment       string `json:"fragment"`       // TLS fragment
+
+	TFO         interface{} `json:"tfo,omitempty"`          // TCP Fast Open
+	UDPFragment interface{} `json:"udp_fragment,omitempty"` // UDP fragmentation
 }
 
 // Parse parses a VMess URL
@@ -92,7 +101,7 @@ func (p *VmessParser) Parse(rawURL string) (*storage.Node, error) {
 		name = fragmentName
 	}
 	if name == "" {
-		name = fmt.Sprintf("%s:%d", config.Add, port)
+		name = formatServerPort(config.Add, port)
 	}
 
 	// Build Extra
@@ -107,6 +116,19 @@ func (p *VmessParser) Parse(rawURL string) (*storage.Node, error) {
 		extra["security"] = "auto"
 	}
 
+	// Dialer options. An explicit false is kept so it overrides the global default.
+	if config.TFO != nil {
+		extra["tcp_fast_open"] = isTruthy(config.TFO)
+	}
+	if config.UDPFragment != nil {
+		extra["udp_fragment"] = isTruthy(config.UDPFragment)
+	}
+
+	// Packet encoding (e.g. xudp)
+	if pe := normalizePacketEncoding(config.PacketEncoding); pe != "" {
+		extra["packet_encoding"] = pe
+	}
+
 	// Transport layer configuration
 	network := config.Net
 	if network == "" {
@@ -164,9 +186,7 @@ func (p *VmessParser) Parse(rawURL string) (*storage.Node, error) {
 			// This ensures the correct SNI is used during TLS handshake
 			tls["server_name"] = config.Add
 		}
-		if config.Skip {
-			tls["insecure"] = true
-		}
+		applyTLSInsecure(tls, isTruthy(config.Skip) || isTruthy(config.AllowInsecure) || isTruthy(config.Insecure))
 		if config.Fp != "" {
 			tls["utls"] = map[string]interface{}{
 				"enabled":     true,
@@ -176,6 +196,9 @@ func (p *VmessParser) Parse(rawURL string) (*storage.Node, error) {
 		if config.ALPN != "" {
 			tls["alpn"] = strings.Split(config.ALPN, ",")
 		}
+		if config.Fragment != "" {
+			applyTLSFragmentParams(url.Values{"fragment": {config.Fragment}}, tls)
+		}
 		extra["tls"] = tls
 	}
 
@@ -13,8 +13,9 @@ import (
 type Scheduler struct {
 	store      storage.Store
 	subService *SubscriptionService
-	onUpdate   func() error // Callback after subscription update
-	onVerify   func()       // Callback to run verification cycle
+	onUpdate   func() error                     // Callback after subscription update
+	onVerify   func()                           // Callback to run verification cycle
+	onHealth   func(nodes []storage.Node) error // Callback to health-check nodes and record measurements
 	eventBus   *events.Bus
 
 	stopCh            chan struct{}
@@ -26,10 +27,20 @@ type Scheduler struct {
 	lastVerifyTime    *time.Time
 	nextSubUpdateTime *time.Time
 	nextVerifyTime    *time.Time
+	pause             *SchedulerPause
+	resumeTimer       *time.Timer
+	resumeRunning     bool // Whether resuming should start the scheduler again
 	mu                sync.Mutex
 	workersWG         sync.WaitGroup
 }
 
+// SchedulerPause describes why the scheduler is paused and when it resumes on its own
+type SchedulerPause struct {
+	Reason   string     `json:"reason"`
+	PausedAt time.Time  `json:"paused_at"`
+	ResumeAt *time.Time `json:"resume_at,omitempty"` // nil means it stays paused until resumed
+}
+
 // NewScheduler creates a scheduler
 func NewScheduler(store storage.Store, subService *SubscriptionService) *Scheduler {
 	return &Scheduler{
@@ -50,6 +61,12 @@ func (s *Scheduler) SetVerificationCallback(callback func()) {
 	s.onVerify = callback
 }
 
+// SetHealthCheckCallback sets the callback used by the auto-promote pipeline
+// to health-check pending subscription nodes
+func (s *Scheduler) SetHealthCheckCallback(callback func(nodes []storage.Node) error) {
+	s.onHealth = callback
+}
+
 // SetEventBus sets the event bus for publishing pipeline events
 func (s *Scheduler) SetEventBus(bus *events.Bus) {
 	s.eventBus = bus
@@ -69,6 +86,7 @@ func (s *Scheduler) Start() StartStatus {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	s.clearPauseLocked()
 	if s.running {
 		return StartStatusAlreadyRunning
 	}
@@ -117,6 +135,7 @@ func (s *Scheduler) Start() StartStatus {
 // Stop stops the scheduler
 func (s *Scheduler) Stop() {
 	s.mu.Lock()
+	s.clearPauseLocked()
 	if !s.running {
 		s.mu.Unlock()
 		return
@@ -144,6 +163,87 @@ func (s *Scheduler) Restart() {
 	s.Start()
 }
 
+// Pause stops the scheduler and records why. With a positive duration it
+// resumes on its own afterwards; Start, Stop and Resume all end the pause early.
+// Resuming only starts the scheduler again if it was running when paused.
+func (s *Scheduler) Pause(reason string, d time.Duration) {
+	s.mu.Lock()
+	resumeRunning := s.running || (s.pause != nil && s.resumeRunning)
+	s.mu.Unlock()
+
+	s.Stop()
+
+	s.mu.Lock()
+	now := time.Now()
+	pause := &SchedulerPause{Reason: reason, PausedAt: now}
+	if d > 0 {
+		resumeAt := now.Add(d)
+		pause.ResumeAt = &resumeAt
+		s.resumeTimer = time.AfterFunc(d, func() { s.resume(pause) })
+	}
+	s.pause = pause
+	s.resumeRunning = resumeRunning
+	s.mu.Unlock()
+
+	if s.eventBus != nil {
+		s.eventBus.PublishTimestamped("pipeline:pause", map[string]interface{}{
+			"reason":    reason,
+			"resume_at": pause.ResumeAt,
+		})
+	}
+	if pause.ResumeAt != nil {
+		log.Printf("[Scheduler] Paused (%s) until %s", reason, pause.ResumeAt.Format(time.RFC3339))
+	} else {
+		log.Printf("[Scheduler] Paused (%s)", reason)
+	}
+}
+
+// Resume ends the current pause. It returns false if the scheduler was not paused.
+func (s *Scheduler) Resume() bool {
+	return s.resume(nil)
+}
+
+// resume ends the pause if it is still the expected one (nil matches any pause),
+// so a stale auto-resume timer cannot lift a newer pause
+func (s *Scheduler) resume(expected *SchedulerPause) bool {
+	s.mu.Lock()
+	if s.pause == nil || (expected != nil && s.pause != expected) {
+		s.mu.Unlock()
+		return false
+	}
+	reason := s.pause.Reason
+	restart := s.resumeRunning
+	s.clearPauseLocked()
+	s.mu.Unlock()
+
+	log.Printf("[Scheduler] Resumed after pause (%s)", reason)
+	if restart {
+		s.Start()
+	}
+	return true
+}
+
+// clearPauseLocked drops the pause state and its auto-resume timer; s.mu must be held
+func (s *Scheduler) clearPauseLocked() {
+	if s.resumeTimer != nil {
+		s.resumeTimer.Stop()
+		s.resumeTimer = nil
+	}
+	s.pause = nil
+	s.resumeRunning = false
+}
+
+// GetPause returns the current pause, or nil if the scheduler is not paused
+func (s *Scheduler) GetPause() *SchedulerPause {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.pause == nil {
+		return nil
+	}
+	pause := *s.pause
+	return &pause
+}
+
 // IsRunning checks if the scheduler is running
 func (s *Scheduler) IsRunning() bool {
 	s.mu.Lock()
@@ -223,6 +323,42 @@ func (s *Scheduler) runVerification() {
 	s.mu.Unlock()
 
 	log.Println("[Scheduler] Verification completed")
+
+	s.archiveLowUptimeNodes()
+	s.rollupHealthHistory()
+}
+
+// archiveLowUptimeNodes archives flaky verified nodes and re-applies the config if any were archived
+func (s *Scheduler) archiveLowUptimeNodes() {
+	archived, err := ArchiveLowUptimeNodes(s.store, time.Now())
+	if err != nil {
+		log.Printf("[Scheduler] Uptime archiving error: %v\n", err)
+	}
+	if archived == 0 {
+		return
+	}
+	log.Printf("[Scheduler] Archived %d node(s) below minimum uptime\n", archived)
+	if s.onUpdate != nil {
+		if err := s.onUpdate(); err != nil {
+			log.Printf("[Scheduler] Failed to auto-apply config: %v\n", err)
+		}
+	}
+}
+
+// rollupHealthHistory folds raw health measurements past the retention window into daily rollups
+func (s *Scheduler) rollupHealthHistory() {
+	days := s.store.GetSettings().HealthRetentionDays
+	if days <= 0 {
+		return
+	}
+	pruned, err := s.store.RollupHealthMeasurements(time.Now().AddDate(0, 0, -days))
+	if err != nil {
+		log.Printf("[Scheduler] Health rollup error: %v\n", err)
+		return
+	}
+	if pruned > 0 {
+		log.Printf("[Scheduler] Rolled up %d health measurement(s) older than %d day(s)\n", pruned, days)
+	}
 }
 
 // MarkManualVerificationRun marks a manually-triggered verification as completed and
@@ -246,12 +382,14 @@ func (s *Scheduler) MarkManualVerificationRun() {
 func (s *Scheduler) updateSubscriptions() {
 	log.Println("[Scheduler] Starting automatic subscription update...")
 
-	if err := s.subService.RefreshAll(); err != nil {
-		log.Printf("[Scheduler] Failed to update subscription: %v\n", err)
-		return
+	result, err := s.subService.RefreshAll()
+	if err != nil {
+		log.Printf("[Scheduler] Failed to update some subscriptions: %v\n", err)
 	}
 
-	log.Println("[Scheduler] Subscription update completed")
+	log.Printf("[Scheduler] Subscription update completed: %d refreshed, %d failed\n", result.Refreshed, len(result.Failed))
+
+	s.runSubscriptionPipelines()
 
 	// Call update callback (auto-apply config)
 	if s.onUpdate != nil {
@@ -263,6 +401,32 @@ func (s *Scheduler) updateSubscriptions() {
 	}
 }
 
+// runSubscriptionPipelines health-checks pending nodes of auto-pipeline subscriptions
+// and promotes or removes them according to each subscription's settings
+func (s *Scheduler) runSubscriptionPipelines() {
+	subs := s.store.GetSubscriptions()
+	nodes := pendingPipelineNodes(s.store, subs)
+	if len(nodes) == 0 {
+		return
+	}
+
+	if s.onHealth != nil {
+		if err := s.onHealth(nodes); err != nil {
+			log.Printf("[Scheduler] Pipeline health check failed: %v\n", err)
+			return
+		}
+	}
+
+	for _, sub := range subs {
+		if !sub.Enabled || !sub.AutoPipeline {
+			continue
+		}
+		if _, err := RunSubscriptionPipeline(s.store, sub); err != nil {
+			log.Printf("[Scheduler] Pipeline for subscription %s failed: %v\n", sub.Name, err)
+		}
+	}
+}
+
 // GetNextUpdateTime gets the next subscription update time
 func (s *Scheduler) GetNextUpdateTime() *time.Time {
 	s.mu.Lock()
@@ -0,0 +1,48 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/xiaobei/singbox-manager/internal/storage"
+)
+
+func TestPause_ReportsReasonAndResumesAfterDeadline(t *testing.T) {
+	store, err := storage.NewSQLiteStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("create sqlite store: %v", err)
+	}
+	t.Cleanup(func() { _ = store.Close() })
+
+	settings := store.GetSettings()
+	settings.SubscriptionInterval = 60
+	if err := store.UpdateSettings(settings); err != nil {
+		t.Fatalf("update settings: %v", err)
+	}
+
+	scheduler := NewScheduler(store, NewSubscriptionService(store))
+	if status := scheduler.Start(); status != StartStatusOK {
+		t.Fatalf("start status mismatch: got %v, want %v", status, StartStatusOK)
+	}
+	t.Cleanup(scheduler.Stop)
+
+	scheduler.Pause("database import", 50*time.Millisecond)
+	if scheduler.IsRunning() {
+		t.Fatalf("expected the scheduler to stop while paused")
+	}
+	pause := scheduler.GetPause()
+	if pause == nil || pause.Reason != "database import" || pause.ResumeAt == nil {
+		t.Fatalf("pause mismatch: got %+v, want reason and resume time", pause)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for !scheduler.IsRunning() {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected the scheduler to resume after the pause deadline")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if pause := scheduler.GetPause(); pause != nil {
+		t.Fatalf("expected the pause to be cleared after resuming, got %+v", pause)
+	}
+}
@@ -1,8 +1,10 @@
 package service
 
 import (
+	"errors"
 	"fmt"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/google/uuid"
@@ -12,10 +14,39 @@ import (
 	"github.com/xiaobei/singbox-manager/pkg/utils"
 )
 
+// subscriptionRefreshWorkers bounds how many subscriptions RefreshAll fetches at once
+const subscriptionRefreshWorkers = 4
+
 // SubscriptionService handles subscription operations
 type SubscriptionService struct {
 	store    storage.Store
 	eventBus *events.Bus
+	subLocks sync.Map // subscription ID -> *sync.Mutex, serializes refreshes of one subscription
+}
+
+// SubscriptionRefreshError describes a subscription that failed to refresh
+type SubscriptionRefreshError struct {
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	Error string `json:"error"`
+}
+
+// RefreshAllResult summarizes a RefreshAll run
+type RefreshAllResult struct {
+	Refreshed int                        `json:"refreshed"`
+	Failed    []SubscriptionRefreshError `json:"failed"`
+	Added     int                        `json:"added"`
+	Updated   int                        `json:"updated"`
+	Missing   int                        `json:"missing"`
+	Total     int                        `json:"total"`
+}
+
+// lockSubscription locks the refresh mutex of a subscription and returns its unlock func
+func (s *SubscriptionService) lockSubscription(id string) func() {
+	mu, _ := s.subLocks.LoadOrStore(id, &sync.Mutex{})
+	m := mu.(*sync.Mutex)
+	m.Lock()
+	return m.Unlock
 }
 
 // NewSubscriptionService creates a new subscription service
@@ -41,15 +72,17 @@ func (s *SubscriptionService) Get(id string) *storage.Subscription {
 }
 
 // Add adds a subscription
-func (s *SubscriptionService) Add(name, url string) (*storage.Subscription, error) {
+func (s *SubscriptionService) Add(name, url string, auth storage.SubscriptionAuth, pipeline storage.SubscriptionPipeline) (*storage.Subscription, error) {
 	sub := storage.Subscription{
-		ID:        uuid.New().String(),
-		Name:      name,
-		URL:       url,
-		NodeCount: 0,
-		UpdatedAt: time.Now(),
-		Nodes:     []storage.Node{},
-		Enabled:   true,
+		ID:                   uuid.New().String(),
+		Name:                 name,
+		URL:                  url,
+		NodeCount:            0,
+		UpdatedAt:            time.Now(),
+		Nodes:                []storage.Node{},
+		Enabled:              true,
+		SubscriptionAuth:     auth,
+		SubscriptionPipeline: pipeline,
 	}
 
 	// Fetch and parse subscription
@@ -63,14 +96,8 @@ func (s *SubscriptionService) Add(name, url string) (*storage.Subscription, erro
 	}
 
 	// Sync nodes to unified nodes table as pending
-	added, total, _ := s.syncToUnifiedNodes(&sub)
-	if s.eventBus != nil {
-		s.eventBus.Publish("sub:nodes_synced", map[string]interface{}{
-			"total":   total,
-			"added":   added,
-			"skipped": total - added,
-		})
-	}
+	synced, _ := s.syncToUnifiedNodes(&sub)
+	s.publishNodesSynced(synced, len(sub.Nodes))
 
 	return &sub, nil
 }
@@ -87,6 +114,9 @@ func (s *SubscriptionService) Delete(id string) error {
 
 // Refresh refreshes a subscription
 func (s *SubscriptionService) Refresh(id string) error {
+	unlock := s.lockSubscription(id)
+	defer unlock()
+
 	sub := s.store.GetSubscription(id)
 	if sub == nil {
 		return fmt.Errorf("subscription not found: %s", id)
@@ -108,56 +138,119 @@ func (s *SubscriptionService) Refresh(id string) error {
 		})
 	}
 
-	// Sync new nodes to unified nodes table as pending
-	added, total, _ := s.syncToUnifiedNodes(sub)
-	if s.eventBus != nil {
-		s.eventBus.Publish("sub:nodes_synced", map[string]interface{}{
-			"total":   total,
-			"added":   added,
-			"skipped": total - added,
-		})
-	}
+	// Sync new and changed nodes to unified nodes table
+	synced, _ := s.syncToUnifiedNodes(sub)
+	s.publishNodesSynced(synced, len(sub.Nodes))
 
 	return nil
 }
 
-// RefreshAll refreshes all subscriptions
-func (s *SubscriptionService) RefreshAll() error {
-	subs := s.store.GetSubscriptions()
-	var totalAdded, totalAll int
-	for _, sub := range subs {
+// RefreshAll refreshes all enabled subscriptions concurrently with a bounded
+// worker pool. A failing subscription does not stop the others: failures are
+// collected in the result and also returned joined as the error.
+func (s *SubscriptionService) RefreshAll() (RefreshAllResult, error) {
+	result := RefreshAllResult{Failed: []SubscriptionRefreshError{}}
+
+	var enabled []storage.Subscription
+	for _, sub := range s.store.GetSubscriptions() {
 		if sub.Enabled {
-			if err := s.refresh(&sub); err != nil {
-				// Log error but continue processing other subscriptions
-				continue
-			}
-			if err := s.store.UpdateSubscription(sub); err != nil {
-				continue
-			}
-			added, total, _ := s.syncToUnifiedNodes(&sub)
-			totalAdded += added
-			totalAll += total
+			enabled = append(enabled, sub)
 		}
 	}
-	if s.eventBus != nil && totalAll > 0 {
-		s.eventBus.Publish("sub:nodes_synced", map[string]interface{}{
-			"total":   totalAll,
-			"added":   totalAdded,
-			"skipped": totalAll - totalAdded,
-		})
+
+	var mu sync.Mutex
+	var errs []error
+	var synced storage.NodeSyncResult
+	sem := make(chan struct{}, subscriptionRefreshWorkers)
+	var wg sync.WaitGroup
+
+	for _, sub := range enabled {
+		wg.Add(1)
+		go func(sub storage.Subscription) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			subSynced, total, err := s.refreshAndSync(sub.ID)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				result.Failed = append(result.Failed, SubscriptionRefreshError{ID: sub.ID, Name: sub.Name, Error: err.Error()})
+				errs = append(errs, fmt.Errorf("%s: %w", sub.Name, err))
+				return
+			}
+			result.Refreshed++
+			synced.Added += subSynced.Added
+			synced.Updated += subSynced.Updated
+			synced.Unchanged += subSynced.Unchanged
+			synced.Restored += subSynced.Restored
+			synced.Missing += subSynced.Missing
+			synced.Skipped += subSynced.Skipped
+			result.Total += total
+		}(sub)
 	}
-	return nil
+	wg.Wait()
+
+	result.Added = synced.Added
+	result.Updated = synced.Updated
+	result.Missing = synced.Missing
+	if result.Total > 0 {
+		s.publishNodesSynced(synced, result.Total)
+	}
+	return result, errors.Join(errs...)
+}
+
+// publishNodesSynced announces the outcome of syncing total subscription nodes
+func (s *SubscriptionService) publishNodesSynced(synced storage.NodeSyncResult, total int) {
+	if s.eventBus == nil {
+		return
+	}
+	s.eventBus.Publish("sub:nodes_synced", map[string]interface{}{
+		"total":     total,
+		"added":     synced.Added,
+		"updated":   synced.Updated,
+		"unchanged": synced.Unchanged,
+		"restored":  synced.Restored,
+		"missing":   synced.Missing,
+		"skipped":   synced.Skipped,
+	})
 }
 
-// syncToUnifiedNodes converts subscription nodes to unified nodes (pending) with deduplication.
-// Returns (added, total, error).
-func (s *SubscriptionService) syncToUnifiedNodes(sub *storage.Subscription) (int, int, error) {
+// refreshAndSync refreshes one subscription under its lock, saves it and syncs
+// its nodes to the unified nodes table. Returns (sync result, total, error).
+func (s *SubscriptionService) refreshAndSync(id string) (storage.NodeSyncResult, int, error) {
+	unlock := s.lockSubscription(id)
+	defer unlock()
+
+	// Re-read under the lock so a concurrent edit or refresh is not overwritten
+	sub := s.store.GetSubscription(id)
+	if sub == nil {
+		return storage.NodeSyncResult{}, 0, fmt.Errorf("subscription not found: %s", id)
+	}
+	if err := s.refresh(sub); err != nil {
+		return storage.NodeSyncResult{}, 0, err
+	}
+	if err := s.store.UpdateSubscription(*sub); err != nil {
+		return storage.NodeSyncResult{}, 0, fmt.Errorf("failed to save subscription: %w", err)
+	}
+	synced, _ := s.syncToUnifiedNodes(sub)
+	return synced, len(sub.Nodes), nil
+}
+
+// syncToUnifiedNodes diffs subscription nodes against the unified nodes the subscription
+// owns: new nodes are added as pending, changed ones updated in place and vanished ones
+// marked missing, so curation of unchanged nodes survives a refresh.
+func (s *SubscriptionService) syncToUnifiedNodes(sub *storage.Subscription) (storage.NodeSyncResult, error) {
+	// An empty list is more likely a broken response than a subscription that dropped
+	// every node, so it does not mark everything missing
 	if len(sub.Nodes) == 0 {
-		return 0, 0, nil
+		return storage.NodeSyncResult{}, nil
 	}
 
+	tagTemplate := s.store.GetSettings().NodeTagTemplate
 	var unified []storage.UnifiedNode
-	for _, n := range sub.Nodes {
+	for i, n := range sub.Nodes {
 		sourceTag := strings.TrimSpace(n.Tag)
 		prefix := "NODE"
 		if cc := strings.ToUpper(strings.TrimSpace(n.Country)); cc != "" {
@@ -165,7 +258,7 @@ func (s *SubscriptionService) syncToUnifiedNodes(sub *storage.Subscription) (int
 		}
 		displayName := fmt.Sprintf("%s %s:%d", prefix, n.Server, n.ServerPort)
 
-		unified = append(unified, storage.UnifiedNode{
+		node := storage.UnifiedNode{
 			Tag:          displayName,
 			DisplayName:  displayName,
 			SourceTag:    sourceTag,
@@ -177,17 +270,21 @@ func (s *SubscriptionService) syncToUnifiedNodes(sub *storage.Subscription) (int
 			Extra:        n.Extra,
 			Status:       storage.NodeStatusPending,
 			Source:       sub.ID,
-		})
+			SourceURL:    n.SourceURL,
+		}
+		if name := storage.RenderNodeTagTemplate(tagTemplate, node, i+1); name != "" {
+			node.DisplayName = name
+		}
+		unified = append(unified, node)
 	}
 
-	added, err := s.store.AddNodesBulk(unified)
-	return added, len(unified), err
+	return s.store.SyncSubscriptionNodes(sub.ID, unified)
 }
 
 // refresh internal refresh method
 func (s *SubscriptionService) refresh(sub *storage.Subscription) error {
 	// Fetch subscription content
-	content, info, err := utils.FetchSubscription(sub.URL)
+	content, info, err := utils.FetchSubscriptionWithHeaders(sub.URL, sub.SubscriptionAuth.Headers())
 	if err != nil {
 		return fmt.Errorf("failed to fetch subscription: %w", err)
 	}
@@ -0,0 +1,115 @@
+package service
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/xiaobei/singbox-manager/internal/storage"
+)
+
+// pipelineAction is what the auto-promote pipeline does with a pending node
+type pipelineAction int
+
+const (
+	pipelineKeep    pipelineAction = iota // Not enough evidence yet, leave pending
+	pipelinePromote                       // Stable enough, promote to verified
+	pipelineRemove                        // Never alive, delete from the pool
+)
+
+// PipelineResult summarizes one auto-promote pipeline run for a subscription
+type PipelineResult struct {
+	Checked  int `json:"checked"`
+	Promoted int `json:"promoted"`
+	Removed  int `json:"removed"`
+}
+
+// decidePipelineAction decides the fate of a pending node from its health history.
+// Nodes need minUptimeSamples checks before any decision is made.
+func decidePipelineAction(cfg storage.SubscriptionPipeline, stats *storage.HealthStats) pipelineAction {
+	if stats == nil || stats.TotalChecks < minUptimeSamples {
+		return pipelineKeep
+	}
+	if stats.AliveChecks == 0 {
+		if cfg.PipelineRemoveDead {
+			return pipelineRemove
+		}
+		return pipelineKeep
+	}
+	if stats.UptimePercent >= cfg.PipelineMinStability {
+		return pipelinePromote
+	}
+	return pipelineKeep
+}
+
+// RunSubscriptionPipeline promotes or removes the pending nodes of a subscription
+// according to its pipeline settings. Health measurements must already be recorded.
+func RunSubscriptionPipeline(store storage.Store, sub storage.Subscription) (PipelineResult, error) {
+	var result PipelineResult
+	if !sub.AutoPipeline {
+		return result, nil
+	}
+
+	var errs []error
+	for _, node := range store.GetNodesBySource(sub.ID) {
+		if node.Status != storage.NodeStatusPending {
+			continue
+		}
+		stats, err := store.GetHealthStats(node.Server, node.ServerPort)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("health stats for %s: %w", node.Tag, err))
+			continue
+		}
+		result.Checked++
+
+		switch decidePipelineAction(sub.SubscriptionPipeline, stats) {
+		case pipelinePromote:
+			if sub.PipelineGroupTag != "" && node.GroupTag != sub.PipelineGroupTag {
+				node.GroupTag = sub.PipelineGroupTag
+				if err := store.UpdateNode(node); err != nil {
+					errs = append(errs, fmt.Errorf("set group tag on %s: %w", node.Tag, err))
+					continue
+				}
+			}
+			if err := store.PromoteNode(node.ID); err != nil {
+				errs = append(errs, fmt.Errorf("promote %s: %w", node.Tag, err))
+				continue
+			}
+			result.Promoted++
+		case pipelineRemove:
+			// Pinned nodes are never removed automatically
+			if node.Pinned {
+				continue
+			}
+			if err := store.DeleteNode(node.ID); err != nil {
+				errs = append(errs, fmt.Errorf("remove %s: %w", node.Tag, err))
+				continue
+			}
+			result.Removed++
+		}
+	}
+
+	if result.Promoted > 0 || result.Removed > 0 {
+		log.Printf("[Pipeline] Subscription %s: checked %d, promoted %d, removed %d",
+			sub.Name, result.Checked, result.Promoted, result.Removed)
+	}
+	if len(errs) > 0 {
+		return result, errs[0]
+	}
+	return result, nil
+}
+
+// pendingPipelineNodes collects the pending nodes of all auto-pipeline subscriptions
+func pendingPipelineNodes(store storage.Store, subs []storage.Subscription) []storage.Node {
+	var nodes []storage.Node
+	for _, sub := range subs {
+		if !sub.Enabled || !sub.AutoPipeline {
+			continue
+		}
+		for _, node := range store.GetNodesBySource(sub.ID) {
+			if node.Status == storage.NodeStatusPending {
+				nodes = append(nodes, node.ToNode())
+			}
+		}
+	}
+	return nodes
+}
@@ -0,0 +1,124 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/xiaobei/singbox-manager/internal/storage"
+)
+
+func TestDecidePipelineAction(t *testing.T) {
+	cfg := storage.SubscriptionPipeline{
+		AutoPipeline:         true,
+		PipelineMinStability: 80,
+	}
+	removeDead := cfg
+	removeDead.PipelineRemoveDead = true
+
+	tests := []struct {
+		name  string
+		cfg   storage.SubscriptionPipeline
+		stats *storage.HealthStats
+		want  pipelineAction
+	}{
+		{name: "no stats", cfg: cfg, stats: nil, want: pipelineKeep},
+		{name: "too few samples", cfg: cfg, stats: &storage.HealthStats{TotalChecks: 2, AliveChecks: 2, UptimePercent: 100}, want: pipelineKeep},
+		{name: "stable", cfg: cfg, stats: &storage.HealthStats{TotalChecks: 10, AliveChecks: 9, UptimePercent: 90}, want: pipelinePromote},
+		{name: "exactly at threshold", cfg: cfg, stats: &storage.HealthStats{TotalChecks: 10, AliveChecks: 8, UptimePercent: 80}, want: pipelinePromote},
+		{name: "below threshold", cfg: cfg, stats: &storage.HealthStats{TotalChecks: 10, AliveChecks: 5, UptimePercent: 50}, want: pipelineKeep},
+		{name: "dead kept", cfg: cfg, stats: &storage.HealthStats{TotalChecks: 10}, want: pipelineKeep},
+		{name: "dead removed", cfg: removeDead, stats: &storage.HealthStats{TotalChecks: 10}, want: pipelineRemove},
+	}
+
+	for _, tt := range tests {
+		if got := decidePipelineAction(tt.cfg, tt.stats); got != tt.want {
+			t.Fatalf("%s: action mismatch: got %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestRunSubscriptionPipeline(t *testing.T) {
+	store, err := storage.NewSQLiteStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("create sqlite store: %v", err)
+	}
+	t.Cleanup(func() { _ = store.Close() })
+
+	sub := storage.Subscription{
+		ID:      "sub-1",
+		Name:    "test",
+		URL:     "https://example.com/sub",
+		Enabled: true,
+		SubscriptionPipeline: storage.SubscriptionPipeline{
+			AutoPipeline:         true,
+			PipelineGroupTag:     "auto",
+			PipelineMinStability: 80,
+			PipelineRemoveDead:   true,
+		},
+	}
+	if err := store.AddSubscription(sub); err != nil {
+		t.Fatalf("add subscription: %v", err)
+	}
+	saved := store.GetSubscription(sub.ID)
+	if saved == nil || saved.SubscriptionPipeline != sub.SubscriptionPipeline {
+		t.Fatalf("pipeline settings not persisted: got %+v", saved)
+	}
+
+	stableID, err := store.AddNode(storage.UnifiedNode{
+		Tag: "stable", InternalTag: "stable", Type: "vmess",
+		Server: "10.0.0.1", ServerPort: 443, Status: storage.NodeStatusPending, Source: sub.ID,
+	})
+	if err != nil {
+		t.Fatalf("insert stable node: %v", err)
+	}
+	deadID, err := store.AddNode(storage.UnifiedNode{
+		Tag: "dead", InternalTag: "dead", Type: "vmess",
+		Server: "10.0.0.2", ServerPort: 443, Status: storage.NodeStatusPending, Source: sub.ID,
+	})
+	if err != nil {
+		t.Fatalf("insert dead node: %v", err)
+	}
+	pinnedID, err := store.AddNode(storage.UnifiedNode{
+		Tag: "pinned", InternalTag: "pinned", Type: "vmess",
+		Server: "10.0.0.3", ServerPort: 443, Status: storage.NodeStatusPending, Source: sub.ID,
+	})
+	if err != nil {
+		t.Fatalf("insert pinned node: %v", err)
+	}
+	if err := store.SetNodePinned(pinnedID, true); err != nil {
+		t.Fatalf("pin node: %v", err)
+	}
+
+	now := time.Now()
+	var measurements []storage.HealthMeasurement
+	for i := 0; i < 10; i++ {
+		ts := now.Add(-time.Duration(i) * time.Minute)
+		measurements = append(measurements,
+			storage.HealthMeasurement{Server: "10.0.0.1", ServerPort: 443, NodeTag: "stable", Timestamp: ts, Alive: i != 0, LatencyMs: 100},
+			storage.HealthMeasurement{Server: "10.0.0.2", ServerPort: 443, NodeTag: "dead", Timestamp: ts, Alive: false},
+			storage.HealthMeasurement{Server: "10.0.0.3", ServerPort: 443, NodeTag: "pinned", Timestamp: ts, Alive: false},
+		)
+	}
+	if err := store.AddHealthMeasurements(measurements); err != nil {
+		t.Fatalf("add health measurements: %v", err)
+	}
+
+	result, err := RunSubscriptionPipeline(store, *saved)
+	if err != nil {
+		t.Fatalf("run pipeline: %v", err)
+	}
+	if result.Checked != 3 || result.Promoted != 1 || result.Removed != 1 {
+		t.Fatalf("result mismatch: got %+v", result)
+	}
+
+	stable := store.GetNodeByID(stableID)
+	if stable == nil || stable.Status != storage.NodeStatusVerified || stable.GroupTag != "auto" {
+		t.Fatalf("expected stable node promoted with group tag, got %+v", stable)
+	}
+	if n := store.GetNodeByID(deadID); n != nil {
+		t.Fatalf("expected dead node removed, got %+v", n)
+	}
+	if n := store.GetNodeByID(pinnedID); n == nil || n.Status != storage.NodeStatusPending {
+		t.Fatalf("expected dead pinned node kept pending, got %+v", n)
+	}
+}
@@ -0,0 +1,181 @@
+package service
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/xiaobei/singbox-manager/internal/events"
+	"github.com/xiaobei/singbox-manager/internal/storage"
+)
+
+func TestRefreshAll_AggregatesFailures(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/broken" {
+			http.Error(w, "boom", http.StatusInternalServerError)
+			return
+		}
+		fmt.Fprintf(w, "trojan://secret@10.0.0.%s:443#node\n", r.URL.Path[len("/ok-"):])
+	}))
+	defer srv.Close()
+
+	store, err := storage.NewSQLiteStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("create sqlite store: %v", err)
+	}
+	t.Cleanup(func() { _ = store.Close() })
+
+	subs := []storage.Subscription{
+		{ID: "sub-1", Name: "one", URL: srv.URL + "/ok-1", Enabled: true},
+		{ID: "sub-2", Name: "broken", URL: srv.URL + "/broken", Enabled: true},
+		{ID: "sub-3", Name: "three", URL: srv.URL + "/ok-3", Enabled: true},
+		{ID: "sub-4", Name: "four", URL: srv.URL + "/ok-4", Enabled: true},
+		{ID: "sub-5", Name: "five", URL: srv.URL + "/ok-5", Enabled: true},
+		{ID: "sub-6", Name: "disabled", URL: srv.URL + "/ok-6", Enabled: false},
+	}
+	for _, sub := range subs {
+		if err := store.AddSubscription(sub); err != nil {
+			t.Fatalf("add subscription %s: %v", sub.ID, err)
+		}
+	}
+
+	result, err := NewSubscriptionService(store).RefreshAll()
+	if err == nil {
+		t.Fatalf("expected joined error for the broken subscription")
+	}
+	if result.Refreshed != 4 {
+		t.Fatalf("refreshed count mismatch: got %d, want 4", result.Refreshed)
+	}
+	if len(result.Failed) != 1 || result.Failed[0].ID != "sub-2" {
+		t.Fatalf("failed subscriptions mismatch: got %+v, want only sub-2", result.Failed)
+	}
+	if result.Added != 4 || result.Total != 4 {
+		t.Fatalf("synced nodes mismatch: got added=%d total=%d, want 4/4", result.Added, result.Total)
+	}
+
+	for _, id := range []string{"sub-1", "sub-3", "sub-4", "sub-5"} {
+		if sub := store.GetSubscription(id); sub == nil || sub.NodeCount != 1 {
+			t.Fatalf("expected %s to be refreshed with 1 node, got %+v", id, sub)
+		}
+	}
+	if sub := store.GetSubscription("sub-6"); sub == nil || sub.NodeCount != 0 {
+		t.Fatalf("expected disabled subscription to be skipped, got %+v", sub)
+	}
+}
+
+func TestRefresh_IncrementalSyncKeepsUnchangedNodes(t *testing.T) {
+	content := "trojan://secret@10.0.0.1:443#keep\ntrojan://secret@10.0.0.2:443#change\ntrojan://secret@10.0.0.3:443#drop\n"
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, content)
+	}))
+	defer srv.Close()
+
+	store, err := storage.NewSQLiteStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("create sqlite store: %v", err)
+	}
+	t.Cleanup(func() { _ = store.Close() })
+
+	svc := NewSubscriptionService(store)
+	bus := events.NewBus()
+	var synced map[string]interface{}
+	bus.SetPublishHook(func(eventType string, data interface{}) {
+		if eventType == "sub:nodes_synced" {
+			synced = data.(map[string]interface{})
+		}
+	})
+	svc.SetEventBus(bus)
+	sub, err := svc.Add("sub", srv.URL, storage.SubscriptionAuth{}, storage.SubscriptionPipeline{})
+	if err != nil {
+		t.Fatalf("add subscription: %v", err)
+	}
+
+	kept := store.GetNodeByServerPort("10.0.0.1", 443)
+	changed := store.GetNodeByServerPort("10.0.0.2", 443)
+	toDrop := store.GetNodeByServerPort("10.0.0.3", 443)
+	if kept == nil || changed == nil || toDrop == nil {
+		t.Fatalf("expected all subscription nodes to be added, got %+v", store.GetNodesBySource(sub.ID))
+	}
+	for _, id := range []int64{kept.ID, toDrop.ID} {
+		if err := store.PromoteNode(id); err != nil {
+			t.Fatalf("promote node: %v", err)
+		}
+	}
+	if err := store.SetNodePinned(kept.ID, true); err != nil {
+		t.Fatalf("pin node: %v", err)
+	}
+
+	content = "trojan://secret@10.0.0.1:443#keep\ntrojan://rotated@10.0.0.2:443#change\n"
+	if err := svc.Refresh(sub.ID); err != nil {
+		t.Fatalf("refresh: %v", err)
+	}
+
+	got := store.GetNodeByServerPort("10.0.0.1", 443)
+	if got == nil || got.ID != kept.ID || got.Status != storage.NodeStatusVerified || !got.Pinned || got.MissingSince != nil {
+		t.Fatalf("unchanged node mismatch: got %+v, want id %d verified and pinned", got, kept.ID)
+	}
+	updated := store.GetNodeByServerPort("10.0.0.2", 443)
+	if updated == nil || updated.ID != changed.ID || updated.Extra["password"] != "rotated" {
+		t.Fatalf("changed node mismatch: got %+v, want id %d with the new password", updated, changed.ID)
+	}
+	dropped := store.GetNodeByServerPort("10.0.0.3", 443)
+	if dropped == nil || dropped.MissingSince == nil {
+		t.Fatalf("expected dropped node to be kept and marked missing, got %+v", dropped)
+	}
+	wantSynced := map[string]interface{}{"total": 2, "added": 0, "updated": 1, "unchanged": 1, "restored": 0, "missing": 1, "skipped": 0}
+	if !reflect.DeepEqual(synced, wantSynced) {
+		t.Fatalf("nodes synced event mismatch: got %v, want %v", synced, wantSynced)
+	}
+	configServers := map[string]bool{}
+	for _, n := range store.GetAllNodes() {
+		configServers[n.Server] = true
+	}
+	if configServers["10.0.0.3"] || !configServers["10.0.0.1"] {
+		t.Fatalf("config nodes mismatch: got %v, want the missing node left out and the pinned one kept", configServers)
+	}
+
+	content = "trojan://secret@10.0.0.1:443#keep\ntrojan://rotated@10.0.0.2:443#change\ntrojan://secret@10.0.0.3:443#drop\n"
+	if err := svc.Refresh(sub.ID); err != nil {
+		t.Fatalf("refresh: %v", err)
+	}
+	if restored := store.GetNodeByServerPort("10.0.0.3", 443); restored == nil || restored.ID != dropped.ID || restored.MissingSince != nil {
+		t.Fatalf("expected relisted node to be restored, got %+v", restored)
+	}
+}
+
+func TestAddAndRefresh_SendConfiguredAuthHeader(t *testing.T) {
+	var got []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = append(got, r.Header.Get("X-Sub-Token"))
+		if r.Header.Get("X-Sub-Token") != "s3cret" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprint(w, "trojan://secret@10.0.0.1:443#node\n")
+	}))
+	defer srv.Close()
+
+	store, err := storage.NewSQLiteStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("create sqlite store: %v", err)
+	}
+	t.Cleanup(func() { _ = store.Close() })
+
+	svc := NewSubscriptionService(store)
+	auth := storage.SubscriptionAuth{AuthHeader: "X-Sub-Token", AuthValue: "s3cret"}
+	sub, err := svc.Add("sub", srv.URL, auth, storage.SubscriptionPipeline{})
+	if err != nil {
+		t.Fatalf("add subscription: %v", err)
+	}
+	if stored := store.GetSubscription(sub.ID); stored == nil || stored.SubscriptionAuth != auth {
+		t.Fatalf("stored auth mismatch: got %+v, want %+v", stored, auth)
+	}
+	if err := svc.Refresh(sub.ID); err != nil {
+		t.Fatalf("refresh subscription: %v", err)
+	}
+	if len(got) != 2 || got[0] != "s3cret" || got[1] != "s3cret" {
+		t.Fatalf("auth header mismatch: got %q, want it on both fetches", got)
+	}
+}
@@ -0,0 +1,70 @@
+package service
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/xiaobei/singbox-manager/internal/storage"
+)
+
+// minUptimeSamples is the minimum number of measurements inside the window
+// before a node's uptime is trusted enough to archive it.
+const minUptimeSamples = 5
+
+// defaultUptimeWindowHours is used when the configured window is not positive
+const defaultUptimeWindowHours = 24
+
+// ArchiveLowUptimeNodes archives verified, unpinned nodes whose uptime over the configured
+// window falls below Settings.MinUptimePercent. It returns the number of archived
+// nodes and records the run in the verification logs when anything was archived.
+func ArchiveLowUptimeNodes(store storage.Store, now time.Time) (int, error) {
+	settings := store.GetSettings()
+	if settings.MinUptimePercent <= 0 {
+		return 0, nil
+	}
+	windowHours := settings.UptimeWindowHours
+	if windowHours <= 0 {
+		windowHours = defaultUptimeWindowHours
+	}
+	since := now.Add(-time.Duration(windowHours) * time.Hour)
+	start := time.Now()
+
+	vlog := storage.VerificationLog{Timestamp: now}
+	var errs []error
+	for _, node := range store.GetNodes(storage.NodeStatusVerified) {
+		if node.Pinned {
+			continue
+		}
+		stats, err := store.GetHealthStatsSince(node.Server, node.ServerPort, since)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("health stats for %s: %w", node.Tag, err))
+			continue
+		}
+		vlog.VerifiedChecked++
+		if stats.TotalChecks < minUptimeSamples || stats.UptimePercent >= float64(settings.MinUptimePercent) {
+			continue
+		}
+		if err := store.ArchiveNode(node.ID); err != nil {
+			errs = append(errs, fmt.Errorf("archive %s: %w", node.Tag, err))
+			continue
+		}
+		vlog.UptimeArchived++
+		log.Printf("[Scheduler] Archived node %s: uptime %.1f%% over %dh (min %d%%)",
+			node.Tag, stats.UptimePercent, windowHours, settings.MinUptimePercent)
+	}
+
+	if len(errs) > 0 {
+		vlog.Error = errs[0].Error()
+	}
+	if vlog.UptimeArchived > 0 || vlog.Error != "" {
+		vlog.DurationMs = time.Since(start).Milliseconds()
+		if err := store.AddVerificationLog(vlog); err != nil {
+			log.Printf("[Scheduler] Failed to save uptime archive log: %v", err)
+		}
+	}
+	if len(errs) > 0 {
+		return vlog.UptimeArchived, errs[0]
+	}
+	return vlog.UptimeArchived, nil
+}
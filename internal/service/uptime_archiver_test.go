@@ -0,0 +1,70 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/xiaobei/singbox-manager/internal/storage"
+)
+
+func TestArchiveLowUptimeNodes(t *testing.T) {
+	store, err := storage.NewSQLiteStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("create sqlite store: %v", err)
+	}
+	t.Cleanup(func() { _ = store.Close() })
+
+	settings := store.GetSettings()
+	settings.MinUptimePercent = 80
+	settings.UptimeWindowHours = 24
+	if err := store.UpdateSettings(settings); err != nil {
+		t.Fatalf("update settings: %v", err)
+	}
+
+	flakyID, err := store.AddNode(storage.UnifiedNode{
+		Tag: "flaky", InternalTag: "flaky", Type: "vmess",
+		Server: "10.0.0.1", ServerPort: 443, Status: storage.NodeStatusVerified,
+	})
+	if err != nil {
+		t.Fatalf("insert flaky node: %v", err)
+	}
+	stableID, err := store.AddNode(storage.UnifiedNode{
+		Tag: "stable", InternalTag: "stable", Type: "vmess",
+		Server: "10.0.0.2", ServerPort: 443, Status: storage.NodeStatusVerified,
+	})
+	if err != nil {
+		t.Fatalf("insert stable node: %v", err)
+	}
+
+	now := time.Now()
+	var measurements []storage.HealthMeasurement
+	for i := 0; i < 20; i++ {
+		ts := now.Add(-time.Duration(i) * time.Hour / 2)
+		measurements = append(measurements,
+			storage.HealthMeasurement{Server: "10.0.0.1", ServerPort: 443, NodeTag: "flaky", Timestamp: ts, Alive: i%5 < 2, LatencyMs: 100},
+			storage.HealthMeasurement{Server: "10.0.0.2", ServerPort: 443, NodeTag: "stable", Timestamp: ts, Alive: i != 0, LatencyMs: 100},
+		)
+	}
+	if err := store.AddHealthMeasurements(measurements); err != nil {
+		t.Fatalf("add health measurements: %v", err)
+	}
+
+	archived, err := ArchiveLowUptimeNodes(store, now)
+	if err != nil {
+		t.Fatalf("archive low uptime nodes: %v", err)
+	}
+	if archived != 1 {
+		t.Fatalf("archived count mismatch: got %d, want 1", archived)
+	}
+	if n := store.GetNodeByID(flakyID); n == nil || n.Status != storage.NodeStatusArchived {
+		t.Fatalf("expected 40%% uptime node to be archived, got %+v", n)
+	}
+	if n := store.GetNodeByID(stableID); n == nil || n.Status != storage.NodeStatusVerified {
+		t.Fatalf("expected 95%% uptime node to stay verified, got %+v", n)
+	}
+
+	logs := store.GetVerificationLogs(1)
+	if len(logs) != 1 || logs[0].UptimeArchived != 1 {
+		t.Fatalf("verification log mismatch: %+v", logs)
+	}
+}
@@ -1,6 +1,9 @@
 package storage
 
 import (
+	"fmt"
+	"net"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -36,24 +39,58 @@ type UnifiedNode struct {
 	PromotedAt          *time.Time             `json:"promoted_at,omitempty"`
 	ArchivedAt          *time.Time             `json:"archived_at,omitempty"`
 	IsFavorite          bool                   `json:"is_favorite"`
+	Pinned              bool                   `json:"pinned"`            // Never auto-archived/demoted, always in config
+	CountryLocked       bool                   `json:"country_locked"`    // Country was set by hand, geo checks leave it alone
+	ExcludeFromAuto     bool                   `json:"exclude_from_auto"` // Kept out of the Auto and country urltest groups, still selectable by hand
+	SourceURL           string                 `json:"source_url,omitempty"`
+	MissingSince        *time.Time             `json:"missing_since,omitempty"` // Set while the subscription no longer lists the node
 }
 
 // ToNode converts UnifiedNode to the basic Node type used by config builder
 func (u *UnifiedNode) ToNode() Node {
 	return Node{
-		Tag:          u.DisplayOrTag(),
-		InternalTag:  u.RoutingTag(),
-		DisplayName:  u.DisplayOrTag(),
-		SourceTag:    u.SourceOrTag(),
-		Type:         u.Type,
-		Server:       u.Server,
-		ServerPort:   u.ServerPort,
-		Extra:        u.Extra,
-		Country:      u.Country,
-		CountryEmoji: u.CountryEmoji,
+		Tag:             u.DisplayOrTag(),
+		InternalTag:     u.RoutingTag(),
+		DisplayName:     u.DisplayOrTag(),
+		SourceTag:       u.SourceOrTag(),
+		Type:            u.Type,
+		Server:          u.Server,
+		ServerPort:      u.ServerPort,
+		Extra:           u.Extra,
+		Country:         u.Country,
+		CountryEmoji:    u.CountryEmoji,
+		ExcludeFromAuto: u.ExcludeFromAuto,
 	}
 }
 
+// RenderNodeTagTemplate fills a node display name template.
+// Supported placeholders: {country}, {emoji}, {protocol}, {index}, {server}.
+// Returns an empty string when the template is empty.
+func RenderNodeTagTemplate(template string, node UnifiedNode, index int) string {
+	template = strings.TrimSpace(template)
+	if template == "" {
+		return ""
+	}
+
+	country := strings.ToUpper(strings.TrimSpace(node.Country))
+	if country == "" {
+		country = "NODE"
+	}
+	emoji := strings.TrimSpace(node.CountryEmoji)
+	if emoji == "" {
+		emoji = GetCountryEmoji(country)
+	}
+
+	rendered := strings.NewReplacer(
+		"{country}", country,
+		"{emoji}", emoji,
+		"{protocol}", node.Type,
+		"{index}", strconv.Itoa(index),
+		"{server}", node.Server,
+	).Replace(template)
+	return strings.Join(strings.Fields(rendered), " ")
+}
+
 // VerificationLog represents a verification run log entry
 type VerificationLog struct {
 	ID              int64     `json:"id"`
@@ -63,6 +100,7 @@ type VerificationLog struct {
 	PendingArchived int       `json:"pending_archived"`
 	VerifiedChecked int       `json:"verified_checked"`
 	VerifiedDemoted int       `json:"verified_demoted"`
+	UptimeArchived  int       `json:"uptime_archived"`
 	DurationMs      int64     `json:"duration_ms"`
 	Error           string    `json:"error,omitempty"`
 }
@@ -82,6 +120,16 @@ type NodeCounts struct {
 	Archived int `json:"archived"`
 }
 
+// NodeSyncResult summarises an incremental sync of a subscription's nodes
+type NodeSyncResult struct {
+	Added     int `json:"added"`
+	Updated   int `json:"updated"`   // Same server:port, changed protocol settings
+	Unchanged int `json:"unchanged"`
+	Restored  int `json:"restored"`  // Previously missing nodes listed again
+	Missing   int `json:"missing"`   // Nodes the subscription no longer lists
+	Skipped   int `json:"skipped"`   // server:port already owned by another source
+}
+
 // Subscription represents a proxy subscription
 type Subscription struct {
 	ID        string     `json:"id"`
@@ -93,6 +141,31 @@ type Subscription struct {
 	Traffic   *Traffic   `json:"traffic,omitempty"`
 	Nodes     []Node     `json:"nodes"`
 	Enabled   bool       `json:"enabled"`
+	SubscriptionAuth
+	SubscriptionPipeline
+}
+
+// SubscriptionAuth is an extra request header sent when fetching the subscription,
+// for providers that want Basic auth or a token outside the URL
+type SubscriptionAuth struct {
+	AuthHeader string `json:"auth_header"` // header name, e.g. Authorization; empty to send none
+	AuthValue  string `json:"auth_value"`  // header value, redacted in API responses
+}
+
+// Headers returns the auth header to send on fetch, or nil when none is configured
+func (a SubscriptionAuth) Headers() map[string]string {
+	if a.AuthHeader == "" || a.AuthValue == "" {
+		return nil
+	}
+	return map[string]string{a.AuthHeader: a.AuthValue}
+}
+
+// SubscriptionPipeline holds the per-subscription auto-promote settings
+type SubscriptionPipeline struct {
+	AutoPipeline         bool    `json:"auto_pipeline"`          // Health-check and promote new nodes after each refresh
+	PipelineGroupTag     string  `json:"pipeline_group_tag"`     // Group tag assigned to promoted nodes (empty = keep)
+	PipelineMinStability float64 `json:"pipeline_min_stability"` // Minimum uptime percent required for promotion
+	PipelineRemoveDead   bool    `json:"pipeline_remove_dead"`   // Delete pending nodes that never passed a check
 }
 
 // Traffic represents traffic information
@@ -114,6 +187,9 @@ type Node struct {
 	Extra        map[string]interface{} `json:"extra,omitempty"`         // protocol-specific fields
 	Country      string                 `json:"country,omitempty"`       // country code
 	CountryEmoji string                 `json:"country_emoji,omitempty"` // country emoji
+	SourceURL    string                 `json:"source_url,omitempty"`    // original share URL, used for re-parsing
+
+	ExcludeFromAuto bool `json:"exclude_from_auto,omitempty"` // left out of the Auto and country urltest groups
 }
 
 // RoutingTag returns the stable sing-box/runtime tag for the node.
@@ -199,6 +275,38 @@ type ServerPortKey struct {
 	ServerPort int    `json:"server_port"`
 }
 
+// ServerCluster is a server address shared by several non-archived nodes
+type ServerCluster struct {
+	Server    string              `json:"server"`
+	NodeCount int                 `json:"node_count"`
+	Ports     []int               `json:"ports"`
+	Nodes     []ServerClusterNode `json:"nodes"`
+}
+
+// ServerClusterNode is one node of a ServerCluster
+type ServerClusterNode struct {
+	ID         int64      `json:"id"`
+	Tag        string     `json:"tag"`
+	ServerPort int        `json:"server_port"`
+	Status     NodeStatus `json:"status"`
+}
+
+// NormalizeServer returns a node server as it is stored: trimmed, without IPv6 brackets,
+// and with IPv6 literals in canonical form so every spelling of an address shares one
+// server:port key. The port always follows the last colon, so keys stay unambiguous.
+func NormalizeServer(server string) string {
+	server = strings.TrimSpace(server)
+	if len(server) > 1 && server[0] == '[' && server[len(server)-1] == ']' {
+		server = server[1 : len(server)-1]
+	}
+	if strings.Contains(server, ":") {
+		if ip := net.ParseIP(server); ip != nil {
+			return ip.String()
+		}
+	}
+	return server
+}
+
 // HealthMeasurement represents a single health check measurement
 type HealthMeasurement struct {
 	ID         int64     `json:"id,omitempty"`
@@ -224,6 +332,20 @@ type SiteMeasurement struct {
 	Mode       string    `json:"mode"`
 }
 
+// SiteMatrixRow holds a node's latest delay for each checked site
+type SiteMatrixRow struct {
+	Server     string         `json:"server"`
+	ServerPort int            `json:"server_port"`
+	NodeTag    string         `json:"node_tag"`
+	Sites      map[string]int `json:"sites"` // site -> delay ms, 0 = blocked/failed
+}
+
+// SiteMatrix is a node x site grid of the latest site check delays
+type SiteMatrix struct {
+	Sites []string        `json:"sites"`
+	Nodes []SiteMatrixRow `json:"nodes"`
+}
+
 // SpeedMeasurement represents a speed test result for a node
 type SpeedMeasurement struct {
 	ID            int64     `json:"id,omitempty"`
@@ -268,6 +390,23 @@ type ClientTrafficSnapshot struct {
 	TopHost           string    `json:"top_host"`
 }
 
+// ClientQuota is a traffic allowance for one LAN client (source IP). A zero limit
+// means no quota for that period.
+type ClientQuota struct {
+	SourceIP     string    `json:"source_ip"`
+	DailyBytes   int64     `json:"daily_bytes"`
+	MonthlyBytes int64     `json:"monthly_bytes"`
+	Alert        bool      `json:"alert"` // fire the webhook when the client goes over quota
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// ClientUsage is the traffic a client moved within a time window.
+type ClientUsage struct {
+	SourceIP      string `json:"source_ip"`
+	UploadBytes   int64  `json:"upload_bytes"`
+	DownloadBytes int64  `json:"download_bytes"`
+}
+
 // TrafficClientRecent represents the latest known snapshot of a client (including offline ones).
 type TrafficClientRecent struct {
 	SourceIP          string    `json:"source_ip"`
@@ -326,6 +465,35 @@ type ClientResourceHistory struct {
 	LastSeen      time.Time `json:"last_seen"`
 }
 
+// ConnectionLogEntry is one sampled observation of a client's traffic to a host.
+type ConnectionLogEntry struct {
+	Timestamp         time.Time `json:"timestamp"`
+	SourceIP          string    `json:"source_ip"`
+	Host              string    `json:"host"`
+	ProxyChain        string    `json:"proxy_chain"`
+	ActiveConnections int       `json:"active_connections"`
+	UploadBytes       int64     `json:"upload_bytes"`
+	DownloadBytes     int64     `json:"download_bytes"`
+}
+
+// HostObservation summarises how often a host showed up in sampled traffic.
+type HostObservation struct {
+	Host     string    `json:"host"`
+	Samples  int       `json:"samples"`
+	Clients  int       `json:"clients"`
+	LastSeen time.Time `json:"last_seen"`
+}
+
+// ConnectionLogQuery filters the sampled connection log. Host also matches its
+// subdomains; empty fields and zero times are not applied.
+type ConnectionLogQuery struct {
+	Host     string
+	SourceIP string
+	Since    time.Time
+	Until    time.Time
+	Limit    int
+}
+
 // HealthStats represents aggregated health statistics for a node
 type HealthStats struct {
 	TotalChecks   int     `json:"total_checks"`
@@ -376,6 +544,30 @@ type CountryGroup struct {
 	NodeCount int    `json:"node_count"` // node count
 }
 
+// CountryOverride customizes the emoji and/or name of a country group.
+// Empty fields fall back to the built-in values.
+type CountryOverride struct {
+	Code      string    `json:"code"`
+	Emoji     string    `json:"emoji"`
+	Name      string    `json:"name"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// ResolveCountryDisplay returns the emoji and name for a country code, applying the override if set
+func ResolveCountryDisplay(code string, override *CountryOverride) (emoji, name string) {
+	emoji = GetCountryEmoji(code)
+	name = GetCountryName(code)
+	if override != nil {
+		if override.Emoji != "" {
+			emoji = override.Emoji
+		}
+		if override.Name != "" {
+			name = override.Name
+		}
+	}
+	return emoji, name
+}
+
 // Filter represents a node filter
 type Filter struct {
 	ID               string         `json:"id"`
@@ -391,6 +583,99 @@ type Filter struct {
 	Enabled          bool           `json:"enabled"`
 }
 
+// Profile is a named snapshot of the routing setup (filters and settings, not nodes)
+// that can be re-activated later
+type Profile struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	Filters   []Filter         `json:"filters"`
+	Settings  *ProfileSettings `json:"settings"`
+	CreatedAt time.Time        `json:"created_at"`
+	UpdatedAt time.Time        `json:"updated_at"`
+}
+
+// ProfileSettings are the routing settings a profile switches. Credentials, inbounds,
+// install paths, scheduling and notifications are not part of a profile.
+type ProfileSettings struct {
+	ProxyMode              string      `json:"proxy_mode"`
+	FinalOutbound          string      `json:"final_outbound"`
+	BlockedCountries       []string    `json:"blocked_countries"`
+	ProxyDNS               string      `json:"proxy_dns"`
+	DirectDNS              string      `json:"direct_dns"`
+	Hosts                  []HostEntry `json:"hosts,omitempty"`
+	DirectProcesses        []string    `json:"direct_processes"`
+	TunIncludeRoutes       []string    `json:"tun_include_routes"`
+	TunExcludeRoutes       []string    `json:"tun_exclude_routes"`
+	AutoDetectInterface    bool        `json:"auto_detect_interface"`
+	DefaultInterface       string      `json:"default_interface"`
+	Sniffers               []string    `json:"sniffers"`
+	SniffTimeout           string      `json:"sniff_timeout"`
+	SortProxyNodes         bool        `json:"sort_proxy_nodes"`
+	URLTestURL             string      `json:"urltest_url"`
+	DefaultUTLSFingerprint string      `json:"default_utls_fingerprint"`
+	MultiplexEnabled       bool        `json:"multiplex_enabled"`
+	TCPFastOpen            bool        `json:"tcp_fast_open"`
+	UDPFragment            bool        `json:"udp_fragment"`
+	UDPOverTCP             bool        `json:"udp_over_tcp"`
+	BlockQUIC              bool        `json:"block_quic"`
+	NoNodesMode            string      `json:"no_nodes_mode"`
+}
+
+// ProfileSettingsFrom copies the routing settings a profile keeps out of settings
+func ProfileSettingsFrom(settings *Settings) *ProfileSettings {
+	return &ProfileSettings{
+		ProxyMode:              settings.ProxyMode,
+		FinalOutbound:          settings.FinalOutbound,
+		BlockedCountries:       settings.BlockedCountries,
+		ProxyDNS:               settings.ProxyDNS,
+		DirectDNS:              settings.DirectDNS,
+		Hosts:                  settings.Hosts,
+		DirectProcesses:        settings.DirectProcesses,
+		TunIncludeRoutes:       settings.TunIncludeRoutes,
+		TunExcludeRoutes:       settings.TunExcludeRoutes,
+		AutoDetectInterface:    settings.AutoDetectInterface,
+		DefaultInterface:       settings.DefaultInterface,
+		Sniffers:               settings.Sniffers,
+		SniffTimeout:           settings.SniffTimeout,
+		SortProxyNodes:         settings.SortProxyNodes,
+		URLTestURL:             settings.URLTestURL,
+		DefaultUTLSFingerprint: settings.DefaultUTLSFingerprint,
+		MultiplexEnabled:       settings.MultiplexEnabled,
+		TCPFastOpen:            settings.TCPFastOpen,
+		UDPFragment:            settings.UDPFragment,
+		UDPOverTCP:             settings.UDPOverTCP,
+		BlockQUIC:              settings.BlockQUIC,
+		NoNodesMode:            settings.NoNodesMode,
+	}
+}
+
+// ApplyTo overwrites the routing settings in settings with the profile's, leaving
+// every other setting as it is
+func (p *ProfileSettings) ApplyTo(settings *Settings) {
+	settings.ProxyMode = p.ProxyMode
+	settings.FinalOutbound = p.FinalOutbound
+	settings.BlockedCountries = p.BlockedCountries
+	settings.ProxyDNS = p.ProxyDNS
+	settings.DirectDNS = p.DirectDNS
+	settings.Hosts = p.Hosts
+	settings.DirectProcesses = p.DirectProcesses
+	settings.TunIncludeRoutes = p.TunIncludeRoutes
+	settings.TunExcludeRoutes = p.TunExcludeRoutes
+	settings.AutoDetectInterface = p.AutoDetectInterface
+	settings.DefaultInterface = p.DefaultInterface
+	settings.Sniffers = p.Sniffers
+	settings.SniffTimeout = p.SniffTimeout
+	settings.SortProxyNodes = p.SortProxyNodes
+	settings.URLTestURL = p.URLTestURL
+	settings.DefaultUTLSFingerprint = p.DefaultUTLSFingerprint
+	settings.MultiplexEnabled = p.MultiplexEnabled
+	settings.TCPFastOpen = p.TCPFastOpen
+	settings.UDPFragment = p.UDPFragment
+	settings.UDPOverTCP = p.UDPOverTCP
+	settings.BlockQUIC = p.BlockQUIC
+	settings.NoNodesMode = p.NoNodesMode
+}
+
 // URLTestConfig represents urltest mode configuration
 type URLTestConfig struct {
 	URL       string `json:"url"`
@@ -412,14 +697,28 @@ type Settings struct {
 	SingBoxPath string `json:"singbox_path"`
 	ConfigPath  string `json:"config_path"`
 
+	// sing-box lifecycle
+	DetachSingbox       bool `json:"detach_singbox"`        // leave sing-box running when the manager exits
+	DrainTimeoutSeconds int  `json:"drain_timeout_seconds"` // wait up to this long for connections to finish before a restart, 0 to restart at once
+
 	// inbound configuration
-	MixedPort    int    `json:"mixed_port"`    // HTTP/SOCKS5 mixed port
-	MixedAddress string `json:"mixed_address"` // external address for proxy link
-	TunEnabled   bool   `json:"tun_enabled"`   // TUN mode
-	AllowLAN     bool   `json:"allow_lan"`     // allow LAN access
+	MixedPort     int    `json:"mixed_port"`     // HTTP/SOCKS5 mixed port
+	MixedEnabled  bool   `json:"mixed_enabled"`  // mixed inbound on/off, keeps the port when off
+	MixedAddress  string `json:"mixed_address"`  // external address for proxy link
+	TunEnabled    bool   `json:"tun_enabled"`    // TUN mode
+	AllowLAN      bool   `json:"allow_lan"`      // allow LAN access
+	ListenAddress string `json:"listen_address"` // inbound bind IP, overrides the AllowLAN-derived address when set
+	IPv6Enabled   bool   `json:"ipv6_enabled"`   // IPv6 TUN address, FakeIP range and AAAA answers
+	TunStack      string `json:"tun_stack"`      // TUN network stack: system, gvisor or mixed
+	TunMTU        int    `json:"tun_mtu"`        // TUN interface MTU, 0 for the sing-box default
+
+	// TUN routing, so the manager can coexist with Docker networks and other tunnels
+	TunIncludeRoutes []string `json:"tun_include_routes"` // CIDRs captured by TUN (route_address), empty for all traffic
+	TunExcludeRoutes []string `json:"tun_exclude_routes"` // CIDRs left out of TUN (route_exclude_address)
 
 	// SOCKS5 inbound
 	SocksPort     int    `json:"socks_port"`
+	SocksEnabled  bool   `json:"socks_enabled"`
 	SocksAddress  string `json:"socks_address"` // external address for proxy link
 	SocksAuth     bool   `json:"socks_auth"`
 	SocksUsername string `json:"socks_username,omitempty"`
@@ -427,6 +726,7 @@ type Settings struct {
 
 	// HTTP inbound
 	HttpPort     int    `json:"http_port"`
+	HttpEnabled  bool   `json:"http_enabled"`
 	HttpAddress  string `json:"http_address"` // external address for proxy link
 	HttpAuth     bool   `json:"http_auth"`
 	HttpUsername string `json:"http_username,omitempty"`
@@ -443,6 +743,10 @@ type Settings struct {
 	DirectDNS string      `json:"direct_dns"`      // direct DNS
 	Hosts     []HostEntry `json:"hosts,omitempty"` // DNS hosts mapping
 
+	// Applications always routed to DIRECT, matched by process_name or, for entries
+	// with a path separator, process_path. Only honoured on desktop platforms.
+	DirectProcesses []string `json:"direct_processes"`
+
 	// control panel
 	WebPort        int    `json:"web_port"`         // management UI port
 	ClashAPIPort   int    `json:"clash_api_port"`   // Clash API port
@@ -452,6 +756,10 @@ type Settings struct {
 	// final rule
 	FinalOutbound string `json:"final_outbound"` // default outbound
 
+	// outbound interface binding
+	AutoDetectInterface bool   `json:"auto_detect_interface"` // let sing-box pick the default interface
+	DefaultInterface    string `json:"default_interface"`     // interface bound when auto-detect is off, e.g. eth0
+
 	// rule set source
 	RuleSetBaseURL string `json:"ruleset_base_url"` // rule set download URL
 
@@ -459,6 +767,9 @@ type Settings struct {
 	AutoApply            bool `json:"auto_apply"`            // auto-apply after config changes
 	SubscriptionInterval int  `json:"subscription_interval"` // subscription auto-update interval (minutes), 0 to disable
 
+	// Auto-apply writes the config but defers the sing-box reload inside this window
+	QuietHours QuietHours `json:"quiet_hours"`
+
 	// GitHub proxy settings
 	GithubProxy string `json:"github_proxy"` // GitHub proxy URL, e.g. https://ghproxy.com/
 
@@ -468,12 +779,61 @@ type Settings struct {
 	// Verification settings
 	VerificationInterval int `json:"verification_interval"` // verification interval in minutes, 0 to disable
 	ArchiveThreshold     int `json:"archive_threshold"`     // consecutive failures before archiving
+	MinUptimePercent     int `json:"min_uptime_percent"`    // archive verified nodes below this uptime, 0 to disable
+	UptimeWindowHours    int `json:"uptime_window_hours"`   // window used for uptime-based archiving
+
+	// Node quality score weights, relative to each other; 0 leaves a component out
+	ScoreWeightLatency int `json:"score_weight_latency"` // recent average latency
+	ScoreWeightUptime  int `json:"score_weight_uptime"`  // uptime over the scoring window
+	ScoreWeightSites   int `json:"score_weight_sites"`   // share of checked sites that were reachable
 
 	// Proxy mode
 	ProxyMode string `json:"proxy_mode"` // rule, global, direct
 
 	// GeoIP blocking
 	BlockedCountries []string `json:"blocked_countries"` // country codes excluded from Auto/Proxy
+
+	// Traffic sniffing
+	Sniffers     []string `json:"sniffers"`      // protocols passed to the route sniff action
+	SniffTimeout string   `json:"sniff_timeout"` // sniff action timeout, e.g. 500ms
+
+	// Node import
+	NodeTagTemplate string `json:"node_tag_template"` // display name template for imported nodes, empty to keep parsed names
+
+	// Selector ordering
+	SortProxyNodes bool `json:"sort_proxy_nodes"` // order nodes in Proxy/Auto/country groups by country then tag
+
+	// Health-check notifications
+	WebhookURL    string `json:"webhook_url"`    // POST a JSON summary here after verification runs and bulk health checks, empty to disable
+	WebhookSecret string `json:"webhook_secret"` // HMAC-SHA256 signing key for webhook payloads, empty to send unsigned
+
+	// Latency test target
+	URLTestURL            string `json:"urltest_url"`             // URL for urltest groups and delay checks, empty for generate_204
+	URLTestExpectedStatus string `json:"urltest_expected_status"` // accepted target status for delay checks, e.g. "200/204" or "200-299", empty for any
+	UDPCheckTarget        string `json:"udp_check_target"`        // host:port answering UDP (DNS or echo) for hysteria/tuic/wireguard health checks, empty for 1.1.1.1:53
+
+	// TLS
+	DefaultUTLSFingerprint string `json:"default_utls_fingerprint"` // uTLS fingerprint for TLS nodes that do not set one, empty to disable
+
+	// Multiplex
+	MultiplexEnabled bool `json:"multiplex_enabled"` // enable sing-box multiplex on shadowsocks/trojan/vmess/vless nodes that do not configure it
+
+	// Dialer
+	TCPFastOpen bool `json:"tcp_fast_open"` // TCP Fast Open on TCP-based nodes that do not set it themselves
+	UDPFragment bool `json:"udp_fragment"`  // UDP fragmentation on nodes dialing the server over UDP that do not set it themselves
+
+	// UDP over TCP
+	UDPOverTCP bool `json:"udp_over_tcp"` // tunnel UDP over TCP on shadowsocks nodes that do not set it themselves and use no multiplex
+
+	// QUIC
+	BlockQUIC bool `json:"block_quic"` // reject QUIC so browsers fall back to TCP/TLS through the proxy
+
+	// Empty node set
+	NoNodesMode string `json:"no_nodes_mode"` // direct: Proxy falls back to DIRECT, reject: refuse proxied traffic until nodes are available
+
+	// Monitoring
+	TrafficSampleIntervalSeconds int `json:"traffic_sample_interval_seconds"` // traffic aggregation tick, 1-60 seconds
+	HealthRetentionDays          int `json:"health_retention_days"`           // raw health measurements older than this are rolled up daily, 0 to keep all
 }
 
 // DefaultSettings returns default settings
@@ -481,9 +841,13 @@ func DefaultSettings() *Settings {
 	return &Settings{
 		SingBoxPath:          "bin/sing-box",
 		ConfigPath:           "generated/config.json",
+		DetachSingbox:        true, // sing-box outlives the manager by default
 		MixedPort:            2080,
+		MixedEnabled:         true,
 		TunEnabled:           true,
 		AllowLAN:             false, // LAN access disabled by default
+		IPv6Enabled:          true,  // IPv6 enabled by default
+		TunStack:             DefaultTunStack,
 		SocksPort:            0,     // disabled by default
 		HttpPort:             0,     // disabled by default
 		ShadowsocksPort:      8388,
@@ -495,17 +859,306 @@ func DefaultSettings() *Settings {
 		ClashUIPath:          "",
 		ClashAPISecret:       "", // empty by default, auto-generated when LAN is enabled
 		FinalOutbound:        "Proxy",
+		AutoDetectInterface:  true,
 		RuleSetBaseURL:       "https://github.com/lyc8503/sing-box-rules/raw/rule-set-geosite",
 		AutoApply:            true, // auto-apply enabled by default
 		SubscriptionInterval: 60,   // default 60 minutes update interval
 		GithubProxy:          "",   // no proxy by default
 		VerificationInterval: 30,   // default 30 minutes
 		ArchiveThreshold:     10,   // default 10 consecutive failures
+		MinUptimePercent:     0,    // uptime-based archiving disabled by default
+		UptimeWindowHours:    24,   // default 24 hour uptime window
 		ProxyMode:            ProxyModeGlobal,
+		NoNodesMode:          NoNodesModeDirect,
 		BlockedCountries:     []string{},
+		Sniffers:             DefaultSniffers(),
+		SniffTimeout:         DefaultSniffTimeout,
+		NodeTagTemplate:      "", // keep parsed names by default
+		SortProxyNodes:       false,
+
+		TrafficSampleIntervalSeconds: DefaultTrafficSampleIntervalSeconds,
+		HealthRetentionDays:          DefaultHealthRetentionDays,
+		ScoreWeightLatency:           DefaultScoreWeightLatency,
+		ScoreWeightUptime:            DefaultScoreWeightUptime,
+		ScoreWeightSites:             DefaultScoreWeightSites,
+		TunIncludeRoutes:             []string{},
+		TunExcludeRoutes:             []string{},
+		DirectProcesses:              []string{},
 	}
 }
 
+// DefaultHealthRetentionDays is how long raw health measurements are kept before rollup
+const DefaultHealthRetentionDays = 30
+
+// DefaultSniffTimeout is the sniff action timeout used when none is configured
+const DefaultSniffTimeout = "500ms"
+
+// Traffic sample interval bounds in seconds
+const (
+	DefaultTrafficSampleIntervalSeconds = 2
+	MinTrafficSampleIntervalSeconds     = 1
+	MaxTrafficSampleIntervalSeconds     = 60
+)
+
+// MaxDrainTimeoutSeconds caps how long a restart waits for connections to drain
+const MaxDrainTimeoutSeconds = 300
+
+// NormalizeTrafficSampleInterval clamps the traffic sample interval to its bounds,
+// falling back to the default when unset.
+func NormalizeTrafficSampleInterval(seconds int) int {
+	switch {
+	case seconds <= 0:
+		return DefaultTrafficSampleIntervalSeconds
+	case seconds < MinTrafficSampleIntervalSeconds:
+		return MinTrafficSampleIntervalSeconds
+	case seconds > MaxTrafficSampleIntervalSeconds:
+		return MaxTrafficSampleIntervalSeconds
+	}
+	return seconds
+}
+
+// knownSniffers lists the protocol sniffers supported by sing-box
+var knownSniffers = map[string]bool{
+	"http":       true,
+	"tls":        true,
+	"quic":       true,
+	"stun":       true,
+	"dns":        true,
+	"bittorrent": true,
+	"dtls":       true,
+	"ssh":        true,
+	"rdp":        true,
+	"ntp":        true,
+}
+
+// DefaultSniffers returns the sniffers enabled when none are configured
+func DefaultSniffers() []string {
+	return []string{"dns", "http", "tls", "quic"}
+}
+
+// ValidateSniffSettings checks the sniffer names and sniff timeout.
+// Empty values are allowed and mean "use the defaults".
+func ValidateSniffSettings(sniffers []string, timeout string) error {
+	for _, name := range sniffers {
+		if !knownSniffers[name] {
+			return fmt.Errorf("unknown sniffer: %q", name)
+		}
+	}
+	if timeout != "" {
+		d, err := time.ParseDuration(timeout)
+		if err != nil {
+			return fmt.Errorf("invalid sniff timeout %q: %w", timeout, err)
+		}
+		if d <= 0 {
+			return fmt.Errorf("sniff timeout must be positive: %q", timeout)
+		}
+	}
+	return nil
+}
+
+// DefaultTunStack is the TUN network stack used when none is configured
+const DefaultTunStack = "mixed"
+
+// TUN MTU bounds accepted by ValidateTunSettings
+const (
+	MinTunMTU = 576
+	MaxTunMTU = 65535
+)
+
+// ValidateTunSettings checks the TUN stack name and MTU.
+// Empty stack and zero MTU are allowed and mean "use the defaults".
+func ValidateTunSettings(stack string, mtu int) error {
+	switch stack {
+	case "", "system", "gvisor", "mixed":
+	default:
+		return fmt.Errorf("invalid tun stack %q, expected system, gvisor or mixed", stack)
+	}
+	if mtu != 0 && (mtu < MinTunMTU || mtu > MaxTunMTU) {
+		return fmt.Errorf("tun mtu must be between %d and %d", MinTunMTU, MaxTunMTU)
+	}
+	return nil
+}
+
+// Default node quality score weights
+const (
+	DefaultScoreWeightLatency = 40
+	DefaultScoreWeightUptime  = 40
+	DefaultScoreWeightSites   = 20
+)
+
+// ValidateScoreWeights checks that score weights are non-negative and not all zero.
+func ValidateScoreWeights(latency, uptime, sites int) error {
+	if latency < 0 || uptime < 0 || sites < 0 {
+		return fmt.Errorf("score weights must not be negative")
+	}
+	if latency+uptime+sites == 0 {
+		return fmt.Errorf("at least one score weight must be positive")
+	}
+	return nil
+}
+
+// NormalizeTunRoutes trims TUN route entries and drops blank ones.
+func NormalizeTunRoutes(routes []string) []string {
+	normalized := make([]string, 0, len(routes))
+	for _, route := range routes {
+		if route = strings.TrimSpace(route); route != "" {
+			normalized = append(normalized, route)
+		}
+	}
+	return normalized
+}
+
+// NormalizeDirectProcesses trims direct process entries and drops blank ones.
+func NormalizeDirectProcesses(processes []string) []string {
+	normalized := make([]string, 0, len(processes))
+	for _, process := range processes {
+		if process = strings.TrimSpace(process); process != "" {
+			normalized = append(normalized, process)
+		}
+	}
+	return normalized
+}
+
+// SplitDirectProcesses separates direct process entries into process names and
+// process paths; an entry containing a path separator is a path.
+func SplitDirectProcesses(processes []string) (names, paths []string) {
+	for _, process := range NormalizeDirectProcesses(processes) {
+		if strings.ContainsAny(process, `/\`) {
+			paths = append(paths, process)
+		} else {
+			names = append(names, process)
+		}
+	}
+	return names, paths
+}
+
+// ValidateDirectProcesses checks that direct process entries are bare names or absolute paths.
+func ValidateDirectProcesses(processes []string) error {
+	names, paths := SplitDirectProcesses(processes)
+	for _, name := range names {
+		if name == "." || name == ".." || strings.ContainsAny(name, "\t\r\n") {
+			return fmt.Errorf("invalid process name %q", name)
+		}
+	}
+	for _, path := range paths {
+		if !strings.HasPrefix(path, "/") && !isWindowsAbsPath(path) {
+			return fmt.Errorf("invalid process path %q, expected an absolute path", path)
+		}
+	}
+	return nil
+}
+
+// QuietHours is a daily local-time window given as HH:MM start and end; a window whose
+// end is before its start runs past midnight. Equal or blank bounds disable it.
+type QuietHours struct {
+	Start string `json:"start"`
+	End   string `json:"end"`
+}
+
+// parseClockMinutes parses HH:MM into minutes after midnight.
+func parseClockMinutes(value string) (int, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(value))
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q, expected HH:MM", value)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// Enabled reports whether the window is set and non-empty.
+func (q QuietHours) Enabled() bool {
+	start, errStart := parseClockMinutes(q.Start)
+	end, errEnd := parseClockMinutes(q.End)
+	return errStart == nil && errEnd == nil && start != end
+}
+
+// Contains reports whether t falls inside the window.
+func (q QuietHours) Contains(t time.Time) bool {
+	if !q.Enabled() {
+		return false
+	}
+	start, _ := parseClockMinutes(q.Start)
+	end, _ := parseClockMinutes(q.End)
+	m := t.Hour()*60 + t.Minute()
+	if start < end {
+		return m >= start && m < end
+	}
+	return m >= start || m < end
+}
+
+// NextEnd returns the first end of the window after t.
+func (q QuietHours) NextEnd(t time.Time) time.Time {
+	end, _ := parseClockMinutes(q.End)
+	next := time.Date(t.Year(), t.Month(), t.Day(), end/60, end%60, 0, 0, t.Location())
+	if !next.After(t) {
+		next = next.AddDate(0, 0, 1)
+	}
+	return next
+}
+
+// ValidateQuietHours checks that the window is either blank or two HH:MM times.
+func ValidateQuietHours(q QuietHours) error {
+	if strings.TrimSpace(q.Start) == "" && strings.TrimSpace(q.End) == "" {
+		return nil
+	}
+	if _, err := parseClockMinutes(q.Start); err != nil {
+		return fmt.Errorf("quiet hours start: %w", err)
+	}
+	if _, err := parseClockMinutes(q.End); err != nil {
+		return fmt.Errorf("quiet hours end: %w", err)
+	}
+	return nil
+}
+
+// isWindowsAbsPath reports whether path looks like C:\... or C:/...
+func isWindowsAbsPath(path string) bool {
+	return len(path) >= 3 && path[1] == ':' && (path[2] == '\\' || path[2] == '/') &&
+		(path[0] >= 'a' && path[0] <= 'z' || path[0] >= 'A' && path[0] <= 'Z')
+}
+
+// ValidateTunRoutes checks that every non-blank TUN route entry is a CIDR prefix.
+func ValidateTunRoutes(routes []string) error {
+	for _, route := range NormalizeTunRoutes(routes) {
+		if _, _, err := net.ParseCIDR(route); err != nil {
+			return fmt.Errorf("invalid tun route %q, expected a CIDR such as 172.17.0.0/16", route)
+		}
+	}
+	return nil
+}
+
+// UTLSFingerprints is the set of uTLS fingerprint names accepted by sing-box
+var UTLSFingerprints = map[string]bool{
+	"chrome":     true,
+	"firefox":    true,
+	"safari":     true,
+	"ios":        true,
+	"android":    true,
+	"edge":       true,
+	"360":        true,
+	"qq":         true,
+	"random":     true,
+	"randomized": true,
+}
+
+// ValidateUTLSFingerprint accepts an empty fingerprint (disabled) or a known uTLS fingerprint
+func ValidateUTLSFingerprint(fp string) error {
+	if fp == "" || UTLSFingerprints[fp] {
+		return nil
+	}
+	return fmt.Errorf("invalid uTLS fingerprint %q", fp)
+}
+
+// ValidateListenAddress accepts an empty address (derive from AllowLAN) or a literal IP
+func ValidateListenAddress(addr string) error {
+	addr = strings.TrimSpace(addr)
+	if addr == "" {
+		return nil
+	}
+	if net.ParseIP(addr) == nil {
+		return fmt.Errorf("invalid listen address %q, expected an IP address", addr)
+	}
+	return nil
+}
+
 // Proxy mode constants
 const (
 	ProxyModeRule   = "rule"
@@ -528,6 +1181,85 @@ func IsValidProxyMode(mode string) bool {
 	return m == ProxyModeRule || m == ProxyModeGlobal || m == ProxyModeDirect
 }
 
+// No-nodes mode constants, deciding what the config does when no node is usable
+const (
+	NoNodesModeDirect = "direct" // Proxy falls back to DIRECT so the service keeps working
+	NoNodesModeReject = "reject" // Traffic is rejected instead of leaving unproxied, private destinations excepted
+)
+
+// NormalizeNoNodesMode normalizes the no-nodes mode, falling back to "direct".
+func NormalizeNoNodesMode(mode string) string {
+	if m := strings.ToLower(strings.TrimSpace(mode)); m == NoNodesModeReject {
+		return NoNodesModeReject
+	}
+	return NoNodesModeDirect
+}
+
+// IsValidNoNodesMode checks if the given mode is a valid no-nodes mode. Empty means the default.
+func IsValidNoNodesMode(mode string) bool {
+	m := strings.ToLower(strings.TrimSpace(mode))
+	return m == "" || m == NoNodesModeDirect || m == NoNodesModeReject
+}
+
+// Reject method constants for route-level reject actions
+const (
+	RejectMethodDefault = "default" // Reply with TCP RST / ICMP unreachable so clients fail fast
+	RejectMethodDrop    = "drop"    // Silently drop packets
+)
+
+// NormalizeRejectMethod normalizes reject method string, falling back to "default".
+func NormalizeRejectMethod(method string) string {
+	m := strings.ToLower(strings.TrimSpace(method))
+	if m == RejectMethodDrop {
+		return RejectMethodDrop
+	}
+	return RejectMethodDefault
+}
+
+// Node sort keys accepted by GetAllNodesSorted
+const (
+	NodeSortLatency     = "latency"
+	NodeSortCountry     = "country"
+	NodeSortTag         = "tag"
+	NodeSortLastChecked = "last_checked"
+)
+
+// Global search categories returned by Search
+const (
+	SearchCategoryNodes         = "nodes"
+	SearchCategorySubscriptions = "subscriptions"
+	SearchCategoryRules         = "rules"
+	SearchCategoryFilters       = "filters"
+)
+
+// SearchMatch is a single global search hit
+type SearchMatch struct {
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+	Detail string `json:"detail,omitempty"` // server:port, URL, outbound or mode depending on category
+	Status string `json:"status,omitempty"` // node status, or enabled/disabled
+}
+
+// SearchResults groups global search hits by category.
+// Counts holds the total number of matches, which may exceed the returned hits.
+type SearchResults struct {
+	Query         string         `json:"query"`
+	Nodes         []SearchMatch  `json:"nodes"`
+	Subscriptions []SearchMatch  `json:"subscriptions"`
+	Rules         []SearchMatch  `json:"rules"`
+	Filters       []SearchMatch  `json:"filters"`
+	Counts        map[string]int `json:"counts"`
+}
+
+// IsValidNodeSort checks if the given key is a supported node sort key.
+func IsValidNodeSort(key string) bool {
+	switch key {
+	case NodeSortLatency, NodeSortCountry, NodeSortTag, NodeSortLastChecked:
+		return true
+	}
+	return false
+}
+
 // CountryNames maps country codes to English names
 var CountryNames = map[string]string{
 	"HK":      "Hong Kong",
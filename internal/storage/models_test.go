@@ -0,0 +1,142 @@
+package storage
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRenderNodeTagTemplate(t *testing.T) {
+	node := UnifiedNode{
+		Type:         "vless",
+		Server:       "hk1.example.com",
+		ServerPort:   443,
+		Country:      "hk",
+		CountryEmoji: "🇭🇰",
+	}
+
+	tests := []struct {
+		name     string
+		template string
+		node     UnifiedNode
+		index    int
+		want     string
+	}{
+		{name: "empty template", template: "  ", node: node, index: 1, want: ""},
+		{name: "country and index", template: "{country}-{index}", node: node, index: 3, want: "HK-3"},
+		{name: "emoji country protocol", template: "{emoji} {country} {protocol}", node: node, index: 1, want: "🇭🇰 HK vless"},
+		{name: "server", template: "{protocol}@{server}", node: node, index: 1, want: "vless@hk1.example.com"},
+		{name: "unknown country", template: "{emoji} {country}-{index}", node: UnifiedNode{Type: "trojan"}, index: 2, want: "🌐 NODE-2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := RenderNodeTagTemplate(tt.template, tt.node, tt.index); got != tt.want {
+				t.Fatalf("render mismatch: got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateTunSettings(t *testing.T) {
+	tests := []struct {
+		stack string
+		mtu   int
+		ok    bool
+	}{
+		{stack: "", mtu: 0, ok: true},
+		{stack: "system", mtu: 1500, ok: true},
+		{stack: "gvisor", mtu: 9000, ok: true},
+		{stack: "mixed", mtu: 0, ok: true},
+		{stack: "lwip", mtu: 0, ok: false},
+		{stack: "system", mtu: 100, ok: false},
+		{stack: "system", mtu: 70000, ok: false},
+	}
+	for _, tt := range tests {
+		if err := ValidateTunSettings(tt.stack, tt.mtu); (err == nil) != tt.ok {
+			t.Fatalf("validate(%q, %d) mismatch: got %v, want ok=%v", tt.stack, tt.mtu, err, tt.ok)
+		}
+	}
+}
+
+func TestValidateTunRoutes(t *testing.T) {
+	if err := ValidateTunRoutes([]string{"172.17.0.0/16", " 10.8.0.0/24 ", "", "fd00::/8"}); err != nil {
+		t.Fatalf("expected CIDR routes to be valid: %v", err)
+	}
+	for _, route := range []string{"172.17.0.1", "10.0.0.0/33", "docker0"} {
+		if err := ValidateTunRoutes([]string{route}); err == nil {
+			t.Fatalf("expected %q to be rejected", route)
+		}
+	}
+}
+
+func TestValidateDirectProcesses(t *testing.T) {
+	if err := ValidateDirectProcesses([]string{"restic", " ", "/usr/bin/rclone", `C:\Program Files\Backup\backup.exe`}); err != nil {
+		t.Fatalf("expected process entries to be valid: %v", err)
+	}
+	for _, process := range []string{"..", "bin/restic", `Backup\backup.exe`} {
+		if err := ValidateDirectProcesses([]string{process}); err == nil {
+			t.Fatalf("expected %q to be rejected", process)
+		}
+	}
+}
+
+func TestValidateListenAddress(t *testing.T) {
+	for _, addr := range []string{"", "  ", "192.168.1.5", "0.0.0.0", "::1"} {
+		if err := ValidateListenAddress(addr); err != nil {
+			t.Fatalf("expected %q to be valid: %v", addr, err)
+		}
+	}
+	for _, addr := range []string{"localhost", "192.168.1.300", "10.0.0.1:80"} {
+		if err := ValidateListenAddress(addr); err == nil {
+			t.Fatalf("expected %q to be rejected", addr)
+		}
+	}
+}
+
+func TestNormalizeServer(t *testing.T) {
+	for raw, want := range map[string]string{
+		"example.com":      "example.com",
+		" 10.0.0.1 ":       "10.0.0.1",
+		"[2001:db8::1]":    "2001:db8::1",
+		"2001:DB8:0:0::1":  "2001:db8::1",
+		"[2001:db8:0::1]":  "2001:db8::1",
+		"[not-an-ip]":      "not-an-ip",
+		"::ffff:192.0.2.1": "192.0.2.1",
+	} {
+		if got := NormalizeServer(raw); got != want {
+			t.Fatalf("NormalizeServer(%q) mismatch: got %q, want %q", raw, got, want)
+		}
+	}
+}
+
+func TestQuietHoursContains(t *testing.T) {
+	at := func(hhmm string) time.Time {
+		parsed, _ := time.Parse("15:04", hhmm)
+		return time.Date(2024, 1, 1, parsed.Hour(), parsed.Minute(), 0, 0, time.Local)
+	}
+
+	overnight := QuietHours{Start: "23:00", End: "07:00"}
+	for clock, want := range map[string]bool{"22:59": false, "23:00": true, "02:00": true, "06:59": true, "07:00": false} {
+		if got := overnight.Contains(at(clock)); got != want {
+			t.Fatalf("overnight contains %s mismatch: got %v, want %v", clock, got, want)
+		}
+	}
+
+	daytime := QuietHours{Start: "09:00", End: "17:30"}
+	if !daytime.Contains(at("12:00")) || daytime.Contains(at("18:00")) {
+		t.Fatal("daytime window mismatch")
+	}
+	if next := overnight.NextEnd(at("23:30")); !next.Equal(at("07:00").AddDate(0, 0, 1)) {
+		t.Fatalf("next end mismatch: got %v", next)
+	}
+
+	if (QuietHours{}).Enabled() || (QuietHours{Start: "08:00", End: "08:00"}).Enabled() {
+		t.Fatal("blank and empty windows must be disabled")
+	}
+	if err := ValidateQuietHours(QuietHours{Start: "25:00", End: "07:00"}); err == nil {
+		t.Fatal("expected an invalid start to be rejected")
+	}
+	if err := ValidateQuietHours(QuietHours{}); err != nil {
+		t.Fatalf("blank window rejected: %v", err)
+	}
+}
@@ -0,0 +1,45 @@
+package storage
+
+import (
+	"strings"
+	"time"
+)
+
+// GetClientQuotas returns all client quotas ordered by source IP.
+func (s *SQLiteStore) GetClientQuotas() []ClientQuota {
+	quotas := []ClientQuota{}
+	rows, err := s.db.Query(`SELECT source_ip, daily_bytes, monthly_bytes, alert, updated_at
+		FROM client_quotas ORDER BY source_ip`)
+	if err != nil {
+		return quotas
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var q ClientQuota
+		var alert int
+		if err := rows.Scan(&q.SourceIP, &q.DailyBytes, &q.MonthlyBytes, &alert, &q.UpdatedAt); err != nil {
+			continue
+		}
+		q.Alert = alert != 0
+		quotas = append(quotas, q)
+	}
+	return quotas
+}
+
+// SetClientQuota inserts or replaces the quota for a source IP.
+func (s *SQLiteStore) SetClientQuota(quota ClientQuota) error {
+	if quota.UpdatedAt.IsZero() {
+		quota.UpdatedAt = time.Now()
+	}
+	_, err := s.db.Exec(`INSERT OR REPLACE INTO client_quotas (source_ip, daily_bytes, monthly_bytes, alert, updated_at)
+		VALUES (?, ?, ?, ?, ?)`,
+		strings.TrimSpace(quota.SourceIP), quota.DailyBytes, quota.MonthlyBytes, boolToInt(quota.Alert), quota.UpdatedAt)
+	return err
+}
+
+// DeleteClientQuota removes the quota for a source IP.
+func (s *SQLiteStore) DeleteClientQuota(sourceIP string) error {
+	_, err := s.db.Exec(`DELETE FROM client_quotas WHERE source_ip = ?`, strings.TrimSpace(sourceIP))
+	return err
+}
@@ -0,0 +1,42 @@
+package storage
+
+import (
+	"strings"
+	"time"
+)
+
+// GetCountryOverrides returns all country overrides keyed by upper-case country code.
+func (s *SQLiteStore) GetCountryOverrides() map[string]CountryOverride {
+	overrides := make(map[string]CountryOverride)
+	rows, err := s.db.Query(`SELECT code, emoji, name, updated_at FROM country_overrides`)
+	if err != nil {
+		return overrides
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var o CountryOverride
+		if err := rows.Scan(&o.Code, &o.Emoji, &o.Name, &o.UpdatedAt); err != nil {
+			continue
+		}
+		overrides[o.Code] = o
+	}
+	return overrides
+}
+
+// SetCountryOverride inserts or replaces the override for a country code.
+func (s *SQLiteStore) SetCountryOverride(override CountryOverride) error {
+	override.Code = strings.ToUpper(strings.TrimSpace(override.Code))
+	if override.UpdatedAt.IsZero() {
+		override.UpdatedAt = time.Now()
+	}
+	_, err := s.db.Exec(`INSERT OR REPLACE INTO country_overrides (code, emoji, name, updated_at) VALUES (?, ?, ?, ?)`,
+		override.Code, strings.TrimSpace(override.Emoji), strings.TrimSpace(override.Name), override.UpdatedAt)
+	return err
+}
+
+// DeleteCountryOverride removes the override for a country code.
+func (s *SQLiteStore) DeleteCountryOverride(code string) error {
+	_, err := s.db.Exec(`DELETE FROM country_overrides WHERE code = ?`, strings.ToUpper(strings.TrimSpace(code)))
+	return err
+}
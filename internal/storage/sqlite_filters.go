@@ -65,17 +65,44 @@ func (s *SQLiteStore) upsertFilter(f Filter, mustExist bool) error {
 		}
 	}
 
-	_, err := s.db.Exec(`INSERT OR REPLACE INTO filters
-		(id, name, mode, urltest_config_json, all_nodes, enabled,
-		 include_json, exclude_json, include_countries_json, exclude_countries_json, subscriptions_json)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+	_, err := s.db.Exec(upsertFilterSQL, filterArgs(f)...)
+	return err
+}
+
+// ReplaceFilters swaps the whole filter set in one transaction, so a failure leaves
+// the previous filters in place
+func (s *SQLiteStore) ReplaceFilters(filters []Filter) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM filters"); err != nil {
+		return err
+	}
+	for _, f := range filters {
+		if _, err := tx.Exec(upsertFilterSQL, filterArgs(f)...); err != nil {
+			return fmt.Errorf("insert filter %s: %w", f.ID, err)
+		}
+	}
+	return tx.Commit()
+}
+
+const upsertFilterSQL = `INSERT OR REPLACE INTO filters
+	(id, name, mode, urltest_config_json, all_nodes, enabled,
+	 include_json, exclude_json, include_countries_json, exclude_countries_json, subscriptions_json)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+func filterArgs(f Filter) []interface{} {
+	return []interface{}{
 		f.ID, f.Name, f.Mode,
 		marshalJSON(f.URLTestConfig),
 		boolToInt(f.AllNodes), boolToInt(f.Enabled),
 		marshalJSON(f.Include), marshalJSON(f.Exclude),
 		marshalJSON(f.IncludeCountries), marshalJSON(f.ExcludeCountries),
-		marshalJSON(f.Subscriptions))
-	return err
+		marshalJSON(f.Subscriptions),
+	}
 }
 
 func (s *SQLiteStore) DeleteFilter(id string) error {
@@ -190,8 +190,9 @@ func (s *SQLiteStore) GetGeoDataBulk(keys []string) (map[string]*GeoData, error)
 }
 
 // UpdateNodeCountry updates the country and country_emoji fields for a node by server:port.
+// Nodes whose country is locked are left untouched.
 func (s *SQLiteStore) UpdateNodeCountry(server string, port int, countryCode, countryEmoji string) error {
-	_, err := s.db.Exec(`UPDATE nodes SET country = ?, country_emoji = ? WHERE server = ? AND server_port = ?`,
+	_, err := s.db.Exec(`UPDATE nodes SET country = ?, country_emoji = ? WHERE server = ? AND server_port = ? AND country_locked = 0`,
 		countryCode, countryEmoji, server, port)
 	return err
 }
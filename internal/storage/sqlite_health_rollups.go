@@ -0,0 +1,223 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// healthRollupDayFormat is the layout of health_daily_rollups.day, in local time
+// to match how measurement timestamps are written.
+const healthRollupDayFormat = "2006-01-02"
+
+// healthTotals accumulates check counts and latency sums for one endpoint. Sums
+// rather than averages are kept so raw rows and rollups combine exactly.
+type healthTotals struct {
+	checks       int
+	alive        int
+	latencySum   int64
+	latencyCount int64
+	recentSum    int64 // latency at or after the trend midpoint
+	recentCount  int64
+}
+
+func (t *healthTotals) add(o healthTotals) {
+	t.checks += o.checks
+	t.alive += o.alive
+	t.latencySum += o.latencySum
+	t.latencyCount += o.latencyCount
+	t.recentSum += o.recentSum
+	t.recentCount += o.recentCount
+}
+
+func (t *healthTotals) uptimePercent() float64 {
+	if t.checks == 0 {
+		return 0
+	}
+	return float64(t.alive) / float64(t.checks) * 100
+}
+
+func (t *healthTotals) avgLatency() float64 {
+	if t.latencyCount == 0 {
+		return 0
+	}
+	return float64(t.latencySum) / float64(t.latencyCount)
+}
+
+// startOfDay returns local midnight of the day containing t
+func startOfDay(t time.Time) time.Time {
+	t = t.Local()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.Local)
+}
+
+// RollupHealthMeasurements folds raw health measurements recorded before the day
+// containing before into per-endpoint daily rollups and deletes the raw rows.
+// Only whole days are rolled up, so a day is either fully raw or fully rolled up.
+// It returns the number of raw rows pruned.
+func (s *SQLiteStore) RollupHealthMeasurements(before time.Time) (int64, error) {
+	cutoff := startOfDay(before)
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query(`SELECT server, server_port, timestamp, alive, latency_ms
+		FROM health_measurements WHERE timestamp < ?`, cutoff)
+	if err != nil {
+		return 0, err
+	}
+	type dayKey struct {
+		endpoint ServerPortKey
+		day      string
+	}
+	days := make(map[dayKey]*healthTotals)
+	for rows.Next() {
+		var key dayKey
+		var ts time.Time
+		var alive, latency int
+		if err := rows.Scan(&key.endpoint.Server, &key.endpoint.ServerPort, &ts, &alive, &latency); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("scanning health measurement row: %w", err)
+		}
+		key.day = ts.Local().Format(healthRollupDayFormat)
+		t := days[key]
+		if t == nil {
+			t = &healthTotals{}
+			days[key] = t
+		}
+		t.checks++
+		if alive == 1 {
+			t.alive++
+			if latency > 0 {
+				t.latencySum += int64(latency)
+				t.latencyCount++
+			}
+		}
+	}
+	if err := rows.Err(); err != nil {
+		rows.Close()
+		return 0, fmt.Errorf("iterating health measurement rows: %w", err)
+	}
+	rows.Close()
+	if len(days) == 0 {
+		return 0, nil
+	}
+
+	stmt, err := tx.Prepare(`INSERT INTO health_daily_rollups (server, server_port, day, checks, alive, latency_sum, latency_count)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(server, server_port, day) DO UPDATE SET
+			checks = checks + excluded.checks,
+			alive = alive + excluded.alive,
+			latency_sum = latency_sum + excluded.latency_sum,
+			latency_count = latency_count + excluded.latency_count`)
+	if err != nil {
+		return 0, err
+	}
+	defer stmt.Close()
+	for key, t := range days {
+		if _, err := stmt.Exec(key.endpoint.Server, key.endpoint.ServerPort, key.day, t.checks, t.alive, t.latencySum, t.latencyCount); err != nil {
+			return 0, fmt.Errorf("upsert health rollup %s:%d %s: %w", key.endpoint.Server, key.endpoint.ServerPort, key.day, err)
+		}
+	}
+
+	res, err := tx.Exec(`DELETE FROM health_measurements WHERE timestamp < ?`, cutoff)
+	if err != nil {
+		return 0, fmt.Errorf("prune health measurements: %w", err)
+	}
+	pruned, _ := res.RowsAffected()
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return pruned, nil
+}
+
+// collectHealthTotals sums health checks recorded at or after since per endpoint,
+// reading daily rollups for days whose raw rows were pruned and raw measurements
+// for the rest. Rollup days are counted whole, so since is effectively rounded
+// down to midnight for pruned history. Latency at or after midpoint is also
+// summed separately for trend detection; rolled-up days only count as recent
+// when they start at or after it. A nil endpoint covers all endpoints.
+func (s *SQLiteStore) collectHealthTotals(since, midpoint time.Time, endpoint *ServerPortKey) (map[ServerPortKey]*healthTotals, error) {
+	totals := make(map[ServerPortKey]*healthTotals)
+	merge := func(key ServerPortKey, t healthTotals) {
+		if cur := totals[key]; cur != nil {
+			cur.add(t)
+			return
+		}
+		totals[key] = &t
+	}
+
+	// Raw rows start at the oldest unpruned day; everything before it lives in rollups.
+	var oldestRaw time.Time
+	err := s.db.QueryRow(`SELECT timestamp FROM health_measurements ORDER BY timestamp LIMIT 1`).Scan(&oldestRaw)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+
+	rollupQuery := `SELECT server, server_port,
+		SUM(checks), SUM(alive), SUM(latency_sum), SUM(latency_count),
+		COALESCE(SUM(CASE WHEN day >= ? THEN latency_sum END), 0),
+		COALESCE(SUM(CASE WHEN day >= ? THEN latency_count END), 0)
+		FROM health_daily_rollups
+		WHERE day >= ?`
+	// A rollup day counts as recent only if it starts at or after midpoint
+	midStart := startOfDay(midpoint)
+	if midStart.Before(midpoint) {
+		midStart = midStart.AddDate(0, 0, 1)
+	}
+	midDay := midStart.Format(healthRollupDayFormat)
+	rollupArgs := []interface{}{midDay, midDay, startOfDay(since).Format(healthRollupDayFormat)}
+	if !oldestRaw.IsZero() {
+		rollupQuery += ` AND day < ?`
+		rollupArgs = append(rollupArgs, startOfDay(oldestRaw).Format(healthRollupDayFormat))
+	}
+	if endpoint != nil {
+		rollupQuery += ` AND server = ? AND server_port = ?`
+		rollupArgs = append(rollupArgs, endpoint.Server, endpoint.ServerPort)
+	}
+	if err := s.scanHealthTotals(rollupQuery+` GROUP BY server, server_port`, rollupArgs, merge); err != nil {
+		return nil, fmt.Errorf("health rollups: %w", err)
+	}
+
+	rawQuery := `SELECT server, server_port,
+		COUNT(*),
+		COALESCE(SUM(CASE WHEN alive = 1 THEN 1 ELSE 0 END), 0),
+		COALESCE(SUM(CASE WHEN alive = 1 AND latency_ms > 0 THEN latency_ms END), 0),
+		COALESCE(SUM(CASE WHEN alive = 1 AND latency_ms > 0 THEN 1 ELSE 0 END), 0),
+		COALESCE(SUM(CASE WHEN alive = 1 AND latency_ms > 0 AND timestamp >= ? THEN latency_ms END), 0),
+		COALESCE(SUM(CASE WHEN alive = 1 AND latency_ms > 0 AND timestamp >= ? THEN 1 ELSE 0 END), 0)
+		FROM health_measurements
+		WHERE timestamp >= ?`
+	rawArgs := []interface{}{midpoint, midpoint, since}
+	if endpoint != nil {
+		rawQuery += ` AND server = ? AND server_port = ?`
+		rawArgs = append(rawArgs, endpoint.Server, endpoint.ServerPort)
+	}
+	if err := s.scanHealthTotals(rawQuery+` GROUP BY server, server_port`, rawArgs, merge); err != nil {
+		return nil, fmt.Errorf("health measurements: %w", err)
+	}
+	return totals, nil
+}
+
+// scanHealthTotals runs an aggregate query returning server, server_port and the
+// healthTotals columns in declaration order, passing each row to fn.
+func (s *SQLiteStore) scanHealthTotals(query string, args []interface{}, fn func(ServerPortKey, healthTotals)) error {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var key ServerPortKey
+		var t healthTotals
+		if err := rows.Scan(&key.Server, &key.ServerPort, &t.checks, &t.alive, &t.latencySum, &t.latencyCount, &t.recentSum, &t.recentCount); err != nil {
+			return fmt.Errorf("scanning health totals row: %w", err)
+		}
+		fn(key, t)
+	}
+	return rows.Err()
+}
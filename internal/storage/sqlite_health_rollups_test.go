@@ -0,0 +1,156 @@
+package storage
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestRollupHealthMeasurements_DailyTotals(t *testing.T) {
+	store, err := NewSQLiteStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("create sqlite store: %v", err)
+	}
+	t.Cleanup(func() { _ = store.Close() })
+
+	today := startOfDay(time.Now())
+	fiveDaysAgo := today.AddDate(0, 0, -5)
+	fourDaysAgo := today.AddDate(0, 0, -4)
+	measurements := []HealthMeasurement{
+		{Server: "1.1.1.1", ServerPort: 443, NodeTag: "a", Timestamp: fiveDaysAgo.Add(1 * time.Hour), Alive: true, LatencyMs: 100},
+		{Server: "1.1.1.1", ServerPort: 443, NodeTag: "a", Timestamp: fiveDaysAgo.Add(2 * time.Hour), Alive: true, LatencyMs: 300},
+		{Server: "1.1.1.1", ServerPort: 443, NodeTag: "a", Timestamp: fiveDaysAgo.Add(3 * time.Hour), Alive: false},
+		// alive without a latency reading must not drag the average down
+		{Server: "1.1.1.1", ServerPort: 443, NodeTag: "a", Timestamp: fourDaysAgo.Add(time.Hour), Alive: true, LatencyMs: 0},
+		{Server: "2.2.2.2", ServerPort: 8443, NodeTag: "b", Timestamp: fiveDaysAgo.Add(time.Hour), Alive: true, LatencyMs: 50},
+		// inside the retention window, stays raw
+		{Server: "1.1.1.1", ServerPort: 443, NodeTag: "a", Timestamp: today.AddDate(0, 0, -1), Alive: true, LatencyMs: 200},
+	}
+	if err := store.AddHealthMeasurements(measurements); err != nil {
+		t.Fatalf("add health measurements: %v", err)
+	}
+
+	pruned, err := store.RollupHealthMeasurements(today.AddDate(0, 0, -2))
+	if err != nil {
+		t.Fatalf("rollup health measurements: %v", err)
+	}
+	if pruned != 5 {
+		t.Fatalf("pruned rows mismatch: got %d, want 5", pruned)
+	}
+
+	type rollup struct {
+		server       string
+		day          string
+		checks       int
+		alive        int
+		latencySum   int64
+		latencyCount int64
+	}
+	rows, err := store.db.Query(`SELECT server, day, checks, alive, latency_sum, latency_count
+		FROM health_daily_rollups ORDER BY server, day`)
+	if err != nil {
+		t.Fatalf("query rollups: %v", err)
+	}
+	defer rows.Close()
+	var got []rollup
+	for rows.Next() {
+		var r rollup
+		if err := rows.Scan(&r.server, &r.day, &r.checks, &r.alive, &r.latencySum, &r.latencyCount); err != nil {
+			t.Fatalf("scan rollup: %v", err)
+		}
+		got = append(got, r)
+	}
+	want := []rollup{
+		{server: "1.1.1.1", day: fiveDaysAgo.Format(healthRollupDayFormat), checks: 3, alive: 2, latencySum: 400, latencyCount: 2},
+		{server: "1.1.1.1", day: fourDaysAgo.Format(healthRollupDayFormat), checks: 1, alive: 1},
+		{server: "2.2.2.2", day: fiveDaysAgo.Format(healthRollupDayFormat), checks: 1, alive: 1, latencySum: 50, latencyCount: 1},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("rollups mismatch: got %+v, want %+v", got, want)
+	}
+
+	remaining, err := store.GetHealthMeasurements("1.1.1.1", 443, 0)
+	if err != nil {
+		t.Fatalf("get health measurements: %v", err)
+	}
+	if len(remaining) != 1 || remaining[0].LatencyMs != 200 {
+		t.Fatalf("remaining raw rows mismatch: got %+v, want only the recent check", remaining)
+	}
+}
+
+func TestHealthStats_MatchAfterRollup(t *testing.T) {
+	store, err := NewSQLiteStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("create sqlite store: %v", err)
+	}
+	t.Cleanup(func() { _ = store.Close() })
+
+	today := startOfDay(time.Now())
+	var measurements []HealthMeasurement
+	for d := 6; d >= 1; d-- {
+		day := today.AddDate(0, 0, -d)
+		for h := 0; h < 4; h++ {
+			measurements = append(measurements,
+				// latency grows over time so the trend is "up"
+				HealthMeasurement{Server: "1.1.1.1", ServerPort: 443, NodeTag: "a", Timestamp: day.Add(time.Duration(h*5+1) * time.Hour),
+					Alive: h != 3, LatencyMs: 100 + (6-d)*40 + h},
+				HealthMeasurement{Server: "2.2.2.2", ServerPort: 8443, NodeTag: "b", Timestamp: day.Add(time.Duration(h*5+2) * time.Hour),
+					Alive: d%2 == 0, LatencyMs: 80},
+			)
+		}
+	}
+	if err := store.AddHealthMeasurements(measurements); err != nil {
+		t.Fatalf("add health measurements: %v", err)
+	}
+
+	type snapshot struct {
+		bulk7, bulk90 []NodeStabilityStats
+		all, recent   *HealthStats
+	}
+	recentSince := today.AddDate(0, 0, -2)
+	take := func() snapshot {
+		t.Helper()
+		var s snapshot
+		var err error
+		if s.bulk7, err = store.GetBulkHealthStats(7); err != nil {
+			t.Fatalf("bulk stats 7d: %v", err)
+		}
+		if s.bulk90, err = store.GetBulkHealthStats(90); err != nil {
+			t.Fatalf("bulk stats 90d: %v", err)
+		}
+		if s.all, err = store.GetHealthStats("1.1.1.1", 443); err != nil {
+			t.Fatalf("health stats: %v", err)
+		}
+		if s.recent, err = store.GetHealthStatsSince("1.1.1.1", 443, recentSince); err != nil {
+			t.Fatalf("health stats since: %v", err)
+		}
+		return s
+	}
+
+	before := take()
+	if len(before.bulk7) != 2 || before.bulk7[0].LatencyTrend != "up" {
+		t.Fatalf("unexpected baseline stats: %+v", before.bulk7)
+	}
+
+	for i := 0; i < 2; i++ {
+		pruned, err := store.RollupHealthMeasurements(today.AddDate(0, 0, -4))
+		if err != nil {
+			t.Fatalf("rollup health measurements: %v", err)
+		}
+		// days -6 and -5, 4 checks per endpoint per day; both end before the 7-day trend midpoint
+		wantPruned := int64(16)
+		if i > 0 {
+			wantPruned = 0
+		}
+		if pruned != wantPruned {
+			t.Fatalf("pruned rows mismatch on run %d: got %d, want %d", i+1, pruned, wantPruned)
+		}
+
+		after := take()
+		if !reflect.DeepEqual(after, before) {
+			t.Fatalf("stats changed after rollup run %d:\ngot  %+v %+v %+v %+v\nwant %+v %+v %+v %+v", i+1,
+				after.bulk7, after.bulk90, *after.all, *after.recent,
+				before.bulk7, before.bulk90, *before.all, *before.recent)
+		}
+	}
+}
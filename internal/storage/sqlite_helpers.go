@@ -159,10 +159,18 @@ func (s *SQLiteStore) RemoveNodesByEndpoints(endpoints []ServerPortKey) (int, er
 	return removed, nil
 }
 
-// GetAllNodes returns all verified nodes (used by config builder).
+// configNodeCondition selects nodes that belong in the generated config:
+// verified nodes plus pinned nodes that were demoted back to pending. Nodes their
+// subscription no longer lists stay out unless pinned.
+// Queries using it alias the nodes table as n.
+const configNodeCondition = `((n.status = 'verified' OR (n.pinned = 1 AND n.status = 'pending'))
+	AND (n.missing_since IS NULL OR n.pinned = 1))`
+
+// GetAllNodes returns the nodes selected by configNodeCondition (used by config builder).
 func (s *SQLiteStore) GetAllNodes() []Node {
-	rows, err := s.db.Query(`SELECT tag, internal_tag, display_name, source_tag, type, server, server_port, country, country_emoji, extra_json
-		FROM nodes WHERE status = 'verified'`)
+	rows, err := s.db.Query(`SELECT n.tag, n.internal_tag, n.display_name, n.source_tag, n.type, n.server, n.server_port,
+		n.country, n.country_emoji, n.extra_json, n.exclude_from_auto
+		FROM nodes n WHERE ` + configNodeCondition)
 	if err != nil {
 		return []Node{}
 	}
@@ -181,9 +189,70 @@ func (s *SQLiteStore) GetAllNodes() []Node {
 	return s.enrichNodesWithGeoCountry(nodes)
 }
 
+// nodeSortExpressions maps sort keys to SQL ORDER BY expressions over the aliased nodes query.
+// Latency uses the latest health measurement; dead or unmeasured nodes rank as the slowest.
+var nodeSortExpressions = map[string]string{
+	NodeSortLatency:     `COALESCE(latest_latency, 2147483647)`,
+	NodeSortCountry:     `COALESCE(NULLIF(CASE WHEN g.status = 'success' AND n.country_locked = 0 THEN UPPER(TRIM(g.country_code)) END, ''), n.country)`,
+	NodeSortTag:         `LOWER(COALESCE(NULLIF(n.display_name, ''), n.tag))`,
+	NodeSortLastChecked: `n.last_checked_at`,
+}
+
+// GetAllNodesSorted returns the config nodes (see GetAllNodes) ordered by the given sort key.
+// Unknown keys fall back to store order.
+func (s *SQLiteStore) GetAllNodesSorted(sortBy string, desc bool) []Node {
+	expr, ok := nodeSortExpressions[sortBy]
+	if !ok {
+		return s.GetAllNodes()
+	}
+	dir := "ASC"
+	if desc {
+		dir = "DESC"
+	}
+	orderBy := expr + " " + dir
+	if sortBy == NodeSortLastChecked {
+		// Never-checked nodes always go last
+		orderBy = "(n.last_checked_at IS NULL), " + orderBy
+	}
+
+	rows, err := s.db.Query(`SELECT n.tag, n.internal_tag, n.display_name, n.source_tag, n.type, n.server, n.server_port,
+		n.country, n.country_emoji, n.extra_json, n.exclude_from_auto,
+		(SELECT CASE WHEN hm.alive = 1 AND hm.latency_ms > 0 THEN hm.latency_ms END
+			FROM health_measurements hm
+			WHERE hm.server = n.server AND hm.server_port = n.server_port
+			ORDER BY hm.timestamp DESC, hm.id DESC LIMIT 1) AS latest_latency
+		FROM nodes n
+		LEFT JOIN geo_data g ON g.server = n.server AND g.server_port = n.server_port
+		WHERE ` + configNodeCondition + `
+		ORDER BY ` + orderBy + `, n.id`)
+	if err != nil {
+		return []Node{}
+	}
+	defer rows.Close()
+
+	var nodes []Node
+	for rows.Next() {
+		var n Node
+		var extraJSON *string
+		var latency *int64
+		if err := rows.Scan(&n.Tag, &n.InternalTag, &n.DisplayName, &n.SourceTag, &n.Type, &n.Server, &n.ServerPort,
+			&n.Country, &n.CountryEmoji, &extraJSON, &n.ExcludeFromAuto, &latency); err != nil {
+			continue
+		}
+		if extraJSON != nil && *extraJSON != "" {
+			_ = jsonUnmarshalMap(*extraJSON, &n.Extra)
+		}
+		nodes = append(nodes, n)
+	}
+	if nodes == nil {
+		nodes = []Node{}
+	}
+	return s.enrichNodesWithGeoCountry(nodes)
+}
+
 // GetAllNodesIncludeDisabled returns all nodes regardless of status.
 func (s *SQLiteStore) GetAllNodesIncludeDisabled() []Node {
-	rows, err := s.db.Query(`SELECT tag, internal_tag, display_name, source_tag, type, server, server_port, country, country_emoji, extra_json FROM nodes`)
+	rows, err := s.db.Query(`SELECT tag, internal_tag, display_name, source_tag, type, server, server_port, country, country_emoji, extra_json, exclude_from_auto FROM nodes`)
 	if err != nil {
 		return []Node{}
 	}
@@ -203,10 +272,12 @@ func (s *SQLiteStore) GetAllNodesIncludeDisabled() []Node {
 }
 
 // enrichNodesWithGeoCountry overrides node country from geo_data when the latest geo result is successful.
+// Nodes with a locked country keep the stored one.
 func (s *SQLiteStore) enrichNodesWithGeoCountry(nodes []Node) []Node {
 	if len(nodes) == 0 {
 		return nodes
 	}
+	locked := s.countryLockedKeys()
 
 	keys := make([]string, 0, len(nodes))
 	seen := make(map[string]struct{}, len(nodes))
@@ -226,6 +297,9 @@ func (s *SQLiteStore) enrichNodesWithGeoCountry(nodes []Node) []Node {
 
 	for i := range nodes {
 		key := fmt.Sprintf("%s:%d", nodes[i].Server, nodes[i].ServerPort)
+		if locked[key] {
+			continue
+		}
 		geo := geoMap[key]
 		if geo == nil || geo.Status != "success" {
 			continue
@@ -241,6 +315,25 @@ func (s *SQLiteStore) enrichNodesWithGeoCountry(nodes []Node) []Node {
 	return nodes
 }
 
+// countryLockedKeys returns the server:port keys of nodes whose country is locked.
+func (s *SQLiteStore) countryLockedKeys() map[string]bool {
+	locked := make(map[string]bool)
+	rows, err := s.db.Query(`SELECT server, server_port FROM nodes WHERE country_locked = 1`)
+	if err != nil {
+		return locked
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var server string
+		var port int
+		if err := rows.Scan(&server, &port); err != nil {
+			continue
+		}
+		locked[fmt.Sprintf("%s:%d", server, port)] = true
+	}
+	return locked
+}
+
 // GetNodesByCountry returns verified nodes for a country code.
 func (s *SQLiteStore) GetNodesByCountry(countryCode string) []Node {
 	target := strings.ToUpper(strings.TrimSpace(countryCode))
@@ -284,12 +377,18 @@ func (s *SQLiteStore) GetCountryGroups() []CountryGroup {
 		countryCount[code] += count
 	}
 
+	overrides := s.GetCountryOverrides()
 	var groups []CountryGroup
 	for code, count := range countryCount {
+		var override *CountryOverride
+		if o, ok := overrides[code]; ok {
+			override = &o
+		}
+		emoji, name := ResolveCountryDisplay(code, override)
 		groups = append(groups, CountryGroup{
 			Code:      code,
-			Name:      GetCountryName(code),
-			Emoji:     GetCountryEmoji(code),
+			Name:      name,
+			Emoji:     emoji,
 			NodeCount: count,
 		})
 	}
@@ -305,7 +404,7 @@ func scanNodeRow(rows interface {
 }) *Node {
 	var n Node
 	var extraJSON *string
-	if err := rows.Scan(&n.Tag, &n.InternalTag, &n.DisplayName, &n.SourceTag, &n.Type, &n.Server, &n.ServerPort, &n.Country, &n.CountryEmoji, &extraJSON); err != nil {
+	if err := rows.Scan(&n.Tag, &n.InternalTag, &n.DisplayName, &n.SourceTag, &n.Type, &n.Server, &n.ServerPort, &n.Country, &n.CountryEmoji, &extraJSON, &n.ExcludeFromAuto); err != nil {
 		return nil
 	}
 	if extraJSON != nil && *extraJSON != "" {
@@ -51,6 +51,72 @@ func TestGetAllNodes_UsesSuccessfulGeoCountry(t *testing.T) {
 	}
 }
 
+func TestGetAllNodes_LockedCountrySurvivesGeoCheck(t *testing.T) {
+	store, err := NewSQLiteStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("create sqlite store: %v", err)
+	}
+	t.Cleanup(func() { _ = store.Close() })
+
+	id, err := store.AddNode(UnifiedNode{
+		Tag:          "node-geo-locked",
+		InternalTag:  "node-geo-locked",
+		DisplayName:  "node-geo-locked",
+		SourceTag:    "node-geo-locked",
+		Type:         "vmess",
+		Server:       "1.1.1.1",
+		ServerPort:   443,
+		Country:      "US",
+		CountryEmoji: GetCountryEmoji("US"),
+		Status:       NodeStatusVerified,
+	})
+	if err != nil {
+		t.Fatalf("insert verified node: %v", err)
+	}
+	if err := store.SetNodeCountry(id, "SG", GetCountryEmoji("SG"), true); err != nil {
+		t.Fatalf("set node country: %v", err)
+	}
+
+	// What a geo check stores after detecting the node in Japan
+	if err := store.UpsertGeoData(GeoData{
+		Server:      "1.1.1.1",
+		ServerPort:  443,
+		NodeTag:     "node-geo-locked",
+		Timestamp:   time.Now(),
+		Status:      "success",
+		Country:     "Japan",
+		CountryCode: "JP",
+	}); err != nil {
+		t.Fatalf("upsert geo data: %v", err)
+	}
+	if err := store.UpdateNodeCountry("1.1.1.1", 443, "JP", GetCountryEmoji("JP")); err != nil {
+		t.Fatalf("update node country: %v", err)
+	}
+
+	node := store.GetNodeByID(id)
+	if node == nil {
+		t.Fatalf("node %d not found", id)
+	}
+	if node.Country != "SG" || !node.CountryLocked {
+		t.Fatalf("stored country mismatch: got %q locked=%v, want %q locked=true", node.Country, node.CountryLocked, "SG")
+	}
+	nodes := store.GetAllNodes()
+	if len(nodes) != 1 {
+		t.Fatalf("expected 1 node, got %d", len(nodes))
+	}
+	if nodes[0].Country != "SG" {
+		t.Fatalf("config country mismatch: got %q, want %q", nodes[0].Country, "SG")
+	}
+
+	// Unlocking lets the next geo check take over again
+	if err := store.SetNodeCountry(id, "SG", GetCountryEmoji("SG"), false); err != nil {
+		t.Fatalf("unlock node country: %v", err)
+	}
+	if got := store.GetAllNodes()[0].Country; got != "JP" {
+		t.Fatalf("unlocked country mismatch: got %q, want %q", got, "JP")
+	}
+}
+
 func TestGetAllNodes_IgnoresFailedGeoCountry(t *testing.T) {
 	store, err := NewSQLiteStore(t.TempDir())
 	if err != nil {
@@ -217,3 +283,60 @@ func TestGetCountryGroups_UsesGeoCountry(t *testing.T) {
 		t.Fatalf("unexpected US group — should count from geo_data, not nodes table")
 	}
 }
+
+func TestGetAllNodesSorted_LatencyAscendingDeadLast(t *testing.T) {
+	store, err := NewSQLiteStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("create sqlite store: %v", err)
+	}
+	t.Cleanup(func() { _ = store.Close() })
+
+	for _, spec := range []struct {
+		tag    string
+		server string
+	}{
+		{"node-dead", "10.0.0.1"},
+		{"node-slow", "10.0.0.2"},
+		{"node-fast", "10.0.0.3"},
+		{"node-unchecked", "10.0.0.4"},
+	} {
+		if _, err := store.AddNode(UnifiedNode{
+			Tag:         spec.tag,
+			InternalTag: spec.tag,
+			Type:        "vmess",
+			Server:      spec.server,
+			ServerPort:  443,
+			Status:      NodeStatusVerified,
+		}); err != nil {
+			t.Fatalf("insert node %s: %v", spec.tag, err)
+		}
+	}
+
+	now := time.Now()
+	if err := store.AddHealthMeasurements([]HealthMeasurement{
+		{Server: "10.0.0.1", ServerPort: 443, NodeTag: "node-dead", Timestamp: now.Add(-time.Hour), Alive: true, LatencyMs: 10},
+		{Server: "10.0.0.1", ServerPort: 443, NodeTag: "node-dead", Timestamp: now, Alive: false},
+		{Server: "10.0.0.2", ServerPort: 443, NodeTag: "node-slow", Timestamp: now, Alive: true, LatencyMs: 400},
+		{Server: "10.0.0.3", ServerPort: 443, NodeTag: "node-fast", Timestamp: now.Add(-time.Hour), Alive: true, LatencyMs: 900},
+		{Server: "10.0.0.3", ServerPort: 443, NodeTag: "node-fast", Timestamp: now, Alive: true, LatencyMs: 50},
+	}); err != nil {
+		t.Fatalf("add health measurements: %v", err)
+	}
+
+	nodes := store.GetAllNodesSorted(NodeSortLatency, false)
+	if len(nodes) != 4 {
+		t.Fatalf("expected 4 nodes, got %d", len(nodes))
+	}
+	got := []string{nodes[0].InternalTag, nodes[1].InternalTag}
+	want := []string{"node-fast", "node-slow"}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("order mismatch at %d: got %q, want %q", i, got[i], want[i])
+		}
+	}
+	for _, n := range nodes[2:] {
+		if n.InternalTag != "node-dead" && n.InternalTag != "node-unchecked" {
+			t.Fatalf("expected dead/unchecked nodes last, got %q", n.InternalTag)
+		}
+	}
+}
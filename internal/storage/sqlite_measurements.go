@@ -1,42 +1,84 @@
 package storage
 
 import (
+	"database/sql"
 	"fmt"
+	"sort"
 	"time"
 )
 
-func (s *SQLiteStore) AddHealthMeasurements(measurements []HealthMeasurement) error {
-	if len(measurements) == 0 {
-		return nil
+// measurementChunkSize bounds how many measurement rows go into one transaction,
+// so a check over thousands of nodes doesn't hold the write lock for the whole batch.
+const measurementChunkSize = 500
+
+// ChunkedInsertError reports a batch insert that stopped part way. Rows from the
+// chunks before the failing one are already committed.
+type ChunkedInsertError struct {
+	Inserted int
+	Total    int
+	Err      error
+}
+
+func (e *ChunkedInsertError) Error() string {
+	return fmt.Sprintf("saved %d of %d rows: %v", e.Inserted, e.Total, e.Err)
+}
+
+func (e *ChunkedInsertError) Unwrap() error {
+	return e.Err
+}
+
+// insertInChunks runs insert for consecutive [start, end) ranges of at most size rows,
+// each in its own transaction. It stops at the first failing chunk.
+func (s *SQLiteStore) insertInChunks(total, size int, insert func(tx *sql.Tx, start, end int) error) error {
+	for start := 0; start < total; start += size {
+		end := min(start+size, total)
+		if err := s.insertChunk(start, end, insert); err != nil {
+			return &ChunkedInsertError{Inserted: start, Total: total, Err: err}
+		}
 	}
+	return nil
+}
 
+func (s *SQLiteStore) insertChunk(start, end int, insert func(tx *sql.Tx, start, end int) error) error {
 	tx, err := s.db.Begin()
 	if err != nil {
 		return err
 	}
 	defer tx.Rollback()
 
-	stmt, err := tx.Prepare(`INSERT INTO health_measurements (server, server_port, node_tag, timestamp, alive, latency_ms, mode)
-		VALUES (?, ?, ?, ?, ?, ?, ?)`)
-	if err != nil {
+	if err := insert(tx, start, end); err != nil {
 		return err
 	}
-	defer stmt.Close()
+	return tx.Commit()
+}
 
-	for _, m := range measurements {
-		if m.Timestamp.IsZero() {
-			m.Timestamp = time.Now()
-		}
-		alive := 0
-		if m.Alive {
-			alive = 1
-		}
-		if _, err := stmt.Exec(m.Server, m.ServerPort, m.NodeTag, m.Timestamp, alive, m.LatencyMs, m.Mode); err != nil {
+func (s *SQLiteStore) AddHealthMeasurements(measurements []HealthMeasurement) error {
+	if len(measurements) == 0 {
+		return nil
+	}
+
+	return s.insertInChunks(len(measurements), measurementChunkSize, func(tx *sql.Tx, start, end int) error {
+		stmt, err := tx.Prepare(`INSERT INTO health_measurements (server, server_port, node_tag, timestamp, alive, latency_ms, mode)
+			VALUES (?, ?, ?, ?, ?, ?, ?)`)
+		if err != nil {
 			return err
 		}
-	}
+		defer stmt.Close()
 
-	return tx.Commit()
+		for _, m := range measurements[start:end] {
+			if m.Timestamp.IsZero() {
+				m.Timestamp = time.Now()
+			}
+			alive := 0
+			if m.Alive {
+				alive = 1
+			}
+			if _, err := stmt.Exec(m.Server, m.ServerPort, m.NodeTag, m.Timestamp, alive, m.LatencyMs, m.Mode); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
 }
 
 func (s *SQLiteStore) GetHealthMeasurements(server string, port int, limit int) ([]HealthMeasurement, error) {
@@ -69,21 +111,24 @@ func (s *SQLiteStore) GetHealthMeasurements(server string, port int, limit int)
 }
 
 func (s *SQLiteStore) GetHealthStats(server string, port int) (*HealthStats, error) {
-	row := s.db.QueryRow(`SELECT
-		COUNT(*) as total,
-		SUM(CASE WHEN alive = 1 THEN 1 ELSE 0 END) as alive_count,
-		COALESCE(AVG(CASE WHEN alive = 1 AND latency_ms > 0 THEN latency_ms END), 0) as avg_latency
-		FROM health_measurements
-		WHERE server = ? AND server_port = ?`, server, port)
+	return s.GetHealthStatsSince(server, port, time.Time{})
+}
 
-	var stats HealthStats
-	var avgLatency float64
-	if err := row.Scan(&stats.TotalChecks, &stats.AliveChecks, &avgLatency); err != nil {
+// GetHealthStatsSince aggregates health measurements recorded at or after since,
+// including daily rollups of pruned history. A zero since covers the full history.
+func (s *SQLiteStore) GetHealthStatsSince(server string, port int, since time.Time) (*HealthStats, error) {
+	key := ServerPortKey{Server: server, ServerPort: port}
+	totals, err := s.collectHealthTotals(since, time.Time{}, &key)
+	if err != nil {
 		return nil, err
 	}
-	stats.AvgLatencyMs = avgLatency
-	if stats.TotalChecks > 0 {
-		stats.UptimePercent = float64(stats.AliveChecks) / float64(stats.TotalChecks) * 100
+
+	var stats HealthStats
+	if t := totals[key]; t != nil {
+		stats.TotalChecks = t.checks
+		stats.AliveChecks = t.alive
+		stats.UptimePercent = t.uptimePercent()
+		stats.AvgLatencyMs = t.avgLatency()
 	}
 	return &stats, nil
 }
@@ -97,30 +142,27 @@ func (s *SQLiteStore) GetBulkHealthStats(days int) ([]NodeStabilityStats, error)
 	cutoff := now.AddDate(0, 0, -days)
 	midpoint := cutoff.Add(time.Duration(days) * 12 * time.Hour)
 
-	rows, err := s.db.Query(`SELECT
-		server, server_port,
-		COUNT(*) as total,
-		SUM(CASE WHEN alive = 1 THEN 1 ELSE 0 END) as alive_count,
-		COALESCE(AVG(CASE WHEN alive = 1 AND latency_ms > 0 THEN latency_ms END), 0) as avg_lat,
-		COALESCE(AVG(CASE WHEN alive = 1 AND latency_ms > 0 AND timestamp >= ? THEN latency_ms END), 0) as recent_avg,
-		COALESCE(AVG(CASE WHEN alive = 1 AND latency_ms > 0 AND timestamp < ? THEN latency_ms END), 0) as older_avg
-		FROM health_measurements
-		WHERE timestamp >= ?
-		GROUP BY server, server_port`, midpoint, midpoint, cutoff)
+	totals, err := s.collectHealthTotals(cutoff, midpoint, nil)
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
 
-	var results []NodeStabilityStats
-	for rows.Next() {
-		var st NodeStabilityStats
+	results := make([]NodeStabilityStats, 0, len(totals))
+	for key, t := range totals {
+		st := NodeStabilityStats{
+			Server:        key.Server,
+			ServerPort:    key.ServerPort,
+			TotalChecks:   t.checks,
+			AliveChecks:   t.alive,
+			UptimePercent: t.uptimePercent(),
+			AvgLatencyMs:  t.avgLatency(),
+		}
 		var recentAvg, olderAvg float64
-		if err := rows.Scan(&st.Server, &st.ServerPort, &st.TotalChecks, &st.AliveChecks, &st.AvgLatencyMs, &recentAvg, &olderAvg); err != nil {
-			return nil, fmt.Errorf("scanning health stats row: %w", err)
+		if t.recentCount > 0 {
+			recentAvg = float64(t.recentSum) / float64(t.recentCount)
 		}
-		if st.TotalChecks > 0 {
-			st.UptimePercent = float64(st.AliveChecks) / float64(st.TotalChecks) * 100
+		if olderCount := t.latencyCount - t.recentCount; olderCount > 0 {
+			olderAvg = float64(t.latencySum-t.recentSum) / float64(olderCount)
 		}
 		// Determine latency trend
 		if olderAvg == 0 {
@@ -134,9 +176,12 @@ func (s *SQLiteStore) GetBulkHealthStats(days int) ([]NodeStabilityStats, error)
 		}
 		results = append(results, st)
 	}
-	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("iterating health stats rows: %w", err)
-	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Server != results[j].Server {
+			return results[i].Server < results[j].Server
+		}
+		return results[i].ServerPort < results[j].ServerPort
+	})
 	return results, nil
 }
 
@@ -145,29 +190,24 @@ func (s *SQLiteStore) AddSiteMeasurements(measurements []SiteMeasurement) error
 		return nil
 	}
 
-	tx, err := s.db.Begin()
-	if err != nil {
-		return err
-	}
-	defer tx.Rollback()
-
-	stmt, err := tx.Prepare(`INSERT INTO site_measurements (server, server_port, node_tag, timestamp, site, delay_ms, error_type, mode)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`)
-	if err != nil {
-		return err
-	}
-	defer stmt.Close()
-
-	for _, m := range measurements {
-		if m.Timestamp.IsZero() {
-			m.Timestamp = time.Now()
-		}
-		if _, err := stmt.Exec(m.Server, m.ServerPort, m.NodeTag, m.Timestamp, m.Site, m.DelayMs, m.ErrorType, m.Mode); err != nil {
+	return s.insertInChunks(len(measurements), measurementChunkSize, func(tx *sql.Tx, start, end int) error {
+		stmt, err := tx.Prepare(`INSERT INTO site_measurements (server, server_port, node_tag, timestamp, site, delay_ms, error_type, mode)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?)`)
+		if err != nil {
 			return err
 		}
-	}
+		defer stmt.Close()
 
-	return tx.Commit()
+		for _, m := range measurements[start:end] {
+			if m.Timestamp.IsZero() {
+				m.Timestamp = time.Now()
+			}
+			if _, err := stmt.Exec(m.Server, m.ServerPort, m.NodeTag, m.Timestamp, m.Site, m.DelayMs, m.ErrorType, m.Mode); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
 }
 
 func (s *SQLiteStore) GetLatestHealthMeasurements() ([]HealthMeasurement, error) {
@@ -226,6 +266,56 @@ func (s *SQLiteStore) GetLatestSiteMeasurements() ([]SiteMeasurement, error) {
 	return measurements, nil
 }
 
+// GetSiteMatrix pivots the latest measurement per node and site into a node x site grid.
+func (s *SQLiteStore) GetSiteMatrix() (*SiteMatrix, error) {
+	rows, err := s.db.Query(`SELECT sm.server, sm.server_port, sm.node_tag, sm.site, sm.delay_ms
+		FROM site_measurements sm
+		INNER JOIN (
+			SELECT server, server_port, site, MAX(timestamp) as max_ts
+			FROM site_measurements
+			GROUP BY server, server_port, site
+		) latest ON sm.server = latest.server AND sm.server_port = latest.server_port
+			AND sm.site = latest.site AND sm.timestamp = latest.max_ts
+		ORDER BY sm.server, sm.server_port, sm.site`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	matrix := &SiteMatrix{Sites: []string{}, Nodes: []SiteMatrixRow{}}
+	siteSet := make(map[string]bool)
+	rowIndex := make(map[string]int)
+	for rows.Next() {
+		var server, nodeTag, site string
+		var port, delay int
+		if err := rows.Scan(&server, &port, &nodeTag, &site, &delay); err != nil {
+			return nil, fmt.Errorf("scanning site matrix row: %w", err)
+		}
+		key := fmt.Sprintf("%s:%d", server, port)
+		idx, ok := rowIndex[key]
+		if !ok {
+			idx = len(matrix.Nodes)
+			rowIndex[key] = idx
+			matrix.Nodes = append(matrix.Nodes, SiteMatrixRow{
+				Server:     server,
+				ServerPort: port,
+				NodeTag:    nodeTag,
+				Sites:      make(map[string]int),
+			})
+		}
+		matrix.Nodes[idx].Sites[site] = delay
+		if !siteSet[site] {
+			siteSet[site] = true
+			matrix.Sites = append(matrix.Sites, site)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterating site matrix rows: %w", err)
+	}
+	sort.Strings(matrix.Sites)
+	return matrix, nil
+}
+
 func (s *SQLiteStore) GetSiteMeasurements(server string, port int, limit int) ([]SiteMeasurement, error) {
 	if limit <= 0 {
 		limit = 50
@@ -0,0 +1,133 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestGetSiteMatrix_LatestDelayPerNodeAndSite(t *testing.T) {
+	store, err := NewSQLiteStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("create sqlite store: %v", err)
+	}
+	t.Cleanup(func() { _ = store.Close() })
+
+	older := time.Now().Add(-time.Hour)
+	newer := time.Now()
+	measurements := []SiteMeasurement{
+		// node A: chatgpt improved from blocked to reachable, netflix blocked
+		{Server: "1.1.1.1", ServerPort: 443, NodeTag: "node-a", Timestamp: older, Site: "chatgpt.com", DelayMs: 0},
+		{Server: "1.1.1.1", ServerPort: 443, NodeTag: "node-a", Timestamp: newer, Site: "chatgpt.com", DelayMs: 120},
+		{Server: "1.1.1.1", ServerPort: 443, NodeTag: "node-a", Timestamp: newer, Site: "netflix.com", DelayMs: 0},
+		{Server: "1.1.1.1", ServerPort: 443, NodeTag: "node-a", Timestamp: older, Site: "youtube.com", DelayMs: 80},
+		// node B: only two sites checked
+		{Server: "2.2.2.2", ServerPort: 8443, NodeTag: "node-b", Timestamp: newer, Site: "chatgpt.com", DelayMs: 0},
+		{Server: "2.2.2.2", ServerPort: 8443, NodeTag: "node-b", Timestamp: newer, Site: "netflix.com", DelayMs: 300},
+	}
+	if err := store.AddSiteMeasurements(measurements); err != nil {
+		t.Fatalf("add site measurements: %v", err)
+	}
+
+	matrix, err := store.GetSiteMatrix()
+	if err != nil {
+		t.Fatalf("get site matrix: %v", err)
+	}
+
+	wantSites := []string{"chatgpt.com", "netflix.com", "youtube.com"}
+	if !reflect.DeepEqual(matrix.Sites, wantSites) {
+		t.Fatalf("sites mismatch: got %v, want %v", matrix.Sites, wantSites)
+	}
+	if len(matrix.Nodes) != 2 {
+		t.Fatalf("nodes length mismatch: got %d, want 2", len(matrix.Nodes))
+	}
+
+	a, b := matrix.Nodes[0], matrix.Nodes[1]
+	if a.NodeTag != "node-a" || b.NodeTag != "node-b" {
+		t.Fatalf("node order mismatch: got %s, %s", a.NodeTag, b.NodeTag)
+	}
+	wantA := map[string]int{"chatgpt.com": 120, "netflix.com": 0, "youtube.com": 80}
+	if !reflect.DeepEqual(a.Sites, wantA) {
+		t.Fatalf("node-a sites mismatch: got %v, want %v", a.Sites, wantA)
+	}
+	wantB := map[string]int{"chatgpt.com": 0, "netflix.com": 300}
+	if !reflect.DeepEqual(b.Sites, wantB) {
+		t.Fatalf("node-b sites mismatch: got %v, want %v", b.Sites, wantB)
+	}
+}
+
+func TestAddHealthMeasurements_LargeBatchSpansChunks(t *testing.T) {
+	store, err := NewSQLiteStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("create sqlite store: %v", err)
+	}
+	t.Cleanup(func() { _ = store.Close() })
+
+	total := measurementChunkSize*2 + 1
+	measurements := make([]HealthMeasurement, total)
+	now := time.Now()
+	for i := range measurements {
+		measurements[i] = HealthMeasurement{Server: "1.1.1.1", ServerPort: 1000 + i, NodeTag: "node", Timestamp: now, Alive: true, LatencyMs: 50}
+	}
+	if err := store.AddHealthMeasurements(measurements); err != nil {
+		t.Fatalf("add health measurements: %v", err)
+	}
+
+	var count int
+	if err := store.db.QueryRow(`SELECT COUNT(*) FROM health_measurements`).Scan(&count); err != nil {
+		t.Fatalf("count health measurements: %v", err)
+	}
+	if count != total {
+		t.Fatalf("row count mismatch: got %d, want %d", count, total)
+	}
+}
+
+func TestInsertInChunks_BoundariesAndPartialFailure(t *testing.T) {
+	store, err := NewSQLiteStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("create sqlite store: %v", err)
+	}
+	t.Cleanup(func() { _ = store.Close() })
+
+	var ranges [][2]int
+	if err := store.insertInChunks(1201, 500, func(tx *sql.Tx, start, end int) error {
+		ranges = append(ranges, [2]int{start, end})
+		return nil
+	}); err != nil {
+		t.Fatalf("insert in chunks: %v", err)
+	}
+	wantRanges := [][2]int{{0, 500}, {500, 1000}, {1000, 1201}}
+	if !reflect.DeepEqual(ranges, wantRanges) {
+		t.Fatalf("chunk ranges mismatch: got %v, want %v", ranges, wantRanges)
+	}
+
+	// The second chunk fails: the first stays committed and the error says so
+	failure := errors.New("disk full")
+	err = store.insertInChunks(1201, 500, func(tx *sql.Tx, start, end int) error {
+		if _, err := tx.Exec(`INSERT INTO health_measurements (server, server_port, node_tag, timestamp, alive, latency_ms, mode)
+			VALUES (?, ?, 'node', ?, 1, 10, '')`, "2.2.2.2", start, time.Now()); err != nil {
+			return err
+		}
+		if start == 500 {
+			return failure
+		}
+		return nil
+	})
+	var chunkErr *ChunkedInsertError
+	if !errors.As(err, &chunkErr) {
+		t.Fatalf("error type mismatch: got %T (%v), want *ChunkedInsertError", err, err)
+	}
+	if chunkErr.Inserted != 500 || chunkErr.Total != 1201 || !errors.Is(err, failure) {
+		t.Fatalf("partial failure mismatch: got inserted=%d total=%d err=%v, want 500/1201 disk full", chunkErr.Inserted, chunkErr.Total, chunkErr.Err)
+	}
+
+	var count int
+	if err := store.db.QueryRow(`SELECT COUNT(*) FROM health_measurements WHERE server = '2.2.2.2'`).Scan(&count); err != nil {
+		t.Fatalf("count health measurements: %v", err)
+	}
+	if count != 1 {
+		t.Fatalf("committed chunk count mismatch: got %d, want 1", count)
+	}
+}
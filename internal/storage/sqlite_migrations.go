@@ -40,6 +40,44 @@ func (s *SQLiteStore) migrate() error {
 		s.migrateV13,
 		s.migrateV14,
 		s.migrateV15,
+		s.migrateV16,
+		s.migrateV17,
+		s.migrateV18,
+		s.migrateV19,
+		s.migrateV20,
+		s.migrateV21,
+		s.migrateV22,
+		s.migrateV23,
+		s.migrateV24,
+		s.migrateV25,
+		s.migrateV26,
+		s.migrateV27,
+		s.migrateV28,
+		s.migrateV29,
+		s.migrateV30,
+		s.migrateV31,
+		s.migrateV32,
+		s.migrateV33,
+		s.migrateV34,
+		s.migrateV35,
+		s.migrateV36,
+		s.migrateV37,
+		s.migrateV38,
+		s.migrateV39,
+		s.migrateV40,
+		s.migrateV41,
+		s.migrateV42,
+		s.migrateV43,
+		s.migrateV44,
+		s.migrateV45,
+		s.migrateV46,
+		s.migrateV47,
+		s.migrateV48,
+		s.migrateV49,
+		s.migrateV50,
+		s.migrateV51,
+		s.migrateV52,
+		s.migrateV53,
 	}
 
 	for i, m := range migrations {
@@ -996,6 +1034,848 @@ func (s *SQLiteStore) migrateV15() error {
 	return err
 }
 
+// migrateV16 adds configurable sniffer list and sniff timeout to settings.
+func (s *SQLiteStore) migrateV16() error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	columns := []struct {
+		name string
+		ddl  string
+	}{
+		{"sniffers_json", `ALTER TABLE settings ADD COLUMN sniffers_json TEXT NOT NULL DEFAULT '["dns","http","tls","quic"]'`},
+		{"sniff_timeout", `ALTER TABLE settings ADD COLUMN sniff_timeout TEXT NOT NULL DEFAULT '500ms'`},
+	}
+	for _, column := range columns {
+		hasColumn, err := tableHasColumn(tx, "settings", column.name)
+		if err != nil {
+			return fmt.Errorf("check settings.%s: %w", column.name, err)
+		}
+		if hasColumn {
+			continue
+		}
+		if _, err := tx.Exec(column.ddl); err != nil {
+			return fmt.Errorf("add settings.%s: %w", column.name, err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// migrateV17 adds uptime-based archiving settings and the matching
+// verification log counter.
+func (s *SQLiteStore) migrateV17() error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	columns := []struct {
+		table string
+		name  string
+		ddl   string
+	}{
+		{"settings", "min_uptime_percent", `ALTER TABLE settings ADD COLUMN min_uptime_percent INTEGER NOT NULL DEFAULT 0`},
+		{"settings", "uptime_window_hours", `ALTER TABLE settings ADD COLUMN uptime_window_hours INTEGER NOT NULL DEFAULT 24`},
+		{"verification_logs", "uptime_archived", `ALTER TABLE verification_logs ADD COLUMN uptime_archived INTEGER NOT NULL DEFAULT 0`},
+	}
+	for _, column := range columns {
+		hasColumn, err := tableHasColumn(tx, column.table, column.name)
+		if err != nil {
+			return fmt.Errorf("check %s.%s: %w", column.table, column.name, err)
+		}
+		if hasColumn {
+			continue
+		}
+		if _, err := tx.Exec(column.ddl); err != nil {
+			return fmt.Errorf("add %s.%s: %w", column.table, column.name, err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// migrateV18 creates country_overrides table for custom country group names and emoji
+func (s *SQLiteStore) migrateV18() error {
+	_, err := s.db.Exec(`
+		CREATE TABLE IF NOT EXISTS country_overrides (
+			code TEXT PRIMARY KEY,
+			emoji TEXT NOT NULL DEFAULT '',
+			name TEXT NOT NULL DEFAULT '',
+			updated_at DATETIME NOT NULL
+		)
+	`)
+	return err
+}
+
+// migrateV19 adds ipv6_enabled column to settings.
+func (s *SQLiteStore) migrateV19() error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	hasColumn, err := tableHasColumn(tx, "settings", "ipv6_enabled")
+	if err != nil {
+		return err
+	}
+	if !hasColumn {
+		if _, err := tx.Exec(`ALTER TABLE settings ADD COLUMN ipv6_enabled INTEGER NOT NULL DEFAULT 1`); err != nil {
+			return fmt.Errorf("add settings.ipv6_enabled: %w", err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// migrateV20 adds node_tag_template column to settings.
+func (s *SQLiteStore) migrateV20() error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	hasColumn, err := tableHasColumn(tx, "settings", "node_tag_template")
+	if err != nil {
+		return err
+	}
+	if !hasColumn {
+		if _, err := tx.Exec(`ALTER TABLE settings ADD COLUMN node_tag_template TEXT NOT NULL DEFAULT ''`); err != nil {
+			return fmt.Errorf("add settings.node_tag_template: %w", err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// migrateV21 adds outbound interface binding columns to settings.
+func (s *SQLiteStore) migrateV21() error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	columns := []struct {
+		name string
+		ddl  string
+	}{
+		{"auto_detect_interface", `ALTER TABLE settings ADD COLUMN auto_detect_interface INTEGER NOT NULL DEFAULT 1`},
+		{"default_interface", `ALTER TABLE settings ADD COLUMN default_interface TEXT NOT NULL DEFAULT ''`},
+	}
+	for _, column := range columns {
+		hasColumn, err := tableHasColumn(tx, "settings", column.name)
+		if err != nil {
+			return err
+		}
+		if hasColumn {
+			continue
+		}
+		if _, err := tx.Exec(column.ddl); err != nil {
+			return fmt.Errorf("add settings.%s: %w", column.name, err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// migrateV22 adds source_url column to nodes for re-parsing after parser fixes.
+func (s *SQLiteStore) migrateV22() error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	hasColumn, err := tableHasColumn(tx, "nodes", "source_url")
+	if err != nil {
+		return err
+	}
+	if !hasColumn {
+		if _, err := tx.Exec(`ALTER TABLE nodes ADD COLUMN source_url TEXT NOT NULL DEFAULT ''`); err != nil {
+			return fmt.Errorf("add nodes.source_url: %w", err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// migrateV23 adds a per-site index used by the site matrix pivot.
+func (s *SQLiteStore) migrateV23() error {
+	_, err := s.db.Exec(`CREATE INDEX IF NOT EXISTS idx_site_server_site_ts ON site_measurements(server, server_port, site, timestamp)`)
+	return err
+}
+
+// migrateV24 adds traffic_sample_interval_seconds column to settings.
+func (s *SQLiteStore) migrateV24() error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	hasColumn, err := tableHasColumn(tx, "settings", "traffic_sample_interval_seconds")
+	if err != nil {
+		return err
+	}
+	if !hasColumn {
+		if _, err := tx.Exec(`ALTER TABLE settings ADD COLUMN traffic_sample_interval_seconds INTEGER NOT NULL DEFAULT 2`); err != nil {
+			return fmt.Errorf("add settings.traffic_sample_interval_seconds: %w", err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// migrateV25 adds sort_proxy_nodes column to settings.
+func (s *SQLiteStore) migrateV25() error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	hasColumn, err := tableHasColumn(tx, "settings", "sort_proxy_nodes")
+	if err != nil {
+		return err
+	}
+	if !hasColumn {
+		if _, err := tx.Exec(`ALTER TABLE settings ADD COLUMN sort_proxy_nodes INTEGER NOT NULL DEFAULT 0`); err != nil {
+			return fmt.Errorf("add settings.sort_proxy_nodes: %w", err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// migrateV26 adds webhook_url and webhook_secret columns to settings.
+func (s *SQLiteStore) migrateV26() error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, column := range []string{"webhook_url", "webhook_secret"} {
+		hasColumn, err := tableHasColumn(tx, "settings", column)
+		if err != nil {
+			return err
+		}
+		if hasColumn {
+			continue
+		}
+		if _, err := tx.Exec(`ALTER TABLE settings ADD COLUMN ` + column + ` TEXT NOT NULL DEFAULT ''`); err != nil {
+			return fmt.Errorf("add settings.%s: %w", column, err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// migrateV27 adds urltest_url and urltest_expected_status columns to settings.
+func (s *SQLiteStore) migrateV27() error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, column := range []string{"urltest_url", "urltest_expected_status"} {
+		hasColumn, err := tableHasColumn(tx, "settings", column)
+		if err != nil {
+			return err
+		}
+		if hasColumn {
+			continue
+		}
+		if _, err := tx.Exec(`ALTER TABLE settings ADD COLUMN ` + column + ` TEXT NOT NULL DEFAULT ''`); err != nil {
+			return fmt.Errorf("add settings.%s: %w", column, err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// migrateV28 adds tun_stack and tun_mtu columns to settings.
+func (s *SQLiteStore) migrateV28() error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	columns := []struct{ name, def string }{
+		{"tun_stack", `TEXT NOT NULL DEFAULT 'mixed'`},
+		{"tun_mtu", `INTEGER NOT NULL DEFAULT 0`},
+	}
+	for _, col := range columns {
+		hasColumn, err := tableHasColumn(tx, "settings", col.name)
+		if err != nil {
+			return err
+		}
+		if hasColumn {
+			continue
+		}
+		if _, err := tx.Exec(`ALTER TABLE settings ADD COLUMN ` + col.name + ` ` + col.def); err != nil {
+			return fmt.Errorf("add settings.%s: %w", col.name, err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// migrateV29 adds pinned column to nodes.
+func (s *SQLiteStore) migrateV29() error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	hasPinned, err := tableHasColumn(tx, "nodes", "pinned")
+	if err != nil {
+		return err
+	}
+	if !hasPinned {
+		if _, err := tx.Exec(`ALTER TABLE nodes ADD COLUMN pinned INTEGER NOT NULL DEFAULT 0`); err != nil {
+			return fmt.Errorf("add nodes.pinned: %w", err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// migrateV30 adds listen_address column to settings.
+func (s *SQLiteStore) migrateV30() error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	hasColumn, err := tableHasColumn(tx, "settings", "listen_address")
+	if err != nil {
+		return err
+	}
+	if !hasColumn {
+		if _, err := tx.Exec(`ALTER TABLE settings ADD COLUMN listen_address TEXT NOT NULL DEFAULT ''`); err != nil {
+			return fmt.Errorf("add settings.listen_address: %w", err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// migrateV31 adds default_utls_fingerprint column to settings.
+func (s *SQLiteStore) migrateV31() error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	hasColumn, err := tableHasColumn(tx, "settings", "default_utls_fingerprint")
+	if err != nil {
+		return err
+	}
+	if !hasColumn {
+		if _, err := tx.Exec(`ALTER TABLE settings ADD COLUMN default_utls_fingerprint TEXT NOT NULL DEFAULT ''`); err != nil {
+			return fmt.Errorf("add settings.default_utls_fingerprint: %w", err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// migrateV32 adds daily health rollups so raw measurements can be pruned without losing stats.
+func (s *SQLiteStore) migrateV32() error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmts := []string{
+		`CREATE TABLE IF NOT EXISTS health_daily_rollups (
+			server TEXT NOT NULL,
+			server_port INTEGER NOT NULL,
+			day TEXT NOT NULL,
+			checks INTEGER NOT NULL DEFAULT 0,
+			alive INTEGER NOT NULL DEFAULT 0,
+			latency_sum INTEGER NOT NULL DEFAULT 0,
+			latency_count INTEGER NOT NULL DEFAULT 0,
+			PRIMARY KEY (server, server_port, day)
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_health_timestamp ON health_measurements(timestamp)`,
+	}
+	for _, stmt := range stmts {
+		if _, err := tx.Exec(stmt); err != nil {
+			return fmt.Errorf("exec %q: %w", stmt[:60], err)
+		}
+	}
+
+	hasColumn, err := tableHasColumn(tx, "settings", "health_retention_days")
+	if err != nil {
+		return err
+	}
+	if !hasColumn {
+		if _, err := tx.Exec(`ALTER TABLE settings ADD COLUMN health_retention_days INTEGER NOT NULL DEFAULT 30`); err != nil {
+			return fmt.Errorf("add settings.health_retention_days: %w", err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// migrateV33 indexes traffic_resources by host so the connection log can be queried over time.
+func (s *SQLiteStore) migrateV33() error {
+	if _, err := s.db.Exec(`CREATE INDEX IF NOT EXISTS idx_traffic_resources_host_ts_unix ON traffic_resources(host, timestamp_unix)`); err != nil {
+		return fmt.Errorf("create idx_traffic_resources_host_ts_unix: %w", err)
+	}
+	return nil
+}
+
+// migrateV34 adds per-inbound enable flags so an inbound can be turned off without losing its port.
+// Existing configs keep every inbound with a port set enabled.
+func (s *SQLiteStore) migrateV34() error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, inbound := range []string{"mixed", "socks", "http"} {
+		column := inbound + "_enabled"
+		hasColumn, err := tableHasColumn(tx, "settings", column)
+		if err != nil {
+			return err
+		}
+		if hasColumn {
+			continue
+		}
+		if _, err := tx.Exec(`ALTER TABLE settings ADD COLUMN ` + column + ` INTEGER NOT NULL DEFAULT 0`); err != nil {
+			return fmt.Errorf("add settings.%s: %w", column, err)
+		}
+		if _, err := tx.Exec(`UPDATE settings SET ` + column + ` = CASE WHEN ` + inbound + `_port > 0 THEN 1 ELSE 0 END`); err != nil {
+			return fmt.Errorf("backfill settings.%s: %w", column, err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// migrateV35 adds the global multiplex toggle.
+func (s *SQLiteStore) migrateV35() error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	hasColumn, err := tableHasColumn(tx, "settings", "multiplex_enabled")
+	if err != nil {
+		return err
+	}
+	if !hasColumn {
+		if _, err := tx.Exec(`ALTER TABLE settings ADD COLUMN multiplex_enabled INTEGER NOT NULL DEFAULT 0`); err != nil {
+			return fmt.Errorf("add settings.multiplex_enabled: %w", err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// migrateV36 adds TUN include/exclude route lists.
+func (s *SQLiteStore) migrateV36() error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, column := range []string{"tun_include_routes_json", "tun_exclude_routes_json"} {
+		hasColumn, err := tableHasColumn(tx, "settings", column)
+		if err != nil {
+			return err
+		}
+		if hasColumn {
+			continue
+		}
+		if _, err := tx.Exec(`ALTER TABLE settings ADD COLUMN ` + column + ` TEXT NOT NULL DEFAULT '[]'`); err != nil {
+			return fmt.Errorf("add settings.%s: %w", column, err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// migrateV37 adds nodes.missing_since so a subscription refresh can flag nodes it no longer lists.
+func (s *SQLiteStore) migrateV37() error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	hasColumn, err := tableHasColumn(tx, "nodes", "missing_since")
+	if err != nil {
+		return err
+	}
+	if !hasColumn {
+		if _, err := tx.Exec(`ALTER TABLE nodes ADD COLUMN missing_since TIMESTAMP`); err != nil {
+			return fmt.Errorf("add nodes.missing_since: %w", err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// migrateV38 adds the global TCP Fast Open toggle.
+func (s *SQLiteStore) migrateV38() error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	hasColumn, err := tableHasColumn(tx, "settings", "tcp_fast_open")
+	if err != nil {
+		return err
+	}
+	if !hasColumn {
+		if _, err := tx.Exec(`ALTER TABLE settings ADD COLUMN tcp_fast_open INTEGER NOT NULL DEFAULT 0`); err != nil {
+			return fmt.Errorf("add settings.tcp_fast_open: %w", err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// migrateV39 adds the node quality score weights.
+func (s *SQLiteStore) migrateV39() error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, col := range []struct {
+		name   string
+		weight int
+	}{
+		{"score_weight_latency", DefaultScoreWeightLatency},
+		{"score_weight_uptime", DefaultScoreWeightUptime},
+		{"score_weight_sites", DefaultScoreWeightSites},
+	} {
+		hasColumn, err := tableHasColumn(tx, "settings", col.name)
+		if err != nil {
+			return err
+		}
+		if hasColumn {
+			continue
+		}
+		if _, err := tx.Exec(fmt.Sprintf(`ALTER TABLE settings ADD COLUMN %s INTEGER NOT NULL DEFAULT %d`, col.name, col.weight)); err != nil {
+			return fmt.Errorf("add settings.%s: %w", col.name, err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// migrateV40 adds the list of processes routed to DIRECT.
+func (s *SQLiteStore) migrateV40() error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	hasColumn, err := tableHasColumn(tx, "settings", "direct_processes_json")
+	if err != nil {
+		return err
+	}
+	if !hasColumn {
+		if _, err := tx.Exec(`ALTER TABLE settings ADD COLUMN direct_processes_json TEXT NOT NULL DEFAULT '[]'`); err != nil {
+			return fmt.Errorf("add settings.direct_processes_json: %w", err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// migrateV41 adds the UDP health check target.
+func (s *SQLiteStore) migrateV41() error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	hasColumn, err := tableHasColumn(tx, "settings", "udp_check_target")
+	if err != nil {
+		return err
+	}
+	if !hasColumn {
+		if _, err := tx.Exec(`ALTER TABLE settings ADD COLUMN udp_check_target TEXT NOT NULL DEFAULT ''`); err != nil {
+			return fmt.Errorf("add settings.udp_check_target: %w", err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// migrateV42 adds the detach_singbox flag, on by default to keep the existing behaviour.
+func (s *SQLiteStore) migrateV42() error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	hasColumn, err := tableHasColumn(tx, "settings", "detach_singbox")
+	if err != nil {
+		return err
+	}
+	if !hasColumn {
+		if _, err := tx.Exec(`ALTER TABLE settings ADD COLUMN detach_singbox INTEGER NOT NULL DEFAULT 1`); err != nil {
+			return fmt.Errorf("add settings.detach_singbox: %w", err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// migrateV43 creates the profiles table holding named filter/settings snapshots.
+func (s *SQLiteStore) migrateV43() error {
+	_, err := s.db.Exec(`
+		CREATE TABLE IF NOT EXISTS profiles (
+			id TEXT PRIMARY KEY,
+			name TEXT NOT NULL UNIQUE,
+			filters_json TEXT NOT NULL DEFAULT '[]',
+			settings_json TEXT NOT NULL DEFAULT '{}',
+			created_at DATETIME NOT NULL,
+			updated_at DATETIME NOT NULL
+		)
+	`)
+	return err
+}
+
+// migrateV44 creates the client_quotas table for per-client traffic allowances.
+func (s *SQLiteStore) migrateV44() error {
+	_, err := s.db.Exec(`
+		CREATE TABLE IF NOT EXISTS client_quotas (
+			source_ip TEXT PRIMARY KEY,
+			daily_bytes INTEGER NOT NULL DEFAULT 0,
+			monthly_bytes INTEGER NOT NULL DEFAULT 0,
+			alert INTEGER NOT NULL DEFAULT 0,
+			updated_at DATETIME NOT NULL
+		)
+	`)
+	return err
+}
+
+// migrateV45 adds the auto-apply quiet hours window, blank (disabled) by default.
+func (s *SQLiteStore) migrateV45() error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, column := range []string{"quiet_hours_start", "quiet_hours_end"} {
+		hasColumn, err := tableHasColumn(tx, "settings", column)
+		if err != nil {
+			return err
+		}
+		if !hasColumn {
+			if _, err := tx.Exec(`ALTER TABLE settings ADD COLUMN ` + column + ` TEXT NOT NULL DEFAULT ''`); err != nil {
+				return fmt.Errorf("add settings.%s: %w", column, err)
+			}
+		}
+	}
+
+	return tx.Commit()
+}
+
+// migrateV46 adds the global UDP over TCP default for shadowsocks nodes, off by default.
+func (s *SQLiteStore) migrateV46() error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	hasColumn, err := tableHasColumn(tx, "settings", "udp_over_tcp")
+	if err != nil {
+		return err
+	}
+	if !hasColumn {
+		if _, err := tx.Exec(`ALTER TABLE settings ADD COLUMN udp_over_tcp INTEGER NOT NULL DEFAULT 0`); err != nil {
+			return fmt.Errorf("add settings.udp_over_tcp: %w", err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// migrateV47 adds the flag that keeps a manually set node country from being
+// overwritten by geo checks.
+func (s *SQLiteStore) migrateV47() error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	hasColumn, err := tableHasColumn(tx, "nodes", "country_locked")
+	if err != nil {
+		return err
+	}
+	if !hasColumn {
+		if _, err := tx.Exec(`ALTER TABLE nodes ADD COLUMN country_locked INTEGER NOT NULL DEFAULT 0`); err != nil {
+			return fmt.Errorf("add nodes.country_locked: %w", err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// migrateV48 adds the connection drain timeout used before restarting sing-box,
+// off by default.
+func (s *SQLiteStore) migrateV48() error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	hasColumn, err := tableHasColumn(tx, "settings", "drain_timeout_seconds")
+	if err != nil {
+		return err
+	}
+	if !hasColumn {
+		if _, err := tx.Exec(`ALTER TABLE settings ADD COLUMN drain_timeout_seconds INTEGER NOT NULL DEFAULT 0`); err != nil {
+			return fmt.Errorf("add settings.drain_timeout_seconds: %w", err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// migrateV49 adds the per-node flag that keeps a node out of the Auto and country
+// urltest groups.
+func (s *SQLiteStore) migrateV49() error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	hasColumn, err := tableHasColumn(tx, "nodes", "exclude_from_auto")
+	if err != nil {
+		return err
+	}
+	if !hasColumn {
+		if _, err := tx.Exec(`ALTER TABLE nodes ADD COLUMN exclude_from_auto INTEGER NOT NULL DEFAULT 0`); err != nil {
+			return fmt.Errorf("add nodes.exclude_from_auto: %w", err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// migrateV50 adds the QUIC block toggle, off by default.
+func (s *SQLiteStore) migrateV50() error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	hasColumn, err := tableHasColumn(tx, "settings", "block_quic")
+	if err != nil {
+		return err
+	}
+	if !hasColumn {
+		if _, err := tx.Exec(`ALTER TABLE settings ADD COLUMN block_quic INTEGER NOT NULL DEFAULT 0`); err != nil {
+			return fmt.Errorf("add settings.block_quic: %w", err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// migrateV51 adds the optional auth header sent when fetching a subscription.
+func (s *SQLiteStore) migrateV51() error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, column := range []string{"auth_header", "auth_value"} {
+		hasColumn, err := tableHasColumn(tx, "subscriptions", column)
+		if err != nil {
+			return err
+		}
+		if !hasColumn {
+			if _, err := tx.Exec(`ALTER TABLE subscriptions ADD COLUMN ` + column + ` TEXT NOT NULL DEFAULT ''`); err != nil {
+				return fmt.Errorf("add subscriptions.%s: %w", column, err)
+			}
+		}
+	}
+
+	return tx.Commit()
+}
+
+// migrateV52 adds the no-nodes mode, defaulting to the DIRECT fallback.
+func (s *SQLiteStore) migrateV52() error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	hasColumn, err := tableHasColumn(tx, "settings", "no_nodes_mode")
+	if err != nil {
+		return err
+	}
+	if !hasColumn {
+		if _, err := tx.Exec(`ALTER TABLE settings ADD COLUMN no_nodes_mode TEXT NOT NULL DEFAULT 'direct'`); err != nil {
+			return fmt.Errorf("add settings.no_nodes_mode: %w", err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// migrateV53 adds the global UDP fragmentation toggle.
+func (s *SQLiteStore) migrateV53() error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	hasColumn, err := tableHasColumn(tx, "settings", "udp_fragment")
+	if err != nil {
+		return err
+	}
+	if !hasColumn {
+		if _, err := tx.Exec(`ALTER TABLE settings ADD COLUMN udp_fragment INTEGER NOT NULL DEFAULT 0`); err != nil {
+			return fmt.Errorf("add settings.udp_fragment: %w", err)
+		}
+	}
+
+	return tx.Commit()
+}
+
 func tableHasColumn(tx *sql.Tx, tableName, columnName string) (bool, error) {
 	rows, err := tx.Query("PRAGMA table_info(" + tableName + ")")
 	if err != nil {
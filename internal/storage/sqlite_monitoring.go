@@ -274,6 +274,47 @@ func (s *SQLiteStore) GetTrafficSamplesByTimeRange(since time.Time, maxPoints in
 	return samples, nil
 }
 
+// EachTrafficSampleSince calls fn for every raw traffic sample at or after since,
+// oldest first, without loading the range into memory. A non-nil error from fn
+// stops the iteration and is returned.
+func (s *SQLiteStore) EachTrafficSampleSince(since time.Time, fn func(TrafficSample) error) error {
+	rows, err := s.db.Query(`SELECT
+		id, timestamp, up_bps, down_bps, upload_total, download_total,
+		active_connections, client_count, memory_inuse, memory_oslimit
+		FROM traffic_samples
+		WHERE timestamp_unix >= ?
+		ORDER BY timestamp_unix ASC, id ASC`, monitoringTimestampUnix(since))
+	if err != nil {
+		return fmt.Errorf("query traffic samples since: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var sample TrafficSample
+		if err := rows.Scan(
+			&sample.ID,
+			&sample.Timestamp,
+			&sample.UpBps,
+			&sample.DownBps,
+			&sample.UploadTotal,
+			&sample.DownloadTotal,
+			&sample.ActiveConnections,
+			&sample.ClientCount,
+			&sample.MemoryInuse,
+			&sample.MemoryOSLimit,
+		); err != nil {
+			return fmt.Errorf("scan traffic sample row: %w", err)
+		}
+		if err := fn(sample); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("iterate traffic sample rows: %w", err)
+	}
+	return nil
+}
+
 func (s *SQLiteStore) GetLatestTrafficSample() (*TrafficSample, error) {
 	var sample TrafficSample
 	err := s.db.QueryRow(`SELECT
@@ -1036,6 +1077,107 @@ func (s *SQLiteStore) GetTrafficChainStats(limit int, lookback time.Duration) ([
 	return stats, nil
 }
 
+// GetConnectionLog returns sampled per-host traffic observations, newest first.
+func (s *SQLiteStore) GetConnectionLog(query ConnectionLogQuery) ([]ConnectionLogEntry, error) {
+	limit := query.Limit
+	if limit <= 0 {
+		limit = 500
+	}
+	if limit > 5000 {
+		limit = 5000
+	}
+
+	var conds []string
+	var args []interface{}
+	if host := strings.TrimSpace(query.Host); host != "" {
+		conds = append(conds, `(host = ? OR host LIKE ? ESCAPE '\')`)
+		args = append(args, host, "%."+escapeLike(host))
+	}
+	if sourceIP := strings.TrimSpace(query.SourceIP); sourceIP != "" {
+		conds = append(conds, "source_ip = ?")
+		args = append(args, sourceIP)
+	}
+	if !query.Since.IsZero() {
+		conds = append(conds, "timestamp_unix >= ?")
+		args = append(args, monitoringTimestampUnix(query.Since))
+	}
+	if !query.Until.IsZero() {
+		conds = append(conds, "timestamp_unix < ?")
+		args = append(args, monitoringTimestampUnix(query.Until))
+	}
+
+	sqlQuery := `SELECT timestamp_unix, source_ip, host, proxy_chain, active_connections, upload_bytes, download_bytes
+		FROM traffic_resources`
+	if len(conds) > 0 {
+		sqlQuery += " WHERE " + strings.Join(conds, " AND ")
+	}
+	sqlQuery += " ORDER BY timestamp_unix DESC, id DESC LIMIT ?"
+	args = append(args, limit)
+
+	rows, err := s.db.Query(sqlQuery, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query connection log: %w", err)
+	}
+	defer rows.Close()
+
+	entries := make([]ConnectionLogEntry, 0)
+	for rows.Next() {
+		var (
+			entry  ConnectionLogEntry
+			tsUnix int64
+		)
+		if err := rows.Scan(
+			&tsUnix,
+			&entry.SourceIP,
+			&entry.Host,
+			&entry.ProxyChain,
+			&entry.ActiveConnections,
+			&entry.UploadBytes,
+			&entry.DownloadBytes,
+		); err != nil {
+			return nil, fmt.Errorf("scan connection log row: %w", err)
+		}
+		entry.Timestamp = time.Unix(0, tsUnix).UTC()
+		entries = append(entries, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate connection log rows: %w", err)
+	}
+
+	return entries, nil
+}
+
+// GetHostObservations counts, per host, the traffic samples and distinct clients that
+// saw it since the given time, most observed first.
+func (s *SQLiteStore) GetHostObservations(since time.Time) ([]HostObservation, error) {
+	rows, err := s.db.Query(`SELECT host, COUNT(*), COUNT(DISTINCT source_ip), MAX(timestamp_unix)
+		FROM traffic_resources
+		WHERE timestamp_unix >= ? AND host != ''
+		GROUP BY host
+		ORDER BY COUNT(*) DESC, host ASC`, monitoringTimestampUnix(since))
+	if err != nil {
+		return nil, fmt.Errorf("query host observations: %w", err)
+	}
+	defer rows.Close()
+
+	observations := make([]HostObservation, 0)
+	for rows.Next() {
+		var (
+			item         HostObservation
+			lastSeenUnix int64
+		)
+		if err := rows.Scan(&item.Host, &item.Samples, &item.Clients, &lastSeenUnix); err != nil {
+			return nil, fmt.Errorf("scan host observation row: %w", err)
+		}
+		item.LastSeen = time.Unix(0, lastSeenUnix).UTC()
+		observations = append(observations, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate host observation rows: %w", err)
+	}
+	return observations, nil
+}
+
 func (s *SQLiteStore) latestTrafficSampleID() (int64, error) {
 	var sampleID int64
 	err := s.db.QueryRow(`SELECT id FROM traffic_samples ORDER BY timestamp_unix DESC, id DESC LIMIT 1`).Scan(&sampleID)
@@ -1062,3 +1204,64 @@ func (s *SQLiteStore) latestTrafficSampleIDForSource(sourceIP string) (int64, er
 	}
 	return sampleID, nil
 }
+
+// GetClientUsageSince returns the traffic each client moved since the given time.
+// Client byte counters are cumulative, so usage is the sum of sample-to-sample deltas;
+// each client's last sample before the window anchors its first delta, and counter
+// resets (manager restarts) count the new value as the delta.
+func (s *SQLiteStore) GetClientUsageSince(since time.Time) ([]ClientUsage, error) {
+	sinceUnix := monitoringTimestampUnix(since)
+	rows, err := s.db.Query(`
+		WITH windowed AS (
+			SELECT source_ip, timestamp_unix, id, upload_bytes, download_bytes, 0 AS anchor
+			FROM traffic_clients
+			WHERE timestamp_unix >= ?
+			UNION ALL
+			SELECT source_ip, MAX(timestamp_unix), id, upload_bytes, download_bytes, 1 AS anchor
+			FROM traffic_clients
+			WHERE timestamp_unix < ?
+			GROUP BY source_ip
+		),
+		client_deltas AS (
+			SELECT
+				source_ip,
+				anchor,
+				upload_bytes,
+				download_bytes,
+				LAG(upload_bytes) OVER (PARTITION BY source_ip ORDER BY timestamp_unix, id) AS prev_upload,
+				LAG(download_bytes) OVER (PARTITION BY source_ip ORDER BY timestamp_unix, id) AS prev_download
+			FROM windowed
+		)
+		SELECT
+			source_ip,
+			COALESCE(SUM(CASE
+				WHEN prev_upload IS NULL THEN MAX(upload_bytes, 0)
+				WHEN upload_bytes >= prev_upload THEN upload_bytes - prev_upload
+				ELSE upload_bytes
+			END), 0),
+			COALESCE(SUM(CASE
+				WHEN prev_download IS NULL THEN MAX(download_bytes, 0)
+				WHEN download_bytes >= prev_download THEN download_bytes - prev_download
+				ELSE download_bytes
+			END), 0)
+		FROM client_deltas
+		WHERE anchor = 0
+		GROUP BY source_ip`, sinceUnix, sinceUnix)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	usage := []ClientUsage{}
+	for rows.Next() {
+		var u ClientUsage
+		if err := rows.Scan(&u.SourceIP, &u.UploadBytes, &u.DownloadBytes); err != nil {
+			return nil, fmt.Errorf("scan client usage row: %w", err)
+		}
+		usage = append(usage, u)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate client usage rows: %w", err)
+	}
+	return usage, nil
+}
@@ -662,3 +662,106 @@ func TestGetRecentTrafficClients_OfflineConnectionsAreZero(t *testing.T) {
 		t.Fatalf("expected offline client to be present")
 	}
 }
+
+func TestGetConnectionLog_FiltersByHostAndWindow(t *testing.T) {
+	store, err := NewSQLiteStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("create sqlite store: %v", err)
+	}
+	t.Cleanup(func() { _ = store.Close() })
+
+	base := time.Date(2026, 3, 3, 12, 0, 0, 0, time.UTC)
+	for i, resources := range [][]ClientResourceSnapshot{
+		{
+			{SourceIP: "10.0.0.1", Host: "example.com", UploadBytes: 10, DownloadBytes: 20, ProxyChain: "node_1"},
+			{SourceIP: "10.0.0.2", Host: "other.org", UploadBytes: 1, DownloadBytes: 1, ProxyChain: "DIRECT"},
+		},
+		{
+			{SourceIP: "10.0.0.1", Host: "cdn.example.com", UploadBytes: 30, DownloadBytes: 40, ProxyChain: "node_1"},
+			{SourceIP: "10.0.0.2", Host: "notexample.com", UploadBytes: 5, DownloadBytes: 5, ProxyChain: "node_2"},
+		},
+		{
+			{SourceIP: "10.0.0.2", Host: "example.com", UploadBytes: 50, DownloadBytes: 60, ProxyChain: "node_2"},
+		},
+	} {
+		sample := TrafficSample{Timestamp: base.Add(time.Duration(i) * time.Hour)}
+		if _, err := store.AddTrafficSample(sample, nil, resources); err != nil {
+			t.Fatalf("add traffic sample %d: %v", i, err)
+		}
+	}
+
+	entries, err := store.GetConnectionLog(ConnectionLogQuery{
+		Host:  "example.com",
+		Since: base,
+		Until: base.Add(2 * time.Hour),
+	})
+	if err != nil {
+		t.Fatalf("get connection log: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("entries length mismatch: got %d, want 2 (%+v)", len(entries), entries)
+	}
+	// newest first; the third sample is outside the window and notexample.com is not a subdomain
+	if entries[0].Host != "cdn.example.com" || !entries[0].Timestamp.Equal(base.Add(time.Hour)) {
+		t.Fatalf("first entry mismatch: got %+v", entries[0])
+	}
+	if entries[1].Host != "example.com" || entries[1].SourceIP != "10.0.0.1" || entries[1].UploadBytes != 10 || entries[1].ProxyChain != "node_1" {
+		t.Fatalf("second entry mismatch: got %+v", entries[1])
+	}
+
+	bySource, err := store.GetConnectionLog(ConnectionLogQuery{Host: "example.com", SourceIP: "10.0.0.2", Since: base})
+	if err != nil {
+		t.Fatalf("get connection log by source: %v", err)
+	}
+	if len(bySource) != 1 || !bySource[0].Timestamp.Equal(base.Add(2*time.Hour)) {
+		t.Fatalf("source-filtered entries mismatch: got %+v", bySource)
+	}
+}
+
+func TestGetClientUsageSince_AnchorsOnLastSampleBeforeWindow(t *testing.T) {
+	store, err := NewSQLiteStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("create sqlite store: %v", err)
+	}
+	t.Cleanup(func() { _ = store.Close() })
+
+	base := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	// 10.0.0.1 counts up across the window start, then the counter resets (manager restart)
+	points := []struct {
+		offset   time.Duration
+		ip       string
+		up, down int64
+	}{
+		{-2 * time.Hour, "10.0.0.1", 1000, 5000},
+		{-time.Hour, "10.0.0.1", 1500, 6000},
+		{time.Minute, "10.0.0.1", 1600, 6500},
+		{2 * time.Minute, "10.0.0.1", 100, 200},
+		{3 * time.Minute, "10.0.0.2", 50, 70},
+		{-time.Hour, "10.0.0.3", 900, 900},
+	}
+	for _, p := range points {
+		ts := base.Add(p.offset)
+		clients := []ClientTrafficSnapshot{{Timestamp: ts, SourceIP: p.ip, UploadBytes: p.up, DownloadBytes: p.down}}
+		if _, err := store.AddTrafficSample(TrafficSample{Timestamp: ts}, clients, nil); err != nil {
+			t.Fatalf("add sample: %v", err)
+		}
+	}
+
+	usage, err := store.GetClientUsageSince(base)
+	if err != nil {
+		t.Fatalf("get client usage: %v", err)
+	}
+	got := make(map[string]ClientUsage, len(usage))
+	for _, u := range usage {
+		got[u.SourceIP] = u
+	}
+	if len(got) != 2 {
+		t.Fatalf("expected usage for the two clients active in the window, got %+v", usage)
+	}
+	if u := got["10.0.0.1"]; u.UploadBytes != 200 || u.DownloadBytes != 700 {
+		t.Fatalf("10.0.0.1 usage mismatch: got %d/%d, want 200/700", u.UploadBytes, u.DownloadBytes)
+	}
+	if u := got["10.0.0.2"]; u.UploadBytes != 50 || u.DownloadBytes != 70 {
+		t.Fatalf("10.0.0.2 usage mismatch: got %d/%d, want 50/70", u.UploadBytes, u.DownloadBytes)
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"database/sql"
 	"encoding/json"
 	"fmt"
+	"net"
+	"strconv"
 	"strings"
 	"time"
 
@@ -11,13 +13,14 @@ import (
 )
 
 const nodeColumns = `id, tag, internal_tag, display_name, source_tag, type, server, server_port, country, country_emoji, extra_json,
-	status, source, group_tag, consecutive_failures, last_checked_at, created_at, promoted_at, archived_at, is_favorite`
+	status, source, group_tag, consecutive_failures, last_checked_at, created_at, promoted_at, archived_at, is_favorite, pinned, source_url, missing_since, country_locked, exclude_from_auto`
 
 func normalizeUnifiedNodeForPersistence(node *UnifiedNode) {
 	node.Tag = strings.TrimSpace(node.Tag)
 	node.InternalTag = strings.TrimSpace(node.InternalTag)
 	node.DisplayName = strings.TrimSpace(node.DisplayName)
 	node.SourceTag = strings.TrimSpace(node.SourceTag)
+	node.Server = NormalizeServer(node.Server)
 
 	if node.SourceTag == "" {
 		if node.Tag != "" {
@@ -33,7 +36,7 @@ func normalizeUnifiedNodeForPersistence(node *UnifiedNode) {
 		} else if node.SourceTag != "" {
 			node.DisplayName = node.SourceTag
 		} else if node.Server != "" && node.ServerPort > 0 {
-			node.DisplayName = fmt.Sprintf("%s:%d", node.Server, node.ServerPort)
+			node.DisplayName = net.JoinHostPort(node.Server, strconv.Itoa(node.ServerPort))
 		} else {
 			node.DisplayName = "Node"
 		}
@@ -62,7 +65,7 @@ func (s *SQLiteStore) GetNodeByID(id int64) *UnifiedNode {
 }
 
 func (s *SQLiteStore) GetNodeByServerPort(server string, port int) *UnifiedNode {
-	row := s.db.QueryRow("SELECT "+nodeColumns+" FROM nodes WHERE server = ? AND server_port = ? LIMIT 1", server, port)
+	row := s.db.QueryRow("SELECT "+nodeColumns+" FROM nodes WHERE server = ? AND server_port = ? LIMIT 1", NormalizeServer(server), port)
 	return scanUnifiedNodeRow(row)
 }
 
@@ -90,11 +93,11 @@ func (s *SQLiteStore) AddNode(node UnifiedNode) (int64, error) {
 	}
 
 	res, err := s.db.Exec(`INSERT INTO nodes (tag, internal_tag, display_name, source_tag, type, server, server_port, country, country_emoji, extra_json,
-		status, source, group_tag, consecutive_failures, last_checked_at, created_at, promoted_at, archived_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		status, source, group_tag, consecutive_failures, last_checked_at, created_at, promoted_at, archived_at, source_url)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
 		node.Tag, node.InternalTag, node.DisplayName, node.SourceTag, node.Type, node.Server, node.ServerPort, node.Country, node.CountryEmoji, extraJSON,
 		string(node.Status), node.Source, node.GroupTag, node.ConsecutiveFailures,
-		node.LastCheckedAt, node.CreatedAt, node.PromotedAt, node.ArchivedAt)
+		node.LastCheckedAt, node.CreatedAt, node.PromotedAt, node.ArchivedAt, node.SourceURL)
 	if err != nil {
 		return 0, err
 	}
@@ -109,7 +112,7 @@ func (s *SQLiteStore) AddNodesBulk(nodes []UnifiedNode) (added int, err error) {
 	defer tx.Rollback()
 
 	stmt, err := tx.Prepare(`INSERT OR IGNORE INTO nodes (tag, internal_tag, display_name, source_tag, type, server, server_port, country, country_emoji, extra_json,
-		status, source, group_tag, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`)
+		status, source, group_tag, created_at, source_url) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`)
 	if err != nil {
 		return 0, err
 	}
@@ -130,7 +133,7 @@ func (s *SQLiteStore) AddNodesBulk(nodes []UnifiedNode) (added int, err error) {
 			source = "manual"
 		}
 		res, err := stmt.Exec(n.Tag, n.InternalTag, n.DisplayName, n.SourceTag, n.Type, n.Server, n.ServerPort, n.Country, n.CountryEmoji, extraJSON,
-			status, source, n.GroupTag, now)
+			status, source, n.GroupTag, now, n.SourceURL)
 		if err != nil {
 			continue
 		}
@@ -140,6 +143,109 @@ func (s *SQLiteStore) AddNodesBulk(nodes []UnifiedNode) (added int, err error) {
 	return added, tx.Commit()
 }
 
+// SyncSubscriptionNodes applies a refreshed subscription to the nodes it owns without
+// wiping them. Nodes are matched by server:port; a matched node keeps its ID, status,
+// name, pin and history and only has its protocol settings updated when its
+// fingerprint changed. Unmatched incoming nodes are added, and owned nodes the
+// subscription no longer lists are marked missing instead of deleted.
+func (s *SQLiteStore) SyncSubscriptionNodes(source string, nodes []UnifiedNode) (result NodeSyncResult, err error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return result, err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query("SELECT "+nodeColumns+" FROM nodes WHERE source = ? ORDER BY id", source)
+	if err != nil {
+		return result, err
+	}
+	existing := scanUnifiedNodes(rows)
+	rows.Close()
+
+	owned := make(map[ServerPortKey]UnifiedNode, len(existing))
+	for _, n := range existing {
+		owned[ServerPortKey{Server: n.Server, ServerPort: n.ServerPort}] = n
+	}
+
+	insert, err := tx.Prepare(`INSERT OR IGNORE INTO nodes (tag, internal_tag, display_name, source_tag, type, server, server_port, country, country_emoji, extra_json,
+		status, source, group_tag, created_at, source_url) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return result, err
+	}
+	defer insert.Close()
+
+	now := time.Now()
+	seen := make(map[ServerPortKey]bool, len(nodes))
+	for i := range nodes {
+		n := nodes[i]
+		n.Source = source
+		normalizeUnifiedNodeForPersistence(&n)
+		key := ServerPortKey{Server: n.Server, ServerPort: n.ServerPort}
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		current, ok := owned[key]
+		if !ok {
+			status := string(n.Status)
+			if status == "" {
+				status = string(NodeStatusPending)
+			}
+			res, err := insert.Exec(n.Tag, n.InternalTag, n.DisplayName, n.SourceTag, n.Type, n.Server, n.ServerPort, n.Country, n.CountryEmoji, marshalJSON(n.Extra),
+				status, source, n.GroupTag, now, n.SourceURL)
+			if err != nil {
+				return result, err
+			}
+			if ra, _ := res.RowsAffected(); ra > 0 {
+				result.Added++
+			} else {
+				result.Skipped++
+			}
+			continue
+		}
+
+		if current.MissingSince != nil {
+			result.Restored++
+		}
+		if nodeFingerprint(current) == nodeFingerprint(n) {
+			if current.MissingSince != nil {
+				if _, err := tx.Exec(`UPDATE nodes SET missing_since = NULL WHERE id = ?`, current.ID); err != nil {
+					return result, err
+				}
+			}
+			result.Unchanged++
+			continue
+		}
+		if _, err := tx.Exec(`UPDATE nodes SET type = ?, extra_json = ?, source_tag = ?, source_url = ?, missing_since = NULL WHERE id = ?`,
+			n.Type, marshalJSON(n.Extra), n.SourceTag, n.SourceURL, current.ID); err != nil {
+			return result, err
+		}
+		result.Updated++
+	}
+
+	for key, n := range owned {
+		if seen[key] {
+			continue
+		}
+		result.Missing++
+		if n.MissingSince != nil {
+			continue
+		}
+		if _, err := tx.Exec(`UPDATE nodes SET missing_since = ? WHERE id = ?`, now, n.ID); err != nil {
+			return result, err
+		}
+	}
+
+	return result, tx.Commit()
+}
+
+// nodeFingerprint identifies the protocol settings of a node, so a refresh can tell a
+// changed node from an unchanged one at the same server:port.
+func nodeFingerprint(n UnifiedNode) string {
+	return strings.Join([]string{n.Type, marshalJSON(n.Extra), n.SourceTag, n.SourceURL}, "\x00")
+}
+
 func (s *SQLiteStore) UpdateNode(node UnifiedNode) error {
 	current := s.GetNodeByID(node.ID)
 	if current == nil {
@@ -198,6 +304,19 @@ func (s *SQLiteStore) UpdateNode(node UnifiedNode) error {
 	return nil
 }
 
+// UpdateNodeExtra replaces the protocol-specific fields of a node.
+func (s *SQLiteStore) UpdateNodeExtra(id int64, extra map[string]interface{}) error {
+	res, err := s.db.Exec(`UPDATE nodes SET extra_json = ? WHERE id = ?`, marshalJSON(extra), id)
+	if err != nil {
+		return err
+	}
+	n, _ := res.RowsAffected()
+	if n == 0 {
+		return fmt.Errorf("node not found: %d", id)
+	}
+	return nil
+}
+
 func (s *SQLiteStore) DeleteNode(id int64) error {
 	res, err := s.db.Exec("DELETE FROM nodes WHERE id = ?", id)
 	if err != nil {
@@ -271,6 +390,15 @@ func (s *SQLiteStore) UnarchiveAllNodes() (int64, error) {
 	return res.RowsAffected()
 }
 
+// DeleteArchivedNodesOlderThan permanently removes archived nodes archived before cutoff.
+func (s *SQLiteStore) DeleteArchivedNodesOlderThan(cutoff time.Time) (int64, error) {
+	res, err := s.db.Exec(`DELETE FROM nodes WHERE status = 'archived' AND archived_at IS NOT NULL AND archived_at < ?`, cutoff)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
 func (s *SQLiteStore) IncrementConsecutiveFailures(id int64) (int, error) {
 	now := time.Now()
 	_, err := s.db.Exec(`UPDATE nodes SET consecutive_failures = consecutive_failures + 1, last_checked_at = ? WHERE id = ?`, now, id)
@@ -298,10 +426,10 @@ func (s *SQLiteStore) GetNodeCounts() NodeCounts {
 
 func (s *SQLiteStore) AddVerificationLog(log VerificationLog) error {
 	_, err := s.db.Exec(`INSERT INTO verification_logs (timestamp, pending_checked, pending_promoted, pending_archived,
-		verified_checked, verified_demoted, duration_ms, error)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		verified_checked, verified_demoted, uptime_archived, duration_ms, error)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
 		log.Timestamp, log.PendingChecked, log.PendingPromoted, log.PendingArchived,
-		log.VerifiedChecked, log.VerifiedDemoted, log.DurationMs, log.Error)
+		log.VerifiedChecked, log.VerifiedDemoted, log.UptimeArchived, log.DurationMs, log.Error)
 	return err
 }
 
@@ -310,7 +438,7 @@ func (s *SQLiteStore) GetVerificationLogs(limit int) []VerificationLog {
 		limit = 20
 	}
 	rows, err := s.db.Query(`SELECT id, timestamp, pending_checked, pending_promoted, pending_archived,
-		verified_checked, verified_demoted, duration_ms, error
+		verified_checked, verified_demoted, uptime_archived, duration_ms, error
 		FROM verification_logs ORDER BY timestamp DESC LIMIT ?`, limit)
 	if err != nil {
 		return []VerificationLog{}
@@ -321,7 +449,7 @@ func (s *SQLiteStore) GetVerificationLogs(limit int) []VerificationLog {
 	for rows.Next() {
 		var l VerificationLog
 		if err := rows.Scan(&l.ID, &l.Timestamp, &l.PendingChecked, &l.PendingPromoted, &l.PendingArchived,
-			&l.VerifiedChecked, &l.VerifiedDemoted, &l.DurationMs, &l.Error); err != nil {
+			&l.VerifiedChecked, &l.VerifiedDemoted, &l.UptimeArchived, &l.DurationMs, &l.Error); err != nil {
 			continue
 		}
 		logs = append(logs, l)
@@ -352,12 +480,12 @@ func scanUnifiedNodeFromRows(rows *sql.Rows) (UnifiedNode, error) {
 	var n UnifiedNode
 	var extraJSON sql.NullString
 	var status string
-	var lastCheckedAt, promotedAt, archivedAt sql.NullTime
+	var lastCheckedAt, promotedAt, archivedAt, missingSince sql.NullTime
 	var createdAt time.Time
 
 	err := rows.Scan(&n.ID, &n.Tag, &n.InternalTag, &n.DisplayName, &n.SourceTag, &n.Type, &n.Server, &n.ServerPort, &n.Country, &n.CountryEmoji,
 		&extraJSON, &status, &n.Source, &n.GroupTag, &n.ConsecutiveFailures,
-		&lastCheckedAt, &createdAt, &promotedAt, &archivedAt, &n.IsFavorite)
+		&lastCheckedAt, &createdAt, &promotedAt, &archivedAt, &n.IsFavorite, &n.Pinned, &n.SourceURL, &missingSince, &n.CountryLocked, &n.ExcludeFromAuto)
 	if err != nil {
 		return n, err
 	}
@@ -373,6 +501,9 @@ func scanUnifiedNodeFromRows(rows *sql.Rows) (UnifiedNode, error) {
 	if archivedAt.Valid {
 		n.ArchivedAt = &archivedAt.Time
 	}
+	if missingSince.Valid {
+		n.MissingSince = &missingSince.Time
+	}
 	if extraJSON.Valid && extraJSON.String != "" {
 		json.Unmarshal([]byte(extraJSON.String), &n.Extra)
 	}
@@ -383,12 +514,12 @@ func scanUnifiedNodeRow(row *sql.Row) *UnifiedNode {
 	var n UnifiedNode
 	var extraJSON sql.NullString
 	var status string
-	var lastCheckedAt, promotedAt, archivedAt sql.NullTime
+	var lastCheckedAt, promotedAt, archivedAt, missingSince sql.NullTime
 	var createdAt time.Time
 
 	err := row.Scan(&n.ID, &n.Tag, &n.InternalTag, &n.DisplayName, &n.SourceTag, &n.Type, &n.Server, &n.ServerPort, &n.Country, &n.CountryEmoji,
 		&extraJSON, &status, &n.Source, &n.GroupTag, &n.ConsecutiveFailures,
-		&lastCheckedAt, &createdAt, &promotedAt, &archivedAt, &n.IsFavorite)
+		&lastCheckedAt, &createdAt, &promotedAt, &archivedAt, &n.IsFavorite, &n.Pinned, &n.SourceURL, &missingSince, &n.CountryLocked, &n.ExcludeFromAuto)
 	if err != nil {
 		return nil
 	}
@@ -404,6 +535,9 @@ func scanUnifiedNodeRow(row *sql.Row) *UnifiedNode {
 	if archivedAt.Valid {
 		n.ArchivedAt = &archivedAt.Time
 	}
+	if missingSince.Valid {
+		n.MissingSince = &missingSince.Time
+	}
 	if extraJSON.Valid && extraJSON.String != "" {
 		json.Unmarshal([]byte(extraJSON.String), &n.Extra)
 	}
@@ -425,3 +559,122 @@ func (s *SQLiteStore) SetNodeFavorite(id int64, favorite bool) error {
 	}
 	return nil
 }
+
+// SetNodePinned marks a node as pinned. Pinned nodes are never archived or
+// demoted by automatic checks and stay in the generated config while pending.
+func (s *SQLiteStore) SetNodePinned(id int64, pinned bool) error {
+	val := 0
+	if pinned {
+		val = 1
+	}
+	res, err := s.db.Exec(`UPDATE nodes SET pinned = ? WHERE id = ?`, val, id)
+	if err != nil {
+		return err
+	}
+	n, _ := res.RowsAffected()
+	if n == 0 {
+		return fmt.Errorf("node not found: %d", id)
+	}
+	return nil
+}
+
+// SetNodeCountry sets a node's country by hand. A locked country is kept as is
+// by geo checks until the lock is cleared.
+func (s *SQLiteStore) SetNodeCountry(id int64, countryCode, countryEmoji string, locked bool) error {
+	val := 0
+	if locked {
+		val = 1
+	}
+	res, err := s.db.Exec(`UPDATE nodes SET country = ?, country_emoji = ?, country_locked = ? WHERE id = ?`,
+		countryCode, countryEmoji, val, id)
+	if err != nil {
+		return err
+	}
+	n, _ := res.RowsAffected()
+	if n == 0 {
+		return fmt.Errorf("node not found: %d", id)
+	}
+	return nil
+}
+
+// SetNodeExcludeFromAuto keeps a node out of the Auto and country urltest groups.
+// The node stays in the config and can still be picked by hand.
+func (s *SQLiteStore) SetNodeExcludeFromAuto(id int64, exclude bool) error {
+	val := 0
+	if exclude {
+		val = 1
+	}
+	res, err := s.db.Exec(`UPDATE nodes SET exclude_from_auto = ? WHERE id = ?`, val, id)
+	if err != nil {
+		return err
+	}
+	n, _ := res.RowsAffected()
+	if n == 0 {
+		return fmt.Errorf("node not found: %d", id)
+	}
+	return nil
+}
+
+// GetServerClusters groups non-archived nodes by server address and returns the
+// servers carrying at least minSize nodes, largest first, with their ports and nodes.
+func (s *SQLiteStore) GetServerClusters(minSize int) ([]ServerCluster, error) {
+	if minSize < 1 {
+		minSize = 1
+	}
+	const shared = `SELECT server FROM nodes WHERE status != 'archived' GROUP BY server HAVING COUNT(*) >= ?`
+
+	rows, err := s.db.Query(`SELECT server, COUNT(*) FROM nodes WHERE status != 'archived'
+		GROUP BY server HAVING COUNT(*) >= ? ORDER BY COUNT(*) DESC, server`, minSize)
+	if err != nil {
+		return nil, fmt.Errorf("query server clusters: %w", err)
+	}
+	clusters := []ServerCluster{}
+	index := make(map[string]int)
+	for rows.Next() {
+		var cluster ServerCluster
+		if err := rows.Scan(&cluster.Server, &cluster.NodeCount); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("scan server cluster row: %w", err)
+		}
+		cluster.Ports = []int{}
+		cluster.Nodes = []ServerClusterNode{}
+		index[cluster.Server] = len(clusters)
+		clusters = append(clusters, cluster)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate server cluster rows: %w", err)
+	}
+	if len(clusters) == 0 {
+		return clusters, nil
+	}
+
+	rows, err = s.db.Query(`SELECT id, COALESCE(NULLIF(display_name, ''), tag), server, server_port, status FROM nodes
+		WHERE status != 'archived' AND server IN (`+shared+`)
+		ORDER BY server, server_port, id`, minSize)
+	if err != nil {
+		return nil, fmt.Errorf("query server cluster nodes: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var node ServerClusterNode
+		var server, status string
+		if err := rows.Scan(&node.ID, &node.Tag, &server, &node.ServerPort, &status); err != nil {
+			return nil, fmt.Errorf("scan server cluster node row: %w", err)
+		}
+		node.Status = NodeStatus(status)
+		i, ok := index[server]
+		if !ok {
+			continue
+		}
+		cluster := &clusters[i]
+		if n := len(cluster.Ports); n == 0 || cluster.Ports[n-1] != node.ServerPort {
+			cluster.Ports = append(cluster.Ports, node.ServerPort)
+		}
+		cluster.Nodes = append(cluster.Nodes, node)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate server cluster node rows: %w", err)
+	}
+	return clusters, nil
+}
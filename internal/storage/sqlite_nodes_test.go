@@ -0,0 +1,122 @@
+package storage
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestDeleteArchivedNodesOlderThan_RemovesOnlyOldArchived(t *testing.T) {
+	store, err := NewSQLiteStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("create sqlite store: %v", err)
+	}
+	t.Cleanup(func() { _ = store.Close() })
+
+	now := time.Now()
+	old := now.Add(-45 * 24 * time.Hour)
+	recent := now.Add(-5 * 24 * time.Hour)
+
+	seeds := []struct {
+		tag        string
+		status     NodeStatus
+		archivedAt *time.Time
+	}{
+		{tag: "archived-old", status: NodeStatusArchived, archivedAt: &old},
+		{tag: "archived-recent", status: NodeStatusArchived, archivedAt: &recent},
+		{tag: "archived-unknown", status: NodeStatusArchived},
+		{tag: "verified", status: NodeStatusVerified, archivedAt: &old},
+	}
+	for i, seed := range seeds {
+		if _, err := store.AddNode(UnifiedNode{
+			Tag:        seed.tag,
+			Type:       "vmess",
+			Server:     fmt.Sprintf("10.0.0.%d", i+1),
+			ServerPort: 443,
+			Status:     seed.status,
+			ArchivedAt: seed.archivedAt,
+		}); err != nil {
+			t.Fatalf("insert node %s: %v", seed.tag, err)
+		}
+	}
+
+	deleted, err := store.DeleteArchivedNodesOlderThan(now.Add(-30 * 24 * time.Hour))
+	if err != nil {
+		t.Fatalf("delete archived nodes: %v", err)
+	}
+	if deleted != 1 {
+		t.Fatalf("deleted count mismatch: got %d, want 1", deleted)
+	}
+
+	remaining := make(map[string]bool)
+	for _, status := range []NodeStatus{NodeStatusArchived, NodeStatusVerified} {
+		for _, n := range store.GetNodes(status) {
+			remaining[n.Tag] = true
+		}
+	}
+	if remaining["archived-old"] {
+		t.Fatalf("expected old archived node to be removed")
+	}
+	for _, tag := range []string{"archived-recent", "archived-unknown", "verified"} {
+		if !remaining[tag] {
+			t.Fatalf("expected node %s to remain", tag)
+		}
+	}
+}
+
+func TestGetServerClusters_GroupsNodesSharingAServer(t *testing.T) {
+	store, err := NewSQLiteStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("create sqlite store: %v", err)
+	}
+	t.Cleanup(func() { _ = store.Close() })
+
+	for _, n := range []UnifiedNode{
+		{Tag: "hk-a", Type: "trojan", Server: "203.0.113.5", ServerPort: 8443, Status: NodeStatusVerified},
+		{Tag: "hk-b", Type: "trojan", Server: "203.0.113.5", ServerPort: 443, Status: NodeStatusPending},
+		{Tag: "hk-c", Type: "vmess", Server: "203.0.113.5", ServerPort: 10086, Status: NodeStatusVerified},
+		{Tag: "hk-old", Type: "vmess", Server: "203.0.113.5", ServerPort: 20000, Status: NodeStatusArchived},
+		{Tag: "jp-a", Type: "trojan", Server: "198.51.100.7", ServerPort: 443, Status: NodeStatusVerified},
+		{Tag: "jp-b", Type: "trojan", Server: "198.51.100.7", ServerPort: 8443, Status: NodeStatusVerified},
+		{Tag: "us-a", Type: "trojan", Server: "192.0.2.9", ServerPort: 443, Status: NodeStatusVerified},
+	} {
+		n.InternalTag = n.Tag
+		if _, err := store.AddNode(n); err != nil {
+			t.Fatalf("insert node %s: %v", n.Tag, err)
+		}
+	}
+
+	clusters, err := store.GetServerClusters(2)
+	if err != nil {
+		t.Fatalf("get server clusters: %v", err)
+	}
+	if len(clusters) != 2 {
+		t.Fatalf("cluster count mismatch: got %d, want 2 (%+v)", len(clusters), clusters)
+	}
+
+	hk := clusters[0]
+	if hk.Server != "203.0.113.5" || hk.NodeCount != 3 {
+		t.Fatalf("largest cluster mismatch: got %s with %d nodes, want 203.0.113.5 with 3", hk.Server, hk.NodeCount)
+	}
+	if fmt.Sprint(hk.Ports) != "[443 8443 10086]" {
+		t.Fatalf("ports mismatch: got %v, want [443 8443 10086]", hk.Ports)
+	}
+	var tags []string
+	for _, n := range hk.Nodes {
+		tags = append(tags, n.Tag)
+	}
+	if fmt.Sprint(tags) != "[hk-b hk-a hk-c]" {
+		t.Fatalf("cluster nodes mismatch: got %v, want [hk-b hk-a hk-c] without the archived node", tags)
+	}
+	if clusters[1].Server != "198.51.100.7" || clusters[1].NodeCount != 2 {
+		t.Fatalf("second cluster mismatch: got %+v", clusters[1])
+	}
+
+	all, err := store.GetServerClusters(1)
+	if err != nil {
+		t.Fatalf("get server clusters: %v", err)
+	}
+	if len(all) != 3 || all[2].Server != "192.0.2.9" {
+		t.Fatalf("min size 1 should include single-node servers, got %+v", all)
+	}
+}
@@ -0,0 +1,106 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// GetProfiles returns all saved profiles ordered by name.
+func (s *SQLiteStore) GetProfiles() []Profile {
+	rows, err := s.db.Query(`SELECT id, name, filters_json, settings_json, created_at, updated_at
+		FROM profiles ORDER BY name`)
+	if err != nil {
+		return []Profile{}
+	}
+	defer rows.Close()
+
+	profiles := []Profile{}
+	for rows.Next() {
+		p, err := scanProfile(rows)
+		if err != nil {
+			continue
+		}
+		profiles = append(profiles, p)
+	}
+	return profiles
+}
+
+// GetProfile returns the profile with the given ID, or nil when it does not exist.
+func (s *SQLiteStore) GetProfile(id string) *Profile {
+	rows, err := s.db.Query(`SELECT id, name, filters_json, settings_json, created_at, updated_at
+		FROM profiles WHERE id = ?`, id)
+	if err != nil {
+		return nil
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		return nil
+	}
+	p, err := scanProfile(rows)
+	if err != nil {
+		return nil
+	}
+	return &p
+}
+
+// SaveProfile stores a profile snapshot. Saving under an existing name replaces that
+// profile's snapshot and keeps its ID, so "work" can be re-captured in place.
+func (s *SQLiteStore) SaveProfile(profile Profile) (*Profile, error) {
+	profile.Name = strings.TrimSpace(profile.Name)
+	if profile.Filters == nil {
+		profile.Filters = []Filter{}
+	}
+	now := time.Now()
+	profile.UpdatedAt = now
+
+	var existingID string
+	var createdAt time.Time
+	err := s.db.QueryRow(`SELECT id, created_at FROM profiles WHERE name = ?`, profile.Name).Scan(&existingID, &createdAt)
+	switch {
+	case err == nil:
+		profile.ID = existingID
+		profile.CreatedAt = createdAt
+	case err == sql.ErrNoRows:
+		if profile.ID == "" {
+			profile.ID = uuid.NewString()
+		}
+		profile.CreatedAt = now
+	default:
+		return nil, err
+	}
+
+	_, err = s.db.Exec(`INSERT OR REPLACE INTO profiles (id, name, filters_json, settings_json, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		profile.ID, profile.Name, marshalJSON(profile.Filters), marshalJSON(profile.Settings),
+		profile.CreatedAt, profile.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &profile, nil
+}
+
+func scanProfile(rows *sql.Rows) (Profile, error) {
+	var p Profile
+	var filtersJSON, settingsJSON string
+	if err := rows.Scan(&p.ID, &p.Name, &filtersJSON, &settingsJSON, &p.CreatedAt, &p.UpdatedAt); err != nil {
+		return p, err
+	}
+	if err := json.Unmarshal([]byte(filtersJSON), &p.Filters); err != nil {
+		return p, err
+	}
+	if p.Filters == nil {
+		p.Filters = []Filter{}
+	}
+	// Start from defaults so settings added after the snapshot was taken stay sane.
+	// Older snapshots hold the whole settings row; only the routing fields are read back.
+	p.Settings = ProfileSettingsFrom(DefaultSettings())
+	if err := json.Unmarshal([]byte(settingsJSON), p.Settings); err != nil {
+		return p, err
+	}
+	return p, nil
+}
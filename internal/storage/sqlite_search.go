@@ -0,0 +1,136 @@
+package storage
+
+import (
+	"fmt"
+	"strings"
+)
+
+// searchCategory describes how one table is searched: the matched columns and
+// the expressions selected as SearchMatch id, name, detail and status
+type searchCategory struct {
+	name    string
+	from    string
+	columns []string
+	selects string
+}
+
+var searchCategories = []searchCategory{
+	{
+		name:    SearchCategoryNodes,
+		from:    "nodes",
+		columns: []string{"tag", "display_name", "source_tag", "server", "internal_tag"},
+		selects: "CAST(id AS TEXT), COALESCE(NULLIF(display_name, ''), tag), server || ':' || server_port, status",
+	},
+	{
+		name:    SearchCategorySubscriptions,
+		from:    "subscriptions",
+		columns: []string{"name", "url"},
+		selects: "id, name, url, CASE WHEN enabled = 1 THEN 'enabled' ELSE 'disabled' END",
+	},
+	{
+		name:    SearchCategoryRules,
+		from:    "rules",
+		columns: []string{"name", "values_json", "outbound"},
+		selects: "id, name, outbound, CASE WHEN enabled = 1 THEN 'enabled' ELSE 'disabled' END",
+	},
+	{
+		name:    SearchCategoryRules,
+		from:    "rule_groups",
+		columns: []string{"name", "site_rules_json", "ip_rules_json", "outbound"},
+		selects: "id, name, outbound, CASE WHEN enabled = 1 THEN 'enabled' ELSE 'disabled' END",
+	},
+	{
+		name:    SearchCategoryFilters,
+		from:    "filters",
+		columns: []string{"name"},
+		selects: "id, name, mode, CASE WHEN enabled = 1 THEN 'enabled' ELSE 'disabled' END",
+	},
+}
+
+// escapeLike escapes LIKE wildcards so the query is matched literally
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+// Search runs a case-insensitive substring search over nodes, subscriptions,
+// rules and filters. Each category returns at most limit hits; Counts reports
+// the full number of matches per category.
+func (s *SQLiteStore) Search(query string, limit int) (*SearchResults, error) {
+	query = strings.TrimSpace(query)
+	results := &SearchResults{
+		Query:         query,
+		Nodes:         []SearchMatch{},
+		Subscriptions: []SearchMatch{},
+		Rules:         []SearchMatch{},
+		Filters:       []SearchMatch{},
+		Counts: map[string]int{
+			SearchCategoryNodes:         0,
+			SearchCategorySubscriptions: 0,
+			SearchCategoryRules:         0,
+			SearchCategoryFilters:       0,
+		},
+	}
+	if query == "" || limit <= 0 {
+		return results, nil
+	}
+	pattern := "%" + escapeLike(query) + "%"
+
+	for _, cat := range searchCategories {
+		conds := make([]string, len(cat.columns))
+		args := make([]interface{}, len(cat.columns))
+		for i, col := range cat.columns {
+			conds[i] = "COALESCE(" + col + ", '') LIKE ? ESCAPE '\\'"
+			args[i] = pattern
+		}
+		where := strings.Join(conds, " OR ")
+
+		var count int
+		if err := s.db.QueryRow("SELECT COUNT(*) FROM "+cat.from+" WHERE "+where, args...).Scan(&count); err != nil {
+			return nil, fmt.Errorf("count %s: %w", cat.from, err)
+		}
+		results.Counts[cat.name] += count
+		if count == 0 {
+			continue
+		}
+
+		matches, err := s.searchTable(cat, where, args, limit)
+		if err != nil {
+			return nil, err
+		}
+		switch cat.name {
+		case SearchCategoryNodes:
+			results.Nodes = append(results.Nodes, matches...)
+		case SearchCategorySubscriptions:
+			results.Subscriptions = append(results.Subscriptions, matches...)
+		case SearchCategoryRules:
+			results.Rules = append(results.Rules, matches...)
+		case SearchCategoryFilters:
+			results.Filters = append(results.Filters, matches...)
+		}
+	}
+
+	// rules and rule_groups share a category, keep the combined list bounded
+	if len(results.Rules) > limit {
+		results.Rules = results.Rules[:limit]
+	}
+	return results, nil
+}
+
+func (s *SQLiteStore) searchTable(cat searchCategory, where string, args []interface{}, limit int) ([]SearchMatch, error) {
+	rows, err := s.db.Query("SELECT "+cat.selects+" FROM "+cat.from+" WHERE "+where+" ORDER BY 2 LIMIT ?",
+		append(args, limit)...)
+	if err != nil {
+		return nil, fmt.Errorf("search %s: %w", cat.from, err)
+	}
+	defer rows.Close()
+
+	var matches []SearchMatch
+	for rows.Next() {
+		var m SearchMatch
+		if err := rows.Scan(&m.ID, &m.Name, &m.Detail, &m.Status); err != nil {
+			return nil, fmt.Errorf("scan %s: %w", cat.from, err)
+		}
+		matches = append(matches, m)
+	}
+	return matches, rows.Err()
+}
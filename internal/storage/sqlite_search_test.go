@@ -0,0 +1,50 @@
+package storage
+
+import "testing"
+
+func TestSearch_MatchesNodeServerAndRuleValue(t *testing.T) {
+	store, err := NewSQLiteStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("create sqlite store: %v", err)
+	}
+	t.Cleanup(func() { _ = store.Close() })
+
+	if _, err := store.AddNode(UnifiedNode{Tag: "hk-1", Type: "vmess", Server: "hk.example.net", ServerPort: 443}); err != nil {
+		t.Fatalf("insert node: %v", err)
+	}
+	if _, err := store.AddNode(UnifiedNode{Tag: "jp-1", Type: "vmess", Server: "10.0.0.1", ServerPort: 443}); err != nil {
+		t.Fatalf("insert node: %v", err)
+	}
+	if _, err := store.db.Exec(`INSERT INTO rules (id, name, rule_type, values_json, outbound, enabled)
+		VALUES ('r1', 'streaming', 'domain_suffix', '["example.net","video.test"]', 'Proxy', 1)`); err != nil {
+		t.Fatalf("insert rule: %v", err)
+	}
+	if err := store.AddSubscription(Subscription{ID: "sub-1", Name: "main", URL: "https://sub.test/link", Enabled: true}); err != nil {
+		t.Fatalf("add subscription: %v", err)
+	}
+
+	results, err := store.Search("EXAMPLE.net", 20)
+	if err != nil {
+		t.Fatalf("search: %v", err)
+	}
+	if len(results.Nodes) != 1 || results.Nodes[0].Name != "hk-1" || results.Nodes[0].Detail != "hk.example.net:443" {
+		t.Fatalf("node matches mismatch: got %+v", results.Nodes)
+	}
+	if len(results.Rules) != 1 || results.Rules[0].ID != "r1" || results.Rules[0].Detail != "Proxy" {
+		t.Fatalf("rule matches mismatch: got %+v", results.Rules)
+	}
+	if len(results.Subscriptions) != 0 || len(results.Filters) != 0 {
+		t.Fatalf("expected no subscription or filter matches, got %+v / %+v", results.Subscriptions, results.Filters)
+	}
+	if results.Counts[SearchCategoryNodes] != 1 || results.Counts[SearchCategoryRules] != 1 {
+		t.Fatalf("counts mismatch: got %v", results.Counts)
+	}
+
+	results, err = store.Search("%", 20)
+	if err != nil {
+		t.Fatalf("search wildcard: %v", err)
+	}
+	if results.Counts[SearchCategoryNodes] != 0 {
+		t.Fatalf("expected LIKE wildcard to be matched literally, got %v", results.Counts)
+	}
+}
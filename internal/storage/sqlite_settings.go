@@ -3,41 +3,93 @@ package storage
 import (
 	"database/sql"
 	"encoding/json"
+	"strings"
 )
 
 func (s *SQLiteStore) GetSettings() *Settings {
 	row := s.db.QueryRow(`SELECT singbox_path, config_path,
-		mixed_port, mixed_address, tun_enabled, allow_lan,
+		mixed_port, mixed_address, tun_enabled, allow_lan, ipv6_enabled,
 		socks_port, socks_address, socks_auth, socks_username, socks_password,
 		http_port, http_address, http_auth, http_username, http_password,
 		shadowsocks_port, shadowsocks_address, shadowsocks_method, shadowsocks_password,
 		proxy_dns, direct_dns,
 		web_port, clash_api_port, clash_ui_path, clash_api_secret,
 		final_outbound, ruleset_base_url,
+		auto_detect_interface, default_interface,
 		auto_apply, subscription_interval,
 		github_proxy, debug_api_enabled,
 		verification_interval, archive_threshold,
+		min_uptime_percent, uptime_window_hours,
 		proxy_mode,
-		blocked_countries_json
+		blocked_countries_json,
+		sniffers_json, sniff_timeout,
+		node_tag_template, traffic_sample_interval_seconds,
+		sort_proxy_nodes,
+		webhook_url, webhook_secret,
+		urltest_url, urltest_expected_status,
+		tun_stack, tun_mtu,
+		listen_address,
+		default_utls_fingerprint,
+		health_retention_days,
+		mixed_enabled, socks_enabled, http_enabled,
+		multiplex_enabled,
+		tun_include_routes_json, tun_exclude_routes_json,
+		tcp_fast_open,
+		score_weight_latency, score_weight_uptime, score_weight_sites,
+		direct_processes_json,
+		udp_check_target,
+		detach_singbox,
+		quiet_hours_start, quiet_hours_end,
+		udp_over_tcp,
+		drain_timeout_seconds,
+		block_quic,
+		no_nodes_mode,
+		udp_fragment
 		FROM settings WHERE id = 1`)
 
 	settings := &Settings{}
-	var tunEnabled, allowLAN, socksAuth, httpAuth, autoApply, debugAPI int
-	var blockedCountriesJSON string
+	var tunEnabled, allowLAN, ipv6Enabled, socksAuth, httpAuth, autoApply, debugAPI, autoDetectInterface, sortProxyNodes int
+	var mixedEnabled, socksEnabled, httpEnabled, multiplexEnabled, tcpFastOpen, udpFragment, detachSingbox, udpOverTCP, blockQUIC int
+	var blockedCountriesJSON, sniffersJSON, tunIncludeRoutesJSON, tunExcludeRoutesJSON, directProcessesJSON string
 	err := row.Scan(
 		&settings.SingBoxPath, &settings.ConfigPath,
-		&settings.MixedPort, &settings.MixedAddress, &tunEnabled, &allowLAN,
+		&settings.MixedPort, &settings.MixedAddress, &tunEnabled, &allowLAN, &ipv6Enabled,
 		&settings.SocksPort, &settings.SocksAddress, &socksAuth, &settings.SocksUsername, &settings.SocksPassword,
 		&settings.HttpPort, &settings.HttpAddress, &httpAuth, &settings.HttpUsername, &settings.HttpPassword,
 		&settings.ShadowsocksPort, &settings.ShadowsocksAddress, &settings.ShadowsocksMethod, &settings.ShadowsocksPassword,
 		&settings.ProxyDNS, &settings.DirectDNS,
 		&settings.WebPort, &settings.ClashAPIPort, &settings.ClashUIPath, &settings.ClashAPISecret,
 		&settings.FinalOutbound, &settings.RuleSetBaseURL,
+		&autoDetectInterface, &settings.DefaultInterface,
 		&autoApply, &settings.SubscriptionInterval,
 		&settings.GithubProxy, &debugAPI,
 		&settings.VerificationInterval, &settings.ArchiveThreshold,
+		&settings.MinUptimePercent, &settings.UptimeWindowHours,
 		&settings.ProxyMode,
 		&blockedCountriesJSON,
+		&sniffersJSON, &settings.SniffTimeout,
+		&settings.NodeTagTemplate, &settings.TrafficSampleIntervalSeconds,
+		&sortProxyNodes,
+		&settings.WebhookURL, &settings.WebhookSecret,
+		&settings.URLTestURL, &settings.URLTestExpectedStatus,
+		&settings.TunStack, &settings.TunMTU,
+		&settings.ListenAddress,
+		&settings.DefaultUTLSFingerprint,
+		&settings.HealthRetentionDays,
+		&mixedEnabled, &socksEnabled, &httpEnabled,
+		&multiplexEnabled,
+		&tunIncludeRoutesJSON, &tunExcludeRoutesJSON,
+		&tcpFastOpen,
+		&settings.ScoreWeightLatency, &settings.ScoreWeightUptime, &settings.ScoreWeightSites,
+		&directProcessesJSON,
+		&settings.UDPCheckTarget,
+		&detachSingbox,
+		&settings.QuietHours.Start, &settings.QuietHours.End,
+		&udpOverTCP,
+		&settings.DrainTimeoutSeconds,
+		&blockQUIC,
+		&settings.NoNodesMode,
+		&udpFragment,
 	)
 	if err != nil {
 		return DefaultSettings()
@@ -45,10 +97,22 @@ func (s *SQLiteStore) GetSettings() *Settings {
 
 	settings.TunEnabled = tunEnabled != 0
 	settings.AllowLAN = allowLAN != 0
+	settings.IPv6Enabled = ipv6Enabled != 0
 	settings.SocksAuth = socksAuth != 0
 	settings.HttpAuth = httpAuth != 0
+	settings.MixedEnabled = mixedEnabled != 0
+	settings.SocksEnabled = socksEnabled != 0
+	settings.HttpEnabled = httpEnabled != 0
+	settings.MultiplexEnabled = multiplexEnabled != 0
+	settings.TCPFastOpen = tcpFastOpen != 0
+	settings.UDPFragment = udpFragment != 0
+	settings.UDPOverTCP = udpOverTCP != 0
+	settings.BlockQUIC = blockQUIC != 0
+	settings.DetachSingbox = detachSingbox != 0
 	settings.AutoApply = autoApply != 0
 	settings.DebugAPIEnabled = debugAPI != 0
+	settings.AutoDetectInterface = autoDetectInterface != 0
+	settings.SortProxyNodes = sortProxyNodes != 0
 	settings.ProxyMode = NormalizeProxyMode(settings.ProxyMode)
 
 	// Deserialize blocked countries
@@ -59,6 +123,31 @@ func (s *SQLiteStore) GetSettings() *Settings {
 		settings.BlockedCountries = []string{}
 	}
 
+	// Deserialize sniffers
+	if sniffersJSON != "" {
+		json.Unmarshal([]byte(sniffersJSON), &settings.Sniffers)
+	}
+	if len(settings.Sniffers) == 0 {
+		settings.Sniffers = DefaultSniffers()
+	}
+	if settings.SniffTimeout == "" {
+		settings.SniffTimeout = DefaultSniffTimeout
+	}
+	settings.TrafficSampleIntervalSeconds = NormalizeTrafficSampleInterval(settings.TrafficSampleIntervalSeconds)
+	if settings.TunStack == "" {
+		settings.TunStack = DefaultTunStack
+	}
+
+	// Deserialize TUN routes
+	json.Unmarshal([]byte(tunIncludeRoutesJSON), &settings.TunIncludeRoutes)
+	json.Unmarshal([]byte(tunExcludeRoutesJSON), &settings.TunExcludeRoutes)
+	settings.TunIncludeRoutes = NormalizeTunRoutes(settings.TunIncludeRoutes)
+	settings.TunExcludeRoutes = NormalizeTunRoutes(settings.TunExcludeRoutes)
+
+	// Deserialize direct processes
+	json.Unmarshal([]byte(directProcessesJSON), &settings.DirectProcesses)
+	settings.DirectProcesses = NormalizeDirectProcesses(settings.DirectProcesses)
+
 	// Load host entries
 	settings.Hosts = s.getHostEntries()
 
@@ -76,35 +165,92 @@ func (s *SQLiteStore) UpdateSettings(settings *Settings) error {
 	if settings.BlockedCountries == nil {
 		blockedJSON = []byte("[]")
 	}
+	sniffersJSON, _ := json.Marshal(settings.Sniffers)
+	if settings.Sniffers == nil {
+		sniffersJSON = []byte("[]")
+	}
+	tunIncludeRoutesJSON, _ := json.Marshal(NormalizeTunRoutes(settings.TunIncludeRoutes))
+	tunExcludeRoutesJSON, _ := json.Marshal(NormalizeTunRoutes(settings.TunExcludeRoutes))
+	directProcessesJSON, _ := json.Marshal(NormalizeDirectProcesses(settings.DirectProcesses))
 
 	_, err = tx.Exec(`INSERT OR REPLACE INTO settings (id,
 		singbox_path, config_path,
-		mixed_port, mixed_address, tun_enabled, allow_lan,
+		mixed_port, mixed_address, tun_enabled, allow_lan, ipv6_enabled,
 		socks_port, socks_address, socks_auth, socks_username, socks_password,
 		http_port, http_address, http_auth, http_username, http_password,
 		shadowsocks_port, shadowsocks_address, shadowsocks_method, shadowsocks_password,
 		proxy_dns, direct_dns,
 		web_port, clash_api_port, clash_ui_path, clash_api_secret,
 		final_outbound, ruleset_base_url,
+		auto_detect_interface, default_interface,
 		auto_apply, subscription_interval,
 		github_proxy, debug_api_enabled,
 		verification_interval, archive_threshold,
+		min_uptime_percent, uptime_window_hours,
 		proxy_mode,
-		blocked_countries_json)
-		VALUES (1, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		blocked_countries_json,
+		sniffers_json, sniff_timeout,
+		node_tag_template, traffic_sample_interval_seconds,
+		sort_proxy_nodes,
+		webhook_url, webhook_secret,
+		urltest_url, urltest_expected_status,
+		tun_stack, tun_mtu,
+		listen_address,
+		default_utls_fingerprint,
+		health_retention_days,
+		mixed_enabled, socks_enabled, http_enabled,
+		multiplex_enabled,
+		tun_include_routes_json, tun_exclude_routes_json,
+		tcp_fast_open,
+		score_weight_latency, score_weight_uptime, score_weight_sites,
+		direct_processes_json,
+		udp_check_target,
+		detach_singbox,
+		quiet_hours_start, quiet_hours_end,
+		udp_over_tcp,
+		drain_timeout_seconds,
+		block_quic,
+		no_nodes_mode,
+		udp_fragment)
+		VALUES (1, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
 		settings.SingBoxPath, settings.ConfigPath,
-		settings.MixedPort, settings.MixedAddress, boolToInt(settings.TunEnabled), boolToInt(settings.AllowLAN),
+		settings.MixedPort, settings.MixedAddress, boolToInt(settings.TunEnabled), boolToInt(settings.AllowLAN), boolToInt(settings.IPv6Enabled),
 		settings.SocksPort, settings.SocksAddress, boolToInt(settings.SocksAuth), settings.SocksUsername, settings.SocksPassword,
 		settings.HttpPort, settings.HttpAddress, boolToInt(settings.HttpAuth), settings.HttpUsername, settings.HttpPassword,
 		settings.ShadowsocksPort, settings.ShadowsocksAddress, settings.ShadowsocksMethod, settings.ShadowsocksPassword,
 		settings.ProxyDNS, settings.DirectDNS,
 		settings.WebPort, settings.ClashAPIPort, settings.ClashUIPath, settings.ClashAPISecret,
 		settings.FinalOutbound, settings.RuleSetBaseURL,
+		boolToInt(settings.AutoDetectInterface), settings.DefaultInterface,
 		boolToInt(settings.AutoApply), settings.SubscriptionInterval,
 		settings.GithubProxy, boolToInt(settings.DebugAPIEnabled),
 		settings.VerificationInterval, settings.ArchiveThreshold,
+		settings.MinUptimePercent, settings.UptimeWindowHours,
 		NormalizeProxyMode(settings.ProxyMode),
-		string(blockedJSON))
+		string(blockedJSON),
+		string(sniffersJSON), settings.SniffTimeout,
+		settings.NodeTagTemplate, NormalizeTrafficSampleInterval(settings.TrafficSampleIntervalSeconds),
+		boolToInt(settings.SortProxyNodes),
+		settings.WebhookURL, settings.WebhookSecret,
+		settings.URLTestURL, settings.URLTestExpectedStatus,
+		settings.TunStack, settings.TunMTU,
+		settings.ListenAddress,
+		settings.DefaultUTLSFingerprint,
+		settings.HealthRetentionDays,
+		boolToInt(settings.MixedEnabled), boolToInt(settings.SocksEnabled), boolToInt(settings.HttpEnabled),
+		boolToInt(settings.MultiplexEnabled),
+		string(tunIncludeRoutesJSON), string(tunExcludeRoutesJSON),
+		boolToInt(settings.TCPFastOpen),
+		settings.ScoreWeightLatency, settings.ScoreWeightUptime, settings.ScoreWeightSites,
+		string(directProcessesJSON),
+		settings.UDPCheckTarget,
+		boolToInt(settings.DetachSingbox),
+		strings.TrimSpace(settings.QuietHours.Start), strings.TrimSpace(settings.QuietHours.End),
+		boolToInt(settings.UDPOverTCP),
+		settings.DrainTimeoutSeconds,
+		boolToInt(settings.BlockQUIC),
+		NormalizeNoNodesMode(settings.NoNodesMode),
+		boolToInt(settings.UDPFragment))
 	if err != nil {
 		return err
 	}
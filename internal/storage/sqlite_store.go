@@ -92,6 +92,22 @@ func (s *SQLiteStore) Checkpoint() error {
 	return err
 }
 
+// Vacuum rebuilds the database file to reclaim space left by deleted rows.
+// VACUUM needs exclusive access, so callers must ensure no other writers are active.
+func (s *SQLiteStore) Vacuum() error {
+	if err := s.Checkpoint(); err != nil {
+		return fmt.Errorf("checkpoint before vacuum: %w", err)
+	}
+	if _, err := s.db.Exec("VACUUM"); err != nil {
+		return fmt.Errorf("vacuum: %w", err)
+	}
+	// VACUUM writes through the WAL in WAL mode; truncate it so the file actually shrinks.
+	if err := s.Checkpoint(); err != nil {
+		return fmt.Errorf("checkpoint after vacuum: %w", err)
+	}
+	return nil
+}
+
 // GetDataDir returns the data directory.
 func (s *SQLiteStore) GetDataDir() string {
 	return s.dataDir
@@ -7,7 +7,8 @@ import (
 	"time"
 )
 
-const subscriptionColumns = `id, name, url, node_count, updated_at, expire_at, enabled, traffic_json`
+const subscriptionColumns = `id, name, url, node_count, updated_at, expire_at, enabled, traffic_json,
+	auto_pipeline, pipeline_group_tag, pipeline_min_stability, pipeline_remove_dead, auth_header, auth_value`
 
 func (s *SQLiteStore) GetSubscriptions() []Subscription {
 	rows, err := s.db.Query("SELECT " + subscriptionColumns + " FROM subscriptions")
@@ -54,9 +55,12 @@ func (s *SQLiteStore) AddSubscription(sub Subscription) error {
 		expireAt = sub.ExpireAt
 	}
 
-	_, err = tx.Exec(`INSERT INTO subscriptions (id, name, url, node_count, updated_at, expire_at, enabled, traffic_json)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
-		sub.ID, sub.Name, sub.URL, sub.NodeCount, sub.UpdatedAt, expireAt, boolToInt(sub.Enabled), trafficJSON)
+	_, err = tx.Exec(`INSERT INTO subscriptions (id, name, url, node_count, updated_at, expire_at, enabled, traffic_json,
+		auto_pipeline, pipeline_group_tag, pipeline_min_stability, pipeline_remove_dead, auth_header, auth_value)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		sub.ID, sub.Name, sub.URL, sub.NodeCount, sub.UpdatedAt, expireAt, boolToInt(sub.Enabled), trafficJSON,
+		boolToInt(sub.AutoPipeline), sub.PipelineGroupTag, sub.PipelineMinStability, boolToInt(sub.PipelineRemoveDead),
+		sub.AuthHeader, sub.AuthValue)
 	if err != nil {
 		return err
 	}
@@ -80,9 +84,12 @@ func (s *SQLiteStore) UpdateSubscription(sub Subscription) error {
 		expireAt = sub.ExpireAt
 	}
 
-	res, err := tx.Exec(`UPDATE subscriptions SET name=?, url=?, node_count=?, updated_at=?, expire_at=?, enabled=?, traffic_json=?
+	res, err := tx.Exec(`UPDATE subscriptions SET name=?, url=?, node_count=?, updated_at=?, expire_at=?, enabled=?, traffic_json=?,
+		auto_pipeline=?, pipeline_group_tag=?, pipeline_min_stability=?, pipeline_remove_dead=?, auth_header=?, auth_value=?
 		WHERE id=?`,
-		sub.Name, sub.URL, sub.NodeCount, sub.UpdatedAt, expireAt, boolToInt(sub.Enabled), trafficJSON, sub.ID)
+		sub.Name, sub.URL, sub.NodeCount, sub.UpdatedAt, expireAt, boolToInt(sub.Enabled), trafficJSON,
+		boolToInt(sub.AutoPipeline), sub.PipelineGroupTag, sub.PipelineMinStability, boolToInt(sub.PipelineRemoveDead),
+		sub.AuthHeader, sub.AuthValue, sub.ID)
 	if err != nil {
 		return err
 	}
@@ -163,28 +170,34 @@ func insertNodesTx(tx *sql.Tx, subID string, nodes []Node) error {
 func scanSubscription(rows *sql.Rows) (Subscription, error) {
 	var sub Subscription
 	var updatedAt, expireAt sql.NullTime
-	var enabled int
+	var enabled, autoPipeline, removeDead int
 	var trafficJSON sql.NullString
 
-	err := rows.Scan(&sub.ID, &sub.Name, &sub.URL, &sub.NodeCount, &updatedAt, &expireAt, &enabled, &trafficJSON)
+	err := rows.Scan(&sub.ID, &sub.Name, &sub.URL, &sub.NodeCount, &updatedAt, &expireAt, &enabled, &trafficJSON,
+		&autoPipeline, &sub.PipelineGroupTag, &sub.PipelineMinStability, &removeDead, &sub.AuthHeader, &sub.AuthValue)
 	if err != nil {
 		return sub, err
 	}
 	applySubscriptionFields(&sub, updatedAt, expireAt, enabled, trafficJSON)
+	sub.AutoPipeline = autoPipeline != 0
+	sub.PipelineRemoveDead = removeDead != 0
 	return sub, nil
 }
 
 func scanSubscriptionRow(row *sql.Row) (Subscription, error) {
 	var sub Subscription
 	var updatedAt, expireAt sql.NullTime
-	var enabled int
+	var enabled, autoPipeline, removeDead int
 	var trafficJSON sql.NullString
 
-	err := row.Scan(&sub.ID, &sub.Name, &sub.URL, &sub.NodeCount, &updatedAt, &expireAt, &enabled, &trafficJSON)
+	err := row.Scan(&sub.ID, &sub.Name, &sub.URL, &sub.NodeCount, &updatedAt, &expireAt, &enabled, &trafficJSON,
+		&autoPipeline, &sub.PipelineGroupTag, &sub.PipelineMinStability, &removeDead, &sub.AuthHeader, &sub.AuthValue)
 	if err != nil {
 		return sub, err
 	}
 	applySubscriptionFields(&sub, updatedAt, expireAt, enabled, trafficJSON)
+	sub.AutoPipeline = autoPipeline != 0
+	sub.PipelineRemoveDead = removeDead != 0
 	return sub, nil
 }
 
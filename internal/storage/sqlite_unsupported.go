@@ -58,3 +58,17 @@ func (s *SQLiteStore) DeleteUnsupportedNodesByEndpoints(endpoints []ServerPortKe
 	}
 	return nil
 }
+
+// DeleteStaleUnsupportedNodes removes unsupported entries whose server:port
+// no longer belongs to any stored node.
+func (s *SQLiteStore) DeleteStaleUnsupportedNodes() (int64, error) {
+	res, err := s.db.Exec(`DELETE FROM unsupported_nodes
+		WHERE NOT EXISTS (
+			SELECT 1 FROM nodes n
+			WHERE n.server = unsupported_nodes.server AND n.server_port = unsupported_nodes.server_port
+		)`)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
@@ -0,0 +1,38 @@
+package storage
+
+import "testing"
+
+func TestDeleteStaleUnsupportedNodes(t *testing.T) {
+	store, err := NewSQLiteStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("create sqlite store: %v", err)
+	}
+	t.Cleanup(func() { _ = store.Close() })
+
+	if _, err := store.AddNode(UnifiedNode{
+		Tag: "kept", Type: "vless", Server: "10.0.0.1", ServerPort: 443, Status: NodeStatusVerified,
+	}); err != nil {
+		t.Fatalf("insert node: %v", err)
+	}
+	for _, un := range []UnsupportedNode{
+		{Server: "10.0.0.1", ServerPort: 443, NodeTag: "kept", Error: "unknown transport"},
+		{Server: "10.0.0.2", ServerPort: 443, NodeTag: "deleted", Error: "unknown transport"},
+	} {
+		if err := store.AddUnsupportedNode(un); err != nil {
+			t.Fatalf("add unsupported node %s: %v", un.NodeTag, err)
+		}
+	}
+
+	removed, err := store.DeleteStaleUnsupportedNodes()
+	if err != nil {
+		t.Fatalf("delete stale unsupported nodes: %v", err)
+	}
+	if removed != 1 {
+		t.Fatalf("removed mismatch: got %d, want 1", removed)
+	}
+
+	remaining := store.GetUnsupportedNodes()
+	if len(remaining) != 1 || remaining[0].NodeTag != "kept" {
+		t.Fatalf("expected only the live entry to remain, got %+v", remaining)
+	}
+}
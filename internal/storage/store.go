@@ -17,6 +17,12 @@ type Store interface {
 	AddFilter(filter Filter) error
 	UpdateFilter(filter Filter) error
 	DeleteFilter(id string) error
+	ReplaceFilters(filters []Filter) error
+
+	// Profiles
+	GetProfiles() []Profile
+	GetProfile(id string) *Profile
+	SaveProfile(profile Profile) (*Profile, error)
 
 	// Settings
 	GetSettings() *Settings
@@ -30,16 +36,23 @@ type Store interface {
 	GetNodesBySource(source string) []UnifiedNode
 	AddNode(node UnifiedNode) (int64, error)
 	AddNodesBulk(nodes []UnifiedNode) (int, error)
+	SyncSubscriptionNodes(source string, nodes []UnifiedNode) (NodeSyncResult, error)
 	UpdateNode(node UnifiedNode) error
+	UpdateNodeExtra(id int64, extra map[string]interface{}) error
 	DeleteNode(id int64) error
 	PromoteNode(id int64) error
 	DemoteNode(id int64) error
 	ArchiveNode(id int64) error
 	UnarchiveNode(id int64) error
 	UnarchiveAllNodes() (int64, error)
+	DeleteArchivedNodesOlderThan(cutoff time.Time) (int64, error)
 	IncrementConsecutiveFailures(id int64) (int, error)
 	ResetConsecutiveFailures(id int64) error
 	SetNodeFavorite(id int64, favorite bool) error
+	SetNodePinned(id int64, pinned bool) error
+	SetNodeCountry(id int64, countryCode, countryEmoji string, locked bool) error
+	SetNodeExcludeFromAuto(id int64, exclude bool) error
+	GetServerClusters(minSize int) ([]ServerCluster, error)
 	GetNodeCounts() NodeCounts
 
 	// Verification Logs
@@ -50,13 +63,18 @@ type Store interface {
 
 	// Helpers
 	GetAllNodes() []Node
+	GetAllNodesSorted(sortBy string, desc bool) []Node
 	GetAllNodesIncludeDisabled() []Node
 	GetNodesByCountry(countryCode string) []Node
 	GetCountryGroups() []CountryGroup
+	GetCountryOverrides() map[string]CountryOverride
+	SetCountryOverride(override CountryOverride) error
+	DeleteCountryOverride(code string) error
 	GetDataDir() string
 	Save() error
 	RemoveNodesByTags(tags []string) (int, error)
 	RemoveNodesByEndpoints(endpoints []ServerPortKey) (int, error)
+	Search(query string, limit int) (*SearchResults, error)
 
 	// Unsupported Nodes
 	GetUnsupportedNodes() []UnsupportedNode
@@ -64,19 +82,24 @@ type Store interface {
 	ClearUnsupportedNodes() error
 	DeleteUnsupportedNodesByTags(tags []string) error
 	DeleteUnsupportedNodesByEndpoints(endpoints []ServerPortKey) error
+	DeleteStaleUnsupportedNodes() (int64, error)
 
 	// Measurements
 	AddHealthMeasurements(measurements []HealthMeasurement) error
 	GetHealthMeasurements(server string, port int, limit int) ([]HealthMeasurement, error)
 	GetHealthStats(server string, port int) (*HealthStats, error)
+	GetHealthStatsSince(server string, port int, since time.Time) (*HealthStats, error)
 	GetBulkHealthStats(days int) ([]NodeStabilityStats, error)
+	RollupHealthMeasurements(before time.Time) (int64, error)
 	GetLatestHealthMeasurements() ([]HealthMeasurement, error)
 	AddSiteMeasurements(measurements []SiteMeasurement) error
 	GetSiteMeasurements(server string, port int, limit int) ([]SiteMeasurement, error)
 	GetLatestSiteMeasurements() ([]SiteMeasurement, error)
+	GetSiteMatrix() (*SiteMatrix, error)
 	AddTrafficSample(sample TrafficSample, clients []ClientTrafficSnapshot, resources []ClientResourceSnapshot) (int64, error)
 	GetTrafficSamples(limit int) ([]TrafficSample, error)
 	GetTrafficSamplesByTimeRange(since time.Time, maxPoints int) ([]TrafficSample, error)
+	EachTrafficSampleSince(since time.Time, fn func(TrafficSample) error) error
 	GetLatestTrafficSample() (*TrafficSample, error)
 	GetLatestTrafficClients(limit int) ([]ClientTrafficSnapshot, error)
 	GetRecentTrafficClients(limit int, lookback time.Duration) ([]TrafficClientRecent, error)
@@ -86,6 +109,14 @@ type Store interface {
 	GetClientResourcesHistory(sourceIP string, limit int) ([]ClientResourceHistory, error)
 	GetTrafficLifetimeStats() (*TrafficLifetimeStats, error)
 	GetTrafficChainStats(limit int, lookback time.Duration) ([]TrafficChainStats, error)
+	GetConnectionLog(query ConnectionLogQuery) ([]ConnectionLogEntry, error)
+	GetHostObservations(since time.Time) ([]HostObservation, error)
+	GetClientUsageSince(since time.Time) ([]ClientUsage, error)
+
+	// Client Quotas
+	GetClientQuotas() []ClientQuota
+	SetClientQuota(quota ClientQuota) error
+	DeleteClientQuota(sourceIP string) error
 
 	// Speed Measurements
 	AddSpeedMeasurements(measurements []SpeedMeasurement) error
@@ -20,6 +20,12 @@ type SubscriptionInfo struct {
 
 // FetchSubscription fetches subscription content
 func FetchSubscription(url string) (string, *SubscriptionInfo, error) {
+	return FetchSubscriptionWithHeaders(url, nil)
+}
+
+// FetchSubscriptionWithHeaders fetches subscription content, sending the extra headers
+// (e.g. Authorization) with the request
+func FetchSubscriptionWithHeaders(url string, headers map[string]string) (string, *SubscriptionInfo, error) {
 	client := &http.Client{
 		Timeout: 30 * time.Second,
 	}
@@ -31,6 +37,9 @@ func FetchSubscription(url string) (string, *SubscriptionInfo, error) {
 
 	// Set User-Agent
 	req.Header.Set("User-Agent", "clash-verge/v1.0.0")
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
 
 	resp, err := client.Do(req)
 	if err != nil {
@@ -8,7 +8,7 @@ const api = axios.create({
 // Subscription API
 export const subscriptionApi = {
   getAll: () => api.get('/subscriptions'),
-  add: (name: string, url: string) => api.post('/subscriptions', { name, url }),
+  add: (name: string, url: string, pipeline?: any) => api.post('/subscriptions', { name, url, ...pipeline }),
   update: (id: string, data: any) => api.put(`/subscriptions/${id}`, data),
   delete: (id: string) => api.delete(`/subscriptions/${id}`),
   refresh: (id: string) => api.post(`/subscriptions/${id}/refresh`),
@@ -18,15 +18,27 @@ export const subscriptionApi = {
 // Filter API
 export const filterApi = {
   getAll: () => api.get('/filters'),
-  add: (data: any) => api.post('/filters', data),
-  update: (id: string, data: any) => api.put(`/filters/${id}`, data),
+  // checkUrl asks the server to probe the urltest URL; failures come back in `warnings`
+  add: (data: any, checkUrl?: boolean) => api.post('/filters', data, { params: checkUrl ? { check_url: true } : {} }),
+  preview: (data: any) => api.post('/filters/preview', data),
+  update: (id: string, data: any, checkUrl?: boolean) =>
+    api.put(`/filters/${id}`, data, { params: checkUrl ? { check_url: true } : {} }),
   delete: (id: string) => api.delete(`/filters/${id}`),
 };
 
+// Profile API
+export const profileApi = {
+  getAll: () => api.get('/profiles'),
+  create: (name: string) => api.post('/profiles', { name }),
+  activate: (id: string) => api.post(`/profiles/${id}/activate`),
+};
+
 // Settings API
 export const settingsApi = {
   get: () => api.get('/settings'),
   update: (data: any) => api.put('/settings', data),
+  validate: (data: any) => api.post('/settings/validate', data),
+  rotateSecret: () => api.post('/settings/rotate-secret'),
   getSystemHosts: () => api.get('/system-hosts'),
 };
 
@@ -34,7 +46,26 @@ export const settingsApi = {
 export const configApi = {
   generate: () => api.post('/config/generate'),
   preview: () => api.get('/config/preview'),
+  outbounds: () => api.get('/config/outbounds'),
+  status: () => api.get('/config/status'),
   apply: () => api.post('/config/apply'),
+  // Writes the built config to a .json file under <data dir>/exports instead of the active config
+  writeTo: (path: string) => api.post('/config/apply', { path }),
+};
+
+// Route simulation API
+export const routeApi = {
+  simulate: (target: { domain?: string; ip?: string }) =>
+    api.get('/route/simulate', { params: target }),
+  ruleStats: (params?: { hours?: number; since?: string }) =>
+    api.get('/rules/stats', { params }),
+  // Route rules in evaluation order, each with the setting that produced it
+  rules: () => api.get('/route/rules'),
+};
+
+// Global search API
+export const searchApi = {
+  search: (q: string, limit?: number) => api.get('/search', { params: { q, limit } }),
 };
 
 // Service API
@@ -43,6 +74,7 @@ export const serviceApi = {
   start: () => api.post('/service/start'),
   stop: () => api.post('/service/stop'),
   restart: () => api.post('/service/restart'),
+  reloadFile: () => api.post('/service/reload-file'),
   reload: () => api.post('/service/reload'),
 };
 
@@ -75,25 +107,64 @@ export const monitorApi = {
 export const nodeApi = {
   getAll: () => api.get('/nodes'),
   getCountries: () => api.get('/nodes/countries'),
+  getUsage: (hours?: number) => api.get('/nodes/usage', { params: hours ? { hours } : {} }),
+  getScores: (days?: number) => api.get('/nodes/scores', { params: days ? { days } : {} }),
+  getByEndpoint: (server: string, port: number) => api.get('/nodes/by-endpoint', { params: { server, port } }),
+  // Servers shared by at least minSize nodes (default 2), largest first
+  getClusters: (minSize?: number) => api.get('/nodes/clusters', { params: minSize ? { min_size: minSize } : {} }),
+  setCountryOverride: (code: string, override: { emoji?: string; name?: string }) =>
+    api.put(`/nodes/countries/${code}/override`, override),
   getByCountry: (code: string) => api.get(`/nodes/country/${code}`),
-  parse: (url: string) => api.post('/nodes/parse', { url }),
-  parseBulk: (urls: string[], defaultProtocol?: string) =>
-    api.post('/nodes/parse-bulk', { urls, default_protocol: defaultProtocol }),
-  healthCheck: (tags?: string[]) =>
-    api.post('/nodes/health-check', { tags }, { timeout: 60000 }),
+  parse: (url: string, resolve?: boolean) => api.post('/nodes/parse', { url }, { params: resolve ? { resolve: true } : {} }),
+  reparse: (ids?: number[]) => api.post('/nodes/reparse', { ids }),
+  parseBulk: (urls: string[], defaultProtocol?: string, dedup?: boolean, resolve?: boolean) =>
+    api.post('/nodes/parse-bulk', { urls, default_protocol: defaultProtocol }, {
+      params: { ...(dedup ? { dedup: true } : {}), ...(resolve ? { resolve: true } : {}) },
+    }),
+  healthCheck: (tags?: string[], scope?: { country?: string; group?: string }) =>
+    api.post('/nodes/health-check', { tags, ...scope }, { timeout: 60000 }),
+  // Async variants return a job_id immediately; poll jobApi.get for progress and result
+  healthCheckAsync: (tags?: string[], scope?: { country?: string; group?: string }) =>
+    api.post('/nodes/health-check', { tags, ...scope }, { params: { async: true } }),
   healthCheckSingle: (tag: string) =>
     api.post('/nodes/health-check-single', { tag, internal_tag: tag }, { timeout: 15000 }),
+  // Requires the debug API; returns the sing-box debug log of one connection attempt
+  debugConnect: (tag: string, url?: string) =>
+    api.post(`/nodes/${encodeURIComponent(tag)}/debug-connect`, { url }, { timeout: 30000 }),
+  importFile: (file: File, groupTag?: string, resolve?: boolean) => {
+    const form = new FormData();
+    form.append('file', file);
+    if (groupTag) form.append('group_tag', groupTag);
+    return api.post('/nodes/import-file', form, { params: resolve ? { resolve: true } : {} });
+  },
+  importFileAsync: (file: File, groupTag?: string, resolve?: boolean) => {
+    const form = new FormData();
+    form.append('file', file);
+    if (groupTag) form.append('group_tag', groupTag);
+    return api.post('/nodes/import-file', form, { params: { async: true, ...(resolve ? { resolve: true } : {}) } });
+  },
+  // Imports every share link, base64 subscription block and sing-box outbound found in pasted text
+  smartImport: (text: string, groupTag?: string) =>
+    api.post('/nodes/smart-import', { text, group_tag: groupTag }),
+  tcpPing: (tags?: string[]) =>
+    api.post('/nodes/tcp-ping', { tags }, { timeout: 60000 }),
   siteCheck: (tags?: string[], sites?: string[]) =>
     api.post('/nodes/site-check', { tags, sites }, { timeout: 180000 }),
+  fullCheck: (tags?: string[], sites?: string[]) =>
+    api.post('/nodes/full-check', { tags, sites }, { timeout: 240000 }),
+  fullCheckAsync: (tags?: string[], sites?: string[]) =>
+    api.post('/nodes/full-check', { tags, sites }, { params: { async: true } }),
   speedTest: (tags?: string[], signal?: AbortSignal) =>
     api.post('/nodes/speed-test', { tags }, { timeout: 600000, signal }),
   getGeoData: () => api.get('/nodes/geo'),
   getNodeGeo: (server: string, port: number) =>
     api.get(`/nodes/geo/${encodeURIComponent(server)}/${port}`),
-  geoCheck: (tags?: string[]) =>
-    api.post('/nodes/geo-check', { tags }, { timeout: 300000 }),
+  geoCheck: (tags?: string[], egress?: boolean) =>
+    api.post('/nodes/geo-check', { tags }, { timeout: 300000, params: egress ? { egress: true } : {} }),
   getUnsupported: () => api.get('/nodes/unsupported'),
   recheckUnsupported: () => api.post('/nodes/unsupported/recheck'),
+  recheckUnsupportedAsync: () => api.post('/nodes/unsupported/recheck', undefined, { params: { async: true } }),
+  reconcileUnsupported: () => api.post('/nodes/unsupported/reconcile'),
   clearUnsupported: () => api.delete('/nodes/unsupported'),
   deleteUnsupported: (tags?: string[]) => api.post('/nodes/unsupported/delete', { tags }),
 };
@@ -113,10 +184,18 @@ export const unifiedNodeApi = {
   bulkPromote: (ids: number[]) => api.post('/nodes/unified/bulk-promote', { ids }),
   bulkArchive: (ids: number[]) => api.post('/nodes/unified/bulk-archive', { ids }),
   bulkUnarchive: () => api.post('/nodes/unified/bulk-unarchive'),
+  deleteOldArchived: (olderThanDays: number) =>
+    api.delete('/nodes/unified/archived', { params: { older_than_days: olderThanDays } }),
   getCounts: () => api.get('/nodes/unified/counts'),
   exportLinks: (ids?: number[], status?: string) =>
     api.post('/nodes/unified/export-links', { ids, status }),
   toggleFavorite: (id: number, favorite: boolean) => api.post(`/nodes/unified/${id}/favorite`, { favorite }),
+  togglePin: (id: number, pinned: boolean) => api.post(`/nodes/unified/${id}/pin`, { pinned }),
+  // Excluded nodes stay in the Proxy selector but leave the Auto and country urltest groups
+  toggleExcludeFromAuto: (id: number, exclude: boolean) =>
+    api.post(`/nodes/unified/${id}/exclude-from-auto`, { exclude }),
+  setCountry: (id: number, country: string, locked: boolean) =>
+    api.put(`/nodes/unified/${id}/country`, { country, locked }),
 };
 
 // Verification API
@@ -128,6 +207,8 @@ export const verificationApi = {
   getStatus: () => api.get('/verification/status'),
   start: () => api.post('/verification/start'),
   stop: () => api.post('/verification/stop'),
+  pause: (reason: string, minutes: number) => api.post('/verification/pause', { reason, minutes }),
+  resume: () => api.post('/verification/resume'),
 };
 
 export const pipelineApi = {
@@ -138,14 +219,27 @@ export const pipelineApi = {
 export const kernelApi = {
   getInfo: () => api.get('/kernel/info'),
   getReleases: () => api.get('/kernel/releases'),
-  download: (version: string) => api.post('/kernel/download', { version }),
+  download: (version: string, confirm = false) => api.post('/kernel/download', { version, confirm }),
+  cancelDownload: () => api.post('/kernel/download/cancel'),
   getProgress: () => api.get('/kernel/progress'),
 };
 
+// Clash dashboard API
+export const clashUIApi = {
+  install: (dashboard: 'yacd' | 'metacubexd') => api.post('/clash-ui/install', undefined, { params: { dashboard } }),
+  getProgress: () => api.get('/clash-ui/progress'),
+};
+
+// Async job API
+export const jobApi = {
+  get: (id: string) => api.get(`/jobs/${id}`),
+};
+
 // Probe API
 export const probeApi = {
   status: () => api.get('/probe/status'),
   stop: () => api.post('/probe/stop'),
+  preview: (tags?: string[]) => api.post('/probe/preview', { tags }),
 };
 
 // Proxy group API (Clash API proxy)
@@ -154,6 +248,7 @@ export const proxyApi = {
   switchGroup: (group: string, selected: string) =>
     api.put(`/proxy/groups/${encodeURIComponent(group)}`, { name: selected }),
   checkDelay: (name: string) => api.get(`/proxy/delay/${encodeURIComponent(name)}`),
+  getPublicIP: (refresh = false) => api.get('/proxy/public-ip', { params: refresh ? { refresh: true } : {} }),
 };
 
 // Proxy mode API
@@ -167,9 +262,14 @@ export const monitoringApi = {
   getOverview: () => api.get('/monitoring/overview'),
   getLifetime: () => api.get('/monitoring/lifetime'),
   getHistory: (limit: number = 120, hours?: number) => api.get('/monitoring/history', { params: { limit, hours: hours || undefined } }),
+  historyCsvUrl: (hours: number = 24) => `/api/monitoring/history.csv?hours=${hours}`,
   getClients: (limit: number = 200) => api.get('/monitoring/clients', { params: { limit } }),
   getRecentClients: (limit: number = 300, hours: number = 24) =>
     api.get('/monitoring/clients/recent', { params: { limit, hours } }),
+  getClientQuotas: () => api.get('/monitoring/clients/quota'),
+  setClientQuota: (data: { source_ip: string; daily_bytes: number; monthly_bytes: number; alert: boolean }) =>
+    api.put('/monitoring/clients/quota', data),
+  deleteClientQuota: (sourceIp: string) => api.delete(`/monitoring/clients/quota/${encodeURIComponent(sourceIp)}`),
   getResources: (limit: number = 300, sourceIP?: string) =>
     api.get('/monitoring/resources', { params: { limit, source_ip: sourceIP || undefined } }),
   getClientHistory: (
@@ -186,8 +286,12 @@ export const monitoringApi = {
     }),
   getNodeTraffic: (limit: number = 100, hours: number = 0) =>
     api.get('/monitoring/nodes', { params: { limit, hours } }),
+  getTrafficByCountry: (hours?: number) =>
+    api.get('/monitoring/traffic/by-country', { params: { hours } }),
   getClientResourcesHistory: (sourceIP: string, limit: number = 500) =>
     api.get(`/monitoring/clients/${encodeURIComponent(sourceIP)}/resources/history`, { params: { limit } }),
+  getConnectionLog: (params: { host?: string; source_ip?: string; since?: string; until?: string; limit?: number }) =>
+    api.get('/monitoring/connections/log', { params }),
 };
 
 // Database API
@@ -202,6 +306,7 @@ export const databaseApi = {
       timeout: 60000,
     });
   },
+  vacuum: () => api.post('/database/vacuum', undefined, { timeout: 120000 }),
 };
 
 // Debug API
@@ -223,12 +328,15 @@ export const measurementApi = {
     api.get('/measurements/health/stats/bulk', { params: { days: days || 7 } }),
   getSite: (server: string, port: number, limit?: number) =>
     api.get('/measurements/site', { params: { server, port, limit } }),
+  getSiteMatrix: () => api.get('/measurements/site/matrix'),
   getLatestSpeed: () => api.get('/measurements/speed/latest'),
 };
 
 // Diagnostic API
 export const diagnosticApi = {
   getAll: () => api.get('/diagnostic'),
+  // Small status for widgets: running, active node, bps, node counts, unapplied changes
+  compact: () => api.get('/status'),
 };
 
 export default api;
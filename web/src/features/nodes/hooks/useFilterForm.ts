@@ -1,6 +1,7 @@
 import { useState } from 'react';
 import { useDisclosure } from '@nextui-org/react';
 import { useStore } from '../../../store';
+import { filterApi } from '../../../api';
 import type { Filter } from '../../../store';
 
 const defaultFilterForm: Omit<Filter, 'id'> = {
@@ -27,10 +28,13 @@ export function useFilterForm() {
   const [editingFilter, setEditingFilter] = useState<Filter | null>(null);
   const [filterForm, setFilterForm] = useState<Omit<Filter, 'id'>>(defaultFilterForm);
   const [isSubmitting, setIsSubmitting] = useState(false);
+  const [previewTags, setPreviewTags] = useState<string[] | null>(null);
+  const [isPreviewing, setIsPreviewing] = useState(false);
 
   const handleOpenAdd = () => {
     setEditingFilter(null);
     setFilterForm(defaultFilterForm);
+    setPreviewTags(null);
     onOpen();
   };
 
@@ -52,6 +56,7 @@ export function useFilterForm() {
       all_nodes: filter.all_nodes ?? true,
       enabled: filter.enabled,
     });
+    setPreviewTags(null);
     onOpen();
   };
 
@@ -73,6 +78,18 @@ export function useFilterForm() {
     }
   };
 
+  const handlePreview = async () => {
+    setIsPreviewing(true);
+    try {
+      const res = await filterApi.preview(filterForm);
+      setPreviewTags(res.data.data || []);
+    } catch (error) {
+      console.error('Failed to preview filter:', error);
+    } finally {
+      setIsPreviewing(false);
+    }
+  };
+
   return {
     isOpen,
     onClose,
@@ -83,5 +100,8 @@ export function useFilterForm() {
     handleOpenAdd,
     handleOpenEdit,
     onSave: handleSave,
+    previewTags,
+    isPreviewing,
+    onPreview: handlePreview,
   };
 }
@@ -89,6 +89,7 @@ export function useNodeForm() {
         country: nodeForm.country,
         country_emoji: country?.emoji || '🌐',
         extra: nodeForm.extra,
+        source_url: nodeForm.source_url,
       };
 
       if (editingNode) {
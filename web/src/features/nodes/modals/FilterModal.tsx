@@ -11,6 +11,7 @@ import {
   Switch,
   Card,
   CardBody,
+  Chip,
 } from '@nextui-org/react';
 import type { Filter } from '../../../store';
 import { countryOptions } from '../types';
@@ -23,6 +24,9 @@ interface FilterModalProps {
   setFilterForm: (form: Omit<Filter, 'id'>) => void;
   isSubmitting: boolean;
   onSave: () => void;
+  previewTags: string[] | null;
+  isPreviewing: boolean;
+  onPreview: () => void;
 }
 
 export default function FilterModal({
@@ -33,6 +37,9 @@ export default function FilterModal({
   setFilterForm,
   isSubmitting,
   onSave,
+  previewTags,
+  isPreviewing,
+  onPreview,
 }: FilterModalProps) {
   return (
     <Modal isOpen={isOpen} onClose={onClose} size="2xl">
@@ -175,12 +182,32 @@ export default function FilterModal({
                 onValueChange={(checked) => setFilterForm({ ...filterForm, enabled: checked })}
               />
             </div>
+
+            {previewTags && (
+              <Card className="bg-default-50">
+                <CardBody className="space-y-2">
+                  <h4 className="font-medium text-sm">Matching Nodes ({previewTags.length})</h4>
+                  {previewTags.length === 0 ? (
+                    <p className="text-xs text-gray-400">No nodes match this filter</p>
+                  ) : (
+                    <div className="flex flex-wrap gap-1 max-h-40 overflow-y-auto">
+                      {previewTags.map((tag) => (
+                        <Chip key={tag} size="sm" variant="flat">{tag}</Chip>
+                      ))}
+                    </div>
+                  )}
+                </CardBody>
+              </Card>
+            )}
           </div>
         </ModalBody>
         <ModalFooter>
           <Button variant="flat" onPress={onClose}>
             Cancel
           </Button>
+          <Button variant="flat" onPress={onPreview} isLoading={isPreviewing}>
+            Preview
+          </Button>
           <Button
             color="primary"
             onPress={onSave}
@@ -15,7 +15,7 @@ import {
   Button,
   Tooltip,
 } from '@nextui-org/react';
-import { Search, Activity, Trash2, ArrowUpCircle, Archive, Pencil, Star } from 'lucide-react';
+import { Search, Activity, Trash2, ArrowUpCircle, Archive, Pencil, Star, Pin } from 'lucide-react';
 // Activity is used for empty state icon
 import type { UnifiedNode, NodeHealthResult, HealthCheckMode, NodeSiteCheckResult, SpeedTestResult, GeoData } from '../../../store';
 import { nodeDisplayTag, nodeInternalTag, nodeSourceTag } from '../../../store';
@@ -45,6 +45,7 @@ interface PendingNodesTabProps {
   onDelete: (id: number) => void;
   onEdit: (node: UnifiedNode) => void;
   onToggleFavorite: (id: number) => void;
+  onTogglePin: (id: number) => void;
   nodeTrafficMap?: Map<string, NodeTrafficRow>;
   onBulkPromote: (ids: number[]) => void;
   onBulkArchive: (ids: number[]) => void;
@@ -66,6 +67,7 @@ export default function PendingNodesTab({
   onDelete,
   onEdit,
   onToggleFavorite,
+  onTogglePin,
   nodeTrafficMap,
   onBulkPromote,
   onBulkArchive,
@@ -376,6 +378,11 @@ export default function PendingNodesTab({
                           {nodeSourceTag(node)}
                         </span>
                       )}
+                      {node.missing_since && (
+                        <span className="text-xs text-warning truncate block" title={`Since ${new Date(node.missing_since).toLocaleString()}`}>
+                          No longer in subscription
+                        </span>
+                      )}
                     </div>
                   </TableCell>
                   <TableCell>
@@ -424,6 +431,16 @@ export default function PendingNodesTab({
                           <Star className={`w-4 h-4 ${node.is_favorite ? 'fill-yellow-400 text-yellow-400' : ''}`} />
                         </Button>
                       </Tooltip>
+                      <Tooltip content={node.pinned ? "Unpin (allow auto-archive)" : "Pin (never auto-archive, always in config)"}>
+                        <Button
+                          isIconOnly
+                          size="sm"
+                          variant="light"
+                          onPress={() => onTogglePin(node.id)}
+                        >
+                          <Pin className={`w-4 h-4 ${node.pinned ? 'fill-primary text-primary' : ''}`} />
+                        </Button>
+                      </Tooltip>
                       <Tooltip content="Promote to Verified">
                         <Button
                           isIconOnly
@@ -14,7 +14,7 @@ import {
   Button,
   Tooltip,
 } from '@nextui-org/react';
-import { Search, Activity, Trash2, ArrowDownCircle, Pencil, Star, Copy, Gauge, X } from 'lucide-react';
+import { Search, Activity, Trash2, ArrowDownCircle, Pencil, Star, Pin, Copy, Gauge, X } from 'lucide-react';
 // Activity is used for empty state icon
 import type { UnifiedNode, GeoData } from '../../../store';
 import type { NodeHealthResult, HealthCheckMode, NodeSiteCheckResult, SpeedTestResult } from '../../../store';
@@ -51,6 +51,7 @@ interface VerifiedNodesTabProps {
   onEdit: (node: UnifiedNode) => void;
   nodeTrafficMap?: Map<string, NodeTrafficRow>;
   onToggleFavorite: (id: number) => void;
+  onTogglePin: (id: number) => void;
 }
 
 export default function VerifiedNodesTab({
@@ -73,6 +74,7 @@ export default function VerifiedNodesTab({
   onDelete,
   onEdit,
   onToggleFavorite,
+  onTogglePin,
   nodeTrafficMap,
 }: VerifiedNodesTabProps) {
   const isMobile = useIsMobile();
@@ -340,6 +342,11 @@ export default function VerifiedNodesTab({
                           {nodeSourceTag(node)}
                         </span>
                       )}
+                      {node.missing_since && (
+                        <span className="text-xs text-warning truncate block" title={`Since ${new Date(node.missing_since).toLocaleString()}`}>
+                          No longer in subscription
+                        </span>
+                      )}
                     </div>
                   </TableCell>
                   <TableCell>
@@ -441,6 +448,16 @@ export default function VerifiedNodesTab({
                           <Star className={`w-4 h-4 ${node.is_favorite ? 'fill-yellow-400 text-yellow-400' : ''}`} />
                         </Button>
                       </Tooltip>
+                      <Tooltip content={node.pinned ? "Unpin (allow auto-archive)" : "Pin (never auto-archive, always in config)"}>
+                        <Button
+                          isIconOnly
+                          size="sm"
+                          variant="light"
+                          onPress={() => onTogglePin(node.id)}
+                        >
+                          <Pin className={`w-4 h-4 ${node.pinned ? 'fill-primary text-primary' : ''}`} />
+                        </Button>
+                      </Tooltip>
                       <Tooltip content="Demote to pending">
                         <Button
                           isIconOnly
@@ -147,6 +147,12 @@ export function useEventStream() {
         useStore.getState().addPipelineEvent('pipeline:stop', 'Pipeline stopped');
       });
 
+      es.addEventListener('pipeline:pause', (e) => {
+        const data = JSON.parse(e.data);
+        useStore.getState().addPipelineEvent('pipeline:pause', `Pipeline paused: ${data.reason}`);
+        useStore.getState().fetchVerificationStatus();
+      });
+
       es.addEventListener('sub:refresh', (e) => {
         const data = JSON.parse(e.data);
         useStore.getState().addPipelineEvent('sub:refresh', `Subscription refreshed: ${data.name} (${data.node_count} nodes)`);
@@ -154,7 +160,7 @@ export function useEventStream() {
 
       es.addEventListener('sub:nodes_synced', (e) => {
         const data = JSON.parse(e.data);
-        useStore.getState().addPipelineEvent('sub:nodes_synced', `Nodes synced: ${data.total} processed, +${data.added} added, ${data.skipped} skipped`);
+        useStore.getState().addPipelineEvent('sub:nodes_synced', `Nodes synced: ${data.total} processed, +${data.added} added, ${data.updated} updated, ${data.unchanged} unchanged, ${data.skipped} skipped`);
       });
 
       es.addEventListener('probe:started', (e) => {
@@ -166,6 +172,25 @@ export function useEventStream() {
         useStore.getState().addPipelineEvent('probe:stopped', 'Probe stopped');
       });
 
+      es.addEventListener('service:drain_start', (e) => {
+        const data = JSON.parse(e.data);
+        useStore.getState().addPipelineEvent('service:drain_start', `Restart waiting up to ${data.timeout_seconds}s for connections to drain`);
+      });
+
+      es.addEventListener('service:drain_complete', (e) => {
+        const data = JSON.parse(e.data);
+        const s = useStore.getState();
+        s.addPipelineEvent('service:drain_complete', data.error ? `Restart after drain failed: ${data.error}` : 'Restarted after draining connections');
+        s.fetchServiceStatus();
+      });
+
+      es.addEventListener('config:auto_apply_complete', (e) => {
+        const data = JSON.parse(e.data);
+        const s = useStore.getState();
+        s.addPipelineEvent('config:auto_apply_complete', data.error ? `Auto-apply failed: ${data.error}` : 'Config auto-applied');
+        s.fetchServiceStatus();
+      });
+
       es.addEventListener('speed:download_progress', (e) => {
         const data = JSON.parse(e.data);
         const tag = typeof data.tag === 'string' ? data.tag : '';
@@ -507,7 +507,7 @@ export default function Dashboard() {
 
   const proxyLinks: { key: string; label: string; link: string }[] = [];
   if (settings) {
-    if (settings.mixed_port > 0 && settings.mixed_address) {
+    if (settings.mixed_enabled && settings.mixed_port > 0 && settings.mixed_address) {
       proxyLinks.push({
         key: 'mixed-socks',
         label: 'Mixed SOCKS5',
@@ -519,7 +519,7 @@ export default function Dashboard() {
         link: `http://${settings.mixed_address}:${settings.mixed_port}`,
       });
     }
-    if (settings.socks_port > 0 && settings.socks_address) {
+    if (settings.socks_enabled && settings.socks_port > 0 && settings.socks_address) {
       const auth = settings.socks_auth && settings.socks_username
         ? `${settings.socks_username}:${settings.socks_password}@`
         : '';
@@ -529,7 +529,7 @@ export default function Dashboard() {
         link: `socks5://${auth}${settings.socks_address}:${settings.socks_port}`,
       });
     }
-    if (settings.http_port > 0 && settings.http_address) {
+    if (settings.http_enabled && settings.http_port > 0 && settings.http_address) {
       const auth = settings.http_auth && settings.http_username
         ? `${settings.http_username}:${settings.http_password}@`
         : '';
@@ -1070,6 +1070,16 @@ export default function Dashboard() {
             >
               {serviceStatus?.running ? 'Running' : 'Stopped'}
             </Chip>
+            {serviceStatus?.safe_mode && (
+              <Tooltip content={<div className="text-xs max-w-sm p-1">{serviceStatus.last_error || 'No stderr output captured'}</div>}>
+                <Chip color="warning" variant="flat" size="sm">Safe Mode</Chip>
+              </Tooltip>
+            )}
+            {serviceStatus?.apply_pending && (
+              <Tooltip content={<div className="text-xs max-w-sm p-1">Config changed during quiet hours, it is applied when they end or on a manual apply</div>}>
+                <Chip color="primary" variant="flat" size="sm">Apply Pending</Chip>
+              </Tooltip>
+            )}
           </div>
           <div className="flex flex-wrap gap-2">
             {serviceStatus?.running ? (
@@ -1104,6 +1114,13 @@ export default function Dashboard() {
               >
                 {verificationStatus?.scheduler_running ? 'Running' : 'Stopped'}
               </Chip>
+              {verificationStatus?.scheduler_paused && (
+                <Chip color="warning" variant="flat" size="sm">
+                  Paused: {verificationStatus.scheduler_paused.reason}
+                  {verificationStatus.scheduler_paused.resume_at &&
+                    ` until ${new Date(verificationStatus.scheduler_paused.resume_at).toLocaleTimeString()}`}
+                </Chip>
+              )}
               {probeStatus?.running && (
                 <Tooltip
                   content={
@@ -18,11 +18,12 @@ interface KernelInfo {
 }
 
 interface DownloadProgress {
-  status: 'idle' | 'preparing' | 'downloading' | 'extracting' | 'installing' | 'completed' | 'error';
+  status: 'idle' | 'preparing' | 'downloading' | 'verifying' | 'extracting' | 'checking' | 'confirm' | 'installing' | 'completed' | 'cancelled' | 'error';
   progress: number;
   message: string;
   downloaded?: number;
   total?: number;
+  impact?: { name: string; error: string }[];
 }
 
 interface GithubRelease {
@@ -161,6 +162,7 @@ export default function Settings() {
 
   // Database state
   const [dbImporting, setDbImporting] = useState(false);
+  const [dbVacuuming, setDbVacuuming] = useState(false);
   const [dbExportSize, setDbExportSize] = useState<string>('');
   const dbFileInputRef = useRef<HTMLInputElement>(null);
 
@@ -410,6 +412,20 @@ export default function Settings() {
     }
   };
 
+  const handleVacuumDatabase = async () => {
+    setDbVacuuming(true);
+    try {
+      const res = await databaseApi.vacuum();
+      const data = res.data?.data;
+      toast.success(`Database compacted: ${data?.before_size_human} → ${data?.after_size_human}`);
+      fetchDatabaseStats();
+    } catch (error: any) {
+      toast.error(error.response?.data?.error || 'Failed to compact database');
+    } finally {
+      setDbVacuuming(false);
+    }
+  };
+
   // ─── Kernel download ─────────────────────────────────────────────
   const openDownloadModal = async () => {
     await fetchReleases();
@@ -417,18 +433,18 @@ export default function Settings() {
     setShowDownloadModal(true);
   };
 
-  const startDownload = async () => {
+  const startDownload = async (force = false) => {
     if (!selectedVersion) return;
     setDownloading(true);
     setDownloadProgress({ status: 'preparing', progress: 0, message: 'Preparing...' });
     try {
-      await kernelApi.download(selectedVersion);
+      await kernelApi.download(selectedVersion, force);
       pollIntervalRef.current = setInterval(async () => {
         try {
           const res = await kernelApi.getProgress();
           const progress = res.data.data;
           setDownloadProgress(progress);
-          if (progress.status === 'completed' || progress.status === 'error') {
+          if (progress.status === 'completed' || progress.status === 'error' || progress.status === 'confirm' || progress.status === 'cancelled') {
             if (pollIntervalRef.current) { clearInterval(pollIntervalRef.current); pollIntervalRef.current = null; }
             setDownloading(false);
             if (progress.status === 'completed') {
@@ -565,6 +581,16 @@ export default function Settings() {
                     description="Accelerate GitHub downloads, leave empty for direct"
                     value={f.github_proxy || ''} onChange={(e) => set({ github_proxy: e.target.value })} />
                 </Field>
+                <ToggleRow label="Keep sing-box Running on Exit" description="Leave sing-box running when the manager stops or quits; turn off to stop it on quit"
+                  isSelected={f.detach_singbox ?? true} onChange={(v) => set({ detach_singbox: v })} />
+                <Field field="drain_timeout_seconds" {...undoProps}>
+                  <Input size="sm" type="number" min={0} max={300} label="Restart Drain Timeout (seconds)" placeholder="0"
+                    description="Wait for active connections to finish before restarting sing-box, 0 = restart at once"
+                    value={String(f.drain_timeout_seconds ?? 0)} onChange={(e) => {
+                      const parsed = parseInt(e.target.value, 10);
+                      set({ drain_timeout_seconds: Number.isFinite(parsed) && parsed >= 0 ? Math.min(parsed, 300) : 0 });
+                    }} />
+                </Field>
                 <div className="grid grid-cols-1 sm:grid-cols-2 gap-3">
                   <Input size="sm" type="number" label="Web Port" placeholder="9090" isDisabled
                     value={String(f.web_port)} onChange={(e) => set({ web_port: parseInt(e.target.value) || 9090 })} />
@@ -586,26 +612,30 @@ export default function Settings() {
           <div className="space-y-3">
             {/* Mixed Inbound */}
             <SectionCard title="Mixed (HTTP+SOCKS5)" description="Combined proxy on a single port">
-              <div className="grid grid-cols-1 sm:grid-cols-2 gap-3">
-                <Field field="mixed_port" {...undoProps}>
-                  <Input size="sm" type="number" label="Port" placeholder="2080" description="0 = disabled"
-                    value={String(f.mixed_port)} onChange={(e) => set({ mixed_port: parseInt(e.target.value) || 0 })} />
-                </Field>
-                <Input size="sm" label="Address" placeholder="example.com" description="External address for links"
-                  value={f.mixed_address || ''} onChange={(e) => set({ mixed_address: e.target.value })} />
+              <div className="space-y-3">
+                <ToggleRow label="Enabled" isSelected={f.mixed_enabled} onChange={(v) => set({ mixed_enabled: v })} />
+                <div className="grid grid-cols-1 sm:grid-cols-2 gap-3">
+                  <Field field="mixed_port" {...undoProps}>
+                    <Input size="sm" type="number" label="Port" placeholder="2080" description="0 = disabled"
+                      value={String(f.mixed_port)} onChange={(e) => set({ mixed_port: parseInt(e.target.value) || 0 })} />
+                  </Field>
+                  <Input size="sm" label="Address" placeholder="example.com" description="External address for links"
+                    value={f.mixed_address || ''} onChange={(e) => set({ mixed_address: e.target.value })} />
+                </div>
               </div>
             </SectionCard>
 
             {/* SOCKS5 */}
             <SectionCard title="SOCKS5">
               <div className="space-y-3">
+                <ToggleRow label="Enabled" isSelected={f.socks_enabled} onChange={(v) => set({ socks_enabled: v })} />
                 <div className="grid grid-cols-1 sm:grid-cols-2 gap-3">
                   <Input size="sm" type="number" label="Port" placeholder="0" description="0 = disabled"
                     value={String(f.socks_port)} onChange={(e) => set({ socks_port: parseInt(e.target.value) || 0 })} />
                   <Input size="sm" label="Address" placeholder="example.com"
                     value={f.socks_address || ''} onChange={(e) => set({ socks_address: e.target.value })} />
                 </div>
-                {f.socks_port > 0 && (
+                {f.socks_enabled && f.socks_port > 0 && (
                   <>
                     <ToggleRow label="Authentication" isSelected={f.socks_auth} onChange={(v) => set({ socks_auth: v })} />
                     {f.socks_auth && (
@@ -622,13 +652,14 @@ export default function Settings() {
             {/* HTTP */}
             <SectionCard title="HTTP">
               <div className="space-y-3">
+                <ToggleRow label="Enabled" isSelected={f.http_enabled} onChange={(v) => set({ http_enabled: v })} />
                 <div className="grid grid-cols-1 sm:grid-cols-2 gap-3">
                   <Input size="sm" type="number" label="Port" placeholder="0" description="0 = disabled"
                     value={String(f.http_port)} onChange={(e) => set({ http_port: parseInt(e.target.value) || 0 })} />
                   <Input size="sm" label="Address" placeholder="example.com"
                     value={f.http_address || ''} onChange={(e) => set({ http_address: e.target.value })} />
                 </div>
-                {f.http_port > 0 && (
+                {f.http_enabled && f.http_port > 0 && (
                   <>
                     <ToggleRow label="Authentication" isSelected={f.http_auth} onChange={(v) => set({ http_auth: v })} />
                     {f.http_auth && (
@@ -773,7 +804,27 @@ export default function Settings() {
             {/* Traffic mode toggles */}
             <SectionCard title="Traffic Mode">
               <div className="space-y-1 divide-y divide-default-100">
-                <ToggleRow label="TUN Mode" description="Transparent proxying for all traffic" isSelected={f.tun_enabled} onChange={(v) => set({ tun_enabled: v })} />
+                <ToggleRow label="TUN Mode" description="Transparent proxying for all traffic" isSelected={f.tun_enabled} onChange={(v) => set({ tun_enabled: v })}>
+                  {f.tun_enabled && (
+                    <div className="mt-2 grid grid-cols-1 sm:grid-cols-2 gap-3">
+                      <Select size="sm" label="TUN Stack" description="Try gvisor if the system stack fails to create the interface"
+                        selectedKeys={[f.tun_stack || 'mixed']}
+                        onSelectionChange={(keys) => { const s = Array.from(keys)[0] as SettingsType['tun_stack']; if (s) set({ tun_stack: s }); }}>
+                        {['system', 'gvisor', 'mixed'].map((stack) => (
+                          <SelectItem key={stack}>{stack}</SelectItem>
+                        ))}
+                      </Select>
+                      <Input size="sm" type="number" label="TUN MTU" placeholder="default" description="576-65535, empty for default"
+                        value={f.tun_mtu ? String(f.tun_mtu) : ''} onChange={(e) => set({ tun_mtu: parseInt(e.target.value) || 0 })} />
+                      <Textarea size="sm" label="Include Routes" placeholder={"One CIDR per line\nempty = all traffic"} minRows={2}
+                        description="Only these destinations are captured"
+                        value={(f.tun_include_routes ?? []).join('\n')} onChange={(e) => set({ tun_include_routes: e.target.value.split('\n') })} />
+                      <Textarea size="sm" label="Exclude Routes" placeholder={"One CIDR per line\n172.17.0.0/16"} minRows={2}
+                        description="Left to Docker networks and other tunnels"
+                        value={(f.tun_exclude_routes ?? []).join('\n')} onChange={(e) => set({ tun_exclude_routes: e.target.value.split('\n') })} />
+                    </div>
+                  )}
+                </ToggleRow>
                 <ToggleRow
                   label="Allow LAN Access"
                   description="Other devices can use this proxy"
@@ -823,15 +874,57 @@ export default function Settings() {
                     </div>
                   )}
                 </ToggleRow>
+                <div className="pt-2">
+                  <Field field="listen_address" {...undoProps}>
+                    <Input size="sm" label="Listen Address" placeholder={f.allow_lan ? '0.0.0.0' : '127.0.0.1'}
+                      description="Bind inbounds to a single interface IP, empty to follow Allow LAN Access"
+                      value={f.listen_address || ''} onChange={(e) => set({ listen_address: e.target.value })} />
+                  </Field>
+                </div>
               </div>
             </SectionCard>
 
             {/* Outbound */}
             <SectionCard title="Outbound">
-              <Field field="final_outbound" {...undoProps}>
-                <Input size="sm" label="Final Outbound" placeholder="Proxy"
-                  value={f.final_outbound} onChange={(e) => set({ final_outbound: e.target.value })} />
-              </Field>
+              <div className="space-y-3">
+                <Field field="final_outbound" {...undoProps}>
+                  <Input size="sm" label="Final Outbound" placeholder="Proxy"
+                    value={f.final_outbound} onChange={(e) => set({ final_outbound: e.target.value })} />
+                </Field>
+                <Field field="no_nodes_mode" {...undoProps}>
+                  <Select size="sm" label="When No Nodes Are Available" description="direct keeps LAN clients working through DIRECT; reject refuses internet traffic instead of letting it leave unproxied, LAN destinations stay reachable"
+                    selectedKeys={[f.no_nodes_mode || 'direct']}
+                    onSelectionChange={(keys) => { const m = Array.from(keys)[0] as SettingsType['no_nodes_mode']; if (m) set({ no_nodes_mode: m }); }}>
+                    {['direct', 'reject'].map((m) => (
+                      <SelectItem key={m}>{m}</SelectItem>
+                    ))}
+                  </Select>
+                </Field>
+                <Field field="default_utls_fingerprint" {...undoProps}>
+                  <Select size="sm" label="Default uTLS Fingerprint" description="Applied to TLS nodes that do not set their own fingerprint"
+                    selectedKeys={[f.default_utls_fingerprint || 'none']}
+                    onSelectionChange={(keys) => { const fp = Array.from(keys)[0] as string; if (fp) set({ default_utls_fingerprint: fp === 'none' ? '' : fp }); }}>
+                    {['none', 'chrome', 'firefox', 'safari', 'ios', 'android', 'edge', 'random', 'randomized'].map((fp) => (
+                      <SelectItem key={fp}>{fp}</SelectItem>
+                    ))}
+                  </Select>
+                </Field>
+                <ToggleRow label="Multiplex" description="Enable multiplex on Shadowsocks, Trojan, VMess and VLESS nodes that do not set it; the server must support it"
+                  isSelected={!!f.multiplex_enabled} onChange={(v) => set({ multiplex_enabled: v })} />
+                <ToggleRow label="TCP Fast Open" description="Use TFO on TCP-based nodes that do not set it; nodes with tfo=0 in their link stay off"
+                  isSelected={!!f.tcp_fast_open} onChange={(v) => set({ tcp_fast_open: v })} />
+                <ToggleRow label="UDP fragmentation" description="Allow fragmented UDP on Shadowsocks, SOCKS, Hysteria, TUIC and WireGuard nodes that do not set it; nodes with udp_fragment=0 in their link stay off"
+                  isSelected={!!f.udp_fragment} onChange={(v) => set({ udp_fragment: v })} />
+                <ToggleRow label="UDP over TCP" description="Tunnel UDP over TCP on Shadowsocks nodes that do not set it and use no multiplex; the server must support it"
+                  isSelected={!!f.udp_over_tcp} onChange={(v) => set({ udp_over_tcp: v })} />
+                <ToggleRow label="Block QUIC" description="Reject QUIC so browsers fall back to TCP/TLS; helps when YouTube or Google misbehave over proxied QUIC"
+                  isSelected={!!f.block_quic} onChange={(v) => set({ block_quic: v })} />
+                <Field field="direct_processes" {...undoProps}>
+                  <Textarea size="sm" label="Direct Applications" placeholder={"One process name or absolute path per line\nrestic"} minRows={2}
+                    description="Always routed to DIRECT regardless of destination; desktop platforms only"
+                    value={(f.direct_processes ?? []).join('\n')} onChange={(e) => set({ direct_processes: e.target.value.split('\n') })} />
+                </Field>
+              </div>
             </SectionCard>
 
             {/* Blocked Countries */}
@@ -873,6 +966,18 @@ export default function Settings() {
                 isSelected={f.auto_apply}
                 onChange={(v) => set({ auto_apply: v })}
               />
+              {f.auto_apply && (
+                <div className="grid grid-cols-1 sm:grid-cols-2 gap-3">
+                  <Field field="quiet_hours" {...undoProps}>
+                    <Input size="sm" type="time" label="Quiet Hours Start"
+                      description="Config is written but sing-box is not restarted"
+                      value={f.quiet_hours?.start ?? ''} onChange={(e) => set({ quiet_hours: { start: e.target.value, end: f.quiet_hours?.end ?? '' } })} />
+                  </Field>
+                  <Input size="sm" type="time" label="Quiet Hours End"
+                    description="Deferred changes are applied at this time"
+                    value={f.quiet_hours?.end ?? ''} onChange={(e) => set({ quiet_hours: { start: f.quiet_hours?.start ?? '', end: e.target.value } })} />
+                </div>
+              )}
               <div className="border-t border-default-100 pt-3">
                 <div className="grid grid-cols-1 sm:grid-cols-2 gap-3">
                   <Field field="subscription_interval" {...undoProps}>
@@ -886,8 +991,71 @@ export default function Settings() {
                         set({ archive_threshold: Number.isFinite(parsed) && parsed > 0 ? parsed : 10 });
                       }} />
                   </Field>
+                  <Field field="health_retention_days" {...undoProps}>
+                    <Input size="sm" type="number" min={0} label="Health History (days)" placeholder="30"
+                      description="Older checks are kept as daily rollups, 0 = keep all"
+                      value={String(f.health_retention_days ?? 30)} onChange={(e) => {
+                        const parsed = parseInt(e.target.value, 10);
+                        set({ health_retention_days: Number.isFinite(parsed) && parsed >= 0 ? parsed : 0 });
+                      }} />
+                  </Field>
+                  <Field field="score_weight_latency" {...undoProps}>
+                    <Input size="sm" type="number" min={0} label="Score Weight: Latency" placeholder="40"
+                      description="Relative weight in the node quality score"
+                      value={String(f.score_weight_latency ?? 40)} onChange={(e) => {
+                        const parsed = parseInt(e.target.value, 10);
+                        set({ score_weight_latency: Number.isFinite(parsed) && parsed >= 0 ? parsed : 0 });
+                      }} />
+                  </Field>
+                  <Field field="score_weight_uptime" {...undoProps}>
+                    <Input size="sm" type="number" min={0} label="Score Weight: Uptime" placeholder="40"
+                      description="Relative weight in the node quality score"
+                      value={String(f.score_weight_uptime ?? 40)} onChange={(e) => {
+                        const parsed = parseInt(e.target.value, 10);
+                        set({ score_weight_uptime: Number.isFinite(parsed) && parsed >= 0 ? parsed : 0 });
+                      }} />
+                  </Field>
+                  <Field field="score_weight_sites" {...undoProps}>
+                    <Input size="sm" type="number" min={0} label="Score Weight: Sites" placeholder="20"
+                      description="Relative weight in the node quality score"
+                      value={String(f.score_weight_sites ?? 20)} onChange={(e) => {
+                        const parsed = parseInt(e.target.value, 10);
+                        set({ score_weight_sites: Number.isFinite(parsed) && parsed >= 0 ? parsed : 0 });
+                      }} />
+                  </Field>
                 </div>
               </div>
+              <div className="border-t border-default-100 pt-3">
+                <div className="grid grid-cols-1 sm:grid-cols-2 gap-3">
+                  <Field field="urltest_url" {...undoProps}>
+                    <Input size="sm" label="Latency Test URL" placeholder="https://www.gstatic.com/generate_204"
+                      description="Used by urltest groups and delay checks"
+                      value={f.urltest_url || ''} onChange={(e) => set({ urltest_url: e.target.value })} />
+                  </Field>
+                  <Field field="urltest_expected_status" {...undoProps}>
+                    <Input size="sm" label="Expected Status" placeholder="any"
+                      description="e.g. 200/204 or 200-299"
+                      value={f.urltest_expected_status || ''} onChange={(e) => set({ urltest_expected_status: e.target.value })} />
+                  </Field>
+                  <Field field="udp_check_target" {...undoProps}>
+                    <Input size="sm" label="UDP Test Target" placeholder="1.1.1.1:53"
+                      description="DNS or UDP echo host:port for Hysteria, TUIC and WireGuard checks"
+                      value={f.udp_check_target || ''} onChange={(e) => set({ udp_check_target: e.target.value })} />
+                  </Field>
+                </div>
+              </div>
+              <div className="border-t border-default-100 pt-3 space-y-3">
+                <Field field="webhook_url" {...undoProps}>
+                  <Input size="sm" label="Health-check Webhook URL" placeholder="https://ntfy.sh/my-topic"
+                    description="POST a JSON summary after each verification run or bulk health check, empty to disable"
+                    value={f.webhook_url || ''} onChange={(e) => set({ webhook_url: e.target.value })} />
+                </Field>
+                <Field field="webhook_secret" {...undoProps}>
+                  <Input size="sm" type="password" label="Webhook Secret" placeholder="optional"
+                    description="Signs the body with HMAC-SHA256 in the X-Signature-256 header"
+                    value={f.webhook_secret || ''} onChange={(e) => set({ webhook_secret: e.target.value })} />
+                </Field>
+              </div>
             </div>
           </SectionCard>
         </Tab>
@@ -909,6 +1077,10 @@ export default function Settings() {
                   isLoading={dbImporting} onPress={() => dbFileInputRef.current?.click()} className="h-8">
                   Import
                 </Button>
+                <Button size="sm" variant="flat" startContent={<RefreshCw className="w-3.5 h-3.5" />}
+                  isLoading={dbVacuuming} onPress={handleVacuumDatabase} className="h-8">
+                  Compact
+                </Button>
                 <input ref={dbFileInputRef} type="file" accept=".db,.sqlite,.sqlite3" className="hidden" onChange={handleImportDatabase} />
               </div>
               <div className="p-2 bg-warning-50/60 dark:bg-warning-900/10 rounded-lg">
@@ -1001,12 +1173,23 @@ export default function Settings() {
                 <p className={`text-xs ${downloadProgress.status === 'error' ? 'text-danger' : downloadProgress.status === 'completed' ? 'text-success' : 'text-default-500'}`}>
                   {downloadProgress.message}
                 </p>
+                {downloadProgress.status === 'confirm' && downloadProgress.impact && downloadProgress.impact.length > 0 && (
+                  <ul className="text-xs text-warning space-y-0.5 max-h-32 overflow-y-auto">
+                    {downloadProgress.impact.map((item) => (
+                      <li key={item.name} title={item.error}>{item.name}</li>
+                    ))}
+                  </ul>
+                )}
               </div>
             )}
           </ModalBody>
           <ModalFooter className="pt-2">
             <Button size="sm" variant="flat" onPress={() => setShowDownloadModal(false)} isDisabled={downloading}>Cancel</Button>
-            <Button size="sm" color="primary" onPress={startDownload} isLoading={downloading} isDisabled={!selectedVersion || downloading}>Download</Button>
+            {downloadProgress?.status === 'confirm' ? (
+              <Button size="sm" color="warning" onPress={() => startDownload(true)} isLoading={downloading} isDisabled={downloading}>Install Anyway</Button>
+            ) : (
+              <Button size="sm" color="primary" onPress={() => startDownload()} isLoading={downloading} isDisabled={!selectedVersion || downloading}>Download</Button>
+            )}
           </ModalFooter>
         </ModalContent>
       </Modal>
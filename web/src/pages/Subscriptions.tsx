@@ -61,6 +61,7 @@ export default function Subscriptions() {
     bulkArchiveNodes,
     bulkUnarchiveNodes,
     toggleFavorite,
+    togglePin,
     deleteFilter,
     toggleFilter,
     healthResults,
@@ -248,6 +249,7 @@ export default function Subscriptions() {
             onDelete={(id) => { if (confirm('Delete this node?')) deleteNode(id); }}
             onEdit={handleEditNode}
             onToggleFavorite={toggleFavorite}
+            onTogglePin={togglePin}
             onBulkPromote={bulkPromoteNodes}
             onBulkArchive={bulkArchiveNodes}
           />
@@ -275,6 +277,7 @@ export default function Subscriptions() {
             onDelete={(id) => { if (confirm('Delete this node?')) deleteNode(id); }}
             onEdit={handleEditNode}
             onToggleFavorite={toggleFavorite}
+            onTogglePin={togglePin}
           />
         </Tab>
 
@@ -366,6 +369,9 @@ export default function Subscriptions() {
         setFilterForm={filterForm.setFilterForm}
         isSubmitting={filterForm.isSubmitting}
         onSave={filterForm.onSave}
+        previewTags={filterForm.previewTags}
+        isPreviewing={filterForm.isPreviewing}
+        onPreview={filterForm.onPreview}
       />
 
       <ExportModal
@@ -139,6 +139,11 @@ export interface UnifiedNode {
   promoted_at?: string;
   archived_at?: string;
   is_favorite?: boolean;
+  pinned?: boolean;
+  country_locked?: boolean;
+  exclude_from_auto?: boolean; // Left out of the Auto and country urltest groups
+  source_url?: string;
+  missing_since?: string; // Set while the subscription no longer lists the node
 }
 
 export interface NodeCounts {
@@ -166,6 +171,11 @@ export interface VerificationStatus {
   next_run_at?: string;
   node_counts: NodeCounts;
   scheduler_running: boolean;
+  scheduler_paused?: {
+    reason: string;
+    paused_at: string;
+    resume_at?: string;
+  } | null;
   verification_in_progress?: boolean;
   sub_update_enabled: boolean;
   sub_update_interval_min: number;
@@ -182,6 +192,8 @@ export interface Subscription {
   id: string;
   name: string;
   url: string;
+  auth_header?: string;
+  auth_value?: string;
   node_count: number;
   updated_at: string;
   expire_at?: string;
@@ -192,6 +204,10 @@ export interface Subscription {
   };
   nodes: Node[];
   enabled: boolean;
+  auto_pipeline: boolean;
+  pipeline_group_tag: string;
+  pipeline_min_stability: number;
+  pipeline_remove_dead: boolean;
 }
 
 export interface Node {
@@ -205,6 +221,7 @@ export interface Node {
   country?: string;
   country_emoji?: string;
   extra?: Record<string, any>;
+  source_url?: string;
 }
 
 
@@ -245,18 +262,29 @@ export interface HostEntry {
 export interface Settings {
   singbox_path: string;
   config_path: string;
+  detach_singbox?: boolean;      // Leave sing-box running when the manager exits
+  drain_timeout_seconds?: number; // Wait for connections to finish before a restart, 0 = restart at once
   mixed_port: number;
+  mixed_enabled: boolean;          // Mixed inbound on/off, keeps the port when off
   mixed_address: string;
   tun_enabled: boolean;
   allow_lan: boolean;              // Allow LAN access
+  listen_address?: string;         // Inbound bind IP, overrides the Allow LAN address when set
+  ipv6_enabled?: boolean;          // IPv6 TUN address, FakeIP range and AAAA answers
+  tun_stack?: 'system' | 'gvisor' | 'mixed'; // TUN network stack
+  tun_mtu?: number;                // TUN interface MTU, 0 for the sing-box default
+  tun_include_routes?: string[];   // CIDRs captured by TUN, empty for all traffic
+  tun_exclude_routes?: string[];   // CIDRs left out of TUN (Docker, other VPNs)
 
   socks_port: number;
+  socks_enabled: boolean;
   socks_address: string;
   socks_auth: boolean;
   socks_username: string;
   socks_password: string;
 
   http_port: number;
+  http_enabled: boolean;
   http_address: string;
   http_auth: boolean;
   http_username: string;
@@ -274,15 +302,42 @@ export interface Settings {
   clash_ui_path: string;
   clash_api_secret: string;        // ClashAPI secret
   final_outbound: string;
+  auto_detect_interface?: boolean; // Let sing-box pick the default interface
+  default_interface?: string;      // Interface bound when auto-detect is off, e.g. eth0
   ruleset_base_url: string;
   auto_apply: boolean;           // Auto-apply after config changes
+  quiet_hours?: { start: string; end: string }; // HH:MM window where auto-apply defers the reload, blank to disable
   subscription_interval: number; // Subscription auto-update interval (minutes)
   verification_interval: number; // Verification interval (minutes), 0 to disable
   archive_threshold: number;     // Consecutive failures before archiving
+  min_uptime_percent?: number;   // Archive verified nodes below this uptime, 0 to disable
+  uptime_window_hours?: number;  // Window used for uptime-based archiving (hours)
   github_proxy: string;          // GitHub proxy address
   debug_api_enabled: boolean;    // Enable debug API for remote diagnostics
   proxy_mode: ProxyMode;         // Proxy mode: rule, global, direct
   blocked_countries: string[];   // Country codes excluded from Auto/Proxy
+  sniffers?: string[];           // Protocols passed to the route sniff action
+  sniff_timeout?: string;        // Sniff action timeout, e.g. 500ms
+  node_tag_template?: string;    // Display name template for imported nodes, e.g. {emoji} {country}-{index}
+  traffic_sample_interval_seconds?: number; // Traffic aggregation tick (seconds, 1-60)
+  health_retention_days?: number; // Raw health measurements kept before daily rollup (days), 0 to keep all
+  sort_proxy_nodes?: boolean;    // Order Proxy/Auto/country group members by country then tag
+  webhook_url?: string;          // POST a health-check summary here, empty to disable
+  webhook_secret?: string;       // HMAC-SHA256 key for the X-Signature-256 header
+  urltest_url?: string;          // Latency test URL, empty for generate_204
+  urltest_expected_status?: string; // Accepted status for delay checks, e.g. 200/204
+  udp_check_target?: string;     // host:port answering UDP for QUIC/WireGuard health checks, empty for 1.1.1.1:53
+  default_utls_fingerprint?: string; // uTLS fingerprint for TLS nodes without one, empty to disable
+  multiplex_enabled?: boolean;      // Multiplex shadowsocks/trojan/vmess/vless nodes that do not configure it
+  tcp_fast_open?: boolean;          // TCP Fast Open on TCP-based nodes that do not set it
+  udp_fragment?: boolean;           // UDP fragmentation on UDP-dialing nodes that do not set it
+  udp_over_tcp?: boolean;           // UDP over TCP on Shadowsocks nodes that do not set it and use no multiplex
+  block_quic?: boolean;             // Reject QUIC so browsers fall back to TCP/TLS
+  no_nodes_mode?: 'direct' | 'reject'; // What the config does when no node is usable
+  score_weight_latency?: number;    // Node score weight of the latency component
+  score_weight_uptime?: number;     // Node score weight of the uptime component
+  score_weight_sites?: number;      // Node score weight of the site reachability component
+  direct_processes?: string[];      // Process names or absolute paths always routed to DIRECT (desktop only)
 }
 
 export type ProxyMode = 'rule' | 'global' | 'direct';
@@ -292,6 +347,9 @@ export interface ServiceStatus {
   pid: number;
   version: string;
   sbm_version: string;
+  safe_mode: boolean;
+  last_error: string;
+  apply_pending?: boolean;       // Config written during quiet hours, reload deferred
 }
 
 export interface ProxyGroup {
@@ -434,6 +492,7 @@ interface AppState {
   archiveNode: (id: number) => Promise<void>;
   unarchiveNode: (id: number) => Promise<void>;
   toggleFavorite: (id: number) => Promise<void>;
+  togglePin: (id: number) => Promise<void>;
   bulkPromoteNodes: (ids: number[]) => Promise<void>;
   bulkArchiveNodes: (ids: number[]) => Promise<void>;
   bulkUnarchiveNodes: () => Promise<void>;
@@ -916,6 +975,19 @@ export const useStore = create<AppState>((set, get) => ({
     }
   },
 
+  togglePin: async (id: number) => {
+    const { pendingNodes, verifiedNodes } = get();
+    const node = [...pendingNodes, ...verifiedNodes].find((n) => n.id === id);
+    try {
+      await unifiedNodeApi.togglePin(id, !node?.pinned);
+      const toggle = (nodes: UnifiedNode[]) =>
+        nodes.map((n) => (n.id === id ? { ...n, pinned: !n.pinned } : n));
+      set({ pendingNodes: toggle(get().pendingNodes), verifiedNodes: toggle(get().verifiedNodes) });
+    } catch (error: any) {
+      toast.error(error.response?.data?.error || 'Failed to toggle pin');
+    }
+  },
+
   bulkPromoteNodes: async (ids: number[]) => {
     try {
       await unifiedNodeApi.bulkPromote(ids);